@@ -0,0 +1,399 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ManifestName is the archive entry holding the ArchiveManifest
+const ManifestName = "diffusion-cache-manifest.json"
+
+// ArchiveManifest describes where and when a cache archive was exported
+type ArchiveManifest struct {
+	DiffusionVersion string    `json:"diffusion_version"`
+	OS               string    `json:"os"`
+	Arch             string    `json:"arch"`
+	CacheID          string    `json:"cache_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// NewArchiveManifest returns a manifest for the running binary and platform
+func NewArchiveManifest(version, cacheID string) ArchiveManifest {
+	return ArchiveManifest{
+		DiffusionVersion: version,
+		OS:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		CacheID:          cacheID,
+		CreatedAt:        time.Now().UTC(),
+	}
+}
+
+// Mismatches lists the differences between an imported manifest and the local
+// version/platform that may make the cache unusable (e.g. arch-specific wheels)
+func (m ArchiveManifest) Mismatches(version string) []string {
+	var diffs []string
+	if m.DiffusionVersion != version {
+		diffs = append(diffs, fmt.Sprintf("diffusion version %s (local %s)", m.DiffusionVersion, version))
+	}
+	if m.OS != runtime.GOOS || m.Arch != runtime.GOARCH {
+		diffs = append(diffs, fmt.Sprintf("platform %s/%s (local %s/%s)", m.OS, m.Arch, runtime.GOOS, runtime.GOARCH))
+	}
+	return diffs
+}
+
+// ExportCache writes cacheDir (roles, collections, uv, docker) and the manifest to
+// a tar archive at output. The compression follows the extension: .tar.gz/.tgz
+// (gzip), .tar.zst (zstd binary) or .tar (none).
+func ExportCache(cacheDir, output string, manifest ArchiveManifest) (err error) {
+	if _, err := os.Stat(cacheDir); err != nil {
+		return fmt.Errorf("cache directory not found: %w", err)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(output)
+		}
+	}()
+
+	cw, err := compressWriter(output, f)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+
+	if err := writeManifest(tw, manifest); err != nil {
+		return err
+	}
+	if err := addDirToTar(tw, cacheDir); err != nil {
+		return fmt.Errorf("failed to archive cache: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// ImportCache extracts an archive created by ExportCache into cacheDir and
+// returns its manifest. Entries escaping cacheDir are rejected.
+func ImportCache(input, cacheDir string) (*ArchiveManifest, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	cr, err := decompressReader(input, f)
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	var manifest *ArchiveManifest
+	tr := tar.NewReader(cr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if hdr.Name == ManifestName {
+			var m ArchiveManifest
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return nil, fmt.Errorf("invalid cache manifest: %w", err)
+			}
+			manifest = &m
+			continue
+		}
+		if err := extractEntry(tr, hdr, cacheDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a diffusion cache archive (missing %s)", input, ManifestName)
+	}
+	return manifest, nil
+}
+
+// ReadArchiveManifest returns the manifest of an archive created by ExportCache
+// without extracting it (the manifest is always the first entry)
+func ReadArchiveManifest(input string) (*ArchiveManifest, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	cr, err := decompressReader(input, f)
+	if err != nil {
+		return nil, err
+	}
+	defer cr.Close()
+
+	hdr, err := tar.NewReader(cr).Next()
+	if err != nil || hdr.Name != ManifestName {
+		return nil, fmt.Errorf("%s is not a diffusion cache archive (missing %s)", input, ManifestName)
+	}
+	var m ArchiveManifest
+	if err := json.NewDecoder(io.LimitReader(cr, hdr.Size)).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid cache manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// writeManifest stores the manifest as the first archive entry
+func writeManifest(tw *tar.Writer, manifest ArchiveManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: ManifestName, Mode: 0o644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// addDirToTar adds the contents of root with paths relative to it
+func addDirToTar(tw *tar.Writer, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+}
+
+// extractEntry writes a single archive entry below dir
+func extractEntry(tr *tar.Reader, hdr *tar.Header, dir string) error {
+	target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+	if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return fmt.Errorf("archive entry %q escapes the cache directory", hdr.Name)
+	}
+
+	// An earlier entry may have planted a symlink; never write through one
+	if err := checkNoSymlinkParents(dir, target); err != nil {
+		return fmt.Errorf("archive entry %q: %w", hdr.Name, err)
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0o755)
+	case tar.TypeSymlink:
+		if filepath.IsAbs(hdr.Linkname) {
+			return fmt.Errorf("archive symlink %q points to the absolute path %q", hdr.Name, hdr.Linkname)
+		}
+		resolved := filepath.Join(filepath.Dir(target), filepath.FromSlash(hdr.Linkname))
+		if !strings.HasPrefix(resolved, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive symlink %q points outside the cache directory", hdr.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		_ = os.Remove(target)
+		return os.Symlink(hdr.Linkname, target)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if fi, err := os.Lstat(target); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry %q would be written through a symlink", hdr.Name)
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	default:
+		// Devices, fifos etc. never appear in a cache; skip them
+		return nil
+	}
+}
+
+// checkNoSymlinkParents fails when a directory between dir and target is a symlink
+func checkNoSymlinkParents(dir, target string) error {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+	current := filepath.Clean(dir)
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, part)
+		fi, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+	}
+	return nil
+}
+
+// compressWriter wraps w with the compression implied by the archive name
+func compressWriter(name string, w io.Writer) (io.WriteCloser, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return gzip.NewWriter(w), nil
+	case strings.HasSuffix(name, ".tar.zst"):
+		return zstdPipe(w, "-q", "-c")
+	case strings.HasSuffix(name, ".tar"):
+		return nopWriteCloser{w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive extension for %s (use .tar.gz, .tgz, .tar.zst or .tar)", name)
+	}
+}
+
+// decompressReader wraps r with the decompression implied by the archive name
+func decompressReader(name string, r io.Reader) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		return gz, nil
+	case strings.HasSuffix(name, ".tar.zst"):
+		return zstdReader(r)
+	case strings.HasSuffix(name, ".tar"):
+		return io.NopCloser(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported archive extension for %s (use .tar.gz, .tgz, .tar.zst or .tar)", name)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// zstdCmd is the zstd binary used for .tar.zst archives
+var zstdCmd = "zstd"
+
+// cmdWriteCloser feeds a compressor process and waits for it on Close
+type cmdWriteCloser struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdWriteCloser) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if err := c.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %w", zstdCmd, err)
+	}
+	return nil
+}
+
+// zstdPipe starts zstd compressing its stdin into w
+func zstdPipe(w io.Writer, args ...string) (io.WriteCloser, error) {
+	if _, err := exec.LookPath(zstdCmd); err != nil {
+		return nil, fmt.Errorf("zstd not found in PATH; install it or use a .tar.gz archive")
+	}
+	cmd := exec.Command(zstdCmd, args...)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", zstdCmd, err)
+	}
+	return &cmdWriteCloser{WriteCloser: stdin, cmd: cmd}, nil
+}
+
+// cmdReadCloser reads a decompressor's output and waits for it on Close
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	_ = c.ReadCloser.Close()
+	return c.cmd.Wait()
+}
+
+// zstdReader starts zstd decompressing r
+func zstdReader(r io.Reader) (io.ReadCloser, error) {
+	if _, err := exec.LookPath(zstdCmd); err != nil {
+		return nil, fmt.Errorf("zstd not found in PATH; install it or use a .tar.gz archive")
+	}
+	cmd := exec.Command(zstdCmd, "-q", "-d", "-c")
+	cmd.Stdin = r
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", zstdCmd, err)
+	}
+	return &cmdReadCloser{ReadCloser: stdout, cmd: cmd}, nil
+}
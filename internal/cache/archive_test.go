@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"archive/tar"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func writeCacheFixture(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		filepath.Join(config.CacheRolesDir, "acme.web", "tasks", "main.yml"):  "---\n",
+		filepath.Join(config.CacheCollectionsDir, "ansible_collections", "x"): "collection",
+		filepath.Join(config.CacheUVDir, "wheel.whl"):                         "wheel",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, config.CacheUVDir, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.CacheUVDir, "bin", "tool"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("wheel.whl", filepath.Join(dir, config.CacheUVDir, "latest.whl")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportCacheRoundTrip(t *testing.T) {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".tar.zst"} {
+		t.Run(ext, func(t *testing.T) {
+			if ext == ".tar.zst" {
+				if _, err := exec.LookPath(zstdCmd); err != nil {
+					t.Skip("zstd not installed")
+				}
+			}
+			src := t.TempDir()
+			writeCacheFixture(t, src)
+
+			archive := filepath.Join(t.TempDir(), "cache"+ext)
+			manifest := NewArchiveManifest("1.2.3", "abc123")
+			if err := ExportCache(src, archive, manifest); err != nil {
+				t.Fatalf("ExportCache failed: %v", err)
+			}
+
+			dst := t.TempDir()
+			got, err := ImportCache(archive, dst)
+			if err != nil {
+				t.Fatalf("ImportCache failed: %v", err)
+			}
+			if got.DiffusionVersion != "1.2.3" || got.CacheID != "abc123" || got.Arch != runtime.GOARCH {
+				t.Errorf("unexpected manifest: %+v", got)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dst, config.CacheRolesDir, "acme.web", "tasks", "main.yml"))
+			if err != nil || string(data) != "---\n" {
+				t.Errorf("role file not restored: %q, %v", data, err)
+			}
+			info, err := os.Stat(filepath.Join(dst, config.CacheUVDir, "bin", "tool"))
+			if err != nil || info.Mode().Perm() != 0o755 {
+				t.Errorf("executable mode not preserved: %v, %v", info, err)
+			}
+			if link, err := os.Readlink(filepath.Join(dst, config.CacheUVDir, "latest.whl")); err != nil || link != "wheel.whl" {
+				t.Errorf("symlink not restored: %q, %v", link, err)
+			}
+			if _, err := os.Stat(filepath.Join(dst, ManifestName)); !os.IsNotExist(err) {
+				t.Errorf("manifest should not be extracted into the cache directory")
+			}
+		})
+	}
+}
+
+func TestExportCacheUnsupportedExtension(t *testing.T) {
+	src := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "cache.zip")
+	err := ExportCache(src, archive, NewArchiveManifest("dev", "id"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported archive extension") {
+		t.Fatalf("expected unsupported extension error, got %v", err)
+	}
+	if _, statErr := os.Stat(archive); !os.IsNotExist(statErr) {
+		t.Error("failed export should not leave an archive behind")
+	}
+}
+
+func TestImportCacheRejectsInvalidArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr string
+	}{
+		{name: "missing manifest", entries: []string{"roles/file"}, wantErr: "missing " + ManifestName},
+		{name: "path traversal", entries: []string{"../escape"}, wantErr: "escapes the cache directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "bad.tar")
+			f, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tw := tar.NewWriter(f)
+			for _, name := range tt.entries {
+				if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: 1, Typeflag: tar.TypeReg}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tw.Write([]byte("x")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tw.Close()
+			f.Close()
+
+			_, err = ImportCache(archive, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestArchiveManifestMismatches(t *testing.T) {
+	m := NewArchiveManifest("1.0.0", "id")
+	if diffs := m.Mismatches("1.0.0"); len(diffs) != 0 {
+		t.Errorf("expected no mismatches, got %v", diffs)
+	}
+
+	m.Arch = "other"
+	diffs := m.Mismatches("2.0.0")
+	if len(diffs) != 2 {
+		t.Fatalf("expected version and platform mismatches, got %v", diffs)
+	}
+	if !strings.Contains(diffs[0], "1.0.0") || !strings.Contains(diffs[1], "other") {
+		t.Errorf("unexpected mismatch messages: %v", diffs)
+	}
+}
+
+func TestReadArchiveManifest(t *testing.T) {
+	src := t.TempDir()
+	writeCacheFixture(t, src)
+	archive := filepath.Join(t.TempDir(), "cache.tar.gz")
+	if err := ExportCache(src, archive, NewArchiveManifest("1.2.3", "abc123")); err != nil {
+		t.Fatalf("ExportCache failed: %v", err)
+	}
+
+	m, err := ReadArchiveManifest(archive)
+	if err != nil {
+		t.Fatalf("ReadArchiveManifest failed: %v", err)
+	}
+	if m.CacheID != "abc123" || m.DiffusionVersion != "1.2.3" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+}
+
+func TestImportCacheRejectsSymlinkEscapes(t *testing.T) {
+	link := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Linkname: target, Typeflag: tar.TypeSymlink}
+	}
+	file := &tar.Header{Name: "uv/link/file", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg}
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+		wantErr string
+	}{
+		{name: "absolute target", headers: []*tar.Header{link("uv/link", "/etc")}, wantErr: "absolute path"},
+		{name: "target outside", headers: []*tar.Header{link("uv/link", "../../outside")}, wantErr: "points outside the cache directory"},
+		{name: "write through link", headers: []*tar.Header{link("uv/link", "../roles"), file}, wantErr: "is a symlink"},
+		{name: "overwrite link", headers: []*tar.Header{link("uv/link", "wheel.whl"), {Name: "uv/link", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg}}, wantErr: "written through a symlink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "bad.tar")
+			f, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tw := tar.NewWriter(f)
+			for _, hdr := range tt.headers {
+				if err := tw.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+				if hdr.Size > 0 {
+					if _, err := tw.Write([]byte("x")); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			tw.Close()
+			f.Close()
+
+			_, err = ImportCache(archive, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
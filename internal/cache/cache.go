@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"diffusion/internal/config"
 )
@@ -100,7 +101,8 @@ func ListCaches() ([]string, error) {
 
 	var caches []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		// tests/ holds cloned verify test repositories, not a role cache
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "role_") {
 			caches = append(caches, entry.Name())
 		}
 	}
@@ -164,13 +166,18 @@ func GetDockerImageTarballPath(cacheID, customPath string) (string, error) {
 	return filepath.Join(cacheDir, config.CacheDockerDir, config.DockerImageTarball), nil
 }
 
-// HasCachedDockerImage checks whether a cached Docker image tarball exists.
+// HasCachedDockerImage checks whether a cached Docker image tarball (or a
+// per-image manifest) exists.
 func HasCachedDockerImage(cacheID, customPath string) bool {
 	tarballPath, err := GetDockerImageTarballPath(cacheID, customPath)
 	if err != nil {
 		return false
 	}
-	_, err = os.Stat(tarballPath)
+	if _, err := os.Stat(tarballPath); err == nil {
+		return true
+	}
+	manifestPath := filepath.Join(filepath.Dir(tarballPath), config.DockerImagesDir, config.DockerImagesManifest)
+	_, err = os.Stat(manifestPath)
 	return err == nil
 }
 
@@ -325,6 +325,29 @@ func TestHasCachedDockerImage(t *testing.T) {
 	}
 }
 
+func TestHasCachedDockerImagePerImageManifest(t *testing.T) {
+	cacheID := "testhascachedperimage"
+	customPath := ""
+
+	dockerDir, err := EnsureDockerCacheDir(cacheID, customPath)
+	if err != nil {
+		t.Fatalf("EnsureDockerCacheDir failed: %v", err)
+	}
+	defer CleanupCache(cacheID, customPath)
+
+	imagesDir := filepath.Join(dockerDir, config.DockerImagesDir)
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(imagesDir, config.DockerImagesManifest), []byte(`{"images":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !HasCachedDockerImage(cacheID, customPath) {
+		t.Error("HasCachedDockerImage should return true when a per-image manifest exists")
+	}
+}
+
 func TestGetSubdirSize(t *testing.T) {
 	cacheID := "testsubdir"
 	customPath := ""
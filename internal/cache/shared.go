@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// sharedIDPrefix marks the cache ID of a shared cache (directory role_shared-<name>)
+const sharedIDPrefix = "shared-"
+
+// sharedIndexFile tracks which role configs (diffusion.toml paths) use each shared cache
+const sharedIndexFile = "shared_index.json"
+
+var sharedNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateSharedName checks the name of a shared cache
+func ValidateSharedName(name string) error {
+	if !sharedNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid shared cache name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// SharedCacheID returns the cache ID of the shared cache called name
+func SharedCacheID(name string) string {
+	return sharedIDPrefix + name
+}
+
+// SharedName returns the shared cache name of a cache ID, or "" for a per-role cache
+func SharedName(cacheID string) string {
+	name, ok := strings.CutPrefix(cacheID, sharedIDPrefix)
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// sharedIndexPath returns ~/.diffusion/cache/shared_index.json
+func sharedIndexPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".diffusion", "cache", sharedIndexFile), nil
+}
+
+// LoadSharedIndex returns the diffusion.toml paths referencing each shared cache
+func LoadSharedIndex() (map[string][]string, error) {
+	path, err := sharedIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	index := map[string][]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse shared cache index %s: %w", path, err)
+	}
+	return index, nil
+}
+
+func saveSharedIndex(index map[string][]string) error {
+	path, err := sharedIndexPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared cache index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write shared cache index: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddSharedReference records that the role config at configPath uses the shared cache name
+func AddSharedReference(name, configPath string) error {
+	index, err := LoadSharedIndex()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(index[name], configPath) {
+		index[name] = append(index[name], configPath)
+		slices.Sort(index[name])
+	}
+	return saveSharedIndex(index)
+}
+
+// RemoveSharedReference drops the role config at configPath from the shared cache name
+func RemoveSharedReference(name, configPath string) error {
+	index, err := LoadSharedIndex()
+	if err != nil {
+		return err
+	}
+	refs := slices.DeleteFunc(index[name], func(ref string) bool { return ref == configPath })
+	if len(refs) == 0 {
+		delete(index, name)
+	} else {
+		index[name] = refs
+	}
+	return saveSharedIndex(index)
+}
+
+// SharedReferences returns the role configs still using the shared cache name.
+// Configs that no longer exist are left out.
+func SharedReferences(name string) ([]string, error) {
+	index, err := LoadSharedIndex()
+	if err != nil {
+		return nil, err
+	}
+	var live []string
+	for _, ref := range index[name] {
+		if _, err := os.Stat(ref); err == nil {
+			live = append(live, ref)
+		}
+	}
+	return live, nil
+}
+
+// OtherSharedUsers returns the role configs other than self (an absolute
+// diffusion.toml path) that still use the shared cache name; a shared cache
+// is only deleted by its last user
+func OtherSharedUsers(name, self string) ([]string, error) {
+	users, err := SharedReferences(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(users, func(user string) bool { return user == self }), nil
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSharedCacheID(t *testing.T) {
+	id := SharedCacheID("mono")
+	if got := SharedName(id); got != "mono" {
+		t.Errorf("SharedName(%q) = %q, want mono", id, got)
+	}
+	if got := SharedName("a1b2c3d4e5f60718"); got != "" {
+		t.Errorf("SharedName(per-role id) = %q, want empty", got)
+	}
+	for name, valid := range map[string]bool{"mono": true, "team_a-1": true, "": false, "Mono": false, "../x": false, "-x": false} {
+		if err := ValidateSharedName(name); (err == nil) != valid {
+			t.Errorf("ValidateSharedName(%q) error = %v, want valid %v", name, err, valid)
+		}
+	}
+}
+
+func TestSharedReferences(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	roles := t.TempDir()
+	web := filepath.Join(roles, "web", "diffusion.toml")
+	db := filepath.Join(roles, "db", "diffusion.toml")
+	gone := filepath.Join(roles, "gone", "diffusion.toml")
+	for _, path := range []string{web, db} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, path := range []string{web, db, gone, web} {
+		if err := AddSharedReference("mono", path); err != nil {
+			t.Fatalf("AddSharedReference() error = %v", err)
+		}
+	}
+	refs, err := SharedReferences("mono")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{db, web}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("SharedReferences() = %v, want %v (deduplicated, missing configs dropped)", refs, want)
+	}
+
+	if err := RemoveSharedReference("mono", web); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := SharedReferences("mono"); !reflect.DeepEqual(refs, []string{db}) {
+		t.Errorf("after removing web: %v, want [%s]", refs, db)
+	}
+
+	if err := RemoveSharedReference("mono", db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSharedReference("mono", gone); err != nil {
+		t.Fatal(err)
+	}
+	index, err := LoadSharedIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index["mono"]; ok {
+		t.Errorf("index still lists mono after its last reference was removed: %v", index)
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// testsReposDir holds the clones of verify test repositories, shared by all roles
+const testsReposDir = "tests"
+
+// TestsRepoState records the last fetch of a cached tests repository
+type TestsRepoState struct {
+	URL       string    `json:"url"`
+	Commit    string    `json:"commit"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// TestsRepoDir returns the clone directory of a tests repository:
+// ~/.diffusion/cache/tests/<repo>-<hash>. The hash keeps repositories with the
+// same name apart.
+func TestsRepoDir(repoURL string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(repoURL))
+	name := strings.TrimSuffix(path.Base(strings.TrimRight(repoURL, "/")), ".git")
+	return filepath.Join(homeDir, ".diffusion", "cache", testsReposDir, name+"-"+hex.EncodeToString(sum[:4])), nil
+}
+
+// LoadTestsRepoState reads the state recorded next to the clone in dir; nil
+// means the repository was never fetched
+func LoadTestsRepoState(dir string) (*TestsRepoState, error) {
+	data, err := os.ReadFile(dir + ".json")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tests repository state: %w", err)
+	}
+	var state TestsRepoState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse tests repository state %s.json: %w", dir, err)
+	}
+	return &state, nil
+}
+
+// SaveTestsRepoState records the fetched commit of the clone in dir
+func SaveTestsRepoState(dir string, state TestsRepoState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tests repository state: %w", err)
+	}
+	if err := os.WriteFile(dir+".json", append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write tests repository state: %w", err)
+	}
+	return nil
+}
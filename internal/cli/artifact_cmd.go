@@ -2,7 +2,10 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"strings"
 
@@ -23,6 +26,9 @@ func NewArtifactCmd(cli *CLI) *cobra.Command {
 	artifactCmd.AddCommand(newArtifactListCmd())
 	artifactCmd.AddCommand(newArtifactRemoveCmd())
 	artifactCmd.AddCommand(newArtifactShowCmd())
+	artifactCmd.AddCommand(newArtifactRotateCmd())
+	artifactCmd.AddCommand(newArtifactSetURLCmd())
+	artifactCmd.AddCommand(newArtifactSetVaultCmd())
 
 	return artifactCmd
 }
@@ -53,7 +59,9 @@ func newArtifactAddCmd() *cobra.Command {
 			}
 
 			if useVault {
-				fmt.Printf("Enter Vault path for %s (e.g., secret/data/artifacts): ", sourceName)
+				source.VaultKVVersion = promptVaultKVVersion(reader)
+
+				fmt.Printf("Enter Vault path for %s (e.g., %s): ", sourceName, vaultPathExample(source.VaultKVVersion))
 				vaultPath, _ := reader.ReadString('\n')
 				source.VaultPath = strings.TrimSpace(vaultPath)
 
@@ -95,82 +103,43 @@ func newArtifactAddCmd() *cobra.Command {
 					Token:    token,
 				}
 
-				if err := secrets.SaveArtifactCredentials(creds); err != nil {
+				store, err := openSecretStore()
+				if err != nil {
+					return err
+				}
+				if err := store.Save(creds); err != nil {
 					return fmt.Errorf("failed to save credentials: %w", err)
 				}
 
-				roleName := secrets.GetCurrentRoleName()
-				if roleName == "" {
-					roleName = "default"
-				}
-				fmt.Printf("\033[32mCredentials for '%s' saved successfully (encrypted in ~/.diffusion/secrets/%s/%s)\033[0m\n", sourceName, roleName, sourceName)
+				fmt.Printf("\033[32mCredentials for '%s' saved successfully (%s)\033[0m\n", sourceName, storeLocation(store, sourceName))
 			}
 
-			// Load existing config or create new one
-			cfg, err := config.LoadConfig()
-			if err != nil {
+			// Add or replace the source under the config lock
+			updated := false
+			err := config.UpdateConfig(func(cfg *config.Config) error {
+				updated = upsertArtifactSource(cfg, source)
+				return nil
+			})
+			if errors.Is(err, fs.ErrNotExist) {
 				// Config doesn't exist, create minimal config
-				cfg = &config.Config{
-					ArtifactSources: []config.ArtifactSource{},
-				}
+				err = config.SaveConfig(&config.Config{
+					ArtifactSources: []config.ArtifactSource{source},
+				})
 			}
-
-			// Check if source already exists
-			for i, existing := range cfg.ArtifactSources {
-				if existing.Name == sourceName {
-					// Update existing source
-					cfg.ArtifactSources[i] = source
-					if err := config.SaveConfig(cfg); err != nil {
-						return fmt.Errorf("failed to update config: %w", err)
-					}
-					fmt.Printf("\033[32mUpdated artifact source '%s' in diffusion.toml\033[0m\n", sourceName)
-					return nil
-				}
-			}
-
-			// Add new source
-			cfg.ArtifactSources = append(cfg.ArtifactSources, source)
-			if err := config.SaveConfig(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
-			}
-
-			fmt.Printf("\033[32mAdded artifact source '%s' to diffusion.toml\033[0m\n", sourceName)
-			return nil
-		},
-	}
-
-	return artifactAddCmd
-}
-
-func newArtifactListCmd() *cobra.Command {
-	artifactListCmd := &cobra.Command{
-		Use:   "list",
-		Short: "List all stored artifact sources",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			sources, err := secrets.ListStoredCredentials()
 			if err != nil {
-				return fmt.Errorf("failed to list credentials: %w", err)
+				return fmt.Errorf("failed to save config: %w", err)
 			}
 
-			if len(sources) == 0 {
-				fmt.Println("No stored artifact credentials found.")
+			if updated {
+				fmt.Printf("\033[32mUpdated artifact source '%s' in diffusion.toml\033[0m\n", sourceName)
 				return nil
 			}
-
-			fmt.Println("\033[35mStored Artifact Sources:\033[0m")
-			for _, source := range sources {
-				creds, err := secrets.LoadArtifactCredentials(source)
-				if err != nil {
-					fmt.Printf("  \033[31m✗\033[0m %s (error loading: %v)\n", source, err)
-					continue
-				}
-				fmt.Printf("  \033[32m✓\033[0m %s - %s\n", creds.Name, creds.URL)
-			}
+			fmt.Printf("\033[32mAdded artifact source '%s' to diffusion.toml\033[0m\n", sourceName)
 			return nil
 		},
 	}
 
-	return artifactListCmd
+	return artifactAddCmd
 }
 
 func newArtifactRemoveCmd() *cobra.Command {
@@ -181,8 +150,13 @@ func newArtifactRemoveCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sourceName := args[0]
 
+			store, err := openSecretStore()
+			if err != nil {
+				return err
+			}
+
 			// Delete encrypted credentials (if they exist)
-			if err := secrets.DeleteArtifactCredentials(sourceName); err != nil {
+			if err := store.Delete(sourceName); err != nil {
 				// Don't fail if credentials don't exist - might be Vault-only
 				fmt.Printf("\033[33mNote: No local credentials found for '%s' (may be using Vault)\033[0m\n", sourceName)
 			} else {
@@ -190,27 +164,17 @@ func newArtifactRemoveCmd() *cobra.Command {
 			}
 
 			// Remove from config file
-			cfg, err := config.LoadConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
-			}
-
-			// Find and remove the source
-			found := false
-			for i, source := range cfg.ArtifactSources {
-				if source.Name == sourceName {
-					cfg.ArtifactSources = append(cfg.ArtifactSources[:i], cfg.ArtifactSources[i+1:]...)
-					found = true
-					break
+			err = config.UpdateConfig(func(cfg *config.Config) error {
+				for i, source := range cfg.ArtifactSources {
+					if source.Name == sourceName {
+						cfg.ArtifactSources = append(cfg.ArtifactSources[:i], cfg.ArtifactSources[i+1:]...)
+						return nil
+					}
 				}
-			}
-
-			if !found {
 				return fmt.Errorf("artifact source '%s' not found in diffusion.toml", sourceName)
-			}
-
-			if err := config.SaveConfig(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			})
+			if err != nil {
+				return err
 			}
 
 			fmt.Printf("\033[32mRemoved artifact source '%s' from diffusion.toml\033[0m\n", sourceName)
@@ -222,25 +186,108 @@ func newArtifactRemoveCmd() *cobra.Command {
 }
 
 func newArtifactShowCmd() *cobra.Command {
+	var reveal, yes bool
+
 	artifactShowCmd := &cobra.Command{
 		Use:   "show [source-name]",
-		Short: "Show details for an artifact source (without token)",
+		Short: "Show details for an artifact source (token masked unless --reveal)",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sourceName := args[0]
 
-			creds, err := secrets.LoadArtifactCredentials(sourceName)
+			creds, err := resolveArtifactCredentials(sourceName)
 			if err != nil {
 				return fmt.Errorf("failed to load credentials: %w", err)
 			}
 
-			fmt.Printf("\033[35mArtifact Source: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.Name)
-			fmt.Printf("\033[35mURL: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.URL)
-			fmt.Printf("\033[35mUsername: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.Username)
-			fmt.Printf("\033[35mToken: \033[0m\033[38;2;127;255;212m%s\033[0m\n", maskToken(creds.Token))
+			if reveal {
+				if !yes && !confirmReveal(bufio.NewReader(os.Stdin), os.Stdout, sourceName) {
+					return fmt.Errorf("reveal of '%s' token cancelled", sourceName)
+				}
+				if err := secrets.AppendAuditLog("reveal-token", sourceName); err != nil {
+					return fmt.Errorf("failed to write audit log, token not revealed: %w", err)
+				}
+			}
+
+			writeArtifactDetails(os.Stdout, creds, reveal)
 			return nil
 		},
 	}
 
+	artifactShowCmd.Flags().BoolVar(&reveal, "reveal", false, "print the full decrypted token (asks for confirmation)")
+	artifactShowCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the --reveal confirmation prompt")
+
 	return artifactShowCmd
 }
+
+// openSecretStore opens the [secrets] backend for local credentials; tests
+// replace it with an in-memory store
+var openSecretStore = secrets.ConfiguredStore
+
+// storeLocation describes where a store keeps the credentials of a source
+func storeLocation(store secrets.SecretStore, sourceName string) string {
+	switch store.(type) {
+	case secrets.FileStore:
+		roleName := secrets.GetCurrentRoleName()
+		if roleName == "" {
+			roleName = "default"
+		}
+		return fmt.Sprintf("encrypted in ~/.diffusion/secrets/%s/%s", roleName, sourceName)
+	case *secrets.AgeStore:
+		return "encrypted with age in ~/.diffusion/age-secrets"
+	default:
+		return "stored locally"
+	}
+}
+
+// resolveArtifactCredentials returns the credentials of a source, reading them
+// from Vault when the source is configured with use_vault in diffusion.toml and
+// from the local secret store otherwise
+func resolveArtifactCredentials(sourceName string) (*config.ArtifactCredentials, error) {
+	if cfg, err := config.LoadConfig(); err == nil {
+		for i := range cfg.ArtifactSources {
+			source := &cfg.ArtifactSources[i]
+			if source.Name == sourceName && source.UseVault {
+				return secrets.GetArtifactCredentialsFromVault(source, cfg.HashicorpVault)
+			}
+		}
+	}
+	store, err := openSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load(sourceName)
+}
+
+// confirmReveal asks whether the full token of sourceName may be printed
+func confirmReveal(reader *bufio.Reader, w io.Writer, sourceName string) bool {
+	fmt.Fprintf(w, "\033[33mThis prints the full token for '%s' to the terminal. Continue? (y/N): \033[0m", sourceName)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// writeArtifactDetails prints the source details; the token is masked unless reveal is set
+func writeArtifactDetails(w io.Writer, creds *config.ArtifactCredentials, reveal bool) {
+	token := maskToken(creds.Token)
+	if reveal {
+		token = creds.Token
+	}
+	fmt.Fprintf(w, "\033[35mArtifact Source: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.Name)
+	fmt.Fprintf(w, "\033[35mURL: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.URL)
+	fmt.Fprintf(w, "\033[35mUsername: \033[0m\033[38;2;127;255;212m%s\033[0m\n", creds.Username)
+	fmt.Fprintf(w, "\033[35mToken: \033[0m\033[38;2;127;255;212m%s\033[0m\n", token)
+}
+
+// upsertArtifactSource replaces the source with the same name or appends it;
+// true means an existing source was replaced
+func upsertArtifactSource(cfg *config.Config, source config.ArtifactSource) bool {
+	for i, existing := range cfg.ArtifactSources {
+		if existing.Name == source.Name {
+			cfg.ArtifactSources[i] = source
+			return true
+		}
+	}
+	cfg.ArtifactSources = append(cfg.ArtifactSources, source)
+	return false
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"diffusion/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// Storage values of an artifact source in 'artifact list'
+const (
+	artifactStorageVault = "vault"
+	artifactStorageLocal = "local"
+)
+
+// artifactListEntry is one artifact source printed by 'artifact list'; the
+// token is never included
+type artifactListEntry struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Storage     string `json:"storage"`                // "vault" or "local"
+	VaultPath   string `json:"vault_path,omitempty"`   // Vault path of the secret, vault storage only
+	VaultSecret string `json:"vault_secret,omitempty"` // Vault secret name, vault storage only
+	Username    string `json:"username,omitempty"`     // From the local credentials file
+	Error       string `json:"error,omitempty"`        // Set when the local credentials cannot be read
+}
+
+func newArtifactListCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List artifact sources from diffusion.toml and stored credentials",
+		Long: `List the artifact sources of diffusion.toml together with locally stored
+credentials, so Vault-backed sources without a local credentials file are shown
+too. Tokens are never printed.`,
+		Example: `  diffusion artifact list
+  diffusion artifact list --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			store, err := openSecretStore()
+			if err != nil {
+				return err
+			}
+			stored, err := store.List()
+			if err != nil {
+				return fmt.Errorf("failed to list credentials: %w", err)
+			}
+
+			entries := collectArtifactSources(cfg, stored, store.Load)
+			if asJSON {
+				out, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(out))
+				return nil
+			}
+			writeArtifactList(cmd.OutOrStdout(), entries)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the sources as a JSON array (name, url, storage, vault_path, username)")
+
+	return cmd
+}
+
+// collectArtifactSources merges the config sources with the stored credential
+// files: config sources come first in config order, then credentials that no
+// config source refers to. loadCreds reads the local credentials of a source.
+func collectArtifactSources(cfg *config.Config, stored []string, loadCreds func(string) (*config.ArtifactCredentials, error)) []artifactListEntry {
+	entries := []artifactListEntry{}
+	hasCreds := make(map[string]bool, len(stored))
+	for _, name := range stored {
+		hasCreds[name] = true
+	}
+	listed := map[string]bool{}
+
+	if cfg != nil {
+		for _, source := range cfg.ArtifactSources {
+			listed[source.Name] = true
+			entry := artifactListEntry{Name: source.Name, URL: source.URL, Storage: artifactStorageLocal}
+			if source.UseVault {
+				entry.Storage = artifactStorageVault
+				entry.VaultPath = source.VaultPath
+				entry.VaultSecret = source.VaultSecretName
+			} else if hasCreds[source.Name] {
+				fillLocalCredentials(&entry, loadCreds)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, name := range stored {
+		if listed[name] {
+			continue
+		}
+		entry := artifactListEntry{Name: name, Storage: artifactStorageLocal}
+		fillLocalCredentials(&entry, loadCreds)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// fillLocalCredentials adds the username (and the URL, if the config has none)
+// from the stored credentials of entry
+func fillLocalCredentials(entry *artifactListEntry, loadCreds func(string) (*config.ArtifactCredentials, error)) {
+	creds, err := loadCreds(entry.Name)
+	if err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	entry.Username = creds.Username
+	if entry.URL == "" {
+		entry.URL = creds.URL
+	}
+}
+
+// writeArtifactList prints one line per source
+func writeArtifactList(w io.Writer, entries []artifactListEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No artifact sources found.")
+		return
+	}
+
+	fmt.Fprintln(w, "\033[35mArtifact Sources:\033[0m")
+	for _, entry := range entries {
+		switch {
+		case entry.Error != "":
+			fmt.Fprintf(w, "  \033[31m✗\033[0m %s (error loading: %s)\n", entry.Name, entry.Error)
+		case entry.Storage == artifactStorageVault:
+			fmt.Fprintf(w, "  \033[32m✓\033[0m %s - %s (vault: %s/%s)\n", entry.Name, entry.URL, entry.VaultPath, entry.VaultSecret)
+		default:
+			fmt.Fprintf(w, "  \033[32m✓\033[0m %s - %s (local)\n", entry.Name, entry.URL)
+		}
+	}
+}
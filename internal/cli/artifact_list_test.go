@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+func TestArtifactListJSONMergesVaultAndLocalSources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	for _, creds := range []*config.ArtifactCredentials{
+		{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-secret0000000"},
+		{Name: "legacy", URL: "https://legacy.example.com", Username: "old", Token: "legacy-secret"},
+	} {
+		if err := secrets.SaveArtifactCredentials(creds); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{ArtifactSources: []config.ArtifactSource{
+		{Name: "nexus", URL: "https://nexus.example.com", UseVault: true, VaultPath: "secret/data/ci", VaultSecretName: "nexus"},
+		{Name: "gitlab", URL: "https://gitlab.example.com"},
+	}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := newArtifactListCmd()
+	cmd.SetArgs([]string{"--json"})
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact list error: %v", err)
+	}
+	if strings.Contains(out.String(), "secret0000000") || strings.Contains(out.String(), "legacy-secret") {
+		t.Fatalf("tokens leaked into the output:\n%s", out.String())
+	}
+
+	var got []artifactListEntry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	want := []artifactListEntry{
+		{Name: "nexus", URL: "https://nexus.example.com", Storage: "vault", VaultPath: "secret/data/ci", VaultSecret: "nexus"},
+		{Name: "gitlab", URL: "https://gitlab.example.com", Storage: "local", Username: "ci"},
+		{Name: "legacy", URL: "https://legacy.example.com", Storage: "local", Username: "old"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("artifact list --json =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestArtifactListWithoutConfigShowsStoredCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	if err := secrets.SaveArtifactCredentials(&config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := newArtifactListCmd()
+	cmd.SetArgs(nil)
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact list error: %v", err)
+	}
+	if !strings.Contains(out.String(), "gitlab - https://gitlab.example.com (local)") {
+		t.Errorf("output = %q, want the stored source", out.String())
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"diffusion/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// newArtifactRotateCmd creates the rotate subcommand
+func newArtifactRotateCmd() *cobra.Command {
+	var tokenFile string
+
+	cmd := &cobra.Command{
+		Use:   "rotate [source-name]",
+		Short: "Replace the stored token of an artifact source, keeping its other settings",
+		Long: `Replace the token of a locally stored artifact source, for example after a
+personal access token expired. The URL, username and diffusion.toml settings are
+kept; only the token is re-encrypted. The new token is prompted for, or read from
+--token-file ("-" reads it from stdin).
+
+Sources that read their credentials from Vault are not changed: diffusion does
+not write to Vault, so the command prints the Vault secret to update instead.`,
+		Example: `  diffusion artifact rotate gitlab
+  diffusion artifact rotate gitlab --token-file ~/new-token
+  echo "$NEW_TOKEN" | diffusion artifact rotate gitlab --token-file -`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceName := args[0]
+			w := cmd.OutOrStdout()
+
+			if source := configuredArtifactSource(sourceName); source != nil && source.UseVault {
+				fmt.Fprintf(w, "\033[33mArtifact source '%s' reads its credentials from Vault; diffusion does not write to Vault.\033[0m\n", sourceName)
+				fmt.Fprintf(w, "Update field '%s' of %s/%s in Vault with the new token.\n", source.VaultTokenField, source.VaultPath, source.VaultSecretName)
+				return nil
+			}
+
+			store, err := openSecretStore()
+			if err != nil {
+				return err
+			}
+			if _, err := store.Load(sourceName); err != nil {
+				return fmt.Errorf("no stored credentials for '%s' (add them with 'diffusion artifact add %s'): %w", sourceName, sourceName, err)
+			}
+			token, err := readRotatedToken(tokenFile, cmd.InOrStdin(), w, sourceName)
+			if err != nil {
+				return err
+			}
+			creds, err := rotateArtifactToken(sourceName, token)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "\033[32mToken for '%s' rotated: %s\033[0m\n", sourceName, maskToken(creds.Token))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tokenFile, "token-file", "", `read the new token from this file instead of prompting ("-" reads stdin)`)
+
+	return cmd
+}
+
+// configuredArtifactSource returns the diffusion.toml entry of a source, or nil
+func configuredArtifactSource(sourceName string) *config.ArtifactSource {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	return findArtifactSource(cfg, sourceName)
+}
+
+// readRotatedToken reads the new token from tokenFile ("-" is stdin) or prompts for it
+func readRotatedToken(tokenFile string, stdin io.Reader, w io.Writer, sourceName string) (string, error) {
+	var token string
+	switch tokenFile {
+	case "":
+		fmt.Fprintf(w, "Enter new Token/Password for %s: ", sourceName)
+		line, _ := bufio.NewReader(stdin).ReadString('\n')
+		token = line
+	case "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token from stdin: %w", err)
+		}
+		token = string(data)
+	default:
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = string(data)
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("new token for '%s' is empty", sourceName)
+	}
+	return token, nil
+}
+
+// rotateArtifactToken re-saves the stored credentials of a source with a new
+// token; the URL and username are kept
+func rotateArtifactToken(sourceName, token string) (*config.ArtifactCredentials, error) {
+	store, err := openSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := store.Load(sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	creds.Token = token
+	if err := store.Save(creds); err != nil {
+		return nil, fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return creds, nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+func TestArtifactRotateChangesOnlyToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	original := &config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-expired000000"}
+	if err := secrets.SaveArtifactCredentials(original); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{ArtifactSources: []config.ArtifactSource{{Name: "gitlab", URL: original.URL}}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	tomlBefore, err := os.ReadFile(config.ConfigFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const newToken = "glpat-rotated1234567890"
+	var out bytes.Buffer
+	cmd := newArtifactRotateCmd()
+	cmd.SetArgs([]string{"gitlab", "--token-file", "-"})
+	cmd.SetIn(strings.NewReader(newToken + "\n"))
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact rotate error: %v", err)
+	}
+
+	rotated, err := secrets.LoadArtifactCredentials("gitlab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *original
+	want.Token = newToken
+	if *rotated != want {
+		t.Errorf("stored credentials = %+v, want %+v", *rotated, want)
+	}
+	if tomlAfter, _ := os.ReadFile(config.ConfigFileName); !bytes.Equal(tomlBefore, tomlAfter) {
+		t.Errorf("diffusion.toml changed:\n%s", tomlAfter)
+	}
+	if strings.Contains(out.String(), newToken) || !strings.Contains(out.String(), maskToken(newToken)) {
+		t.Errorf("confirmation should show only the masked token, got:\n%s", out.String())
+	}
+}
+
+func TestArtifactRotateVaultSourcePrintsPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	cfg := &config.Config{ArtifactSources: []config.ArtifactSource{{
+		Name: "vaulted", URL: "https://git.example.com", UseVault: true,
+		VaultPath: "secret/data/ci", VaultSecretName: "git", VaultTokenField: "token",
+	}}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := newArtifactRotateCmd()
+	cmd.SetArgs([]string{"vaulted"})
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact rotate error: %v", err)
+	}
+	if !strings.Contains(out.String(), "'token' of secret/data/ci/git") {
+		t.Errorf("output should name the Vault secret to update, got:\n%s", out.String())
+	}
+}
+
+func TestReadRotatedToken(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := readRotatedToken("", strings.NewReader("\n"), &out, "gitlab"); err == nil {
+		t.Error("readRotatedToken() should reject an empty token")
+	}
+
+	path := t.TempDir() + "/token"
+	if err := os.WriteFile(path, []byte("  from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readRotatedToken(path, strings.NewReader(""), &out, "gitlab"); err != nil || got != "from-file" {
+		t.Errorf("readRotatedToken(file) = %q, %v; want from-file", got, err)
+	}
+}
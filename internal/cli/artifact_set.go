@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"diffusion/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// artifactVaultFields are the Vault settings set-vault changes; empty fields
+// (and a zero KV version) keep the current value
+type artifactVaultFields struct {
+	Path          string
+	Secret        string
+	UsernameField string
+	TokenField    string
+	KVVersion     int
+}
+
+// newArtifactSetURLCmd creates the set-url subcommand
+func newArtifactSetURLCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-url [source-name] [url]",
+		Short: "Change the URL of an artifact source in place",
+		Long: `Change the URL of an existing artifact source in diffusion.toml. Vault settings
+and stored credentials are kept; the URL in locally stored credentials is updated too.`,
+		Example: `  diffusion artifact set-url gitlab https://gitlab.example.com/group`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceName, url := args[0], strings.TrimSpace(args[1])
+
+			err := config.UpdateConfig(func(cfg *config.Config) error {
+				return setArtifactURL(cfg, sourceName, url)
+			})
+			if err != nil {
+				return err
+			}
+
+			// Local credentials carry their own copy of the URL
+			store, err := openSecretStore()
+			if err != nil {
+				return err
+			}
+			if creds, err := store.Load(sourceName); err == nil {
+				creds.URL = url
+				if err := store.Save(creds); err != nil {
+					return fmt.Errorf("failed to update stored credentials: %w", err)
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "\033[32mArtifact source '%s' URL set to %s\033[0m\n", sourceName, url)
+			return nil
+		},
+	}
+}
+
+// newArtifactSetVaultCmd creates the set-vault subcommand
+func newArtifactSetVaultCmd() *cobra.Command {
+	var fields artifactVaultFields
+
+	cmd := &cobra.Command{
+		Use:   "set-vault [source-name]",
+		Short: "Change the Vault settings of an artifact source in place",
+		Long: `Change where an existing artifact source reads its credentials from Vault and
+switch it to Vault if it used local storage. Only the given flags change; the URL
+is kept. A source switched to Vault needs --path and --secret.`,
+		Example: `  diffusion artifact set-vault gitlab --path secret/data/ci --secret gitlab
+  diffusion artifact set-vault gitlab --token-field pat`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceName := args[0]
+			if fields == (artifactVaultFields{}) {
+				return fmt.Errorf("nothing to change: pass at least one of --path, --secret, --username-field, --token-field or --kv-version")
+			}
+
+			var source config.ArtifactSource
+			err := config.UpdateConfig(func(cfg *config.Config) error {
+				if err := setArtifactVault(cfg, sourceName, fields); err != nil {
+					return err
+				}
+				source = *findArtifactSource(cfg, sourceName)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "\033[32mArtifact source '%s' configured to use Vault at %s/%s\033[0m\n", sourceName, source.VaultPath, source.VaultSecretName)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fields.Path, "path", "", "Vault path of the secret (e.g. secret/data/ci)")
+	cmd.Flags().StringVar(&fields.Secret, "secret", "", "Vault secret name")
+	cmd.Flags().StringVar(&fields.UsernameField, "username-field", "", "field holding the username")
+	cmd.Flags().StringVar(&fields.TokenField, "token-field", "", "field holding the token")
+	cmd.Flags().IntVar(&fields.KVVersion, "kv-version", 0, "KV secret engine version (1 or 2)")
+
+	return cmd
+}
+
+// findArtifactSource returns the diffusion.toml entry of a source, or nil
+func findArtifactSource(cfg *config.Config, sourceName string) *config.ArtifactSource {
+	for i := range cfg.ArtifactSources {
+		if cfg.ArtifactSources[i].Name == sourceName {
+			return &cfg.ArtifactSources[i]
+		}
+	}
+	return nil
+}
+
+// setArtifactURL changes the URL of an existing source
+func setArtifactURL(cfg *config.Config, sourceName, url string) error {
+	source := findArtifactSource(cfg, sourceName)
+	if source == nil {
+		return fmt.Errorf("artifact source '%s' not found in diffusion.toml", sourceName)
+	}
+	if url == "" {
+		return fmt.Errorf("URL for '%s' must not be empty", sourceName)
+	}
+	source.URL = url
+	return nil
+}
+
+// setArtifactVault applies the non-empty Vault fields to an existing source and
+// turns use_vault on; username/token fields default to username/token
+func setArtifactVault(cfg *config.Config, sourceName string, fields artifactVaultFields) error {
+	source := findArtifactSource(cfg, sourceName)
+	if source == nil {
+		return fmt.Errorf("artifact source '%s' not found in diffusion.toml", sourceName)
+	}
+	if fields.KVVersion != 0 && fields.KVVersion != 1 && fields.KVVersion != 2 {
+		return fmt.Errorf("--kv-version must be 1 or 2, got %d", fields.KVVersion)
+	}
+
+	updated := *source
+	updated.UseVault = true
+	if fields.Path != "" {
+		updated.VaultPath = fields.Path
+	}
+	if fields.Secret != "" {
+		updated.VaultSecretName = fields.Secret
+	}
+	if fields.UsernameField != "" {
+		updated.VaultUsernameField = fields.UsernameField
+	}
+	if fields.TokenField != "" {
+		updated.VaultTokenField = fields.TokenField
+	}
+	if fields.KVVersion != 0 {
+		updated.VaultKVVersion = fields.KVVersion
+	}
+	if updated.VaultUsernameField == "" {
+		updated.VaultUsernameField = "username"
+	}
+	if updated.VaultTokenField == "" {
+		updated.VaultTokenField = "token"
+	}
+	if updated.VaultPath == "" || updated.VaultSecretName == "" {
+		return fmt.Errorf("artifact source '%s' needs --path and --secret to use Vault", sourceName)
+	}
+
+	*source = updated
+	return nil
+}
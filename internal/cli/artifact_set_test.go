@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// setupArtifactSources writes a diffusion.toml with a local and a Vault source
+// into a temporary working directory
+func setupArtifactSources(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	cfg := &config.Config{ArtifactSources: []config.ArtifactSource{
+		{Name: "gitlab", URL: "https://gitlab.example.com", Type: "git"},
+		{
+			Name: "vaulted", URL: "https://git.example.com", Type: "git", UseVault: true,
+			VaultPath: "secret/data/ci", VaultSecretName: "git", VaultUsernameField: "user", VaultTokenField: "token", VaultKVVersion: 2,
+		},
+	}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadArtifactSource(t *testing.T, name string) config.ArtifactSource {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := findArtifactSource(cfg, name)
+	if source == nil {
+		t.Fatalf("source %q missing from diffusion.toml", name)
+	}
+	return *source
+}
+
+func TestArtifactSetURL(t *testing.T) {
+	setupArtifactSources(t)
+	creds := &config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-token"}
+	if err := secrets.SaveArtifactCredentials(creds); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newArtifactSetURLCmd()
+	cmd.SetArgs([]string{"gitlab", "https://gitlab.internal.example.com/group"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact set-url error: %v", err)
+	}
+
+	want := config.ArtifactSource{Name: "gitlab", URL: "https://gitlab.internal.example.com/group", Type: "git"}
+	if got := loadArtifactSource(t, "gitlab"); got != want {
+		t.Errorf("source = %+v, want %+v", got, want)
+	}
+	stored, err := secrets.LoadArtifactCredentials("gitlab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.URL != want.URL || stored.Token != creds.Token || stored.Username != creds.Username {
+		t.Errorf("stored credentials = %+v, want only the URL changed", *stored)
+	}
+}
+
+func TestArtifactSetVault(t *testing.T) {
+	setupArtifactSources(t)
+
+	cmd := newArtifactSetVaultCmd()
+	cmd.SetArgs([]string{"vaulted", "--token-field", "pat", "--path", "secret/data/platform"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact set-vault error: %v", err)
+	}
+	want := config.ArtifactSource{
+		Name: "vaulted", URL: "https://git.example.com", Type: "git", UseVault: true,
+		VaultPath: "secret/data/platform", VaultSecretName: "git", VaultUsernameField: "user", VaultTokenField: "pat", VaultKVVersion: 2,
+	}
+	if got := loadArtifactSource(t, "vaulted"); got != want {
+		t.Errorf("source = %+v, want %+v", got, want)
+	}
+
+	// Switching a local source to Vault needs a path and a secret
+	cmd = newArtifactSetVaultCmd()
+	cmd.SetArgs([]string{"gitlab", "--path", "secret/data/ci"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--secret") {
+		t.Errorf("set-vault without --secret error = %v, want missing --secret", err)
+	}
+	if got := loadArtifactSource(t, "gitlab"); got.UseVault {
+		t.Error("failed set-vault must not change the source")
+	}
+
+	cmd = newArtifactSetVaultCmd()
+	cmd.SetArgs([]string{"gitlab", "--path", "secret/data/ci", "--secret", "gitlab"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact set-vault error: %v", err)
+	}
+	if got := loadArtifactSource(t, "gitlab"); !got.UseVault || got.VaultUsernameField != "username" || got.VaultTokenField != "token" {
+		t.Errorf("source = %+v, want use_vault with default fields", got)
+	}
+}
+
+func TestArtifactSetNotFound(t *testing.T) {
+	setupArtifactSources(t)
+
+	for _, args := range [][]string{
+		{"set-url", "missing", "https://example.com"},
+		{"set-vault", "missing", "--path", "secret/data/ci", "--secret", "git"},
+	} {
+		cmd := NewArtifactCmd(nil)
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "artifact source 'missing' not found") {
+			t.Errorf("%s error = %v, want not found", args[0], err)
+		}
+	}
+}
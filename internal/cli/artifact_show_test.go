@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+func TestWriteArtifactDetailsMasksTokenByDefault(t *testing.T) {
+	creds := &config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-1234567890abcdef"}
+
+	var masked bytes.Buffer
+	writeArtifactDetails(&masked, creds, false)
+	if strings.Contains(masked.String(), creds.Token) {
+		t.Errorf("token should be masked without --reveal, got:\n%s", masked.String())
+	}
+	if !strings.Contains(masked.String(), maskToken(creds.Token)) {
+		t.Errorf("masked token %q missing from output:\n%s", maskToken(creds.Token), masked.String())
+	}
+
+	var revealed bytes.Buffer
+	writeArtifactDetails(&revealed, creds, true)
+	if !strings.Contains(revealed.String(), creds.Token) {
+		t.Errorf("full token missing with --reveal:\n%s", revealed.String())
+	}
+}
+
+func TestConfirmReveal(t *testing.T) {
+	for input, want := range map[string]bool{
+		"y\n":   true,
+		"YES\n": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+	} {
+		var out bytes.Buffer
+		if got := confirmReveal(bufio.NewReader(strings.NewReader(input)), &out, "gitlab"); got != want {
+			t.Errorf("confirmReveal(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestArtifactShowRevealWritesAuditLog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	creds := &config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-1234567890abcdef"}
+	if err := secrets.SaveArtifactCredentials(creds); err != nil {
+		t.Fatal(err)
+	}
+	auditPath, _ := secrets.AuditLogPath()
+
+	cmd := newArtifactShowCmd()
+	cmd.SetArgs([]string{"gitlab"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact show error: %v", err)
+	}
+	if _, err := os.Stat(auditPath); !os.IsNotExist(err) {
+		t.Errorf("audit log should not be written without --reveal (err %v)", err)
+	}
+
+	cmd = newArtifactShowCmd()
+	cmd.SetArgs([]string{"gitlab", "--reveal", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("artifact show --reveal error: %v", err)
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("audit log not written: %v", err)
+	}
+	line := string(data)
+	if !strings.Contains(line, "action=reveal-token") || !strings.Contains(line, "source=gitlab") {
+		t.Errorf("unexpected audit line %q", line)
+	}
+	if strings.Contains(line, creds.Token) {
+		t.Error("audit log must not contain the token")
+	}
+}
+
+func TestMaskTokenTailHidesLength(t *testing.T) {
+	tests := []struct {
+		token string
+		n     int
+		want  string
+	}{
+		{"", 0, "****"},
+		{"ab", 0, "****"},
+		{"abcdefgh", 7, "****"},
+		{"abcdefgh", 2, "****gh"},
+		{"abcdefgh", -1, "****"},
+	}
+	for _, tt := range tests {
+		if got := maskTokenTail(tt.token, tt.n); got != tt.want {
+			t.Errorf("maskTokenTail(%q, %d) = %q, want %q", tt.token, tt.n, got, tt.want)
+		}
+	}
+	if short, long := maskToken("0123456789"), maskToken("0123456789012345678901234567890123456789"); len(short) != len(long) {
+		t.Errorf("masked tokens %q and %q reveal the token length", short, long)
+	}
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// memoryStore is an in-memory secrets.SecretStore
+type memoryStore map[string]config.ArtifactCredentials
+
+func (m memoryStore) Save(creds *config.ArtifactCredentials) error {
+	m[creds.Name] = *creds
+	return nil
+}
+
+func (m memoryStore) Load(sourceName string) (*config.ArtifactCredentials, error) {
+	creds, ok := m[sourceName]
+	if !ok {
+		return nil, fmt.Errorf("credentials not found for source '%s'", sourceName)
+	}
+	return &creds, nil
+}
+
+func (m memoryStore) Delete(sourceName string) error {
+	if _, ok := m[sourceName]; !ok {
+		return fmt.Errorf("credentials not found for source '%s'", sourceName)
+	}
+	delete(m, sourceName)
+	return nil
+}
+
+func (m memoryStore) List() ([]string, error) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+var _ secrets.SecretStore = memoryStore{}
+
+// useMemoryStore makes the artifact commands use an in-memory store
+func useMemoryStore(t *testing.T) memoryStore {
+	t.Helper()
+	store := memoryStore{}
+	orig := openSecretStore
+	t.Cleanup(func() { openSecretStore = orig })
+	openSecretStore = func() (secrets.SecretStore, error) { return store, nil }
+	return store
+}
+
+func TestArtifactCommandsUseSecretStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	store := useMemoryStore(t)
+	store["gitlab"] = config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-secret0000000"}
+	if err := config.SaveConfig(&config.Config{ArtifactSources: []config.ArtifactSource{{Name: "gitlab", URL: "https://gitlab.example.com"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	list := newArtifactListCmd()
+	list.SetArgs(nil)
+	list.SetOut(&out)
+	if err := list.Execute(); err != nil {
+		t.Fatalf("artifact list error: %v", err)
+	}
+	if !strings.Contains(out.String(), "gitlab - https://gitlab.example.com (local)") {
+		t.Errorf("list output = %q, want the stored source", out.String())
+	}
+
+	creds, err := resolveArtifactCredentials("gitlab")
+	if err != nil {
+		t.Fatalf("resolveArtifactCredentials() error = %v", err)
+	}
+	if creds.Username != "ci" {
+		t.Errorf("show loaded %+v, want the stored credentials", creds)
+	}
+
+	remove := newArtifactRemoveCmd()
+	remove.SetArgs([]string{"gitlab"})
+	if err := remove.Execute(); err != nil {
+		t.Fatalf("artifact remove error: %v", err)
+	}
+	if _, ok := store["gitlab"]; ok {
+		t.Error("artifact remove left the credentials in the store")
+	}
+}
+
+func TestArtifactRotateUsesSecretStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	store := useMemoryStore(t)
+	store["gitlab"] = config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "old"}
+
+	if _, err := rotateArtifactToken("gitlab", "new-token"); err != nil {
+		t.Fatalf("rotateArtifactToken() error = %v", err)
+	}
+	if got := store["gitlab"].Token; got != "new-token" {
+		t.Errorf("stored token = %q, want new-token", got)
+	}
+	if stored, _ := secrets.ListStoredCredentials(); len(stored) != 0 {
+		t.Errorf("file backend holds %v, want nothing", stored)
+	}
+}
+
+func TestArtifactSourcesHelperUsesSecretStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	store := useMemoryStore(t)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origStdin := os.Stdin
+	t.Cleanup(func() { os.Stdin = origStdin })
+	os.Stdin = r
+	answers := "y\ngitlab\nhttps://gitlab.example.com\nn\nci\nglpat-secret0000000\nn\n"
+	if _, err := w.WriteString(answers); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	sources := ArtifactSourcesHelper()
+	if len(sources) != 1 || sources[0].Name != "gitlab" || sources[0].UseVault {
+		t.Fatalf("sources = %+v, want one local gitlab source", sources)
+	}
+	creds, ok := store["gitlab"]
+	if !ok || creds.Username != "ci" || creds.Token != "glpat-secret0000000" {
+		t.Errorf("stored credentials = %+v, want the entered ones", creds)
+	}
+}
@@ -2,9 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"diffusion/internal/cache"
 	"diffusion/internal/config"
+	"diffusion/internal/molecule"
+	"diffusion/internal/role"
 
 	"github.com/spf13/cobra"
 )
@@ -18,57 +22,82 @@ func NewCacheCmd(cli *CLI) *cobra.Command {
 
 	cacheCmd.AddCommand(newCacheEnableCmd())
 	cacheCmd.AddCommand(newCacheDisableCmd())
+	cacheCmd.AddCommand(newCacheShareCmd())
 	cacheCmd.AddCommand(newCacheCleanCmd())
 	cacheCmd.AddCommand(newCacheStatusCmd())
 	cacheCmd.AddCommand(newCacheListCmd())
+	cacheCmd.AddCommand(newCacheWarmCmd())
+	cacheCmd.AddCommand(newCacheExportCmd())
+	cacheCmd.AddCommand(newCacheImportCmd())
 
 	return cacheCmd
 }
 
 func newCacheEnableCmd() *cobra.Command {
 	var dockerCache bool
+	var dockerPerImage bool
 	var uvCache bool
 
 	cmd := &cobra.Command{
 		Use:   "enable",
 		Short: "Enable Ansible cache for this role",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.LoadConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
-			}
+			var cfg *config.Config
+			var cacheID, cacheDir string
+			err := config.UpdateConfig(func(c *config.Config) error {
+				cfg = c
+				var err error
+
+				// Generate or get cache ID
+				cacheID, err = cache.GetOrCreateCacheID(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to generate cache ID: %w", err)
+				}
 
-			// Generate or get cache ID
-			cacheID, err := cache.GetOrCreateCacheID(cfg)
-			if err != nil {
-				return fmt.Errorf("failed to generate cache ID: %w", err)
-			}
+				// Initialize CacheConfig if needed
+				if cfg.CacheConfig == nil {
+					cfg.CacheConfig = &config.CacheSettings{}
+				}
 
-			// Initialize CacheConfig if needed
-			if cfg.CacheConfig == nil {
-				cfg.CacheConfig = &config.CacheSettings{}
-			}
+				// Create cache directory
+				cacheDir, err = cache.EnsureCacheDir(cacheID, cfg.CacheConfig.CachePath)
+				if err != nil {
+					return fmt.Errorf("failed to create cache directory: %w", err)
+				}
 
-			// Create cache directory
-			cacheDir, err := cache.EnsureCacheDir(cacheID, cfg.CacheConfig.CachePath)
-			if err != nil {
-				return fmt.Errorf("failed to create cache directory: %w", err)
-			}
+				// Update config
+				cfg.CacheConfig.Enabled = true
+				cfg.CacheConfig.CacheID = cacheID
 
-			// Update config
-			cfg.CacheConfig.Enabled = true
-			cfg.CacheConfig.CacheID = cacheID
+				// Apply flags if explicitly set, otherwise preserve existing values
+				if cmd.Flags().Changed("docker") {
+					cfg.CacheConfig.DockerCache = dockerCache
+				}
+				if cmd.Flags().Changed("uv") {
+					cfg.CacheConfig.UVCache = uvCache
+				}
+				if cmd.Flags().Changed("docker-per-image") {
+					cfg.CacheConfig.DockerPerImage = dockerPerImage
+					if dockerPerImage {
+						cfg.CacheConfig.DockerCache = true
+					}
+				}
 
-			// Apply flags if explicitly set, otherwise preserve existing values
-			if cmd.Flags().Changed("docker") {
-				cfg.CacheConfig.DockerCache = dockerCache
-			}
-			if cmd.Flags().Changed("uv") {
-				cfg.CacheConfig.UVCache = uvCache
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 
-			if err := config.SaveConfig(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			// Re-enabling a shared cache makes this role one of its users again
+			if name := cache.SharedName(cacheID); name != "" {
+				configPath, err := roleConfigPath()
+				if err != nil {
+					return err
+				}
+				if err := cache.AddSharedReference(name, configPath); err != nil {
+					return err
+				}
 			}
 
 			fmt.Printf("\033[32mCache enabled for this role\033[0m\n")
@@ -81,6 +110,7 @@ func newCacheEnableCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&dockerCache, "docker", false, "Enable Docker image caching (saves/loads image tarballs)")
+	cmd.Flags().BoolVar(&dockerPerImage, "docker-per-image", false, "Save each Docker image to its own tarball (implies --docker); lowers disk pressure and keeps other images on a failed save")
 	cmd.Flags().BoolVar(&uvCache, "uv", false, "Enable UV/Python package caching")
 
 	return cmd
@@ -91,22 +121,36 @@ func newCacheDisableCmd() *cobra.Command {
 		Use:   "disable",
 		Short: "Disable cache for this role",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.LoadConfig()
+			var cacheID string
+			configured := true
+			err := config.UpdateConfig(func(cfg *config.Config) error {
+				if cfg.CacheConfig == nil {
+					configured = false
+					return nil
+				}
+				cfg.CacheConfig.Enabled = false
+				cfg.CacheConfig.DockerCache = false
+				cfg.CacheConfig.UVCache = false
+				cacheID = cfg.CacheConfig.CacheID
+				return nil
+			})
 			if err != nil {
-				return fmt.Errorf("failed to load config: %w", err)
+				return err
 			}
-
-			if cfg.CacheConfig == nil {
+			if !configured {
 				fmt.Println("\033[33mCache is not configured\033[0m")
 				return nil
 			}
 
-			cfg.CacheConfig.Enabled = false
-			cfg.CacheConfig.DockerCache = false
-			cfg.CacheConfig.UVCache = false
-
-			if err := config.SaveConfig(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			// A disabled role no longer keeps a shared cache alive
+			if name := cache.SharedName(cacheID); name != "" {
+				configPath, err := roleConfigPath()
+				if err != nil {
+					return err
+				}
+				if err := cache.RemoveSharedReference(name, configPath); err != nil {
+					return err
+				}
 			}
 
 			fmt.Printf("\033[32mCache disabled for this role (roles, collections, Docker, UV)\033[0m\n")
@@ -134,6 +178,21 @@ func newCacheCleanCmd() *cobra.Command {
 			cacheID := cfg.CacheConfig.CacheID
 			cachePath := cfg.CacheConfig.CachePath
 
+			// A shared cache is only deleted by its last user
+			if name := cache.SharedName(cacheID); name != "" {
+				others, err := otherSharedCacheUsers(name)
+				if err != nil {
+					return err
+				}
+				if len(others) > 0 {
+					fmt.Printf("\033[33mShared cache '%s' is still used by %d other role(s), not deleted:\033[0m\n", name, len(others))
+					for _, other := range others {
+						fmt.Printf("  %s\n", other)
+					}
+					return nil
+				}
+			}
+
 			// Get per-type sizes before cleaning
 			rolesSize, _ := cache.GetSubdirSize(cacheID, cachePath, config.CacheRolesDir)
 			collectionsSize, _ := cache.GetSubdirSize(cacheID, cachePath, config.CacheCollectionsDir)
@@ -185,6 +244,10 @@ func newCacheStatusCmd() *cobra.Command {
 			fmt.Println("\033[35m[Cache Status]\033[0m")
 			fmt.Printf("  Enabled:       \033[38;2;127;255;212m%t\033[0m\n", cfg.CacheConfig.Enabled)
 			fmt.Printf("  Cache ID:      \033[38;2;127;255;212m%s\033[0m\n", cfg.CacheConfig.CacheID)
+			if name := cache.SharedName(cfg.CacheConfig.CacheID); name != "" {
+				users, _ := cache.SharedReferences(name)
+				fmt.Printf("  Shared:        \033[38;2;127;255;212m%s (used by %d role(s))\033[0m\n", name, len(users))
+			}
 			fmt.Printf("  Docker cache:  \033[38;2;127;255;212m%t\033[0m\n", cfg.CacheConfig.DockerCache)
 			fmt.Printf("  UV cache:      \033[38;2;127;255;212m%t\033[0m\n", cfg.CacheConfig.UVCache)
 
@@ -248,10 +311,167 @@ func newCacheListCmd() *cobra.Command {
 				// Extract cache ID from directory name (role_<id>)
 				cacheID := cacheEntry[5:] // Remove "role_" prefix
 				size, _ := cache.GetCacheSize(cacheID, cfg.CacheConfig.CachePath)
-				fmt.Printf("  \033[32m✓\033[0m %s - \033[38;2;127;255;212m%.2f MB\033[0m\n", cacheEntry, float64(size)/(1024*1024))
+				shared := ""
+				if name := cache.SharedName(cacheID); name != "" {
+					users, _ := cache.SharedReferences(name)
+					shared = fmt.Sprintf(" (shared '%s', %d role(s))", name, len(users))
+				}
+				fmt.Printf("  \033[32m✓\033[0m %s - \033[38;2;127;255;212m%.2f MB\033[0m%s\n", cacheEntry, float64(size)/(1024*1024), shared)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newCacheWarmCmd() *cobra.Command {
+	var warm molecule.WarmOptions
+	var scenario string
+	var ciMode bool
+
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Install roles/collections into the cache without running converge",
+		Long: `Install the role's collections and roles from requirements.yml into the cache
+of the molecule container, starting the container (with the role copied in) when
+it is not running, and run uv-sync to fill the UV cache. Converge is not run.
+Use --collections-only or --roles-only to warm a single category; in --ci mode
+the warmed cache is copied back out of the container.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			meta, _, err := role.LoadRoleConfig(scenario)
+			if err != nil {
+				return fmt.Errorf("failed to load role config: %w", err)
+			}
+			if meta.GalaxyInfo.RoleName == "" || meta.GalaxyInfo.Namespace == "" {
+				return fmt.Errorf("%s", config.WarnRoleNameMissing)
+			}
+
+			opts := &molecule.MoleculeOptions{
+				RoleFlag:     meta.GalaxyInfo.RoleName,
+				OrgFlag:      strings.ToLower(meta.GalaxyInfo.Namespace),
+				RoleScenario: scenario,
+				CIMode:       ciMode,
+			}
+			return molecule.WarmCache(opts, warm)
+		},
+	}
+
+	cmd.Flags().BoolVar(&warm.CollectionsOnly, "collections-only", false, "only install and cache collections")
+	cmd.Flags().BoolVar(&warm.RolesOnly, "roles-only", false, "only install and cache roles")
+	cmd.Flags().StringVarP(&scenario, "scenario", "s", "", "molecule scenario whose requirements.yml is installed (default: 'default')")
+	cmd.Flags().BoolVar(&ciMode, "ci", false, "CI/CD mode (copy the warmed cache out of the container)")
+	cmd.MarkFlagsMutuallyExclusive("collections-only", "roles-only")
+
+	return cmd
+}
+
+func newCacheExportCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export this role's cache to an archive for use on another machine",
+		Long: `Archive the role's cache directory (roles, collections, uv, docker) together with
+a manifest (diffusion version, platform, creation time). The compression follows
+the output extension: .tar.zst (the default, requires the zstd binary),
+.tar.gz/.tgz or .tar.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if cfg.CacheConfig == nil || cfg.CacheConfig.CacheID == "" {
+				return fmt.Errorf("no cache configured for this role; run 'diffusion cache enable' first")
+			}
+
+			cacheDir, err := cache.GetCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
+			if err != nil {
+				return fmt.Errorf("failed to get cache directory: %w", err)
 			}
 
+			manifest := cache.NewArchiveManifest(Version, cfg.CacheConfig.CacheID)
+			if err := cache.ExportCache(cacheDir, output, manifest); err != nil {
+				return fmt.Errorf("failed to export cache: %w", err)
+			}
+
+			fmt.Printf("\033[32mCache exported\033[0m\n")
+			fmt.Printf("\033[35mCache ID: \033[0m\033[38;2;127;255;212m%s\033[0m\n", manifest.CacheID)
+			fmt.Printf("\033[35mArchive:  \033[0m\033[38;2;127;255;212m%s\033[0m\n", output)
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "cache.tar.zst", "archive to write (.tar.zst, .tar.gz, .tgz or .tar)")
+
+	return cmd
+}
+
+func newCacheImportCmd() *cobra.Command {
+	var input string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a cache archive created by 'diffusion cache export'",
+		Long: `Extract a cache archive into this role's cache directory and enable the cache.
+The role's existing cache ID is kept; without one, the exporting machine's ID is
+registered. A warning is printed when the archive comes from a different
+diffusion version or platform.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if input == "" {
+				return fmt.Errorf("--input is required")
+			}
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if cfg.CacheConfig == nil {
+				cfg.CacheConfig = &config.CacheSettings{}
+			}
+
+			manifest, err := cache.ReadArchiveManifest(input)
+			if err != nil {
+				return err
+			}
+
+			cacheID := cfg.CacheConfig.CacheID
+			if cacheID == "" {
+				cacheID = manifest.CacheID
+			}
+			if cacheID == "" {
+				if cacheID, err = cache.GenerateCacheID(); err != nil {
+					return fmt.Errorf("failed to generate cache ID: %w", err)
+				}
+			}
+
+			cacheDir, err := cache.EnsureCacheDir(cacheID, cfg.CacheConfig.CachePath)
+			if err != nil {
+				return fmt.Errorf("failed to create cache directory: %w", err)
+			}
+			if _, err := cache.ImportCache(input, cacheDir); err != nil {
+				return fmt.Errorf("failed to import cache: %w", err)
+			}
+
+			// The import can take a while, so the config is locked only to record the ID
+			if err := config.UpdateConfig(func(cfg *config.Config) error {
+				enableCacheID(cfg, cacheID)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			for _, mismatch := range manifest.Mismatches(Version) {
+				fmt.Printf("\033[33mWarning: archive was exported by %s; the cache may need re-warming\033[0m\n", mismatch)
+			}
+			fmt.Printf("\033[32mCache imported and enabled for this role\033[0m\n")
+			fmt.Printf("\033[35mCache ID:   \033[0m\033[38;2;127;255;212m%s\033[0m\n", cacheID)
+			fmt.Printf("\033[35mCache Path: \033[0m\033[38;2;127;255;212m%s\033[0m\n", cacheDir)
+			fmt.Printf("\033[35mExported:   \033[0m\033[38;2;127;255;212m%s\033[0m\n", manifest.CreatedAt.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&input, "input", "i", "", "archive created by 'diffusion cache export'")
+
+	return cmd
 }
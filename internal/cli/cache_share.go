@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// newCacheShareCmd creates the share subcommand
+func newCacheShareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "share <name>",
+		Short: "Use a named cache shared with other roles instead of a per-role cache",
+		Long: `Point this role at the shared cache <name> and enable caching. Every role that
+runs 'diffusion cache share <name>' uses the same cache directory, so roles of a
+monorepo keep one copy of their collections, roles and Python packages.
+
+Roles using a shared cache are tracked in ~/.diffusion/cache/shared_index.json:
+'cache clean' only deletes a shared cache once no other role uses it, and
+'cache disable' stops this role from counting as a user.
+
+Two roles converging at the same time against one shared cache can race while
+installing the same collection or role, and their Docker image tarballs overwrite
+each other on --wipe. Run such roles one after another, or give parallel CI jobs
+their own cache.`,
+		Example: `  diffusion cache share monorepo`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := cache.ValidateSharedName(name); err != nil {
+				return err
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			configPath, err := roleConfigPath()
+			if err != nil {
+				return err
+			}
+
+			if cfg.CacheConfig == nil {
+				cfg.CacheConfig = &config.CacheSettings{}
+			}
+			previous := cfg.CacheConfig.CacheID
+			cacheID := cache.SharedCacheID(name)
+
+			cacheDir, err := cache.EnsureCacheDir(cacheID, cfg.CacheConfig.CachePath)
+			if err != nil {
+				return fmt.Errorf("failed to create cache directory: %w", err)
+			}
+			if err := config.UpdateConfig(func(cfg *config.Config) error {
+				enableCacheID(cfg, cacheID)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			if oldName := cache.SharedName(previous); oldName != "" && oldName != name {
+				if err := cache.RemoveSharedReference(oldName, configPath); err != nil {
+					return err
+				}
+			}
+			if err := cache.AddSharedReference(name, configPath); err != nil {
+				return err
+			}
+			users, err := cache.SharedReferences(name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\033[32mRole now uses shared cache '%s'\033[0m\n", name)
+			fmt.Printf("\033[35mCache ID:      \033[0m\033[38;2;127;255;212m%s\033[0m\n", cacheID)
+			fmt.Printf("\033[35mCache Path:    \033[0m\033[38;2;127;255;212m%s\033[0m\n", cacheDir)
+			fmt.Printf("\033[35mUsed by:       \033[0m\033[38;2;127;255;212m%d role(s)\033[0m\n", len(users))
+			if previous != "" && previous != cacheID && cache.SharedName(previous) == "" {
+				oldDir, _ := cache.GetCacheDir(previous, cfg.CacheConfig.CachePath)
+				fmt.Printf("\033[33mNote: the previous per-role cache %s was kept; delete it by hand if it is no longer needed.\033[0m\n", oldDir)
+			}
+			fmt.Println("\033[33mNote: do not converge two roles against one shared cache at the same time (see 'diffusion cache share --help').\033[0m")
+			return nil
+		},
+	}
+}
+
+// roleConfigPath returns the absolute path of this role's diffusion.toml, the
+// key of the role in the shared cache index
+func roleConfigPath() (string, error) {
+	path, err := config.ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// otherSharedCacheUsers returns the role configs other than this one that use
+// the shared cache name
+func otherSharedCacheUsers(name string) ([]string, error) {
+	self, err := roleConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return cache.OtherSharedUsers(name, self)
+}
+
+// enableCacheID turns the cache on with the given cache ID, keeping the other
+// cache settings
+func enableCacheID(cfg *config.Config, cacheID string) {
+	if cfg.CacheConfig == nil {
+		cfg.CacheConfig = &config.CacheSettings{}
+	}
+	cfg.CacheConfig.Enabled = true
+	cfg.CacheConfig.CacheID = cacheID
+}
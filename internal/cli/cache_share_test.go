@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+)
+
+// shareRoleCache creates a role directory with a diffusion.toml, runs
+// 'cache share name' in it and returns the directory
+func shareRoleCache(t *testing.T, parent, role, name string) string {
+	t.Helper()
+	dir := filepath.Join(parent, role)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	cmd := newCacheShareCmd()
+	cmd.SetArgs([]string{name})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cache share in %s error: %v", role, err)
+	}
+	return dir
+}
+
+func runCacheClean(t *testing.T, dir string) {
+	t.Helper()
+	t.Chdir(dir)
+	cmd := newCacheCleanCmd()
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cache clean in %s error: %v", dir, err)
+	}
+}
+
+func TestCacheShareCleanKeepsCacheInUse(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	roles := t.TempDir()
+
+	web := shareRoleCache(t, roles, "web", "mono")
+	db := shareRoleCache(t, roles, "db", "mono")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled || cfg.CacheConfig.CacheID != cache.SharedCacheID("mono") {
+		t.Fatalf("cache config = %+v, want shared cache mono enabled", cfg.CacheConfig)
+	}
+	cacheDir, _ := cache.GetCacheDir(cache.SharedCacheID("mono"), "")
+	if users, _ := cache.SharedReferences("mono"); len(users) != 2 {
+		t.Fatalf("shared cache users = %v, want 2", users)
+	}
+
+	runCacheClean(t, web)
+	if _, err := os.Stat(cacheDir); err != nil {
+		t.Fatalf("shared cache deleted while db still uses it: %v", err)
+	}
+
+	// After web stops using it, db is the last user and clean deletes it
+	t.Chdir(web)
+	disable := newCacheDisableCmd()
+	disable.SetArgs(nil)
+	if err := disable.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	runCacheClean(t, db)
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("shared cache not deleted by its last user (err %v)", err)
+	}
+}
+
+func TestCacheShareRejectsInvalidName(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	cmd := newCacheShareCmd()
+	cmd.SetArgs([]string{"../other"})
+	cmd.SilenceUsage = true
+	if err := cmd.Execute(); err == nil {
+		t.Error("cache share accepted an invalid name")
+	}
+}
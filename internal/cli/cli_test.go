@@ -245,8 +245,9 @@ func TestMaskToken(t *testing.T) {
 		want  string
 	}{
 		{"short token", "abc", "****"},
-		{"medium token", "12345678", "****"},
-		{"long token", "abcdefghijklmnop", "abcd...mnop"},
+		{"shorter than tail plus two", "12345", "****"},
+		{"tail plus two", "123456", "****3456"},
+		{"long token", "abcdefghijklmnop", "****mnop"},
 		{"empty token", "", "****"},
 	}
 
@@ -297,3 +297,27 @@ func TestDepsSyncUpdatesMetaWithoutVersions(t *testing.T) {
 func containsVersionConstraint(s string) bool {
 	return len(s) > 0 && (s[0] == '>' || s[0] == '<' || s[0] == '=' || s[0] == '~' || s[0] == '^')
 }
+
+// TestAddCollectionVersionArgument verifies the optional version constraint argument is validated
+func TestAddCollectionVersionArgument(t *testing.T) {
+	cmd := NewRoleAddCollectionCmd(&CLI{})
+	if err := cmd.Args(cmd, []string{"general", ">=7.0.0"}); err != nil {
+		t.Errorf("expected name and constraint to be accepted: %v", err)
+	}
+	if err := cmd.Args(cmd, []string{"general", ">=7.0.0", "extra"}); err == nil {
+		t.Error("expected three arguments to be rejected")
+	}
+
+	for _, args := range [][]string{
+		{"general", ">=abc", "-n", "community"},
+		{"general>=7.0.0", ">=8.0.0", "-n", "community"},
+	} {
+		cmd := NewRoleAddCollectionCmd(&CLI{})
+		cmd.SetArgs(args)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"diffusion/internal/config"
@@ -203,4 +204,62 @@ func TestCLIScenarioHandling(t *testing.T) {
 	if cli2.RoleScenario != "" {
 		t.Errorf("expected empty scenario by default, got %q", cli2.RoleScenario)
 	}
-}
\ No newline at end of file
+}
+// TestMoleculePrepareFlag tests that --prepare is registered and mapped to the CLI
+func TestMoleculePrepareFlag(t *testing.T) {
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+
+	if cmd.Flags().Lookup("prepare") == nil {
+		t.Fatal("expected --prepare flag to be registered")
+	}
+	if err := cmd.Flags().Set("prepare", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if !cli.PrepareFlag {
+		t.Error("expected --prepare to set PrepareFlag")
+	}
+}
+
+// TestMoleculeLimitFlag tests that --limit is registered and rejects an empty pattern
+func TestMoleculeLimitFlag(t *testing.T) {
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+
+	if cmd.Flags().Lookup("limit") == nil {
+		t.Fatal("expected --limit flag to be registered")
+	}
+	if err := cmd.Flags().Set("limit", " "); err != nil {
+		t.Fatal(err)
+	}
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "non-empty host pattern") {
+		t.Fatalf("expected empty --limit to be rejected, got %v", err)
+	}
+}
+
+// TestMoleculePlatformFlag tests that --platform is repeatable and validated
+func TestMoleculePlatformFlag(t *testing.T) {
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+
+	for _, v := range []string{"name=ubuntu,image=ubuntu:22.04", "name=rocky"} {
+		if err := cmd.Flags().Set("platform", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(cli.PlatformFlags) != 2 {
+		t.Fatalf("expected 2 platform values, got %v", cli.PlatformFlags)
+	}
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "image is required") {
+		t.Fatalf("expected invalid platform to be rejected, got %v", err)
+	}
+}
+
+// TestDepsLockUsesGlobalQuiet checks deps lock does not shadow the persistent --quiet
+func TestDepsLockUsesGlobalQuiet(t *testing.T) {
+	if newDepsLockCmd().Flags().Lookup("quiet") != nil {
+		t.Error("deps lock should rely on the global --quiet flag")
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+
+	"diffusion/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCmd creates the config command
+func NewConfigCmd(cli *CLI) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get or set individual diffusion.toml keys, or migrate the file",
+		Long: `Read or change a single diffusion.toml value by its dotted TOML key path.
+
+Examples:
+  diffusion config get container_registry.registry_server
+  diffusion config set container_registry.registry_server cr.example.com
+  diffusion config set tests.remote_repositories https://a.git,https://b.git
+  diffusion config set artifact_sources.0.vault_kv_version 1
+  diffusion config migrate`,
+	}
+
+	configCmd.AddCommand(newConfigGetCmd())
+	configCmd.AddCommand(newConfigSetCmd())
+	configCmd.AddCommand(newConfigMigrateCmd())
+
+	return configCmd
+}
+
+// newConfigGetCmd creates the get subcommand
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a config key (sections are printed as TOML)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			value, err := config.GetValue(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+// newConfigSetCmd creates the set subcommand
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a config key (lists are comma-separated)",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if err := config.SetValue(cfg, args[0], args[1]); err != nil {
+				return err
+			}
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\033[32mSet %s = %s\033[0m\n", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+// newConfigMigrateCmd creates the migrate subcommand
+func newConfigMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Rewrite diffusion.toml at the current config_version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notes, err := config.MigrateConfigFile()
+			if err != nil {
+				return fmt.Errorf("failed to migrate config: %w", err)
+			}
+			if len(notes) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Config is already at config_version %d\n", config.CurrentConfigVersion)
+				return nil
+			}
+			for _, note := range notes {
+				fmt.Fprintf(cmd.OutOrStdout(), "\033[32mMigrated: %s\033[0m\n", note)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestConfigGetSetCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(&config.Config{
+		ContainerRegistry: &config.ContainerRegistry{RegistryServer: "ghcr.io", RegistryProvider: "Public"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := NewConfigCmd(&CLI{})
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("set", "container_registry.registry_server", "cr.example.com"); err != nil {
+		t.Fatalf("config set error = %v", err)
+	}
+	out, err := run("get", "container_registry.registry_server")
+	if err != nil {
+		t.Fatalf("config get error = %v", err)
+	}
+	if strings.TrimSpace(out) != "cr.example.com" {
+		t.Errorf("config get = %q, want cr.example.com", out)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ContainerRegistry.RegistryServer != "cr.example.com" || cfg.ContainerRegistry.RegistryProvider != "Public" {
+		t.Errorf("unexpected saved registry %+v", cfg.ContainerRegistry)
+	}
+
+	if _, err := run("set", "container_registry.registry_provider", "Docker"); err == nil {
+		t.Error("expected invalid provider to be rejected")
+	}
+	if _, err := run("get", "container_registry.nope"); err == nil {
+		t.Error("expected unknown key error")
+	}
+}
+
+func TestConfigMigrateCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	legacy := "url = \"https://artifacts.example.com\"\n\n[tests]\ntype = \"local\"\n"
+	if err := os.WriteFile("diffusion.toml", []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func() string {
+		var out bytes.Buffer
+		cmd := NewConfigCmd(&CLI{})
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs([]string{"migrate"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("config migrate error = %v", err)
+		}
+		return out.String()
+	}
+
+	if out := run(); !strings.Contains(out, "Migrated") {
+		t.Errorf("first migrate output = %q", out)
+	}
+	data, err := os.ReadFile("diffusion.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "config_version = 1") {
+		t.Errorf("file not migrated:\n%s", data)
+	}
+	if out := run(); !strings.Contains(out, "already at config_version") {
+		t.Errorf("second migrate output = %q", out)
+	}
+}
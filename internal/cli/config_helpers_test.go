@@ -204,3 +204,28 @@ func TestMixedArtifactSources(t *testing.T) {
 		t.Errorf("expected 2 vault sources, got %d", vaultCount)
 	}
 }
+
+// TestParseVaultKVVersion tests parsing of the KV engine version prompt answer
+func TestParseVaultKVVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"", 2, false},
+		{"2\n", 2, false},
+		{"1\n", 1, false},
+		{" v1 ", 1, false},
+		{"3", 2, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseVaultKVVersion(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseVaultKVVersion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseVaultKVVersion(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
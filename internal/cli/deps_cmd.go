@@ -1,13 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"diffusion/internal/config"
 	"diffusion/internal/dependency"
+	"diffusion/internal/galaxy"
+	"diffusion/internal/molecule"
 	"diffusion/internal/role"
 	"diffusion/internal/utils"
 
@@ -28,26 +32,84 @@ Generates diffusion.lock file and updates pyproject.toml for the molecule contai
 	depsCmd.AddCommand(newDepsResolveCmd())
 	depsCmd.AddCommand(newDepsInitCmd())
 	depsCmd.AddCommand(newDepsSyncCmd())
+	depsCmd.AddCommand(newDepsAddCmd())
+	depsCmd.AddCommand(newDepsRemoveCmd())
 
 	return depsCmd
 }
 
 // newDepsLockCmd creates the lock subcommand
 func newDepsLockCmd() *cobra.Command {
-	return &cobra.Command{
+	var dryRun, frozen, strict, updateAll bool
+	var constraints, emitReview, platform string
+	var update []string
+	var threads int
+
+	cmd := &cobra.Command{
 		Use:   "lock",
 		Short: "Generate or update diffusion.lock file",
 		Long: `Generate or update the diffusion.lock file based on current dependencies
-from meta/main.yml, requirements.yml, and diffusion.toml configuration.`,
+from meta/main.yml, requirements.yml, and diffusion.toml configuration.
+
+With --update <name> only the named collections, roles or tools are resolved
+again; every other dependency keeps its locked version.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			fmt.Println("Generating lock file...")
-			if err := dependency.UpdateLockFile(); err != nil {
+			quiet := utils.IsQuiet()
+			if frozen && emitReview != "" {
+				return fmt.Errorf("--frozen does not write files and cannot be combined with --emit-review")
+			}
+			if threads < 1 {
+				return fmt.Errorf("--threads must be at least 1, got %d", threads)
+			}
+			if platform != "" {
+				normalized, err := galaxy.NormalizePlatform(platform)
+				if err != nil {
+					return err
+				}
+				platform = normalized
+			}
+			if !quiet && !frozen {
+				fmt.Println("Generating lock file...")
+			}
+			opts := &dependency.LockOptions{Quiet: quiet, TTY: molecule.StdoutIsTerminal(), DryRun: dryRun, Frozen: frozen, Constraints: constraints, EmitReview: emitReview, Concurrency: threads, Strict: strict, Platform: platform, Update: update}
+			if err := dependency.UpdateLockFileWithOptions(opts); err != nil {
+				if frozen || errors.Is(err, dependency.ErrMovingRefs) {
+					return err
+				}
 				return fmt.Errorf("failed to update lock file: %w", err)
 			}
+			if frozen {
+				fmt.Printf("\033[32m%s matches the resolved dependencies\033[0m\n", config.LockFileName)
+				return nil
+			}
+			if dryRun {
+				fmt.Printf("\033[33mDry run: %s was not modified\033[0m\n", config.LockFileName)
+				return nil
+			}
 			fmt.Printf("\033[32m%s\033[0m\n", config.MsgLockFileGenerated)
+			if emitReview != "" {
+				fmt.Printf("\033[32mReview file written to %s\033[0m\n", emitReview)
+			}
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "resolve and print versions and hash without writing the lock file")
+	cmd.Flags().BoolVar(&frozen, "frozen", false, "resolve in memory and fail if the result differs from diffusion.lock (writes nothing)")
+	cmd.Flags().BoolVar(&frozen, "check", false, "alias for --frozen")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "frozen")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "check")
+	cmd.Flags().StringVar(&constraints, "constraints", "", "path or URL of a shared constraints file with org floor versions (overrides dependencies.constraints)")
+	cmd.Flags().IntVar(&threads, "threads", dependency.DefaultResolveConcurrency(), fmt.Sprintf("number of parallel Galaxy/PyPI/git lookups; defaults to the CPU count, at most %d", dependency.MaxDefaultResolveConcurrency))
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail when a role resolves to a moving ref (a branch such as main) instead of a tag or commit SHA")
+	cmd.Flags().StringVar(&platform, "platform", "", "resolve Python packages to releases with wheels for this platform (linux/amd64 or linux/arm64; bare amd64/arm64 also work) and record it in the lock file")
+	cmd.Flags().StringVar(&platform, "arch", "", "alias for --platform")
+	cmd.Flags().StringArrayVar(&update, "update", nil, "re-resolve only this collection (namespace.name), role or tool to its latest matching version and keep every other locked version (repeatable)")
+	cmd.Flags().BoolVar(&updateAll, "update-all", false, "re-resolve every dependency (the default)")
+	cmd.MarkFlagsMutuallyExclusive("update", "update-all")
+	cmd.Flags().StringVar(&emitReview, "emit-review", "", "also write a flat, sorted YAML of resolved versions to this file for PR review (e.g. deps-review.yaml)")
+
+	return cmd
 }
 
 // newDepsCheckCmd creates the check subcommand
@@ -392,10 +454,15 @@ func newDepsInitCmd() *cobra.Command {
 
 // newDepsSyncCmd creates the sync subcommand
 func newDepsSyncCmd() *cobra.Command {
-	return &cobra.Command{
+	var dryRun bool
+
+	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Sync dependencies from lock file to requirements.yml and meta.yml",
-		Long:  `Restore dependency versions from diffusion.lock to requirements.yml and meta.yml. Useful for rollback scenarios.`,
+		Long: `Restore dependency versions from diffusion.lock to requirements.yml and meta.yml. Useful for rollback scenarios.
+
+With --dry-run nothing is written: the changes to every file are printed as a
+unified diff, and the command exits 0 whether or not files would change.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Load lock file
 			lockFile, err := dependency.LoadLockFile()
@@ -431,31 +498,12 @@ func newDepsSyncCmd() *cobra.Command {
 				}
 
 				// Sync collections to requirements.yml (structured format with resolved versions)
-				// Collections are scenario-prefixed in lock file (e.g., "default.general")
+				// Collections are scenario-prefixed in lock file (e.g., "default.general");
+				// another scenario's collections never reach this one
 				fmt.Printf("Syncing collections to requirements.yml for %s...\n", scenario)
-				req.Collections = []role.RequirementCollection{}
-				colPrefix := scenario + "."
-				for _, col := range lockFile.Collections {
-					if !strings.HasPrefix(col.Name, colPrefix) {
-						continue
-					}
-					// Strip scenario prefix from collection name
-					colName := strings.TrimPrefix(col.Name, colPrefix)
-					// Reconstruct namespace.name format for YAML output
-					yamlName := colName
-					if col.Namespace != "" {
-						yamlName = col.Namespace + "." + colName
-					}
-
-					version := col.ResolvedVersion
-					if version == "" {
-						version = col.Version
-					}
-					req.Collections = append(req.Collections, role.RequirementCollection{
-						Name:    yamlName,
-						Version: version,
-					})
-					fmt.Printf("  + %s: %s\n", yamlName, version)
+				req.Collections = dependency.ScenarioCollections(lockFile, scenario)
+				for _, col := range req.Collections {
+					fmt.Printf("  + %s: %s\n", col.Name, col.Version)
 				}
 
 				// Sync roles to requirements.yml
@@ -471,31 +519,15 @@ func newDepsSyncCmd() *cobra.Command {
 				}
 				// Now add the filtered roles to requirements.yml
 				for _, lockRole := range scenarioRoles {
-					version := lockRole.ResolvedVersion
-					if version == "" {
-						version = lockRole.Version
-					}
-					// If still no version, default to "main"
-					if version == "" || version == "latest" {
-						version = "main"
-					}
-
-					// Remove scenario prefix from role name
-					roleName := strings.TrimPrefix(lockRole.Name, rolePrefix)
-
-					// Reconstruct namespace.rolename for YAML output (Galaxy roles)
-					yamlRoleName := roleName
-					if lockRole.Namespace != "" && lockRole.Src == "" {
-						yamlRoleName = lockRole.Namespace + "." + roleName
+					reqRole := requirementRoleFromLock(lockRole, scenario)
+					req.Roles = append(req.Roles, reqRole)
+					fmt.Printf("  + %s: %s\n", reqRole.Name, reqRole.Version)
+				}
+				if dryRun {
+					if err := printSyncDiff(cmd.OutOrStdout(), role.RequirementFilePath(scenario), req); err != nil {
+						return err
 					}
-
-					req.Roles = append(req.Roles, role.RequirementRole{
-						Name:    yamlRoleName,
-						Version: version,
-						Src:     lockRole.Src,    // Restore git URL
-						Scm:     lockRole.Source, // Restore SCM type
-					})
-					fmt.Printf("  + %s: %s\n", yamlRoleName, version)
+					continue
 				}
 				// Save requirements.yml
 				if err := role.SaveRequirementFile(req, scenario); err != nil {
@@ -515,21 +547,18 @@ func newDepsSyncCmd() *cobra.Command {
 			// Sync collections to meta.yml (simple string format - namespace.name, no versions)
 			fmt.Println("Syncing collections to meta.yml (default scenario only)...")
 			meta.Collections = []string{}
-			defaultColPrefix := "default."
-			for _, col := range lockFile.Collections {
-				if !strings.HasPrefix(col.Name, defaultColPrefix) {
-					continue // Only default scenario collections go into meta.yml
-				}
-				// Strip scenario prefix and reconstruct namespace.name format
-				colName := strings.TrimPrefix(col.Name, defaultColPrefix)
-				metaName := colName
-				if col.Namespace != "" {
-					metaName = col.Namespace + "." + colName
-				}
-				meta.Collections = append(meta.Collections, metaName)
-				fmt.Printf("  + %s\n", metaName)
+			for _, name := range dependency.ScenarioCollectionNames(lockFile, config.DefaultScenario) {
+				meta.Collections = append(meta.Collections, name)
+				fmt.Printf("  + %s\n", name)
 			}
 
+			if dryRun {
+				if err := printSyncDiff(cmd.OutOrStdout(), config.MetaFilePath, meta); err != nil {
+					return err
+				}
+				fmt.Printf("\033[33mDry run: no files were written\033[0m\n")
+				return nil
+			}
 			// Save meta.yml
 			if err := role.SaveMetaFile(meta); err != nil {
 				return fmt.Errorf("failed to save meta.yml: %w", err)
@@ -540,4 +569,78 @@ func newDepsSyncCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print a unified diff of requirements.yml and meta.yml changes without writing them")
+
+	return cmd
+}
+
+// requirementRoleFromLock converts a locked role of the scenario into its
+// requirements.yml entry. Git roles keep their src and scm; Galaxy roles are
+// written as namespace.name with a version only, since ansible-galaxy treats an
+// entry without src as a Galaxy role and rejects "scm: galaxy".
+func requirementRoleFromLock(lockRole dependency.LockFileEntry, scenario string) role.RequirementRole {
+	version := lockRole.ResolvedVersion
+	if version == "" {
+		version = lockRole.Version
+	}
+	// Remove scenario prefix from role name
+	roleName := strings.TrimPrefix(lockRole.Name, scenario+".")
+
+	if lockRole.Src == "" {
+		if version == "" || version == "latest" {
+			version = ""
+		}
+		if lockRole.Namespace != "" {
+			roleName = lockRole.Namespace + "." + roleName
+		}
+		return role.RequirementRole{Name: roleName, Version: version}
+	}
+
+	// If still no version, default to "main"
+	if version == "" || version == "latest" {
+		version = "main"
+	}
+	return role.RequirementRole{
+		Name:    roleName,
+		Version: version,
+		Src:     lockRole.Src,    // Restore git URL
+		Scm:     lockRole.Source, // Restore SCM type
+	}
+}
+
+// printSyncDiff prints the diff between a file on disk and the content deps
+// sync would write for it (a *role.Requirement or *role.Meta)
+func printSyncDiff(w io.Writer, path string, content any) error {
+	var (
+		next []byte
+		err  error
+	)
+	switch v := content.(type) {
+	case *role.Requirement:
+		next, err = role.MarshalRequirementFile(v)
+	case *role.Meta:
+		next, err = role.MarshalMetaFile(v)
+	default:
+		return fmt.Errorf("unsupported sync content %T", content)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+
+	fromFile := "a/" + path
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fromFile = "/dev/null"
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	diff := utils.UnifiedDiff(fromFile, "b/"+path, string(current), string(next))
+	if diff == "" {
+		fmt.Fprintf(w, "  %s is up to date\n", path)
+		return nil
+	}
+	fmt.Fprint(w, diff)
+	return nil
 }
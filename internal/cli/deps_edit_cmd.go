@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/dependency"
+
+	"github.com/spf13/cobra"
+)
+
+// depsToolFields maps tool names accepted by deps add/remove tool to their DependencyConfig field
+var depsToolFields = map[string]func(dc *config.DependencyConfig) *string{
+	"ansible":      func(dc *config.DependencyConfig) *string { return &dc.Ansible },
+	"ansible-lint": func(dc *config.DependencyConfig) *string { return &dc.AnsibleLint },
+	"molecule":     func(dc *config.DependencyConfig) *string { return &dc.Molecule },
+	"yamllint":     func(dc *config.DependencyConfig) *string { return &dc.YamlLint },
+}
+
+// newDepsAddCmd creates the add subcommand
+func newDepsAddCmd() *cobra.Command {
+	var version, src, scenario, source, sourceURL string
+
+	cmd := &cobra.Command{
+		Use:   "add <collection|role|tool> <name>",
+		Short: "Add or update a dependency constraint in diffusion.toml",
+		Long: `Add a collection, role or tool constraint to the [dependencies] section of diffusion.toml.
+Re-adding an existing dependency updates it in place. Run 'diffusion deps lock' afterwards.
+
+Examples:
+  diffusion deps add collection community.general --version '>=7.0.0'
+  diffusion deps add collection acme.internal --source git --url https://git.example.com/acme/internal.git
+  diffusion deps add role geerlingguy.docker --version '>=7.0.0'
+  diffusion deps add role myrole --src https://github.com/org/myrole.git --version main
+  diffusion deps add tool molecule --version '>=24.0.0'`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dependency.ValidateConstraint(version); err != nil {
+				return err
+			}
+			return editDependencies(func(dc *config.DependencyConfig) (string, error) {
+				switch args[0] {
+				case "collection":
+					req, err := collectionRequirement(args[1], scenario, version)
+					if err != nil {
+						return "", err
+					}
+					if err := setCollectionSource(&req, source, sourceURL); err != nil {
+						return "", err
+					}
+					return upsertCollection(dc, req), nil
+				case "role":
+					req, err := roleRequirement(args[1], scenario, src, version)
+					if err != nil {
+						return "", err
+					}
+					return upsertRole(dc, req), nil
+				case "tool":
+					if version == "" {
+						return "", fmt.Errorf("--version is required for tools")
+					}
+					return setToolConstraint(dc, args[1], version)
+				default:
+					return "", fmt.Errorf("unknown dependency kind %q (expected collection, role or tool)", args[0])
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&version, "version", "", "version constraint (e.g. '>=7.0.0', '==1.2.3', 'main'; empty means latest)")
+	cmd.Flags().StringVar(&src, "src", "", "git URL of the role (roles only)")
+	cmd.Flags().StringVar(&source, "source", "", "where the collection is installed from: galaxy or git (collections only; default: keep the current source, else galaxy)")
+	cmd.Flags().StringVar(&sourceURL, "url", "", "git repository of the collection, required with --source git")
+	cmd.Flags().StringVarP(&scenario, "scenario", "s", config.DefaultScenario, "scenario the collection or role belongs to")
+
+	return cmd
+}
+
+// newDepsRemoveCmd creates the remove subcommand
+func newDepsRemoveCmd() *cobra.Command {
+	var scenario string
+
+	cmd := &cobra.Command{
+		Use:   "remove <collection|role|tool> <name>",
+		Short: "Remove a dependency constraint from diffusion.toml",
+		Long: `Remove a collection, role or tool constraint from the [dependencies] section of diffusion.toml.
+Removing a tool restores its default constraint. Run 'diffusion deps lock' afterwards.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editDependencies(func(dc *config.DependencyConfig) (string, error) {
+				switch args[0] {
+				case "collection":
+					req, err := collectionRequirement(args[1], scenario, "")
+					if err != nil {
+						return "", err
+					}
+					return removeCollection(dc, req.Name)
+				case "role":
+					return removeRole(dc, roleConfigName(args[1], scenario))
+				case "tool":
+					return setToolConstraint(dc, args[1], "")
+				default:
+					return "", fmt.Errorf("unknown dependency kind %q (expected collection, role or tool)", args[0])
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&scenario, "scenario", "s", config.DefaultScenario, "scenario the collection or role belongs to")
+
+	return cmd
+}
+
+// editDependencies loads diffusion.toml, applies edit to its dependency section and saves it
+func editDependencies(edit func(dc *config.DependencyConfig) (string, error)) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.DependencyConfig == nil {
+		cfg.DependencyConfig = &config.DependencyConfig{}
+	}
+
+	msg, err := edit(cfg.DependencyConfig)
+	if err != nil {
+		return err
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("\033[32m%s\033[0m\n", msg)
+	fmt.Println("\033[33mRun 'diffusion deps lock' to update diffusion.lock\033[0m")
+	return nil
+}
+
+// collectionRequirement builds the config entry for a namespace.name collection.
+// Collections are stored as <scenario>.<name> with the namespace kept separately.
+func collectionRequirement(fullName, scenario, version string) (config.CollectionRequirement, error) {
+	namespace, name, ok := strings.Cut(fullName, ".")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, ".") {
+		return config.CollectionRequirement{}, fmt.Errorf("collection name must be namespace.name, got %q", fullName)
+	}
+	return config.CollectionRequirement{
+		Name:      scenario + "." + name,
+		Namespace: namespace,
+		Version:   version,
+	}, nil
+}
+
+// setCollectionSource applies --source/--url to a collection entry. A git
+// collection needs the repository URL; Galaxy collections take no URL.
+func setCollectionSource(req *config.CollectionRequirement, source, sourceURL string) error {
+	switch source {
+	case "":
+		if sourceURL != "" {
+			return fmt.Errorf("--url needs --source git")
+		}
+	case "galaxy":
+		if sourceURL != "" {
+			return fmt.Errorf("--url is only used with --source git")
+		}
+		req.Source = source
+	case "git":
+		if sourceURL == "" {
+			return fmt.Errorf("--source git needs --url <repository>")
+		}
+		req.Source, req.SourceURL = source, sourceURL
+	default:
+		return fmt.Errorf("unknown collection source %q (expected galaxy or git)", source)
+	}
+	return nil
+}
+
+// roleRequirement builds the config entry for a role. Without --src the name must be
+// a Galaxy namespace.name; with --src it is a plain role name cloned via git.
+func roleRequirement(fullName, scenario, src, version string) (config.RoleRequirement, error) {
+	if src != "" {
+		if strings.Contains(fullName, ".") {
+			return config.RoleRequirement{}, fmt.Errorf("dots are not allowed in git role names (dots are reserved as scenario prefixes), got %q", fullName)
+		}
+		return config.RoleRequirement{Name: scenario + "." + fullName, Src: src, Scm: "git", Version: version}, nil
+	}
+
+	namespace, name, ok := strings.Cut(fullName, ".")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, ".") {
+		return config.RoleRequirement{}, fmt.Errorf("role name must be namespace.name, or a plain name with --src <git url>, got %q", fullName)
+	}
+	return config.RoleRequirement{Name: scenario + "." + name, Namespace: namespace, Scm: "galaxy", Version: version}, nil
+}
+
+// roleConfigName returns the <scenario>.<name> key of a role given as name or namespace.name
+func roleConfigName(fullName, scenario string) string {
+	if _, name, ok := strings.Cut(fullName, "."); ok {
+		return scenario + "." + name
+	}
+	return scenario + "." + fullName
+}
+
+// shortName strips the scenario prefix from a config entry name
+func shortName(configName string) string {
+	if _, name, ok := strings.Cut(configName, "."); ok {
+		return name
+	}
+	return configName
+}
+
+// upsertCollection adds req or replaces the existing entry with the same name
+func upsertCollection(dc *config.DependencyConfig, req config.CollectionRequirement) string {
+	label := strings.TrimSpace(fmt.Sprintf("%s.%s %s", req.Namespace, shortName(req.Name), req.Version))
+	for i, existing := range dc.Collections {
+		if existing.Name == req.Name {
+			// Without --source the collection keeps where it is installed from
+			if req.Source == "" {
+				req.Source, req.SourceURL = existing.Source, existing.SourceURL
+			}
+			dc.Collections[i] = req
+			return "Updated collection " + label
+		}
+	}
+	dc.Collections = append(dc.Collections, req)
+	return "Added collection " + label
+}
+
+// upsertRole adds req or replaces the existing entry with the same name
+func upsertRole(dc *config.DependencyConfig, req config.RoleRequirement) string {
+	label := strings.TrimSpace(req.Name + " " + req.Version)
+	for i, existing := range dc.Roles {
+		if existing.Name == req.Name {
+			dc.Roles[i] = req
+			return "Updated role " + label
+		}
+	}
+	dc.Roles = append(dc.Roles, req)
+	return "Added role " + label
+}
+
+// removeCollection deletes the collection with the given config name
+func removeCollection(dc *config.DependencyConfig, name string) (string, error) {
+	for i, existing := range dc.Collections {
+		if existing.Name == name {
+			dc.Collections = append(dc.Collections[:i], dc.Collections[i+1:]...)
+			return fmt.Sprintf("Removed collection %s.%s", existing.Namespace, shortName(name)), nil
+		}
+	}
+	return "", fmt.Errorf("collection %s not found in diffusion.toml", name)
+}
+
+// removeRole deletes the role with the given config name
+func removeRole(dc *config.DependencyConfig, name string) (string, error) {
+	for i, existing := range dc.Roles {
+		if existing.Name == name {
+			dc.Roles = append(dc.Roles[:i], dc.Roles[i+1:]...)
+			return "Removed role " + name, nil
+		}
+	}
+	return "", fmt.Errorf("role %s not found in diffusion.toml", name)
+}
+
+// setToolConstraint sets a tool constraint; an empty version removes it so the default applies
+func setToolConstraint(dc *config.DependencyConfig, tool, version string) (string, error) {
+	field, ok := depsToolFields[tool]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q (expected ansible, ansible-lint, molecule or yamllint)", tool)
+	}
+	ptr := field(dc)
+	if version == "" {
+		if *ptr == "" {
+			return "", fmt.Errorf("tool %s has no constraint in diffusion.toml", tool)
+		}
+		*ptr = ""
+		return fmt.Sprintf("Removed %s constraint (default will be used)", tool), nil
+	}
+	*ptr = version
+	return fmt.Sprintf("Set %s %s", tool, version), nil
+}
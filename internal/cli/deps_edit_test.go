@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestDepsAddRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cmdArgs ...string) error {
+		cmd := NewDepsCmd(&CLI{})
+		cmd.SetArgs(cmdArgs)
+		return cmd.Execute()
+	}
+
+	steps := [][]string{
+		{"add", "collection", "community.general", "--version", ">=7.0.0"},
+		{"add", "collection", "community.general", "--version", ">=8.0.0"}, // re-add updates
+		{"add", "role", "geerlingguy.docker", "--version", ">=7.0.0"},
+		{"add", "role", "myrole", "--src", "https://github.com/org/myrole.git", "--version", "main", "-s", "ha"},
+		{"add", "tool", "molecule", "--version", ">=24.0.0"},
+	}
+	for _, args := range steps {
+		if err := run(args...); err != nil {
+			t.Fatalf("deps %v: %v", args, err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := cfg.DependencyConfig
+	if len(dc.Collections) != 1 || dc.Collections[0] != (config.CollectionRequirement{Name: "default.general", Namespace: "community", Version: ">=8.0.0"}) {
+		t.Errorf("unexpected collections %+v", dc.Collections)
+	}
+	if len(dc.Roles) != 2 {
+		t.Fatalf("expected 2 roles, got %+v", dc.Roles)
+	}
+	if dc.Roles[0] != (config.RoleRequirement{Name: "default.docker", Namespace: "geerlingguy", Scm: "galaxy", Version: ">=7.0.0"}) {
+		t.Errorf("unexpected galaxy role %+v", dc.Roles[0])
+	}
+	if dc.Roles[1] != (config.RoleRequirement{Name: "ha.myrole", Src: "https://github.com/org/myrole.git", Scm: "git", Version: "main"}) {
+		t.Errorf("unexpected git role %+v", dc.Roles[1])
+	}
+	if dc.Molecule != ">=24.0.0" {
+		t.Errorf("Molecule = %q", dc.Molecule)
+	}
+
+	for _, args := range [][]string{
+		{"remove", "collection", "community.general"},
+		{"remove", "role", "myrole", "-s", "ha"},
+		{"remove", "tool", "molecule"},
+	} {
+		if err := run(args...); err != nil {
+			t.Fatalf("deps %v: %v", args, err)
+		}
+	}
+
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc = cfg.DependencyConfig
+	if len(dc.Collections) != 0 || len(dc.Roles) != 1 || dc.Roles[0].Name != "default.docker" || dc.Molecule != "" {
+		t.Errorf("unexpected config after remove: %+v", dc)
+	}
+
+	if err := run("remove", "collection", "community.general"); err == nil {
+		t.Error("expected error removing a missing collection")
+	}
+}
+
+func TestDepsAddValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"bad constraint", []string{"add", "collection", "community.general", "--version", ">=abc"}},
+		{"collection without namespace", []string{"add", "collection", "general"}},
+		{"galaxy role without namespace", []string{"add", "role", "docker"}},
+		{"git role with dots", []string{"add", "role", "org.role", "--src", "https://example.com/r.git"}},
+		{"unknown tool", []string{"add", "tool", "pip", "--version", "1.0"}},
+		{"tool without version", []string{"add", "tool", "ansible"}},
+		{"unknown kind", []string{"add", "plugin", "x"}},
+		{"git collection without url", []string{"add", "collection", "acme.internal", "--source", "git"}},
+		{"url without git source", []string{"add", "collection", "acme.internal", "--url", "https://git.example.com/acme/internal.git"}},
+		{"unknown collection source", []string{"add", "collection", "acme.internal", "--source", "svn"}},
+	}
+
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewDepsCmd(&CLI{})
+			cmd.SetArgs(tt.args)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			if err := cmd.Execute(); err == nil {
+				t.Errorf("expected error for %v", tt.args)
+			}
+		})
+	}
+}
+
+func TestDepsAddGitCollection(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := config.SaveConfig(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cmdArgs ...string) {
+		t.Helper()
+		cmd := NewDepsCmd(&CLI{})
+		cmd.SetArgs(cmdArgs)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("deps %v: %v", cmdArgs, err)
+		}
+	}
+	const repo = "https://git.example.com/acme/internal.git"
+	run("add", "collection", "acme.internal", "--source", "git", "--url", repo, "--version", "v1.2.0")
+	// Re-adding without --source only changes the version
+	run("add", "collection", "acme.internal", "--version", "v1.3.0")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.CollectionRequirement{Name: "default.internal", Namespace: "acme", Version: "v1.3.0", Source: "git", SourceURL: repo}
+	if cols := cfg.DependencyConfig.Collections; len(cols) != 1 || cols[0] != want {
+		t.Errorf("collections = %+v, want %+v", cols, want)
+	}
+}
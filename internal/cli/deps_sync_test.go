@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -327,3 +328,218 @@ roles: []
 		t.Errorf("Expected role scm 'git', got %q", role.Scm)
 	}
 }
+
+func TestDepsSyncKeepsScenarioCollectionsApart(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"meta", "scenarios/default", "scenarios/cloud"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"meta/main.yml":                      "galaxy_info:\n  role_name: web\n  namespace: acme\n",
+		"scenarios/default/requirements.yml": "collections: []\n",
+		"scenarios/cloud/requirements.yml":   "collections: []\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lockFile := &dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Collections: []dependency.LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.4.0", ResolvedVersion: "9.1.0", Type: "collection"},
+			{Name: "cloud.aws", Namespace: "amazon", Version: ">=7.0.0", ResolvedVersion: "8.2.0", Type: "collection"},
+		},
+	}
+	if err := dependency.SaveLockFile(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newDepsSyncCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("deps sync error: %v", err)
+	}
+
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.FromSlash(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	defaultReq := read("scenarios/default/requirements.yml")
+	if strings.Contains(defaultReq, "amazon.aws") {
+		t.Errorf("cloud-only collection leaked into default requirements.yml:\n%s", defaultReq)
+	}
+	if !strings.Contains(defaultReq, "community.general") {
+		t.Errorf("default requirements.yml misses community.general:\n%s", defaultReq)
+	}
+	cloudReq := read("scenarios/cloud/requirements.yml")
+	if !strings.Contains(cloudReq, "amazon.aws") || strings.Contains(cloudReq, "community.general") {
+		t.Errorf("unexpected cloud requirements.yml:\n%s", cloudReq)
+	}
+	if meta := read("meta/main.yml"); strings.Contains(meta, "amazon.aws") {
+		t.Errorf("cloud-only collection leaked into meta/main.yml:\n%s", meta)
+	}
+}
+
+func TestDepsSyncDryRunPrintsDiffWithoutWriting(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"meta", "scenarios/default"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta := "galaxy_info:\n  role_name: web\n  namespace: acme\n"
+	req := "---\ncollections:\n    - name: community.general\n      version: 7.4.0\n"
+	if err := os.WriteFile("meta/main.yml", []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("scenarios/default/requirements.yml", []byte(req), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lockFile := &dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Collections: []dependency.LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.4.0", ResolvedVersion: "9.1.0", Type: "collection"},
+		},
+	}
+	if err := dependency.SaveLockFile(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := newDepsSyncCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--dry-run"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("deps sync --dry-run error: %v", err)
+	}
+
+	for _, want := range []string{
+		"--- a/scenarios/default/requirements.yml\n+++ b/scenarios/default/requirements.yml\n",
+		"-      version: 7.4.0\n+      version: 9.1.0\n",
+		"--- a/meta/main.yml\n+++ b/meta/main.yml\n",
+		"+collections:\n+    - community.general\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry-run output misses %q:\n%s", want, out.String())
+		}
+	}
+
+	// Nothing is written
+	if data, _ := os.ReadFile("scenarios/default/requirements.yml"); string(data) != req {
+		t.Errorf("requirements.yml changed in dry run:\n%s", data)
+	}
+	if data, _ := os.ReadFile("meta/main.yml"); string(data) != meta {
+		t.Errorf("meta/main.yml changed in dry run:\n%s", data)
+	}
+}
+
+func TestDepsSyncWritesGalaxyRoleWithoutSrc(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"meta", "scenarios/default"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile("meta/main.yml", []byte("galaxy_info:\n  role_name: web\n  namespace: acme\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("scenarios/default/requirements.yml", []byte("collections: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lockFile := &dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Roles: []dependency.LockFileEntry{
+			{Name: "default.docker", Namespace: "geerlingguy", Version: ">=7.4.0", ResolvedVersion: "7.4.1", Type: "role", Source: "galaxy"},
+			{Name: "default.nginx", Version: "main", ResolvedVersion: "v1.2.0", Type: "role", Src: "https://github.com/acme/nginx.git", Source: "git"},
+		},
+	}
+	if err := dependency.SaveLockFile(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newDepsSyncCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("deps sync error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.FromSlash("scenarios/default/requirements.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := string(data)
+	if !strings.Contains(req, "    - name: geerlingguy.docker\n      version: 7.4.1\n") {
+		t.Errorf("galaxy role not written as namespace.name with its version:\n%s", req)
+	}
+	if strings.Contains(req, "scm: galaxy") {
+		t.Errorf("galaxy role written with scm: galaxy:\n%s", req)
+	}
+	if !strings.Contains(req, "src: https://github.com/acme/nginx.git") || !strings.Contains(req, "scm: git") {
+		t.Errorf("git role lost its src/scm:\n%s", req)
+	}
+}
+
+func TestDepsSyncWritesGitCollection(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"meta", "scenarios/default"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"meta/main.yml":                      "galaxy_info:\n  role_name: web\n  namespace: acme\n",
+		"scenarios/default/requirements.yml": "collections: []\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lockFile := &dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Collections: []dependency.LockFileEntry{
+			{Name: "default.internal", Namespace: "acme", Version: "latest", ResolvedVersion: "v1.2.0", Type: "collection", Source: "git", Src: "https://git.example.com/acme/internal.git"},
+		},
+	}
+	if err := dependency.SaveLockFile(lockFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newDepsSyncCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("deps sync error: %v", err)
+	}
+
+	req, err := os.ReadFile(role.RequirementFilePath("default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\ncollections:\n    - name: https://git.example.com/acme/internal.git\n      type: git\n      version: v1.2.0\n"
+	if string(req) != want {
+		t.Errorf("requirements.yml =\n%s\nwant\n%s", req, want)
+	}
+	meta, err := os.ReadFile("meta/main.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(meta), "- acme.internal") {
+		t.Errorf("meta/main.yml should list the collection by name:\n%s", meta)
+	}
+	inSync, err := dependency.CheckLockFileStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inSync {
+		t.Error("CheckLockFileStatus() = false right after deps sync")
+	}
+}
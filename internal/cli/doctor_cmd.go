@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// doctorCheck is a single environment check run by the doctor command
+type doctorCheck struct {
+	Name     string
+	Required bool
+	Run      func() (string, error) // Returns a short detail (e.g. version) on success
+}
+
+// NewDoctorCmd creates the doctor command
+func NewDoctorCmd(cli *CLI) *cobra.Command {
+	return &cobra.Command{
+		Use:          "doctor",
+		Short:        "Check required external tools and environment",
+		SilenceUsage: true, // A failed check is a result, not a usage error
+		Long: `Check that the external tools diffusion shells out to are installed and usable.
+The required set depends on diffusion.toml: the registry provider CLI is checked
+for YC/AWS/GCP registries, and VAULT_ADDR/VAULT_TOKEN for Vault-enabled configs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				fmt.Printf("\033[33mNo usable diffusion.toml (%v); checking base tools only\033[0m\n", err)
+				cfg = nil
+			}
+
+			fmt.Println("\033[35m[Environment]\033[0m")
+			if failed := runDoctorChecks(os.Stdout, doctorChecks(cfg)); failed > 0 {
+				return fmt.Errorf("%d required check(s) failed", failed)
+			}
+			fmt.Printf("\033[32mAll required checks passed\033[0m\n")
+			return nil
+		},
+	}
+}
+
+// doctorChecks builds the list of checks for the given configuration.
+// A nil config yields only the checks that every command needs.
+func doctorChecks(cfg *config.Config) []doctorCheck {
+	checks := []doctorCheck{
+		binaryCheck("docker", true, "--version"),
+		{Name: "docker daemon", Required: true, Run: func() (string, error) {
+			return runDoctorCommand("docker", "info", "--format", "{{.ServerVersion}}")
+		}},
+		binaryCheck("git", true, "--version"),
+		{Name: "/sys/fs/cgroup", Required: false, Run: checkCgroupMount},
+	}
+
+	if cfg == nil {
+		return checks
+	}
+
+	if cfg.ContainerRegistry != nil {
+		switch cfg.ContainerRegistry.RegistryProvider {
+		case config.RegistryProviderYC:
+			checks = append(checks, binaryCheck("yc", true, "--version"))
+		case config.RegistryProviderAWS:
+			checks = append(checks, binaryCheck("aws", true, "--version"))
+		case config.RegistryProviderGCP:
+			checks = append(checks, binaryCheck("gcloud", true, "--version"))
+		case config.RegistryProviderBasic:
+			if cfg.ContainerRegistry.PasswordEnv != "" {
+				checks = append(checks, envCheck(cfg.ContainerRegistry.PasswordEnv, true))
+			}
+		}
+	}
+
+	if vaultEnabled(cfg) {
+		checks = append(checks,
+			envCheck(config.EnvVaultAddr, true),
+			envCheck(config.EnvVaultToken, true),
+		)
+	}
+
+	return checks
+}
+
+// vaultEnabled reports whether any part of the config reads secrets from Vault
+func vaultEnabled(cfg *config.Config) bool {
+	if cfg.HashicorpVault != nil && cfg.HashicorpVault.HashicorpVaultIntegration {
+		return true
+	}
+	for _, source := range cfg.ArtifactSources {
+		if source.UseVault {
+			return true
+		}
+	}
+	return false
+}
+
+// binaryCheck verifies that name is on PATH and reports its version output
+func binaryCheck(name string, required bool, versionArgs ...string) doctorCheck {
+	return doctorCheck{
+		Name:     name,
+		Required: required,
+		Run: func() (string, error) {
+			if _, err := exec.LookPath(name); err != nil {
+				return "", fmt.Errorf("not found in PATH")
+			}
+			return runDoctorCommand(name, versionArgs...)
+		},
+	}
+}
+
+// envCheck verifies that an environment variable is set
+func envCheck(name string, required bool) doctorCheck {
+	return doctorCheck{
+		Name:     name,
+		Required: required,
+		Run: func() (string, error) {
+			if os.Getenv(name) == "" {
+				return "", fmt.Errorf("not set")
+			}
+			return "set", nil
+		},
+	}
+}
+
+// checkCgroupMount verifies the cgroup filesystem is available on the host
+func checkCgroupMount() (string, error) {
+	info, err := os.Stat("/sys/fs/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("not available")
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory")
+	}
+	return "available", nil
+}
+
+// runDoctorCommand runs a command with a short timeout and returns the first line of its output
+func runDoctorCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := utils.RunCommandCapture(ctx, name, args...)
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %v", name, strings.Join(args, " "), err)
+	}
+	first, _, _ := strings.Cut(out, "\n")
+	return first, nil
+}
+
+// runDoctorChecks prints a ✓/✗ checklist to w and returns the number of failed required checks
+func runDoctorChecks(w io.Writer, checks []doctorCheck) int {
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.Run()
+		switch {
+		case err == nil:
+			fmt.Fprintf(w, "  \033[32m✓\033[0m %s - %s\n", check.Name, detail)
+		case check.Required:
+			failed++
+			fmt.Fprintf(w, "  \033[31m✗\033[0m %s (%v)\n", check.Name, err)
+		default:
+			fmt.Fprintf(w, "  \033[33m✗\033[0m %s (%v, optional)\n", check.Name, err)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func doctorCheckNames(checks []doctorCheck) []string {
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	return names
+}
+
+func TestDoctorChecksDependOnConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "no config",
+			cfg:     nil,
+			want:    []string{"docker", "docker daemon", "git"},
+			notWant: []string{"yc", "aws", "gcloud", config.EnvVaultAddr},
+		},
+		{
+			name: "yc registry",
+			cfg: &config.Config{
+				ContainerRegistry: &config.ContainerRegistry{RegistryProvider: config.RegistryProviderYC},
+			},
+			want:    []string{"docker", "git", "yc"},
+			notWant: []string{"aws", "gcloud"},
+		},
+		{
+			name: "aws registry with vault",
+			cfg: &config.Config{
+				ContainerRegistry: &config.ContainerRegistry{RegistryProvider: config.RegistryProviderAWS},
+				HashicorpVault:    &config.HashicorpVault{HashicorpVaultIntegration: true},
+			},
+			want:    []string{"aws", config.EnvVaultAddr, config.EnvVaultToken},
+			notWant: []string{"yc", "gcloud"},
+		},
+		{
+			name: "public registry with vault artifact source",
+			cfg: &config.Config{
+				ContainerRegistry: &config.ContainerRegistry{RegistryProvider: config.RegistryProviderPublic},
+				ArtifactSources:   []config.ArtifactSource{{Name: "private", UseVault: true}},
+			},
+			want:    []string{config.EnvVaultAddr, config.EnvVaultToken},
+			notWant: []string{"yc", "aws", "gcloud"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := strings.Join(doctorCheckNames(doctorChecks(tt.cfg)), ",")
+			for _, want := range tt.want {
+				if !strings.Contains(","+names+",", ","+want+",") {
+					t.Errorf("expected check %q in %s", want, names)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(","+names+",", ","+notWant+",") {
+					t.Errorf("unexpected check %q in %s", notWant, names)
+				}
+			}
+		})
+	}
+}
+
+func TestRunDoctorChecks(t *testing.T) {
+	checks := []doctorCheck{
+		{Name: "ok-tool", Required: true, Run: func() (string, error) { return "1.0", nil }},
+		{Name: "missing-tool", Required: true, Run: func() (string, error) { return "", errors.New("not found in PATH") }},
+		{Name: "optional-tool", Required: false, Run: func() (string, error) { return "", errors.New("not available") }},
+	}
+
+	var buf bytes.Buffer
+	failed := runDoctorChecks(&buf, checks)
+	if failed != 1 {
+		t.Errorf("runDoctorChecks() failed = %d, want 1", failed)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "✓\033[0m ok-tool - 1.0") {
+		t.Errorf("expected passing check in output:\n%s", out)
+	}
+	if !strings.Contains(out, "✗\033[0m missing-tool (not found in PATH)") {
+		t.Errorf("expected failing check in output:\n%s", out)
+	}
+	if !strings.Contains(out, "optional-tool (not available, optional)") {
+		t.Errorf("expected optional check in output:\n%s", out)
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+
+	"github.com/spf13/cobra"
+)
+
+// NewGalaxyCmd creates the galaxy command for private collection servers
+func NewGalaxyCmd(cli *CLI) *cobra.Command {
+	galaxyCmd := &cobra.Command{
+		Use:   "galaxy",
+		Short: "Manage the Automation Hub login used to install private collections",
+	}
+
+	galaxyCmd.AddCommand(newGalaxyLoginCmd())
+	galaxyCmd.AddCommand(newGalaxyLogoutCmd())
+
+	return galaxyCmd
+}
+
+func newGalaxyLoginCmd() *cobra.Command {
+	var (
+		serverURL string
+		authURL   string
+		tokenFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store an Automation Hub token for private collections",
+		Long: `Store the token of Red Hat Automation Hub or a private Automation Hub, encrypted
+with the same key as the artifact credentials (~/.diffusion/secrets/_galaxy). The
+login is shared by all roles. On 'diffusion molecule' runs the server is passed
+to ansible-galaxy as its first galaxy server, followed by galaxy.ansible.com, so
+collections the hub does not carry still resolve.
+
+The server URL comes from --url, ` + config.EnvGalaxyServer + ` or a prompt and defaults to
+console.redhat.com. The token comes from --token-file ("-" reads stdin),
+` + config.EnvGalaxyToken + ` or a prompt. The Red Hat SSO token endpoint is used as
+--auth-url for console.redhat.com; private hubs need none.`,
+		Example: `  diffusion galaxy login
+  diffusion galaxy login --url https://hub.example.com/api/galaxy/content/published/ --token-file ~/hub-token
+  echo "$HUB_TOKEN" | diffusion galaxy login --token-file -`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+			stdin := cmd.InOrStdin()
+			envToken := os.Getenv(config.EnvGalaxyToken)
+			interactive := tokenFile == "" && envToken == ""
+
+			if serverURL == "" {
+				serverURL = os.Getenv(config.EnvGalaxyServer)
+			}
+			reader := bufio.NewReader(stdin)
+			if serverURL == "" && interactive {
+				fmt.Fprintf(w, "Enter Automation Hub URL (default: %s): ", config.DefaultAutomationHubURL)
+				line, _ := reader.ReadString('\n')
+				serverURL = strings.TrimSpace(line)
+			}
+
+			var token string
+			if tokenFile == "" && envToken != "" {
+				token = strings.TrimSpace(envToken)
+			} else {
+				var err error
+				if token, err = readRotatedToken(tokenFile, reader, w, "Automation Hub"); err != nil {
+					return err
+				}
+			}
+
+			creds, err := galaxyLogin(serverURL, authURL, token)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\033[32mAutomation Hub login stored for %s: %s\033[0m\n", creds.URL, maskToken(creds.Token))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&serverURL, "url", "", "Automation Hub server URL (default: console.redhat.com)")
+	cmd.Flags().StringVar(&authURL, "auth-url", "", "SSO token endpoint of the server (default: Red Hat SSO for console.redhat.com)")
+	cmd.Flags().StringVar(&tokenFile, "token-file", "", `read the token from this file instead of prompting ("-" reads stdin)`)
+
+	return cmd
+}
+
+func newGalaxyLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the stored Automation Hub login",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := secrets.DeleteGalaxyCredentials(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "\033[32mAutomation Hub login removed\033[0m")
+			return nil
+		},
+	}
+}
+
+// galaxyLogin fills in the server defaults and stores the login encrypted
+func galaxyLogin(serverURL, authURL, token string) (*secrets.GalaxyCredentials, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("Automation Hub token is empty")
+	}
+	serverURL = strings.TrimSpace(serverURL)
+	if serverURL == "" {
+		serverURL = config.DefaultAutomationHubURL
+	}
+	if !strings.HasPrefix(serverURL, "https://") && !strings.HasPrefix(serverURL, "http://") {
+		return nil, fmt.Errorf("invalid Automation Hub URL %q: must start with https:// or http://", serverURL)
+	}
+	if !strings.HasSuffix(serverURL, "/") {
+		// ansible-galaxy joins API paths onto the server URL
+		serverURL += "/"
+	}
+	if authURL == "" && serverURL == config.DefaultAutomationHubURL {
+		authURL = config.DefaultAutomationHubAuthURL
+	}
+
+	creds := &secrets.GalaxyCredentials{URL: serverURL, AuthURL: authURL, Token: token}
+	if err := secrets.SaveGalaxyCredentials(creds); err != nil {
+		return nil, fmt.Errorf("failed to save Automation Hub login: %w", err)
+	}
+	return creds, nil
+}
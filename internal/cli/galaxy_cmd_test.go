@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+func TestGalaxyLoginDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	creds, err := galaxyLogin("", "", " token-123456\n")
+	if err != nil {
+		t.Fatalf("galaxyLogin failed: %v", err)
+	}
+	if creds.URL != config.DefaultAutomationHubURL || creds.AuthURL != config.DefaultAutomationHubAuthURL {
+		t.Errorf("unexpected defaults: %+v", creds)
+	}
+
+	stored, err := secrets.LoadGalaxyCredentials()
+	if err != nil || stored == nil {
+		t.Fatalf("login not stored: %v", err)
+	}
+	if stored.Token != "token-123456" {
+		t.Errorf("stored token = %q", stored.Token)
+	}
+}
+
+func TestGalaxyLoginPrivateHub(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	creds, err := galaxyLogin("https://hub.example.com/api/galaxy/content/published", "", "token")
+	if err != nil {
+		t.Fatalf("galaxyLogin failed: %v", err)
+	}
+	if creds.URL != "https://hub.example.com/api/galaxy/content/published/" {
+		t.Errorf("URL = %q, want a trailing slash", creds.URL)
+	}
+	if creds.AuthURL != "" {
+		t.Errorf("private hub got auth URL %q", creds.AuthURL)
+	}
+
+	if _, err := galaxyLogin("hub.example.com", "", "token"); err == nil {
+		t.Error("expected an error for a URL without scheme")
+	}
+	if _, err := galaxyLogin("", "", "  "); err == nil {
+		t.Error("expected an error for an empty token")
+	}
+}
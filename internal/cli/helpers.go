@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"diffusion/internal/secrets"
 )
 
 // PromptInput prompts the user for input and returns the trimmed response
@@ -15,10 +17,53 @@ func PromptInput(prompt string) string {
 	return strings.TrimSpace(val)
 }
 
-// maskToken masks a token for display, showing only first and last few characters
+// maskedTokenTail is how many trailing characters maskToken reveals, enough to
+// compare a token with the one shown by Vault or the registry UI
+const maskedTokenTail = 4
+
+// maskToken masks a token for display, showing only its last maskedTokenTail characters
 func maskToken(token string) string {
-	if len(token) <= 8 {
+	return maskTokenTail(token, maskedTokenTail)
+}
+
+// maskTokenTail replaces all but the last n characters of a token with a
+// fixed-width "****", so the output does not leak the token length. Tokens
+// shorter than n+2 characters are masked completely.
+func maskTokenTail(token string, n int) string {
+	if n < 0 || len(token) < n+2 {
 		return "****"
 	}
-	return token[:4] + "..." + token[len(token)-4:]
+	return "****" + token[len(token)-n:]
+}
+
+// promptVaultKVVersion asks for the KV secret engine version of a Vault mount.
+// Empty or invalid input falls back to KV v2.
+func promptVaultKVVersion(reader *bufio.Reader) int {
+	fmt.Print("Enter Vault KV engine version (1/2, default: 2): ")
+	input, _ := reader.ReadString('\n')
+	version, err := parseVaultKVVersion(input)
+	if err != nil {
+		fmt.Printf("\033[33m%v, using KV v2\033[0m\n", err)
+	}
+	return version
+}
+
+// parseVaultKVVersion parses a KV engine version answer, defaulting to 2
+func parseVaultKVVersion(input string) (int, error) {
+	switch strings.TrimSpace(input) {
+	case "", "2", "v2":
+		return secrets.KVVersion2, nil
+	case "1", "v1":
+		return secrets.KVVersion1, nil
+	default:
+		return secrets.KVVersion2, fmt.Errorf("invalid KV version %q", strings.TrimSpace(input))
+	}
+}
+
+// vaultPathExample returns a sample Vault path for the prompt of the given KV version
+func vaultPathExample(kvVersion int) string {
+	if kvVersion == secrets.KVVersion1 {
+		return "kv/artifacts"
+	}
+	return "secret/data/artifacts"
 }
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// initOptions holds the flag values of the init command
+type initOptions struct {
+	RegistryServer     string
+	RegistryProvider   string
+	RegistryUsername   string
+	PasswordEnv        string
+	PasswordCredential string
+	ContainerName      string
+	ContainerTag       string
+	TestsType          string
+	TestsRepos         []string
+	Vault              bool
+	Force              bool
+}
+
+// NewInitCmd creates the init command
+func NewInitCmd(cli *CLI) *cobra.Command {
+	opts := &initOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create diffusion.toml non-interactively from flags",
+		Long: `Write a diffusion.toml in the current directory (or at --config) without
+prompting, using the same defaults as the interactive setup of 'diffusion molecule'.
+An existing diffusion.toml is only replaced with --force.`,
+		Example: `  diffusion init
+  diffusion init --registry-provider YC --registry-server cr.yandex --container-name <registry-id>/diffusion
+  diffusion init --tests-type remote --tests-repo https://github.com/org/tests.git --vault`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := config.ConfigPath()
+			if err != nil {
+				return err
+			}
+			if !opts.Force {
+				if _, err := os.Stat(configPath); err == nil {
+					return fmt.Errorf("%s already exists; use --force to overwrite it", configPath)
+				}
+			}
+
+			cfg, err := buildInitConfig(opts)
+			if err != nil {
+				return err
+			}
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			fmt.Printf("\033[32m%s created\033[0m\n", configPath)
+			fmt.Printf("\033[35mRegistry:   \033[0m\033[38;2;127;255;212m%s (%s)\033[0m\n", cfg.ContainerRegistry.RegistryServer, cfg.ContainerRegistry.RegistryProvider)
+			fmt.Printf("\033[35mContainer:  \033[0m\033[38;2;127;255;212m%s:%s\033[0m\n", cfg.ContainerRegistry.MoleculeContainerName, cfg.ContainerRegistry.MoleculeContainerTag)
+			fmt.Printf("\033[35mTests type: \033[0m\033[38;2;127;255;212m%s\033[0m\n", cfg.TestsConfig.Type)
+			fmt.Printf("\033[35mVault:      \033[0m\033[38;2;127;255;212m%t\033[0m\n", opts.Vault)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RegistryServer, "registry-server", config.DefaultRegistryServer, "container registry server")
+	cmd.Flags().StringVar(&opts.RegistryProvider, "registry-provider", config.DefaultRegistryProvider, "registry provider (YC, AWS, GCP, Basic, Public)")
+	cmd.Flags().StringVar(&opts.RegistryUsername, "registry-username", "", "docker login username (Basic provider)")
+	cmd.Flags().StringVar(&opts.PasswordEnv, "registry-password-env", "", "environment variable holding the registry password (Basic provider)")
+	cmd.Flags().StringVar(&opts.PasswordCredential, "registry-password-credential", "", "stored credential holding the registry password (Basic provider)")
+	cmd.Flags().StringVar(&opts.ContainerName, "container-name", config.DefaultMoleculeContainerName, "molecule container image name")
+	cmd.Flags().StringVar(&opts.ContainerTag, "container-tag", utils.GetDefaultMoleculeTag(), "molecule container image tag")
+	cmd.Flags().StringVar(&opts.TestsType, "tests-type", config.TestsTypeDiffusion, "verify tests source (diffusion, local, remote)")
+	cmd.Flags().StringSliceVar(&opts.TestsRepos, "tests-repo", nil, "remote tests repository URL (repeatable or comma-separated; required for --tests-type remote)")
+	cmd.Flags().BoolVar(&opts.Vault, "vault", false, "enable HashiCorp Vault integration for artifact sources")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "overwrite an existing diffusion.toml")
+
+	return cmd
+}
+
+// buildInitConfig validates the init flags and assembles the new config
+func buildInitConfig(opts *initOptions) (*config.Config, error) {
+	provider := strings.TrimSpace(opts.RegistryProvider)
+	if err := config.ValidateValue("container_registry.registry_provider", provider); err != nil {
+		return nil, err
+	}
+	testsType := strings.ToLower(strings.TrimSpace(opts.TestsType))
+	if err := config.ValidateValue("tests.type", testsType); err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, repo := range opts.TestsRepos {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			repos = append(repos, repo)
+		}
+	}
+	if testsType == config.TestsTypeRemote && len(repos) == 0 {
+		return nil, fmt.Errorf("--tests-type remote requires at least one --tests-repo")
+	}
+
+	registry := &config.ContainerRegistry{
+		RegistryServer:        strings.TrimSpace(opts.RegistryServer),
+		RegistryProvider:      provider,
+		MoleculeContainerName: strings.TrimSpace(opts.ContainerName),
+		MoleculeContainerTag:  strings.TrimSpace(opts.ContainerTag),
+	}
+	if registry.RegistryServer == "" || registry.MoleculeContainerName == "" || registry.MoleculeContainerTag == "" {
+		return nil, fmt.Errorf("--registry-server, --container-name and --container-tag must not be empty")
+	}
+	if provider == config.RegistryProviderBasic {
+		registry.Username = strings.TrimSpace(opts.RegistryUsername)
+		registry.PasswordEnv = strings.TrimSpace(opts.PasswordEnv)
+		registry.PasswordCredential = strings.TrimSpace(opts.PasswordCredential)
+		if registry.Username == "" {
+			return nil, fmt.Errorf("--registry-provider Basic requires --registry-username")
+		}
+		if registry.PasswordEnv == "" && registry.PasswordCredential == "" {
+			registry.PasswordEnv = config.EnvRegistryPassword
+		}
+	}
+
+	return newConfig(registry, opts.Vault, nil, newTestsSettings(testsType, repos)), nil
+}
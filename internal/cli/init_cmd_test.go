@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+func TestInitCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) error {
+		cmd := NewInitCmd(&CLI{})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	if err := run(); err != nil {
+		t.Fatalf("init error = %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := &config.ContainerRegistry{
+		RegistryServer:        config.DefaultRegistryServer,
+		RegistryProvider:      config.DefaultRegistryProvider,
+		MoleculeContainerName: config.DefaultMoleculeContainerName,
+		MoleculeContainerTag:  utils.GetDefaultMoleculeTag(),
+	}
+	if !reflect.DeepEqual(cfg.ContainerRegistry, want) {
+		t.Errorf("registry = %+v, want %+v", cfg.ContainerRegistry, want)
+	}
+	if cfg.TestsConfig == nil || cfg.TestsConfig.Type != config.TestsTypeDiffusion {
+		t.Errorf("expected diffusion tests type, got %+v", cfg.TestsConfig)
+	}
+	if cfg.YamlLintConfig == nil || cfg.AnsibleLintConfig == nil {
+		t.Error("expected default yamllint and ansible-lint settings")
+	}
+
+	// An existing config is only replaced with --force
+	err = run("--registry-provider", "YC", "--registry-server", "cr.yandex")
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected refusal to overwrite, got %v", err)
+	}
+	if err := run("--force", "--registry-provider", "YC", "--registry-server", "cr.yandex",
+		"--tests-type", "remote", "--tests-repo", "https://example.com/a.git,https://example.com/b.git", "--vault"); err != nil {
+		t.Fatalf("init --force error = %v", err)
+	}
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ContainerRegistry.RegistryProvider != "YC" || cfg.ContainerRegistry.RegistryServer != "cr.yandex" {
+		t.Errorf("registry not overwritten: %+v", cfg.ContainerRegistry)
+	}
+	if len(cfg.TestsConfig.RemoteRepositories) != 2 || cfg.TestsConfig.Type != config.TestsTypeRemote {
+		t.Errorf("unexpected tests settings: %+v", cfg.TestsConfig)
+	}
+	if cfg.HashicorpVault == nil || !cfg.HashicorpVault.HashicorpVaultIntegration {
+		t.Error("expected Vault integration to be enabled")
+	}
+}
+
+func TestBuildInitConfigValidation(t *testing.T) {
+	base := initOptions{
+		RegistryServer:   "ghcr.io",
+		RegistryProvider: "Public",
+		ContainerName:    "org/image",
+		ContainerTag:     "latest",
+		TestsType:        "diffusion",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(o *initOptions)
+		wantErr string
+	}{
+		{"invalid provider", func(o *initOptions) { o.RegistryProvider = "OCI" }, "RegistryProvider"},
+		{"invalid tests type", func(o *initOptions) { o.TestsType = "unit" }, "invalid tests type"},
+		{"remote without repos", func(o *initOptions) { o.TestsType = "remote" }, "--tests-repo"},
+		{"empty tag", func(o *initOptions) { o.ContainerTag = " " }, "must not be empty"},
+		{"basic without username", func(o *initOptions) { o.RegistryProvider = "Basic" }, "--registry-username"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			tt.modify(&opts)
+			_, err := buildInitConfig(&opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/molecule"
+	"diffusion/internal/role"
+	"diffusion/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// errLintFindings is returned when a linter ran and reported problems
+var errLintFindings = errors.New("lint found problems")
+
+// lintTool is one host linter run by 'diffusion lint'
+type lintTool struct {
+	Name       string // Executable looked up on PATH
+	ConfigFile string // Generated config file name, passed with -c when written
+}
+
+var hostLintTools = []lintTool{
+	{Name: "yamllint", ConfigFile: config.YamlLintFileName},
+	{Name: "ansible-lint", ConfigFile: config.AnsibleLintFileName},
+}
+
+// lintLookPath and lintExec find and run the host linters; replaced in tests
+var (
+	lintLookPath = exec.LookPath
+	lintExec     = func(dir string, stdout, stderr io.Writer, name string, args ...string) error {
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	}
+)
+
+// NewLintCmd creates the lint command
+func NewLintCmd(cli *CLI) *cobra.Command {
+	var (
+		inContainer bool
+		format      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Run yamllint and ansible-lint on the host without a molecule container",
+		Long: `Run yamllint and ansible-lint directly on the host for quick feedback. The
+.yamllint and .ansible-lint files are generated from the [yaml_lint] and
+[ansible_lint] sections of diffusion.toml into a temporary directory, exactly as
+molecule runs write them, and passed with -c; the role itself is not touched.
+
+Both linters must be installed on the host (e.g. 'pipx install yamllint
+ansible-lint'). With --container the lint runs in the molecule container like
+'diffusion molecule --lint'. Exits non-zero when a linter reports problems.
+
+--format github prints the findings of both linters as GitHub Actions
+annotations (::error file=...,line=...::message) and --format json as a JSON
+array, parsed from 'yamllint -f parsable' and 'ansible-lint -f json'.`,
+		Example: `  diffusion lint
+  diffusion lint --container
+  diffusion lint --format github`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := molecule.ValidateLintFormat(format); err != nil {
+				return err
+			}
+			if inContainer {
+				return runContainerLint(format)
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runHostLint(cfg, dir, format, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().BoolVar(&inContainer, "container", false, "lint inside the molecule container instead of on the host (same as 'diffusion molecule --lint')")
+	cmd.Flags().StringVar(&format, "format", molecule.LintFormatText, "output format: text, github (workflow annotations) or json")
+
+	return cmd
+}
+
+// runHostLint generates the linter configs into a temp dir and runs every
+// linter on dir, even after one fails, so all findings are shown at once. With
+// a structured format the output is captured and only the findings are printed.
+func runHostLint(cfg *config.Config, dir, format string, stdout, stderr io.Writer) error {
+	if missing := missingLintTools(); len(missing) > 0 {
+		return fmt.Errorf("%s not found on PATH; install with 'pipx install %s' (or 'uv tool install'), or run 'diffusion lint --container'",
+			strings.Join(missing, " and "), strings.Join(missing, " "))
+	}
+
+	configDir, err := os.MkdirTemp("", "diffusion-lint-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(configDir)
+	if err := utils.ExportLinters(cfg, configDir, false, "", ""); err != nil {
+		return fmt.Errorf("failed to write linter configs: %w", err)
+	}
+
+	structured := molecule.StructuredLintFormat(format)
+	// Keep stdout machine-readable for structured formats
+	status := stdout
+	if structured {
+		status = stderr
+	}
+
+	var (
+		failed   []string
+		findings []molecule.LintFinding
+	)
+	for _, tool := range hostLintTools {
+		fmt.Fprintf(status, "\033[35mRunning %s...\033[0m\n", tool.Name)
+		args := lintArgs(tool, configDir, dir)
+		if !structured {
+			if err := lintExec(dir, stdout, stderr, tool.Name, args...); err != nil {
+				failed = append(failed, tool.Name)
+			}
+			continue
+		}
+
+		var output bytes.Buffer
+		args = append(molecule.LintFormatArgs(tool.Name), args...)
+		if err := lintExec(dir, &output, stderr, tool.Name, args...); err != nil {
+			failed = append(failed, tool.Name)
+		}
+		parsed, err := molecule.ParseLintOutput(tool.Name, output.String())
+		if err != nil {
+			stderr.Write(output.Bytes())
+			fmt.Fprintf(stderr, "\033[33mwarning: %s output could not be parsed: %v\033[0m\n", tool.Name, err)
+		}
+		findings = append(findings, parsed...)
+	}
+	if structured {
+		if err := molecule.WriteLintFindings(stdout, format, findings); err != nil {
+			return fmt.Errorf("failed to write lint findings: %w", err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", errLintFindings, strings.Join(failed, ", "))
+	}
+	fmt.Fprintln(status, "\033[32mLint Done Successfully!\033[0m")
+	return nil
+}
+
+// missingLintTools returns the host linters that are not on PATH
+func missingLintTools() []string {
+	var missing []string
+	for _, tool := range hostLintTools {
+		if _, err := lintLookPath(tool.Name); err != nil {
+			missing = append(missing, tool.Name)
+		}
+	}
+	return missing
+}
+
+// lintArgs returns the arguments of a host linter; the generated config is only
+// passed when it was written (an incomplete [yaml_lint]/[ansible_lint] section
+// leaves the linter on its defaults)
+func lintArgs(tool lintTool, configDir, projectDir string) []string {
+	var args []string
+	configPath := filepath.Join(configDir, tool.ConfigFile)
+	if _, err := os.Stat(configPath); err == nil {
+		args = append(args, "-c", configPath)
+	}
+	switch tool.Name {
+	case "yamllint":
+		args = append(args, ".")
+	case "ansible-lint":
+		// The config lives in a temp dir, so the project dir is not derived from it
+		args = append(args, "--project-dir", projectDir)
+	}
+	return args
+}
+
+// runContainerLint runs the lint phase in the molecule container for the role
+// in meta/main.yml
+func runContainerLint(format string) error {
+	meta, _, err := role.LoadRoleConfig("")
+	if err != nil {
+		return fmt.Errorf("failed to load role config: %w", err)
+	}
+	if meta.GalaxyInfo == nil || meta.GalaxyInfo.RoleName == "" {
+		return fmt.Errorf("role name or namespace missing in meta/main.yml")
+	}
+	return molecule.RunMolecule(&molecule.MoleculeOptions{
+		RoleFlag:   meta.GalaxyInfo.RoleName,
+		OrgFlag:    strings.ToLower(meta.GalaxyInfo.Namespace),
+		LintFlag:   true,
+		LintFormat: format,
+	})
+}
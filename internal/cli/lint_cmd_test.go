@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/molecule"
+)
+
+// stubHostLint records the linter invocations; failing names exit non-zero
+func stubHostLint(t *testing.T, failing ...string) *[][]string {
+	t.Helper()
+	origLook, origExec := lintLookPath, lintExec
+	t.Cleanup(func() { lintLookPath, lintExec = origLook, origExec })
+
+	var calls [][]string
+	lintLookPath = func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	lintExec = func(dir string, stdout, stderr io.Writer, name string, args ...string) error {
+		for _, arg := range args {
+			// The generated configs only exist while the linters run
+			if strings.HasSuffix(arg, config.YamlLintFileName) || strings.HasSuffix(arg, config.AnsibleLintFileName) {
+				if _, err := os.Stat(arg); err != nil {
+					t.Errorf("%s config %s missing: %v", name, arg, err)
+				}
+			}
+		}
+		calls = append(calls, append([]string{name}, args...))
+		for _, f := range failing {
+			if f == name {
+				return errors.New("exit status 2")
+			}
+		}
+		return nil
+	}
+	return &calls
+}
+
+func lintTestConfig() *config.Config {
+	return &config.Config{
+		YamlLintConfig: &config.YamlLint{
+			Extends: "default",
+			Rules:   &config.YamlLintRules{},
+		},
+		AnsibleLintConfig: &config.AnsibleLint{SkipList: []string{"yaml"}},
+	}
+}
+
+func TestRunHostLintPassesGeneratedConfigs(t *testing.T) {
+	calls := stubHostLint(t)
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+	if err := runHostLint(lintTestConfig(), dir, molecule.LintFormatText, &out, &out); err != nil {
+		t.Fatalf("runHostLint failed: %v", err)
+	}
+	if len(*calls) != 2 {
+		t.Fatalf("expected 2 linter runs, got %v", *calls)
+	}
+
+	yamllint := (*calls)[0]
+	if yamllint[0] != "yamllint" || yamllint[1] != "-c" || filepath.Base(yamllint[2]) != ".yamllint" || yamllint[3] != "." {
+		t.Errorf("unexpected yamllint args: %v", yamllint)
+	}
+	ansibleLint := (*calls)[1]
+	if ansibleLint[0] != "ansible-lint" || filepath.Base(ansibleLint[2]) != ".ansible-lint" ||
+		ansibleLint[3] != "--project-dir" || ansibleLint[4] != dir {
+		t.Errorf("unexpected ansible-lint args: %v", ansibleLint)
+	}
+
+	// The configs go to a temp dir, never into the role
+	if _, err := os.Stat(filepath.Join(dir, ".yamllint")); !os.IsNotExist(err) {
+		t.Errorf(".yamllint written into the role dir")
+	}
+	if _, err := os.Stat(filepath.Dir(yamllint[2])); !os.IsNotExist(err) {
+		t.Errorf("temp config dir not removed")
+	}
+}
+
+func TestRunHostLintReportsFindings(t *testing.T) {
+	calls := stubHostLint(t, "yamllint")
+
+	err := runHostLint(lintTestConfig(), t.TempDir(), molecule.LintFormatText, io.Discard, io.Discard)
+	if !errors.Is(err, errLintFindings) || !strings.Contains(err.Error(), "yamllint") {
+		t.Fatalf("expected lint findings error for yamllint, got %v", err)
+	}
+	// ansible-lint still runs so all findings show up at once
+	if len(*calls) != 2 {
+		t.Errorf("expected both linters to run, got %v", *calls)
+	}
+}
+
+func TestRunHostLintWithoutConfigUsesDefaults(t *testing.T) {
+	calls := stubHostLint(t)
+
+	if err := runHostLint(&config.Config{}, t.TempDir(), molecule.LintFormatText, io.Discard, io.Discard); err != nil {
+		t.Fatalf("runHostLint failed: %v", err)
+	}
+	for _, call := range *calls {
+		if call[1] == "-c" {
+			t.Errorf("%s got -c without a generated config: %v", call[0], call)
+		}
+	}
+}
+
+func TestRunHostLintMissingTools(t *testing.T) {
+	calls := stubHostLint(t)
+	lintLookPath = func(name string) (string, error) {
+		if name == "ansible-lint" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + name, nil
+	}
+
+	err := runHostLint(lintTestConfig(), t.TempDir(), molecule.LintFormatText, io.Discard, io.Discard)
+	if err == nil {
+		t.Fatal("expected an error for a missing linter")
+	}
+	for _, want := range []string{"ansible-lint not found", "pipx install ansible-lint", "--container"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if len(*calls) != 0 {
+		t.Errorf("no linter should run when one is missing, got %v", *calls)
+	}
+}
+
+func TestRunHostLintGitHubFormat(t *testing.T) {
+	calls := stubHostLint(t, "yamllint")
+	orig := lintExec
+	lintExec = func(dir string, stdout, stderr io.Writer, name string, args ...string) error {
+		err := orig(dir, stdout, stderr, name, args...)
+		if name == "yamllint" {
+			io.WriteString(stdout, "./tasks/main.yml:3:1: [error] trailing spaces (trailing-spaces)\n")
+		} else {
+			io.WriteString(stdout, "[]\n")
+		}
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	err := runHostLint(lintTestConfig(), t.TempDir(), molecule.LintFormatGitHub, &stdout, &stderr)
+	if !errors.Is(err, errLintFindings) {
+		t.Fatalf("expected lint findings error, got %v", err)
+	}
+	want := "::error file=tasks/main.yml,line=3,col=1,title=yamllint trailing-spaces::trailing spaces\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want only the annotation %q", stdout.String(), want)
+	}
+	if (*calls)[0][1] != "-f" || (*calls)[0][2] != "parsable" {
+		t.Errorf("yamllint not run with the parsable format: %v", (*calls)[0])
+	}
+	if (*calls)[1][1] != "-f" || (*calls)[1][2] != "json" {
+		t.Errorf("ansible-lint not run with the json format: %v", (*calls)[1])
+	}
+}
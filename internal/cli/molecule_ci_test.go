@@ -0,0 +1,34 @@
+package cli
+
+import "testing"
+
+func TestResolveCIMode(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		tty  bool
+		want bool
+	}{
+		{"interactive terminal", nil, map[string]string{}, true, false},
+		{"no terminal", nil, map[string]string{}, false, true},
+		{"CI env", nil, map[string]string{"CI": "true"}, true, true},
+		{"github actions", nil, map[string]string{"GITHUB_ACTIONS": "true"}, true, true},
+		{"gitlab ci", nil, map[string]string{"GITLAB_CI": "true"}, true, true},
+		{"explicit --ci on a terminal", []string{"--ci"}, map[string]string{}, true, true},
+		{"--ci=false on a CI runner", []string{"--ci=false"}, map[string]string{"CI": "true", "GITHUB_ACTIONS": "true"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &CLI{}
+			cmd := NewMoleculeCmd(cli)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			resolveCIMode(cmd, cli, func(k string) string { return tt.env[k] }, tt.tty)
+			if cli.CIMode != tt.want {
+				t.Errorf("CIMode = %v, want %v", cli.CIMode, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"diffusion/internal/config"
 	"diffusion/internal/molecule"
@@ -19,25 +20,128 @@ import (
 func NewMoleculeCmd(cli *CLI) *cobra.Command {
 	molCmd := &cobra.Command{
 		Use:   "molecule",
-		Short: "run molecule workflow (create/converge/verify/lint/idempotence/wipe)",
+		Short: "run molecule workflow (create/prepare/converge/verify/lint/idempotence/wipe)",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfg, err := config.LoadConfig(); err == nil && cfg.DefaultsConfig != nil {
+				if err := applyMoleculeDefaults(cmd.Flags(), cfg.DefaultsConfig.Molecule); err != nil {
+					return err
+				}
+			}
+			resolveCIMode(cmd, cli, os.Getenv, molecule.StdoutIsTerminal())
+			if cmd.Flags().Changed("limit") && strings.TrimSpace(cli.LimitFlag) == "" {
+				return fmt.Errorf("--limit requires a non-empty host pattern")
+			}
+			baseRef := cli.BaseRefFlag
+			if cli.SkipUnchangedFlag && baseRef == "" {
+				// pull_request events in GitHub Actions name the target branch
+				if target := os.Getenv("GITHUB_BASE_REF"); target != "" {
+					baseRef = "origin/" + target
+				} else {
+					return fmt.Errorf("--skip-if-unchanged requires --base <ref>")
+				}
+			}
+			if cli.PullFlag != "" {
+				if err := config.ValidatePullPolicy(cli.PullFlag); err != nil {
+					return err
+				}
+			}
+			if cli.BuildContextFlag != "" && cli.PullFlag != "" && cli.PullFlag != config.PullPolicyNever {
+				return fmt.Errorf("--build-context builds the image locally and always runs with --pull never")
+			}
+			for _, server := range cli.DNSFlags {
+				if err := config.ValidateDNSServer(server); err != nil {
+					return err
+				}
+			}
+			for _, domain := range cli.DNSSearchFlags {
+				if err := config.ValidateDNSSearch(domain); err != nil {
+					return err
+				}
+			}
+			if cli.RetryFlag < 0 {
+				return fmt.Errorf("--retry must be 0 or greater")
+			}
+			platforms := make([]molecule.Platform, 0, len(cli.PlatformFlags))
+			for _, value := range cli.PlatformFlags {
+				platform, err := molecule.ParsePlatform(value)
+				if err != nil {
+					return err
+				}
+				platforms = append(platforms, platform)
+			}
+			phases, err := phaseFlags(cmd, cli)
+			if err != nil {
+				return err
+			}
+			envFileVars, err := molecule.LoadEnvFiles(cli.EnvFileFlags)
+			if err != nil {
+				return err
+			}
 			opts := &molecule.MoleculeOptions{
 				RoleFlag:        cli.RoleFlag,
 				OrgFlag:         cli.OrgFlag,
 				RoleScenario:    cli.RoleScenario,
+				AllScenarios:    cli.AllScenariosFlag,
 				TagFlag:         cli.TagFlag,
+				LimitFlag:       strings.TrimSpace(cli.LimitFlag),
+				Platforms:       platforms,
+				Phases:          phases,
+				EnvFileVars:     envFileVars,
+				PreScripts:      cli.PreScriptFlags,
+				PostScripts:     cli.PostScriptFlags,
+				PostScriptFatal: cli.PostScriptFatal,
+				PullPolicy:      cli.PullFlag,
+				BuildContext:    cli.BuildContextFlag,
+				RegistryServer:  cli.RegistryServerFlag,
+				RegistryImage:   cli.RegistryImageFlag,
+				RegistryTag:     cli.RegistryTagFlag,
+				DNS:             cli.DNSFlags,
+				DNSSearch:       cli.DNSSearchFlags,
 				ConvergeFlag:    cli.ConvergeFlag,
-				VerifyFlag:      cli.VerifyFlag,
+				StepFlag:        cli.StepFlag,
+				DiffFlag:        cli.DiffFlag,
+				PrepareFlag:     cli.PrepareFlag,
+				VerifyFlag:      cli.VerifyFlag || cli.VerifyOnlyFlag,
+				VerifyOnly:      cli.VerifyOnlyFlag,
 				TestsOverWrite:  cli.TestsOverWriteFlag,
+				KeepTestsCache:  cli.KeepTestsCacheFlag,
+				RefreshTests:    cli.RefreshTestsFlag,
 				LintFlag:        cli.LintFlag,
 				IdempotenceFlag: cli.IdempotenceFlag,
 				DestroyFlag:     cli.DestroyFlag,
+				DestroyFirst:    cli.DestroyFirstFlag,
+				DestroyOnFail:   cli.DestroyOnFailFlag,
 				WipeFlag:        cli.WipeFlag,
+				VerifyCopy:      cli.VerifyCopyFlag,
+				KeepCache:       cli.KeepCacheFlag,
+				PurgeCache:      cli.PurgeCacheFlag,
 				CIMode:          cli.CIMode,
 				OidcFlag:        cli.OidcFlag,
 				ForceFlag:       cli.ForceFlag,
+				KeepFlag:        cli.KeepFlag,
+				SkipPermFix:     !cli.FixPermissions,
+				LogsFlag:        cli.LogsFlag,
+				Verbosity:       cli.VerbosityFlag,
+				NoCache:         cli.NoCacheFlag,
+				Timeout:         cli.TimeoutFlag,
+				Retry:           cli.RetryFlag,
+				OnlyChangedFlag: cli.OnlyChangedFlag,
+				SkipIfUnchanged: cli.SkipUnchangedFlag,
+				BaseRef:         baseRef,
 			}
-			return molecule.RunMolecule(opts)
+			if cli.ReportJSONFlag == "" {
+				return molecule.RunMolecule(opts)
+			}
+
+			opts.Results = molecule.NewResultCollector()
+			started := time.Now()
+			runErr := molecule.RunMolecule(opts)
+			if err := writeRunReport(cli.ReportJSONFlag, opts, started, runErr); err != nil {
+				log.Printf("\033[33mwarning: failed to write --report-json: %v\033[0m", err)
+			} else {
+				log.Printf("\033[32mRun report written to %s\033[0m", cli.ReportJSONFlag)
+			}
+			return runErr
 		},
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Ensure some env defaults and prompt when needed
@@ -49,26 +153,6 @@ func NewMoleculeCmd(cli *CLI) *cobra.Command {
 				log.Printf("\033[33mwarning loading config: %v\033[0m", err)
 				log.Printf("\033[38;2;127;255;212mNew config file will be created...\033[0m")
 
-				YamlLintRulesDefault := &config.YamlLintRules{
-					Braces:              map[string]any{"max-spaces-inside": 1, "level": "warning"},
-					Brackets:            map[string]any{"max-spaces-inside": 1, "level": "warning"},
-					NewLines:            map[string]any{"type": "platform"},
-					Comments:            map[string]any{"min-spaces-from-content": 1},
-					CommentsIndentation: false,
-					OctalValues:         map[string]any{"forbid-implicit-octal": true},
-				}
-				YamlLintDefault := &config.YamlLint{
-					Extends: "default",
-					Ignore:  []string{".git/*", "molecule/**", "vars/*", "files/*", ".yamllint", ".ansible-lint"},
-					Rules:   YamlLintRulesDefault,
-				}
-
-				AnsibleLintDefault := &config.AnsibleLint{
-					ExcludedPaths: []string{"molecule/default/tests/*.yml", "molecule/default/tests/*/*/*.yml", "tests/test.yml"},
-					WarnList:      []string{"meta-no-info", "yaml[line-length]"},
-					SkipList:      []string{"meta-incorrect", "role-name[path]"},
-				}
-
 				fmt.Printf("Enter RegistryServer (%s): ", config.DefaultRegistryServer)
 				registryServer, _ := reader.ReadString('\n')
 				registryServer = strings.TrimSpace(registryServer)
@@ -83,11 +167,16 @@ func NewMoleculeCmd(cli *CLI) *cobra.Command {
 					registryProvider = config.DefaultRegistryProvider
 				}
 
-				if registryProvider != "YC" && registryProvider != "AWS" && registryProvider != "GCP" && registryProvider != "Public" {
-					fmt.Fprintln(os.Stderr, "\033[31mInvalid RegistryProvider. Allowed values are: YC, AWS, GCP. \nIf you're using public registry, then choose Public - or choose it, if you want to authenticate externally.\033[0m")
+				if registryProvider != "YC" && registryProvider != "AWS" && registryProvider != "GCP" && registryProvider != "Basic" && registryProvider != "Public" {
+					fmt.Fprintln(os.Stderr, "\033[31mInvalid RegistryProvider. Allowed values are: YC, AWS, GCP, Basic (username/password). \nIf you're using public registry, then choose Public - or choose it, if you want to authenticate externally.\033[0m")
 					os.Exit(1)
 				}
 
+				var registryUsername, passwordEnv, passwordCredential string
+				if registryProvider == config.RegistryProviderBasic {
+					registryUsername, passwordEnv, passwordCredential = BasicRegistryPrompt(reader)
+				}
+
 				fmt.Printf("Enter MoleculeContainerName (%s): ", config.DefaultMoleculeContainerName)
 				moleculeContainerName, _ := reader.ReadString('\n')
 				moleculeContainerName = strings.TrimSpace(moleculeContainerName)
@@ -108,6 +197,9 @@ func NewMoleculeCmd(cli *CLI) *cobra.Command {
 					RegistryProvider:      registryProvider,
 					MoleculeContainerName: moleculeContainerName,
 					MoleculeContainerTag:  moleculeContainerTag,
+					Username:              registryUsername,
+					PasswordEnv:           passwordEnv,
+					PasswordCredential:    passwordCredential,
 				}
 
 				fmt.Print("Enable Vault Integration for artifact sources? (y/N): ")
@@ -118,21 +210,12 @@ func NewMoleculeCmd(cli *CLI) *cobra.Command {
 				}
 				vaultEnabled := strings.ToLower(vaultEnabledStr) == "y"
 
-				HashicorpVaultSet := VaultConfigHelper(vaultEnabled)
-
 				// Configure artifact sources
 				ArtifactSourcesList := ArtifactSourcesHelper()
 
 				TestsSettings := TestsConfigSetup()
 
-				cfg = &config.Config{
-					ContainerRegistry: ContainerRegistry,
-					HashicorpVault:    HashicorpVaultSet,
-					ArtifactSources:   ArtifactSourcesList,
-					YamlLintConfig:    YamlLintDefault,
-					AnsibleLintConfig: AnsibleLintDefault,
-					TestsConfig:       TestsSettings,
-				}
+				cfg = newConfig(ContainerRegistry, vaultEnabled, ArtifactSourcesList, TestsSettings)
 
 				if err := config.SaveConfig(cfg); err != nil {
 					log.Printf("\033[33mwarning saving new config: %v\033[0m", err)
@@ -166,17 +249,89 @@ func NewMoleculeCmd(cli *CLI) *cobra.Command {
 	molCmd.Flags().StringVarP(&cli.RoleFlag, "role", "r", cli.RoleFlag, "role name")
 	molCmd.Flags().StringVarP(&cli.OrgFlag, "org", "o", cli.OrgFlag, "organization prefix")
 	molCmd.Flags().StringVarP(&cli.RoleScenario, "scenario", "s", "", "molecule scenario name (default: 'default')")
+	molCmd.Flags().BoolVar(&cli.AllScenariosFlag, "all-scenarios", false, "run the selected phase (default: create/converge) for every folder under scenarios/ in the same container and print a pass/fail summary")
 	molCmd.Flags().StringVarP(&cli.TagFlag, "tag", "t", "", "Ansible tags to run (comma-separated, e.g., 'install,configure')")
+	molCmd.Flags().StringVar(&cli.LimitFlag, "limit", "", "limit converge to an Ansible host pattern (passed as 'molecule converge -- --limit <pattern>')")
+	molCmd.Flags().StringArrayVar(&cli.PlatformFlags, "platform", nil, "override the molecule platform at runtime (name=<name>,image=<image>; repeatable), exported as MOLECULE_PLATFORM_NAME/IMAGE")
+	molCmd.Flags().StringArrayVar(&cli.EnvFileFlags, "env-file", nil, "load KEY=VALUE lines from a file into the container env (repeatable; later files override earlier ones)")
+	molCmd.Flags().StringArrayVar(&cli.PreScriptFlags, "pre-script", nil, "shell command run inside the molecule container, in the role directory, before converge (repeatable; runs after [hooks] pre_converge); a failure aborts the run")
+	molCmd.Flags().StringArrayVar(&cli.PostScriptFlags, "post-script", nil, "shell command run inside the molecule container, in the role directory, after verify (repeatable; runs after [hooks] post_verify); failures are logged")
+	molCmd.Flags().BoolVar(&cli.PostScriptFatal, "post-script-fatal", false, "fail the run when a post-script fails (default: [hooks] post_verify_fatal)")
+	molCmd.Flags().StringArrayVar(&cli.DNSFlags, "dns", nil, "DNS server IP for the molecule container and its inner Docker daemon (repeatable; default: [container] dns)")
+	molCmd.Flags().StringArrayVar(&cli.DNSSearchFlags, "dns-search", nil, "DNS search domain for the molecule container and its inner Docker daemon (repeatable; default: [container] dns_search)")
+	molCmd.Flags().StringVar(&cli.PullFlag, "pull", "", "image pull policy for the molecule container: always, missing or never (default: [container] pull_policy, else always)")
+	molCmd.Flags().StringVar(&cli.RegistryServerFlag, "registry-server", "", "registry server of the molecule image for this run only (default: [container_registry] registry_server)")
+	molCmd.Flags().StringVar(&cli.RegistryImageFlag, "registry-image", "", "molecule image name for this run only (default: [container_registry] molecule_container_name)")
+	molCmd.Flags().StringVar(&cli.RegistryTagFlag, "registry-tag", "", "molecule image tag for this run only, e.g. a release candidate (default: [container_registry] molecule_container_tag)")
+	molCmd.Flags().StringVar(&cli.BuildContextFlag, "build-context", "", "build the molecule image from this directory's Dockerfile (tagged as the registry image) instead of pulling; implies --pull never (default: [container] build_context)")
+	molCmd.Flags().StringVar(&cli.OnlyFlag, "only", "", "run exactly one phase: create, prepare, converge, verify, lint, idempotence, destroy or full (the default create/converge flow)")
+	molCmd.Flags().StringSliceVar(&cli.PhasesFlags, "phases", nil, "run phases in order, stopping at the first failure (e.g. converge,verify,idempotence)")
 	molCmd.Flags().BoolVar(&cli.ConvergeFlag, "converge", false, "run molecule converge")
+	molCmd.Flags().BoolVar(&cli.StepFlag, "step", false, "confirm each task during converge (passes 'molecule converge -- --step'; not with --ci)")
+	molCmd.Flags().BoolVar(&cli.DiffFlag, "diff", false, "show the file changes ansible makes during converge and idempotence (sets ANSIBLE_DIFF_ALWAYS=1)")
+	molCmd.Flags().BoolVar(&cli.PrepareFlag, "prepare", false, "run molecule prepare (scenario prepare.yml); combine with --converge to prepare first")
 	molCmd.Flags().BoolVar(&cli.VerifyFlag, "verify", false, "run molecule verify")
+	molCmd.Flags().BoolVar(&cli.VerifyOnlyFlag, "verify-only", false, "run molecule verify against the already converged instance, skipping role data copy and test provisioning when tests exist")
 	molCmd.Flags().BoolVar(&cli.TestsOverWriteFlag, "testsoverwrite", false, "overwrite molecule tests folder for remote or diffusion type")
+	molCmd.Flags().BoolVar(&cli.KeepTestsCacheFlag, "keep-tests-cache", false, "use the cached remote/diffusion tests repositories (~/.diffusion/cache/tests) as they are, without pulling; clones only missing ones")
+	molCmd.Flags().BoolVar(&cli.RefreshTestsFlag, "refresh-tests", false, "clone the remote/diffusion tests repositories again instead of pulling the cached clones")
 	molCmd.Flags().BoolVar(&cli.LintFlag, "lint", false, "run linting (yamllint / ansible-lint)")
 	molCmd.Flags().BoolVar(&cli.IdempotenceFlag, "idempotence", false, "run molecule idempotence")
 	molCmd.Flags().BoolVar(&cli.DestroyFlag, "destroy", false, "run molecule destroy")
+	molCmd.Flags().StringVar(&cli.ReportJSONFlag, "report-json", "", "write a JSON report of the run (redacted config, locked dependencies, steps with status and duration, cache stats, result) to this file, on success or failure")
+	molCmd.Flags().BoolVar(&cli.DestroyFirstFlag, "destroy-first", false, "run molecule destroy and create before converge for fresh instances (keeps the container, unlike --wipe)")
 	molCmd.Flags().BoolVar(&cli.WipeFlag, "wipe", false, "remove container and molecule role folder")
-	molCmd.Flags().BoolVar(&cli.CIMode, "ci", false, "CI/CD mode (non-interactive, skip TTY and permission fixes)")
+	molCmd.Flags().BoolVar(&cli.KeepCacheFlag, "keep-cache", false, "with --wipe: make sure the roles/collections/uv/docker caches are on the host before the container is removed (copies them out when the container has no cache mounts)")
+	molCmd.Flags().BoolVar(&cli.PurgeCacheFlag, "purge-cache", false, "with --wipe: also delete the role cache directory")
+	molCmd.Flags().BoolVar(&cli.VerifyCopyFlag, "verify-copy", false, "after copying the role into the molecule layout, compare every file's size and SHA-256 with its source and fail on differences")
+	molCmd.Flags().BoolVar(&cli.CIMode, "ci", false, "CI/CD mode (non-interactive, skip TTY and permission fixes); auto-enabled on CI runners and without a TTY, --ci=false disables")
 	molCmd.Flags().BoolVar(&cli.OidcFlag, "oidc", false, "use OIDC token from env (TOKEN + provider-specific vars: YC_CLOUD_ID/YC_FOLDER_ID for YC, AWS_REGION for AWS)")
-	molCmd.Flags().BoolVar(&cli.ForceFlag, "force", false, "force reinstall of roles/collections from requirements.yml before converge")
+	molCmd.Flags().BoolVar(&cli.ForceFlag, "force", false, "force reinstall of roles/collections from requirements.yml before converge; with --only-changed, converge even if unchanged")
+	molCmd.Flags().BoolVar(&cli.FixPermissions, "fix-permissions", true, "chown /opt/molecule to the host user after converge and role init (Unix only; --fix-permissions=false skips it, e.g. on NFS workspaces)")
+	molCmd.Flags().BoolVar(&cli.KeepFlag, "keep", false, "start the container without --rm so it survives failures for debugging (remove with --wipe)")
+	molCmd.Flags().BoolVar(&cli.DestroyOnFailFlag, "destroy-on-failure", false, "when a phase fails, run molecule destroy and remove the molecule-<role> container before exiting non-zero")
+	molCmd.Flags().BoolVar(&cli.LogsFlag, "logs", false, "follow the molecule container logs (docker logs -f)")
+	molCmd.Flags().BoolVar(&cli.NoCacheFlag, "no-cache", false, "skip the role cache for this run (no cache mounts, copies or DinD image loads); [cache] settings are left untouched")
+	molCmd.Flags().CountVarP(&cli.VerbosityFlag, "verbose", "v", "ansible verbosity for every molecule phase, repeatable (-v, -vv, -vvv; -vvv also sets ANSIBLE_DEBUG=1 and traces the container shell); any level streams the full idempotence output")
+	molCmd.Flags().BoolVar(&cli.OnlyChangedFlag, "only-changed", false, "skip converge when role files are unchanged since the last successful converge (state in ~/.diffusion/state)")
+	molCmd.Flags().BoolVar(&cli.SkipUnchangedFlag, "skip-if-unchanged", false, "exit 0 without running when no role files changed in 'git diff <base>...HEAD' (for monorepo CI)")
+	molCmd.Flags().StringVar(&cli.BaseRefFlag, "base", "", "base ref for --skip-if-unchanged (default: origin/$GITHUB_BASE_REF when set)")
+	molCmd.Flags().DurationVar(&cli.TimeoutFlag, "timeout", 0, "kill converge/verify/idempotence/destroy after this duration and clean up (e.g. 30m; 0 = no timeout)")
+	molCmd.Flags().IntVar(&cli.RetryFlag, "retry", 0, "re-run a failing converge/verify/idempotence up to N times before giving up")
+	for _, phase := range []string{"prepare", "converge", "verify", "lint", "idempotence", "destroy"} {
+		_ = molCmd.Flags().MarkDeprecated(phase, fmt.Sprintf("use --only %s or --phases", phase))
+	}
+	for _, pair := range moleculeExclusiveFlags {
+		molCmd.MarkFlagsMutuallyExclusive(pair[0], pair[1])
+	}
+
+	molCmd.AddCommand(newMoleculeExecCmd(cli))
 
 	return molCmd
 }
+
+// phaseFlags returns the phases selected with --only or --phases (or their
+// [defaults.molecule] values); nil when neither is set, leaving the deprecated
+// phase flags in charge
+func phaseFlags(cmd *cobra.Command, cli *CLI) ([]string, error) {
+	switch {
+	case cmd.Flags().Changed("only") || cli.OnlyFlag != "":
+		return molecule.ParsePhases([]string{cli.OnlyFlag})
+	case cmd.Flags().Changed("phases") || len(cli.PhasesFlags) > 0:
+		return molecule.ParsePhases(cli.PhasesFlags)
+	}
+	return nil, nil
+}
+
+// resolveCIMode turns CI mode on when a CI runner or a missing TTY is detected.
+// An explicit --ci or --ci=false always wins, so detection only runs when the
+// flag was not passed; it must run before the workflow picks its clone/copy path.
+func resolveCIMode(cmd *cobra.Command, cli *CLI, getenv func(string) string, stdoutIsTTY bool) {
+	if cmd.Flags().Changed("ci") {
+		return
+	}
+	if reason := molecule.DetectCIMode(getenv, stdoutIsTTY); reason != "" {
+		cli.CIMode = true
+		log.Printf("\033[33mCI mode enabled automatically (%s); pass --ci=false to disable\033[0m", reason)
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/spf13/pflag"
+)
+
+// moleculePhaseFlags select what a molecule run does. They are one setting: a
+// phase given on the command line replaces every phase from [defaults.molecule].
+var moleculePhaseFlags = []string{"only", "phases", "prepare", "converge", "verify", "verify-only", "lint", "idempotence", "destroy"}
+
+// moleculeExclusiveFlags are the molecule flag pairs that cannot be combined
+var moleculeExclusiveFlags = [][2]string{
+	{"only", "phases"},
+	{"verify-only", "prepare"},
+	{"verify-only", "converge"},
+	{"verify-only", "lint"},
+	{"verify-only", "testsoverwrite"},
+	{"destroy-first", "destroy"},
+	{"destroy-first", "wipe"},
+	{"keep-cache", "purge-cache"},
+	{"keep-tests-cache", "refresh-tests"},
+	{"keep", "destroy-on-failure"},
+	{"all-scenarios", "scenario"},
+	{"all-scenarios", "wipe"},
+	{"all-scenarios", "logs"},
+}
+
+// exclusiveWith returns the flags that cannot be combined with name
+func exclusiveWith(name string) []string {
+	var others []string
+	for _, pair := range moleculeExclusiveFlags {
+		switch name {
+		case pair[0]:
+			others = append(others, pair[1])
+		case pair[1]:
+			others = append(others, pair[0])
+		}
+	}
+	return others
+}
+
+// applyMoleculeDefaults sets the molecule flags not given on the command line
+// from [defaults.molecule]. The flags stay unchanged as far as cobra is
+// concerned, so environment detection (e.g. CI mode) still overrides a config
+// default: flag > env > config > built-in. A default is also skipped when a
+// flag it cannot be combined with was given, and two such defaults are an error.
+func applyMoleculeDefaults(flags *pflag.FlagSet, defaults map[string]any) error {
+	phaseGiven := slices.ContainsFunc(moleculePhaseFlags, flags.Changed)
+
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("[defaults.molecule]: unknown molecule flag %q", name)
+		}
+		if flag.Changed || (phaseGiven && slices.Contains(moleculePhaseFlags, name)) || slices.ContainsFunc(exclusiveWith(name), flags.Changed) {
+			continue
+		}
+		if err := setFlagDefault(flag, defaults[name]); err != nil {
+			return fmt.Errorf("[defaults.molecule] %s: %w", name, err)
+		}
+	}
+
+	for _, pair := range moleculeExclusiveFlags {
+		if defaultSet(flags, pair[0]) && defaultSet(flags, pair[1]) {
+			return fmt.Errorf("[defaults.molecule]: %s and %s cannot be combined", pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+// defaultSet reports whether a flag not given on the command line holds a
+// value other than its built-in default
+func defaultSet(flags *pflag.FlagSet, name string) bool {
+	flag := flags.Lookup(name)
+	return flag != nil && !flag.Changed && flag.Value.String() != flag.DefValue
+}
+
+// setFlagDefault stores a TOML value in a flag without marking it as changed;
+// arrays replace the value of list flags
+func setFlagDefault(flag *pflag.Flag, value any) error {
+	switch v := value.(type) {
+	case []any:
+		list, ok := flag.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("takes a single value, not a list")
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return list.Replace(items)
+	case map[string]any:
+		return fmt.Errorf("takes a value, not a table")
+	default:
+		return flag.Value.Set(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"diffusion/internal/config"
+)
+
+// moleculeDefaults parses a diffusion.toml snippet and returns its
+// [defaults.molecule] table
+func moleculeDefaults(t *testing.T, toml string) map[string]any {
+	t.Helper()
+	cfg, _, err := config.MigrateConfig([]byte(toml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.DefaultsConfig.Molecule
+}
+
+func TestApplyMoleculeDefaultsWhenFlagAbsent(t *testing.T) {
+	defaults := moleculeDefaults(t, `
+[defaults.molecule]
+ci = true
+verify = true
+tag = "install"
+verbose = 2
+timeout = "30m"
+dns = ["10.0.0.2", "10.0.0.3"]
+`)
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyMoleculeDefaults(cmd.Flags(), defaults); err != nil {
+		t.Fatalf("applyMoleculeDefaults() error = %v", err)
+	}
+
+	if !cli.CIMode || !cli.VerifyFlag || cli.TagFlag != "install" || cli.VerbosityFlag != 2 || cli.TimeoutFlag != 30*time.Minute {
+		t.Errorf("defaults not applied: ci=%v verify=%v tag=%q verbose=%d timeout=%v", cli.CIMode, cli.VerifyFlag, cli.TagFlag, cli.VerbosityFlag, cli.TimeoutFlag)
+	}
+	if want := []string{"10.0.0.2", "10.0.0.3"}; !reflect.DeepEqual(cli.DNSFlags, want) {
+		t.Errorf("dns = %q, want %q", cli.DNSFlags, want)
+	}
+	if cmd.Flags().Changed("ci") {
+		t.Error("a config default marked --ci as changed, CI detection would be skipped")
+	}
+}
+
+func TestApplyMoleculeDefaultsFlagOverrides(t *testing.T) {
+	defaults := moleculeDefaults(t, `
+[defaults.molecule]
+ci = true
+tag = "install"
+converge = true
+`)
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+	if err := cmd.ParseFlags([]string{"--ci=false", "--tag", "configure", "--only", "lint"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyMoleculeDefaults(cmd.Flags(), defaults); err != nil {
+		t.Fatalf("applyMoleculeDefaults() error = %v", err)
+	}
+
+	if cli.CIMode || cli.TagFlag != "configure" {
+		t.Errorf("flags did not override the defaults: ci=%v tag=%q", cli.CIMode, cli.TagFlag)
+	}
+	if cli.ConvergeFlag {
+		t.Error("converge default applied although --only selects the phase")
+	}
+}
+
+func TestApplyMoleculeDefaultsSkipsConflictingFlag(t *testing.T) {
+	defaults := moleculeDefaults(t, `
+[defaults.molecule]
+keep = true
+destroy-first = true
+`)
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+	if err := cmd.ParseFlags([]string{"--destroy-on-failure", "--wipe"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyMoleculeDefaults(cmd.Flags(), defaults); err != nil {
+		t.Fatalf("applyMoleculeDefaults() error = %v", err)
+	}
+	if cli.KeepFlag || cli.DestroyFirstFlag {
+		t.Errorf("defaults conflicting with the given flags applied: keep=%v destroy-first=%v", cli.KeepFlag, cli.DestroyFirstFlag)
+	}
+	if err := cmd.ValidateFlagGroups(); err != nil {
+		t.Errorf("ValidateFlagGroups() error = %v, want the command line to win", err)
+	}
+}
+
+func TestApplyMoleculeDefaultsPhaseFromConfig(t *testing.T) {
+	cli := &CLI{}
+	cmd := NewMoleculeCmd(cli)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyMoleculeDefaults(cmd.Flags(), map[string]any{"phases": []any{"converge", "verify"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := phaseFlags(cmd, cli)
+	if err != nil || !reflect.DeepEqual(got, []string{"converge", "verify"}) {
+		t.Errorf("phaseFlags() = %q, %v; want the configured phases", got, err)
+	}
+}
+
+func TestApplyMoleculeDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults map[string]any
+		want     string
+	}{
+		{"unknown flag", map[string]any{"fast": true}, `unknown molecule flag "fast"`},
+		{"list for scalar", map[string]any{"tag": []any{"a", "b"}}, "not a list"},
+		{"bad value", map[string]any{"retry": "many"}, "[defaults.molecule] retry"},
+		{"conflicting defaults", map[string]any{"only": "lint", "phases": []any{"converge"}}, "only and phases cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewMoleculeCmd(&CLI{})
+			if err := cmd.ParseFlags(nil); err != nil {
+				t.Fatal(err)
+			}
+			err := applyMoleculeDefaults(cmd.Flags(), tt.defaults)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyMoleculeDefaults() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"diffusion/internal/molecule"
+
+	"github.com/spf13/cobra"
+)
+
+// newMoleculeExecCmd creates the molecule exec subcommand
+func newMoleculeExecCmd(cli *CLI) *cobra.Command {
+	opts := molecule.ExecOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- <cmd> [args...]",
+		Short: "Run a command in the role's molecule container",
+		Long: `Run a command inside the running molecule-<role> container via docker exec,
+in the role directory (/opt/molecule/<namespace>.<role>). Everything after --
+is passed through as the command. The command runs as the host uid:gid so
+files it creates stay yours; --root runs it as the container's default user.`,
+		Example: `  diffusion molecule exec -- ansible-galaxy collection list
+  diffusion molecule exec --workdir molecule/default -- ls -la
+  diffusion molecule exec --root -- sh`,
+		Args: cobra.MinimumNArgs(1),
+		// No config prompt: exec only needs the running container
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Role = cli.RoleFlag
+			opts.Org = cli.OrgFlag
+			opts.TTY = molecule.StdoutIsTerminal()
+			opts.Command = args
+			return molecule.Exec(opts)
+		},
+	}
+
+	// Flags end at the first argument, so the command's own flags pass through
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVarP(&cli.RoleFlag, "role", "r", cli.RoleFlag, "role name")
+	cmd.Flags().StringVarP(&cli.OrgFlag, "org", "o", cli.OrgFlag, "organization prefix")
+	cmd.Flags().StringVar(&opts.Workdir, "workdir", "", "working directory in the container; relative paths resolve against the role directory (default: the role directory)")
+	cmd.Flags().BoolVar(&opts.Root, "root", false, "run as the container's default user (root) instead of mapping the host uid:gid")
+
+	return cmd
+}
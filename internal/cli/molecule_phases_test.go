@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPhaseFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"only", []string{"--only", "lint"}, []string{"lint"}, false},
+		{"phases", []string{"--phases", "converge,verify"}, []string{"converge", "verify"}, false},
+		{"repeated phases", []string{"--phases", "converge", "--phases", "idempotence"}, []string{"converge", "idempotence"}, false},
+		{"unknown only", []string{"--only", "test"}, nil, true},
+		{"empty only", []string{"--only="}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &CLI{}
+			cmd := NewMoleculeCmd(cli)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() error = %v", err)
+			}
+			got, err := phaseFlags(cmd, cli)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("phaseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("phaseFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+	"diffusion/internal/dependency"
+	"diffusion/internal/molecule"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Final results in the --report-json document
+const (
+	reportResultPassed = "passed"
+	reportResultFailed = "failed"
+)
+
+// runReport is the --report-json document: one artifact describing a whole
+// molecule run for CI
+type runReport struct {
+	Role            string                `json:"role"`
+	Scenario        string                `json:"scenario"`
+	Result          string                `json:"result"`
+	Error           string                `json:"error,omitempty"`
+	StartedAt       string                `json:"started_at"`
+	DurationSeconds float64               `json:"duration_seconds"`
+	Config          map[string]any        `json:"config,omitempty"`       // Redacted diffusion.toml
+	Dependencies    map[string]any        `json:"dependencies,omitempty"` // diffusion.lock, if present
+	Steps           []molecule.StepResult `json:"steps"`
+	Cache           reportCache           `json:"cache"`
+}
+
+// reportCache holds the role cache stats of a run
+type reportCache struct {
+	Enabled    bool   `json:"enabled"`
+	ID         string `json:"id,omitempty"`
+	SizeBytes  int64  `json:"size_bytes,omitempty"`
+	WarnSizeMB int    `json:"warn_size_mb,omitempty"`
+}
+
+// writeRunReport collects the config, lock file and cache stats and writes the
+// report of a finished run (runErr is its result) to path
+func writeRunReport(path string, opts *molecule.MoleculeOptions, started time.Time, runErr error) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		cfg = nil // no diffusion.toml yet; report the run without it
+	}
+	lockFile, err := dependency.LoadLockFile()
+	if err != nil {
+		return fmt.Errorf("failed to load lock file: %w", err)
+	}
+	cacheStats := reportCacheStats(opts, cfg)
+
+	report, err := buildRunReport(opts, cfg, lockFile, cacheStats, started, time.Since(started), runErr)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// buildRunReport composes the report from the run's recorded steps and the
+// config, lock file and cache stats; cfg and lockFile may be nil
+func buildRunReport(opts *molecule.MoleculeOptions, cfg *config.Config, lockFile *dependency.LockFile, cacheStats reportCache, started time.Time, duration time.Duration, runErr error) (*runReport, error) {
+	report := &runReport{
+		Role:            opts.RoleFlag,
+		Scenario:        opts.RoleScenario,
+		StartedAt:       started.UTC().Format(time.RFC3339),
+		DurationSeconds: duration.Round(time.Millisecond).Seconds(),
+		Steps:           opts.Results.Steps(),
+		Cache:           cacheStats,
+	}
+	if opts.AllScenarios {
+		report.Scenario = "all"
+	} else if report.Scenario == "" {
+		report.Scenario = config.DefaultScenario
+	}
+	if report.Steps == nil {
+		report.Steps = []molecule.StepResult{}
+	}
+	report.Result = stepsResult(report.Steps)
+	if runErr != nil {
+		report.Result = reportResultFailed
+		report.Error = runErr.Error()
+	}
+
+	if cfg != nil {
+		doc, err := configDocument(cfg)
+		if err != nil {
+			return nil, err
+		}
+		report.Config = doc
+	}
+	if lockFile != nil {
+		doc, err := lockDocument(lockFile)
+		if err != nil {
+			return nil, err
+		}
+		report.Dependencies = doc
+	}
+	return report, nil
+}
+
+// stepsResult is failed when the last attempt of any phase did not pass, so
+// a converge the default flow only warns about still fails the report
+func stepsResult(steps []molecule.StepResult) string {
+	type phaseKey struct{ scenario, phase string }
+	last := map[phaseKey]string{}
+	for _, step := range steps {
+		last[phaseKey{step.Scenario, step.Phase}] = step.Status
+	}
+	for _, status := range last {
+		if status != molecule.StepPassed {
+			return reportResultFailed
+		}
+	}
+	return reportResultPassed
+}
+
+// lockDocument converts the lock file to a map keyed by its diffusion.lock names
+func lockDocument(lockFile *dependency.LockFile) (map[string]any, error) {
+	data, err := yaml.Marshal(lockFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock file: %w", err)
+	}
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to convert lock file: %w", err)
+	}
+	return doc, nil
+}
+
+// reportCacheStats measures the role cache; a failed measurement leaves the size out
+func reportCacheStats(opts *molecule.MoleculeOptions, cfg *config.Config) reportCache {
+	if opts.NoCache || cfg == nil || cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled {
+		return reportCache{}
+	}
+	stats := reportCache{
+		Enabled:    true,
+		ID:         cfg.CacheConfig.CacheID,
+		WarnSizeMB: cfg.CacheConfig.WarnSizeMB,
+	}
+	if stats.WarnSizeMB <= 0 {
+		stats.WarnSizeMB = config.DefaultCacheWarnSizeMB
+	}
+	if stats.ID != "" {
+		if size, err := cache.GetCacheSize(stats.ID, cfg.CacheConfig.CachePath); err == nil {
+			stats.SizeBytes = size
+		}
+	}
+	return stats
+}
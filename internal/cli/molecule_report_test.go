@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"diffusion/internal/dependency"
+	"diffusion/internal/molecule"
+)
+
+func TestBuildRunReportJSON(t *testing.T) {
+	opts := &molecule.MoleculeOptions{RoleFlag: "web", RoleScenario: "ubuntu", Results: molecule.NewResultCollector()}
+	opts.Results.Record("ubuntu", "converge", 90*time.Second, nil)
+	opts.Results.Record("ubuntu", "verify", 2*time.Second, errors.New("exit status 1"))
+
+	lockFile := &dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Collections: []dependency.LockFileEntry{
+			{Name: "ubuntu.general", Namespace: "community", Type: "collection", Version: ">=8.0.0", ResolvedVersion: "8.5.0"},
+		},
+	}
+	cacheStats := reportCache{Enabled: true, ID: "abc123", SizeBytes: 2048, WarnSizeMB: 10240}
+	started := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	report, err := buildRunReport(opts, showFormatTestConfig(), lockFile, cacheStats, started, 92*time.Second, errors.New("verify failed"))
+	if err != nil {
+		t.Fatalf("buildRunReport() error = %v", err)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "s3cr3t-token-value") || strings.Contains(string(data), "plain-secret-value") {
+		t.Errorf("report leaks a secret: %s", data)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"role", "scenario", "result", "error", "started_at", "duration_seconds", "config", "dependencies", "steps", "cache"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("report missing %q", key)
+		}
+	}
+	if doc["result"] != reportResultFailed || doc["scenario"] != "ubuntu" || doc["started_at"] != "2026-10-01T12:00:00Z" {
+		t.Errorf("report header = result %v, scenario %v, started_at %v", doc["result"], doc["scenario"], doc["started_at"])
+	}
+
+	steps := doc["steps"].([]any)
+	if len(steps) != 2 {
+		t.Fatalf("report has %d steps, want 2", len(steps))
+	}
+	verify := steps[1].(map[string]any)
+	if verify["phase"] != "verify" || verify["status"] != molecule.StepFailed || verify["duration_seconds"] != 2.0 || verify["error"] != "exit status 1" {
+		t.Errorf("verify step = %v", verify)
+	}
+
+	cfg := doc["config"].(map[string]any)
+	if _, ok := cfg["container_registry"]; !ok {
+		t.Errorf("config should use diffusion.toml keys, got %v", cfg)
+	}
+	deps := doc["dependencies"].(map[string]any)
+	collection := deps["collections"].([]any)[0].(map[string]any)
+	if collection["resolved_version"] != "8.5.0" {
+		t.Errorf("dependency = %v, want resolved_version 8.5.0", collection)
+	}
+	cacheDoc := doc["cache"].(map[string]any)
+	if cacheDoc["enabled"] != true || cacheDoc["size_bytes"] != 2048.0 {
+		t.Errorf("cache = %v", cacheDoc)
+	}
+}
+
+func TestBuildRunReportWithoutConfigOrLock(t *testing.T) {
+	opts := &molecule.MoleculeOptions{RoleFlag: "web", AllScenarios: true}
+
+	report, err := buildRunReport(opts, nil, nil, reportCache{}, time.Now(), time.Second, nil)
+	if err != nil {
+		t.Fatalf("buildRunReport() error = %v", err)
+	}
+	data, _ := json.Marshal(report)
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if doc["result"] != reportResultPassed || doc["scenario"] != "all" {
+		t.Errorf("report = %s", data)
+	}
+	if steps, ok := doc["steps"].([]any); !ok || len(steps) != 0 {
+		t.Errorf("steps = %v, want an empty list", doc["steps"])
+	}
+	for _, key := range []string{"config", "dependencies", "error"} {
+		if _, ok := doc[key]; ok {
+			t.Errorf("report should omit %q without data", key)
+		}
+	}
+}
+
+func TestBuildRunReportResultFromSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(*molecule.ResultCollector)
+		want   string
+	}{
+		{"all passed", func(c *molecule.ResultCollector) {
+			c.Record("default", "create", time.Second, nil)
+			c.Record("default", "converge", time.Second, nil)
+		}, reportResultPassed},
+		{"warned converge failure", func(c *molecule.ResultCollector) {
+			c.Record("default", "create", time.Second, nil)
+			c.Record("default", "converge", time.Second, errors.New("exit status 2"))
+		}, reportResultFailed},
+		{"passed on retry", func(c *molecule.ResultCollector) {
+			c.Record("default", "converge", time.Second, errors.New("exit status 2"))
+			c.Record("default", "converge", time.Second, nil)
+		}, reportResultPassed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &molecule.MoleculeOptions{RoleFlag: "web", Results: molecule.NewResultCollector()}
+			tt.record(opts.Results)
+			report, err := buildRunReport(opts, nil, nil, reportCache{}, time.Now(), time.Second, nil)
+			if err != nil {
+				t.Fatalf("buildRunReport() error = %v", err)
+			}
+			if report.Result != tt.want {
+				t.Errorf("result = %q, want %q", report.Result, tt.want)
+			}
+		})
+	}
+}
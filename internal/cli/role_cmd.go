@@ -38,6 +38,10 @@ func NewRoleCmd(cli *CLI) *cobra.Command {
 				}
 
 				MetaConfig := MetaConfigSetup(roleName)
+				if violations := role.LintGalaxyNames(MetaConfig.GalaxyInfo); len(violations) > 0 {
+					fmt.Println("\033[33mWarning: this role cannot be published to Galaxy as named (see 'diffusion role lint-name'):\033[0m")
+					writeNameViolations(os.Stdout, violations)
+				}
 				RequirementConfig := RequirementConfigSetup(MetaConfig.Collections)
 				err = role.SaveMetaFile(MetaConfig)
 				if err != nil {
@@ -87,6 +91,11 @@ func NewRoleCmd(cli *CLI) *cobra.Command {
 	roleCmd.AddCommand(newRoleRemoveRoleCmd(cli))
 	roleCmd.AddCommand(NewRoleAddCollectionCmd(cli))
 	roleCmd.AddCommand(NewRoleRemoveCollectionCmd(cli))
+	roleCmd.AddCommand(newRoleLintConfigCmd())
+	roleCmd.AddCommand(newRoleLintNameCmd())
+	roleCmd.AddCommand(newRoleScenarioCmd())
+	roleCmd.AddCommand(newRoleTreeCmd())
+	roleCmd.AddCommand(newRolePublishCmd())
 
 	return roleCmd
 }
@@ -105,11 +114,17 @@ func newRoleAddRoleCmd(cli *CLI) *cobra.Command {
 					strings.SplitN(roleName, ".", 2)[1], strings.SplitN(roleName, ".", 2)[0])
 			}
 
-			if strings.HasSuffix(cli.RoleSrcFlag, ".git") {
-				cli.RoleScmFlag = "git"
-
-			} else {
-				cli.RoleScmFlag = "galaxy"
+			scm, err := addRoleScm(cli.RoleSrcFlag, cli.RoleScmFlag, cmd.Flags().Changed("scm"), cli.RoleGalaxyFlag)
+			if err != nil {
+				return err
+			}
+			cli.RoleScmFlag = scm
+			if err := galaxy.ValidateRoleSrc(cli.RoleSrcFlag); err != nil {
+				return err
+			}
+			// The "main" default is a git branch; Galaxy roles get their latest release
+			if cli.RoleScmFlag == "galaxy" && !cmd.Flags().Changed("version") {
+				cli.RoleVersionFlag = ""
 			}
 
 			// Require --namespace when using Galaxy source (no --src git URL provided)
@@ -152,7 +167,13 @@ func newRoleAddRoleCmd(cli *CLI) *cobra.Command {
 					}
 				case "galaxy":
 					// Try Galaxy API with separate namespace and name
-					resolved, err := galaxy.GetRoleVersion(cli.NamespaceFlag, roleName, resolvedVersion)
+					var resolved string
+					var err error
+					if resolvedVersion == "" || resolvedVersion == "latest" {
+						resolved, err = galaxy.NewGalaxyAPI().GetRoleLatestVersion(cli.NamespaceFlag, roleName)
+					} else {
+						resolved, err = galaxy.GetRoleVersion(cli.NamespaceFlag, roleName, resolvedVersion)
+					}
 					if err != nil {
 						fmt.Printf("\033[33mWarning: Failed to resolve role version: %v\033[0m\n", err)
 						fmt.Printf("\033[33mUsing 'main' as default version\033[0m\n")
@@ -254,14 +275,46 @@ func newRoleAddRoleCmd(cli *CLI) *cobra.Command {
 	}
 
 	roleAddRoleCmd.Flags().StringVarP(&cli.RoleScenario, "scenario", "s", "default", "Molecule scenarios folder to use")
-	roleAddRoleCmd.Flags().StringVarP(&cli.RoleSrcFlag, "src", "", "", "Source URL of the role (required)")
-	roleAddRoleCmd.Flags().StringVarP(&cli.RoleScmFlag, "scm", "", "git", "SCM type (e.g., git) of the role (optional)")
-	roleAddRoleCmd.Flags().StringVarP(&cli.RoleVersionFlag, "version", "v", "main", "Version of the role (optional)")
+	roleAddRoleCmd.Flags().StringVarP(&cli.RoleSrcFlag, "src", "", "", "Git URL of the role (required unless it is a Galaxy role)")
+	roleAddRoleCmd.Flags().StringVarP(&cli.RoleScmFlag, "scm", "", "git", "SCM type of the role: git, or galaxy for a Galaxy role (default: git with a .git --src, else galaxy)")
+	roleAddRoleCmd.Flags().StringVarP(&cli.RoleVersionFlag, "version", "v", "main", "Version of the role (optional; Galaxy roles default to the latest release)")
 	roleAddRoleCmd.Flags().StringVarP(&cli.NamespaceFlag, "namespace", "n", "", "Namespace for galaxy roles (optional)")
+	roleAddRoleCmd.Flags().BoolVar(&cli.RoleGalaxyFlag, "galaxy", false, "add a Galaxy role (namespace.name from --namespace), same as --scm galaxy")
+	roleAddRoleCmd.MarkFlagsMutuallyExclusive("galaxy", "src")
+	roleAddRoleCmd.MarkFlagsMutuallyExclusive("galaxy", "scm")
 
 	return roleAddRoleCmd
 }
 
+// addRoleScm returns the SCM of a role added with add-role: galaxy with
+// --galaxy, the explicit --scm, else git for a .git --src and galaxy without one.
+// Galaxy roles are installed by namespace.name, so they take no --src.
+func addRoleScm(src, scm string, scmSet, galaxyFlag bool) (string, error) {
+	switch {
+	case galaxyFlag:
+		scm = "galaxy"
+	case !scmSet:
+		if strings.HasSuffix(galaxy.ParseGitSource(src).URL, ".git") {
+			return "git", nil
+		}
+		return "galaxy", nil
+	}
+
+	switch scm {
+	case "galaxy":
+		if src != "" {
+			return "", fmt.Errorf("galaxy roles are installed by namespace.name and take no --src")
+		}
+	case "git":
+		if src == "" {
+			return "", fmt.Errorf("--scm git needs the role's --src git URL")
+		}
+	default:
+		return "", fmt.Errorf("unsupported --scm %q (expected git or galaxy)", scm)
+	}
+	return scm, nil
+}
+
 func newRoleRemoveRoleCmd(cli *CLI) *cobra.Command {
 	roleRemoveRoleCmd := &cobra.Command{
 		Use:   "remove-role [role-name]",
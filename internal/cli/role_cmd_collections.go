@@ -15,14 +15,30 @@ import (
 // NewRoleAddCollectionCmd creates the add-collection subcommand
 func NewRoleAddCollectionCmd(cli *CLI) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add-collection [collection-name]",
+		Use:   "add-collection [collection-name] [version-constraint]",
 		Short: "Add a collection to diffusion.toml (use 'deps sync' to update requirements.yml and meta.yml)",
-		Args:  cobra.ExactArgs(1),
+		Long: `Add a collection to diffusion.toml. The version constraint can be given as a second
+argument or inline in the name; without one the latest Galaxy version is pinned as >=<version>.
+
+Examples:
+  diffusion role add-collection general -n community
+  diffusion role add-collection general '>=7.0.0' -n community
+  diffusion role add-collection 'general>=7.0.0' -n community`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			collectionName := args[0]
 
 			// Parse collection name and version constraint
 			name, versionConstraint := utils.ParseCollectionString(collectionName)
+			if len(args) == 2 {
+				if versionConstraint != "" && versionConstraint != args[1] {
+					return fmt.Errorf("version constraint given twice: %q in the name and %q as argument", versionConstraint, args[1])
+				}
+				versionConstraint = strings.TrimSpace(args[1])
+			}
+			if err := dependency.ValidateConstraint(versionConstraint); err != nil {
+				return err
+			}
 
 			// Validate: dots are forbidden in collection names (dots are reserved as scenario name prefixes)
 			if strings.Contains(name, ".") {
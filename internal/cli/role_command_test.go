@@ -246,3 +246,34 @@ func TestCheckRoleExists(t *testing.T) {
 		})
 	}
 }
+
+func TestAddRoleScm(t *testing.T) {
+	tests := []struct {
+		name       string
+		src, scm   string
+		scmSet     bool
+		galaxyFlag bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "git url detected", src: "https://github.com/acme/nginx.git", scm: "git", want: "git"},
+		{name: "no src defaults to galaxy", scm: "git", want: "galaxy"},
+		{name: "--galaxy", scm: "git", galaxyFlag: true, want: "galaxy"},
+		{name: "--scm galaxy", scm: "galaxy", scmSet: true, want: "galaxy"},
+		{name: "--scm galaxy with src", src: "https://github.com/acme/nginx.git", scm: "galaxy", scmSet: true, wantErr: true},
+		{name: "--scm git without src", scm: "git", scmSet: true, wantErr: true},
+		{name: "--scm git with a non .git url", src: "https://git.example.com/acme/nginx", scm: "git", scmSet: true, want: "git"},
+		{name: "unknown scm", src: "https://hg.example.com/nginx", scm: "hg", scmSet: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addRoleScm(tt.src, tt.scm, tt.scmSet, tt.galaxyFlag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addRoleScm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("addRoleScm() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// newRoleLintConfigCmd creates the lint-config subcommand group
+func newRoleLintConfigCmd() *cobra.Command {
+	lintConfigCmd := &cobra.Command{
+		Use:   "lint-config",
+		Short: "Inspect the linter configuration generated from diffusion.toml",
+	}
+
+	lintConfigCmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the .yamllint and .ansible-lint files diffusion would generate",
+		Long: `Print the .yamllint and .ansible-lint content generated from the [yaml_lint]
+and [ansible_lint] sections of diffusion.toml, exactly as molecule runs write it,
+without starting a container.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			return writeLintConfigPreview(cmd.OutOrStdout(), cfg)
+		},
+	})
+
+	return lintConfigCmd
+}
+
+// writeLintConfigPreview writes both generated linter files to w, each under a
+// "# <file name>" header line
+func writeLintConfigPreview(w io.Writer, cfg *config.Config) error {
+	yamllint, err := utils.MarshalYamlLint(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to render .yamllint: %w", err)
+	}
+	ansiblelint, err := utils.MarshalAnsibleLint(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to render .ansible-lint: %w", err)
+	}
+
+	fmt.Fprintln(w, "# .yamllint")
+	if _, err := w.Write(yamllint); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "# .ansible-lint")
+	_, err = w.Write(ansiblelint)
+	return err
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+func TestWriteLintConfigPreviewMatchesExportedFiles(t *testing.T) {
+	cfg := &config.Config{
+		YamlLintConfig:    defaultYamlLint(),
+		AnsibleLintConfig: defaultAnsibleLint(),
+	}
+
+	dir := t.TempDir()
+	if err := utils.ExportLinters(cfg, dir, false, "role", "org"); err != nil {
+		t.Fatalf("ExportLinters() error: %v", err)
+	}
+	yamllint, err := os.ReadFile(filepath.Join(dir, ".yamllint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ansiblelint, err := os.ReadFile(filepath.Join(dir, ".ansible-lint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeLintConfigPreview(&out, cfg); err != nil {
+		t.Fatalf("writeLintConfigPreview() error: %v", err)
+	}
+
+	want := "# .yamllint\n" + string(yamllint) + "\n# .ansible-lint\n" + string(ansiblelint)
+	if out.String() != want {
+		t.Errorf("preview does not match exported files:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriteLintConfigPreviewIncompleteConfig(t *testing.T) {
+	cfg := &config.Config{YamlLintConfig: defaultYamlLint()}
+	if err := writeLintConfigPreview(&bytes.Buffer{}, cfg); err == nil {
+		t.Error("expected error when [ansible_lint] is missing")
+	}
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"diffusion/internal/role"
+
+	"github.com/spf13/cobra"
+)
+
+// newRoleLintNameCmd creates the lint-name subcommand
+func newRoleLintNameCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lint-name",
+		Short: "Check the role namespace and name against Ansible Galaxy naming rules",
+		Long: `Check galaxy_info.namespace and galaxy_info.role_name in meta/main.yml against
+Ansible Galaxy's rules (lowercase letters, digits and '_', starting with a letter,
+2-64 characters). Each violation is reported with a suggested name; the command
+exits non-zero when any are found, so it can gate CI before publishing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			meta, err := role.ParseMetaFile()
+			if err != nil {
+				return fmt.Errorf("failed to read meta/main.yml: %w", err)
+			}
+			violations := role.LintGalaxyNames(meta.GalaxyInfo)
+			writeNameViolations(cmd.OutOrStdout(), violations)
+			if len(violations) > 0 {
+				return fmt.Errorf("%d Galaxy naming violation(s) found", len(violations))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\033[32mNamespace %q and role name %q are valid Galaxy names\033[0m\n",
+				meta.GalaxyInfo.Namespace, meta.GalaxyInfo.RoleName)
+			return nil
+		},
+	}
+}
+
+// writeNameViolations prints one line per naming violation
+func writeNameViolations(w io.Writer, violations []role.NameViolation) {
+	for _, v := range violations {
+		fmt.Fprintf(w, "  \033[31m✗\033[0m %s\n", v)
+	}
+}
@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/role"
+	"diffusion/internal/secrets"
+	"diffusion/internal/utils"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/spf13/cobra"
+)
+
+// publishDistDir is where role publish writes the role archive
+const publishDistDir = "dist"
+
+// publishExcluded are role-root entries that never go into the archive:
+// VCS data, the diffusion molecule layout and diffusion's own files
+var publishExcluded = []string{".git", "molecule", publishDistDir, config.ConfigFileName, config.LockFileName, config.IgnoreFileName}
+
+// publishExec runs git and ansible-galaxy for role publish with extra
+// environment, returning the combined output; replaced in tests
+var publishExec = func(env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.CombinedOutput()
+}
+
+// publishLookPath finds ansible-galaxy on the host; replaced in tests
+var publishLookPath = exec.LookPath
+
+// loadPublishCredentials returns the stored galaxy login; replaced in tests
+var loadPublishCredentials = secrets.LoadGalaxyCredentials
+
+// newRolePublishCmd creates the publish subcommand
+func newRolePublishCmd() *cobra.Command {
+	var (
+		dryRun     bool
+		tag        string
+		server     string
+		githubUser string
+		githubRepo string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Build the role archive and import the role into Galaxy",
+		Long: `Build <namespace>-<role_name>.tar.gz in dist/ from the role directory (without
+.git, molecule/, dist/, diffusion's own files and .diffusionignore matches) and
+run 'ansible-galaxy role import' on the host for the GitHub repository of the
+origin remote. Namespace and name come from meta/main.yml and must be valid
+Galaxy names.
+
+The galaxy server and token come from 'diffusion galaxy login', ` + config.EnvGalaxyToken + `
+overrides the token and --server the URL (default galaxy.ansible.com). They are
+passed to ansible-galaxy as ANSIBLE_GALAXY_SERVER_* variables, never as
+arguments. --tag builds the archive from that git tag and imports it after
+checking that its meta/main.yml has the same namespace and name; --dry-run only
+builds the archive.`,
+		Example: `  diffusion role publish --dry-run
+  diffusion role publish
+  diffusion role publish --tag v1.2.0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+
+			meta, err := role.ParseMetaFile()
+			if err != nil {
+				return fmt.Errorf("failed to read meta/main.yml: %w", err)
+			}
+			if violations := role.LintGalaxyNames(meta.GalaxyInfo); len(violations) > 0 {
+				writeNameViolations(w, violations)
+				return fmt.Errorf("meta/main.yml has %d Galaxy naming violation(s); fix them before publishing", len(violations))
+			}
+			info := meta.GalaxyInfo
+
+			if tag != "" {
+				if err := checkPublishTag(tag, info); err != nil {
+					return err
+				}
+			}
+
+			// With --tag the archive holds the tagged files, not the working tree
+			root := "."
+			if tag != "" {
+				tmp, err := os.MkdirTemp("", "diffusion-publish-")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tmp)
+				if root, err = exportPublishTag(tag, tmp); err != nil {
+					return err
+				}
+			}
+
+			archive := filepath.Join(publishDistDir, publishArchiveName(info, tag))
+			count, err := buildRoleArchive(root, archive, utils.GetRoleDirName(info.Namespace, info.RoleName))
+			if err != nil {
+				return fmt.Errorf("failed to build %s: %w", archive, err)
+			}
+			fmt.Fprintf(w, "\033[32mBuilt %s (%d files)\033[0m\n", archive, count)
+			if dryRun {
+				fmt.Fprintln(w, "\033[33mDry run: not importing into Galaxy\033[0m")
+				return nil
+			}
+
+			if githubUser == "" || githubRepo == "" {
+				user, repo, err := originGitHubRepo()
+				if err != nil {
+					return err
+				}
+				githubUser = cmp.Or(githubUser, user)
+				githubRepo = cmp.Or(githubRepo, repo)
+			}
+			env, serverURL, err := publishServerEnv(server)
+			if err != nil {
+				return err
+			}
+			if _, err := publishLookPath("ansible-galaxy"); err != nil {
+				return fmt.Errorf("ansible-galaxy not found on PATH (install it with 'pipx install ansible-core')")
+			}
+
+			importArgs := []string{"role", "import"}
+			if tag != "" {
+				importArgs = append(importArgs, "--branch", tag)
+			}
+			importArgs = append(importArgs, githubUser, githubRepo)
+			fmt.Fprintf(w, "Importing %s/%s as %s.%s into %s...\n", githubUser, githubRepo, info.Namespace, info.RoleName, serverURL)
+			out, err := publishExec(env, "ansible-galaxy", importArgs...)
+			if err != nil {
+				return fmt.Errorf("galaxy import of %s/%s failed: %s", githubUser, githubRepo, galaxyErrorMessage(out, err))
+			}
+			fmt.Fprintf(w, "\033[32mPublished %s.%s to %s\033[0m\n", info.Namespace, info.RoleName, serverURL)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only build the role archive in dist/, do not import")
+	cmd.Flags().StringVar(&tag, "tag", "", "import this git tag (its meta/main.yml must match the current namespace and name)")
+	cmd.Flags().StringVar(&server, "server", "", "galaxy server URL (default: the 'diffusion galaxy login' server, else galaxy.ansible.com)")
+	cmd.Flags().StringVar(&githubUser, "github-user", "", "GitHub user or organization of the role repository (default: from the origin remote)")
+	cmd.Flags().StringVar(&githubRepo, "github-repo", "", "GitHub repository name (default: from the origin remote)")
+
+	return cmd
+}
+
+// publishArchiveName returns <namespace>-<role_name>[-<tag>].tar.gz
+func publishArchiveName(info *role.GalaxyInfo, tag string) string {
+	name := info.Namespace + "-" + info.RoleName
+	if tag != "" {
+		name += "-" + strings.TrimPrefix(tag, "v")
+	}
+	return name + ".tar.gz"
+}
+
+// checkPublishTag makes sure tag exists and its meta/main.yml names the same
+// namespace and role as the working tree
+func checkPublishTag(tag string, info *role.GalaxyInfo) error {
+	if out, err := publishExec(nil, "git", "rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err != nil {
+		return fmt.Errorf("git tag %q not found: %s", tag, strings.TrimSpace(string(out)))
+	}
+	out, err := publishExec(nil, "git", "show", tag+":meta/main.yml")
+	if err != nil {
+		return fmt.Errorf("failed to read meta/main.yml at %s: %s", tag, strings.TrimSpace(string(out)))
+	}
+	var tagged role.Meta
+	if err := yaml.Unmarshal(out, &tagged); err != nil || tagged.GalaxyInfo == nil {
+		return fmt.Errorf("meta/main.yml at %s has no galaxy_info", tag)
+	}
+	if tagged.GalaxyInfo.Namespace != info.Namespace || tagged.GalaxyInfo.RoleName != info.RoleName {
+		return fmt.Errorf("meta/main.yml at %s names %s.%s, not %s.%s", tag,
+			tagged.GalaxyInfo.Namespace, tagged.GalaxyInfo.RoleName, info.Namespace, info.RoleName)
+	}
+	return nil
+}
+
+// exportPublishTag extracts the files of a git tag below tmp with git archive
+// and returns the directory holding them
+func exportPublishTag(tag, tmp string) (string, error) {
+	tarPath := filepath.Join(tmp, "tag.tar")
+	if out, err := publishExec(nil, "git", "archive", "--format=tar", "--output", tarPath, tag); err != nil {
+		return "", fmt.Errorf("git archive %s failed: %s", tag, strings.TrimSpace(string(out)))
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	root := filepath.Join(tmp, "tree")
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read the archive of %s: %w", tag, err)
+		}
+		name := filepath.FromSlash(strings.TrimSuffix(hdr.Name, "/"))
+		if !filepath.IsLocal(name) {
+			return "", fmt.Errorf("archive of %s has an unsafe path %q", tag, hdr.Name)
+		}
+		target := filepath.Join(root, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(out, tr)
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+		// Symlinks and the pax header git writes are skipped, as in buildRoleArchive
+	}
+}
+
+// buildRoleArchive writes the role at root to a gzipped tarball at output, with
+// entries under prefix/, and returns the number of files
+func buildRoleArchive(root, output, prefix string) (count int, err error) {
+	matcher, err := utils.LoadIgnoreFile(root)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.WalkDir(root, func(p string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if excludedFromPublish(rel) || matcher.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // Symlinks and special files are not part of a role archive
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(prefix, rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		if _, err := io.Copy(tw, src); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return count, gz.Close()
+}
+
+// excludedFromPublish reports whether a role-relative path is one of the
+// top-level publishExcluded entries or below one
+func excludedFromPublish(rel string) bool {
+	top, _, _ := strings.Cut(rel, "/")
+	for _, name := range publishExcluded {
+		if top == name {
+			return true
+		}
+	}
+	return false
+}
+
+// originGitHubRepo returns the GitHub user and repository of the origin remote
+func originGitHubRepo() (string, string, error) {
+	out, err := publishExec(nil, "git", "remote", "get-url", "origin")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the origin remote (use --github-user and --github-repo): %s", strings.TrimSpace(string(out)))
+	}
+	user, repo, ok := parseGitHubRemote(strings.TrimSpace(string(out)))
+	if !ok {
+		return "", "", fmt.Errorf("origin remote %q is not a GitHub repository (use --github-user and --github-repo)", strings.TrimSpace(string(out)))
+	}
+	return user, repo, nil
+}
+
+// parseGitHubRemote splits git@github.com:user/repo.git, https://github.com/user/repo
+// and ssh://git@github.com/user/repo remotes into user and repository
+func parseGitHubRemote(remote string) (string, string, bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(remote, "git@github.com:"):
+		rest = strings.TrimPrefix(remote, "git@github.com:")
+	default:
+		i := strings.Index(remote, "github.com/")
+		if i < 0 {
+			return "", "", false
+		}
+		rest = remote[i+len("github.com/"):]
+	}
+	rest = strings.TrimSuffix(strings.TrimSuffix(rest, "/"), ".git")
+	user, repo, ok := strings.Cut(rest, "/")
+	if !ok || user == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return user, repo, true
+}
+
+// publishServerEnv returns the ANSIBLE_GALAXY_SERVER_* variables selecting the
+// galaxy server and token for the import, and the server URL
+func publishServerEnv(server string) ([]string, string, error) {
+	creds, err := loadPublishCredentials()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load the galaxy login: %w", err)
+	}
+	if creds == nil {
+		creds = &secrets.GalaxyCredentials{}
+	}
+	token := cmp.Or(os.Getenv(config.EnvGalaxyToken), creds.Token)
+	if token == "" {
+		return nil, "", fmt.Errorf("no galaxy token: run 'diffusion galaxy login' or set %s", config.EnvGalaxyToken)
+	}
+	serverURL := cmp.Or(server, creds.URL, config.DefaultGalaxyServerURL)
+
+	env := []string{
+		"ANSIBLE_GALAXY_SERVER_LIST=publish",
+		"ANSIBLE_GALAXY_SERVER_PUBLISH_URL=" + serverURL,
+		"ANSIBLE_GALAXY_SERVER_PUBLISH_TOKEN=" + token,
+	}
+	if creds.AuthURL != "" && serverURL == creds.URL {
+		env = append(env, "ANSIBLE_GALAXY_SERVER_PUBLISH_AUTH_URL="+creds.AuthURL)
+	}
+	return env, serverURL, nil
+}
+
+// galaxyErrorMessage picks the "ERROR!" lines of ansible-galaxy output, falling
+// back to the whole output and then to the exit error
+func galaxyErrorMessage(out []byte, err error) string {
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "ERROR!") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if len(lines) > 0 {
+		return strings.Join(lines, "; ")
+	}
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
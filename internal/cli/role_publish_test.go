@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// publishCall is one command run by role publish
+type publishCall struct {
+	env  []string
+	name string
+	args []string
+}
+
+// stubPublish replaces git, ansible-galaxy and the galaxy login. outputs maps
+// "name arg0 arg1" prefixes to the output returned for matching commands;
+// fail makes ansible-galaxy fail.
+func stubPublish(t *testing.T, creds *secrets.GalaxyCredentials, outputs map[string]string, fail bool) *[]publishCall {
+	t.Helper()
+	origExec, origLook, origCreds := publishExec, publishLookPath, loadPublishCredentials
+	t.Cleanup(func() { publishExec, publishLookPath, loadPublishCredentials = origExec, origLook, origCreds })
+
+	var calls []publishCall
+	publishExec = func(env []string, name string, args ...string) ([]byte, error) {
+		calls = append(calls, publishCall{env: env, name: name, args: args})
+		line := strings.Join(append([]string{name}, args...), " ")
+		for prefix, out := range outputs {
+			if strings.HasPrefix(line, prefix) {
+				return []byte(out), nil
+			}
+		}
+		if name == "ansible-galaxy" && fail {
+			return []byte("Starting import\nERROR! Galaxy import failed: role name is already taken\n"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+	publishLookPath = func(string) (string, error) { return "/usr/bin/ansible-galaxy", nil }
+	loadPublishCredentials = func() (*secrets.GalaxyCredentials, error) { return creds, nil }
+	return &calls
+}
+
+// writePublishRole creates a role in the working directory
+func writePublishRole(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		"meta/main.yml":       "galaxy_info:\n  namespace: acme\n  role_name: web\n",
+		"tasks/main.yml":      "- debug: msg=hi\n",
+		"files/nested/ok.txt": "ok",
+		"files/big.bin":       "data",
+		"files/site.key":      "secret",
+		"vars/secrets.yml":    "password: x\n",
+		"README.md":           "# web\n",
+		".git/HEAD":           "ref: refs/heads/main\n",
+		"molecule/default/x":  "x",
+		"dist/old.tar.gz":     "old",
+		config.ConfigFileName: "",
+		config.IgnoreFileName: "files/big.bin\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// archiveEntries lists the file entries of a gzipped tarball
+func archiveEntries(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			names = append(names, hdr.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRolePublishDryRunBuildsArchive(t *testing.T) {
+	writePublishRole(t)
+	calls := stubPublish(t, nil, nil, false)
+
+	var out bytes.Buffer
+	cmd := newRolePublishCmd()
+	cmd.SetArgs([]string{"--dry-run"})
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("role publish --dry-run error: %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("dry run ran %+v, want nothing", *calls)
+	}
+
+	got := archiveEntries(t, filepath.Join("dist", "acme-web.tar.gz"))
+	for _, want := range []string{"acme.web/meta/main.yml", "acme.web/tasks/main.yml", "acme.web/files/nested/ok.txt", "acme.web/README.md"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("archive is missing %s: %v", want, got)
+		}
+	}
+	for _, name := range got {
+		for _, excluded := range []string{"/.git/", "/molecule/", "/dist/", "big.bin", "site.key", "secrets.yml", config.ConfigFileName, config.IgnoreFileName} {
+			if strings.Contains(name, excluded) {
+				t.Errorf("archive contains excluded %s", name)
+			}
+		}
+	}
+	if !strings.Contains(out.String(), "Dry run") {
+		t.Errorf("output = %q, want the dry run note", out.String())
+	}
+}
+
+func TestRolePublishImportsWithStoredLogin(t *testing.T) {
+	writePublishRole(t)
+	t.Setenv(config.EnvGalaxyToken, "")
+	creds := &secrets.GalaxyCredentials{URL: "https://galaxy.example.com/", Token: "tok-123"}
+	calls := stubPublish(t, creds, map[string]string{"git remote get-url origin": "git@github.com:acme/ansible-role-web.git\n"}, false)
+
+	cmd := newRolePublishCmd()
+	cmd.SetArgs(nil)
+	cmd.SetOut(io.Discard)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("role publish error: %v", err)
+	}
+
+	imp := (*calls)[len(*calls)-1]
+	if imp.name != "ansible-galaxy" || !slices.Equal(imp.args, []string{"role", "import", "acme", "ansible-role-web"}) {
+		t.Fatalf("import call = %+v", imp)
+	}
+	for _, want := range []string{"ANSIBLE_GALAXY_SERVER_PUBLISH_URL=https://galaxy.example.com/", "ANSIBLE_GALAXY_SERVER_PUBLISH_TOKEN=tok-123"} {
+		if !slices.Contains(imp.env, want) {
+			t.Errorf("import env = %q, want %s", imp.env, want)
+		}
+	}
+	if slices.ContainsFunc(imp.args, func(a string) bool { return strings.Contains(a, "tok-123") }) {
+		t.Error("token passed as an argument")
+	}
+}
+
+func TestRolePublishSurfacesGalaxyErrors(t *testing.T) {
+	writePublishRole(t)
+	t.Setenv(config.EnvGalaxyToken, "env-token")
+	stubPublish(t, nil, nil, true)
+
+	cmd := newRolePublishCmd()
+	cmd.SetArgs([]string{"--github-user", "acme", "--github-repo", "web"})
+	cmd.SetOut(io.Discard)
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "ERROR! Galaxy import failed: role name is already taken") || strings.Contains(err.Error(), "Starting import") {
+		t.Fatalf("role publish error = %v, want only the galaxy ERROR! line", err)
+	}
+}
+
+func TestRolePublishTagMustMatchMeta(t *testing.T) {
+	writePublishRole(t)
+	t.Setenv(config.EnvGalaxyToken, "env-token")
+	calls := stubPublish(t, nil, map[string]string{
+		"git show v1.0.0:meta/main.yml": "galaxy_info:\n  namespace: acme\n  role_name: nginx\n",
+		"git show v2.0.0:meta/main.yml": "galaxy_info:\n  namespace: acme\n  role_name: web\n",
+	}, false)
+
+	cmd := newRolePublishCmd()
+	cmd.SetArgs([]string{"--tag", "v1.0.0", "--github-user", "acme", "--github-repo", "web"})
+	cmd.SetOut(io.Discard)
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "names acme.nginx, not acme.web") {
+		t.Fatalf("role publish --tag v1.0.0 error = %v, want a meta mismatch", err)
+	}
+
+	// git archive writes the tagged tree, which differs from the working tree
+	stubbed := publishExec
+	publishExec = func(env []string, name string, args ...string) ([]byte, error) {
+		if name == "git" && len(args) > 0 && args[0] == "archive" {
+			writeTagTar(t, args[slices.Index(args, "--output")+1], map[string]string{
+				"meta/main.yml":  "galaxy_info:\n  namespace: acme\n  role_name: web\n",
+				"tasks/main.yml": "- debug: msg=tagged\n",
+			})
+		}
+		return stubbed(env, name, args...)
+	}
+
+	*calls = nil
+	cmd = newRolePublishCmd()
+	cmd.SetArgs([]string{"--tag", "v2.0.0", "--github-user", "acme", "--github-repo", "web"})
+	cmd.SetOut(io.Discard)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("role publish --tag v2.0.0 error: %v", err)
+	}
+	imp := (*calls)[len(*calls)-1]
+	if !slices.Equal(imp.args, []string{"role", "import", "--branch", "v2.0.0", "acme", "web"}) {
+		t.Errorf("import args = %q", imp.args)
+	}
+	got := archiveEntries(t, filepath.Join("dist", "acme-web-2.0.0.tar.gz"))
+	if !slices.Equal(got, []string{"acme.web/meta/main.yml", "acme.web/tasks/main.yml"}) {
+		t.Errorf("tagged archive = %v, want only the files of the tag", got)
+	}
+}
+
+// writeTagTar writes a tar of files to path, as git archive does
+func writeTagTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseGitHubRemote(t *testing.T) {
+	tests := []struct {
+		remote     string
+		user, repo string
+		ok         bool
+	}{
+		{"git@github.com:acme/ansible-role-web.git", "acme", "ansible-role-web", true},
+		{"https://github.com/acme/web", "acme", "web", true},
+		{"https://github.com/acme/web.git/", "acme", "web", true},
+		{"ssh://git@github.com/acme/web.git", "acme", "web", true},
+		{"https://gitlab.com/acme/web.git", "", "", false},
+		{"https://github.com/acme", "", "", false},
+	}
+	for _, tt := range tests {
+		user, repo, ok := parseGitHubRemote(tt.remote)
+		if user != tt.user || repo != tt.repo || ok != tt.ok {
+			t.Errorf("parseGitHubRemote(%q) = %q, %q, %v; want %q, %q, %v", tt.remote, user, repo, ok, tt.user, tt.repo, tt.ok)
+		}
+	}
+}
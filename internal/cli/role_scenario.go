@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"diffusion/internal/role"
+
+	"github.com/spf13/cobra"
+)
+
+// newRoleScenarioCmd creates the scenario subcommand group
+func newRoleScenarioCmd() *cobra.Command {
+	scenarioCmd := &cobra.Command{
+		Use:   "scenario",
+		Short: "Manage molecule scenarios of the role",
+	}
+	scenarioCmd.AddCommand(newRoleScenarioCloneCmd())
+	return scenarioCmd
+}
+
+// newRoleScenarioCloneCmd creates the scenario clone subcommand
+func newRoleScenarioCloneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clone <existing> <new>",
+		Short: "Copy an existing scenario to a new one",
+		Long: `Copy scenarios/<existing> to scenarios/<new>, including its requirements.yml,
+as a starting point for a variant scenario. The command refuses to overwrite an
+existing scenario.`,
+		Example: "  diffusion role scenario clone default ubuntu",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := role.CloneScenario(".", args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\033[32mScenario %q cloned to scenarios/%s\033[0m\n", args[0], args[1])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"diffusion/internal/dependency"
+	"diffusion/internal/role"
+	"diffusion/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// treeNode is one line of the role tree; Children are rendered indented below it
+type treeNode struct {
+	Name     string     `json:"name"`
+	Version  string     `json:"version,omitempty"`  // Constraint from meta/requirements or the lock
+	Resolved string     `json:"resolved,omitempty"` // Version pinned in diffusion.lock
+	Source   string     `json:"source,omitempty"`   // Git src of a role
+	Children []treeNode `json:"children,omitempty"`
+}
+
+// newRoleTreeCmd creates the tree subcommand
+func newRoleTreeCmd() *cobra.Command {
+	var (
+		scenario string
+		depth    int
+		asJSON   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tree",
+		Short: "Print the role's collections, roles and tools as a tree",
+		Long: `Print what the role pulls in, grouped under the role: meta/main.yml and
+requirements.yml collections, requirements.yml roles with their sources, and the
+tools and Python versions from diffusion.lock. Resolved versions come from
+diffusion.lock when it exists. The command is read-only.`,
+		Example: `  diffusion role tree
+  diffusion role tree --depth 2
+  diffusion role tree --scenario ubuntu --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if depth < 0 {
+				return fmt.Errorf("--depth must be 0 (unlimited) or greater")
+			}
+			meta, req, err := role.LoadRoleConfig(scenario)
+			if err != nil {
+				return fmt.Errorf("failed to load role config: %w", err)
+			}
+			lockFile, err := dependency.LoadLockFile()
+			if err != nil {
+				return fmt.Errorf("failed to load lock file: %w", err)
+			}
+
+			tree := pruneTree(buildRoleTree(meta, req, lockFile, scenario), depth)
+			if asJSON {
+				out, err := json.MarshalIndent(tree, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(out))
+				return nil
+			}
+			writeTree(cmd.OutOrStdout(), tree)
+			if lockFile == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "\033[33mdiffusion.lock not found; run 'diffusion deps lock' for resolved versions\033[0m")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&scenario, "scenario", "s", "default", "Molecule scenarios folder to use")
+	cmd.Flags().IntVar(&depth, "depth", 0, "levels below the role to print (0 = unlimited)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the tree as JSON")
+
+	return cmd
+}
+
+// buildRoleTree groups the role's dependencies under it: collections, roles,
+// tools and Python. lockFile may be nil, in which case nothing is resolved.
+func buildRoleTree(meta *role.Meta, req *role.Requirement, lockFile *dependency.LockFile, scenario string) treeNode {
+	root := treeNode{Name: roleTreeName(meta)}
+
+	root.Children = append(root.Children,
+		treeNode{Name: "collections", Children: collectionNodes(meta, req, lockFile, scenario)},
+		treeNode{Name: "roles", Children: roleNodes(req, lockFile, scenario)},
+	)
+	if lockFile != nil {
+		root.Children = append(root.Children, treeNode{Name: "tools", Children: toolNodes(lockFile)})
+		if lockFile.Python != nil {
+			root.Children = append(root.Children, treeNode{
+				Name:     "python",
+				Version:  fmt.Sprintf("%s-%s", lockFile.Python.Min, lockFile.Python.Max),
+				Resolved: lockFile.Python.Pinned,
+			})
+		}
+	}
+	return root
+}
+
+// roleTreeName returns namespace.role_name from meta/main.yml
+func roleTreeName(meta *role.Meta) string {
+	if meta == nil || meta.GalaxyInfo == nil {
+		return "role"
+	}
+	return utils.GetRoleDirName(meta.GalaxyInfo.Namespace, meta.GalaxyInfo.RoleName)
+}
+
+// collectionNodes merges meta and requirements collections, keyed by full name
+func collectionNodes(meta *role.Meta, req *role.Requirement, lockFile *dependency.LockFile, scenario string) []treeNode {
+	constraints := map[string]string{}
+	if meta != nil {
+		for _, col := range meta.Collections {
+			name, version := utils.ParseCollectionString(col)
+			constraints[name] = version
+		}
+	}
+	if req != nil {
+		for _, col := range req.Collections {
+			if col.Version != "" || constraints[col.Name] == "" {
+				constraints[col.Name] = col.Version
+			}
+		}
+	}
+
+	names := make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make([]treeNode, 0, len(names))
+	for _, name := range names {
+		node := treeNode{Name: name, Version: constraints[name]}
+		if entry := findLockEntry(lockCollections(lockFile), scenario, name); entry != nil {
+			node.Resolved = entry.ResolvedVersion
+			if node.Version == "" {
+				node.Version = entry.Version
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// roleNodes lists the requirements.yml roles with their sources
+func roleNodes(req *role.Requirement, lockFile *dependency.LockFile, scenario string) []treeNode {
+	if req == nil {
+		return nil
+	}
+	nodes := make([]treeNode, 0, len(req.Roles))
+	for _, r := range req.Roles {
+		node := treeNode{Name: r.Name, Version: r.Version, Source: r.Src}
+		if entry := findLockEntry(lockRoles(lockFile), scenario, r.Name); entry != nil {
+			node.Resolved = entry.ResolvedVersion
+			if node.Source == "" {
+				node.Source = entry.Src
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+// toolNodes lists the locked tools with their Python dependencies as children
+func toolNodes(lockFile *dependency.LockFile) []treeNode {
+	nodes := make([]treeNode, 0, len(lockFile.Tools))
+	for _, tool := range lockFile.Tools {
+		node := treeNode{Name: tool.Name, Version: tool.Version, Resolved: tool.ResolvedVersion}
+		deps := make([]string, 0, len(tool.PythonDeps))
+		for dep := range tool.PythonDeps {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			node.Children = append(node.Children, treeNode{Name: dep, Resolved: tool.PythonDeps[dep]})
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func lockCollections(lockFile *dependency.LockFile) []dependency.LockFileEntry {
+	if lockFile == nil {
+		return nil
+	}
+	return lockFile.Collections
+}
+
+func lockRoles(lockFile *dependency.LockFile) []dependency.LockFileEntry {
+	if lockFile == nil {
+		return nil
+	}
+	return lockFile.Roles
+}
+
+// findLockEntry finds the lock entry of a scenario by its "namespace.name" or
+// bare name; lock entries are stored as "<scenario>.<name>" plus a namespace
+func findLockEntry(entries []dependency.LockFileEntry, scenario, name string) *dependency.LockFileEntry {
+	for i := range entries {
+		entry := &entries[i]
+		entryScenario, entryName, ok := strings.Cut(entry.Name, ".")
+		if !ok || entryScenario != scenario {
+			continue
+		}
+		if entryName == name || (entry.Namespace != "" && entry.Namespace+"."+entryName == name) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// pruneTree drops nodes more than depth levels below the root; 0 keeps everything
+func pruneTree(node treeNode, depth int) treeNode {
+	if depth == 0 {
+		return node
+	}
+	if depth == 1 {
+		for i := range node.Children {
+			node.Children[i].Children = nil
+		}
+		return node
+	}
+	for i := range node.Children {
+		node.Children[i] = pruneTree(node.Children[i], depth-1)
+	}
+	return node
+}
+
+// writeTree renders the tree with box-drawing branches
+func writeTree(w io.Writer, root treeNode) {
+	fmt.Fprintf(w, "\033[35m%s\033[0m\n", root.Name)
+	writeTreeChildren(w, root.Children, "")
+}
+
+func writeTreeChildren(w io.Writer, children []treeNode, prefix string) {
+	for i, child := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", prefix, branch, treeLabel(child))
+		writeTreeChildren(w, child.Children, prefix+next)
+	}
+}
+
+// treeLabel formats "name constraint → resolved (src)"
+func treeLabel(node treeNode) string {
+	label := node.Name
+	if node.Version != "" {
+		label += " " + node.Version
+	}
+	if node.Resolved != "" && node.Resolved != node.Version {
+		label += " → \033[38;2;127;255;212m" + node.Resolved + "\033[0m"
+	}
+	if node.Source != "" {
+		label += " (" + node.Source + ")"
+	}
+	return label
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/dependency"
+	"diffusion/internal/role"
+)
+
+func sampleRoleTree() treeNode {
+	meta := &role.Meta{
+		GalaxyInfo:  &role.GalaxyInfo{Namespace: "acme", RoleName: "nginx"},
+		Collections: []string{"community.general>=7.4.0", "ansible.posix"},
+	}
+	req := &role.Requirement{
+		Collections: []role.RequirementCollection{{Name: "community.docker", Version: ">=3.0.0"}},
+		Roles:       []role.RequirementRole{{Name: "geerlingguy.docker", Src: "https://github.com/geerlingguy/ansible-role-docker.git", Version: "latest"}},
+	}
+	lock := &dependency.LockFile{
+		Python: &config.PythonVersion{Min: "3.11", Max: "3.13", Pinned: "3.13"},
+		Collections: []dependency.LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.4.0", ResolvedVersion: "7.5.0"},
+			{Name: "default.docker", Namespace: "community", Version: ">=3.0.0", ResolvedVersion: "3.12.1"},
+			{Name: "ubuntu.general", Namespace: "community", Version: ">=7.4.0", ResolvedVersion: "9.0.0"},
+		},
+		Roles: []dependency.LockFileEntry{
+			{Name: "default.geerlingguy.docker", Version: "latest", ResolvedVersion: "v7.4.1", Src: "https://github.com/geerlingguy/ansible-role-docker.git"},
+		},
+		Tools: []dependency.LockFileEntry{
+			{Name: "ansible", Version: ">=10.0.0", ResolvedVersion: "10.3.0", PythonDeps: map[string]string{"jinja2": "3.1.4"}},
+		},
+	}
+	return buildRoleTree(meta, req, lock, "default")
+}
+
+func TestBuildRoleTree(t *testing.T) {
+	tree := sampleRoleTree()
+	if tree.Name != "acme.nginx" {
+		t.Errorf("root = %q, want acme.nginx", tree.Name)
+	}
+
+	var out bytes.Buffer
+	writeTree(&out, tree)
+	text := out.String()
+	for _, want := range []string{
+		"├── collections",
+		"ansible.posix",
+		"community.general >=7.4.0 → \033[38;2;127;255;212m7.5.0",
+		"community.docker >=3.0.0 → \033[38;2;127;255;212m3.12.1",
+		"geerlingguy.docker latest → \033[38;2;127;255;212mv7.4.1\033[0m (https://github.com/geerlingguy/ansible-role-docker.git)",
+		"ansible >=10.0.0 → \033[38;2;127;255;212m10.3.0",
+		"jinja2",
+		"└── python 3.11-3.13 → \033[38;2;127;255;212m3.13",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("tree missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "9.0.0") {
+		t.Errorf("tree shows another scenario's lock entry:\n%s", text)
+	}
+}
+
+func TestRoleTreeDepthAndJSON(t *testing.T) {
+	tree := pruneTree(sampleRoleTree(), 1)
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Name     string `json:"name"`
+		Children []struct {
+			Name     string            `json:"name"`
+			Children []json.RawMessage `json:"children"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Name != "acme.nginx" {
+		t.Errorf("name = %q", decoded.Name)
+	}
+	var groups []string
+	for _, group := range decoded.Children {
+		groups = append(groups, group.Name)
+		if len(group.Children) != 0 {
+			t.Errorf("--depth 1 kept children under %s", group.Name)
+		}
+	}
+	if strings.Join(groups, ",") != "collections,roles,tools,python" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	// Depth 2 keeps the entries but not the tool Python deps
+	tree = pruneTree(sampleRoleTree(), 2)
+	tools := tree.Children[2]
+	if len(tools.Children) != 1 || len(tools.Children[0].Children) != 0 {
+		t.Errorf("unexpected tools at depth 2: %+v", tools)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -22,6 +26,7 @@ type CLI struct {
 	RoleSrcFlag     string
 	RoleScmFlag     string
 	RoleVersionFlag string
+	RoleGalaxyFlag  bool
 
 	// Namespace flag (shared by role and collection commands)
 	NamespaceFlag string
@@ -31,16 +36,58 @@ type CLI struct {
 
 	// Molecule flags
 	TagFlag            string
+	LimitFlag          string
+	PlatformFlags      []string
+	EnvFileFlags       []string
+	PreScriptFlags     []string
+	PostScriptFlags    []string
+	PostScriptFatal    bool
+	PullFlag           string
+	BuildContextFlag   string
+	RegistryServerFlag string
+	RegistryImageFlag  string
+	RegistryTagFlag    string
+	DNSFlags           []string
+	DNSSearchFlags     []string
+	AllScenariosFlag   bool
+	OnlyFlag           string
+	PhasesFlags        []string
 	ConvergeFlag       bool
+	StepFlag           bool
+	PrepareFlag        bool
 	VerifyFlag         bool
+	VerifyOnlyFlag     bool
 	TestsOverWriteFlag bool
+	KeepTestsCacheFlag bool
+	RefreshTestsFlag   bool
 	LintFlag           bool
 	IdempotenceFlag    bool
 	DestroyFlag        bool
+	DestroyFirstFlag   bool
+	ReportJSONFlag     string
 	WipeFlag           bool
+	VerifyCopyFlag     bool
+	KeepCacheFlag      bool
+	PurgeCacheFlag     bool
 	CIMode             bool
 	OidcFlag           bool
 	ForceFlag          bool
+	KeepFlag           bool
+	FixPermissions     bool
+	DestroyOnFailFlag  bool
+	DiffFlag           bool
+	LogsFlag           bool
+	VerbosityFlag      int
+	NoCacheFlag        bool
+	TimeoutFlag        time.Duration
+	RetryFlag          int
+	OnlyChangedFlag    bool
+	SkipUnchangedFlag  bool
+	BaseRefFlag        string
+
+	// Global flags
+	QuietFlag  bool
+	ConfigFlag string
 }
 
 // Execute is the main entry point for the CLI
@@ -54,6 +101,12 @@ func Execute() {
 		Short:   "Molecule workflow helper (cross-platform)",
 		Version: versionInfo,
 	}
+	rootCmd.PersistentFlags().BoolVar(&cli.QuietFlag, "quiet", false, "hide the spinner and info logs; errors and warnings still go to stderr (or set "+config.EnvQuiet+"=1)")
+	rootCmd.PersistentFlags().StringVar(&cli.ConfigFlag, "config", "", "path to diffusion.toml (default: ./diffusion.toml, or set "+config.EnvConfig+")")
+	cobra.OnInitialize(func() {
+		utils.SetQuiet(cli.QuietFlag || utils.IsQuiet())
+		config.SetConfigPath(cli.ConfigFlag)
+	})
 
 	// Add all commands using factory functions
 	rootCmd.AddCommand(NewRoleCmd(cli))
@@ -63,6 +116,13 @@ func Execute() {
 	rootCmd.AddCommand(NewShowCmd(cli))
 	rootCmd.AddCommand(NewDepsCmd(cli))
 	rootCmd.AddCommand(NewDeployCmd(cli))
+	rootCmd.AddCommand(NewDoctorCmd(cli))
+	rootCmd.AddCommand(NewConfigCmd(cli))
+	rootCmd.AddCommand(NewInitCmd(cli))
+	rootCmd.AddCommand(NewSecretsCmd(cli))
+	rootCmd.AddCommand(NewGalaxyCmd(cli))
+	rootCmd.AddCommand(NewLintCmd(cli))
+	rootCmd.AddCommand(NewVersionCmd(cli))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"diffusion/internal/secrets"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSecretsCmd creates the secrets command for managing the local encryption key
+func NewSecretsCmd(cli *CLI) *cobra.Command {
+	secretsCmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the local encryption key for stored artifact credentials",
+	}
+
+	secretsCmd.AddCommand(newSecretsRekeyCmd())
+
+	return secretsCmd
+}
+
+func newSecretsRekeyCmd() *cobra.Command {
+	var keyFile string
+
+	cmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "Rotate the local encryption key and re-encrypt stored credentials",
+		Long: `Re-encrypt every locally stored artifact credential (all roles) with a new key.
+Credentials live in ~/.diffusion/secrets/<role>/<source>; the key is stored in
+~/.diffusion/secrets/.key (mode 0600). Before the first rekey the key is derived
+from hostname and username. The new key is random unless --key-file is given
+(32 bytes, raw or base64). On failure the credentials and key are rolled back.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var newKey []byte
+			if keyFile != "" {
+				data, err := os.ReadFile(keyFile)
+				if err != nil {
+					return fmt.Errorf("failed to read key file: %w", err)
+				}
+				if newKey, err = secrets.ParseKey(data); err != nil {
+					return fmt.Errorf("invalid key file %s: %w", keyFile, err)
+				}
+			} else {
+				var err error
+				if newKey, err = secrets.GenerateKey(); err != nil {
+					return err
+				}
+			}
+
+			count, err := secrets.Rekey(newKey)
+			if err != nil {
+				return fmt.Errorf("failed to rekey credentials: %w", err)
+			}
+
+			keyPath, err := secrets.KeyPath()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\033[32mRe-encrypted %d credential(s) with the new key\033[0m\n", count)
+			fmt.Printf("\033[35mKey file: \033[0m\033[38;2;127;255;212m%s\033[0m\n", keyPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "read the new 32-byte key (raw or base64) from this file instead of generating one")
+
+	return cmd
+}
@@ -9,7 +9,6 @@ import (
 
 	"diffusion/internal/config"
 	"diffusion/internal/role"
-	"diffusion/internal/secrets"
 	"diffusion/internal/utils"
 )
 
@@ -62,8 +61,30 @@ func AnsibleGalaxyInit() (string, error) {
 	}
 	// Create scenarios/default directory structure
 	scenariosPath := filepath.Join(currentDir, roleName, "scenarios", "default")
-	if err := os.MkdirAll(scenariosPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create scenarios directory: %w", err)
+	if err := writeScenarioFiles(scenariosPath); err != nil {
+		return "", err
+	}
+
+	// Create .gitignore file
+	gitignoreContent := `**/molecule/*
+**/roles/*
+vars/secrets.yml
+.diffusion.toml.lock
+`
+	gitignorePath := filepath.Join(currentDir, roleName, ".gitignore")
+	if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
+		return "", fmt.Errorf("failed to create .gitignore: %w", err)
+	}
+
+	fmt.Printf("Created .gitignore in %s\n", roleName)
+	return roleName, nil
+}
+
+// writeScenarioFiles creates a scenario directory with commented converge.yml,
+// molecule.yml, prepare.yml and verify.yml templates
+func writeScenarioFiles(scenarioPath string) error {
+	if err := os.MkdirAll(scenarioPath, 0755); err != nil {
+		return fmt.Errorf("failed to create scenarios directory: %w", err)
 	}
 
 	converge_content := `# Converge playbook
@@ -124,35 +145,31 @@ provisioner:
 verifier:
    name: ansible
 `
-	// Create converge.yml
-	convergePath := filepath.Join(currentDir, roleName, "scenarios", "default", "converge.yml")
-	if err := os.WriteFile(convergePath, []byte(converge_content), 0644); err != nil {
-		return "", fmt.Errorf("failed to create converge.yml: %w", err)
-	}
+	prepare_content := `# Prepare playbook
+# Runs once after create and before converge (diffusion molecule --prepare)
+---
+- name: Prepare
+  hosts: all
+  gather_facts: false
+#  tasks:
+#    - name: Install prerequisites
+#      ansible.builtin.package:
+#        name: YOUR_PACKAGE
+#        state: present
+`
 
-	// Create molecule.yml
-	moleculePath := filepath.Join(currentDir, roleName, "scenarios", "default", "molecule.yml")
-	if err := os.WriteFile(moleculePath, []byte(molecule_content), 0644); err != nil {
-		return "", fmt.Errorf("failed to create molecule.yml: %w", err)
+	files := []struct{ name, content string }{
+		{"converge.yml", converge_content},
+		{"molecule.yml", molecule_content},
+		{"prepare.yml", prepare_content},
+		{"verify.yml", verify_content},
 	}
-	// Create verify.yml
-	verifyPath := filepath.Join(currentDir, roleName, "scenarios", "default", "verify.yml")
-	if err := os.WriteFile(verifyPath, []byte(verify_content), 0644); err != nil {
-		return "", fmt.Errorf("failed to create verify.yml: %w", err)
-	}
-
-	// Create .gitignore file
-	gitignoreContent := `**/molecule/*
-**/roles/*
-vars/secrets.yml
-`
-	gitignorePath := filepath.Join(currentDir, roleName, ".gitignore")
-	if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
-		return "", fmt.Errorf("failed to create .gitignore: %w", err)
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(scenarioPath, f.name), []byte(f.content), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", f.name, err)
+		}
 	}
-
-	fmt.Printf("Created .gitignore in %s\n", roleName)
-	return roleName, nil
+	return nil
 }
 
 func MetaConfigSetup(roleName string) *role.Meta {
@@ -372,7 +389,9 @@ func ArtifactSourcesHelper() []config.ArtifactSource {
 		}
 
 		if useVault {
-			fmt.Printf("Enter Vault path for %s (e.g., secret/data/artifacts): ", name)
+			source.VaultKVVersion = promptVaultKVVersion(reader)
+
+			fmt.Printf("Enter Vault path for %s (e.g., %s): ", name, vaultPathExample(source.VaultKVVersion))
 			vaultPath, _ := reader.ReadString('\n')
 			source.VaultPath = strings.TrimSpace(vaultPath)
 
@@ -416,10 +435,14 @@ func ArtifactSourcesHelper() []config.ArtifactSource {
 				Token:    token,
 			}
 
-			if err := secrets.SaveArtifactCredentials(creds); err != nil {
+			store, err := openSecretStore()
+			if err == nil {
+				err = store.Save(creds)
+			}
+			if err != nil {
 				fmt.Printf("\033[33mWarning: failed to save credentials for '%s': %v\033[0m\n", name, err)
 			} else {
-				fmt.Printf("\033[32mCredentials for '%s' saved locally (encrypted)\033[0m\n", name)
+				fmt.Printf("\033[32mCredentials for '%s' saved (%s)\033[0m\n", name, storeLocation(store, name))
 			}
 		}
 
@@ -466,10 +489,95 @@ func TestsConfigSetup() *config.TestsSettings {
 			}
 		}
 	}
-	testsSettings := &config.TestsSettings{
-		Type:               configType,
-		RemoteRepositories: remoteReposList,
+	return newTestsSettings(configType, remoteReposList)
+}
+
+// BasicRegistryPrompt asks for the Basic registry username and where its
+// password comes from: an environment variable or a stored credential.
+func BasicRegistryPrompt(reader *bufio.Reader) (username, passwordEnv, passwordCredential string) {
+	fmt.Print("Enter registry username: ")
+	username, _ = reader.ReadString('\n')
+	username = strings.TrimSpace(username)
+	if username == "" {
+		fmt.Fprintln(os.Stderr, "\033[31mThe Basic registry provider requires a username.\033[0m")
+		os.Exit(1)
 	}
 
-	return testsSettings
+	fmt.Print("Read registry password from env / credential (Default: env): ")
+	source, _ := reader.ReadString('\n')
+	source = strings.TrimSpace(strings.ToLower(source))
+
+	switch source {
+	case "", "env":
+		fmt.Printf("Enter password environment variable (%s): ", config.EnvRegistryPassword)
+		passwordEnv, _ = reader.ReadString('\n')
+		passwordEnv = strings.TrimSpace(passwordEnv)
+		if passwordEnv == "" {
+			passwordEnv = config.EnvRegistryPassword
+		}
+	case "credential":
+		fmt.Print("Enter stored credential name (see 'diffusion artifact add'): ")
+		passwordCredential, _ = reader.ReadString('\n')
+		passwordCredential = strings.TrimSpace(passwordCredential)
+		if passwordCredential == "" {
+			fmt.Fprintln(os.Stderr, "\033[31mA credential name is required.\033[0m")
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "\033[31mInvalid password source. Allowed values are: env, credential.\033[0m")
+		os.Exit(1)
+	}
+	return username, passwordEnv, passwordCredential
+}
+
+// newTestsSettings builds the [tests] section, defaulting to the diffusion type
+func newTestsSettings(testsType string, remoteRepos []string) *config.TestsSettings {
+	if testsType == "" {
+		testsType = config.TestsTypeDiffusion
+	}
+	if remoteRepos == nil {
+		remoteRepos = []string{}
+	}
+	return &config.TestsSettings{
+		Type:               testsType,
+		RemoteRepositories: remoteRepos,
+	}
+}
+
+// defaultYamlLint returns the yamllint settings written to new configs
+func defaultYamlLint() *config.YamlLint {
+	return &config.YamlLint{
+		Extends: "default",
+		Ignore:  []string{".git/*", "molecule/**", "vars/*", "files/*", ".yamllint", ".ansible-lint"},
+		Rules: &config.YamlLintRules{
+			Braces:              map[string]any{"max-spaces-inside": 1, "level": "warning"},
+			Brackets:            map[string]any{"max-spaces-inside": 1, "level": "warning"},
+			NewLines:            map[string]any{"type": "platform"},
+			Comments:            map[string]any{"min-spaces-from-content": 1},
+			CommentsIndentation: false,
+			OctalValues:         map[string]any{"forbid-implicit-octal": true},
+		},
+	}
+}
+
+// defaultAnsibleLint returns the ansible-lint settings written to new configs
+func defaultAnsibleLint() *config.AnsibleLint {
+	return &config.AnsibleLint{
+		ExcludedPaths: []string{"molecule/default/tests/*.yml", "molecule/default/tests/*/*/*.yml", "tests/test.yml"},
+		WarnList:      []string{"meta-no-info", "yaml[line-length]"},
+		SkipList:      []string{"meta-incorrect", "role-name[path]"},
+	}
+}
+
+// newConfig assembles a new diffusion.toml with the default linter settings.
+// Used by both the interactive molecule setup and 'diffusion init'.
+func newConfig(registry *config.ContainerRegistry, vaultEnabled bool, sources []config.ArtifactSource, tests *config.TestsSettings) *config.Config {
+	return &config.Config{
+		ContainerRegistry: registry,
+		HashicorpVault:    VaultConfigHelper(vaultEnabled),
+		ArtifactSources:   sources,
+		YamlLintConfig:    defaultYamlLint(),
+		AnsibleLintConfig: defaultAnsibleLint(),
+		TestsConfig:       tests,
+	}
 }
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteScenarioFiles tests that role init scaffolds all scenario playbooks
+func TestWriteScenarioFiles(t *testing.T) {
+	scenarioPath := filepath.Join(t.TempDir(), "myrole", "scenarios", "default")
+	if err := writeScenarioFiles(scenarioPath); err != nil {
+		t.Fatalf("writeScenarioFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"converge.yml", "molecule.yml", "prepare.yml", "verify.yml"} {
+		if _, err := os.Stat(filepath.Join(scenarioPath, name)); err != nil {
+			t.Errorf("expected %s to be created: %v", name, err)
+		}
+	}
+
+	prepare, err := os.ReadFile(filepath.Join(scenarioPath, "prepare.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(prepare), "- name: Prepare") {
+		t.Errorf("prepare.yml missing Prepare play:\n%s", prepare)
+	}
+	if !strings.Contains(string(prepare), "#  tasks:") {
+		t.Errorf("prepare.yml tasks should be commented out:\n%s", prepare)
+	}
+}
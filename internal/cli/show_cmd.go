@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"diffusion/internal/config"
 
@@ -10,14 +11,21 @@ import (
 
 // NewShowCmd creates the show command
 func NewShowCmd(cli *CLI) *cobra.Command {
+	var format string
 	showCmd := &cobra.Command{
 		Use:   "show",
 		Short: "Display all diffusion configuration in readable format",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateShowFormat(format); err != nil {
+				return err
+			}
 			cfg, err := config.LoadConfig()
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
+			if format != showFormatText {
+				return writeConfig(os.Stdout, cfg, format)
+			}
 
 			fmt.Println("\n\033[1m=== Diffusion Configuration ===\033[0m")
 
@@ -25,6 +33,15 @@ func NewShowCmd(cli *CLI) *cobra.Command {
 			fmt.Println("\033[35m[Container Registry]\033[0m")
 			fmt.Printf("  Registry Server:         \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.RegistryServer)
 			fmt.Printf("  Registry Provider:       \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.RegistryProvider)
+			if cfg.ContainerRegistry.Username != "" {
+				fmt.Printf("  Registry Username:       \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.Username)
+			}
+			if cfg.ContainerRegistry.PasswordEnv != "" {
+				fmt.Printf("  Registry Password Env:   \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.PasswordEnv)
+			}
+			if cfg.ContainerRegistry.PasswordCredential != "" {
+				fmt.Printf("  Registry Credential:     \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.PasswordCredential)
+			}
 			fmt.Printf("  Molecule Container Name: \033[38;2;127;255;212m%s\033[0m\n", cfg.ContainerRegistry.MoleculeContainerName)
 			fmt.Printf("  Molecule Container Tag:  \033[38;2;127;255;212m%s\033[0m\n\n", cfg.ContainerRegistry.MoleculeContainerTag)
 
@@ -129,5 +146,7 @@ func NewShowCmd(cli *CLI) *cobra.Command {
 		},
 	}
 
+	showCmd.Flags().StringVar(&format, "format", showFormatText, "output format: text, json or yaml (secrets in URLs and extra_env are masked)")
+
 	return showCmd
 }
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/galaxy"
+
+	"github.com/spf13/cobra"
+)
+
+// releaseCheckTimeout bounds the GitHub lookup so --check never hangs offline
+const releaseCheckTimeout = 5 * time.Second
+
+// latestReleaseURL is the GitHub releases endpoint; replaced in tests
+var latestReleaseURL = config.DiffusionLatestReleaseAPI
+
+// versionReport is the --json output of the version command
+type versionReport struct {
+	Current         string `json:"current"`
+	Latest          string `json:"latest,omitempty"`
+	UpdateAvailable bool   `json:"update_available"`
+	Error           string `json:"error,omitempty"` // Why the latest release could not be checked
+}
+
+// NewVersionCmd creates the version command
+func NewVersionCmd(cli *CLI) *cobra.Command {
+	var (
+		check  bool
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the diffusion version, optionally checking for a newer release",
+		Long: `Print the diffusion version. With --check the latest GitHub release of
+Polar-Team/diffusion is looked up (5s timeout) and compared with this build; when
+GitHub cannot be reached only the local version is printed. The network is only
+used with --check.`,
+		Example: `  diffusion version
+  diffusion version --check
+  diffusion version --check --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+			report := versionReport{Current: Version}
+			if check {
+				client := &http.Client{Timeout: releaseCheckTimeout}
+				report = checkLatestRelease(client, Version)
+			}
+
+			if asJSON {
+				out, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(w, string(out))
+				return nil
+			}
+
+			fmt.Fprintf(w, "diffusion %s\nGo version: %s\nOS/Arch: %s/%s\n", Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+			if check {
+				writeReleaseCheck(w, report)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "compare with the latest GitHub release")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print current, latest and update_available as JSON")
+
+	return cmd
+}
+
+// checkLatestRelease compares current with the latest release; a failed lookup
+// is reported in Error instead of failing the command. Development builds are
+// never reported as outdated.
+func checkLatestRelease(client *http.Client, current string) versionReport {
+	report := versionReport{Current: current}
+	latest, err := fetchLatestRelease(client, latestReleaseURL)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Latest = latest
+	report.UpdateAvailable = current != "dev" && galaxy.CompareVersions(latest, current) > 0
+	return report
+}
+
+// fetchLatestRelease returns the tag of the latest GitHub release
+func fetchLatestRelease(client *http.Client, url string) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("latest release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// writeReleaseCheck prints the --check result below the version
+func writeReleaseCheck(w io.Writer, report versionReport) {
+	switch {
+	case report.Error != "":
+		fmt.Fprintf(w, "\033[33mCould not check for a newer release: %s\033[0m\n", report.Error)
+	case report.UpdateAvailable:
+		fmt.Fprintf(w, "\033[33mA newer release is available: %s (this is %s)\033[0m\n", report.Latest, report.Current)
+	default:
+		fmt.Fprintf(w, "\033[32mUp to date (latest release: %s)\033[0m\n", report.Latest)
+	}
+}
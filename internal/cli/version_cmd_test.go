@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveLatestRelease points latestReleaseURL at a server answering with tag
+func serveLatestRelease(t *testing.T, status int, tag string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"tag_name":"` + tag + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	orig := latestReleaseURL
+	latestReleaseURL = srv.URL
+	t.Cleanup(func() { latestReleaseURL = orig })
+}
+
+func TestCheckLatestRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    string
+		tag        string
+		wantUpdate bool
+	}{
+		{name: "newer release", current: "0.5.7", tag: "v0.6.0", wantUpdate: true},
+		{name: "same release", current: "v0.6.0", tag: "v0.6.0", wantUpdate: false},
+		{name: "ahead of release", current: "0.6.1", tag: "v0.6.0", wantUpdate: false},
+		{name: "development build", current: "dev", tag: "v0.6.0", wantUpdate: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveLatestRelease(t, http.StatusOK, tt.tag)
+			report := checkLatestRelease(http.DefaultClient, tt.current)
+			if report.Error != "" {
+				t.Fatalf("unexpected error %q", report.Error)
+			}
+			if report.Latest != tt.tag || report.UpdateAvailable != tt.wantUpdate {
+				t.Errorf("report = %+v, want latest %s, update %v", report, tt.tag, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestCheckLatestReleaseOffline(t *testing.T) {
+	serveLatestRelease(t, http.StatusForbidden, "")
+	report := checkLatestRelease(http.DefaultClient, "0.5.7")
+	if report.Error == "" || report.Latest != "" || report.UpdateAvailable {
+		t.Errorf("expected a degraded report, got %+v", report)
+	}
+
+	var out bytes.Buffer
+	writeReleaseCheck(&out, report)
+	if !strings.Contains(out.String(), "Could not check") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestVersionCmdJSON(t *testing.T) {
+	serveLatestRelease(t, http.StatusOK, "v99.0.0")
+	orig := Version
+	Version = "0.5.7"
+	t.Cleanup(func() { Version = orig })
+
+	cmd := NewVersionCmd(&CLI{})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--check", "--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("version --check --json error: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if got["current"] != "0.5.7" || got["latest"] != "v99.0.0" || got["update_available"] != true {
+		t.Errorf("unexpected report %v", got)
+	}
+}
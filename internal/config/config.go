@@ -2,8 +2,14 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 )
@@ -33,7 +39,8 @@ type DependencyConfig struct {
 	Molecule    string                  `toml:"molecule,omitempty"`     // e.g., ">=24.0.0"
 	YamlLint    string                  `toml:"yamllint,omitempty"`     // e.g., ">=1.35.0"
 	Collections []CollectionRequirement `toml:"collections,omitempty"`
-	Roles       []RoleRequirement       `toml:"roles,omitempty"` // Roles per scenario: scenario.role_name
+	Roles       []RoleRequirement       `toml:"roles,omitempty"`       // Roles per scenario: scenario.role_name
+	Constraints string                  `toml:"constraints,omitempty"` // Path or URL of a shared constraints file with floor versions
 }
 
 // RoleRequirement represents a role with version constraints
@@ -61,6 +68,7 @@ type ArtifactSource struct {
 	VaultSecretName    string `toml:"vault_secret_name,omitempty"`
 	VaultUsernameField string `toml:"vault_username_field,omitempty"`
 	VaultTokenField    string `toml:"vault_token_field,omitempty"`
+	VaultKVVersion     int    `toml:"vault_kv_version,omitempty"` // KV secret engine version: 1 or 2 (default 2)
 	UseVault           bool   `toml:"use_vault"`
 }
 
@@ -154,11 +162,65 @@ type ContainerRegistry struct {
 	RegistryProvider      string `toml:"registry_provider"`
 	MoleculeContainerName string `toml:"molecule_container_name"`
 	MoleculeContainerTag  string `toml:"molecule_container_tag"`
+
+	// Basic provider only: docker login username and the password source, either
+	// an environment variable name or a credential stored with 'diffusion artifact add'
+	Username           string `toml:"username,omitempty"`
+	PasswordEnv        string `toml:"password_env,omitempty"`
+	PasswordCredential string `toml:"password_credential,omitempty"`
 }
 
 type TestsSettings struct {
 	Type               string   `toml:"type"`
 	RemoteRepositories []string `toml:"remote_repositories,omitempty"`
+	DiffusionRepo      string   `toml:"diffusion_repo,omitempty"` // Repository of the diffusion tests type; empty uses DiffusionTestsRepo
+}
+
+// DiffusionRepository returns the repository cloned for the diffusion tests type
+func (t *TestsSettings) DiffusionRepository() string {
+	if t == nil || t.DiffusionRepo == "" {
+		return DiffusionTestsRepo
+	}
+	return t.DiffusionRepo
+}
+
+// Validate checks the configured diffusion tests repository
+func (t *TestsSettings) Validate() error {
+	if t == nil || t.DiffusionRepo == "" {
+		return nil
+	}
+	if err := ValidateGitURL(t.DiffusionRepo); err != nil {
+		return fmt.Errorf("[tests] diffusion_repo: %w", err)
+	}
+	return nil
+}
+
+// scpGitURL matches scp-style git remotes such as git@github.com:org/repo.git
+var scpGitURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^/\s][^\s]*$`)
+
+// ValidateGitURL checks that u is a URL git can clone: https, http, ssh, git
+// or file URLs, or scp-style user@host:path remotes
+func ValidateGitURL(u string) error {
+	if scpGitURL.MatchString(u) {
+		return nil
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid git URL %q: %w", u, err)
+	}
+	switch parsed.Scheme {
+	case "https", "http", "ssh", "git":
+		if parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+			return fmt.Errorf("invalid git URL %q: expected <scheme>://<host>/<repository>", u)
+		}
+	case "file":
+		if parsed.Path == "" {
+			return fmt.Errorf("invalid git URL %q: missing path", u)
+		}
+	default:
+		return fmt.Errorf("invalid git URL %q: use https://, ssh://, git://, file:// or user@host:path", u)
+	}
+	return nil
 }
 
 type CacheSettings struct {
@@ -167,55 +229,367 @@ type CacheSettings struct {
 	CachePath   string `toml:"cache_path,omitempty"`   // Custom cache path (optional)
 	DockerCache bool   `toml:"docker_cache,omitempty"` // Cache Docker images as tarballs
 	UVCache     bool   `toml:"uv_cache,omitempty"`     // Cache UV/Python packages
+
+	DockerPerImage bool `toml:"docker_per_image,omitempty"` // Save one tarball per image (images/<id>.tar) instead of a single images.tar
+	WarnSizeMB     int  `toml:"warn_size_mb,omitempty"`     // Warn after a run when the cache exceeds this size (0 = default)
+}
+
+// AnsibleCfgSettings is rendered into an ansible.cfg at the root of the role
+// layout so molecule converge/verify pick it up. Unset fields use defaults.
+type AnsibleCfgSettings struct {
+	Forks            int                          `toml:"forks,omitempty"`
+	Timeout          int                          `toml:"timeout,omitempty"`           // Connection timeout in seconds
+	HostKeyChecking  *bool                        `toml:"host_key_checking,omitempty"` // Defaults to false
+	CollectionsPaths []string                     `toml:"collections_paths,omitempty"`
+	RolesPath        []string                     `toml:"roles_path,omitempty"`
+	Extra            map[string]map[string]string `toml:"extra,omitempty"` // Additional settings: section -> key -> value
+}
+
+// HooksSettings are shell snippets run inside the molecule container, in the
+// role directory, around the molecule phases. Snippets run in order.
+type HooksSettings struct {
+	PreConverge     []string `toml:"pre_converge,omitempty"`      // Before converge; a failure aborts the run
+	PostVerify      []string `toml:"post_verify,omitempty"`       // After verify, whether it passed or not
+	PostVerifyFatal bool     `toml:"post_verify_fatal,omitempty"` // Fail the run when a post_verify snippet fails
+}
+
+// DefaultsSettings seeds command flags from the config. Keys are flag names
+// without dashes; flags given on the command line override them.
+type DefaultsSettings struct {
+	Molecule map[string]any `toml:"molecule,omitempty"` // Defaults for 'diffusion molecule' flags, e.g. ci = true, tag = "install"
+}
+
+// SecretsSettings selects where artifact credentials are stored locally
+type SecretsSettings struct {
+	Backend      string `toml:"backend,omitempty"`       // file (default) or age
+	AgeIdentity  string `toml:"age_identity,omitempty"`  // age identity file used to decrypt (required for age)
+	AgeRecipient string `toml:"age_recipient,omitempty"` // Encrypt to this recipient instead of the identity's own
+}
+
+// ContainerSettings holds extra docker run options for the molecule container.
+// Values support ${VAR} environment interpolation.
+type ContainerSettings struct {
+	ExtraEnv     map[string]string `toml:"extra_env,omitempty"`     // Extra -e NAME=value pairs
+	ExtraVolumes []string          `toml:"extra_volumes,omitempty"` // Extra -v src:dst[:mode] mounts
+	Tmpfs        []string          `toml:"tmpfs,omitempty"`         // Extra --tmpfs dst[:options] mounts
+	PullPolicy   string            `toml:"pull_policy,omitempty"`   // docker run --pull: always (default), missing or never
+	DNS          []string          `toml:"dns,omitempty"`           // DNS server IPs for the container and its inner Docker daemon
+	DNSSearch    []string          `toml:"dns_search,omitempty"`    // DNS search domains for the container and its inner Docker daemon
+	BuildSecrets []BuildSecret     `toml:"build_secrets,omitempty"` // BuildKit secrets for building the molecule image
+	BuildContext string            `toml:"build_context,omitempty"` // Build the molecule image from this directory (with a Dockerfile) instead of pulling
+	HomePath     string            `toml:"home_path,omitempty"`     // Home of the image's working user, where the .ansible cache mounts go (default /root)
+}
+
+// BuildSecret exposes a credential to a local molecule image build as a BuildKit
+// secret (docker build --secret id=<ID>,src=<file>). The Dockerfile reads it with
+// RUN --mount=type=secret,id=<ID>, so it never lands in build args or layers.
+// Exactly one of Source or File must be set.
+type BuildSecret struct {
+	ID     string `toml:"id"`               // Secret id referenced by RUN --mount=type=secret,id=...
+	Source string `toml:"source,omitempty"` // Artifact source whose stored credential is exposed
+	Field  string `toml:"field,omitempty"`  // Credential field of Source: "token" (default) or "username"
+	File   string `toml:"file,omitempty"`   // Host file passed through as-is
+}
+
+// Validate checks that the secret has an id and exactly one value origin
+func (b BuildSecret) Validate() error {
+	if b.ID == "" {
+		return fmt.Errorf("build secret: id is required")
+	}
+	if (b.Source == "") == (b.File == "") {
+		return fmt.Errorf("build secret %q: set exactly one of source or file", b.ID)
+	}
+	switch b.Field {
+	case "", "token", "username":
+	default:
+		return fmt.Errorf("build secret %q: unknown field %q (expected token or username)", b.ID, b.Field)
+	}
+	return nil
 }
 
 type Config struct {
-	ContainerRegistry *ContainerRegistry `toml:"container_registry"`
-	HashicorpVault    *HashicorpVault    `toml:"vault"`
-	ArtifactSources   []ArtifactSource   `toml:"artifact_sources,omitempty"`
-	YamlLintConfig    *YamlLint          `toml:"yaml_lint"`
-	AnsibleLintConfig *AnsibleLint       `toml:"ansible_lint"`
-	TestsConfig       *TestsSettings     `toml:"tests"`
-	CacheConfig       *CacheSettings     `toml:"cache,omitempty"`
-	DependencyConfig  *DependencyConfig  `toml:"dependencies,omitempty"`
+	ConfigVersion     int                 `toml:"config_version"` // Schema version, see CurrentConfigVersion
+	ContainerRegistry *ContainerRegistry  `toml:"container_registry"`
+	HashicorpVault    *HashicorpVault     `toml:"vault"`
+	ArtifactSources   []ArtifactSource    `toml:"artifact_sources,omitempty"`
+	YamlLintConfig    *YamlLint           `toml:"yaml_lint"`
+	AnsibleLintConfig *AnsibleLint        `toml:"ansible_lint"`
+	TestsConfig       *TestsSettings      `toml:"tests"`
+	CacheConfig       *CacheSettings      `toml:"cache,omitempty"`
+	DependencyConfig  *DependencyConfig   `toml:"dependencies,omitempty"`
+	ContainerConfig   *ContainerSettings  `toml:"container,omitempty"`
+	AnsibleCfgConfig  *AnsibleCfgSettings `toml:"ansible_cfg,omitempty"`
+	HooksConfig       *HooksSettings      `toml:"hooks,omitempty"`
+	SecretsConfig     *SecretsSettings    `toml:"secrets,omitempty"`
+	DefaultsConfig    *DefaultsSettings   `toml:"defaults,omitempty"`
 }
 
-// LoadConfig reads configuration from a TOML file in the project directory
-func LoadConfig() (*Config, error) {
+// configPathOverride is the config file set with --config, if any
+var configPathOverride string
+
+// SetConfigPath overrides the config file used by LoadConfig and SaveConfig.
+// An empty path restores the default lookup.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// ConfigPath returns the config file used by LoadConfig and SaveConfig: the
+// --config path, else DIFFUSION_CONFIG, else diffusion.toml in the working directory
+func ConfigPath() (string, error) {
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
 	projectDir, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get project directory: %w", err)
+		return "", fmt.Errorf("failed to get project directory: %w", err)
 	}
-	configPath := filepath.Join(projectDir, "diffusion.toml")
+	return filepath.Join(projectDir, ConfigFileName), nil
+}
 
-	data, err := os.ReadFile(configPath)
+// migrationHint makes LoadConfig point at 'diffusion config migrate' once per process
+var migrationHint sync.Once
+
+// LoadConfig reads configuration from the TOML file returned by ConfigPath.
+// An older schema version is migrated in memory only; the file is upgraded by
+// the next save or by MigrateConfigFile.
+func LoadConfig() (*Config, error) {
+	configPath, err := ConfigPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
 
-	var configMap *Config
-	if err := toml.Unmarshal(data, &configMap); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+	configMap, notes, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) > 0 {
+		migrationHint.Do(func() {
+			log.Printf(ColorYellow+"%s uses an older config_version; run 'diffusion config migrate' to upgrade it"+ColorReset, configPath)
+		})
 	}
 
 	return configMap, nil
 }
 
+// MigrateConfigFile upgrades the file returned by ConfigPath to
+// CurrentConfigVersion under the config lock and returns the applied
+// migrations. A current file is left untouched.
+func MigrateConfigFile() ([]string, error) {
+	configPath, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	configMap, notes, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 || configMap == nil {
+		return nil, nil
+	}
+	if err := writeConfigFile(configPath, configMap); err != nil {
+		return nil, fmt.Errorf("failed to save migrated config: %w", err)
+	}
+	return notes, nil
+}
+
+// SaveConfig writes configuration back to the TOML file returned by ConfigPath.
+// The write holds the config lock; use UpdateConfig when the new content
+// depends on what is currently in the file.
 func SaveConfig(config *Config) error {
-	projectDir, err := os.Getwd()
+	configPath, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return writeConfigFile(configPath, config)
+}
+
+// UpdateConfig loads the config, applies fn and saves the result while holding
+// the config lock, so concurrent diffusion processes never overwrite each
+// other's changes. Nothing is written when fn returns an error.
+func UpdateConfig(fn func(*Config) error) error {
+	configPath, err := ConfigPath()
 	if err != nil {
-		return fmt.Errorf("failed to get project directory: %w", err)
+		return err
 	}
-	configPath := filepath.Join(projectDir, "diffusion.toml")
 
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	configMap, notes, err := readConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	if configMap == nil {
+		configMap = &Config{}
+	}
+	if err := fn(configMap); err != nil {
+		return err
+	}
+	if err := writeConfigFile(configPath, configMap); err != nil {
+		return err
+	}
+	logMigrationNotes(configPath, notes)
+	return nil
+}
+
+// readConfigFile reads and migrates the config without taking the lock
+func readConfigFile(configPath string) (*Config, []string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return MigrateConfig(data)
+}
+
+// writeConfigFile replaces the config through a temporary file in the same
+// directory, so readers never see a half-written file. The caller holds the lock.
+func writeConfigFile(configPath string, config *Config) error {
+	if config != nil {
+		config.ConfigVersion = CurrentConfigVersion
+	}
 	newData, err := toml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, newData, 0644); err != nil {
+	// Keep a symlinked diffusion.toml a symlink
+	if resolved, err := filepath.EvalSymlinks(configPath); err == nil {
+		configPath = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), "."+filepath.Base(configPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(newData); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), configPath); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
+
+func logMigrationNotes(configPath string, notes []string) {
+	for _, note := range notes {
+		log.Printf(ColorYellow+"Migrated %s (%s)"+ColorReset, configPath, note)
+	}
+}
+
+// ValidateTmpfsSpec checks that a tmpfs mount looks like dst[:options] with an
+// absolute container path, e.g. /run or /run/lock:rw,size=64m.
+func ValidateTmpfsSpec(spec string) error {
+	target, options, hasOptions := strings.Cut(spec, ":")
+	if !strings.HasPrefix(target, "/") {
+		return fmt.Errorf("invalid tmpfs %q: mount target must be an absolute container path", spec)
+	}
+	if hasOptions && options == "" {
+		return fmt.Errorf("invalid tmpfs %q: options must not be empty after ':'", spec)
+	}
+	return nil
+}
+
+// ValidateContainerHomePath checks that [container] home_path is an absolute
+// container path that can be used as a -v target
+func ValidateContainerHomePath(home string) error {
+	if !strings.HasPrefix(home, "/") {
+		return fmt.Errorf("invalid home_path %q: must be an absolute container path", home)
+	}
+	if strings.Contains(home, ":") {
+		return fmt.Errorf("invalid home_path %q: must not contain ':'", home)
+	}
+	return nil
+}
+
+// ValidateDNSServer checks that a --dns value is an IPv4 or IPv6 address
+func ValidateDNSServer(server string) error {
+	if net.ParseIP(server) == nil {
+		return fmt.Errorf("invalid DNS server %q: must be an IP address", server)
+	}
+	return nil
+}
+
+// dnsSearchDomain matches a search domain such as corp.example.com
+var dnsSearchDomain = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*\.?$`)
+
+// ValidateDNSSearch checks that a --dns-search value is a domain name
+func ValidateDNSSearch(domain string) error {
+	if !dnsSearchDomain.MatchString(domain) {
+		return fmt.Errorf("invalid DNS search domain %q", domain)
+	}
+	return nil
+}
+
+// ValidatePullPolicy checks a docker run --pull value
+func ValidatePullPolicy(policy string) error {
+	switch policy {
+	case PullPolicyAlways, PullPolicyMissing, PullPolicyNever:
+		return nil
+	}
+	return fmt.Errorf("invalid pull policy %q. Allowed values are: always, missing, never", policy)
+}
+
+// volumeModes lists the options docker accepts in the mode part of a -v mount
+var volumeModes = map[string]bool{
+	"ro": true, "rw": true, "z": true, "Z": true, "nocopy": true,
+	"cached": true, "delegated": true, "consistent": true,
+	"shared": true, "slave": true, "private": true,
+	"rshared": true, "rslave": true, "rprivate": true,
+}
+
+// ValidateVolumeSpec checks that a volume string looks like src:dst[:mode].
+// A leading Windows drive letter (C:\path) is allowed in src.
+func ValidateVolumeSpec(spec string) error {
+	rest := spec
+	drive := ""
+	if len(rest) >= 2 && rest[1] == ':' && ((rest[0] >= 'a' && rest[0] <= 'z') || (rest[0] >= 'A' && rest[0] <= 'Z')) {
+		drive, rest = rest[:2], rest[2:]
+	}
+
+	parts := strings.Split(rest, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid volume %q: expected src:dst[:mode]", spec)
+	}
+	if drive+parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid volume %q: source and destination must not be empty", spec)
+	}
+	if !strings.HasPrefix(parts[1], "/") {
+		return fmt.Errorf("invalid volume %q: destination %q must be an absolute container path", spec, parts[1])
+	}
+	if len(parts) == 3 {
+		for _, mode := range strings.Split(parts[2], ",") {
+			if !volumeModes[mode] {
+				return fmt.Errorf("invalid volume %q: unknown mode %q", spec, mode)
+			}
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -281,4 +282,160 @@ func TestArtifactCredentials_Validation(t *testing.T) {
 	if creds.Username != "testuser" {
 		t.Errorf("expected Username 'testuser', got %q", creds.Username)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateVolumeSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"/etc/ssl/certs:/etc/ssl/certs", false},
+		{"/etc/ssl/certs:/etc/ssl/certs:ro", false},
+		{"/data:/data:ro,z", false},
+		{"named-volume:/cache", false},
+		{`C:\certs:/etc/ssl/certs:ro`, false},
+		{"/etc/ssl/certs", true},
+		{":/dst", true},
+		{"/src:", true},
+		{"/src:relative", true},
+		{"/src:/dst:bogus", true},
+		{"/a:/b:ro:extra", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			err := ValidateVolumeSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVolumeSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTmpfsSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"/tmp", false},
+		{"/run/lock:rw,size=64m", false},
+		{"run", true},
+		{"", true},
+		{"/run:", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTmpfsSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTmpfsSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateContainerHomePath(t *testing.T) {
+	for _, home := range []string{"/root", "/home/ansible"} {
+		if err := ValidateContainerHomePath(home); err != nil {
+			t.Errorf("ValidateContainerHomePath(%q) = %v", home, err)
+		}
+	}
+	for _, home := range []string{"home/ansible", "~", "/home/a:b"} {
+		if err := ValidateContainerHomePath(home); err == nil {
+			t.Errorf("ValidateContainerHomePath(%q) should fail", home)
+		}
+	}
+}
+
+func TestValidateDNS(t *testing.T) {
+	servers := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"10.0.0.53", false},
+		{"2001:4860:4860::8888", false},
+		{"10.0.0", true},
+		{"dns.corp.example.com", true},
+		{"", true},
+	}
+	for _, tt := range servers {
+		if err := ValidateDNSServer(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDNSServer(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+
+	domains := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"corp.example.com", false},
+		{"internal", false},
+		{"-bad.example.com", true},
+		{"has space.com", true},
+		{"", true},
+	}
+	for _, tt := range domains {
+		if err := ValidateDNSSearch(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDNSSearch(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfig_CustomPath(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "custom.toml")
+	content := "config_version = 1\n\n[tests]\ntype = \"local\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Run from a directory without diffusion.toml
+	t.Chdir(t.TempDir())
+	t.Setenv(EnvConfig, "")
+	SetConfigPath(configPath)
+	t.Cleanup(func() { SetConfigPath("") })
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.TestsConfig == nil || cfg.TestsConfig.Type != TestsTypeLocal {
+		t.Fatalf("expected tests type %q from %s, got %+v", TestsTypeLocal, configPath, cfg.TestsConfig)
+	}
+
+	cfg.TestsConfig.Type = TestsTypeDiffusion
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if _, err := os.Stat(ConfigFileName); !os.IsNotExist(err) {
+		t.Errorf("SaveConfig wrote %s in the working directory", ConfigFileName)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig after save failed: %v", err)
+	}
+	if reloaded.TestsConfig.Type != TestsTypeDiffusion {
+		t.Errorf("expected saved tests type %q at %s, got %q", TestsTypeDiffusion, configPath, reloaded.TestsConfig.Type)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Cleanup(func() { SetConfigPath("") })
+
+	t.Setenv(EnvConfig, "")
+	if got, _ := ConfigPath(); filepath.Base(got) != ConfigFileName {
+		t.Errorf("default ConfigPath() = %q, want %s in the working directory", got, ConfigFileName)
+	}
+
+	t.Setenv(EnvConfig, "/etc/diffusion/env.toml")
+	if got, _ := ConfigPath(); got != "/etc/diffusion/env.toml" {
+		t.Errorf("ConfigPath() with %s = %q", EnvConfig, got)
+	}
+
+	// --config takes precedence over the environment
+	SetConfigPath("/srv/flag.toml")
+	if got, _ := ConfigPath(); got != "/srv/flag.toml" {
+		t.Errorf("ConfigPath() with SetConfigPath = %q", got)
+	}
+}
@@ -26,6 +26,7 @@ const (
 	MoleculeContainerPrefix      = "molecule-"
 	DiffusionTestsRepo           = "https://github.com/Polar-Team/diffusion-ansible-tests-role.git"
 	DiffusionTestsTempPrefix     = "diffusion-tests-"
+	DiffusionLatestReleaseAPI    = "https://api.github.com/repos/Polar-Team/diffusion/releases/latest"
 	BufferSize                   = 32 * 1024 // 32KB buffer for file I/O
 	DefaultRegistryServer        = "ghcr.io"
 	DefaultRegistryProvider      = "Public"
@@ -38,6 +39,15 @@ const (
 	DefaultAnsibleLintVersion = ">=24.0.0" // Requires Python 3.10+
 	DefaultMoleculeVersion    = ">=24.0.0" // Requires Python 3.10+
 	DefaultYamlLintVersion    = ">=1.35.0"
+	// ansible.cfg defaults
+	DefaultAnsibleForks   = 10
+	DefaultAnsibleTimeout = 30
+	// Cache size (MB) above which a post-run warning is printed
+	DefaultCacheWarnSizeMB = 10240
+	// Galaxy servers used by 'diffusion galaxy login'
+	DefaultAutomationHubURL     = "https://console.redhat.com/api/automation-hub/content/published/"
+	DefaultAutomationHubAuthURL = "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token"
+	DefaultGalaxyServerURL      = "https://galaxy.ansible.com/"
 )
 
 // File paths
@@ -47,7 +57,9 @@ const (
 	RequirementsFileName   = "requirements.yml"
 	YamlLintFileName       = ".yamllint"
 	AnsibleLintFileName    = ".ansible-lint"
+	AnsibleCfgFileName     = "ansible.cfg"
 	GitIgnoreFileName      = ".gitignore"
+	IgnoreFileName         = ".diffusionignore" // gitignore-style patterns CopyRoleData leaves out
 	MoleculeDir            = "molecule"
 	ScenariosDir           = "scenarios"
 	TestsDir               = "tests"
@@ -58,17 +70,20 @@ const (
 
 // Cache directory names and container paths
 const (
-	CacheRolesDir                 = "roles"
-	CacheCollectionsDir           = "collections"
-	CacheUVDir                    = "uv"
-	CacheDockerDir                = "docker"
-	ContainerRolesCachePath       = "/root/.ansible/roles"       // Ansible roles inside the container
-	ContainerCollectionsCachePath = "/root/.ansible/collections" // Ansible collections inside the container
-	ContainerUVCachePath          = "/root/.cache/uv"            // UV cache location inside the container
-	ContainerUVPrecachePath       = "/root/.precache/uv"         // UV staging path for Windows (NTFS mount point)
-	UVCacheTarball                = "uv-cache.tar"               // Filename for packed UV cache tarball (Windows precache)
-	ContainerDockerCachePath      = "/root/.cache/docker"        // Docker image tarballs inside the container
-	DockerImageTarball            = "images.tar"                 // Filename for cached Docker image tarball (multi-image)
+	CacheRolesDir              = "roles"
+	CacheCollectionsDir        = "collections"
+	CacheUVDir                 = "uv"
+	CacheDockerDir             = "docker"
+	DefaultContainerHomePath   = "/root"                // Home of the molecule container user unless [container] home_path is set
+	ContainerRolesSubdir       = ".ansible/roles"       // Ansible roles, relative to the container home
+	ContainerCollectionsSubdir = ".ansible/collections" // Ansible collections, relative to the container home
+	ContainerUVCachePath       = "/root/.cache/uv"      // UV cache location inside the container
+	ContainerUVPrecachePath    = "/root/.precache/uv"   // UV staging path for Windows (NTFS mount point)
+	UVCacheTarball             = "uv-cache.tar"         // Filename for packed UV cache tarball (Windows precache)
+	ContainerDockerCachePath   = "/root/.cache/docker"  // Docker image tarballs inside the container
+	DockerImageTarball         = "images.tar"           // Filename for cached Docker image tarball (multi-image)
+	DockerImagesDir            = "images"               // Subdirectory for per-image tarballs (<image-id>.tar)
+	DockerImagesManifest       = "manifest.json"        // Lists the per-image tarballs and their tags
 )
 
 // Registry providers
@@ -77,6 +92,24 @@ const (
 	RegistryProviderAWS    = "AWS"
 	RegistryProviderGCP    = "GCP"
 	RegistryProviderPublic = "Public"
+	RegistryProviderBasic  = "Basic" // Static username/password (DockerHub, Quay, Harbor)
+)
+
+// Image pull policies for the molecule container (docker run --pull)
+const (
+	PullPolicyAlways  = "always"
+	PullPolicyMissing = "missing"
+	PullPolicyNever   = "never"
+
+	DefaultPullPolicy = PullPolicyAlways
+)
+
+// Local credential store backends ([secrets] backend)
+const (
+	SecretsBackendFile = "file" // AES-GCM files under ~/.diffusion/secrets (default)
+	SecretsBackendAge  = "age"  // age-encrypted files, using the age CLI and an age identity
+
+	DefaultSecretsBackend = SecretsBackendFile
 )
 
 // Test configuration types
@@ -105,17 +138,24 @@ const (
 
 // Environment variables
 const (
-	EnvToken           = "TOKEN"
-	EnvVaultToken      = "VAULT_TOKEN"
-	EnvVaultAddr       = "VAULT_ADDR"
-	EnvGitUserPrefix   = "GIT_USER_"     // Indexed: GIT_USER_1, GIT_USER_2, etc.
-	EnvGitPassPrefix   = "GIT_PASSWORD_" // Indexed: GIT_PASSWORD_1, GIT_PASSWORD_2, etc.
-	EnvGitURLPrefix    = "GIT_URL_"      // Indexed: GIT_URL_1, GIT_URL_2, etc.
-	EnvYCCloudID       = "YC_CLOUD_ID"
-	EnvYCFolderID      = "YC_FOLDER_ID"
-	EnvGCPProjectID    = "GCP_PROJECT_ID"
-	EnvAnsibleRunTags  = "ANSIBLE_RUN_TAGS"
-	MaxArtifactSources = 10 // Maximum number of artifact sources supported
+	EnvToken            = "TOKEN"
+	EnvVaultToken       = "VAULT_TOKEN"
+	EnvVaultAddr        = "VAULT_ADDR"
+	EnvVaultRoleID      = "VAULT_ROLE_ID"   // AppRole role ID; with EnvVaultSecretID an expiring token is replaced by an AppRole login
+	EnvVaultSecretID    = "VAULT_SECRET_ID" // AppRole secret ID
+	EnvGitUserPrefix    = "GIT_USER_"       // Indexed: GIT_USER_1, GIT_USER_2, etc.
+	EnvGitPassPrefix    = "GIT_PASSWORD_"   // Indexed: GIT_PASSWORD_1, GIT_PASSWORD_2, etc.
+	EnvGitURLPrefix     = "GIT_URL_"        // Indexed: GIT_URL_1, GIT_URL_2, etc.
+	EnvYCCloudID        = "YC_CLOUD_ID"
+	EnvYCFolderID       = "YC_FOLDER_ID"
+	EnvGCPProjectID     = "GCP_PROJECT_ID"
+	EnvAnsibleRunTags   = "ANSIBLE_RUN_TAGS"
+	EnvQuiet            = "DIFFUSION_QUIET"         // Same as --quiet when set to a true value
+	EnvConfig           = "DIFFUSION_CONFIG"        // Same as --config: path to diffusion.toml
+	EnvRegistryPassword = "REGISTRY_PASSWORD"       // Default password variable of the Basic registry provider
+	EnvGalaxyToken      = "DIFFUSION_GALAXY_TOKEN"  // Token read by 'diffusion galaxy login' instead of prompting
+	EnvGalaxyServer     = "DIFFUSION_GALAXY_SERVER" // Server URL read by 'diffusion galaxy login' instead of prompting
+	MaxArtifactSources  = 10                        // Maximum number of artifact sources supported
 )
 
 // GCP-specific constants
@@ -125,7 +165,7 @@ const (
 
 // Error messages
 const (
-	ErrInvalidRegistryProvider = "invalid RegistryProvider. Allowed values are: YC, AWS, GCP, Public"
+	ErrInvalidRegistryProvider = "invalid RegistryProvider. Allowed values are: YC, AWS, GCP, Basic, Public"
 	ErrRoleNameEmpty           = "role name cannot be empty"
 )
 
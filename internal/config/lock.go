@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrConfigLocked is returned when another process keeps the config lock
+// longer than ConfigLockTimeout
+var ErrConfigLocked = errors.New("config is locked by another process")
+
+// ConfigLockTimeout is how long a config write waits for another diffusion
+// process to release the lock
+var ConfigLockTimeout = 10 * time.Second
+
+// configLockRetry is the polling interval while waiting for the lock
+const configLockRetry = 50 * time.Millisecond
+
+// ConfigLockPath returns the advisory lock file guarding configPath, a hidden
+// file next to it (.diffusion.toml.lock)
+func ConfigLockPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "."+filepath.Base(configPath)+".lock")
+}
+
+// lockConfig takes the exclusive advisory lock next to configPath and returns
+// the function releasing it. The lock file itself is left in place, since
+// removing it would let a waiting process lock an unlinked file.
+func lockConfig(configPath string) (func(), error) {
+	lockPath := ConfigLockPath(configPath)
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config lock %s: %w", lockPath, err)
+	}
+
+	deadline := time.Now().Add(ConfigLockTimeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s is held (waited %s); retry once the other diffusion command finishes", ErrConfigLocked, lockPath, ConfigLockTimeout)
+		}
+		time.Sleep(configLockRetry)
+	}
+}
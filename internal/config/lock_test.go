@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateConfigConcurrentWritesKeepEveryChange(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName)
+	t.Setenv(EnvConfig, configPath)
+	if err := SaveConfig(&Config{}); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- UpdateConfig(func(cfg *Config) error {
+				cfg.ArtifactSources = append(cfg.ArtifactSources, ArtifactSource{Name: fmt.Sprintf("source-%d", i)})
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("UpdateConfig() error = %v", err)
+		}
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.ArtifactSources) != writers {
+		t.Fatalf("got %d artifact sources, want %d: %+v", len(cfg.ArtifactSources), writers, cfg.ArtifactSources)
+	}
+}
+
+func TestUpdateConfigSkipsWriteOnError(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName)
+	t.Setenv(EnvConfig, configPath)
+	if err := SaveConfig(&Config{ArtifactSources: []ArtifactSource{{Name: "gitlab"}}}); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	wantErr := errors.New("boom")
+	err := UpdateConfig(func(cfg *Config) error {
+		cfg.ArtifactSources = nil
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("UpdateConfig() error = %v, want %v", err, wantErr)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.ArtifactSources) != 1 {
+		t.Fatalf("config was written despite the error: %+v", cfg.ArtifactSources)
+	}
+}
+
+func TestSaveConfigTimesOutWhenLocked(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName)
+	t.Setenv(EnvConfig, configPath)
+
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		t.Fatalf("lockConfig() error = %v", err)
+	}
+	defer unlock()
+
+	previous := ConfigLockTimeout
+	ConfigLockTimeout = 100 * time.Millisecond
+	defer func() { ConfigLockTimeout = previous }()
+
+	err = SaveConfig(&Config{})
+	if !errors.Is(err, ErrConfigLocked) {
+		t.Fatalf("SaveConfig() error = %v, want ErrConfigLocked", err)
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentConfigVersion is the diffusion.toml schema version written by SaveConfig.
+// Files without config_version are version 0.
+const CurrentConfigVersion = 1
+
+// configMigration upgrades the raw TOML document of version from to from+1.
+// apply reports whether it changed anything.
+type configMigration struct {
+	from        int
+	description string
+	apply       func(raw map[string]any) (bool, error)
+}
+
+// configMigrations is ordered by from; the last entry upgrades to CurrentConfigVersion
+var configMigrations = []configMigration{
+	{from: 0, description: "moved legacy [vault] secret_kv2_* settings and top-level url to [[artifact_sources]]", apply: migrateLegacyArtifactSource},
+}
+
+// MigrateConfig decodes diffusion.toml content, upgrading it from older schema
+// versions first. The returned notes describe the migrations that changed the
+// document; when there are none the file does not need to be rewritten.
+func MigrateConfig(data []byte) (*Config, []string, error) {
+	raw := map[string]any{}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	version, err := rawConfigVersion(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > CurrentConfigVersion {
+		return nil, nil, fmt.Errorf("diffusion.toml has config_version %d, but this diffusion supports up to %d; please upgrade diffusion", version, CurrentConfigVersion)
+	}
+
+	var notes []string
+	for _, m := range configMigrations {
+		if m.from < version {
+			continue
+		}
+		changed, err := m.apply(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate config from version %d to %d: %w", m.from, m.from+1, err)
+		}
+		if changed {
+			notes = append(notes, fmt.Sprintf("v%d -> v%d: %s", m.from, m.from+1, m.description))
+		}
+	}
+
+	if len(notes) > 0 {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode migrated config: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	var cfg *Config
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if cfg != nil {
+		cfg.ConfigVersion = CurrentConfigVersion
+		if err := cfg.TestsConfig.Validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cfg, notes, nil
+}
+
+// rawConfigVersion returns config_version of a decoded document, 0 when absent
+func rawConfigVersion(raw map[string]any) (int, error) {
+	value, ok := raw["config_version"]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := value.(int64)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("invalid config_version %v: expected a non-negative integer", value)
+	}
+	return int(version), nil
+}
+
+// migrateLegacyArtifactSource converts the single-source layout (top-level url
+// plus [vault] secret_kv2_path/secret_kv2_name/username_field/token_field) into
+// an [[artifact_sources]] entry named "primary", as described in the migration guide
+func migrateLegacyArtifactSource(raw map[string]any) (bool, error) {
+	vault, _ := raw["vault"].(map[string]any)
+	url, _ := raw["url"].(string)
+	vaultPath := ""
+	if vault != nil {
+		vaultPath, _ = vault["secret_kv2_path"].(string)
+	}
+	if url == "" && vaultPath == "" {
+		if vault != nil {
+			// Leftover per-field names are meaningless without a legacy path
+			_, hadUser := vault["username_field"]
+			_, hadToken := vault["token_field"]
+			delete(vault, "username_field")
+			delete(vault, "token_field")
+			return hadUser || hadToken, nil
+		}
+		return false, nil
+	}
+
+	sources, err := rawArtifactSources(raw)
+	if err != nil {
+		return false, err
+	}
+
+	source := map[string]any{
+		"name":      legacySourceName(sources),
+		"url":       url,
+		"type":      "git",
+		"use_vault": vaultPath != "",
+	}
+	if vaultPath != "" {
+		source["vault_path"] = vaultPath
+		for legacyKey, key := range map[string]string{
+			"secret_kv2_name": "vault_secret_name",
+			"username_field":  "vault_username_field",
+			"token_field":     "vault_token_field",
+		} {
+			if value, ok := vault[legacyKey].(string); ok && value != "" {
+				source[key] = value
+			}
+		}
+	}
+	if vault != nil {
+		for _, key := range []string{"secret_kv2_path", "secret_kv2_name", "username_field", "token_field"} {
+			delete(vault, key)
+		}
+	}
+	delete(raw, "url")
+
+	raw["artifact_sources"] = append(sources, source)
+	return true, nil
+}
+
+// rawArtifactSources returns the [[artifact_sources]] tables of a decoded document
+func rawArtifactSources(raw map[string]any) ([]map[string]any, error) {
+	switch sources := raw["artifact_sources"].(type) {
+	case nil:
+		return nil, nil
+	case []map[string]any:
+		return sources, nil
+	default:
+		return nil, fmt.Errorf("artifact_sources must be an array of tables")
+	}
+}
+
+// legacySourceName returns "primary", or "legacy" if that name is already taken
+func legacySourceName(sources []map[string]any) string {
+	for _, s := range sources {
+		if s["name"] == "primary" {
+			return "legacy"
+		}
+	}
+	return "primary"
+}
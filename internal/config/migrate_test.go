@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyConfigV0 = `url = "https://artifacts.example.com"
+
+[container_registry]
+registry_server = "ghcr.io"
+registry_provider = "Public"
+molecule_container_name = "polar-team/diffusion-molecule-container"
+molecule_container_tag = "latest"
+
+[vault]
+enabled = true
+secret_kv2_path = "secret/data/git"
+secret_kv2_name = "credentials"
+username_field = "git_username"
+token_field = "git_token"
+
+[tests]
+type = "diffusion"
+`
+
+func TestLoadConfigMigratesLegacyV0(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := filepath.Join(dir, "diffusion.toml")
+	if err := os.WriteFile(path, []byte(legacyConfigV0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.ConfigVersion != CurrentConfigVersion {
+		t.Errorf("ConfigVersion = %d, want %d", cfg.ConfigVersion, CurrentConfigVersion)
+	}
+	if len(cfg.ArtifactSources) != 1 {
+		t.Fatalf("ArtifactSources = %+v, want one migrated source", cfg.ArtifactSources)
+	}
+	want := ArtifactSource{
+		Name:               "primary",
+		URL:                "https://artifacts.example.com",
+		Type:               "git",
+		UseVault:           true,
+		VaultPath:          "secret/data/git",
+		VaultSecretName:    "credentials",
+		VaultUsernameField: "git_username",
+		VaultTokenField:    "git_token",
+	}
+	if cfg.ArtifactSources[0] != want {
+		t.Errorf("migrated source = %+v, want %+v", cfg.ArtifactSources[0], want)
+	}
+	if !cfg.HashicorpVault.HashicorpVaultIntegration || cfg.HashicorpVault.SecretKV2Path != "" || cfg.HashicorpVault.SecretKV2Name != "" {
+		t.Errorf("vault = %+v, want enabled without legacy fields", cfg.HashicorpVault)
+	}
+	if cfg.ContainerRegistry == nil || cfg.ContainerRegistry.RegistryServer != "ghcr.io" {
+		t.Errorf("container_registry lost in migration: %+v", cfg.ContainerRegistry)
+	}
+
+	// Loading migrates in memory only
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != legacyConfigV0 {
+		t.Errorf("LoadConfig() rewrote the file:\n%s", data)
+	}
+
+	notes, err := MigrateConfigFile()
+	if err != nil || len(notes) == 0 {
+		t.Fatalf("MigrateConfigFile() notes = %v, err = %v", notes, err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := string(data)
+	if !strings.Contains(written, "config_version = 1") || strings.Contains(written, "secret_kv2_path") {
+		t.Errorf("migrated file not written as expected:\n%s", written)
+	}
+
+	if _, notes, err := MigrateConfig(data); err != nil || len(notes) != 0 {
+		t.Errorf("MigrateConfig(migrated) notes = %v, err = %v; want no further migration", notes, err)
+	}
+	if notes, err := MigrateConfigFile(); err != nil || len(notes) != 0 {
+		t.Errorf("MigrateConfigFile() on a current file = %v, %v; want nothing to do", notes, err)
+	}
+}
+
+func TestMigrateConfigUrlOnly(t *testing.T) {
+	cfg, notes, err := MigrateConfig([]byte(`url = "https://git.example.com"
+
+[[artifact_sources]]
+name = "primary"
+url = "https://other.example.com"
+type = "git"
+use_vault = false
+`))
+	if err != nil {
+		t.Fatalf("MigrateConfig() error: %v", err)
+	}
+	if len(notes) != 1 {
+		t.Errorf("notes = %v, want one migration", notes)
+	}
+	if len(cfg.ArtifactSources) != 2 || cfg.ArtifactSources[1].Name != "legacy" || cfg.ArtifactSources[1].URL != "https://git.example.com" || cfg.ArtifactSources[1].UseVault {
+		t.Errorf("ArtifactSources = %+v, want the legacy url appended as 'legacy'", cfg.ArtifactSources)
+	}
+}
+
+func TestMigrateConfigVersions(t *testing.T) {
+	cfg, notes, err := MigrateConfig([]byte("config_version = 1\n\n[tests]\ntype = \"local\"\n"))
+	if err != nil || len(notes) != 0 || cfg.TestsConfig.Type != "local" {
+		t.Errorf("current config: cfg = %+v, notes = %v, err = %v", cfg, notes, err)
+	}
+
+	if _, _, err := MigrateConfig([]byte("config_version = 99\n")); err == nil || !strings.Contains(err.Error(), "upgrade diffusion") {
+		t.Errorf("newer config_version: err = %v, want upgrade hint", err)
+	}
+	if _, _, err := MigrateConfig([]byte("config_version = \"one\"\n")); err == nil {
+		t.Error("non-integer config_version: expected error")
+	}
+}
+
+func TestTestsDiffusionRepoValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		want    string
+		wantErr string
+	}{
+		{"default", "[tests]\ntype = \"diffusion\"\n", DiffusionTestsRepo, ""},
+		{"https", "[tests]\ndiffusion_repo = \"https://git.example.com/qa/tests.git\"\n", "https://git.example.com/qa/tests.git", ""},
+		{"scp style", "[tests]\ndiffusion_repo = \"git@git.example.com:qa/tests.git\"\n", "git@git.example.com:qa/tests.git", ""},
+		{"placeholder path", "[tests]\ndiffusion_repo = \"github.com/qa/tests\"\n", "", "[tests] diffusion_repo"},
+		{"no repository", "[tests]\ndiffusion_repo = \"https://github.com/\"\n", "", "invalid git URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _, err := MigrateConfig([]byte(tt.toml))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MigrateConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MigrateConfig() error = %v", err)
+			}
+			if got := cfg.TestsConfig.DiffusionRepository(); got != tt.want {
+				t.Errorf("DiffusionRepository() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// valueValidators check values assigned with SetValue, keyed by lowercase dotted path
+var valueValidators = map[string]func(value string) error{
+	"container_registry.registry_provider": func(value string) error {
+		switch value {
+		case RegistryProviderYC, RegistryProviderAWS, RegistryProviderGCP, RegistryProviderBasic, RegistryProviderPublic:
+			return nil
+		}
+		return fmt.Errorf("%s", ErrInvalidRegistryProvider)
+	},
+	"tests.type": func(value string) error {
+		switch value {
+		case TestsTypeLocal, TestsTypeRemote, TestsTypeDiffusion:
+			return nil
+		}
+		return fmt.Errorf("invalid tests type %q. Allowed values are: local, remote, diffusion", value)
+	},
+	"container.extra_volumes": func(value string) error {
+		for _, spec := range splitList(value) {
+			if err := ValidateVolumeSpec(spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"container.tmpfs": func(value string) error {
+		for _, spec := range splitList(value) {
+			if err := ValidateTmpfsSpec(spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"container.pull_policy": ValidatePullPolicy,
+	"container.home_path":   ValidateContainerHomePath,
+	"container.dns": func(value string) error {
+		for _, server := range splitList(value) {
+			if err := ValidateDNSServer(server); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"container.dns_search": func(value string) error {
+		for _, domain := range splitList(value) {
+			if err := ValidateDNSSearch(domain); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	"cache.warn_size_mb": func(value string) error {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("invalid cache warn size %q: must be a non-negative number of MB", value)
+		}
+		return nil
+	},
+	"dependencies.python.pinned": func(value string) error {
+		_, err := ValidatePythonVersion(value)
+		return err
+	},
+}
+
+// GetValue returns the value at a dotted TOML key path such as
+// "container_registry.registry_server". Lists are comma-separated, sections and
+// tables are returned TOML-encoded, and keys of missing sections read as empty.
+func GetValue(cfg *Config, path string) (string, error) {
+	segs, err := splitPath(path)
+	if err != nil {
+		return "", err
+	}
+	v, err := getPath(reflect.ValueOf(cfg), segs, path)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(v, segs[len(segs)-1])
+}
+
+// SetValue parses value into the field at a dotted TOML key path, creating
+// missing sections. Lists take comma-separated values; known keys are validated.
+func SetValue(cfg *Config, path, value string) error {
+	segs, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	if err := ValidateValue(path, value); err != nil {
+		return err
+	}
+	return setPath(reflect.ValueOf(cfg), segs, path, value)
+}
+
+// ValidateValue checks value against the validator for a dotted key path, if any
+// (e.g. "container_registry.registry_provider", "tests.type")
+func ValidateValue(path, value string) error {
+	if validate, ok := valueValidators[strings.ToLower(path)]; ok {
+		return validate(value)
+	}
+	return nil
+}
+
+// splitPath splits a dotted key path, rejecting empty segments
+func splitPath(path string) ([]string, error) {
+	segs := strings.Split(path, ".")
+	for _, seg := range segs {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid key path %q", path)
+		}
+	}
+	return segs, nil
+}
+
+// splitList parses a comma-separated list value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// tomlField returns the struct field whose TOML key matches key (case-insensitively)
+func tomlField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		if name != "-" && strings.EqualFold(name, key) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// getPath walks segs from v. Nil sections are walked as zero values so that
+// invalid keys are still reported.
+func getPath(v reflect.Value, segs []string, path string) (reflect.Value, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	if len(segs) == 0 {
+		return v, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := tomlField(v, segs[0])
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unknown config key %q in %q", segs[0], path)
+		}
+		return getPath(f, segs[1:], path)
+	case reflect.Map:
+		elem := v.MapIndex(reflect.ValueOf(segs[0]))
+		if !elem.IsValid() {
+			elem = reflect.Zero(v.Type().Elem())
+		}
+		return getPath(elem, segs[1:], path)
+	case reflect.Slice:
+		idx, err := sliceIndex(v, segs[0], path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return getPath(v.Index(idx), segs[1:], path)
+	default:
+		return reflect.Value{}, fmt.Errorf("config key %q in %q is not a section", segs[0], path)
+	}
+}
+
+// setPath walks segs from v, allocating nil sections and maps, and parses value into the leaf
+func setPath(v reflect.Value, segs []string, path, value string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(segs) == 0 {
+		return parseInto(v, path, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := tomlField(v, segs[0])
+		if !ok {
+			return fmt.Errorf("unknown config key %q in %q", segs[0], path)
+		}
+		return setPath(f, segs[1:], path, value)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot set %q: unsupported map type", path)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// Map entries are not addressable: edit a copy and store it back
+		key := reflect.ValueOf(segs[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setPath(elem, segs[1:], path, value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	case reflect.Slice:
+		idx, err := sliceIndex(v, segs[0], path)
+		if err != nil {
+			return err
+		}
+		return setPath(v.Index(idx), segs[1:], path, value)
+	default:
+		return fmt.Errorf("config key %q in %q is not a section", segs[0], path)
+	}
+}
+
+// sliceIndex parses a list index segment and checks bounds
+func sliceIndex(v reflect.Value, seg, path string) (int, error) {
+	idx, err := strconv.Atoi(seg)
+	if err != nil {
+		return 0, fmt.Errorf("expected a list index instead of %q in %q", seg, path)
+	}
+	if idx < 0 || idx >= v.Len() {
+		return 0, fmt.Errorf("index %d out of range in %q (%d entries)", idx, path, v.Len())
+	}
+	return idx, nil
+}
+
+// parseInto assigns the textual value to a leaf field
+func parseInto(v reflect.Value, path, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected true or false, got %q", path, value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected an integer, got %q", path, value)
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("cannot set %s: list of tables; set individual keys with an index (e.g. %s.0.<key>)", path, path)
+		}
+		v.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("cannot set %s: unsupported value type %s", path, v.Type())
+	}
+	return nil
+}
+
+// formatValue renders a value for GetValue
+func formatValue(v reflect.Value, key string) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(v.Interface()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			return strings.Join(v.Interface().([]string), ","), nil
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return fmt.Sprint(v.Interface()), nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{key: v.Interface()}); err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetSetValue(t *testing.T) {
+	cfg := &Config{
+		ContainerRegistry: &ContainerRegistry{RegistryServer: "ghcr.io", RegistryProvider: "Public"},
+		ArtifactSources:   []ArtifactSource{{Name: "gitlab", URL: "https://gitlab.example.com"}},
+	}
+
+	sets := []struct{ path, value string }{
+		{"container_registry.registry_server", "cr.example.com"},
+		{"container_registry.registry_provider", "YC"},
+		{"cache.enabled", "true"},
+		{"cache.cache_id", "abc123"},
+		{"tests.remote_repositories", "https://a.git, https://b.git"},
+		{"container.extra_env.HTTP_PROXY", "http://proxy:3128"},
+		{"ansible_cfg.forks", "20"},
+		{"ansible_cfg.host_key_checking", "true"},
+		{"ansible_cfg.extra.ssh_connection.pipelining", "True"},
+		{"artifact_sources.0.vault_kv_version", "1"},
+		{"dependencies.python.pinned", "3.12"},
+	}
+	for _, s := range sets {
+		if err := SetValue(cfg, s.path, s.value); err != nil {
+			t.Fatalf("SetValue(%q, %q) error = %v", s.path, s.value, err)
+		}
+	}
+
+	gets := map[string]string{
+		"container_registry.registry_server":          "cr.example.com",
+		"container_registry.registry_provider":        "YC",
+		"cache.enabled":                               "true",
+		"cache.cache_id":                              "abc123",
+		"tests.remote_repositories":                   "https://a.git,https://b.git",
+		"container.extra_env.HTTP_PROXY":              "http://proxy:3128",
+		"ansible_cfg.forks":                           "20",
+		"ansible_cfg.host_key_checking":               "true",
+		"ansible_cfg.extra.ssh_connection.pipelining": "True",
+		"artifact_sources.0.name":                     "gitlab",
+		"artifact_sources.0.vault_kv_version":         "1",
+		"dependencies.python.pinned":                  "3.12",
+		"vault.enabled":                               "false", // missing section reads as zero value
+	}
+	for path, want := range gets {
+		got, err := GetValue(cfg, path)
+		if err != nil {
+			t.Errorf("GetValue(%q) error = %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("GetValue(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	if cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled {
+		t.Error("expected cache section to be created")
+	}
+	if !reflect.DeepEqual(cfg.TestsConfig.RemoteRepositories, []string{"https://a.git", "https://b.git"}) {
+		t.Errorf("RemoteRepositories = %v", cfg.TestsConfig.RemoteRepositories)
+	}
+
+	section, err := GetValue(cfg, "container_registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(section, "[container_registry]") || !strings.Contains(section, `registry_server = "cr.example.com"`) {
+		t.Errorf("unexpected section output:\n%s", section)
+	}
+}
+
+func TestGetSetValueErrors(t *testing.T) {
+	cfg := &Config{}
+	setErrors := []struct{ path, value string }{
+		{"container_registry.registry_sever", "x"},      // unknown key
+		{"container_registry.registry_provider", "OCI"}, // invalid provider
+		{"tests.type", "unit"},
+		{"container.extra_volumes", "/src"},
+		{"cache.enabled", "maybe"},
+		{"ansible_cfg.forks", "many"},
+		{"container_registry.registry_server.x", "y"}, // leaf used as section
+		{"artifact_sources.0.name", "x"},              // index out of range
+		{"artifact_sources.first.name", "x"},
+		{"container_registry..registry_server", "x"},
+	}
+	for _, s := range setErrors {
+		if err := SetValue(cfg, s.path, s.value); err == nil {
+			t.Errorf("SetValue(%q, %q) expected error", s.path, s.value)
+		}
+	}
+
+	for _, path := range []string{"nope", "cache.nope", "container_registry.registry_server.x", ""} {
+		if _, err := GetValue(cfg, path); err == nil {
+			t.Errorf("GetValue(%q) expected error", path)
+		}
+	}
+}
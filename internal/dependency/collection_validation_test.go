@@ -0,0 +1,109 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/galaxy"
+)
+
+func newVersionsFixture(t *testing.T) *galaxy.GalaxyAPI {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/plugin/ansible/content/published/collections/index/community/general/versions/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"version":"9.1.0"},{"version":"8.6.0"}],"links":{"next":null}}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &galaxy.GalaxyAPI{BaseURL: server.URL + "/api/v3", Client: server.Client()}
+}
+
+func TestValidateCollectionVersion(t *testing.T) {
+	api := newVersionsFixture(t)
+
+	tests := []struct {
+		name     string
+		col      string
+		version  string
+		notFound bool
+	}{
+		{"published pin", "general", "8.6.0", false},
+		{"published == pin", "general", "==9.1.0", false},
+		{"unpublished pin", "general", "8.7.0", true},
+		{"unpublished == pin", "general", "==1.0.0", true},
+		{"open constraint is not looked up", "general", ">=99.0.0", false},
+		{"range is not looked up", "general", ">=8.0.0,<9.0.0", false},
+		{"latest", "general", "latest", false},
+		{"empty", "general", "", false},
+		{"unknown collection", "missing", "1.0.0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCollectionVersion(api, "community", tt.col, tt.version)
+			if tt.notFound {
+				if !errors.Is(err, ErrCollectionVersionNotFound) {
+					t.Errorf("validateCollectionVersion(%q) error = %v, want ErrCollectionVersionNotFound", tt.version, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("validateCollectionVersion(%q) unexpected error: %v", tt.version, err)
+			}
+		})
+	}
+}
+
+func TestValidateCollectionVersionRejectsBadName(t *testing.T) {
+	if err := ValidateCollectionVersion("general", "1.0.0"); err == nil {
+		t.Error("expected error for a collection name without namespace")
+	}
+	if _, err := FetchCollectionMetadata("general"); err == nil {
+		t.Error("expected error for a collection name without namespace")
+	}
+}
+
+func TestLockFailsOnUnpublishedCollectionPin(t *testing.T) {
+	origValidate, origGalaxy := galaxyValidateVersion, galaxyCollectionVersion
+	defer func() { galaxyValidateVersion, galaxyCollectionVersion = origValidate, origGalaxy }()
+
+	galaxyValidateVersion = func(_ *galaxy.GalaxyAPI, namespace, name, version string) error {
+		if version == "8.7.0" {
+			return fmt.Errorf("%w: %s.%s %s is not published on Galaxy", ErrCollectionVersionNotFound, namespace, name, version)
+		}
+		return nil
+	}
+	galaxyCollectionVersion = func(_ *galaxy.GalaxyAPI, namespace, name, constraint string) (string, error) {
+		return strings.TrimPrefix(constraint, ">="), nil
+	}
+
+	collections := []config.CollectionRequirement{
+		{Name: "default.general", Namespace: "community", Version: "8.7.0"},
+		{Name: "default.utils", Namespace: "ansible", Version: ">=2.0.0"},
+	}
+	opts := &LockOptions{Quiet: true, Output: io.Discard}
+	_, err := GenerateLockFileWithOptions(collections, nil, map[string]string{}, nil, opts)
+	if !errors.Is(err, ErrCollectionVersionNotFound) {
+		t.Fatalf("GenerateLockFileWithOptions() error = %v, want ErrCollectionVersionNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "community.general") {
+		t.Errorf("error should name the collection: %v", err)
+	}
+
+	// Lookup failures other than "not found" only warn
+	galaxyValidateVersion = func(_ *galaxy.GalaxyAPI, namespace, name, version string) error {
+		return fmt.Errorf("failed to fetch: connection refused")
+	}
+	lockFile, err := GenerateLockFileWithOptions(collections, nil, map[string]string{}, nil, opts)
+	if err != nil {
+		t.Fatalf("GenerateLockFileWithOptions() should tolerate an unreachable Galaxy: %v", err)
+	}
+	if len(lockFile.Collections) != 2 {
+		t.Errorf("expected 2 collections in lock file, got %d", len(lockFile.Collections))
+	}
+}
@@ -0,0 +1,47 @@
+package dependency
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// constraintVersionPattern matches the version part of a constraint: 1, 1.2, 1.2.3,
+// optionally v-prefixed and with a pre-release/build suffix (1.2.3-rc1, 1.2.3+build, 1.2.3a1)
+var constraintVersionPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}([-+.]?[A-Za-z][0-9A-Za-z.-]*|[-+][0-9A-Za-z.-]+)?$`)
+
+// constraintOperators are checked longest first so ">=" is not read as ">"
+var constraintOperators = []string{">=", "<=", "==", "!=", "~=", ">", "<", "="}
+
+// ValidateConstraint checks that a version constraint parses. Accepted forms are
+// a bare version ("1.2.3"), an operator and version (">=7.0.0"), comma-separated
+// combinations (">=7.0.0,<8.0.0"), and the moving refs "latest", "main" and "master".
+// An empty constraint is valid and means latest.
+func ValidateConstraint(constraint string) error {
+	constraint = strings.TrimSpace(constraint)
+	switch constraint {
+	case "", "latest", "main", "master":
+		return nil
+	}
+
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		_, version := splitConstraint(part)
+		if !constraintVersionPattern.MatchString(version) {
+			return fmt.Errorf("invalid version constraint %q: %q is not a version", constraint, part)
+		}
+	}
+	return nil
+}
+
+// splitConstraint splits a single constraint part such as ">=7.0.0" into its
+// operator and version. A bare version has an empty operator.
+func splitConstraint(part string) (op, version string) {
+	part = strings.TrimSpace(part)
+	for _, candidate := range constraintOperators {
+		if strings.HasPrefix(part, candidate) {
+			return candidate, strings.TrimSpace(strings.TrimPrefix(part, candidate))
+		}
+	}
+	return "", part
+}
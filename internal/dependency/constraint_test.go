@@ -0,0 +1,19 @@
+package dependency
+
+import "testing"
+
+func TestValidateConstraint(t *testing.T) {
+	valid := []string{"", "latest", "main", "1.2.3", "v1.2.3", ">=7.0.0", "==10.1.0", ">= 7.0", ">=7.0.0,<8.0.0", "~=2.4", "1.0.0-rc1", "1.0.0a1", "1.0.0.post1"}
+	for _, c := range valid {
+		if err := ValidateConstraint(c); err != nil {
+			t.Errorf("ValidateConstraint(%q) unexpected error: %v", c, err)
+		}
+	}
+
+	invalid := []string{">=", "abc", ">=x.y.z", "=>1.0.0", ">=7.0.0,", "1.2.3.4.5"}
+	for _, c := range invalid {
+		if err := ValidateConstraint(c); err == nil {
+			t.Errorf("ValidateConstraint(%q) expected error", c)
+		}
+	}
+}
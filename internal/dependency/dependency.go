@@ -3,6 +3,7 @@ package dependency
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 
 	"sort"
@@ -434,22 +435,60 @@ func SaveDependencyConfig(depConfig *config.DependencyConfig) error {
 	return config.SaveConfig(cfg)
 }
 
-// FetchCollectionMetadata fetches metadata for a collection (placeholder for future implementation)
-func FetchCollectionMetadata(collectionName string) (map[string]interface{}, error) {
-	// TODO: Implement fetching from Ansible Galaxy API
-	// For now, return empty metadata
-	return map[string]interface{}{
-		"name":    collectionName,
-		"version": "latest",
-	}, nil
+// ErrCollectionVersionNotFound is returned when a pinned collection version is not published on Galaxy
+var ErrCollectionVersionNotFound = errors.New("collection version not found")
+
+// FetchCollectionMetadata fetches the Galaxy metadata (highest version, deprecation
+// flag, dependencies) of a collection given as namespace.name
+func FetchCollectionMetadata(collectionName string) (*galaxy.CollectionMetadata, error) {
+	namespace, name, ok := strings.Cut(collectionName, ".")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid collection name %q: expected namespace.name", collectionName)
+	}
+	return galaxy.NewGalaxyAPI().GetCollectionMetadata(namespace, name)
 }
 
-// ValidateCollectionVersion validates if a collection version exists
+// ValidateCollectionVersion checks that a concrete collection version (1.2.3 or
+// ==1.2.3) is published on Galaxy. Empty, "latest" and open constraints such as
+// >=1.0.0 are accepted without a lookup.
 func ValidateCollectionVersion(collectionName, version string) error {
-	// TODO: Implement validation against Ansible Galaxy API
-	// For now, accept all versions
-	if version == "" || version == "latest" {
+	namespace, name, ok := strings.Cut(collectionName, ".")
+	if !ok || namespace == "" || name == "" {
+		return fmt.Errorf("invalid collection name %q: expected namespace.name", collectionName)
+	}
+	return validateCollectionVersion(galaxy.NewGalaxyAPI(), namespace, name, version)
+}
+
+// validateCollectionVersion implements ValidateCollectionVersion against the given API
+func validateCollectionVersion(api *galaxy.GalaxyAPI, namespace, name, version string) error {
+	pinned, ok := pinnedVersion(version)
+	if !ok {
 		return nil
 	}
-	return nil
+	versions, err := api.GetCollectionVersions(namespace, name)
+	if errors.Is(err, galaxy.ErrNotFound) {
+		return fmt.Errorf("%w: collection %s.%s does not exist on Galaxy", ErrCollectionVersionNotFound, namespace, name)
+	}
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if galaxy.CompareVersions(v, pinned) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s.%s %s is not published on Galaxy", ErrCollectionVersionNotFound, namespace, name, pinned)
+}
+
+// pinnedVersion returns the version of a single exact constraint (1.2.3, ==1.2.3)
+func pinnedVersion(constraint string) (string, bool) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" || constraint == "latest" || strings.Contains(constraint, ",") || ValidateConstraint(constraint) != nil {
+		return "", false
+	}
+	op, version := splitConstraint(constraint)
+	if op != "" && op != "==" && op != "=" {
+		return "", false
+	}
+	return version, true
 }
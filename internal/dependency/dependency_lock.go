@@ -1,9 +1,12 @@
 package dependency
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -29,13 +32,14 @@ type LockFileEntry struct {
 
 // LockFile represents the diffusion.lock file structure
 type LockFile struct {
-	Version     string                `yaml:"version"`     // Lock file format version
-	Generated   string                `yaml:"generated"`   // Timestamp
-	Hash        string                `yaml:"hash"`        // Overall dependency hash
-	Python      *config.PythonVersion `yaml:"python"`      // Python version info
-	Collections []LockFileEntry       `yaml:"collections"` // Locked collections
-	Roles       []LockFileEntry       `yaml:"roles"`       // Locked roles
-	Tools       []LockFileEntry       `yaml:"tools"`       // Locked tools (ansible, molecule, etc.)
+	Version     string                `yaml:"version"`            // Lock file format version
+	Generated   string                `yaml:"generated"`          // Timestamp
+	Hash        string                `yaml:"hash"`               // Overall dependency hash
+	Python      *config.PythonVersion `yaml:"python"`             // Python version info
+	Platform    string                `yaml:"platform,omitempty"` // Target platform Python packages were resolved for (deps lock --platform)
+	Collections []LockFileEntry       `yaml:"collections"`        // Locked collections
+	Roles       []LockFileEntry       `yaml:"roles"`              // Locked roles
+	Tools       []LockFileEntry       `yaml:"tools"`              // Locked tools (ansible, molecule, etc.)
 }
 
 const (
@@ -83,214 +87,324 @@ func SaveLockFile(lockFile *LockFile) error {
 
 // GenerateLockFile generates a new lock file from current dependencies with resolved versions
 func GenerateLockFile(collections []config.CollectionRequirement, roles []config.RoleRequirement, toolVersions map[string]string, pythonVersion *config.PythonVersion) (*LockFile, error) {
+	return GenerateLockFileWithOptions(collections, roles, toolVersions, pythonVersion, nil)
+}
+
+// GenerateLockFileWithOptions generates a lock file, resolving collections,
+// roles and tools concurrently. Entry order in the lock file matches the
+// input order regardless of which lookup finishes first.
+func GenerateLockFileWithOptions(collections []config.CollectionRequirement, roles []config.RoleRequirement, toolVersions map[string]string, pythonVersion *config.PythonVersion, opts *LockOptions) (*LockFile, error) {
+	if opts == nil {
+		opts = &LockOptions{}
+	}
+
 	lockFile := &LockFile{
 		Version:     LockFileVersion,
 		Python:      pythonVersion,
 		Collections: make([]LockFileEntry, 0),
 		Roles:       make([]LockFileEntry, 0),
 		Tools:       make([]LockFileEntry, 0),
+		Platform:    opts.Platform,
+	}
+
+	for _, role := range roles {
+		if err := galaxy.ValidateRoleSrc(role.Src); err != nil {
+			return nil, fmt.Errorf("role %s: %w", role.Name, err)
+		}
 	}
 
 	galaxyAPI := galaxy.NewGalaxyAPI()
+	// Artifact source credentials are looked up once for the whole run
+	gitCreds := galaxy.LoadGitCredentials()
 
-	// Add collections with resolved versions
-	for _, col := range collections {
-		if col.Source == "" {
-			col.Source = "galaxy"
-		}
-		entry := LockFileEntry{
-			Name:      col.Name,
-			Namespace: col.Namespace,
-			Version:   col.Version,
-			Type:      "collection",
-			Source:    col.Source,
-			Src:       col.SourceURL,
-		}
+	// Tools are resolved in name order so the lock file is stable
+	toolNames := make([]string, 0, len(toolVersions))
+	for tool := range toolVersions {
+		toolNames = append(toolNames, tool)
+	}
+	sort.Strings(toolNames)
 
-		if col.Source != "galaxy" {
-			// For non-Galaxy sources we are trying to resolve from git
-			if col.SourceURL == "" {
-				log.Printf("Skipping collection %s: missing source URL for non-Galaxy source %s", col.Name, col.Source)
-				continue
-			}
+	update, err := newLockUpdate(opts)
+	if err != nil {
+		return nil, err
+	}
+	kept, err := update.plan(collections, roles, toolNames, toolVersions)
+	if err != nil {
+		return nil, err
+	}
 
-			resolvedVersion, err := galaxy.ResolveVersionFromGit(col.SourceURL, col.Version)
-			if err != nil {
-				log.Printf("Failed to resolve version for collection %s from git: %v", col.Name, err)
-				// Use the version constraint if resolution fails
-				if col.Version != "" && col.Version != "latest" {
-					entry.ResolvedVersion = col.Version
-				} else {
-					entry.ResolvedVersion = "main"
-				}
-			} else {
-				entry.ResolvedVersion = resolvedVersion
+	progress := newResolveProgress(len(collections)+len(roles)+len(toolNames), opts)
+	pool := newWorkerPool(opts.concurrency())
+
+	// run executes fn on the bounded worker pool and records progress when done
+	run := func(fn func()) {
+		pool.Go(func() {
+			fn()
+			progress.Step()
+		})
+	}
+
+	collectionEntries := make([]*LockFileEntry, len(collections))
+	collectionErrs := make([]error, len(collections))
+	for i, col := range collections {
+		run(func() {
+			if kept.collections[i] != nil {
+				collectionEntries[i] = kept.collections[i]
+				return
 			}
-		} else {
-			// Resolve version from Galaxy using Namespace field
-			// Collection Name in config is "scenario.collectionname", Namespace is separate
-			namespace := col.Namespace
-			collectionName := col.Name
-
-			// Strip scenario prefix from collection name for Galaxy resolution
-			if parts := strings.SplitN(collectionName, ".", 2); len(parts) == 2 {
-				collectionName = parts[1]
+			if err := checkPinnedCollection(galaxyAPI, col); err != nil {
+				collectionErrs[i] = err
+				return
 			}
+			collectionEntries[i] = resolveCollectionEntry(galaxyAPI, gitCreds, col, opts.Platform)
+		})
+	}
 
-			resolvedVersion, err := galaxyAPI.ResolveVersion(namespace, collectionName, "collection", col.Version)
-			if err != nil {
-				// Display as namespace.name for clarity in warning
-				displayName := col.Name
-				if namespace != "" {
-					displayName = namespace + "." + collectionName
-				}
-				fmt.Printf("Warning: Failed to resolve version for %s: %v\n", displayName, err)
-				// Use the version constraint if resolution fails
-				if col.Version != "" && col.Version != "latest" {
-					entry.ResolvedVersion = col.Version
-				} else {
-					entry.ResolvedVersion = "latest"
-				}
-			} else {
-				entry.ResolvedVersion = resolvedVersion
+	roleEntries := make([]LockFileEntry, len(roles))
+	for i, role := range roles {
+		run(func() {
+			if kept.roles[i] != nil {
+				roleEntries[i] = *kept.roles[i]
+				return
 			}
+			roleEntries[i] = resolveRoleEntry(galaxyAPI, gitCreds, role)
+		})
+	}
+
+	toolEntries := make([]LockFileEntry, len(toolNames))
+	for i, tool := range toolNames {
+		run(func() {
+			if kept.tools[i] != nil {
+				toolEntries[i] = *kept.tools[i]
+				return
+			}
+			toolEntries[i] = resolveToolEntry(tool, toolVersions[tool], opts.Platform)
+		})
+	}
+
+	pool.Wait()
+	progress.Finish()
+
+	if err := errors.Join(collectionErrs...); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range collectionEntries {
+		if entry != nil {
+			lockFile.Collections = append(lockFile.Collections, *entry)
 		}
+	}
+	lockFile.Roles = append(lockFile.Roles, roleEntries...)
+	lockFile.Tools = append(lockFile.Tools, toolEntries...)
 
-		// Get Python dependencies for this collection
-		// Use namespace.name format for lookup (e.g., "community.general")
-		pythonDepsKey := col.Name
-		if col.Namespace != "" {
-			// Strip scenario prefix and reconstruct as namespace.name
-			colName := col.Name
-			if parts := strings.SplitN(colName, ".", 2); len(parts) == 2 {
-				colName = parts[1]
+	// Compute overall hash
+	lockFile.Hash = ComputeDependencyHash(collections, roles, toolVersions, pythonVersion)
+
+	return lockFile, nil
+}
+
+// Version lookups used while resolving collections; tests replace them to
+// simulate Galaxy or git outages.
+var (
+	galaxyCollectionVersion = func(api *galaxy.GalaxyAPI, namespace, name, constraint string) (string, error) {
+		return api.ResolveVersion(namespace, name, "collection", constraint)
+	}
+	gitVersion            = galaxy.ResolveVersionFromGitWithCredentials
+	galaxyValidateVersion = validateCollectionVersion
+)
+
+// checkPinnedCollection fails when a Galaxy collection is pinned to a version that
+// is not published. Lookup failures (e.g. Galaxy unreachable) only warn, so an
+// offline lock still works.
+func checkPinnedCollection(galaxyAPI *galaxy.GalaxyAPI, col config.CollectionRequirement) error {
+	if col.Source != "" && col.Source != "galaxy" {
+		return nil
+	}
+	_, fullName := CollectionScope(col.Name, col.Namespace)
+	namespace, name, _ := strings.Cut(fullName, ".")
+	err := galaxyValidateVersion(galaxyAPI, namespace, name, col.Version)
+	if errors.Is(err, ErrCollectionVersionNotFound) {
+		return fmt.Errorf("invalid version %q for collection %s: %w", col.Version, fullName, err)
+	}
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: could not validate %s %s: %v"+config.ColorReset, fullName, col.Version, err)
+	}
+	return nil
+}
+
+// resolveCollectionEntry resolves a single collection to a lock file entry, with
+// its Python dependencies resolved for platform ("" for any). It returns nil
+// when the collection must be skipped.
+func resolveCollectionEntry(galaxyAPI *galaxy.GalaxyAPI, gitCreds *galaxy.GitCredentials, col config.CollectionRequirement, platform string) *LockFileEntry {
+	if col.Source == "" {
+		col.Source = "galaxy"
+	}
+	entry := LockFileEntry{
+		Name:      col.Name,
+		Namespace: col.Namespace,
+		Version:   col.Version,
+		Type:      "collection",
+		Source:    col.Source,
+		Src:       col.SourceURL,
+	}
+
+	if col.Source != "galaxy" {
+		// For non-Galaxy sources we are trying to resolve from git
+		if col.SourceURL == "" {
+			log.Printf("Skipping collection %s: missing source URL for non-Galaxy source %s", col.Name, col.Source)
+			return nil
+		}
+
+		resolvedVersion, err := gitVersion(col.SourceURL, col.Version, gitCreds)
+		if err != nil {
+			log.Printf("Failed to resolve version for collection %s from git: %v", col.Name, err)
+			// Use the version constraint if resolution fails
+			if col.Version != "" && col.Version != "latest" {
+				entry.ResolvedVersion = col.Version
+			} else {
+				entry.ResolvedVersion = "main"
 			}
-			pythonDepsKey = col.Namespace + "." + colName
+		} else {
+			entry.ResolvedVersion = resolvedVersion
 		}
-		pythonDeps := getCollectionPythonDependencies(pythonDepsKey)
-		if len(pythonDeps) > 0 {
-			// Resolve Python package versions
-			resolved, err := galaxy.ResolvePythonDependencies(pythonDeps)
+	} else {
+		// Collection Name in config is "scenario.collectionname" with a separate
+		// Namespace, or a shared "namespace.collectionname"
+		_, fullName := CollectionScope(col.Name, col.Namespace)
+		namespace, collectionName, _ := strings.Cut(fullName, ".")
+
+		resolvedVersion, err := galaxyCollectionVersion(galaxyAPI, namespace, collectionName, col.Version)
+		if err != nil && col.SourceURL != "" {
+			// Galaxy (or the proxy in front of it) is unreachable: fall back to the git mirror
+			log.Printf("Galaxy resolution failed for %s.%s (%v), falling back to git source %s", namespace, collectionName, err, col.SourceURL)
+			resolvedVersion, err = gitVersion(col.SourceURL, col.Version, gitCreds)
 			if err != nil {
-				fmt.Printf("Warning: Failed to resolve Python deps for %s: %v\n", col.Name, err)
+				err = fmt.Errorf("galaxy unreachable and git fallback failed: %w", err)
+			}
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to resolve version for %s: %v\n", fullName, err)
+			// Use the version constraint if resolution fails
+			if col.Version != "" && col.Version != "latest" {
+				entry.ResolvedVersion = col.Version
 			} else {
-				entry.PythonDeps = resolved
+				entry.ResolvedVersion = "latest"
 			}
+		} else {
+			entry.ResolvedVersion = resolvedVersion
 		}
-
-		lockFile.Collections = append(lockFile.Collections, entry)
 	}
 
-	// Add roles with resolved versions
-	for _, role := range roles {
-		if role.Scm == "" {
-			role.Scm = "git"
-		}
-
-		entry := LockFileEntry{
-			Name:      role.Name,
-			Namespace: role.Namespace,
-			Version:   role.Version, // This is the constraint from diffusion.toml
-			Type:      "role",
-			Src:       role.Src, // Store git URL
-			Source:    role.Scm, // Store SCM type (git, hg, etc.) - yaml tag is "scm"
+	// Get Python dependencies for this collection
+	// Use namespace.name format for lookup (e.g., "community.general")
+	_, pythonDepsKey := CollectionScope(col.Name, col.Namespace)
+	pythonDeps := getCollectionPythonDependencies(pythonDepsKey)
+	if len(pythonDeps) > 0 {
+		// Resolve Python package versions
+		resolved, err := galaxy.ResolvePythonDependenciesForPlatform(pythonDeps, platform)
+		if err != nil {
+			fmt.Printf("Warning: Failed to resolve Python deps for %s: %v\n", col.Name, err)
+		} else {
+			entry.PythonDeps = resolved
 		}
+	}
 
-		// Determine resolution strategy based on source
-		resolved := false
+	return &entry
+}
 
-		maxAttempts := 3
+// resolveRoleEntry resolves a single role to a lock file entry, retrying
+// git and Galaxy lookups with exponential backoff.
+func resolveRoleEntry(galaxyAPI *galaxy.GalaxyAPI, gitCreds *galaxy.GitCredentials, role config.RoleRequirement) LockFileEntry {
+	if role.Scm == "" {
+		role.Scm = "git"
+	}
 
-		for attempt := range maxAttempts {
-			// Priority 1: If git URL is provided, resolve from git
-			if role.Src != "" && (strings.Contains(role.Src, "github.com") || strings.Contains(role.Src, "gitlab.com") || strings.HasSuffix(role.Src, ".git")) {
-				resolvedVersion, err := galaxy.ResolveVersionFromGit(role.Src, role.Version)
-				if err != nil {
-					fmt.Printf("Warning: Failed to resolve version for role %s from git (attempt %d/%d): %v\n", role.Name, attempt+1, maxAttempts, err)
-				} else {
-					entry.ResolvedVersion = resolvedVersion
-					resolved = true
-				}
-			}
+	entry := LockFileEntry{
+		Name:      role.Name,
+		Namespace: role.Namespace,
+		Version:   role.Version, // This is the constraint from diffusion.toml
+		Type:      "role",
+		Src:       role.Src, // Store git URL
+		Source:    role.Scm, // Store SCM type (git, hg, etc.) - yaml tag is "scm"
+	}
 
-			// Priority 2: If not resolved and has Namespace, try Galaxy API
-			if !resolved && role.Namespace != "" && role.Scm != "git" {
-				// Role Name in config is "scenario.rolename", Namespace is separate
-				roleName := fmt.Sprintf("%s.%s", role.Namespace, role.Name)
-				// Strip scenario prefix for Galaxy resolution
-				if parts := strings.SplitN(roleName, ".", 2); len(parts) == 2 {
-					roleName = parts[1]
-				}
-				resolvedVersion, err := galaxyAPI.ResolveRoleVersion(role.Namespace, roleName, role.Version)
-				if err != nil {
-					fmt.Printf("Warning: Failed to resolve version for role %s from Galaxy (attempt %d/%d): %v\n", role.Name, attempt+1, maxAttempts, err)
-				} else {
-					entry.ResolvedVersion = resolvedVersion
-					resolved = true
-				}
-			}
+	// Determine resolution strategy based on source
+	resolved := false
 
-			if resolved {
-				break
-			}
+	maxAttempts := 3
 
-			// Wait before retrying (exponential backoff)
-			if attempt < maxAttempts-1 {
-				backoff := time.Duration(1<<uint(attempt)) * time.Second
-				fmt.Printf("Retrying role %s in %v...\n", role.Name, backoff)
-				time.Sleep(backoff)
+	for attempt := range maxAttempts {
+		// Priority 1: If git URL is provided, resolve from git
+		if role.Src != "" && galaxy.ParseGitSource(role.Src).IsGitRepository() {
+			resolvedVersion, err := galaxy.ResolveVersionFromGitWithCredentials(role.Src, role.Version, gitCreds)
+			if err != nil {
+				fmt.Printf("Warning: Failed to resolve version for role %s from git (attempt %d/%d): %v\n", role.Name, attempt+1, maxAttempts, err)
+			} else {
+				entry.ResolvedVersion = resolvedVersion
+				resolved = true
 			}
 		}
 
-		// Fallback: Use constraint or default to "main"
-		if !resolved {
-			if entry.ResolvedVersion == "" || entry.ResolvedVersion == "latest" || entry.ResolvedVersion == "main" {
-				entry.ResolvedVersion = "main"
+		// Priority 2: If not resolved and has Namespace, try Galaxy API
+		if !resolved && role.Namespace != "" && role.Scm != "git" {
+			// Role Name in config is "scenario.rolename", Namespace is separate
+			roleName := fmt.Sprintf("%s.%s", role.Namespace, role.Name)
+			// Strip scenario prefix for Galaxy resolution
+			if parts := strings.SplitN(roleName, ".", 2); len(parts) == 2 {
+				roleName = parts[1]
+			}
+			resolvedVersion, err := galaxyAPI.ResolveRoleVersion(role.Namespace, roleName, role.Version)
+			if err != nil {
+				fmt.Printf("Warning: Failed to resolve version for role %s from Galaxy (attempt %d/%d): %v\n", role.Name, attempt+1, maxAttempts, err)
 			} else {
-				// Use the version constraint as resolved version
-				entry.ResolvedVersion = role.Version
+				entry.ResolvedVersion = resolvedVersion
+				resolved = true
 			}
 		}
 
-		lockFile.Roles = append(lockFile.Roles, entry)
-	}
-
-	// Add tools with resolved Python package versions
-	toolPackages := []string{}
-	for tool, version := range toolVersions {
-		entry := LockFileEntry{
-			Name:    tool,
-			Version: version,
-			Type:    "tool",
-			Source:  "pypi",
+		if resolved {
+			break
 		}
 
-		// Format as Python package
-		pkgName := formatDependency(tool, version)
-		toolPackages = append(toolPackages, pkgName)
-
-		lockFile.Tools = append(lockFile.Tools, entry)
+		// Wait before retrying (exponential backoff)
+		if attempt < maxAttempts-1 {
+			backoff := time.Duration(1<<uint(attempt)) * time.Second
+			fmt.Printf("Retrying role %s in %v...\n", role.Name, backoff)
+			time.Sleep(backoff)
+		}
 	}
 
-	// Resolve all tool versions from PyPI
-	if len(toolPackages) > 0 {
-		resolved, err := galaxy.ResolvePythonDependencies(toolPackages)
-		if err != nil {
-			fmt.Printf("Warning: Failed to resolve tool versions: %v\n", err)
+	// Fallback: Use constraint or default to "main"
+	if !resolved {
+		if entry.ResolvedVersion == "" || entry.ResolvedVersion == "latest" || entry.ResolvedVersion == "main" {
+			entry.ResolvedVersion = "main"
 		} else {
-			// Update tool entries with resolved versions
-			for i := range lockFile.Tools {
-				if resolvedVer, ok := resolved[lockFile.Tools[i].Name]; ok {
-					lockFile.Tools[i].ResolvedVersion = resolvedVer
-				}
-			}
+			// Use the version constraint as resolved version
+			entry.ResolvedVersion = role.Version
 		}
 	}
 
-	// Compute overall hash
-	lockFile.Hash = ComputeDependencyHash(collections, roles, toolVersions, pythonVersion)
+	return entry
+}
 
-	return lockFile, nil
+// resolveToolEntry resolves a tool (ansible, molecule, ...) against PyPI,
+// considering only releases installable on platform ("" for any).
+func resolveToolEntry(tool, version, platform string) LockFileEntry {
+	entry := LockFileEntry{
+		Name:    tool,
+		Version: version,
+		Type:    "tool",
+		Source:  "pypi",
+	}
+
+	resolved, err := galaxy.ResolvePythonDependenciesForPlatform([]string{formatDependency(tool, version)}, platform)
+	if err != nil {
+		fmt.Printf("Warning: Failed to resolve tool version for %s: %v\n", tool, err)
+	} else if resolvedVer, ok := resolved[tool]; ok {
+		entry.ResolvedVersion = resolvedVer
+	}
+
+	return entry
 }
 
 // ValidateLockFile validates if the lock file is up-to-date
@@ -304,10 +418,74 @@ func ValidateLockFile(lockFile *LockFile, collections []config.CollectionRequire
 
 // UpdateLockFile updates the lock file with current dependencies
 func UpdateLockFile() error {
+	return UpdateLockFileWithOptions(nil)
+}
+
+// UpdateLockFileWithOptions updates the lock file with current dependencies
+// using the given lock options. With DryRun set the resolved lock file is
+// printed instead of written.
+func UpdateLockFileWithOptions(opts *LockOptions) error {
+	lockFile, err := ResolveLockFile(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := checkMovingRefs(opts.output(), lockFile, opts != nil && opts.Strict); err != nil {
+		return err
+	}
+
+	if opts != nil && opts.DryRun {
+		PrintLockFilePreview(opts.output(), lockFile)
+		return nil
+	}
+
+	if opts != nil && opts.Frozen {
+		return checkFrozenLockFile(opts, lockFile)
+	}
+
+	if err := SaveLockFile(lockFile); err != nil {
+		return fmt.Errorf("failed to save lock file: %w", err)
+	}
+
+	if opts != nil && opts.EmitReview != "" {
+		if err := WriteLockReview(opts.EmitReview, lockFile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFrozenLockFile diffs the resolved lock file against diffusion.lock,
+// printing each difference; nothing is written
+func checkFrozenLockFile(opts *LockOptions, resolved *LockFile) error {
+	current, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("%w: %s not found", ErrLockFileMismatch, config.LockFileName)
+	}
+
+	diffs := DiffLockFiles(current, resolved)
+	if len(diffs) == 0 {
+		return nil
+	}
+	w := opts.output()
+	fmt.Fprintf(w, "%s differs from the resolved dependencies:\n", config.LockFileName)
+	for _, diff := range diffs {
+		fmt.Fprintf(w, "  %s\n", diff)
+	}
+	return fmt.Errorf("%w (%d difference(s)); run 'diffusion deps lock' and commit the result", ErrLockFileMismatch, len(diffs))
+}
+
+// ResolveLockFile loads the dependency configuration and resolves it into a
+// lock file without writing anything to disk
+func ResolveLockFile(opts *LockOptions) (*LockFile, error) {
 	// Load current configuration
 	depConfig, err := LoadDependencyConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load dependency config: %w", err)
+		return nil, fmt.Errorf("failed to load dependency config: %w", err)
 	}
 
 	// Convert depConfig to resolver format
@@ -355,17 +533,73 @@ func UpdateLockFile() error {
 		toolVersions["yamllint"] = config.DefaultYamlLintVersion
 	}
 
+	// Raise constraints to the org floors before resolving. The hash still covers
+	// the manifests only, so 'deps check' compares against what the role declares.
+	resolveCollections, resolveRoles, resolveTools := collections, roles, toolVersions
+	constraintsSource := depConfig.Constraints
+	if opts != nil && opts.Constraints != "" {
+		constraintsSource = opts.Constraints
+	}
+	if constraintsSource != "" {
+		orgConstraints, err := LoadOrgConstraints(constraintsSource)
+		if err != nil {
+			return nil, err
+		}
+		resolveCollections = append([]config.CollectionRequirement(nil), collections...)
+		resolveRoles = append([]config.RoleRequirement(nil), roles...)
+		resolveTools = make(map[string]string, len(toolVersions))
+		for name, version := range toolVersions {
+			resolveTools[name] = version
+		}
+		changes, err := orgConstraints.Apply(resolveCollections, resolveRoles, resolveTools)
+		if err != nil {
+			return nil, fmt.Errorf("constraints file %s: %w", constraintsSource, err)
+		}
+		if opts == nil || !opts.Quiet {
+			for _, change := range changes {
+				fmt.Fprintf(opts.output(), "Applied %s\n", change)
+			}
+		}
+	}
+
 	// Generate and save lock file
-	lockFile, err := GenerateLockFile(collections, roles, toolVersions, pythonVersion)
+	lockFile, err := GenerateLockFileWithOptions(resolveCollections, resolveRoles, resolveTools, pythonVersion, opts)
 	if err != nil {
-		return fmt.Errorf("failed to generate lock file: %w", err)
+		return nil, fmt.Errorf("failed to generate lock file: %w", err)
 	}
+	lockFile.Hash = ComputeDependencyHash(collections, roles, toolVersions, pythonVersion)
 
-	if err := SaveLockFile(lockFile); err != nil {
-		return fmt.Errorf("failed to save lock file: %w", err)
+	return lockFile, nil
+}
+
+// PrintLockFilePreview writes the resolved entries and hash of a lock file
+func PrintLockFilePreview(w io.Writer, lockFile *LockFile) {
+	printSection := func(title string, entries []LockFileEntry) {
+		fmt.Fprintf(w, "%s:\n", title)
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "  (none)")
+			return
+		}
+		for _, entry := range entries {
+			name := entry.Name
+			if entry.Namespace != "" {
+				name = fmt.Sprintf("%s (%s)", entry.Name, entry.Namespace)
+			}
+			resolved := entry.ResolvedVersion
+			if resolved == "" {
+				resolved = "unresolved"
+			}
+			fmt.Fprintf(w, "  %s %s -> %s\n", name, entry.Version, resolved)
+		}
 	}
 
-	return nil
+	printSection("Collections", lockFile.Collections)
+	printSection("Roles", lockFile.Roles)
+	printSection("Tools", lockFile.Tools)
+	if lockFile.Platform != "" {
+		fmt.Fprintf(w, "Platform: %s\n", lockFile.Platform)
+	}
+	fmt.Fprintf(w, "Hash: %s\n", lockFile.Hash)
 }
 
 // CheckLockFileStatus checks if YAML manifests (requirements.yml, meta.yml)
@@ -418,6 +652,10 @@ func CheckLockFileStatus() (bool, error) {
 			if col.Namespace != "" {
 				yamlName = col.Namespace + "." + colName
 			}
+			if IsGitCollection(col) {
+				// deps sync writes git collections with the repository as name
+				yamlName = galaxy.ParseGitSource(col.Src).CollectionSrc()
+			}
 			version := col.ResolvedVersion
 			if version == "" {
 				version = col.Version
@@ -0,0 +1,43 @@
+package dependency
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestUpdateLockFileDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tmpDir)
+
+	configContent := `
+[dependencies]
+ansible = ">=10.0.0"
+molecule = ">=24.0.0"
+`
+	if err := os.WriteFile("diffusion.toml", []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err := UpdateLockFileWithOptions(&LockOptions{DryRun: true, Quiet: true, Output: &buf})
+	if err != nil {
+		t.Fatalf("UpdateLockFileWithOptions() error = %v", err)
+	}
+
+	if _, err := os.Stat(config.LockFileName); !os.IsNotExist(err) {
+		t.Errorf("dry run must not write %s", config.LockFileName)
+	}
+
+	out := buf.String()
+	for _, expected := range []string{"Collections:", "Roles:", "Tools:", "ansible >=10.0.0 ->", "molecule >=24.0.0 ->", "Hash: "} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("dry run output missing %q:\n%s", expected, out)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"diffusion/internal/config"
+	"diffusion/internal/galaxy"
 )
 
 func TestLockFileRoleResolution(t *testing.T) {
@@ -68,3 +69,48 @@ func TestLockFileRoleResolution(t *testing.T) {
 	fmt.Printf("   ResolvedVersion: %s\n", role.ResolvedVersion)
 	fmt.Printf("   Src: %s\n", role.Src)
 }
+
+func TestCollectionResolutionFallsBackToGit(t *testing.T) {
+	origGalaxy, origGit := galaxyCollectionVersion, gitVersion
+	defer func() { galaxyCollectionVersion, gitVersion = origGalaxy, origGit }()
+
+	galaxyCollectionVersion = func(_ *galaxy.GalaxyAPI, namespace, name, constraint string) (string, error) {
+		return "", fmt.Errorf("failed to fetch collection info: connection refused")
+	}
+	var gitURL string
+	gitVersion = func(url, constraint string, _ *galaxy.GitCredentials) (string, error) {
+		gitURL = url
+		return "7.5.0", nil
+	}
+
+	col := config.CollectionRequirement{
+		Name:      "default.general",
+		Namespace: "community",
+		Version:   ">=7.0.0",
+		SourceURL: "https://git.example.com/mirrors/community.general.git",
+	}
+	entry := resolveCollectionEntry(nil, nil, col, "")
+	if entry == nil {
+		t.Fatal("expected a lock entry")
+	}
+	if gitURL != col.SourceURL {
+		t.Errorf("expected git fallback against %s, got %q", col.SourceURL, gitURL)
+	}
+	if entry.ResolvedVersion != "7.5.0" {
+		t.Errorf("expected resolved version from git 7.5.0, got %q", entry.ResolvedVersion)
+	}
+	if entry.Source != "galaxy" {
+		t.Errorf("expected source to stay galaxy, got %q", entry.Source)
+	}
+
+	// Without a SourceURL there is nothing to fall back to: keep the constraint
+	gitURL = ""
+	col.SourceURL = ""
+	entry = resolveCollectionEntry(nil, nil, col, "")
+	if gitURL != "" {
+		t.Errorf("git fallback should not run without a source URL")
+	}
+	if entry.ResolvedVersion != ">=7.0.0" {
+		t.Errorf("expected constraint as resolved version, got %q", entry.ResolvedVersion)
+	}
+}
@@ -0,0 +1,111 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// ErrLockFileMismatch is returned by a frozen lock when resolution differs from diffusion.lock
+var ErrLockFileMismatch = errors.New("diffusion.lock does not match resolved dependencies")
+
+// DiffLockFiles compares the committed lock file with a freshly resolved one
+// and describes every difference: the dependency hash, the Python pin and each
+// added, removed or changed collection, role and tool entry. Timestamps and the
+// format version are ignored. An empty result means the files are equivalent.
+func DiffLockFiles(current, resolved *LockFile) []string {
+	var diffs []string
+	if current.Hash != resolved.Hash {
+		diffs = append(diffs, fmt.Sprintf("hash: %s -> %s (manifests changed since the lock was written)", current.Hash, resolved.Hash))
+	}
+	if oldPin, newPin := pinnedPython(current), pinnedPython(resolved); oldPin != newPin {
+		diffs = append(diffs, fmt.Sprintf("python: pinned %s -> %s", oldPin, newPin))
+	}
+	if current.Platform != resolved.Platform {
+		diffs = append(diffs, fmt.Sprintf("platform: %q -> %q", current.Platform, resolved.Platform))
+	}
+	diffs = append(diffs, diffLockEntries("collection", current.Collections, resolved.Collections)...)
+	diffs = append(diffs, diffLockEntries("role", current.Roles, resolved.Roles)...)
+	diffs = append(diffs, diffLockEntries("tool", current.Tools, resolved.Tools)...)
+	return diffs
+}
+
+func pinnedPython(lockFile *LockFile) string {
+	if lockFile.Python == nil {
+		return ""
+	}
+	return lockFile.Python.Pinned
+}
+
+// diffLockEntries matches entries by namespace and name and reports, in name
+// order, entries only present on one side and fields that changed
+func diffLockEntries(kind string, current, resolved []LockFileEntry) []string {
+	key := func(e LockFileEntry) string {
+		if e.Namespace == "" {
+			return e.Name
+		}
+		return e.Namespace + "/" + e.Name
+	}
+	before := make(map[string]LockFileEntry, len(current))
+	for _, e := range current {
+		before[key(e)] = e
+	}
+	after := make(map[string]LockFileEntry, len(resolved))
+	for _, e := range resolved {
+		after[key(e)] = e
+	}
+
+	names := slices.Collect(maps.Keys(before))
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		old, inOld := before[name]
+		cur, inNew := after[name]
+		switch {
+		case !inNew:
+			diffs = append(diffs, fmt.Sprintf("%s %s: removed (was %s)", kind, name, displayResolved(old)))
+		case !inOld:
+			diffs = append(diffs, fmt.Sprintf("%s %s: added (%s)", kind, name, displayResolved(cur)))
+		default:
+			for _, change := range entryChanges(old, cur) {
+				diffs = append(diffs, fmt.Sprintf("%s %s: %s", kind, name, change))
+			}
+		}
+	}
+	return diffs
+}
+
+// entryChanges lists the fields that differ between two entries of the same dependency
+func entryChanges(old, cur LockFileEntry) []string {
+	var changes []string
+	field := func(label, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s %q -> %q", label, a, b))
+		}
+	}
+	field("resolved_version", old.ResolvedVersion, cur.ResolvedVersion)
+	field("version", old.Version, cur.Version)
+	field("hash", old.Hash, cur.Hash)
+	field("src", old.Src, cur.Src)
+	// Source is the collection source (galaxy, git) or the role SCM
+	field("source", old.Source, cur.Source)
+	if !maps.Equal(old.PythonDeps, cur.PythonDeps) {
+		changes = append(changes, fmt.Sprintf("python_deps %v -> %v", old.PythonDeps, cur.PythonDeps))
+	}
+	return changes
+}
+
+func displayResolved(e LockFileEntry) string {
+	if e.ResolvedVersion == "" {
+		return "unresolved"
+	}
+	return e.ResolvedVersion
+}
@@ -0,0 +1,126 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestDiffLockFiles(t *testing.T) {
+	current := &LockFile{
+		Hash:   "abc",
+		Python: &config.PythonVersion{Pinned: "3.13"},
+		Collections: []LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.0.0", ResolvedVersion: "9.0.0", Type: "collection", Source: "galaxy"},
+			{Name: "default.posix", Namespace: "ansible", Version: "1.5.4", ResolvedVersion: "1.5.4", Type: "collection"},
+		},
+		Tools: []LockFileEntry{{Name: "ansible", Version: ">=10.0.0", ResolvedVersion: "10.1.0", Type: "tool"}},
+	}
+
+	if diffs := DiffLockFiles(current, current); len(diffs) != 0 {
+		t.Fatalf("DiffLockFiles(same) = %v, want no differences", diffs)
+	}
+
+	resolved := &LockFile{
+		Hash:   "abc",
+		Python: &config.PythonVersion{Pinned: "3.13"},
+		Collections: []LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.0.0", ResolvedVersion: "9.1.0", Type: "collection", Source: "git"},
+			{Name: "default.docker", Namespace: "community", Version: "3.10.0", ResolvedVersion: "3.10.0", Type: "collection"},
+		},
+		Tools: []LockFileEntry{{Name: "ansible", Version: ">=10.0.0", ResolvedVersion: "10.1.0", Type: "tool"}},
+	}
+	want := []string{
+		`collection ansible/default.posix: removed (was 1.5.4)`,
+		`collection community/default.docker: added (3.10.0)`,
+		`collection community/default.general: resolved_version "9.0.0" -> "9.1.0"`,
+		`collection community/default.general: source "galaxy" -> "git"`,
+	}
+	diffs := DiffLockFiles(current, resolved)
+	if strings.Join(diffs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffLockFiles() =\n  %s\nwant\n  %s", strings.Join(diffs, "\n  "), strings.Join(want, "\n  "))
+	}
+
+	resolved.Hash = "def"
+	resolved.Python = &config.PythonVersion{Pinned: "3.12"}
+	diffs = DiffLockFiles(current, resolved)
+	if len(diffs) != 6 || !strings.HasPrefix(diffs[0], "hash: abc -> def") || !strings.HasPrefix(diffs[1], "python: pinned 3.13 -> 3.12") {
+		t.Errorf("DiffLockFiles() with hash and python changes = %v", diffs)
+	}
+}
+
+func TestLockFilePlatform(t *testing.T) {
+	lockFile, err := GenerateLockFileWithOptions(nil, nil, map[string]string{}, nil, &LockOptions{Quiet: true, Platform: "linux/arm64"})
+	if err != nil {
+		t.Fatalf("GenerateLockFileWithOptions() error = %v", err)
+	}
+	if lockFile.Platform != "linux/arm64" {
+		t.Errorf("Platform = %q, want the --platform value recorded", lockFile.Platform)
+	}
+
+	previous := *lockFile
+	previous.Platform = ""
+	diffs := DiffLockFiles(&previous, lockFile)
+	if len(diffs) != 1 || diffs[0] != `platform: "" -> "linux/arm64"` {
+		t.Errorf("DiffLockFiles() = %v, want the platform change", diffs)
+	}
+}
+
+func TestUpdateLockFileFrozen(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(tmpDir)
+
+	if err := os.WriteFile("diffusion.toml", []byte("[dependencies]\nansible = \">=10.0.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No lock file yet
+	err := UpdateLockFileWithOptions(&LockOptions{Frozen: true, Quiet: true, Output: &bytes.Buffer{}})
+	if !errors.Is(err, ErrLockFileMismatch) {
+		t.Fatalf("frozen without a lock file: error = %v, want ErrLockFileMismatch", err)
+	}
+	if _, err := os.Stat(config.LockFileName); !os.IsNotExist(err) {
+		t.Fatal("frozen lock must not write diffusion.lock")
+	}
+
+	if err := UpdateLockFileWithOptions(&LockOptions{Quiet: true}); err != nil {
+		t.Fatalf("UpdateLockFileWithOptions() error = %v", err)
+	}
+	if err := UpdateLockFileWithOptions(&LockOptions{Frozen: true, Quiet: true, Output: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("frozen against a fresh lock: error = %v", err)
+	}
+
+	// A hand-edited resolved version is reported and the file is left alone
+	lock, err := LoadLockFile()
+	if err != nil || lock == nil {
+		t.Fatalf("LoadLockFile() = %v, %v", lock, err)
+	}
+	for i := range lock.Tools {
+		if lock.Tools[i].Name == "ansible" {
+			lock.Tools[i].ResolvedVersion = "0.0.1"
+		}
+	}
+	if err := SaveLockFile(lock); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(config.LockFileName)
+
+	var out bytes.Buffer
+	err = UpdateLockFileWithOptions(&LockOptions{Frozen: true, Quiet: true, Output: &out})
+	if !errors.Is(err, ErrLockFileMismatch) {
+		t.Fatalf("frozen with a changed entry: error = %v, want ErrLockFileMismatch", err)
+	}
+	if !strings.Contains(out.String(), `tool ansible: resolved_version "0.0.1"`) {
+		t.Errorf("frozen output should name the changed entry:\n%s", out.String())
+	}
+	after, _ := os.ReadFile(config.LockFileName)
+	if !bytes.Equal(before, after) {
+		t.Error("frozen lock must not rewrite diffusion.lock")
+	}
+}
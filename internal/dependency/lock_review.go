@@ -0,0 +1,87 @@
+package dependency
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reviewHeader marks the review file as derived output
+const reviewHeader = "# Flattened view of diffusion.lock for code review.\n# Informational only: diffusion does not read this file. Regenerate with 'diffusion deps lock --emit-review'.\n\n"
+
+// LockReview is the flat, human-reviewable form of a lock file. Each section
+// maps a dependency to its resolved version; yaml.v3 writes map keys sorted.
+type LockReview struct {
+	Python      string            `yaml:"python,omitempty"`
+	Collections map[string]string `yaml:"collections,omitempty"`
+	Roles       map[string]string `yaml:"roles,omitempty"`
+	Tools       map[string]string `yaml:"tools,omitempty"`
+}
+
+// NewLockReview flattens a lock file into name -> resolved version entries.
+// Roles carry "@<hash>" when the lock records one.
+func NewLockReview(lockFile *LockFile) *LockReview {
+	review := &LockReview{
+		Collections: reviewSection(lockFile.Collections),
+		Roles:       reviewSection(lockFile.Roles),
+		Tools:       reviewSection(lockFile.Tools),
+	}
+	if lockFile.Python != nil {
+		review.Python = lockFile.Python.Pinned
+	}
+	return review
+}
+
+func reviewSection(entries []LockFileEntry) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	section := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		version := entry.ResolvedVersion
+		if version == "" {
+			version = "unresolved"
+		}
+		if entry.Type == "role" && entry.Hash != "" {
+			version += "@" + entry.Hash
+		}
+		section[reviewName(entry)] = version
+	}
+	return section
+}
+
+// reviewName returns namespace.name, followed by the scenario when the lock
+// name is scenario-prefixed (e.g. "community.general (default)")
+func reviewName(entry LockFileEntry) string {
+	if entry.Namespace == "" {
+		return entry.Name
+	}
+	scenario, name, ok := strings.Cut(entry.Name, ".")
+	if !ok {
+		return entry.Namespace + "." + entry.Name
+	}
+	return fmt.Sprintf("%s.%s (%s)", entry.Namespace, name, scenario)
+}
+
+// MarshalLockReview renders the review file content for a lock file
+func MarshalLockReview(lockFile *LockFile) ([]byte, error) {
+	data, err := yaml.Marshal(NewLockReview(lockFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal review file: %w", err)
+	}
+	return append([]byte(reviewHeader), data...), nil
+}
+
+// WriteLockReview writes the review file for a lock file to path
+func WriteLockReview(path string, lockFile *LockFile) error {
+	data, err := MarshalLockReview(lockFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write review file: %w", err)
+	}
+	return nil
+}
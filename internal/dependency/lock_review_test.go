@@ -0,0 +1,117 @@
+package dependency
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+func reviewTestLock() *LockFile {
+	return &LockFile{
+		Python: &config.PythonVersion{Min: "3.11", Max: "3.13", Pinned: "3.13"},
+		Collections: []LockFileEntry{
+			{Name: "default.general", Namespace: "community", Version: ">=7.0.0", ResolvedVersion: "9.1.0", Type: "collection"},
+			{Name: "default.docker", Namespace: "community", Version: "3.10.0", ResolvedVersion: "3.10.0", Type: "collection"},
+			{Name: "ansible.posix", Version: "latest", ResolvedVersion: "1.5.4", Type: "collection"},
+		},
+		Roles: []LockFileEntry{
+			{Name: "default.docker", Namespace: "geerlingguy", Version: ">=7.0.0", ResolvedVersion: "7.4.1", Type: "role", Hash: "3f2a9c1"},
+			{Name: "default.acme_base", Src: "https://github.com/acme/base.git", Version: "main", ResolvedVersion: "main", Type: "role"},
+		},
+		Tools: []LockFileEntry{
+			{Name: "molecule", Version: ">=24.0.0", ResolvedVersion: "25.1.0", Type: "tool"},
+			{Name: "ansible", Version: ">=10.0.0", Type: "tool"},
+		},
+	}
+}
+
+func TestLockReviewMatchesLock(t *testing.T) {
+	lock := reviewTestLock()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deps-review.yaml")
+	if err := WriteLockReview(path, lock); err != nil {
+		t.Fatalf("WriteLockReview() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var review LockReview
+	if err := yaml.Unmarshal(data, &review); err != nil {
+		t.Fatalf("review file is not valid YAML: %v", err)
+	}
+	want := LockReview{
+		Python: "3.13",
+		Collections: map[string]string{
+			"community.general (default)": "9.1.0",
+			"community.docker (default)":  "3.10.0",
+			"ansible.posix":               "1.5.4",
+		},
+		Roles: map[string]string{
+			"geerlingguy.docker (default)": "7.4.1@3f2a9c1",
+			"default.acme_base":            "main",
+		},
+		Tools: map[string]string{
+			"molecule": "25.1.0",
+			"ansible":  "unresolved",
+		},
+	}
+	for section, pair := range map[string][2]map[string]string{
+		"collections": {review.Collections, want.Collections},
+		"roles":       {review.Roles, want.Roles},
+		"tools":       {review.Tools, want.Tools},
+	} {
+		got, exp := pair[0], pair[1]
+		if len(got) != len(exp) {
+			t.Errorf("%s = %v, want %v", section, got, exp)
+			continue
+		}
+		for name, version := range exp {
+			if got[name] != version {
+				t.Errorf("%s[%q] = %q, want %q", section, name, got[name], version)
+			}
+		}
+	}
+	if review.Python != want.Python {
+		t.Errorf("python = %q, want %q", review.Python, want.Python)
+	}
+}
+
+func TestLockReviewIsSorted(t *testing.T) {
+	data, err := MarshalLockReview(reviewTestLock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# ") {
+		t.Error("review file should start with an informational header")
+	}
+
+	// Entries of each section appear in sorted order
+	var section string
+	keys := map[string][]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#") || line == "":
+		case !strings.HasPrefix(line, " "):
+			section = strings.TrimSuffix(strings.SplitN(line, ":", 2)[0], ":")
+		default:
+			key := strings.Trim(strings.SplitN(strings.TrimSpace(line), ": ", 2)[0], `"`)
+			keys[section] = append(keys[section], key)
+		}
+	}
+	for _, section := range []string{"collections", "roles", "tools"} {
+		if len(keys[section]) == 0 {
+			t.Errorf("section %s missing from review file:\n%s", section, data)
+		}
+		if !sort.StringsAreSorted(keys[section]) {
+			t.Errorf("section %s is not sorted: %v", section, keys[section])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package dependency
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"diffusion/internal/config"
+)
+
+// lockUpdate keeps the entries of the current lock file during a targeted
+// 'deps lock --update <name>': only the named dependencies, and those the lock
+// does not hold with the same constraint and source, are resolved again.
+type lockUpdate struct {
+	names  []string
+	locked map[string]LockFileEntry
+}
+
+// newLockUpdate loads diffusion.lock for opts.Update; nil means a full lock
+func newLockUpdate(opts *LockOptions) (*lockUpdate, error) {
+	if len(opts.Update) == 0 {
+		return nil, nil
+	}
+	current, err := LoadLockFile()
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("--update needs an existing %s; run 'diffusion deps lock' first", config.LockFileName)
+	}
+	if current.Platform != opts.Platform {
+		return nil, fmt.Errorf("%s was resolved for platform %q, not %q; run a full 'diffusion deps lock' to change the platform", config.LockFileName, current.Platform, opts.Platform)
+	}
+
+	u := &lockUpdate{names: opts.Update, locked: map[string]LockFileEntry{}}
+	for _, entries := range [][]LockFileEntry{current.Collections, current.Roles, current.Tools} {
+		for _, entry := range entries {
+			u.locked[lockEntryKey(entry)] = entry
+		}
+	}
+	return u, nil
+}
+
+// lockEntryKey identifies the dependency of a lock file entry across runs
+func lockEntryKey(entry LockFileEntry) string {
+	return entry.Type + "\x00" + entry.Namespace + "\x00" + entry.Name
+}
+
+// lockEntryNames returns the names --update accepts for an entry: the lock
+// name and, with a namespace, namespace.name (e.g. community.general)
+func lockEntryNames(entry LockFileEntry) []string {
+	names := []string{entry.Name}
+	switch {
+	case entry.Type == "collection":
+		if _, fullName := CollectionScope(entry.Name, entry.Namespace); fullName != entry.Name {
+			names = append(names, fullName)
+		}
+	case entry.Namespace != "" && !strings.HasPrefix(entry.Name, entry.Namespace+"."):
+		names = append(names, entry.Namespace+"."+entry.Name)
+	}
+	return names
+}
+
+// keptEntries are the locked entries reused by --update, indexed like the
+// collections, roles and tools being locked; nil entries are resolved
+type keptEntries struct {
+	collections, roles, tools []*LockFileEntry
+}
+
+// plan picks the locked entries to keep. It fails when an --update name
+// matches no dependency.
+func (u *lockUpdate) plan(collections []config.CollectionRequirement, roles []config.RoleRequirement, toolNames []string, toolVersions map[string]string) (keptEntries, error) {
+	var wantCollections, wantRoles, wantTools []LockFileEntry
+	for _, col := range collections {
+		wantCollections = append(wantCollections, LockFileEntry{Name: col.Name, Namespace: col.Namespace, Version: col.Version, Type: "collection", Source: cmp.Or(col.Source, "galaxy"), Src: col.SourceURL})
+	}
+	for _, role := range roles {
+		wantRoles = append(wantRoles, LockFileEntry{Name: role.Name, Namespace: role.Namespace, Version: role.Version, Type: "role", Source: cmp.Or(role.Scm, "git"), Src: role.Src})
+	}
+	for _, tool := range toolNames {
+		wantTools = append(wantTools, LockFileEntry{Name: tool, Version: toolVersions[tool], Type: "tool", Source: "pypi"})
+	}
+
+	matched := map[string]bool{}
+	keep := func(wanted []LockFileEntry) []*LockFileEntry {
+		kept := make([]*LockFileEntry, len(wanted))
+		if u == nil {
+			return kept
+		}
+		for i, want := range wanted {
+			if name, ok := u.updated(want); ok {
+				matched[name] = true
+				continue
+			}
+			locked, ok := u.locked[lockEntryKey(want)]
+			if ok && locked.Version == want.Version && locked.Source == want.Source && locked.Src == want.Src {
+				kept[i] = &locked
+			}
+		}
+		return kept
+	}
+	kept := keptEntries{collections: keep(wantCollections), roles: keep(wantRoles), tools: keep(wantTools)}
+
+	if u != nil {
+		for _, name := range u.names {
+			if !matched[name] {
+				return kept, fmt.Errorf("--update %s: no collection, role or tool with that name", name)
+			}
+		}
+	}
+	return kept, nil
+}
+
+// updated reports which --update name, if any, selects want
+func (u *lockUpdate) updated(want LockFileEntry) (string, bool) {
+	for _, name := range lockEntryNames(want) {
+		if slices.Contains(u.names, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
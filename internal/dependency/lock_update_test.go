@@ -0,0 +1,81 @@
+package dependency
+
+import (
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/galaxy"
+)
+
+// stubCollectionVersions makes Galaxy report latest for every collection
+func stubCollectionVersions(t *testing.T, latest map[string]string) *[]string {
+	t.Helper()
+	origValidate, origGalaxy := galaxyValidateVersion, galaxyCollectionVersion
+	t.Cleanup(func() { galaxyValidateVersion, galaxyCollectionVersion = origValidate, origGalaxy })
+
+	var resolved []string
+	galaxyValidateVersion = func(*galaxy.GalaxyAPI, string, string, string) error { return nil }
+	galaxyCollectionVersion = func(_ *galaxy.GalaxyAPI, namespace, name, _ string) (string, error) {
+		resolved = append(resolved, namespace+"."+name)
+		return latest[namespace+"."+name], nil
+	}
+	return &resolved
+}
+
+// writeLockedCollections writes a diffusion.lock holding the collections at version
+func writeLockedCollections(t *testing.T, versions map[string]string) {
+	t.Helper()
+	lock := &LockFile{Version: LockFileVersion}
+	for _, name := range []string{"general", "docker"} {
+		lock.Collections = append(lock.Collections, LockFileEntry{Name: "default." + name, Namespace: "community", Version: ">=1.0.0", ResolvedVersion: versions[name], Type: "collection", Source: "galaxy"})
+	}
+	if err := SaveLockFile(lock); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var updateCollections = []config.CollectionRequirement{
+	{Name: "default.general", Namespace: "community", Version: ">=1.0.0"},
+	{Name: "default.docker", Namespace: "community", Version: ">=1.0.0"},
+}
+
+func TestLockUpdateResolvesOnlyNamedEntry(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeLockedCollections(t, map[string]string{"general": "9.0.0", "docker": "3.0.0"})
+	resolved := stubCollectionVersions(t, map[string]string{"community.general": "10.1.0", "community.docker": "4.2.0"})
+
+	lock, err := GenerateLockFileWithOptions(updateCollections, nil, map[string]string{}, &config.PythonVersion{}, &LockOptions{Quiet: true, Update: []string{"community.general"}})
+	if err != nil {
+		t.Fatalf("GenerateLockFileWithOptions() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, entry := range lock.Collections {
+		got[entry.Name] = entry.ResolvedVersion
+	}
+	if got["default.general"] != "10.1.0" || got["default.docker"] != "3.0.0" {
+		t.Errorf("resolved versions = %v, want general bumped to 10.1.0 and docker kept at 3.0.0", got)
+	}
+	if strings.Join(*resolved, ",") != "community.general" {
+		t.Errorf("Galaxy lookups = %v, want only community.general", *resolved)
+	}
+	if want := ComputeDependencyHash(updateCollections, nil, map[string]string{}, &config.PythonVersion{}); lock.Hash != want {
+		t.Errorf("hash = %s, want %s", lock.Hash, want)
+	}
+}
+
+func TestLockUpdateErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	stubCollectionVersions(t, nil)
+	opts := &LockOptions{Quiet: true, Update: []string{"community.general"}}
+
+	if _, err := GenerateLockFileWithOptions(updateCollections, nil, map[string]string{}, &config.PythonVersion{}, opts); err == nil || !strings.Contains(err.Error(), "run 'diffusion deps lock' first") {
+		t.Errorf("error without a lock file = %v", err)
+	}
+
+	writeLockedCollections(t, map[string]string{"general": "9.0.0", "docker": "3.0.0"})
+	opts.Update = []string{"community.crypto"}
+	if _, err := GenerateLockFileWithOptions(updateCollections, nil, map[string]string{}, &config.PythonVersion{}, opts); err == nil || !strings.Contains(err.Error(), "--update community.crypto") {
+		t.Errorf("error for an unknown name = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// ErrMovingRefs is returned by a --strict lock when a role resolves to a branch
+var ErrMovingRefs = errors.New("roles resolve to moving refs")
+
+var (
+	// versionTagPattern matches release tags such as 1.2.0, v2 or v1.0.0-rc1
+	versionTagPattern = regexp.MustCompile(`^v?\d+(\.\d+)*([-+.][0-9A-Za-z.-]*)?$`)
+	// commitSHAPattern matches abbreviated and full git commit SHAs
+	commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// IsMovingRef reports whether a resolved role version names something that can
+// move under the lock: a branch such as main or master, HEAD, latest or nothing.
+// Version tags and commit SHAs are pinned.
+func IsMovingRef(version string) bool {
+	return !versionTagPattern.MatchString(version) && !commitSHAPattern.MatchString(version)
+}
+
+// MovingRefRoles returns the locked roles whose resolved version is a moving ref
+func MovingRefRoles(lockFile *LockFile) []LockFileEntry {
+	var moving []LockFileEntry
+	for _, entry := range lockFile.Roles {
+		if IsMovingRef(entry.ResolvedVersion) {
+			moving = append(moving, entry)
+		}
+	}
+	return moving
+}
+
+// checkMovingRefs prints a warning listing every role locked to a moving ref;
+// with strict set the warning becomes an ErrMovingRefs error
+func checkMovingRefs(w io.Writer, lockFile *LockFile, strict bool) error {
+	moving := MovingRefRoles(lockFile)
+	if len(moving) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\033[33mWarning: %d role(s) resolve to a moving ref, the lock is not reproducible:\033[0m\n", len(moving))
+	for _, entry := range moving {
+		ref := entry.ResolvedVersion
+		if ref == "" {
+			ref = "unresolved"
+		}
+		if entry.Src != "" {
+			fmt.Fprintf(w, "  %s -> %s (%s)\n", entry.Name, ref, entry.Src)
+		} else {
+			fmt.Fprintf(w, "  %s -> %s\n", entry.Name, ref)
+		}
+	}
+	fmt.Fprintln(w, "Pin each role's version in requirements.yml to a release tag or a commit SHA.")
+	if strict {
+		return fmt.Errorf("%w (%d role(s)); pin them or drop --strict", ErrMovingRefs, len(moving))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsMovingRef(t *testing.T) {
+	tests := map[string]bool{
+		"main":          true,
+		"master":        true,
+		"develop":       true,
+		"feature/login": true,
+		"HEAD":          true,
+		"latest":        true,
+		"":              true,
+		"1.2.0":         false,
+		"v2.0.1":        false,
+		"v1.0.0-rc1":    false,
+		"3":             false,
+		"9f2c1ab":       false,
+		"9f2c1ab0d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9": false,
+	}
+	for version, want := range tests {
+		if got := IsMovingRef(version); got != want {
+			t.Errorf("IsMovingRef(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestCheckMovingRefsWarnsOnBranch(t *testing.T) {
+	lockFile := &LockFile{
+		Roles: []LockFileEntry{
+			{Name: "default.nginx", Type: "role", ResolvedVersion: "main", Src: "https://github.com/org/nginx.git"},
+			{Name: "default.users", Type: "role", ResolvedVersion: "v1.4.0", Src: "https://github.com/org/users.git"},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := checkMovingRefs(&out, lockFile, false); err != nil {
+		t.Fatalf("checkMovingRefs() error = %v, want a warning only", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "default.nginx -> main (https://github.com/org/nginx.git)") {
+		t.Errorf("warning does not list the role on main:\n%s", got)
+	}
+	if strings.Contains(got, "default.users") {
+		t.Errorf("warning lists a role pinned to a tag:\n%s", got)
+	}
+	if !strings.Contains(got, "release tag or a commit SHA") {
+		t.Errorf("warning does not suggest pinning:\n%s", got)
+	}
+
+	out.Reset()
+	if err := checkMovingRefs(&out, lockFile, true); !errors.Is(err, ErrMovingRefs) {
+		t.Errorf("checkMovingRefs(strict) error = %v, want ErrMovingRefs", err)
+	}
+
+	out.Reset()
+	lockFile.Roles = lockFile.Roles[1:]
+	if err := checkMovingRefs(&out, lockFile, true); err != nil || out.Len() != 0 {
+		t.Errorf("pinned roles: error = %v, output %q; want neither", err, out.String())
+	}
+}
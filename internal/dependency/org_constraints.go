@@ -0,0 +1,231 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/galaxy"
+
+	"github.com/BurntSushi/toml"
+)
+
+// OrgConstraints is a shared constraints file with floor (minimum) versions
+// applied to every role's dependencies, similar to pip's constraints files:
+//
+//	[tools]
+//	ansible = ">=10.0.0"
+//
+//	[collections]
+//	"community.general" = ">=7.0.0"
+//
+//	[roles]
+//	"geerlingguy.docker" = "7.0.0"
+//
+// Keys are tool names, namespace.name for collections and Galaxy roles, and the
+// role name for git roles. Values are ">=<version>" or a bare version.
+type OrgConstraints struct {
+	Tools       map[string]string `toml:"tools"`
+	Collections map[string]string `toml:"collections"`
+	Roles       map[string]string `toml:"roles"`
+}
+
+// LoadOrgConstraints reads a constraints file from a local path or an
+// http(s) URL.
+func LoadOrgConstraints(source string) (*OrgConstraints, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch constraints file: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch constraints file %s: status %d", source, resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read constraints file: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("failed to read constraints file: %w", err)
+		}
+	}
+	return ParseOrgConstraints(data)
+}
+
+// ParseOrgConstraints parses a constraints file and normalizes every floor to
+// its bare version.
+func ParseOrgConstraints(data []byte) (*OrgConstraints, error) {
+	var c OrgConstraints
+	if err := toml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse constraints file: %w", err)
+	}
+	for section, floors := range map[string]map[string]string{"tools": c.Tools, "collections": c.Collections, "roles": c.Roles} {
+		for name, floor := range floors {
+			op, version := splitConstraint(floor)
+			if (op != "" && op != ">=") || !constraintVersionPattern.MatchString(version) {
+				return nil, fmt.Errorf("invalid floor %q for %s.%s: expected >=<version> or <version>", floor, section, name)
+			}
+			floors[name] = version
+		}
+	}
+	if c.Tools != nil {
+		// Accept the diffusion.toml spelling (ansible_lint) for tool names
+		for name, floor := range c.Tools {
+			if normalized := strings.ReplaceAll(name, "_", "-"); normalized != name {
+				delete(c.Tools, name)
+				c.Tools[normalized] = floor
+			}
+		}
+	}
+	return &c, nil
+}
+
+// Apply raises the constraints of the given collections, roles and tools to the
+// org floors in place and returns a description of each change. Constraints
+// already at or above the floor (or pinned to a branch) are kept.
+func (c *OrgConstraints) Apply(collections []config.CollectionRequirement, roles []config.RoleRequirement, tools map[string]string) ([]string, error) {
+	var changes []string
+	raise := func(kind, key, local, floor string) (string, error) {
+		updated, err := applyFloor(local, floor)
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %w", kind, key, err)
+		}
+		if updated != local {
+			changes = append(changes, fmt.Sprintf("%s %s: %q -> %q (org floor %s)", kind, key, local, updated, floor))
+		}
+		return updated, nil
+	}
+
+	for i, col := range collections {
+		key := dependencyKey(col.Namespace, col.Name)
+		if floor, ok := c.Collections[key]; ok {
+			version, err := raise("collection", key, col.Version, floor)
+			if err != nil {
+				return nil, err
+			}
+			collections[i].Version = version
+		}
+	}
+
+	for i, r := range roles {
+		namespace := r.Namespace
+		if r.Src != "" {
+			namespace = ""
+		}
+		key := dependencyKey(namespace, r.Name)
+		if floor, ok := c.Roles[key]; ok {
+			version, err := raise("role", key, r.Version, floor)
+			if err != nil {
+				return nil, err
+			}
+			roles[i].Version = version
+		}
+	}
+
+	toolNames := make([]string, 0, len(tools))
+	for name := range tools {
+		toolNames = append(toolNames, name)
+	}
+	sort.Strings(toolNames)
+	for _, name := range toolNames {
+		if floor, ok := c.Tools[name]; ok {
+			version, err := raise("tool", name, tools[name], floor)
+			if err != nil {
+				return nil, err
+			}
+			tools[name] = version
+		}
+	}
+
+	return changes, nil
+}
+
+// dependencyKey returns the constraints file key for a dependency whose config
+// name carries a scenario prefix ("default.general" -> "community.general")
+func dependencyKey(namespace, name string) string {
+	if _, short, ok := strings.Cut(name, "."); ok {
+		name = short
+	}
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// applyFloor combines a local constraint with an org floor version. The local
+// constraint wins when it is at least as strict; a weaker or missing lower bound
+// is replaced by ">=floor" while upper bounds and exclusions are kept. A pin or
+// upper bound below the floor cannot be satisfied and is an error.
+func applyFloor(local, floor string) (string, error) {
+	local = strings.TrimSpace(local)
+	switch local {
+	case "", "latest":
+		return ">=" + floor, nil
+	case "main", "master":
+		return local, nil
+	}
+	if ValidateConstraint(local) != nil {
+		// Branch or tag names cannot be compared with a version floor
+		return local, nil
+	}
+
+	var rest []string
+	for _, part := range strings.Split(local, ",") {
+		op, version := splitConstraint(part)
+		switch op {
+		case "", "==", "=":
+			if galaxy.CompareVersions(version, floor) < 0 {
+				return "", fmt.Errorf("pinned version %s is below the org floor %s", version, floor)
+			}
+			return local, nil
+		case ">=", ">":
+			if galaxy.CompareVersions(version, floor) >= 0 {
+				return local, nil
+			}
+		case "~=":
+			if galaxy.CompareVersions(version, floor) >= 0 {
+				return local, nil
+			}
+			// ~=X also caps the release series, so keep it next to the floor
+			if upper := compatibleUpperBound(version); galaxy.CompareVersions(upper, floor) <= 0 {
+				return "", fmt.Errorf("compatible release ~=%s is below the org floor %s", version, floor)
+			}
+			rest = append(rest, strings.TrimSpace(part))
+		case "<", "<=":
+			if cmp := galaxy.CompareVersions(version, floor); cmp < 0 || (cmp == 0 && op == "<") {
+				return "", fmt.Errorf("upper bound %s%s is below the org floor %s", op, version, floor)
+			}
+			rest = append(rest, strings.TrimSpace(part))
+		default:
+			rest = append(rest, strings.TrimSpace(part))
+		}
+	}
+
+	return strings.Join(append([]string{">=" + floor}, rest...), ","), nil
+}
+
+// compatibleUpperBound returns the exclusive upper bound of a ~= constraint
+// ("2.4" -> "3", "2.4.1" -> "2.5")
+func compatibleUpperBound(version string) string {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) > 1 {
+		parts = parts[:len(parts)-1]
+	}
+	last := len(parts) - 1
+	n, err := strconv.Atoi(parts[last])
+	if err != nil {
+		return version
+	}
+	parts[last] = strconv.Itoa(n + 1)
+	return strings.Join(parts, ".")
+}
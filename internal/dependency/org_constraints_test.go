@@ -0,0 +1,189 @@
+package dependency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+const testOrgConstraints = `
+[tools]
+ansible_lint = ">=24.0.0"
+
+[collections]
+"community.general" = ">=7.0.0"
+
+[roles]
+"geerlingguy.docker" = "7.0.0"
+"my_role" = ">=2.0.0"
+`
+
+func TestParseOrgConstraints(t *testing.T) {
+	c, err := ParseOrgConstraints([]byte(testOrgConstraints))
+	if err != nil {
+		t.Fatalf("ParseOrgConstraints() error: %v", err)
+	}
+	if got := c.Collections["community.general"]; got != "7.0.0" {
+		t.Errorf("collection floor = %q, want 7.0.0", got)
+	}
+	if got := c.Roles["geerlingguy.docker"]; got != "7.0.0" {
+		t.Errorf("role floor = %q, want 7.0.0", got)
+	}
+	if got := c.Tools["ansible-lint"]; got != "24.0.0" {
+		t.Errorf("tool floor = %q, want 24.0.0 under ansible-lint", got)
+	}
+
+	for _, data := range []string{
+		`[collections]` + "\n" + `"community.general" = "<7.0.0"`,
+		`[collections]` + "\n" + `"community.general" = ">=7.0.0,<8.0.0"`,
+		`[roles]` + "\n" + `"geerlingguy.docker" = "main"`,
+		`[tools`,
+	} {
+		if _, err := ParseOrgConstraints([]byte(data)); err == nil {
+			t.Errorf("ParseOrgConstraints(%q) expected error", data)
+		}
+	}
+}
+
+func TestApplyFloor(t *testing.T) {
+	tests := []struct {
+		local   string
+		want    string
+		wantErr bool
+	}{
+		{local: "", want: ">=7.0.0"},
+		{local: "latest", want: ">=7.0.0"},
+		{local: ">=6.0.0", want: ">=7.0.0"},
+		{local: ">=7.0.0", want: ">=7.0.0"},
+		{local: ">=8.0.0", want: ">=8.0.0"},
+		{local: "==7.5.0", want: "==7.5.0"},
+		{local: "8.0.0", want: "8.0.0"},
+		{local: ">=6.0.0,<9.0.0", want: ">=7.0.0,<9.0.0"},
+		{local: "<9.0.0", want: ">=7.0.0,<9.0.0"},
+		{local: "~=6.1", wantErr: true},
+		{local: "~=7.1", want: "~=7.1"},
+		{local: "main", want: "main"},
+		{local: "==6.0.0", wantErr: true},
+		{local: "<6.0.0", wantErr: true},
+		{local: ">=5.0.0,<=7.0.0", want: ">=7.0.0,<=7.0.0"},
+	}
+	for _, tt := range tests {
+		got, err := applyFloor(tt.local, "7.0.0")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("applyFloor(%q) expected error, got %q", tt.local, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("applyFloor(%q) unexpected error: %v", tt.local, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("applyFloor(%q) = %q, want %q", tt.local, got, tt.want)
+		}
+	}
+}
+
+func TestOrgConstraintsApply(t *testing.T) {
+	c, err := ParseOrgConstraints([]byte(testOrgConstraints))
+	if err != nil {
+		t.Fatalf("ParseOrgConstraints() error: %v", err)
+	}
+
+	collections := []config.CollectionRequirement{
+		{Name: "default.general", Namespace: "community", Version: ">=6.0.0"},
+		{Name: "default.docker", Namespace: "community", Version: ">=3.0.0"},
+	}
+	roles := []config.RoleRequirement{
+		{Name: "default.docker", Namespace: "geerlingguy", Version: ">=8.0.0"},
+		{Name: "default.my_role", Src: "https://github.com/example/my_role.git", Version: "main"},
+	}
+	tools := map[string]string{"ansible-lint": ">=6.0.0", "yamllint": ">=1.0.0"}
+
+	changes, err := c.Apply(collections, roles, tools)
+	if err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	// Central floor applied
+	if got := collections[0].Version; got != ">=7.0.0" {
+		t.Errorf("community.general = %q, want >=7.0.0", got)
+	}
+	if got := tools["ansible-lint"]; got != ">=24.0.0" {
+		t.Errorf("ansible-lint = %q, want >=24.0.0", got)
+	}
+	// Stricter local constraint and unconstrained dependencies are kept
+	if got := roles[0].Version; got != ">=8.0.0" {
+		t.Errorf("geerlingguy.docker = %q, want the stricter local >=8.0.0", got)
+	}
+	if got := collections[1].Version; got != ">=3.0.0" {
+		t.Errorf("community.docker = %q, want >=3.0.0", got)
+	}
+	if got := roles[1].Version; got != "main" {
+		t.Errorf("my_role = %q, want branch main kept", got)
+	}
+	if got := tools["yamllint"]; got != ">=1.0.0" {
+		t.Errorf("yamllint = %q, want >=1.0.0", got)
+	}
+	if len(changes) != 2 {
+		t.Errorf("Apply() returned %d changes, want 2: %v", len(changes), changes)
+	}
+
+	roles[0].Version = "==6.0.0"
+	if _, err := c.Apply(nil, roles, nil); err == nil {
+		t.Error("Apply() expected error for a pin below the org floor")
+	}
+}
+
+func TestLoadOrgConstraints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "constraints.toml")
+	if err := os.WriteFile(path, []byte(testOrgConstraints), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadOrgConstraints(path)
+	if err != nil {
+		t.Fatalf("LoadOrgConstraints(file) error: %v", err)
+	}
+	if got := c.Collections["community.general"]; got != "7.0.0" {
+		t.Errorf("file collection floor = %q, want 7.0.0", got)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/constraints.toml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testOrgConstraints))
+	}))
+	defer server.Close()
+
+	c, err = LoadOrgConstraints(server.URL + "/constraints.toml")
+	if err != nil {
+		t.Fatalf("LoadOrgConstraints(url) error: %v", err)
+	}
+	if got := c.Roles["geerlingguy.docker"]; got != "7.0.0" {
+		t.Errorf("url role floor = %q, want 7.0.0", got)
+	}
+
+	if _, err := LoadOrgConstraints(server.URL + "/missing.toml"); err == nil {
+		t.Error("LoadOrgConstraints() expected error for a 404 response")
+	}
+	if _, err := LoadOrgConstraints(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("LoadOrgConstraints() expected error for a missing file")
+	}
+}
+
+func TestApplyFloorCompatibleRelease(t *testing.T) {
+	got, err := applyFloor("~=6.1", "6.5.0")
+	if err != nil {
+		t.Fatalf("applyFloor() unexpected error: %v", err)
+	}
+	if want := ">=6.5.0,~=6.1"; got != want {
+		t.Errorf("applyFloor(~=6.1, 6.5.0) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"diffusion/internal/utils"
+)
+
+// MaxDefaultResolveConcurrency caps the default number of Galaxy/PyPI/git
+// lookups run in parallel; --threads can go higher
+const MaxDefaultResolveConcurrency = 8
+
+// DefaultResolveConcurrency is the default number of parallel lookups: the CPU
+// count, capped at MaxDefaultResolveConcurrency
+func DefaultResolveConcurrency() int {
+	return max(1, min(runtime.NumCPU(), MaxDefaultResolveConcurrency))
+}
+
+// LockOptions controls how a lock file is generated
+type LockOptions struct {
+	Quiet       bool      // Suppress progress output
+	TTY         bool      // Output is a terminal: progress redraws one spinner line instead of printing a line per lookup
+	Concurrency int       // Number of parallel lookups (DefaultResolveConcurrency() when <= 0)
+	DryRun      bool      // Print the resolved lock file instead of writing it
+	Frozen      bool      // Compare the resolved lock file with diffusion.lock instead of writing it
+	Output      io.Writer // Destination for progress and preview output (os.Stdout when nil)
+	Constraints string    // Path or URL of an org constraints file; overrides dependencies.constraints
+	EmitReview  string    // Path of a flattened review YAML written next to the lock file
+	Strict      bool      // Fail instead of warning when a role resolves to a moving ref (branch)
+	Platform    string    // Target platform (linux/amd64, linux/arm64) Python wheels must support; "" accepts any
+	Update      []string  // Resolve only these collections, roles or tools again and keep the rest of diffusion.lock; empty resolves everything
+}
+
+func (o *LockOptions) output() io.Writer {
+	if o == nil || o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+func (o *LockOptions) concurrency() int {
+	if o == nil || o.Concurrency <= 0 {
+		return DefaultResolveConcurrency()
+	}
+	return o.Concurrency
+}
+
+// resolveProgress reports how many dependencies have been resolved so far.
+// Step is safe to call from multiple goroutines.
+type resolveProgress struct {
+	total   int64
+	done    atomic.Int64
+	out     io.Writer
+	spinner *utils.Spinner // Set on a terminal: the count is redrawn in place
+	mu      sync.Mutex
+}
+
+// newResolveProgress creates a progress reporter for total lookups. Output is
+// disabled when opts requests quiet output.
+func newResolveProgress(total int, opts *LockOptions) *resolveProgress {
+	p := &resolveProgress{total: int64(total)}
+	if opts != nil && opts.Quiet {
+		return p
+	}
+	p.out = opts.output()
+	if opts != nil && opts.TTY && total > 0 {
+		p.spinner = utils.NewSpinner(p.message(0))
+		p.spinner.SetOutput(p.out)
+		p.spinner.Start()
+	}
+	return p
+}
+
+func (p *resolveProgress) message(done int64) string {
+	return fmt.Sprintf("Resolved %d/%d dependencies", done, p.total)
+}
+
+// Step records one completed lookup and shows the updated count: in place on
+// a terminal, otherwise as a new line
+func (p *resolveProgress) Step() {
+	done := p.done.Add(1)
+	if p.out == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spinner != nil {
+		p.spinner.UpdateMessage(p.message(done))
+		return
+	}
+	fmt.Fprintln(p.out, p.message(done))
+}
+
+// Done returns the number of completed lookups
+func (p *resolveProgress) Done() int64 {
+	return p.done.Load()
+}
+
+// Finish stops the spinner and prints a final summary line once all lookups
+// are complete
+func (p *resolveProgress) Finish() {
+	if p.out == nil || p.total == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spinner != nil {
+		p.spinner.Stop()
+	}
+	fmt.Fprintf(p.out, "\033[32mResolved all %d dependencies\033[0m\n", p.total)
+}
@@ -0,0 +1,83 @@
+package dependency
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResolveProgressReachesTotal(t *testing.T) {
+	const total = 40
+	var buf bytes.Buffer
+	p := newResolveProgress(total, &LockOptions{Output: &buf})
+
+	var wg sync.WaitGroup
+	for range total {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Step()
+		}()
+	}
+	wg.Wait()
+	p.Finish()
+
+	if p.Done() != total {
+		t.Errorf("Done() = %d, want %d", p.Done(), total)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != total+1 {
+		t.Fatalf("got %d progress lines, want %d", len(lines), total+1)
+	}
+	if !strings.Contains(buf.String(), "Resolved 40/40 dependencies") {
+		t.Errorf("progress output missing final count:\n%s", buf.String())
+	}
+}
+
+func TestResolveProgressTerminal(t *testing.T) {
+	const total = 40
+	var buf bytes.Buffer
+	p := newResolveProgress(total, &LockOptions{Output: &buf, TTY: true})
+	for range total {
+		p.Step()
+	}
+	p.Finish()
+
+	// The count is redrawn on one spinner line; only the summary ends a line
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Errorf("got %d lines of progress output on a terminal, want 1:\n%q", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "\r") || !strings.HasSuffix(buf.String(), "Resolved all 40 dependencies\033[0m\n") {
+		t.Errorf("unexpected terminal progress output: %q", buf.String())
+	}
+}
+
+func TestResolveProgressQuiet(t *testing.T) {
+	for _, tty := range []bool{false, true} {
+		var buf bytes.Buffer
+		p := newResolveProgress(3, &LockOptions{Quiet: true, TTY: tty, Output: &buf})
+		for range 3 {
+			p.Step()
+		}
+		p.Finish()
+
+		if p.Done() != 3 {
+			t.Errorf("Done() = %d, want 3", p.Done())
+		}
+		if buf.Len() != 0 {
+			t.Errorf("tty=%v: expected no progress output, got %q", tty, buf.String())
+		}
+	}
+}
+
+func TestLockOptionsConcurrency(t *testing.T) {
+	var nilOpts *LockOptions
+	if got := nilOpts.concurrency(); got != DefaultResolveConcurrency() {
+		t.Errorf("nil concurrency() = %d, want %d", got, DefaultResolveConcurrency())
+	}
+	if got := (&LockOptions{Concurrency: 2}).concurrency(); got != 2 {
+		t.Errorf("concurrency() = %d, want 2", got)
+	}
+}
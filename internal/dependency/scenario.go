@@ -0,0 +1,106 @@
+package dependency
+
+import (
+	"sort"
+	"strings"
+
+	"diffusion/internal/galaxy"
+	"diffusion/internal/role"
+)
+
+// Collections are stored in diffusion.toml and diffusion.lock as
+// "<scenario>.<name>" with the Galaxy namespace kept separately, so each
+// scenario has its own set. Entries without a namespace use the plain
+// "namespace.name" of older configs and are shared by every scenario.
+
+// CollectionScope splits a collection config or lock name into its scenario and
+// namespace.name; scenario is empty for a shared (unscoped) collection
+func CollectionScope(name, namespace string) (scenario, fullName string) {
+	if namespace == "" {
+		return "", name
+	}
+	if s, short, ok := strings.Cut(name, "."); ok {
+		return s, namespace + "." + short
+	}
+	return "", namespace + "." + name
+}
+
+// scenarioLockCollections returns the locked collections of a scenario keyed by
+// namespace.name: the scenario's own collections plus the shared ones it does
+// not declare itself. Collections scoped to other scenarios are left out.
+func scenarioLockCollections(lockFile *LockFile, scenario string) map[string]LockFileEntry {
+	byName := map[string]LockFileEntry{}
+	scoped := map[string]bool{}
+	for _, entry := range lockFile.Collections {
+		entryScenario, fullName := CollectionScope(entry.Name, entry.Namespace)
+		if entryScenario != "" && entryScenario != scenario {
+			continue
+		}
+		if entryScenario == "" && scoped[fullName] {
+			continue
+		}
+		byName[fullName] = entry
+		if entryScenario != "" {
+			scoped[fullName] = true
+		}
+	}
+	return byName
+}
+
+// ScenarioCollections returns the locked collections of a scenario for its
+// requirements.yml, sorted by namespace.name (see scenarioLockCollections).
+// Git-sourced collections are written the way ansible-galaxy installs them
+// from git: the repository as name and type git; "source" is left out, since
+// ansible-galaxy reads it as a Galaxy server URL.
+func ScenarioCollections(lockFile *LockFile, scenario string) []role.RequirementCollection {
+	if lockFile == nil {
+		return nil
+	}
+	byName := scenarioLockCollections(lockFile, scenario)
+	names := sortedCollectionNames(byName)
+	collections := make([]role.RequirementCollection, 0, len(names))
+	for _, fullName := range names {
+		collections = append(collections, requirementCollection(fullName, byName[fullName]))
+	}
+	return collections
+}
+
+// ScenarioCollectionNames returns the sorted namespace.name of the locked
+// collections of a scenario, the form meta/main.yml lists them in
+func ScenarioCollectionNames(lockFile *LockFile, scenario string) []string {
+	if lockFile == nil {
+		return nil
+	}
+	return sortedCollectionNames(scenarioLockCollections(lockFile, scenario))
+}
+
+func sortedCollectionNames(byName map[string]LockFileEntry) []string {
+	names := make([]string, 0, len(byName))
+	for fullName := range byName {
+		names = append(names, fullName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requirementCollection converts a locked collection into its requirements.yml entry
+func requirementCollection(fullName string, entry LockFileEntry) role.RequirementCollection {
+	version := entry.ResolvedVersion
+	if version == "" {
+		version = entry.Version
+	}
+	if IsGitCollection(entry) {
+		return role.RequirementCollection{
+			Name:    galaxy.ParseGitSource(entry.Src).CollectionSrc(),
+			Type:    "git",
+			Version: version,
+		}
+	}
+	return role.RequirementCollection{Name: fullName, Version: version}
+}
+
+// IsGitCollection reports whether a locked collection is installed from git
+// rather than Galaxy
+func IsGitCollection(entry LockFileEntry) bool {
+	return entry.Source == "git" && entry.Src != ""
+}
@@ -0,0 +1,80 @@
+package dependency
+
+import (
+	"reflect"
+	"testing"
+
+	"diffusion/internal/role"
+)
+
+func TestCollectionScope(t *testing.T) {
+	tests := []struct {
+		name, namespace        string
+		wantScenario, wantFull string
+	}{
+		{"default.general", "community", "default", "community.general"},
+		{"cloud.aws", "amazon", "cloud", "amazon.aws"},
+		{"community.general", "", "", "community.general"},
+		{"general", "community", "", "community.general"},
+	}
+	for _, tt := range tests {
+		scenario, full := CollectionScope(tt.name, tt.namespace)
+		if scenario != tt.wantScenario || full != tt.wantFull {
+			t.Errorf("CollectionScope(%q, %q) = %q, %q; want %q, %q", tt.name, tt.namespace, scenario, full, tt.wantScenario, tt.wantFull)
+		}
+	}
+}
+
+func TestScenarioCollections(t *testing.T) {
+	lockFile := &LockFile{Collections: []LockFileEntry{
+		{Name: "default.general", Namespace: "community", Version: ">=7.4.0", ResolvedVersion: "9.1.0"},
+		{Name: "cloud.aws", Namespace: "amazon", Version: ">=7.0.0", ResolvedVersion: "8.2.0"},
+		{Name: "cloud.general", Namespace: "community", Version: ">=8.0.0", ResolvedVersion: "9.1.0"},
+		{Name: "ansible.posix", Version: ">=1.5.0", ResolvedVersion: "1.6.2"},
+		{Name: "community.general", Version: ">=6.0.0", ResolvedVersion: "6.6.0"},
+	}}
+
+	got := ScenarioCollections(lockFile, "default")
+	want := []role.RequirementCollection{
+		{Name: "ansible.posix", Version: "1.6.2"},
+		{Name: "community.general", Version: "9.1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default collections = %v, want %v", got, want)
+	}
+
+	got = ScenarioCollections(lockFile, "cloud")
+	want = []role.RequirementCollection{
+		{Name: "amazon.aws", Version: "8.2.0"},
+		{Name: "ansible.posix", Version: "1.6.2"},
+		{Name: "community.general", Version: "9.1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cloud collections = %v, want %v", got, want)
+	}
+
+	if got := ScenarioCollections(nil, "default"); got != nil {
+		t.Errorf("nil lock file gave %v", got)
+	}
+}
+
+func TestScenarioCollectionsWritesGitSources(t *testing.T) {
+	lockFile := &LockFile{Collections: []LockFileEntry{
+		{Name: "default.general", Namespace: "community", ResolvedVersion: "9.1.0", Source: "galaxy"},
+		{Name: "default.internal", Namespace: "acme", Version: "latest", ResolvedVersion: "v1.2.0", Source: "git", Src: "https://git.example.com/acme/collections.git//internal"},
+	}}
+
+	got := ScenarioCollections(lockFile, "default")
+	want := []role.RequirementCollection{
+		{Name: "https://git.example.com/acme/collections.git#/internal", Type: "git", Version: "v1.2.0"},
+		{Name: "community.general", Version: "9.1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collections = %+v, want %+v", got, want)
+	}
+
+	names := ScenarioCollectionNames(lockFile, "default")
+	if !reflect.DeepEqual(names, []string{"acme.internal", "community.general"}) {
+		t.Errorf("collection names = %v", names)
+	}
+}
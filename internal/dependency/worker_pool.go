@@ -0,0 +1,35 @@
+package dependency
+
+import "sync"
+
+// workerPool runs functions on goroutines, at most size of them at a time
+type workerPool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// newWorkerPool creates a pool running up to size functions at once (at least one)
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{sem: make(chan struct{}, max(1, size))}
+}
+
+// Size returns the number of functions the pool runs concurrently
+func (p *workerPool) Size() int {
+	return cap(p.sem)
+}
+
+// Go schedules fn; it blocks in its goroutine until a worker slot is free
+func (p *workerPool) Go(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.sem <- struct{}{}
+		defer func() { <-p.sem }()
+		fn()
+	}()
+}
+
+// Wait blocks until all scheduled functions have returned
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,98 @@
+package dependency
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"diffusion/internal/config"
+)
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	for _, size := range []int{1, 3} {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			pool := newWorkerPool(size)
+			if pool.Size() != size {
+				t.Fatalf("Size() = %d, want %d", pool.Size(), size)
+			}
+
+			var running, done atomic.Int64
+			release := make(chan struct{})
+			for range 12 {
+				pool.Go(func() {
+					running.Add(1)
+					<-release
+					running.Add(-1)
+					done.Add(1)
+				})
+			}
+
+			// Wait for the pool to fill up, then make sure no extra task starts
+			deadline := time.Now().Add(2 * time.Second)
+			for running.Load() < int64(size) && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if got := running.Load(); got != int64(size) {
+				t.Errorf("running tasks = %d, want %d", got, size)
+			}
+			close(release)
+			pool.Wait()
+
+			if got := done.Load(); got != 12 {
+				t.Errorf("completed tasks = %d, want 12", got)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolMinimumSize(t *testing.T) {
+	if got := newWorkerPool(0).Size(); got != 1 {
+		t.Errorf("newWorkerPool(0).Size() = %d, want 1", got)
+	}
+}
+
+func TestGenerateLockFileWithThreads(t *testing.T) {
+	// Refs pinned in monorepo sources resolve without network access
+	var collections []config.CollectionRequirement
+	for i := range 6 {
+		collections = append(collections, config.CollectionRequirement{
+			Name:      fmt.Sprintf("default.col%d", i),
+			Namespace: "acme",
+			Source:    "git",
+			SourceURL: fmt.Sprintf("https://git.example.com/org/collections.git//acme/col%d@v1.%d.0", i, i),
+		})
+	}
+
+	opts := &LockOptions{Quiet: true, Concurrency: 2}
+	if got := opts.concurrency(); got != 2 {
+		t.Fatalf("concurrency() = %d, want 2", got)
+	}
+	lockFile, err := GenerateLockFileWithOptions(collections, nil, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("GenerateLockFileWithOptions() error = %v", err)
+	}
+	if len(lockFile.Collections) != len(collections) {
+		t.Fatalf("got %d collections, want %d", len(lockFile.Collections), len(collections))
+	}
+	for i, entry := range lockFile.Collections {
+		if entry.Name != collections[i].Name {
+			t.Errorf("collection %d = %s, want %s (input order)", i, entry.Name, collections[i].Name)
+		}
+		if want := fmt.Sprintf("v1.%d.0", i); entry.ResolvedVersion != want {
+			t.Errorf("collection %s resolved to %q, want %q", entry.Name, entry.ResolvedVersion, want)
+		}
+	}
+}
+
+func TestGenerateLockFileRejectsSubdirRoleSrc(t *testing.T) {
+	roles := []config.RoleRequirement{{
+		Name: "default.nginx",
+		Src:  "https://git.example.com/org/roles.git//roles/nginx@v1.0.0",
+		Scm:  "git",
+	}}
+	if _, err := GenerateLockFileWithOptions(nil, roles, nil, nil, &LockOptions{Quiet: true}); err == nil {
+		t.Error("expected a role src with a subdirectory to be rejected")
+	}
+}
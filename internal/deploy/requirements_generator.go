@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"diffusion/internal/dependency"
+	"diffusion/internal/galaxy"
 
 	"gopkg.in/yaml.v3"
 )
@@ -56,6 +57,9 @@ func GenerateRequirements(lock *dependency.LockFile) ([]byte, error) {
 
 		if entry.Source == "git" || entry.Src != "" {
 			// Git role: src is the URL, name is optional override.
+			if err := galaxy.ValidateRoleSrc(entry.Src); err != nil {
+				return nil, err
+			}
 			role.Src = entry.Src
 			role.Scm = "git"
 			if entry.Namespace != "" {
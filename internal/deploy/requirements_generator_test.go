@@ -90,6 +90,26 @@ func TestGenerateRequirements_GitRole(t *testing.T) {
 	}
 }
 
+func TestGenerateRequirements_RejectsMonorepoGitRole(t *testing.T) {
+	lock := dependency.LockFile{
+		Version: dependency.LockFileVersion,
+		Roles: []dependency.LockFileEntry{
+			{
+				Name:            "nginx",
+				Version:         "",
+				ResolvedVersion: "v1.2.0",
+				Source:          "git",
+				Src:             "https://git.example.com/org/roles.git//roles/nginx@v1.2.0",
+				Type:            "role",
+			},
+		},
+	}
+	// ansible-galaxy cannot install a role from a repository subdirectory
+	if _, err := GenerateRequirements(&lock); err == nil {
+		t.Error("expected a role src with a subdirectory to be rejected")
+	}
+}
+
 func TestGenerateRequirements_Collection(t *testing.T) {
 	lock := lockWithCollection("community", "general", ">=7.0.0", "7.5.0")
 	out, err := GenerateRequirements(&lock)
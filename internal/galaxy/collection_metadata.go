@@ -0,0 +1,162 @@
+package galaxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNotFound is returned when Galaxy answers 404 for a collection or version
+var ErrNotFound = errors.New("not found on Galaxy")
+
+// collectionVersionsPageSize is the page size requested from the versions endpoint
+const collectionVersionsPageSize = 100
+
+// CollectionMetadata is the Galaxy v3 index entry of a collection
+type CollectionMetadata struct {
+	Namespace      string
+	Name           string
+	HighestVersion string
+	Deprecated     bool
+	Dependencies   map[string]string // Dependencies of HighestVersion: namespace.name -> constraint
+}
+
+// collectionIndexURL returns the v3 index endpoint of a collection
+func (g *GalaxyAPI) collectionIndexURL(namespace, name string) string {
+	return fmt.Sprintf("%s/plugin/ansible/content/published/collections/index/%s/%s/",
+		strings.TrimSuffix(g.BaseURL, "/"), url.PathEscape(namespace), url.PathEscape(name))
+}
+
+// GetCollectionMetadata fetches the highest version, deprecation flag and the
+// dependencies of the highest version of a collection
+func (g *GalaxyAPI) GetCollectionMetadata(namespace, name string) (*CollectionMetadata, error) {
+	var index struct {
+		Namespace      string `json:"namespace"`
+		Name           string `json:"name"`
+		Deprecated     bool   `json:"deprecated"`
+		HighestVersion struct {
+			Href    string `json:"href"`
+			Version string `json:"version"`
+		} `json:"highest_version"`
+	}
+	indexURL := g.collectionIndexURL(namespace, name)
+	if err := g.getJSON(indexURL, &index); err != nil {
+		return nil, fmt.Errorf("collection %s.%s: %w", namespace, name, err)
+	}
+	if index.HighestVersion.Version == "" {
+		return nil, fmt.Errorf("no version found for %s.%s", namespace, name)
+	}
+
+	meta := &CollectionMetadata{
+		Namespace:      namespace,
+		Name:           name,
+		HighestVersion: index.HighestVersion.Version,
+		Deprecated:     index.Deprecated,
+		Dependencies:   map[string]string{},
+	}
+
+	versionURL := indexURL + "versions/" + url.PathEscape(index.HighestVersion.Version) + "/"
+	if index.HighestVersion.Href != "" {
+		if resolved, err := g.resolveURL(index.HighestVersion.Href); err == nil {
+			versionURL = resolved
+		}
+	}
+	if err := g.fillDependencies(meta, versionURL); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// fillDependencies reads metadata.dependencies from a collection version endpoint
+func (g *GalaxyAPI) fillDependencies(meta *CollectionMetadata, versionURL string) error {
+	var version struct {
+		Metadata struct {
+			Dependencies map[string]string `json:"dependencies"`
+		} `json:"metadata"`
+	}
+	if err := g.getJSON(versionURL, &version); err != nil {
+		return fmt.Errorf("collection %s.%s %s: %w", meta.Namespace, meta.Name, meta.HighestVersion, err)
+	}
+	for dep, constraint := range version.Metadata.Dependencies {
+		meta.Dependencies[dep] = constraint
+	}
+	return nil
+}
+
+// GetCollectionVersions returns every published version of a collection,
+// following the pagination links of the v3 versions endpoint
+func (g *GalaxyAPI) GetCollectionVersions(namespace, name string) ([]string, error) {
+	next := fmt.Sprintf("%sversions/?limit=%d", g.collectionIndexURL(namespace, name), collectionVersionsPageSize)
+	var versions []string
+	for next != "" {
+		var page struct {
+			Data []struct {
+				Version string `json:"version"`
+			} `json:"data"`
+			Links struct {
+				Next string `json:"next"`
+			} `json:"links"`
+		}
+		if err := g.getJSON(next, &page); err != nil {
+			return nil, fmt.Errorf("collection %s.%s versions: %w", namespace, name, err)
+		}
+		for _, v := range page.Data {
+			versions = append(versions, v.Version)
+		}
+
+		next = ""
+		if page.Links.Next != "" {
+			resolved, err := g.resolveURL(page.Links.Next)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pagination link %q: %w", page.Links.Next, err)
+			}
+			next = resolved
+		}
+	}
+	return versions, nil
+}
+
+// resolveURL resolves a link returned by Galaxy (usually host-relative) against BaseURL
+func (g *GalaxyAPI) resolveURL(ref string) (string, error) {
+	base, err := url.Parse(g.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	target, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(target).String(), nil
+}
+
+// getJSON performs a GET request and decodes the JSON body into v. A 404 is
+// reported as ErrNotFound.
+func (g *GalaxyAPI) getJSON(rawURL string, v interface{}) error {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
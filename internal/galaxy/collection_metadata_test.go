@@ -0,0 +1,138 @@
+package galaxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newGalaxyFixture serves a v3 index, a version detail and two pages of versions
+// for community.general under /api/v3
+func newGalaxyFixture(t *testing.T) *GalaxyAPI {
+	t.Helper()
+	const base = "/api/v3/plugin/ansible/content/published/collections/index/community/general/"
+	mux := http.NewServeMux()
+	mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"namespace":"community","name":"general","deprecated":true,
+			"highest_version":{"href":"`+base+`versions/9.1.0/","version":"9.1.0"}}`)
+	})
+	mux.HandleFunc(base+"versions/9.1.0/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version":"9.1.0","metadata":{"dependencies":{"ansible.utils":">=2.0.0"}}}`)
+	})
+	mux.HandleFunc(base+"versions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			fmt.Fprint(w, `{"data":[{"version":"9.1.0"},{"version":"9.0.0"}],
+				"links":{"next":"`+base+`versions/?limit=2&offset=2"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"version":"8.6.0"}],"links":{"next":null}}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &GalaxyAPI{BaseURL: server.URL + "/api/v3", Client: server.Client()}
+}
+
+func TestGetCollectionMetadata(t *testing.T) {
+	api := newGalaxyFixture(t)
+
+	meta, err := api.GetCollectionMetadata("community", "general")
+	if err != nil {
+		t.Fatalf("GetCollectionMetadata() error: %v", err)
+	}
+	if meta.HighestVersion != "9.1.0" {
+		t.Errorf("HighestVersion = %q, want 9.1.0", meta.HighestVersion)
+	}
+	if !meta.Deprecated {
+		t.Error("Deprecated = false, want true")
+	}
+	if want := map[string]string{"ansible.utils": ">=2.0.0"}; !reflect.DeepEqual(meta.Dependencies, want) {
+		t.Errorf("Dependencies = %v, want %v", meta.Dependencies, want)
+	}
+
+	if _, err := api.GetCollectionMetadata("community", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCollectionMetadata(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetCollectionVersionsFollowsPagination(t *testing.T) {
+	api := newGalaxyFixture(t)
+
+	versions, err := api.GetCollectionVersions("community", "general")
+	if err != nil {
+		t.Fatalf("GetCollectionVersions() error: %v", err)
+	}
+	if want := []string{"9.1.0", "9.0.0", "8.6.0"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("GetCollectionVersions() = %v, want %v", versions, want)
+	}
+
+	if _, err := api.GetCollectionVersions("community", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCollectionVersions(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+// newVersionsFixture serves the given versions of community.general on a
+// single page of the v3 versions endpoint
+func newVersionsFixture(t *testing.T, versions ...string) *GalaxyAPI {
+	t.Helper()
+	const base = "/api/v3/plugin/ansible/content/published/collections/index/community/general/"
+	mux := http.NewServeMux()
+	mux.HandleFunc(base+"versions/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[`)
+		for i, v := range versions {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"version":%q}`, v)
+		}
+		fmt.Fprint(w, `],"links":{"next":null}}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &GalaxyAPI{BaseURL: server.URL + "/api/v3", Client: server.Client()}
+}
+
+func TestResolveVersionMatchesConstraint(t *testing.T) {
+	api := newVersionsFixture(t, "8.2.0", "9.1.0", "7.0.0")
+
+	tests := []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{constraint: ">=8.0.0", want: "9.1.0"},
+		{constraint: "<=8.1.0", want: "7.0.0"},
+		{constraint: "<=8.2.0", want: "8.2.0"},
+		{constraint: "<9.0.0", want: "8.2.0"},
+		{constraint: ">8.2.0", want: "9.1.0"},
+		{constraint: "==8.2.0", want: "8.2.0"},
+		{constraint: ">=10.0.0", wantErr: true},
+		{constraint: "==8.1.0", wantErr: true},
+		{constraint: "<7.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := api.ResolveVersion("community", "general", "collection", tt.constraint)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveVersion(%q) error = %v, wantErr %v", tt.constraint, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveVersion(%q) = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestResolveVersionFollowsPagination(t *testing.T) {
+	api := newGalaxyFixture(t)
+
+	// 8.6.0 is only on the second page
+	got, err := api.ResolveVersion("community", "general", "collection", "<9.0.0")
+	if err != nil {
+		t.Fatalf("ResolveVersion() error = %v", err)
+	}
+	if got != "8.6.0" {
+		t.Errorf("ResolveVersion(<9.0.0) = %q, want 8.6.0", got)
+	}
+}
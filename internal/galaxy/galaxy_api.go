@@ -1,12 +1,10 @@
 package galaxy
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
@@ -179,105 +177,97 @@ func (g *GalaxyAPI) ResolveVersion(namespace, name, objectType, versionConstrain
 			return "", fmt.Errorf("invalid version constraint: %s", versionConstraint)
 		}
 
-		url := ""
-		switch objectType {
-		case "collection":
-			url = fmt.Sprintf("https://galaxy.ansible.com/api/v3/plugin/ansible/content/published/collections/index/%s/%s/versions/", namespace, name)
-		case "role":
-			url = fmt.Sprintf("https://galaxy.ansible.com/api/v1/roles/?owner__username=%s&name=%s", namespace, name)
-		default:
-			return "", fmt.Errorf("unknown object type: %s", objectType)
-		}
-
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Get(url)
+		versions, err := g.constraintVersions(namespace, name, objectType)
 		if err != nil {
-			return "", fmt.Errorf("failed to fetch collection info: %w", err)
+			return "", err
 		}
+		return matchVersionConstraint(versions, operand, constraintVersion, name)
+	}
 
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				fmt.Printf("failed to close response body: %v\n", err)
-			}
-		}()
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("galaxy return status %d for collection %s", resp.StatusCode, name)
-		}
+	// If it's a specific version, return as-is
+	return versionConstraint, nil
+}
 
-		var result struct {
-			Data []struct {
-				Version         string `json:"version"`
-				RequiresAnsible string `json:"requires_ansible"`
-			} `json:"data"`
-		}
+// constraintVersions lists the published versions a constraint is matched
+// against: every page of the v3 versions endpoint for a collection, the v1
+// roles endpoint for a role
+func (g *GalaxyAPI) constraintVersions(namespace, name, objectType string) ([]string, error) {
+	if objectType == "collection" {
+		return g.GetCollectionVersions(namespace, name)
+	}
+	if objectType != "role" {
+		return nil, fmt.Errorf("unknown object type: %s", objectType)
+	}
 
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return "", fmt.Errorf("failed to decode response: %w", err)
-		}
+	url := fmt.Sprintf("https://galaxy.ansible.com/api/v1/roles/?owner__username=%s&name=%s", namespace, name)
+	resp, err := g.Client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch role info: %w", err)
+	}
 
-		// Get all versions strings from data array
-		versions := make([]string, 0, len(result.Data))
-		for _, versionData := range result.Data {
-			versions = append(versions, versionData.Version)
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			fmt.Printf("failed to close response body: %v\n", err)
 		}
+	}()
 
-		if len(versions) == 0 {
-			return "", fmt.Errorf("no releases found for collection %s", name)
-		}
-		// Sort versions in descending order (highest first)
-		sort.Slice(versions, func(i, j int) bool {
-			return CompareVersions(versions[i], versions[j]) > 0
-		})
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("galaxy return status %d for role %s", resp.StatusCode, name)
+	}
 
-		// If no constraint, return latest version
-		if operand == "" {
-			return versions[0], nil
-		}
+	var result struct {
+		Data []struct {
+			Version string `json:"version"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
-		// Validate that latest version satisfies the constraint
-		latestVersion := versions[0]
-		cmp := CompareVersions(latestVersion, constraintVersion)
+	versions := make([]string, 0, len(result.Data))
+	for _, versionData := range result.Data {
+		versions = append(versions, versionData.Version)
+	}
+	return versions, nil
+}
 
-		switch operand {
-		case ">=":
-			if cmp >= 0 {
-				return latestVersion, nil
-			}
-			return constraintVersion, nil
-		case "<=":
-			// Find the highest version that is <= constraint
-			for _, v := range versions {
-				if CompareVersions(v, constraintVersion) <= 0 {
-					return v, nil
-				}
-			}
-			return "", fmt.Errorf("no version found <= %s for package %s", constraintVersion, name)
-		case "==", "=":
-			if cmp == 0 {
-				return constraintVersion, nil
-			}
-			return "", fmt.Errorf("exact version %s not found for package %s", constraintVersion, name)
-		case ">":
-			if cmp > 0 {
-				return latestVersion, nil
-			}
-			return "", fmt.Errorf("no version found > %s for package %s", constraintVersion, name)
-		case "<":
-			// Find the highest version that is < constraint
-			for _, v := range versions {
-				if CompareVersions(v, constraintVersion) < 0 {
-					return v, nil
-				}
-			}
-			return "", fmt.Errorf("no version found < %s for package %s", constraintVersion, name)
-		}
+// matchVersionConstraint picks the version satisfying "<operand> <constraintVersion>":
+// the highest matching version for >=, >, <= and <, the exact version for == and =
+func matchVersionConstraint(versions []string, operand, constraintVersion, name string) (string, error) {
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no releases found for %s", name)
+	}
+	// Sort versions in descending order (highest first)
+	sorted := append([]string(nil), versions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return CompareVersions(sorted[i], sorted[j]) > 0
+	})
 
-		return latestVersion, nil
+	var matches func(cmp int) bool
+	switch operand {
+	case ">=":
+		matches = func(cmp int) bool { return cmp >= 0 }
+	case ">":
+		matches = func(cmp int) bool { return cmp > 0 }
+	case "<=":
+		matches = func(cmp int) bool { return cmp <= 0 }
+	case "<":
+		matches = func(cmp int) bool { return cmp < 0 }
+	case "==", "=":
+		matches = func(cmp int) bool { return cmp == 0 }
+	default:
+		return sorted[0], nil
 	}
 
-	// If it's a specific version, return as-is
-	return versionConstraint, nil
+	for _, v := range sorted {
+		if matches(CompareVersions(v, constraintVersion)) {
+			return v, nil
+		}
+	}
+	if operand == "==" || operand == "=" {
+		return "", fmt.Errorf("exact version %s not found for %s", constraintVersion, name)
+	}
+	return "", fmt.Errorf("no version found %s %s for %s", operand, constraintVersion, name)
 }
 
 // ResolveRoleVersion resolves a role version constraint to an actual version
@@ -292,42 +282,34 @@ func (g *GalaxyAPI) ResolveRoleVersion(namespace, name, versionConstraint string
 }
 
 // ResolveVersionFromGit resolves a role version from a git repository
-// It fetches tags from the git repo and returns the latest version or resolves a constraint
+// It fetches tags from the git repo and returns the latest version or resolves a constraint.
+// A monorepo src (repo.git//path/to/role[@ref]) is resolved against the repository;
+// a ref pinned in the src is used when no constraint is given.
 func ResolveVersionFromGit(gitURL, versionConstraint string) (string, error) {
+	return ResolveVersionFromGitWithCredentials(gitURL, versionConstraint, defaultGitCredentials())
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// ResolveVersionFromGitWithCredentials is ResolveVersionFromGit with the
+// credential lookup of the caller, so a lock run resolves each source once
+func ResolveVersionFromGitWithCredentials(gitURL, versionConstraint string, creds *GitCredentials) (string, error) {
+	source := ParseGitSource(gitURL)
+	gitURL = source.URL
+	if source.Ref != "" && (versionConstraint == "" || versionConstraint == "latest") {
+		return source.Ref, nil
+	}
 
 	// If version is "latest", "main", or empty, fetch latest tag
 	if versionConstraint == "" || versionConstraint == "latest" || versionConstraint == "main" || versionConstraint == "master" {
-
-		attempts := 0
-		maxAttempts := 3
-		var tag string
-		var err error
-
-		for attempts < maxAttempts {
-			tag, err = GetLatestGitTag(gitURL)
+		// GetLatestGitTag retries unreachable repositories itself
+		tag, err := latestGitTag(gitURL, creds)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch tags from git: %w", err)
+		}
+		if tag != "main" && tag != "master" && tag != "" {
 			fmt.Printf("Fetched latest git tag: %s\n", tag)
-
-			if err != nil {
-				// Error occurred, retry
-				attempts++
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			// Success - check if we got a valid tag
-			if tag != "main" && tag != "master" && tag != "" {
-				return NormalizeVersion(tag), nil
-			}
-
-			fmt.Println("No valid tags found, retrying...")
-			attempts++
-			time.Sleep(2 * time.Second)
+			return NormalizeVersion(tag), nil
 		}
-
-		fmt.Println("No tags found after all attempts, defaulting to 'not-defined'")
+		fmt.Printf("No tags found in %s, keeping %q\n", gitURL, versionConstraint)
 	} else {
 		// If version has operators (>=, <=, etc.), resolve from git tags
 		if strings.ContainsAny(versionConstraint, ">=<") {
@@ -347,9 +329,7 @@ func ResolveVersionFromGit(gitURL, versionConstraint string) (string, error) {
 			}
 
 			// Fetch all tags from git
-			cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", "--sort=-v:refname", gitURL)
-			output, err := cmd.Output()
-
+			output, err := lsRemoteTags(gitURL, creds)
 			if err != nil {
 				return "", fmt.Errorf("failed to fetch tags from git: %w", err)
 			}
@@ -405,16 +385,18 @@ func ResolveVersionFromGit(gitURL, versionConstraint string) (string, error) {
 	return versionConstraint, nil
 }
 
-// GetLatestGitTag fetches the latest tag from a git repository
+// GetLatestGitTag fetches the latest tag from a git repository. A repository
+// without tags falls back to "main"; an unreachable repository or a failed
+// login is returned as an error.
 func GetLatestGitTag(gitURL string) (string, error) {
-	// Use git ls-remote to fetch tags with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return latestGitTag(gitURL, defaultGitCredentials())
+}
 
-	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", "--sort=-v:refname", gitURL)
-	output, err := cmd.Output()
+// latestGitTag is GetLatestGitTag with the given credential lookup
+func latestGitTag(gitURL string, creds *GitCredentials) (string, error) {
+	output, err := lsRemoteTags(gitURL, creds)
 	if err != nil {
-		return "main", nil // Fallback to main if git command fails
+		return "", err
 	}
 
 	// Parse output to find the latest tag
@@ -546,8 +528,17 @@ func compareVersions(v1, v2 []int) int {
 	return 0
 }
 
+// pypiBaseURL is the PyPI JSON API root; tests point it at a fixture server
+var pypiBaseURL = "https://pypi.org/pypi"
+
 // GetPythonPackageVersion fetches the latest version of a Python package from PyPI
 func GetPythonPackageVersion(packageName string) (string, error) {
+	return GetPythonPackageVersionForPlatform(packageName, "")
+}
+
+// GetPythonPackageVersionForPlatform is GetPythonPackageVersion considering only
+// releases installable on platform (see NormalizePlatform); "" considers all
+func GetPythonPackageVersionForPlatform(packageName, platform string) (string, error) {
 	// Remove version constraints if present
 	pkgName := packageName
 	var operand string
@@ -568,7 +559,7 @@ func GetPythonPackageVersion(packageName string) (string, error) {
 		pkgName = pkgName[:idx]
 	}
 
-	url := fmt.Sprintf("https://pypi.org/pypi/%s/json", pkgName)
+	url := fmt.Sprintf("%s/%s/json", pypiBaseURL, pkgName)
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Get(url)
@@ -588,6 +579,8 @@ func GetPythonPackageVersion(packageName string) (string, error) {
 
 	var result struct {
 		Releases map[string][]struct {
+			Filename       string `json:"filename"`
+			PackageType    string `json:"packagetype"`
 			RequiresPython string `json:"requires_python"`
 			URL            string `json:"url"`
 			Digests        struct {
@@ -602,11 +595,25 @@ func GetPythonPackageVersion(packageName string) (string, error) {
 
 	// Get all version strings from releases map keys
 	versions := make([]string, 0, len(result.Releases))
-	for version := range result.Releases {
+	for version, files := range result.Releases {
+		if platform != "" {
+			filenames := make([]string, 0, len(files))
+			for _, file := range files {
+				if file.PackageType == "bdist_wheel" || strings.HasSuffix(file.Filename, ".whl") {
+					filenames = append(filenames, file.Filename)
+				}
+			}
+			if !releaseFitsPlatform(filenames, len(files), platform) {
+				continue
+			}
+		}
 		versions = append(versions, version)
 	}
 
 	if len(versions) == 0 {
+		if platform != "" {
+			return "", fmt.Errorf("no release of %s has a wheel for %s", pkgName, platform)
+		}
 		return "", fmt.Errorf("no releases found for package %s", pkgName)
 	}
 
@@ -663,6 +670,12 @@ func GetPythonPackageVersion(packageName string) (string, error) {
 
 // ResolvePythonDependencies resolves Python package versions
 func ResolvePythonDependencies(packages []string) (map[string]string, error) {
+	return ResolvePythonDependenciesForPlatform(packages, "")
+}
+
+// ResolvePythonDependenciesForPlatform resolves Python package versions to
+// releases installable on platform; "" considers all releases
+func ResolvePythonDependenciesForPlatform(packages []string, platform string) (map[string]string, error) {
 	resolved := make(map[string]string)
 
 	for _, pkg := range packages {
@@ -683,7 +696,7 @@ func ResolvePythonDependencies(packages []string) (map[string]string, error) {
 		}
 
 		// Fetch version from PyPI with full constraint
-		version, err := GetPythonPackageVersion(pkg)
+		version, err := GetPythonPackageVersionForPlatform(pkg, platform)
 		if err != nil {
 			// If we can't fetch, use the constraint as-is
 			resolved[baseName] = pkg
@@ -0,0 +1,71 @@
+package galaxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitSource is a git source URL split into the repository, an optional
+// subdirectory inside it (monorepos) and an optional ref. Only collections can
+// be installed from a subdirectory; see ValidateRoleSrc.
+//
+// Accepted forms:
+//
+//	https://git.example.com/org/roles.git
+//	https://git.example.com/org/roles.git//path/to/role
+//	https://git.example.com/org/roles.git//path/to/role@v1.2.0
+//	git@git.example.com:org/roles.git//path/to/role@main
+type GitSource struct {
+	URL    string // Repository URL, as passed to git
+	Subdir string // Path of the role inside the repository, without slashes around it
+	Ref    string // Tag or branch pinned in the source, if any
+}
+
+// ParseGitSource splits a git src into repository URL, subdirectory and ref.
+// The "//" after the repository URL starts the subdirectory (the "//" of a
+// scheme is skipped); a trailing "@ref" is only read from the subdirectory
+// part, since '@' also appears in scp-style URLs such as git@host:org/repo.git.
+func ParseGitSource(src string) GitSource {
+	start := 0
+	if i := strings.Index(src, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	sep := strings.Index(src[start:], "//")
+	if sep < 0 {
+		return GitSource{URL: src}
+	}
+	sep += start
+
+	source := GitSource{URL: src[:sep]}
+	subdir := src[sep+2:]
+	if at := strings.LastIndex(subdir, "@"); at >= 0 {
+		subdir, source.Ref = subdir[:at], subdir[at+1:]
+	}
+	source.Subdir = strings.Trim(subdir, "/")
+	return source
+}
+
+// IsGitRepository reports whether the repository part of a role src looks like
+// a git URL (GitHub, GitLab or a .git URL)
+func (s GitSource) IsGitRepository() bool {
+	return strings.Contains(s.URL, "github.com") || strings.Contains(s.URL, "gitlab.com") || strings.HasSuffix(s.URL, ".git")
+}
+
+// CollectionSrc returns the name of a git collection in requirements.yml: the
+// repository URL, with the subdirectory in ansible-galaxy's "#/<path>" form
+func (s GitSource) CollectionSrc() string {
+	if s.Subdir == "" {
+		return s.URL
+	}
+	return s.URL + "#/" + s.Subdir
+}
+
+// ValidateRoleSrc rejects a role src pointing at a subdirectory of a
+// repository: ansible-galaxy installs roles from the repository root only and
+// has no "#/<path>" form for them.
+func ValidateRoleSrc(src string) error {
+	if source := ParseGitSource(src); source.Subdir != "" {
+		return fmt.Errorf("role src %q points at the subdirectory %s, but ansible-galaxy installs roles from the repository root only; use a repository per role or ship the roles in a collection", src, source.Subdir)
+	}
+	return nil
+}
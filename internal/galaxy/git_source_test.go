@@ -0,0 +1,99 @@
+package galaxy
+
+import (
+	"testing"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected GitSource
+	}{
+		{
+			name:     "plain repository",
+			src:      "https://github.com/myorg/ansible-myrole.git",
+			expected: GitSource{URL: "https://github.com/myorg/ansible-myrole.git"},
+		},
+		{
+			name:     "subdirectory",
+			src:      "https://git.example.com/org/roles.git//roles/nginx",
+			expected: GitSource{URL: "https://git.example.com/org/roles.git", Subdir: "roles/nginx"},
+		},
+		{
+			name:     "subdirectory with ref",
+			src:      "https://git.example.com/org/roles.git//roles/nginx@v1.2.0",
+			expected: GitSource{URL: "https://git.example.com/org/roles.git", Subdir: "roles/nginx", Ref: "v1.2.0"},
+		},
+		{
+			name:     "subdirectory with trailing slash",
+			src:      "https://git.example.com/org/roles.git//roles/nginx/@main",
+			expected: GitSource{URL: "https://git.example.com/org/roles.git", Subdir: "roles/nginx", Ref: "main"},
+		},
+		{
+			name:     "scp-style URL",
+			src:      "git@git.example.com:org/roles.git//roles/nginx@v1",
+			expected: GitSource{URL: "git@git.example.com:org/roles.git", Subdir: "roles/nginx", Ref: "v1"},
+		},
+		{
+			name:     "scp-style URL without subdirectory",
+			src:      "git@git.example.com:org/roles.git",
+			expected: GitSource{URL: "git@git.example.com:org/roles.git"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseGitSource(tt.src); got != tt.expected {
+				t.Errorf("ParseGitSource(%q) = %+v, want %+v", tt.src, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitSourceCollectionSrc(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"https://github.com/myorg/ansible-myrole.git", "https://github.com/myorg/ansible-myrole.git"},
+		{"https://git.example.com/org/roles.git//roles/nginx@v1.2.0", "https://git.example.com/org/roles.git#/roles/nginx"},
+		{"git@git.example.com:org/roles.git//roles/nginx", "git@git.example.com:org/roles.git#/roles/nginx"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseGitSource(tt.src).CollectionSrc(); got != tt.expected {
+			t.Errorf("CollectionSrc(%q) = %q, want %q", tt.src, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateRoleSrc(t *testing.T) {
+	for _, src := range []string{"", "https://github.com/myorg/ansible-myrole.git", "git@git.example.com:org/nginx.git"} {
+		if err := ValidateRoleSrc(src); err != nil {
+			t.Errorf("ValidateRoleSrc(%q) = %v, want nil", src, err)
+		}
+	}
+	if err := ValidateRoleSrc("https://git.example.com/org/roles.git//roles/nginx@v1.2.0"); err == nil {
+		t.Error("expected a role src with a subdirectory to be rejected")
+	}
+}
+
+func TestGitSourceIsGitRepository(t *testing.T) {
+	if !ParseGitSource("https://git.example.com/org/roles.git//roles/nginx@v1").IsGitRepository() {
+		t.Error("expected monorepo src to be detected as a git repository")
+	}
+	if ParseGitSource("geerlingguy.docker").IsGitRepository() {
+		t.Error("expected Galaxy role name not to be detected as a git repository")
+	}
+}
+
+func TestResolveVersionFromGitUsesPinnedRef(t *testing.T) {
+	version, err := ResolveVersionFromGit("https://git.example.com/org/roles.git//roles/nginx@v1.2.0", "latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "v1.2.0" {
+		t.Errorf("expected pinned ref 'v1.2.0', got %q", version)
+	}
+}
@@ -0,0 +1,182 @@
+package galaxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// gitTagAttempts is how often git ls-remote is tried before giving up
+const gitTagAttempts = 3
+
+// gitTagRetryDelay is the pause between ls-remote attempts; shortened in tests
+var gitTagRetryDelay = 2 * time.Second
+
+// Credential variables read by the inline git credential helper
+const (
+	envGitTagsUser     = "DIFFUSION_GIT_USER"
+	envGitTagsPassword = "DIFFUSION_GIT_PASSWORD"
+)
+
+// GitCredentials looks up the artifact source credentials used by git
+// ls-remote. Each source is resolved at most once, so a lock run queries Vault
+// or the secret store per source instead of per repository. A nil
+// *GitCredentials accesses git anonymously.
+type GitCredentials struct {
+	mu       sync.Mutex
+	cfg      *config.Config
+	resolved map[string]*config.ArtifactCredentials // By source name; nil when the lookup failed
+}
+
+// NewGitCredentials returns the credential lookup for the artifact sources of cfg
+func NewGitCredentials(cfg *config.Config) *GitCredentials {
+	return &GitCredentials{cfg: cfg, resolved: map[string]*config.ArtifactCredentials{}}
+}
+
+// LoadGitCredentials returns the credential lookup for diffusion.toml, or nil
+// when there is no config to read
+func LoadGitCredentials() *GitCredentials {
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg == nil {
+		return nil
+	}
+	return NewGitCredentials(cfg)
+}
+
+// defaultGitCredentials is the lookup of single git resolutions; replaced in tests
+var defaultGitCredentials = LoadGitCredentials
+
+// sourceCredentials loads the credentials of one artifact source; replaced in tests
+var sourceCredentials = secrets.GetArtifactCredentials
+
+// For returns the credentials of the configured artifact source matching
+// gitURL, or nil when there is none
+func (g *GitCredentials) For(gitURL string) *config.ArtifactCredentials {
+	if g == nil || g.cfg == nil {
+		return nil
+	}
+	source := matchArtifactSource(g.cfg.ArtifactSources, gitURL)
+	if source == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if creds, ok := g.resolved[source.Name]; ok {
+		return creds
+	}
+	creds, err := sourceCredentials(source, g.cfg.HashicorpVault)
+	if err != nil {
+		fmt.Printf("Warning: failed to load credentials for artifact source '%s': %v\n", source.Name, err)
+		creds = nil
+	}
+	g.resolved[source.Name] = creds
+	return creds
+}
+
+// matchArtifactSource returns the git artifact source whose URL prefixes gitURL
+func matchArtifactSource(sources []config.ArtifactSource, gitURL string) *config.ArtifactSource {
+	target := trimGitScheme(gitURL)
+	for i := range sources {
+		source := &sources[i]
+		if source.URL == "" || (source.Type != "" && source.Type != "git") {
+			continue
+		}
+		if strings.HasPrefix(target, strings.TrimSuffix(trimGitScheme(source.URL), "/")) {
+			return source
+		}
+	}
+	return nil
+}
+
+// trimGitScheme strips the scheme, user info and the scp-style ':' of a git URL
+func trimGitScheme(u string) string {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git@"} {
+		u = strings.TrimPrefix(u, prefix)
+	}
+	if at := strings.Index(u, "@"); at >= 0 && at < strings.Index(u+"/", "/") {
+		u = u[at+1:]
+	}
+	return strings.Replace(u, ":", "/", 1)
+}
+
+// gitCommandEnv returns the environment for git ls-remote. Terminal prompts are
+// disabled so a missing login fails instead of hanging; with credentials an
+// inline credential helper answers from the environment, so the secret never
+// appears in the URL or on the command line.
+func gitCommandEnv(creds *config.ArtifactCredentials) []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if creds == nil {
+		return env
+	}
+	password := creds.Token
+	if password == "" {
+		password = creds.Password
+	}
+	username := creds.Username
+	if username == "" {
+		username = "git"
+	}
+	return append(env,
+		envGitTagsUser+"="+username,
+		envGitTagsPassword+"="+password,
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=credential.helper",
+		fmt.Sprintf("GIT_CONFIG_VALUE_0=!f(){ echo username=$%s; echo password=$%s; }; f", envGitTagsUser, envGitTagsPassword),
+	)
+}
+
+// isGitAuthError reports whether git output shows an authentication failure,
+// which retrying cannot fix
+func isGitAuthError(output string) bool {
+	output = strings.ToLower(output)
+	for _, marker := range []string{"authentication failed", "could not read username", "terminal prompts disabled", "permission denied", "403"} {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// lsRemoteTags lists the tags of a git repository, newest version first. Network
+// failures are retried; authentication failures are returned right away.
+func lsRemoteTags(gitURL string, creds *GitCredentials) ([]byte, error) {
+	env := gitCommandEnv(creds.For(gitURL))
+
+	var lastErr error
+	for attempt := 1; attempt <= gitTagAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", "--sort=-v:refname", gitURL)
+		cmd.Env = env
+		output, err := cmd.Output()
+		cancel()
+		if err == nil {
+			return output, nil
+		}
+
+		stderr := ""
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		if isGitAuthError(stderr) {
+			return nil, fmt.Errorf("authentication to %s failed (configure credentials with 'diffusion artifact add'): %s", gitURL, stderr)
+		}
+		lastErr = fmt.Errorf("git ls-remote %s failed: %w", gitURL, err)
+		if stderr != "" {
+			lastErr = fmt.Errorf("%w: %s", lastErr, stderr)
+		}
+		if attempt < gitTagAttempts {
+			time.Sleep(gitTagRetryDelay)
+		}
+	}
+	return nil, fmt.Errorf("%w (after %d attempts)", lastErr, gitTagAttempts)
+}
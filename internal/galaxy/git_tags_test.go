@@ -0,0 +1,183 @@
+package galaxy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// newBareRepo creates a bare git repository with one commit and the given tags
+func newBareRepo(t *testing.T, tags ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	bare := filepath.Join(root, "role.git")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	if err := os.MkdirAll(work, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git(work, "init", "-q")
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("role\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(work, "add", "README.md")
+	git(work, "commit", "-q", "-m", "initial")
+	for _, tag := range tags {
+		git(work, "tag", tag)
+	}
+	git(root, "clone", "-q", "--bare", work, bare)
+	return bare
+}
+
+func stubGitTags(t *testing.T) {
+	t.Helper()
+	origDelay, origCreds := gitTagRetryDelay, defaultGitCredentials
+	t.Cleanup(func() { gitTagRetryDelay, defaultGitCredentials = origDelay, origCreds })
+	gitTagRetryDelay = 0
+	defaultGitCredentials = func() *GitCredentials { return nil }
+}
+
+func TestGetLatestGitTagLocalRepo(t *testing.T) {
+	stubGitTags(t)
+
+	tag, err := GetLatestGitTag(newBareRepo(t, "v1.0.0", "v1.10.0", "v1.2.0"))
+	if err != nil {
+		t.Fatalf("GetLatestGitTag: %v", err)
+	}
+	if tag != "v1.10.0" {
+		t.Errorf("tag = %q, want v1.10.0", tag)
+	}
+
+	// A repository without tags legitimately falls back to main
+	tag, err = GetLatestGitTag(newBareRepo(t))
+	if err != nil || tag != "main" {
+		t.Errorf("untagged repo: got %q, %v; want main, nil", tag, err)
+	}
+}
+
+func TestGetLatestGitTagUnreachable(t *testing.T) {
+	stubGitTags(t)
+
+	missing := filepath.Join(t.TempDir(), "missing.git")
+	tag, err := GetLatestGitTag(missing)
+	if err == nil {
+		t.Fatalf("expected an error for an unreachable repository, got tag %q", tag)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("expected the error after retries, got %v", err)
+	}
+
+	if _, err := ResolveVersionFromGit(missing, "latest"); err == nil {
+		t.Error("ResolveVersionFromGit should report the unreachable repository")
+	}
+}
+
+func TestMatchArtifactSource(t *testing.T) {
+	sources := []config.ArtifactSource{
+		{Name: "galaxy", URL: "https://galaxy.example.com", Type: "galaxy"},
+		{Name: "gitlab", URL: "https://gitlab.example.com/infra", Type: "git"},
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://gitlab.example.com/infra/roles/nginx.git", "gitlab"},
+		{"git@gitlab.example.com:infra/roles/nginx.git", "gitlab"},
+		{"https://ci-token@gitlab.example.com/infra/nginx.git", "gitlab"},
+		{"https://gitlab.example.com/other/nginx.git", ""},
+		{"https://galaxy.example.com/roles/nginx.git", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if source := matchArtifactSource(sources, tt.url); source != nil {
+			got = source.Name
+		}
+		if got != tt.want {
+			t.Errorf("matchArtifactSource(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestGitCredentialsResolvedOncePerSource(t *testing.T) {
+	orig := sourceCredentials
+	t.Cleanup(func() { sourceCredentials = orig })
+	lookups := 0
+	sourceCredentials = func(source *config.ArtifactSource, _ *config.HashicorpVault) (*config.ArtifactCredentials, error) {
+		lookups++
+		return &config.ArtifactCredentials{Username: "deploy", Token: source.Name}, nil
+	}
+
+	creds := NewGitCredentials(&config.Config{ArtifactSources: []config.ArtifactSource{
+		{Name: "gitlab", URL: "https://gitlab.example.com/infra", Type: "git"},
+	}})
+	for _, url := range []string{
+		"https://gitlab.example.com/infra/roles/nginx.git",
+		"https://gitlab.example.com/infra/roles/redis.git",
+		"git@gitlab.example.com:infra/collections/base.git",
+	} {
+		if got := creds.For(url); got == nil || got.Token != "gitlab" {
+			t.Errorf("For(%q) = %+v, want the gitlab credentials", url, got)
+		}
+	}
+	if creds.For("https://github.com/org/role.git") != nil {
+		t.Error("unmatched URL should be anonymous")
+	}
+	if lookups != 1 {
+		t.Errorf("credential lookups = %d, want 1", lookups)
+	}
+
+	var anonymous *GitCredentials
+	if anonymous.For("https://gitlab.example.com/infra/roles/nginx.git") != nil {
+		t.Error("nil lookup should be anonymous")
+	}
+}
+
+func TestGitCommandEnvCredentials(t *testing.T) {
+	env := gitCommandEnv(&config.ArtifactCredentials{Username: "deploy", Token: "glpat-secret"})
+	for _, want := range []string{
+		"GIT_TERMINAL_PROMPT=0",
+		envGitTagsUser + "=deploy",
+		envGitTagsPassword + "=glpat-secret",
+		"GIT_CONFIG_KEY_0=credential.helper",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("env missing %q", want)
+		}
+	}
+
+	env = gitCommandEnv(nil)
+	if !slices.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+		t.Error("prompts must be disabled without credentials too")
+	}
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envGitTagsPassword+"=") {
+			t.Errorf("unexpected credential variable without credentials: %q", kv)
+		}
+	}
+}
+
+func TestIsGitAuthError(t *testing.T) {
+	if !isGitAuthError("remote: HTTP Basic: Access denied\nfatal: Authentication failed for 'https://gitlab.example.com/x.git/'") {
+		t.Error("expected an authentication failure")
+	}
+	if isGitAuthError("fatal: unable to access 'https://gitlab.example.com/x.git/': Could not resolve host: gitlab.example.com") {
+		t.Error("a DNS failure is not an authentication failure")
+	}
+}
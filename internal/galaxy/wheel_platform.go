@@ -0,0 +1,69 @@
+package galaxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Platforms accepted by deps lock --platform, mapped to the architecture
+// suffix of their wheel platform tags
+var wheelArchSuffixes = map[string]string{
+	"linux/amd64": "_x86_64",
+	"linux/arm64": "_aarch64",
+}
+
+// NormalizePlatform turns a --platform value (linux/arm64, arm64, aarch64,
+// linux/amd64, amd64, x86_64) into its os/arch form. Only Linux is accepted,
+// since the Python packages are installed in the Linux molecule container.
+func NormalizePlatform(value string) (string, error) {
+	osName, arch, ok := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "/")
+	if !ok {
+		osName, arch = "linux", osName
+	}
+	if osName != "linux" {
+		return "", fmt.Errorf("unsupported platform %q: the molecule container runs Linux (use linux/amd64 or linux/arm64)", value)
+	}
+	switch arch {
+	case "amd64", "x86_64":
+		return "linux/amd64", nil
+	case "arm64", "aarch64":
+		return "linux/arm64", nil
+	}
+	return "", fmt.Errorf("unsupported platform %q (use linux/amd64 or linux/arm64)", value)
+}
+
+// releaseFitsPlatform reports whether a release installs on platform given its
+// wheel file names out of total files: one pure or matching wheel is enough,
+// and a release without wheels (sdist only) is built by pip on any platform
+func releaseFitsPlatform(wheels []string, total int, platform string) bool {
+	if len(wheels) == 0 {
+		return total > 0
+	}
+	for _, wheel := range wheels {
+		if wheelFitsPlatform(wheel, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// wheelFitsPlatform checks the platform tag of a wheel file name
+// (name-version[-build]-python-abi-platform.whl). Compressed tag sets such as
+// manylinux_2_17_aarch64.manylinux2014_aarch64 match when any tag does;
+// musllinux wheels do not fit the glibc-based molecule image.
+func wheelFitsPlatform(filename, platform string) bool {
+	parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-")
+	if len(parts) < 5 {
+		return false
+	}
+	suffix := wheelArchSuffixes[platform]
+	for _, tag := range strings.Split(parts[len(parts)-1], ".") {
+		if tag == "any" {
+			return true
+		}
+		if suffix != "" && (strings.HasPrefix(tag, "manylinux") || strings.HasPrefix(tag, "linux_")) && strings.HasSuffix(tag, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package galaxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pypiFixture serves /<package>/json with the given release files per version
+func pypiFixture(t *testing.T, packages map[string]map[string][]string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/json")
+		releases, ok := packages[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body := map[string]map[string][]map[string]string{"releases": {}}
+		for version, files := range releases {
+			entries := []map[string]string{}
+			for _, file := range files {
+				packageType := "sdist"
+				if strings.HasSuffix(file, ".whl") {
+					packageType = "bdist_wheel"
+				}
+				entries = append(entries, map[string]string{"filename": file, "packagetype": packageType})
+			}
+			body["releases"][version] = entries
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	orig := pypiBaseURL
+	pypiBaseURL = server.URL
+	t.Cleanup(func() { pypiBaseURL = orig })
+}
+
+func TestGetPythonPackageVersionForPlatform(t *testing.T) {
+	pypiFixture(t, map[string]map[string][]string{
+		"fastlib": {
+			"1.0.0": {
+				"fastlib-1.0.0-cp312-cp312-manylinux_2_17_x86_64.manylinux2014_x86_64.whl",
+				"fastlib-1.0.0-cp312-cp312-manylinux_2_17_aarch64.manylinux2014_aarch64.whl",
+			},
+			"1.1.0": {
+				"fastlib-1.1.0-cp312-cp312-manylinux_2_17_x86_64.whl",
+				"fastlib-1.1.0-cp312-cp312-musllinux_1_1_aarch64.whl",
+			},
+			"1.2.0": {"fastlib-1.2.0-cp312-cp312-manylinux_2_28_x86_64.whl", "fastlib-1.2.0-cp312-cp312-macosx_11_0_arm64.whl"},
+		},
+		"purelib": {
+			"2.0.0": {"purelib-2.0.0-py3-none-any.whl", "purelib-2.0.0.tar.gz"},
+			"2.1.0": {"purelib-2.1.0.tar.gz"},
+		},
+	})
+
+	tests := []struct {
+		pkg      string
+		platform string
+		want     string
+		wantErr  bool
+	}{
+		{"fastlib", "", "1.2.0", false},
+		{"fastlib", "linux/amd64", "1.2.0", false},
+		{"fastlib", "linux/arm64", "1.0.0", false},
+		{"fastlib<=1.2.0", "linux/arm64", "1.0.0", false},
+		{"fastlib>1.0.0", "linux/arm64", "", true},
+		{"purelib", "linux/arm64", "2.1.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg+" "+tt.platform, func(t *testing.T) {
+			got, err := GetPythonPackageVersionForPlatform(tt.pkg, tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetPythonPackageVersionForPlatform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetPythonPackageVersionForPlatform() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePlatform(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"linux/arm64", "linux/arm64", false},
+		{"arm64", "linux/arm64", false},
+		{"aarch64", "linux/arm64", false},
+		{"Linux/AMD64", "linux/amd64", false},
+		{"x86_64", "linux/amd64", false},
+		{"darwin/arm64", "", true},
+		{"linux/riscv64", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePlatform(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizePlatform(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWheelFitsPlatform(t *testing.T) {
+	tests := []struct {
+		filename string
+		platform string
+		want     bool
+	}{
+		{"pkg-1.0-py3-none-any.whl", "linux/arm64", true},
+		{"pkg-1.0-cp312-cp312-manylinux_2_17_aarch64.manylinux2014_aarch64.whl", "linux/arm64", true},
+		{"pkg-1.0-1-cp312-cp312-linux_aarch64.whl", "linux/arm64", true},
+		{"pkg-1.0-cp312-cp312-manylinux_2_17_x86_64.whl", "linux/arm64", false},
+		{"pkg-1.0-cp312-cp312-musllinux_1_1_aarch64.whl", "linux/arm64", false},
+		{"pkg-1.0-cp312-cp312-macosx_11_0_arm64.whl", "linux/arm64", false},
+		{"pkg-1.0-cp312-cp312-win_amd64.whl", "linux/amd64", false},
+		{"broken.whl", "linux/amd64", false},
+	}
+	for _, tt := range tests {
+		if got := wheelFitsPlatform(tt.filename, tt.platform); got != tt.want {
+			t.Errorf("wheelFitsPlatform(%q, %q) = %v, want %v", tt.filename, tt.platform, got, tt.want)
+		}
+	}
+}
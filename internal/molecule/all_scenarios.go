@@ -0,0 +1,109 @@
+package molecule
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"diffusion/internal/config"
+)
+
+// scenarioResult is the outcome of one scenario of an --all-scenarios run
+type scenarioResult struct {
+	Scenario string
+	Err      error
+}
+
+// runScenario runs the selected phase (or the default flow) for one scenario;
+// replaced in tests
+var runScenario = func(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+	if hasPhaseFlag(opts) {
+		return handleSubcommands(opts, cfg, path, roleDirName, roleMoleculePath)
+	}
+	return handleDefaultFlow(opts, cfg, path, roleDirName, roleMoleculePath)
+}
+
+// hasPhaseFlag reports whether specific molecule phases were requested rather
+// than the default flow (no phase, or --only full)
+func hasPhaseFlag(opts *MoleculeOptions) bool {
+	phases := selectedPhases(opts)
+	return len(phases) > 0 && phases[0] != PhaseFull
+}
+
+// discoverScenarios lists the scenario folders under <path>/scenarios
+func discoverScenarios(path string) ([]string, error) {
+	scenariosDir := filepath.Join(path, config.ScenariosDir)
+	entries, err := os.ReadDir(scenariosDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", scenariosDir, err)
+	}
+	var scenarios []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			scenarios = append(scenarios, entry.Name())
+		}
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no scenarios found in %s", scenariosDir)
+	}
+	return scenarios, nil
+}
+
+// ensureScenarioTestsDir creates the host tests directory of the active scenario
+func ensureScenarioTestsDir(opts *MoleculeOptions) string {
+	testsPath := fmt.Sprintf("molecule/%s.%s/molecule/%s/tests", opts.OrgFlag, opts.RoleFlag, activeScenario(opts))
+	if err := os.MkdirAll(testsPath, 0o755); err != nil {
+		log.Printf(config.ColorYellow+"warning: cannot create scenario tests dir: %v"+config.ColorReset, err)
+	}
+	return testsPath
+}
+
+// runAllScenarios runs the selected phase for every scenario in turn, keeping
+// going past failures, and prints a summary. The first scenario starts the
+// container and the others reuse it.
+func runAllScenarios(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+	scenarios, err := discoverScenarios(path)
+	if err != nil {
+		return err
+	}
+
+	results := make([]scenarioResult, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		log.Printf(config.ColorMagenta+"==> Scenario %s"+config.ColorReset, scenario)
+		scenarioOpts := *opts
+		scenarioOpts.RoleScenario = scenario
+		ensureScenarioTestsDir(&scenarioOpts)
+		err := runScenario(&scenarioOpts, cfg, path, roleDirName, roleMoleculePath)
+		if err != nil {
+			log.Printf(config.ColorRed+"Scenario %s failed: %v"+config.ColorReset, scenario, err)
+		}
+		results = append(results, scenarioResult{Scenario: scenario, Err: err})
+	}
+
+	if failed := writeScenarioSummary(os.Stdout, results); failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(results))
+	}
+	return nil
+}
+
+// writeScenarioSummary prints one line per scenario and returns the number of failures
+func writeScenarioSummary(w io.Writer, results []scenarioResult) int {
+	width := len("SCENARIO")
+	for _, r := range results {
+		width = max(width, len(r.Scenario))
+	}
+
+	failed := 0
+	fmt.Fprintf(w, "\n"+config.ColorMagenta+"%-*s  RESULT"+config.ColorReset+"\n", width, "SCENARIO")
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(w, "%-*s  "+config.ColorRed+"FAIL"+config.ColorReset+"  %v\n", width, r.Scenario, r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%-*s  "+config.ColorGreen+"PASS"+config.ColorReset+"\n", width, r.Scenario)
+	}
+	return failed
+}
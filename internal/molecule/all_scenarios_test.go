@@ -0,0 +1,93 @@
+package molecule
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestDiscoverScenarios(t *testing.T) {
+	dir := t.TempDir()
+	for _, s := range []string{"ubuntu", "default"} {
+		if err := os.MkdirAll(filepath.Join(dir, "scenarios", s), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scenarios", "README.md"), []byte("notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := discoverScenarios(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"default", "ubuntu"}; !slices.Equal(got, want) {
+		t.Errorf("scenarios = %v, want %v", got, want)
+	}
+
+	if _, err := discoverScenarios(t.TempDir()); err == nil {
+		t.Error("expected an error without a scenarios directory")
+	}
+}
+
+func TestRunAllScenariosContinuesPastFailures(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, s := range []string{"alpine", "default", "ubuntu"} {
+		if err := os.MkdirAll(filepath.Join(dir, "scenarios", s), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ran []string
+	orig := runScenario
+	t.Cleanup(func() { runScenario = orig })
+	runScenario = func(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+		ran = append(ran, opts.RoleScenario)
+		if opts.RoleScenario == "default" {
+			return errors.New("converge failed")
+		}
+		return nil
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "nginx", OrgFlag: "acme", AllScenarios: true, ConvergeFlag: true}
+	err := runAllScenarios(opts, &config.Config{}, dir, "acme.nginx", filepath.Join(dir, "molecule", "acme.nginx"))
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 scenarios failed") {
+		t.Fatalf("expected one failed scenario, got %v", err)
+	}
+	if want := []string{"alpine", "default", "ubuntu"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	if opts.RoleScenario != "" {
+		t.Errorf("caller options modified: RoleScenario = %q", opts.RoleScenario)
+	}
+	for _, s := range ran {
+		testsDir := filepath.Join(dir, "molecule", "acme.nginx", "molecule", s, "tests")
+		if info, err := os.Stat(testsDir); err != nil || !info.IsDir() {
+			t.Errorf("tests dir for scenario %s not created: %v", s, err)
+		}
+	}
+}
+
+func TestWriteScenarioSummary(t *testing.T) {
+	var buf bytes.Buffer
+	failed := writeScenarioSummary(&buf, []scenarioResult{
+		{Scenario: "default"},
+		{Scenario: "ubuntu-noble", Err: errors.New("verify failed")},
+	})
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	out := buf.String()
+	for _, want := range []string{"SCENARIO", "default       " + config.ColorGreen + "PASS", "ubuntu-noble  " + config.ColorRed + "FAIL", "verify failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package molecule
+
+import "fmt"
+
+// validateDiff rejects --diff on runs without a converge or idempotence, the
+// phases whose task changes ansible can show as diffs
+func validateDiff(opts *MoleculeOptions) error {
+	if opts.DiffFlag && !isConverging(opts) && !runsPhase(opts, PhaseIdempotence) {
+		return fmt.Errorf("--diff only applies to converge and idempotence (use --converge, --idempotence or the default flow)")
+	}
+	return nil
+}
+
+// diffEnv returns the env prefix that makes ansible print file diffs (like
+// ansible-playbook --diff) without editing molecule.yml
+func diffEnv(opts *MoleculeOptions) string {
+	if !opts.DiffFlag {
+		return ""
+	}
+	return "ANSIBLE_DIFF_ALWAYS=1 "
+}
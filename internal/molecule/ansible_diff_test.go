@@ -0,0 +1,51 @@
+package molecule
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunIdempotenceDiffEnv(t *testing.T) {
+	orig := phaseExec
+	t.Cleanup(func() { phaseExec = orig })
+
+	var cmd string
+	phaseExec = func(_ context.Context, _ *MoleculeOptions, cmdStr string) error {
+		cmd = cmdStr
+		return nil
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "web", IdempotenceFlag: true, DiffFlag: true, TagFlag: "configure"}
+	if err := runIdempotence(opts, "acme.web"); err != nil {
+		t.Fatalf("runIdempotence: %v", err)
+	}
+	if !strings.Contains(cmd, "ANSIBLE_RUN_TAGS=configure ANSIBLE_DIFF_ALWAYS=1 molecule idempotence") {
+		t.Errorf("idempotence command = %q, want the diff env with the tags", cmd)
+	}
+
+	opts.DiffFlag = false
+	if err := runIdempotence(opts, "acme.web"); err != nil {
+		t.Fatalf("runIdempotence: %v", err)
+	}
+	if strings.Contains(cmd, "ANSIBLE_DIFF_ALWAYS") {
+		t.Errorf("diff env set without --diff: %q", cmd)
+	}
+}
+
+func TestValidateDiff(t *testing.T) {
+	valid := []MoleculeOptions{
+		{DiffFlag: true}, // The default flow converges
+		{DiffFlag: true, ConvergeFlag: true},
+		{DiffFlag: true, IdempotenceFlag: true},
+		{VerifyFlag: true},
+	}
+	for _, opts := range valid {
+		if err := validateDiff(&opts); err != nil {
+			t.Errorf("validateDiff(%+v) = %v", opts, err)
+		}
+	}
+	if err := validateDiff(&MoleculeOptions{DiffFlag: true, VerifyFlag: true}); err == nil {
+		t.Error("expected --diff with --verify to be rejected")
+	}
+}
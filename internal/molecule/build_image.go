@@ -0,0 +1,74 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"diffusion/internal/config"
+)
+
+// buildExec runs docker with the given arguments and returns its combined
+// output; replaced in tests
+var buildExec = func(args []string) ([]byte, error) {
+	cmd := exec.Command("docker", args...)
+	// --secret mounts need BuildKit
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	return cmd.CombinedOutput()
+}
+
+// buildContextDir returns the --build-context override, else [container]
+// build_context; empty means the image is pulled
+func buildContextDir(opts *MoleculeOptions, cfg *config.Config) string {
+	dir := opts.BuildContext
+	if dir == "" && cfg.ContainerConfig != nil {
+		dir = cfg.ContainerConfig.BuildContext
+	}
+	return os.ExpandEnv(dir)
+}
+
+// validateBuildContext checks that dir is a directory with a Dockerfile
+func validateBuildContext(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("build context %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("build context %s is not a directory", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Dockerfile")); err != nil {
+		return fmt.Errorf("build context %s has no Dockerfile", dir)
+	}
+	return nil
+}
+
+// buildMoleculeImage builds the molecule image from the build context, tagged
+// with the registry image URL so the run uses it without pulling. Does nothing
+// without a build context.
+func buildMoleculeImage(opts *MoleculeOptions, cfg *config.Config, image string) error {
+	dir := buildContextDir(opts, cfg)
+	if dir == "" {
+		return nil
+	}
+	if err := validateBuildContext(dir); err != nil {
+		return err
+	}
+
+	secretArgs, cleanup, err := buildSecretArgs(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	log.Printf(config.ColorGreen+"Building %s from %s"+config.ColorReset, image, dir)
+	output, err := buildExec(dockerBuildArgs(image, dir, secretArgs))
+	if err != nil {
+		if len(output) > 0 {
+			log.Printf(config.ColorRed+"docker build output:\n%s"+config.ColorReset, string(output))
+		}
+		return fmt.Errorf("failed to build %s from %s: %w", image, dir, err)
+	}
+	return nil
+}
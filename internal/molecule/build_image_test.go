@@ -0,0 +1,93 @@
+package molecule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+func TestBuildMoleculeImage(t *testing.T) {
+	contextDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := buildExec
+	defer func() { buildExec = orig }()
+	var got []string
+	buildExec = func(args []string) ([]byte, error) {
+		got = args
+		return nil, nil
+	}
+
+	cfg := &config.Config{ContainerRegistry: &config.ContainerRegistry{
+		RegistryServer:        "ghcr.io",
+		RegistryProvider:      "Public",
+		MoleculeContainerName: "polar-team/diffusion-molecule-container",
+		MoleculeContainerTag:  "latest",
+	}}
+	image := utils.GetImageURL(cfg.ContainerRegistry)
+	opts := &MoleculeOptions{BuildContext: contextDir}
+
+	if err := buildMoleculeImage(opts, cfg, image); err != nil {
+		t.Fatalf("buildMoleculeImage() error = %v", err)
+	}
+	want := []string{"build", "-t", "ghcr.io/polar-team/diffusion-molecule-container:latest", contextDir}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("docker args = %v, want %v", got, want)
+	}
+
+	pull, err := pullPolicy(&MoleculeOptions{BuildContext: contextDir, PullPolicy: config.PullPolicyAlways}, cfg)
+	if err != nil || pull != config.PullPolicyNever {
+		t.Errorf("pullPolicy() with a build context = %q, %v; want never", pull, err)
+	}
+}
+
+func TestBuildMoleculeImageFromConfig(t *testing.T) {
+	contextDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := buildExec
+	defer func() { buildExec = orig }()
+	var got []string
+	buildExec = func(args []string) ([]byte, error) {
+		got = args
+		return []byte("step 1/1 failed"), errors.New("exit status 1")
+	}
+
+	cfg := &config.Config{ContainerConfig: &config.ContainerSettings{BuildContext: contextDir}}
+	if err := buildMoleculeImage(&MoleculeOptions{}, cfg, "registry.example.com/molecule:dev"); err == nil {
+		t.Fatal("expected the build failure to be returned")
+	}
+	if len(got) < 2 || got[len(got)-2] != "registry.example.com/molecule:dev" || got[len(got)-1] != contextDir {
+		t.Errorf("docker args = %v, want the image tag and [container] build_context", got)
+	}
+}
+
+func TestBuildMoleculeImageValidatesContext(t *testing.T) {
+	orig := buildExec
+	defer func() { buildExec = orig }()
+	buildExec = func(args []string) ([]byte, error) {
+		t.Fatalf("docker build ran for an invalid context: %v", args)
+		return nil, nil
+	}
+
+	cfg := &config.Config{}
+	if err := buildMoleculeImage(&MoleculeOptions{BuildContext: t.TempDir()}, cfg, "img"); err == nil {
+		t.Error("expected an error for a context without a Dockerfile")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := buildMoleculeImage(&MoleculeOptions{BuildContext: missing}, cfg, "img"); err == nil {
+		t.Error("expected an error for a missing context")
+	}
+	if err := buildMoleculeImage(&MoleculeOptions{}, cfg, "img"); err != nil {
+		t.Errorf("no build context should build nothing, got %v", err)
+	}
+}
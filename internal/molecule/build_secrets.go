@@ -0,0 +1,91 @@
+package molecule
+
+import (
+	"fmt"
+	"os"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// artifactCredentials looks up artifact source credentials; replaced in tests
+var artifactCredentials = secrets.GetArtifactCredentials
+
+// buildSecretArgs turns [container] build_secrets into docker build --secret
+// arguments. Credentials from artifact sources are written to private temp files
+// that the returned cleanup removes; call it once the build has finished.
+func buildSecretArgs(cfg *config.Config) ([]string, func(), error) {
+	var args, tmpFiles []string
+	cleanup := func() {
+		for _, f := range tmpFiles {
+			_ = os.Remove(f)
+		}
+	}
+	if cfg.ContainerConfig == nil {
+		return nil, cleanup, nil
+	}
+
+	for _, bs := range cfg.ContainerConfig.BuildSecrets {
+		if err := bs.Validate(); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+
+		src := os.ExpandEnv(bs.File)
+		if bs.Source != "" {
+			value, err := buildSecretValue(cfg, bs)
+			if err != nil {
+				cleanup()
+				return nil, func() {}, err
+			}
+			f, err := os.CreateTemp("", "diffusion-secret-*")
+			if err != nil {
+				cleanup()
+				return nil, func() {}, fmt.Errorf("build secret %q: %w", bs.ID, err)
+			}
+			tmpFiles = append(tmpFiles, f.Name())
+			// CreateTemp already uses 0600; Chmod keeps it private on umask-less platforms
+			_ = f.Chmod(0o600)
+			_, werr := f.WriteString(value)
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				cleanup()
+				return nil, func() {}, fmt.Errorf("build secret %q: failed to write secret file", bs.ID)
+			}
+			src = f.Name()
+		} else if _, err := os.Stat(src); err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("build secret %q: %w", bs.ID, err)
+		}
+
+		args = append(args, "--secret", fmt.Sprintf("id=%s,src=%s", bs.ID, src))
+	}
+	return args, cleanup, nil
+}
+
+// buildSecretValue resolves the credential field of the artifact source named by bs.Source
+func buildSecretValue(cfg *config.Config, bs config.BuildSecret) (string, error) {
+	for i := range cfg.ArtifactSources {
+		source := &cfg.ArtifactSources[i]
+		if source.Name != bs.Source {
+			continue
+		}
+		creds, err := artifactCredentials(source, cfg.HashicorpVault)
+		if err != nil {
+			return "", fmt.Errorf("build secret %q: failed to load credentials for %s: %w", bs.ID, bs.Source, err)
+		}
+		if bs.Field == "username" {
+			return creds.Username, nil
+		}
+		return creds.Token, nil
+	}
+	return "", fmt.Errorf("build secret %q: artifact source %q not found", bs.ID, bs.Source)
+}
+
+// dockerBuildArgs assembles a BuildKit docker build for the molecule image.
+// Secrets are passed only as --secret mounts, never as --build-arg.
+func dockerBuildArgs(image, contextDir string, secretArgs []string) []string {
+	args := []string{"build"}
+	args = append(args, secretArgs...)
+	return append(args, "-t", image, contextDir)
+}
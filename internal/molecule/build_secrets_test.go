@@ -0,0 +1,97 @@
+package molecule
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestBuildSecretArgs(t *testing.T) {
+	orig := artifactCredentials
+	defer func() { artifactCredentials = orig }()
+	artifactCredentials = func(source *config.ArtifactSource, _ *config.HashicorpVault) (*config.ArtifactCredentials, error) {
+		return &config.ArtifactCredentials{Username: "ci", Token: "pypi-s3cr3t"}, nil
+	}
+
+	hostFile := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(hostFile, []byte("machine x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		ArtifactSources: []config.ArtifactSource{{Name: "pypi", URL: "https://pypi.example.com"}},
+		ContainerConfig: &config.ContainerSettings{BuildSecrets: []config.BuildSecret{
+			{ID: "pypi_token", Source: "pypi"},
+			{ID: "netrc", File: hostFile},
+		}},
+	}
+
+	secretArgs, cleanup, err := buildSecretArgs(cfg)
+	if err != nil {
+		t.Fatalf("buildSecretArgs() error = %v", err)
+	}
+	if len(secretArgs) != 4 || secretArgs[0] != "--secret" || secretArgs[2] != "--secret" {
+		t.Fatalf("unexpected secret args %v", secretArgs)
+	}
+	tokenFile := strings.TrimPrefix(secretArgs[1], "id=pypi_token,src=")
+	if tokenFile == secretArgs[1] {
+		t.Fatalf("unexpected token secret arg %q", secretArgs[1])
+	}
+	if secretArgs[3] != "id=netrc,src="+hostFile {
+		t.Errorf("unexpected file secret arg %q", secretArgs[3])
+	}
+
+	data, err := os.ReadFile(tokenFile)
+	if err != nil || string(data) != "pypi-s3cr3t" {
+		t.Errorf("secret file content = %q, %v", data, err)
+	}
+	if info, err := os.Stat(tokenFile); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("secret file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	got := dockerBuildArgs("ghcr.io/polar-team/diffusion-molecule-container:latest", "./image", secretArgs)
+	want := append(append([]string{"build"}, secretArgs...), "-t", "ghcr.io/polar-team/diffusion-molecule-container:latest", "./image")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dockerBuildArgs() = %v, want %v", got, want)
+	}
+	for _, arg := range got {
+		if strings.Contains(arg, "pypi-s3cr3t") || arg == "--build-arg" {
+			t.Errorf("secret leaked into build command: %v", got)
+		}
+	}
+
+	cleanup()
+	if _, err := os.Stat(tokenFile); !os.IsNotExist(err) {
+		t.Errorf("expected secret file to be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(hostFile); err != nil {
+		t.Errorf("host secret file must not be removed: %v", err)
+	}
+}
+
+func TestBuildSecretArgsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret config.BuildSecret
+	}{
+		{"missing id", config.BuildSecret{File: "x"}},
+		{"no origin", config.BuildSecret{ID: "a"}},
+		{"both origins", config.BuildSecret{ID: "a", Source: "s", File: "f"}},
+		{"bad field", config.BuildSecret{ID: "a", Source: "pypi", Field: "password"}},
+		{"unknown source", config.BuildSecret{ID: "a", Source: "nope"}},
+		{"missing file", config.BuildSecret{ID: "a", File: filepath.Join(t.TempDir(), "absent")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerConfig: &config.ContainerSettings{BuildSecrets: []config.BuildSecret{tt.secret}}}
+			if _, _, err := buildSecretArgs(cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+)
+
+// cacheWarnSizeMB returns the configured cache size warning threshold in MB.
+func cacheWarnSizeMB(cfg *config.Config) int {
+	if cfg.CacheConfig == nil || cfg.CacheConfig.WarnSizeMB <= 0 {
+		return config.DefaultCacheWarnSizeMB
+	}
+	return cfg.CacheConfig.WarnSizeMB
+}
+
+// cacheSizeReport formats the post-run cache size line and, when size exceeds
+// limitMB, a warning pointing at 'diffusion cache clean'.
+func cacheSizeReport(size int64, limitMB int) (report, warning string) {
+	sizeMB := float64(size) / (1024 * 1024)
+	report = fmt.Sprintf("Cache size: %.2f MB", sizeMB)
+	if sizeMB > float64(limitMB) {
+		warning = fmt.Sprintf("warning: cache size %.2f MB exceeds warn_size_mb (%d MB); run 'diffusion cache clean' to free space", sizeMB, limitMB)
+	}
+	return report, warning
+}
+
+// reportCacheSize logs the role cache size after a run that wrote to it.
+func reportCacheSize(opts *MoleculeOptions, cfg *config.Config) {
+	if !cacheEnabled(opts, cfg) {
+		return
+	}
+	size, err := cache.GetCacheSize(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to measure cache size: %v"+config.ColorReset, err)
+		return
+	}
+	report, warning := cacheSizeReport(size, cacheWarnSizeMB(cfg))
+	log.Printf(config.ColorAquamarine+"%s"+config.ColorReset, report)
+	if warning != "" {
+		log.Printf(config.ColorYellow+"%s"+config.ColorReset, warning)
+	}
+}
@@ -0,0 +1,39 @@
+package molecule
+
+import (
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestCacheSizeReportThreshold(t *testing.T) {
+	const mb = 1024 * 1024
+
+	report, warning := cacheSizeReport(512*mb, 1024)
+	if report != "Cache size: 512.00 MB" {
+		t.Errorf("report = %q", report)
+	}
+	if warning != "" {
+		t.Errorf("expected no warning below threshold, got %q", warning)
+	}
+
+	if _, warning := cacheSizeReport(1024*mb, 1024); warning != "" {
+		t.Errorf("expected no warning at threshold, got %q", warning)
+	}
+
+	_, warning = cacheSizeReport(2048*mb, 1024)
+	if !strings.Contains(warning, "exceeds warn_size_mb (1024 MB)") || !strings.Contains(warning, "diffusion cache clean") {
+		t.Errorf("expected warning above threshold, got %q", warning)
+	}
+}
+
+func TestCacheWarnSizeMB(t *testing.T) {
+	if got := cacheWarnSizeMB(&config.Config{}); got != config.DefaultCacheWarnSizeMB {
+		t.Errorf("nil cache config: got %d, want default %d", got, config.DefaultCacheWarnSizeMB)
+	}
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, WarnSizeMB: 500}}
+	if got := cacheWarnSizeMB(cfg); got != 500 {
+		t.Errorf("got %d, want 500", got)
+	}
+}
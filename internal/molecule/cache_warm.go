@@ -0,0 +1,163 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// WarmOptions limits which dependency categories cache warm installs
+type WarmOptions struct {
+	CollectionsOnly bool
+	RolesOnly       bool
+}
+
+// categories returns the cache categories selected by the warm options
+func (w WarmOptions) categories() cacheCategories {
+	return cacheCategories{
+		Roles:       !w.CollectionsOnly,
+		Collections: !w.RolesOnly,
+	}
+}
+
+// warmExec runs a shell command inside the molecule container. Tests replace
+// it to record the issued commands.
+var warmExec = func(opts *MoleculeOptions, cmdStr string) error {
+	return utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// containerExists reports whether the molecule container for the role is up.
+// A stopped container does not count: docker exec fails against it.
+var containerExists = func(opts *MoleculeOptions) bool {
+	return containerRunning(ContainerName(opts.RoleFlag))
+}
+
+// containerPresent reports whether the molecule container for the role exists,
+// running or not. Tests replace it.
+var containerPresent = func(opts *MoleculeOptions) bool {
+	return exec.Command("docker", "inspect", ContainerName(opts.RoleFlag)).Run() == nil
+}
+
+// containerStart starts a stopped container and containerRemove force-removes
+// it. Tests replace them.
+var (
+	containerStart = func(opts *MoleculeOptions) error {
+		return utils.RunCommandHide(opts.CIMode, "docker", "start", ContainerName(opts.RoleFlag))
+	}
+	containerRemove = func(opts *MoleculeOptions) error {
+		return utils.RunCommandHide(opts.CIMode, "docker", "rm", "-f", ContainerName(opts.RoleFlag))
+	}
+)
+
+// reviveContainer reports whether the role container is up, starting it when it
+// exists but is stopped (e.g. after a host reboot). A stopped container that
+// fails to start is removed, so the caller can create a fresh one under the
+// same name.
+func reviveContainer(opts *MoleculeOptions) bool {
+	if containerExists(opts) {
+		return true
+	}
+	if !containerPresent(opts) {
+		return false
+	}
+	name := ContainerName(opts.RoleFlag)
+	log.Printf(config.ColorYellow+"Container %s is stopped, starting it..."+config.ColorReset, name)
+	if err := containerStart(opts); err == nil && containerExists(opts) {
+		return true
+	}
+	log.Printf(config.ColorYellow+"warning: failed to start container %s, removing it"+config.ColorReset, name)
+	if err := containerRemove(opts); err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to remove container %s: %v"+config.ColorReset, name, err)
+	}
+	return false
+}
+
+// warmStart starts the molecule container with the role copied in, as the
+// default flow does before converge. Tests replace it.
+var warmStart = func(opts *MoleculeOptions, cfg *config.Config) error {
+	path, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if cfg.ContainerRegistry == nil {
+		cfg.ContainerRegistry = &config.ContainerRegistry{}
+	}
+	roleDirName := utils.GetRoleDirName(opts.OrgFlag, opts.RoleFlag)
+	return prepareContainer(opts, cfg, path, roleDirName, filepath.Join(path, config.MoleculeDir, roleDirName))
+}
+
+// warmInstallCommands returns the ansible-galaxy install commands for the
+// selected categories, installing straight into the cache-mounted paths, and
+// uv-sync to fill the UV cache.
+func warmInstallCommands(cfg *config.Config, roleDirName, scenario string, cats cacheCategories) []string {
+	requirements := fmt.Sprintf("molecule/%s/%s", scenario, config.RequirementsFileName)
+
+	var cmds []string
+	if cats.Collections {
+		cmds = append(cmds, fmt.Sprintf("cd ./%s && ansible-galaxy collection install -r %s -p %s",
+			roleDirName, requirements, utils.ContainerCollectionsPath(cfg)))
+	}
+	if cats.Roles {
+		cmds = append(cmds, fmt.Sprintf("cd ./%s && ansible-galaxy role install -r %s -p %s",
+			roleDirName, requirements, utils.ContainerRolesPath(cfg)))
+	}
+	if cats.UV {
+		cmds = append(cmds, "uv-sync")
+	}
+	return cmds
+}
+
+// WarmCache installs the role's collections and/or roles (and, when the UV
+// cache is on and neither is selected alone, the Python packages) into the
+// cache of the molecule container without running converge. The container is
+// started when it does not exist yet. In CI mode the installed categories are
+// copied back to the host cache directory.
+func WarmCache(opts *MoleculeOptions, w WarmOptions) error {
+	if w.CollectionsOnly && w.RolesOnly {
+		return fmt.Errorf("--collections-only and --roles-only are mutually exclusive")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled || cfg.CacheConfig.CacheID == "" {
+		return fmt.Errorf("cache is not enabled for this role; run 'diffusion cache enable' first")
+	}
+
+	return warmCache(opts, cfg, w)
+}
+
+func warmCache(opts *MoleculeOptions, cfg *config.Config, w WarmOptions) error {
+	if !containerExists(opts) {
+		log.Printf(config.ColorGreen+"Starting container %s to warm the cache"+config.ColorReset, ContainerName(opts.RoleFlag))
+		if err := warmStart(opts, cfg); err != nil {
+			return fmt.Errorf("failed to start %s: %w", ContainerName(opts.RoleFlag), err)
+		}
+	}
+
+	scenario := activeScenario(opts)
+	roleDirName := utils.GetRoleDirName(opts.OrgFlag, opts.RoleFlag)
+	cats := w.categories()
+	cats.UV = cats.Roles && cats.Collections && cfg.CacheConfig.UVCache
+
+	for _, cmdStr := range warmInstallCommands(cfg, roleDirName, scenario, cats) {
+		if err := warmExec(opts, cmdStr); err != nil {
+			return fmt.Errorf("cache warm failed: %w", err)
+		}
+	}
+
+	// Non-CI containers have the cache volume-mounted, so it is already on the host
+	if opts.CIMode {
+		copyCacheCategoriesFromContainer(opts, cfg, cats)
+	}
+
+	log.Printf(config.ColorGreen + "Cache warmed successfully" + config.ColorReset)
+	reportCacheSize(opts, cfg)
+	return nil
+}
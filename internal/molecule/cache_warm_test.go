@@ -0,0 +1,175 @@
+package molecule
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestWarmCacheInstallModes(t *testing.T) {
+	tests := []struct {
+		name            string
+		warm            WarmOptions
+		wantCollections bool
+		wantRoles       bool
+	}{
+		{name: "both", warm: WarmOptions{}, wantCollections: true, wantRoles: true},
+		{name: "collections only", warm: WarmOptions{CollectionsOnly: true}, wantCollections: true},
+		{name: "roles only", warm: WarmOptions{RolesOnly: true}, wantRoles: true},
+	}
+
+	origExec, origExists := warmExec, containerExists
+	defer func() { warmExec, containerExists = origExec, origExists }()
+	containerExists = func(*MoleculeOptions) bool { return true }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issued []string
+			warmExec = func(_ *MoleculeOptions, cmdStr string) error {
+				issued = append(issued, cmdStr)
+				return nil
+			}
+
+			opts := &MoleculeOptions{RoleFlag: "web", OrgFlag: "acme"}
+			cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc"}}
+			if err := warmCache(opts, cfg, tt.warm); err != nil {
+				t.Fatalf("warmCache() error = %v", err)
+			}
+
+			all := strings.Join(issued, "\n")
+			if got := strings.Contains(all, "ansible-galaxy collection install -r molecule/default/requirements.yml -p /root/.ansible/collections"); got != tt.wantCollections {
+				t.Errorf("collection install issued = %v, want %v\n%s", got, tt.wantCollections, all)
+			}
+			if got := strings.Contains(all, "ansible-galaxy role install -r molecule/default/requirements.yml -p /root/.ansible/roles"); got != tt.wantRoles {
+				t.Errorf("role install issued = %v, want %v\n%s", got, tt.wantRoles, all)
+			}
+			for _, cmd := range issued {
+				if !strings.HasPrefix(cmd, "cd ./acme.web && ") {
+					t.Errorf("command not run from role dir: %q", cmd)
+				}
+			}
+		})
+	}
+}
+
+func TestWarmCacheStartsMissingContainer(t *testing.T) {
+	origExec, origExists, origStart := warmExec, containerExists, warmStart
+	defer func() { warmExec, containerExists, warmStart = origExec, origExists, origStart }()
+	containerExists = func(*MoleculeOptions) bool { return false }
+
+	var issued []string
+	warmStart = func(*MoleculeOptions, *config.Config) error {
+		issued = append(issued, "start")
+		return nil
+	}
+	warmExec = func(_ *MoleculeOptions, cmdStr string) error {
+		issued = append(issued, cmdStr)
+		return nil
+	}
+
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc", UVCache: true}}
+	if err := warmCache(&MoleculeOptions{RoleFlag: "web", OrgFlag: "acme"}, cfg, WarmOptions{}); err != nil {
+		t.Fatalf("warmCache() error = %v", err)
+	}
+
+	want := []string{
+		"start",
+		"cd ./acme.web && ansible-galaxy collection install -r molecule/default/requirements.yml -p /root/.ansible/collections",
+		"cd ./acme.web && ansible-galaxy role install -r molecule/default/requirements.yml -p /root/.ansible/roles",
+		"uv-sync",
+	}
+	if strings.Join(issued, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issued commands:\n%s\nwant:\n%s", strings.Join(issued, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWarmCacheSkipsUVForSingleCategory(t *testing.T) {
+	origExec, origExists := warmExec, containerExists
+	defer func() { warmExec, containerExists = origExec, origExists }()
+	containerExists = func(*MoleculeOptions) bool { return true }
+
+	var issued []string
+	warmExec = func(_ *MoleculeOptions, cmdStr string) error {
+		issued = append(issued, cmdStr)
+		return nil
+	}
+
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc", UVCache: true}}
+	if err := warmCache(&MoleculeOptions{RoleFlag: "web", OrgFlag: "acme"}, cfg, WarmOptions{RolesOnly: true}); err != nil {
+		t.Fatalf("warmCache() error = %v", err)
+	}
+	for _, cmd := range issued {
+		if cmd == "uv-sync" {
+			t.Errorf("uv-sync issued with --roles-only: %q", issued)
+		}
+	}
+}
+
+func TestWarmCacheStartFailure(t *testing.T) {
+	origExists, origStart := containerExists, warmStart
+	defer func() { containerExists, warmStart = origExists, origStart }()
+	containerExists = func(*MoleculeOptions) bool { return false }
+	warmStart = func(*MoleculeOptions, *config.Config) error { return fmt.Errorf("pull failed") }
+
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc"}}
+	err := warmCache(&MoleculeOptions{RoleFlag: "web"}, cfg, WarmOptions{})
+	if err == nil || !strings.Contains(err.Error(), "failed to start molecule-web") {
+		t.Errorf("warmCache() error = %v, want a start failure", err)
+	}
+}
+
+func TestWarmCacheRejectsBothModes(t *testing.T) {
+	err := WarmCache(&MoleculeOptions{}, WarmOptions{CollectionsOnly: true, RolesOnly: true})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestReviveContainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		running     bool
+		present     bool
+		startErr    error
+		want        bool
+		wantStarted bool
+		wantRemoved bool
+	}{
+		{name: "running", running: true, present: true, want: true},
+		{name: "absent", want: false},
+		{name: "stopped", present: true, want: true, wantStarted: true},
+		{name: "stopped and broken", present: true, startErr: fmt.Errorf("exit status 1"), wantStarted: true, wantRemoved: true},
+	}
+
+	origExists, origPresent, origStart, origRemove := containerExists, containerPresent, containerStart, containerRemove
+	defer func() {
+		containerExists, containerPresent, containerStart, containerRemove = origExists, origPresent, origStart, origRemove
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running := tt.running
+			var started, removed bool
+			containerExists = func(*MoleculeOptions) bool { return running }
+			containerPresent = func(*MoleculeOptions) bool { return tt.present }
+			containerStart = func(*MoleculeOptions) error {
+				started = true
+				running = tt.startErr == nil
+				return tt.startErr
+			}
+			containerRemove = func(*MoleculeOptions) error {
+				removed = true
+				return nil
+			}
+
+			if got := reviveContainer(&MoleculeOptions{RoleFlag: "web"}); got != tt.want {
+				t.Errorf("reviveContainer() = %v, want %v", got, tt.want)
+			}
+			if started != tt.wantStarted || removed != tt.wantRemoved {
+				t.Errorf("started = %v, removed = %v, want %v, %v", started, removed, tt.wantStarted, tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package molecule
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"diffusion/internal/config"
+)
+
+// hostCgroupRoot is where the host cgroup hierarchy is mounted
+const hostCgroupRoot = "/sys/fs/cgroup"
+
+// CgroupVersion identifies the cgroup hierarchy layout of the host
+type CgroupVersion int
+
+const (
+	CgroupUnknown CgroupVersion = iota // cgroup filesystem not available (e.g. WSL2 without systemd)
+	CgroupV1                           // legacy or hybrid per-controller hierarchy
+	CgroupV2                           // unified hierarchy
+)
+
+func (v CgroupVersion) String() string {
+	switch v {
+	case CgroupV1:
+		return "v1"
+	case CgroupV2:
+		return "v2"
+	default:
+		return "unknown"
+	}
+}
+
+// detectCgroupVersion inspects the cgroup mount at root. The unified (v2)
+// hierarchy exposes cgroup.controllers at its root; the v1 layout only has
+// per-controller directories such as memory/ or cpu/.
+func detectCgroupVersion(root string) CgroupVersion {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return CgroupUnknown
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return CgroupV2
+	}
+	return CgroupV1
+}
+
+// cgroupMountArgs returns the docker run arguments needed for systemd images
+// on a host with the given cgroup version. The container always runs with
+// --cgroupns host, so both layouts only need the hierarchy mounted read-write.
+func cgroupMountArgs(version CgroupVersion) []string {
+	if version == CgroupUnknown {
+		return nil
+	}
+	return []string{"-v", hostCgroupRoot + ":" + hostCgroupRoot + ":rw"}
+}
+
+// printCgroupHint explains cgroup v2 requirements after a failed container or
+// instance creation. It is a no-op on v1 hosts where the defaults work.
+func printCgroupHint(version CgroupVersion) {
+	if version != CgroupV2 {
+		return
+	}
+	log.Printf(config.ColorYellow + "Host uses cgroup v2. Systemd-based platform images only boot when:" + config.ColorReset)
+	log.Printf(config.ColorYellow + "  - the platform in molecule.yml sets cgroupns_mode: host and privileged: true" + config.ColorReset)
+	log.Printf(config.ColorYellow + "  - /sys/fs/cgroup:/sys/fs/cgroup:rw is listed in the platform volumes" + config.ColorReset)
+	log.Printf(config.ColorYellow + "  - the image ships systemd >= 247 (older systemd only understands cgroup v1)" + config.ColorReset)
+}
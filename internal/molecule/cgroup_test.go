@@ -0,0 +1,51 @@
+package molecule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectCgroupVersion(t *testing.T) {
+	v2Root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(v2Root, "cgroup.controllers"), []byte("cpu memory io\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v1Root := t.TempDir()
+	for _, controller := range []string{"cpu", "memory", "systemd"} {
+		if err := os.Mkdir(filepath.Join(v1Root, controller), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		root string
+		want CgroupVersion
+	}{
+		{name: "unified hierarchy", root: v2Root, want: CgroupV2},
+		{name: "per-controller hierarchy", root: v1Root, want: CgroupV1},
+		{name: "missing mount", root: filepath.Join(t.TempDir(), "absent"), want: CgroupUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectCgroupVersion(tt.root); got != tt.want {
+				t.Errorf("detectCgroupVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCgroupMountArgs(t *testing.T) {
+	if args := cgroupMountArgs(CgroupUnknown); len(args) != 0 {
+		t.Errorf("expected no mount without cgroup fs, got %v", args)
+	}
+	for _, v := range []CgroupVersion{CgroupV1, CgroupV2} {
+		args := cgroupMountArgs(v)
+		if len(args) != 2 || args[0] != "-v" || args[1] != "/sys/fs/cgroup:/sys/fs/cgroup:rw" {
+			t.Errorf("cgroupMountArgs(%s) = %v", v, args)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package molecule
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"diffusion/internal/config"
+)
+
+// CIProvider identifies a CI system whose log viewer understands annotations
+type CIProvider int
+
+const (
+	CIProviderNone CIProvider = iota
+	CIProviderGitHub
+	CIProviderGitLab
+)
+
+// detectCIProvider reads the provider marker variables (GITHUB_ACTIONS / GITLAB_CI)
+func detectCIProvider(getenv func(string) string) CIProvider {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return CIProviderGitHub
+	case getenv("GITLAB_CI") == "true":
+		return CIProviderGitLab
+	default:
+		return CIProviderNone
+	}
+}
+
+// ciSectionStart opens a collapsible log section for a phase
+func ciSectionStart(w io.Writer, p CIProvider, phase string) {
+	switch p {
+	case CIProviderGitHub:
+		fmt.Fprintf(w, "::group::molecule %s\n", phase)
+	case CIProviderGitLab:
+		fmt.Fprintf(w, "\033[0Ksection_start:%d:molecule_%s\r\033[0Kmolecule %s\n", time.Now().Unix(), phase, phase)
+	}
+}
+
+// ciSectionEnd closes the section opened by ciSectionStart
+func ciSectionEnd(w io.Writer, p CIProvider, phase string) {
+	switch p {
+	case CIProviderGitHub:
+		fmt.Fprintln(w, "::endgroup::")
+	case CIProviderGitLab:
+		fmt.Fprintf(w, "\033[0Ksection_end:%d:molecule_%s\r\033[0K\n", time.Now().Unix(), phase)
+	}
+}
+
+// ciError emits a failure the provider surfaces outside the raw log.
+// GitHub gets an ::error annotation (pointing at file when known); GitLab has no
+// annotation syntax, so the message is printed as a plain uncolored line.
+func ciError(w io.Writer, p CIProvider, file, message string) {
+	switch p {
+	case CIProviderGitHub:
+		if file != "" {
+			fmt.Fprintf(w, "::error file=%s::%s\n", escapeGitHubProperty(file), escapeGitHubData(message))
+		} else {
+			fmt.Fprintf(w, "::error::%s\n", escapeGitHubData(message))
+		}
+	case CIProviderGitLab:
+		fmt.Fprintf(w, "ERROR: %s\n", message)
+	}
+}
+
+// escapeGitHubData escapes a workflow command message
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// phaseFile returns the scenario playbook a phase failure should point at
+func phaseFile(opts *MoleculeOptions, phase string) string {
+	scenario := activeScenario(opts)
+	switch phase {
+	case "prepare", "converge", "verify":
+		return path.Join(config.ScenariosDir, scenario, phase+".yml")
+	default:
+		return ""
+	}
+}
+
+// withCISection runs a molecule phase inside a collapsible CI log section and
+// annotates its failure. Outside --ci or a detected provider it just runs the phase.
+func withCISection(opts *MoleculeOptions, phase string, run func() error) error {
+	p := CIProviderNone
+	if opts.CIMode {
+		p = detectCIProvider(os.Getenv)
+	}
+	if p == CIProviderNone {
+		return run()
+	}
+
+	ciSectionStart(os.Stdout, p, phase)
+	err := run()
+	ciSectionEnd(os.Stdout, p, phase)
+	if err != nil {
+		ciError(os.Stdout, p, phaseFile(opts, phase), err.Error())
+	}
+	return err
+}
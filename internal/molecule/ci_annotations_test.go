@@ -0,0 +1,92 @@
+package molecule
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectCIProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want CIProvider
+	}{
+		{"none", map[string]string{}, CIProviderNone},
+		{"github", map[string]string{"GITHUB_ACTIONS": "true"}, CIProviderGitHub},
+		{"gitlab", map[string]string{"GITLAB_CI": "true"}, CIProviderGitLab},
+		{"github wins", map[string]string{"GITHUB_ACTIONS": "true", "GITLAB_CI": "true"}, CIProviderGitHub},
+		{"not true", map[string]string{"GITHUB_ACTIONS": "1"}, CIProviderNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectCIProvider(func(k string) string { return tt.env[k] })
+			if got != tt.want {
+				t.Errorf("detectCIProvider() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIAnnotationsGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	ciSectionStart(&buf, CIProviderGitHub, "converge")
+	ciSectionEnd(&buf, CIProviderGitHub, "converge")
+	ciError(&buf, CIProviderGitHub, "scenarios/default/converge.yml", "converge failed: exit status 2\n100%")
+
+	want := "::group::molecule converge\n" +
+		"::endgroup::\n" +
+		"::error file=scenarios/default/converge.yml::converge failed: exit status 2%0A100%25\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	ciError(&buf, CIProviderGitHub, "", "lint failed")
+	if buf.String() != "::error::lint failed\n" {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestCIAnnotationsGitLab(t *testing.T) {
+	var buf bytes.Buffer
+	ciSectionStart(&buf, CIProviderGitLab, "verify")
+	ciSectionEnd(&buf, CIProviderGitLab, "verify")
+	ciError(&buf, CIProviderGitLab, "scenarios/default/verify.yml", "verify failed")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "\033[0Ksection_start:") || !strings.Contains(lines[0], ":molecule_verify\r\033[0Kmolecule verify") {
+		t.Errorf("unexpected section start %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "\033[0Ksection_end:") || !strings.HasSuffix(lines[1], ":molecule_verify\r\033[0K") {
+		t.Errorf("unexpected section end %q", lines[1])
+	}
+	if lines[2] != "ERROR: verify failed" {
+		t.Errorf("unexpected error line %q", lines[2])
+	}
+}
+
+func TestCIAnnotationsNone(t *testing.T) {
+	var buf bytes.Buffer
+	ciSectionStart(&buf, CIProviderNone, "lint")
+	ciSectionEnd(&buf, CIProviderNone, "lint")
+	ciError(&buf, CIProviderNone, "", "lint failed")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output without a provider, got %q", buf.String())
+	}
+}
+
+func TestPhaseFile(t *testing.T) {
+	if got := phaseFile(&MoleculeOptions{}, "converge"); got != "scenarios/default/converge.yml" {
+		t.Errorf("phaseFile(converge) = %q", got)
+	}
+	if got := phaseFile(&MoleculeOptions{RoleScenario: "ha"}, "verify"); got != "scenarios/ha/verify.yml" {
+		t.Errorf("phaseFile(verify) = %q", got)
+	}
+	if got := phaseFile(&MoleculeOptions{}, "lint"); got != "" {
+		t.Errorf("phaseFile(lint) = %q, want empty", got)
+	}
+}
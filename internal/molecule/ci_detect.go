@@ -0,0 +1,34 @@
+package molecule
+
+import (
+	"os"
+	"strconv"
+)
+
+// DetectCIMode reports why CI mode should be enabled when --ci was not given:
+// CI=true, a GitHub Actions or GitLab CI runner, or a stdout that is not a
+// terminal (the -ti docker exec flags fail there). It returns "" otherwise.
+func DetectCIMode(getenv func(string) string, stdoutIsTTY bool) string {
+	if ci, err := strconv.ParseBool(getenv("CI")); err == nil && ci {
+		return "CI=" + getenv("CI")
+	}
+	switch detectCIProvider(getenv) {
+	case CIProviderGitHub:
+		return "GITHUB_ACTIONS=true"
+	case CIProviderGitLab:
+		return "GITLAB_CI=true"
+	}
+	if !stdoutIsTTY {
+		return "stdout is not a terminal"
+	}
+	return ""
+}
+
+// StdoutIsTerminal reports whether stdout is attached to a terminal
+func StdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
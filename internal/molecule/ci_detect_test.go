@@ -0,0 +1,31 @@
+package molecule
+
+import "testing"
+
+func TestDetectCIMode(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		tty  bool
+		want string
+	}{
+		{"terminal, no env", map[string]string{}, true, ""},
+		{"no terminal", map[string]string{}, false, "stdout is not a terminal"},
+		{"CI=true", map[string]string{"CI": "true"}, true, "CI=true"},
+		{"CI=1", map[string]string{"CI": "1"}, true, "CI=1"},
+		{"CI=false", map[string]string{"CI": "false"}, true, ""},
+		{"CI=false without terminal", map[string]string{"CI": "false"}, false, "stdout is not a terminal"},
+		{"github actions", map[string]string{"GITHUB_ACTIONS": "true"}, true, "GITHUB_ACTIONS=true"},
+		{"gitlab ci", map[string]string{"GITLAB_CI": "true"}, true, "GITLAB_CI=true"},
+		{"CI wins over provider", map[string]string{"CI": "true", "GITLAB_CI": "true"}, false, "CI=true"},
+		{"unrelated value", map[string]string{"CI": "yes please"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectCIMode(func(k string) string { return tt.env[k] }, tt.tty)
+			if got != tt.want {
+				t.Errorf("DetectCIMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
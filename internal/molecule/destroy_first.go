@@ -0,0 +1,27 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/config"
+)
+
+// validateDestroyFirst rejects --destroy-first on runs that do not converge
+func validateDestroyFirst(opts *MoleculeOptions) error {
+	if opts.DestroyFirst && !isConverging(opts) {
+		return fmt.Errorf("--destroy-first only applies to converge (use --converge or the default flow)")
+	}
+	return nil
+}
+
+// destroyAndCreate runs molecule destroy and then molecule create for the
+// active scenario, so the following converge starts from fresh instances. The
+// molecule container itself is kept, unlike with --wipe.
+func destroyAndCreate(opts *MoleculeOptions, roleDirName string) error {
+	log.Printf(config.ColorAquamarine + "Destroying molecule instances before converge (--destroy-first)" + config.ColorReset)
+	if err := runDestroy(opts, roleDirName); err != nil {
+		return err
+	}
+	return runCreate(opts, roleDirName)
+}
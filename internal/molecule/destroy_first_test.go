@@ -0,0 +1,60 @@
+package molecule
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDestroyAndCreateOrder(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	opts := &MoleculeOptions{RoleFlag: "web", RoleScenario: "ubuntu", DestroyFirst: true}
+
+	if err := destroyAndCreate(opts, "acme.web"); err != nil {
+		t.Fatalf("destroyAndCreate: %v", err)
+	}
+	want := []string{
+		"cd ./acme.web && molecule destroy -s ubuntu",
+		"cd ./acme.web && molecule create -s ubuntu",
+	}
+	if len(*calls) != len(want) {
+		t.Fatalf("calls = %q, want %q", *calls, want)
+	}
+	for i := range want {
+		if (*calls)[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, (*calls)[i], want[i])
+		}
+	}
+}
+
+func TestDestroyAndCreateStopsOnDestroyFailure(t *testing.T) {
+	calls := stubPhaseExec(t, 1, true)
+	opts := &MoleculeOptions{RoleFlag: "web", DestroyFirst: true}
+
+	err := destroyAndCreate(opts, "acme.web")
+	if err == nil || !strings.Contains(err.Error(), "destroy failed") {
+		t.Fatalf("expected destroy failure, got %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("create ran after a failed destroy: %q", *calls)
+	}
+}
+
+func TestValidateDestroyFirst(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MoleculeOptions
+		wantErr bool
+	}{
+		{"default flow", MoleculeOptions{DestroyFirst: true}, false},
+		{"converge", MoleculeOptions{DestroyFirst: true, ConvergeFlag: true}, false},
+		{"verify", MoleculeOptions{DestroyFirst: true, VerifyFlag: true}, true},
+		{"unset", MoleculeOptions{VerifyFlag: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDestroyFirst(&tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateDestroyFirst() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
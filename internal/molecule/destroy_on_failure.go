@@ -0,0 +1,40 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// validateDestroyOnFailure rejects --destroy-on-failure with --keep, which
+// keeps the container after a failure on purpose
+func validateDestroyOnFailure(opts *MoleculeOptions) error {
+	if opts.DestroyOnFail && opts.KeepFlag {
+		return fmt.Errorf("--destroy-on-failure and --keep cannot be combined")
+	}
+	return nil
+}
+
+// cleanupAfterFailure runs molecule destroy in the container and removes it, so
+// a failed run leaves no instances or molecule-<role> container behind. Both
+// steps are best-effort: the container may already be gone. Tests replace it.
+var cleanupAfterFailure = func(opts *MoleculeOptions, roleDirName string) {
+	log.Printf(config.ColorAquamarine+"Run failed, cleaning up (--destroy-on-failure): molecule destroy and removing container %s"+config.ColorReset, ContainerName(opts.RoleFlag))
+	_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "bash", opts.CIMode, "-c", fmt.Sprintf("cd ./%s && molecule destroy%s", roleDirName, scenarioFlag(opts)))
+	_ = utils.RunCommandHide(opts.CIMode, "docker", "rm", ContainerName(opts.RoleFlag), "-f")
+}
+
+// runWithFailureCleanup runs the molecule phases and, with --destroy-on-failure,
+// cleans up the container when they fail. The run's error is returned as is.
+func runWithFailureCleanup(opts *MoleculeOptions, roleDirName string, run func() error) (err error) {
+	if opts.DestroyOnFail {
+		defer func() {
+			if err != nil {
+				cleanupAfterFailure(opts, roleDirName)
+			}
+		}()
+	}
+	return run()
+}
@@ -0,0 +1,135 @@
+package molecule
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// stubFailureCleanup records the role dirs cleanupAfterFailure was called for
+func stubFailureCleanup(t *testing.T) *[]string {
+	t.Helper()
+	orig := cleanupAfterFailure
+	t.Cleanup(func() { cleanupAfterFailure = orig })
+
+	var cleaned []string
+	cleanupAfterFailure = func(_ *MoleculeOptions, roleDirName string) {
+		cleaned = append(cleaned, roleDirName)
+	}
+	return &cleaned
+}
+
+func TestDestroyOnFailureCleansUpAfterFailedPhase(t *testing.T) {
+	cleaned := stubFailureCleanup(t)
+	calls := stubPhaseExec(t, 1, true)
+	opts := &MoleculeOptions{RoleFlag: "web", DestroyOnFail: true}
+
+	err := runWithFailureCleanup(opts, "acme.web", func() error { return runConverge(opts, "acme.web") })
+	if err == nil {
+		t.Fatal("expected the converge failure to be returned")
+	}
+	if len(*calls) != 1 {
+		t.Errorf("converge calls = %q", *calls)
+	}
+	if len(*cleaned) != 1 || (*cleaned)[0] != "acme.web" {
+		t.Errorf("cleanup calls = %q, want one for acme.web", *cleaned)
+	}
+}
+
+func TestDestroyOnFailureSkipsCleanupOnSuccess(t *testing.T) {
+	cleaned := stubFailureCleanup(t)
+	stubPhaseExec(t, 0, true)
+	opts := &MoleculeOptions{RoleFlag: "web", DestroyOnFail: true}
+
+	if err := runWithFailureCleanup(opts, "acme.web", func() error { return runConverge(opts, "acme.web") }); err != nil {
+		t.Fatalf("converge failed: %v", err)
+	}
+	if len(*cleaned) != 0 {
+		t.Errorf("cleanup ran after a successful run: %q", *cleaned)
+	}
+}
+
+// stubDefaultFlow replaces the container setup and uv-sync of the default flow
+func stubDefaultFlow(t *testing.T) {
+	t.Helper()
+	origPrepare, origSync := defaultPrepare, defaultUVSync
+	t.Cleanup(func() { defaultPrepare, defaultUVSync = origPrepare, origSync })
+
+	defaultPrepare = func(*MoleculeOptions, *config.Config, string, string, string) error { return nil }
+	defaultUVSync = func(*MoleculeOptions, bool) error { return nil }
+}
+
+func TestDestroyOnFailureCleansUpAfterDefaultFlow(t *testing.T) {
+	cleaned := stubFailureCleanup(t)
+	stubPhaseExec(t, 1, true)
+	stubDefaultFlow(t)
+	opts := &MoleculeOptions{RoleFlag: "web", CIMode: true, DestroyOnFail: true}
+
+	err := runWithFailureCleanup(opts, "acme.web", func() error {
+		return handleDefaultFlow(opts, &config.Config{}, "", "acme.web", "")
+	})
+	if err == nil {
+		t.Fatal("expected the converge failure to be returned")
+	}
+	if len(*cleaned) != 1 || (*cleaned)[0] != "acme.web" {
+		t.Errorf("cleanup calls = %q, want one for acme.web", *cleaned)
+	}
+}
+
+func TestDefaultFlowOnlyWarnsWithoutDestroyOnFailure(t *testing.T) {
+	stubPhaseExec(t, 1, true)
+	stubDefaultFlow(t)
+	opts := &MoleculeOptions{RoleFlag: "web", CIMode: true}
+
+	if err := handleDefaultFlow(opts, &config.Config{}, "", "acme.web", ""); err != nil {
+		t.Errorf("converge failure should only warn, got %v", err)
+	}
+}
+
+func TestDefaultFlowRecordsCreate(t *testing.T) {
+	stubPhaseExec(t, 0, false)
+	stubDefaultFlow(t)
+	origCreate := defaultCreate
+	t.Cleanup(func() { defaultCreate = origCreate })
+	var created []string
+	defaultCreate = func(_ *MoleculeOptions, cmdStr string) error {
+		created = append(created, cmdStr)
+		return nil
+	}
+	opts := &MoleculeOptions{RoleFlag: "web", CIMode: true, Results: NewResultCollector()}
+
+	if err := handleDefaultFlow(opts, &config.Config{}, "", "acme.web", ""); err != nil {
+		t.Fatalf("default flow failed: %v", err)
+	}
+	if len(created) != 1 || !strings.Contains(created[0], "molecule create") {
+		t.Errorf("create commands = %q", created)
+	}
+	steps := opts.Results.Steps()
+	if len(steps) != 2 || steps[0].Phase != "create" || steps[1].Phase != "converge" {
+		t.Errorf("recorded steps = %+v, want create then converge", steps)
+	}
+}
+
+func TestFailureCleanupNeedsFlag(t *testing.T) {
+	cleaned := stubFailureCleanup(t)
+	opts := &MoleculeOptions{RoleFlag: "web"}
+
+	runErr := errors.New("converge failed")
+	if err := runWithFailureCleanup(opts, "acme.web", func() error { return runErr }); !errors.Is(err, runErr) {
+		t.Fatalf("error = %v, want the run's error", err)
+	}
+	if len(*cleaned) != 0 {
+		t.Errorf("cleanup ran without --destroy-on-failure: %q", *cleaned)
+	}
+}
+
+func TestValidateDestroyOnFailure(t *testing.T) {
+	if err := validateDestroyOnFailure(&MoleculeOptions{DestroyOnFail: true, KeepFlag: true}); err == nil {
+		t.Error("expected --destroy-on-failure with --keep to be rejected")
+	}
+	if err := validateDestroyOnFailure(&MoleculeOptions{DestroyOnFail: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package molecule
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// dindImage is a DinD image saved to its own tarball, named by image ID
+type dindImage struct {
+	ID   string   `json:"id"`
+	File string   `json:"file"`
+	Refs []string `json:"refs"`
+}
+
+// dindImageManifest lists the per-image tarballs in images/manifest.json
+type dindImageManifest struct {
+	Images []dindImage `json:"images"`
+}
+
+// dindImageListCmd prints "<repo>:<tag> <full image ID>" for every image in the DinD daemon
+const dindImageListCmd = `docker images --no-trunc --format '{{.Repository}}:{{.Tag}} {{.ID}}'`
+
+// dindImagesPath is the per-image tarball directory inside the container.
+// Container paths are always Linux — use forward slashes, never filepath.Join.
+func dindImagesPath() string {
+	return config.ContainerDockerCachePath + "/" + config.DockerImagesDir
+}
+
+// dindManifestPath is the manifest location inside the container
+func dindManifestPath() string {
+	return dindImagesPath() + "/" + config.DockerImagesManifest
+}
+
+// parseDinDImageList groups the output of dindImageListCmd by image ID, so an
+// image with several tags is saved once. Untagged images are skipped.
+func parseDinDImageList(out string) []dindImage {
+	var images []dindImage
+	index := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		ref, id, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok || ref == "<none>:<none>" || strings.HasSuffix(ref, ":<none>") {
+			continue
+		}
+		if i, seen := index[id]; seen {
+			images[i].Refs = append(images[i].Refs, ref)
+			continue
+		}
+		index[id] = len(images)
+		images = append(images, dindImage{ID: id, File: dindImageFile(id), Refs: []string{ref}})
+	}
+	return images
+}
+
+// dindImageFile returns the tarball name for an image ID ("sha256:abc" -> "abc.tar")
+func dindImageFile(id string) string {
+	return strings.TrimPrefix(id, "sha256:") + ".tar"
+}
+
+// dindSaveCommand saves one image to its tarball. The tarball is written to a
+// temporary name and renamed, so an interrupted save never leaves a truncated
+// file; an existing tarball is kept since the name is content-addressed.
+func dindSaveCommand(img dindImage) string {
+	dir := dindImagesPath()
+	refs := make([]string, len(img.Refs))
+	for i, ref := range img.Refs {
+		refs[i] = shellQuote(ref)
+	}
+	return fmt.Sprintf("test -s %[1]s/%[2]s || { docker save %[3]s > %[1]s/%[2]s.tmp && mv %[1]s/%[2]s.tmp %[1]s/%[2]s; }",
+		dir, img.File, strings.Join(refs, " "))
+}
+
+// dindLoadCommand loads one image tarball unless the daemon already has the image
+func dindLoadCommand(img dindImage) string {
+	return fmt.Sprintf("docker image inspect %s >/dev/null 2>&1 || docker load -i %s/%s",
+		shellQuote(img.ID), dindImagesPath(), img.File)
+}
+
+// dindManifestWriteCommand writes the manifest inside the container
+func dindManifestWriteCommand(m dindImageManifest) (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("printf '%%s' %s | base64 -d > %s",
+		base64.StdEncoding.EncodeToString(data), dindManifestPath()), nil
+}
+
+// dindPruneCommand removes tarballs (and leftover .tmp files) of images no
+// longer present in the manifest
+func dindPruneCommand(m dindImageManifest) string {
+	keep := make([]string, len(m.Images))
+	for i, img := range m.Images {
+		keep[i] = img.File
+	}
+	pattern := strings.Join(keep, "|")
+	if pattern == "" {
+		pattern = config.DockerImagesManifest
+	}
+	return fmt.Sprintf(`for f in %s/*.tar %s/*.tmp; do [ -e "$f" ] || continue; case "${f##*/}" in %s) ;; *) rm -f "$f" ;; esac; done`,
+		dindImagesPath(), dindImagesPath(), pattern)
+}
+
+// saveDinDImagesPerImage saves every DinD image to its own tarball under
+// /root/.cache/docker/images and records them in manifest.json. A failed save
+// only drops that image from the manifest, and each save needs disk space for
+// one image rather than the whole set.
+func saveDinDImagesPerImage(opts *MoleculeOptions) {
+	containerName := ContainerName(opts.RoleFlag)
+
+	// Never use -ti here: stdout is captured programmatically.
+	out, err := exec.Command("docker", "exec", containerName, "sh", "-c", dindImageListCmd).Output()
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to list DinD images: %v"+config.ColorReset, err)
+		return
+	}
+	images := parseDinDImageList(string(out))
+	if len(images) == 0 {
+		log.Printf(config.ColorYellow + "No DinD images to cache" + config.ColorReset)
+		return
+	}
+
+	// Best-effort: mkdir -p never fails on a running container.
+	_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", "mkdir -p "+dindImagesPath())
+
+	var manifest dindImageManifest
+	for _, img := range images {
+		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", dindSaveCommand(img)); err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to save DinD image %s: %v"+config.ColorReset, strings.Join(img.Refs, ", "), err)
+			continue
+		}
+		manifest.Images = append(manifest.Images, img)
+	}
+
+	writeCmd, err := dindManifestWriteCommand(manifest)
+	if err == nil {
+		err = utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", writeCmd)
+	}
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to write DinD image manifest: %v"+config.ColorReset, err)
+		return
+	}
+	// Best-effort: stale tarballs only cost disk space.
+	_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", dindPruneCommand(manifest))
+
+	log.Printf(config.ColorGreen+"Saved %d/%d DinD image(s) to %s"+config.ColorReset, len(manifest.Images), len(images), dindImagesPath())
+}
+
+// loadDinDImagesPerImage loads the images listed in the manifest one by one,
+// skipping images the daemon already has. A failed load is reported and the
+// remaining images are still loaded.
+func loadDinDImagesPerImage(containerName string) {
+	out, err := exec.Command("docker", "exec", containerName, "cat", dindManifestPath()).Output()
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to read DinD image manifest: %v"+config.ColorReset, err)
+		return
+	}
+	var manifest dindImageManifest
+	if err := json.Unmarshal(out, &manifest); err != nil {
+		log.Printf(config.ColorYellow+"warning: invalid DinD image manifest: %v"+config.ColorReset, err)
+		return
+	}
+
+	loaded := 0
+	for _, img := range manifest.Images {
+		output, err := exec.Command("docker", "exec", containerName, "sh", "-c", dindLoadCommand(img)).CombinedOutput()
+		if err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to load DinD image %s: %v"+config.ColorReset, strings.Join(img.Refs, ", "), err)
+			if len(output) > 0 {
+				log.Printf(config.ColorYellow+"Docker load output: %s"+config.ColorReset, strings.TrimSpace(string(output)))
+			}
+			continue
+		}
+		loaded++
+	}
+	log.Printf(config.ColorGreen+"DinD images loaded from cache (%d/%d)"+config.ColorReset, loaded, len(manifest.Images))
+}
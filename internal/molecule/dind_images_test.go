@@ -0,0 +1,79 @@
+package molecule
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDinDImageList(t *testing.T) {
+	out := `geerlingguy/docker-ubuntu2204-ansible:latest sha256:aaa111
+ubuntu:22.04 sha256:bbb222
+ubuntu:jammy sha256:bbb222
+<none>:<none> sha256:ccc333
+`
+	want := []dindImage{
+		{ID: "sha256:aaa111", File: "aaa111.tar", Refs: []string{"geerlingguy/docker-ubuntu2204-ansible:latest"}},
+		{ID: "sha256:bbb222", File: "bbb222.tar", Refs: []string{"ubuntu:22.04", "ubuntu:jammy"}},
+	}
+	if got := parseDinDImageList(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDinDImageList() = %+v, want %+v", got, want)
+	}
+	if got := parseDinDImageList(""); len(got) != 0 {
+		t.Errorf("expected no images for empty output, got %+v", got)
+	}
+}
+
+func TestDinDImageCommands(t *testing.T) {
+	img := dindImage{ID: "sha256:bbb222", File: "bbb222.tar", Refs: []string{"ubuntu:22.04", "ubuntu:jammy"}}
+
+	wantSave := "test -s /root/.cache/docker/images/bbb222.tar || { docker save 'ubuntu:22.04' 'ubuntu:jammy' > " +
+		"/root/.cache/docker/images/bbb222.tar.tmp && mv /root/.cache/docker/images/bbb222.tar.tmp /root/.cache/docker/images/bbb222.tar; }"
+	if got := dindSaveCommand(img); got != wantSave {
+		t.Errorf("dindSaveCommand() =\n  %s\nwant\n  %s", got, wantSave)
+	}
+
+	wantLoad := "docker image inspect 'sha256:bbb222' >/dev/null 2>&1 || docker load -i /root/.cache/docker/images/bbb222.tar"
+	if got := dindLoadCommand(img); got != wantLoad {
+		t.Errorf("dindLoadCommand() =\n  %s\nwant\n  %s", got, wantLoad)
+	}
+
+	prune := dindPruneCommand(dindImageManifest{Images: []dindImage{img, {File: "aaa111.tar"}}})
+	if !strings.Contains(prune, `case "${f##*/}" in bbb222.tar|aaa111.tar) ;;`) {
+		t.Errorf("prune command should keep manifest files: %s", prune)
+	}
+	if empty := dindPruneCommand(dindImageManifest{}); !strings.Contains(empty, "in manifest.json) ;;") {
+		t.Errorf("prune with an empty manifest should remove all tarballs: %s", empty)
+	}
+}
+
+func TestDinDManifestWriteCommand(t *testing.T) {
+	manifest := dindImageManifest{Images: []dindImage{
+		{ID: "sha256:aaa111", File: "aaa111.tar", Refs: []string{"alpine:3.20"}},
+		{ID: "sha256:bbb222", File: "bbb222.tar", Refs: []string{"ubuntu:22.04"}},
+	}}
+
+	cmd, err := dindManifestWriteCommand(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(cmd, "| base64 -d > /root/.cache/docker/images/manifest.json") {
+		t.Fatalf("unexpected manifest command: %s", cmd)
+	}
+
+	// Decode the embedded payload and check it lists every image
+	encoded := strings.Fields(cmd)[2]
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("payload is not base64: %v", err)
+	}
+	var got dindImageManifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("payload is not a manifest: %v", err)
+	}
+	if !reflect.DeepEqual(got, manifest) {
+		t.Errorf("manifest round trip = %+v, want %+v", got, manifest)
+	}
+}
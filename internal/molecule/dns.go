@@ -0,0 +1,104 @@
+package molecule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"diffusion/internal/config"
+)
+
+// dindDaemonConfigPath is where the generated daemon.json is mounted in the container
+const dindDaemonConfigPath = "/etc/docker/daemon.json"
+
+// dindConfigDir returns the host directory holding generated DinD daemon
+// configs; replaced in tests
+var dindConfigDir = func() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".diffusion", "dind"), nil
+}
+
+// containerDNS returns the DNS servers and search domains for the molecule
+// container: --dns/--dns-search when given, else [container] dns/dns_search.
+func containerDNS(opts *MoleculeOptions, cfg *config.Config) (servers, search []string, err error) {
+	servers, search = opts.DNS, opts.DNSSearch
+	if cfg.ContainerConfig != nil {
+		if len(servers) == 0 {
+			servers = cfg.ContainerConfig.DNS
+		}
+		if len(search) == 0 {
+			search = cfg.ContainerConfig.DNSSearch
+		}
+	}
+	for _, server := range servers {
+		if err := config.ValidateDNSServer(server); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, domain := range search {
+		if err := config.ValidateDNSSearch(domain); err != nil {
+			return nil, nil, err
+		}
+	}
+	return servers, search, nil
+}
+
+// dindDaemonConfig renders the daemon.json for the Docker daemon inside the
+// molecule container, so nested platform containers use the same DNS
+func dindDaemonConfig(servers, search []string) ([]byte, error) {
+	daemon := map[string][]string{}
+	if len(servers) > 0 {
+		daemon["dns"] = servers
+	}
+	if len(search) > 0 {
+		daemon["dns-search"] = search
+	}
+	data, err := json.MarshalIndent(daemon, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// dnsRunArgs returns the docker run arguments for custom DNS. The outer
+// container gets --dns/--dns-search; the inner DinD daemon gets a generated
+// daemon.json mounted read-only over /etc/docker/daemon.json (replacing the
+// image's own, if any). No DNS settings means no arguments.
+func dnsRunArgs(opts *MoleculeOptions, cfg *config.Config) ([]string, error) {
+	servers, search, err := containerDNS(opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 && len(search) == 0 {
+		return nil, nil
+	}
+
+	var args []string
+	for _, server := range servers {
+		args = append(args, "--dns", server)
+	}
+	for _, domain := range search {
+		args = append(args, "--dns-search", domain)
+	}
+
+	data, err := dindDaemonConfig(servers, search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render DinD daemon config: %w", err)
+	}
+	dir, err := dindConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	hostPath := filepath.Join(dir, ContainerName(opts.RoleFlag)+"-daemon.json")
+	if err := os.WriteFile(hostPath, data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write DinD daemon config: %w", err)
+	}
+	return append(args, "-v", fmt.Sprintf("%s:%s:ro", hostPath, dindDaemonConfigPath)), nil
+}
@@ -0,0 +1,96 @@
+package molecule
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func fakeDinDConfigDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := dindConfigDir
+	t.Cleanup(func() { dindConfigDir = orig })
+	dindConfigDir = func() (string, error) { return dir, nil }
+	return dir
+}
+
+func TestDNSRunArgsOuterAndInnerDaemon(t *testing.T) {
+	dir := fakeDinDConfigDir(t)
+	opts := &MoleculeOptions{RoleFlag: "nginx"}
+	cfg := &config.Config{ContainerConfig: &config.ContainerSettings{
+		DNS:       []string{"10.0.0.53", "10.0.1.53"},
+		DNSSearch: []string{"corp.example.com"},
+	}}
+
+	args, err := dnsRunArgs(opts, cfg)
+	if err != nil {
+		t.Fatalf("dnsRunArgs() error = %v", err)
+	}
+	daemonPath := filepath.Join(dir, "molecule-nginx-daemon.json")
+	want := []string{
+		"--dns", "10.0.0.53", "--dns", "10.0.1.53",
+		"--dns-search", "corp.example.com",
+		"-v", daemonPath + ":" + dindDaemonConfigPath + ":ro",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("dnsRunArgs() =\n  %v\nwant\n  %v", args, want)
+	}
+
+	data, err := os.ReadFile(daemonPath)
+	if err != nil {
+		t.Fatalf("DinD daemon config not written: %v", err)
+	}
+	var daemon map[string][]string
+	if err := json.Unmarshal(data, &daemon); err != nil {
+		t.Fatalf("invalid daemon.json: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(daemon["dns"], []string{"10.0.0.53", "10.0.1.53"}) {
+		t.Errorf("daemon.json dns = %v", daemon["dns"])
+	}
+	if !reflect.DeepEqual(daemon["dns-search"], []string{"corp.example.com"}) {
+		t.Errorf("daemon.json dns-search = %v", daemon["dns-search"])
+	}
+}
+
+func TestDNSRunArgsFlagsOverrideConfig(t *testing.T) {
+	fakeDinDConfigDir(t)
+	opts := &MoleculeOptions{RoleFlag: "nginx", DNS: []string{"192.168.1.1"}}
+	cfg := &config.Config{ContainerConfig: &config.ContainerSettings{DNS: []string{"10.0.0.53"}}}
+
+	args, err := dnsRunArgs(opts, cfg)
+	if err != nil {
+		t.Fatalf("dnsRunArgs() error = %v", err)
+	}
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--dns 192.168.1.1") || strings.Contains(joined, "10.0.0.53") {
+		t.Errorf("expected --dns flag to replace [container] dns, got %q", joined)
+	}
+	if strings.Contains(joined, "--dns-search") {
+		t.Errorf("unexpected --dns-search without search domains: %q", joined)
+	}
+}
+
+func TestDNSRunArgsNone(t *testing.T) {
+	dir := fakeDinDConfigDir(t)
+	args, err := dnsRunArgs(&MoleculeOptions{RoleFlag: "nginx"}, &config.Config{})
+	if err != nil || args != nil {
+		t.Errorf("dnsRunArgs() = %v, %v; want no args", args, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("daemon.json written without DNS settings")
+	}
+}
+
+func TestDNSRunArgsRejectsInvalidServer(t *testing.T) {
+	fakeDinDConfigDir(t)
+	cfg := &config.Config{ContainerConfig: &config.ContainerSettings{DNS: []string{"dns.corp"}}}
+	if _, err := dnsRunArgs(&MoleculeOptions{RoleFlag: "nginx"}, cfg); err == nil {
+		t.Error("expected error for a non-IP DNS server")
+	}
+}
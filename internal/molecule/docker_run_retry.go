@@ -0,0 +1,89 @@
+package molecule
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"diffusion/internal/config"
+)
+
+// dockerRunAttempts bounds the docker run attempts on transient network errors;
+// it is separate from --retry, which re-runs failing molecule phases
+const dockerRunAttempts = 3
+
+// dockerRunDelay is the pause between docker run attempts; shortened in tests
+var dockerRunDelay = 5 * time.Second
+
+// dockerRunExec runs docker run and returns its combined output; replaced in tests
+var dockerRunExec = func(args []string) ([]byte, error) {
+	return exec.Command("docker", args...).CombinedOutput()
+}
+
+// removeFailedContainer removes a container a failed docker run left behind,
+// so the next attempt does not hit a name conflict; replaced in tests
+var removeFailedContainer = func(name string) {
+	_ = exec.Command("docker", "rm", "-f", name).Run()
+}
+
+// permanentDockerErrors are failures another attempt cannot fix: a missing
+// image or tag, bad credentials or an invalid reference. They win over the
+// transient markers, since registries wrap them in varied network wording.
+var permanentDockerErrors = []string{
+	"manifest unknown",
+	"not found",
+	"unauthorized",
+	"denied",
+	"authentication required",
+	"invalid reference format",
+	"conflict",
+}
+
+// transientDockerErrors are network failures worth another attempt
+var transientDockerErrors = []string{
+	"i/o timeout",
+	"tls handshake timeout",
+	"connection reset by peer",
+	"connection refused",
+	"client.timeout exceeded",
+	"request canceled while waiting for connection",
+	"temporary failure in name resolution",
+	"unexpected eof",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// isTransientDockerError reports whether docker output describes a network
+// failure that may succeed on retry
+func isTransientDockerError(output string) bool {
+	output = strings.ToLower(output)
+	for _, marker := range permanentDockerErrors {
+		if strings.Contains(output, marker) {
+			return false
+		}
+	}
+	for _, marker := range transientDockerErrors {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// runDockerWithRetry runs docker run, retrying up to dockerRunAttempts times
+// while it fails with a transient network error (e.g. a registry timeout while
+// pulling). Permanent and unrecognized failures are returned at once.
+func runDockerWithRetry(args []string, containerName string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		output, err := dockerRunExec(args)
+		if err == nil || attempt == dockerRunAttempts || !isTransientDockerError(string(output)) {
+			return output, err
+		}
+		log.Printf(config.ColorYellow+"docker run failed with a transient network error: %s; retrying in %s (attempt %d/%d)"+config.ColorReset,
+			strings.TrimSpace(string(output)), dockerRunDelay, attempt+1, dockerRunAttempts)
+		removeFailedContainer(containerName)
+		time.Sleep(dockerRunDelay)
+	}
+}
@@ -0,0 +1,84 @@
+package molecule
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsTransientDockerError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{`docker: Error response from daemon: Get "https://ghcr.io/v2/": dial tcp 140.82.121.34:443: i/o timeout.`, true},
+		{`docker: Error response from daemon: Get "https://ghcr.io/v2/": net/http: TLS handshake timeout.`, true},
+		{`docker: error pulling image configuration: read tcp 10.0.0.2:51234->140.82.121.34:443: read: connection reset by peer.`, true},
+		{`docker: Error response from daemon: Head "https://ghcr.io/v2/x/manifests/latest": received unexpected HTTP status: 503 Service Unavailable.`, true},
+		{`docker: Error response from daemon: manifest unknown.`, false},
+		{`docker: Error response from daemon: manifest for ghcr.io/polar-team/x:nope not found: manifest unknown: manifest unknown.`, false},
+		{`docker: Error response from daemon: Head "https://ghcr.io/v2/x/manifests/latest": unauthorized.`, false},
+		{`docker: Error response from daemon: pull access denied for x, repository does not exist or may require 'docker login'.`, false},
+		{`docker: Error response from daemon: Conflict. The container name "/molecule-web" is already in use.`, false},
+		{`docker: invalid reference format.`, false},
+		{`docker: Error response from daemon: failed to create task for container: cgroup mountpoint does not exist.`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := isTransientDockerError(tt.output); got != tt.want {
+			t.Errorf("isTransientDockerError(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+// stubDockerRun makes docker run fail with the given outputs in turn, then succeed
+func stubDockerRun(t *testing.T, failures ...string) (calls, removed *int) {
+	t.Helper()
+	origExec, origRemove, origDelay := dockerRunExec, removeFailedContainer, dockerRunDelay
+	t.Cleanup(func() {
+		dockerRunExec, removeFailedContainer, dockerRunDelay = origExec, origRemove, origDelay
+	})
+	calls, removed = new(int), new(int)
+	dockerRunDelay = 0
+	dockerRunExec = func(args []string) ([]byte, error) {
+		*calls++
+		if *calls <= len(failures) {
+			return []byte(failures[*calls-1]), errors.New("exit status 125")
+		}
+		return []byte("container-id\n"), nil
+	}
+	removeFailedContainer = func(string) { *removed++ }
+	return calls, removed
+}
+
+func TestRunDockerWithRetry(t *testing.T) {
+	t.Run("transient then success", func(t *testing.T) {
+		calls, removed := stubDockerRun(t, "dial tcp: i/o timeout")
+		if _, err := runDockerWithRetry([]string{"run"}, "molecule-web"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *calls != 2 || *removed != 1 {
+			t.Errorf("calls = %d, removed = %d; want 2, 1", *calls, *removed)
+		}
+	})
+
+	t.Run("permanent is not retried", func(t *testing.T) {
+		calls, _ := stubDockerRun(t, "manifest unknown")
+		if _, err := runDockerWithRetry([]string{"run"}, "molecule-web"); err == nil {
+			t.Fatal("expected the permanent error")
+		}
+		if *calls != 1 {
+			t.Errorf("calls = %d, want 1", *calls)
+		}
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		calls, _ := stubDockerRun(t, "i/o timeout", "i/o timeout", "i/o timeout", "i/o timeout")
+		output, err := runDockerWithRetry([]string{"run"}, "molecule-web")
+		if err == nil || string(output) != "i/o timeout" {
+			t.Fatalf("expected the last transient failure, got %q, %v", output, err)
+		}
+		if *calls != dockerRunAttempts {
+			t.Errorf("calls = %d, want %d", *calls, dockerRunAttempts)
+		}
+	})
+}
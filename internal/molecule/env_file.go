@@ -0,0 +1,115 @@
+package molecule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EnvVar is a KEY=VALUE pair loaded from an --env-file
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadEnvFiles parses the given env files in order. A key set by a later file
+// overrides the earlier value but keeps its original position.
+func LoadEnvFiles(paths []string) ([]EnvVar, error) {
+	var vars []EnvVar
+	index := map[string]int{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open env file: %w", err)
+		}
+		parsed, err := ParseEnvFile(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("env file %s: %w", path, err)
+		}
+		for _, v := range parsed {
+			if i, ok := index[v.Key]; ok {
+				vars[i] = v
+				continue
+			}
+			index[v.Key] = len(vars)
+			vars = append(vars, v)
+		}
+	}
+	return vars, nil
+}
+
+// ParseEnvFile parses KEY=VALUE lines. Blank lines and # comments are skipped,
+// an optional "export " prefix is allowed, and values may be single-quoted
+// (literal) or double-quoted (with \n, \t, \" and \\ escapes). Everything after
+// the first '=' belongs to the value.
+func ParseEnvFile(r io.Reader) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		vars = append(vars, EnvVar{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a value; unquoted values drop a trailing " # comment"
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch quote := value[0]; quote {
+	case '\'', '"':
+		end := strings.LastIndexByte(value, quote)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated %c quote", quote)
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected text after closing quote")
+		}
+		inner := value[1:end]
+		if quote == '\'' {
+			return inner, nil
+		}
+		return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(inner), nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// envFileArgs returns -e KEY=VALUE docker run arguments. They are appended after
+// the built-in variables, so a key the file sets explicitly (e.g. TOKEN)
+// replaces the value taken from the process environment.
+func envFileArgs(vars []EnvVar) []string {
+	args := make([]string, 0, 2*len(vars))
+	for _, v := range vars {
+		args = append(args, "-e", v.Key+"="+v.Value)
+	}
+	return args
+}
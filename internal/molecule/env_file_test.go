@@ -0,0 +1,86 @@
+package molecule
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# cloud credentials
+AWS_REGION=eu-north-1
+
+export FEATURE_FLAG=on
+DSN=postgres://user:pass@db/app?sslmode=disable
+QUOTED="hello world"
+ESCAPED="line1\nline2 \"quoted\""
+SINGLE='literal $HOME \n'
+EMPTY=
+TRAILING=value # comment
+HASH="a # b"
+`
+	vars, err := ParseEnvFile(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseEnvFile() error: %v", err)
+	}
+
+	want := []EnvVar{
+		{Key: "AWS_REGION", Value: "eu-north-1"},
+		{Key: "FEATURE_FLAG", Value: "on"},
+		{Key: "DSN", Value: "postgres://user:pass@db/app?sslmode=disable"},
+		{Key: "QUOTED", Value: "hello world"},
+		{Key: "ESCAPED", Value: "line1\nline2 \"quoted\""},
+		{Key: "SINGLE", Value: `literal $HOME \n`},
+		{Key: "EMPTY", Value: ""},
+		{Key: "TRAILING", Value: "value"},
+		{Key: "HASH", Value: "a # b"},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("ParseEnvFile() =\n  %v\nwant\n  %v", vars, want)
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	for _, content := range []string{
+		"NO_EQUALS",
+		"1BAD=value",
+		"BAD KEY=value",
+		`OPEN="unterminated`,
+		`AFTER="quoted" trailing`,
+	} {
+		if _, err := ParseEnvFile(strings.NewReader(content)); err == nil {
+			t.Errorf("ParseEnvFile(%q) expected error", content)
+		}
+	}
+}
+
+func TestLoadEnvFilesMergesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	if err := os.WriteFile(first, []byte("A=1\nB=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("B=override\nC=3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := LoadEnvFiles([]string{first, second})
+	if err != nil {
+		t.Fatalf("LoadEnvFiles() error: %v", err)
+	}
+	want := []EnvVar{{Key: "A", Value: "1"}, {Key: "B", Value: "override"}, {Key: "C", Value: "3"}}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("LoadEnvFiles() = %v, want %v", vars, want)
+	}
+
+	if got := strings.Join(envFileArgs(vars), " "); got != "-e A=1 -e B=override -e C=3" {
+		t.Errorf("envFileArgs() = %q", got)
+	}
+
+	if _, err := LoadEnvFiles([]string{filepath.Join(dir, "missing.env")}); err == nil {
+		t.Error("LoadEnvFiles() expected error for a missing file")
+	}
+}
@@ -0,0 +1,110 @@
+package molecule
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+
+	"diffusion/internal/utils"
+)
+
+// containerRoleRoot is where the role directories live inside the molecule container
+const containerRoleRoot = "/opt/molecule"
+
+// ExecOptions configures 'diffusion molecule exec'
+type ExecOptions struct {
+	Role    string
+	Org     string
+	Workdir string   // Relative paths resolve against the role directory
+	Root    bool     // Run as the container's default user instead of the host uid:gid
+	TTY     bool     // Allocate a pseudo-TTY (-t)
+	Command []string // Command and arguments run in the container
+}
+
+// ContainerName returns the name of the molecule container of a role
+func ContainerName(role string) string {
+	return utils.GetMoleculeContainerName(role)
+}
+
+// ContainerRoleDir returns the role's directory inside the molecule container
+func ContainerRoleDir(org, role string) string {
+	return path.Join(containerRoleRoot, utils.GetRoleDirName(org, role))
+}
+
+// execWorkdir returns the working directory of the exec: the role directory,
+// or --workdir resolved against it
+func execWorkdir(opts ExecOptions) string {
+	roleDir := ContainerRoleDir(opts.Org, opts.Role)
+	switch {
+	case opts.Workdir == "":
+		return roleDir
+	case path.IsAbs(opts.Workdir):
+		return path.Clean(opts.Workdir)
+	default:
+		return path.Join(roleDir, opts.Workdir)
+	}
+}
+
+// ExecArgs assembles the docker arguments that run opts.Command in the role
+// container. hostUser is the "uid:gid" mapping; it is ignored with opts.Root
+// and skipped when empty.
+func ExecArgs(opts ExecOptions, hostUser string) []string {
+	args := []string{"exec", "-i"}
+	if opts.TTY {
+		args = append(args, "-t")
+	}
+	args = append(args, "-w", execWorkdir(opts))
+	if !opts.Root && hostUser != "" {
+		args = append(args, "--user", hostUser)
+	}
+	args = append(args, ContainerName(opts.Role))
+	return append(args, opts.Command...)
+}
+
+// hostUserMapping returns the host "uid:gid" so files the command creates in
+// the role directory stay owned by the host user; there is none on Windows.
+func hostUserMapping() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
+
+// containerRunning reports whether the role container is running. Tests
+// replace it.
+var containerRunning = func(name string) bool {
+	out, err := exec.Command("docker", "inspect", "-f", "{{.State.Running}}", name).Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// execRun runs docker with the given arguments attached to the terminal.
+// Tests replace it.
+var execRun = func(args []string) error {
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Exec runs a command in the role's molecule container, in the role directory
+// unless opts.Workdir says otherwise
+func Exec(opts ExecOptions) error {
+	if opts.Role == "" {
+		return fmt.Errorf("role name is not set (use --role or run from a role directory)")
+	}
+	if len(opts.Command) == 0 {
+		return fmt.Errorf("no command given (usage: diffusion molecule exec -- <cmd> [args...])")
+	}
+	name := ContainerName(opts.Role)
+	if !containerRunning(name) {
+		return fmt.Errorf("container %s is not running; start it with 'diffusion molecule' first", name)
+	}
+	if err := execRun(ExecArgs(opts, hostUserMapping())); err != nil {
+		return fmt.Errorf("command failed in %s: %w", name, err)
+	}
+	return nil
+}
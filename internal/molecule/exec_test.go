@@ -0,0 +1,109 @@
+package molecule
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubExec replaces the container check and the docker call, recording the
+// docker arguments
+func stubExec(t *testing.T, running bool) *[][]string {
+	t.Helper()
+	origRunning, origRun := containerRunning, execRun
+	t.Cleanup(func() { containerRunning, execRun = origRunning, origRun })
+
+	var calls [][]string
+	containerRunning = func(string) bool { return running }
+	execRun = func(args []string) error {
+		calls = append(calls, args)
+		return nil
+	}
+	return &calls
+}
+
+func TestExecArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ExecOptions
+		hostUser string
+		want     []string
+	}{
+		{
+			name:     "role directory and host user",
+			opts:     ExecOptions{Role: "web", Org: "acme", Command: []string{"ansible-galaxy", "collection", "list"}},
+			hostUser: "1000:1000",
+			want:     []string{"exec", "-i", "-w", "/opt/molecule/acme.web", "--user", "1000:1000", "molecule-web", "ansible-galaxy", "collection", "list"},
+		},
+		{
+			name:     "tty",
+			opts:     ExecOptions{Role: "web", Org: "acme", TTY: true, Command: []string{"sh"}},
+			hostUser: "1000:1000",
+			want:     []string{"exec", "-i", "-t", "-w", "/opt/molecule/acme.web", "--user", "1000:1000", "molecule-web", "sh"},
+		},
+		{
+			name:     "root skips the user mapping",
+			opts:     ExecOptions{Role: "web", Org: "acme", Root: true, Command: []string{"id"}},
+			hostUser: "1000:1000",
+			want:     []string{"exec", "-i", "-w", "/opt/molecule/acme.web", "molecule-web", "id"},
+		},
+		{
+			name: "no host user on windows",
+			opts: ExecOptions{Role: "web", Org: "acme", Command: []string{"id"}},
+			want: []string{"exec", "-i", "-w", "/opt/molecule/acme.web", "molecule-web", "id"},
+		},
+		{
+			name: "relative workdir",
+			opts: ExecOptions{Role: "web", Org: "acme", Workdir: "molecule/default", Command: []string{"ls", "-la"}},
+			want: []string{"exec", "-i", "-w", "/opt/molecule/acme.web/molecule/default", "molecule-web", "ls", "-la"},
+		},
+		{
+			name: "absolute workdir",
+			opts: ExecOptions{Role: "web", Org: "acme", Workdir: "/tmp/", Command: []string{"ls"}},
+			want: []string{"exec", "-i", "-w", "/tmp", "molecule-web", "ls"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExecArgs(tt.opts, tt.hostUser); !slices.Equal(got, tt.want) {
+				t.Errorf("ExecArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecRunsInContainer(t *testing.T) {
+	calls := stubExec(t, true)
+
+	if err := Exec(ExecOptions{Role: "web", Org: "acme", Root: true, Command: []string{"id"}}); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	want := [][]string{{"exec", "-i", "-w", "/opt/molecule/acme.web", "molecule-web", "id"}}
+	if !slices.EqualFunc(*calls, want, slices.Equal[[]string]) {
+		t.Errorf("docker calls = %q, want %q", *calls, want)
+	}
+}
+
+func TestExecFailsWhenContainerNotRunning(t *testing.T) {
+	calls := stubExec(t, false)
+
+	err := Exec(ExecOptions{Role: "web", Org: "acme", Command: []string{"id"}})
+	if err == nil || !strings.Contains(err.Error(), "molecule-web is not running") {
+		t.Fatalf("Exec() error = %v, want a not running error", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("docker calls = %q, want none", *calls)
+	}
+}
+
+func TestExecRequiresRoleAndCommand(t *testing.T) {
+	stubExec(t, true)
+
+	if err := Exec(ExecOptions{Command: []string{"id"}}); err == nil {
+		t.Error("Exec() without a role succeeded, want an error")
+	}
+	if err := Exec(ExecOptions{Role: "web", Org: "acme"}); err == nil {
+		t.Error("Exec() without a command succeeded, want an error")
+	}
+}
@@ -0,0 +1,51 @@
+package molecule
+
+import (
+	"log"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// loadGalaxyCredentials is swapped in tests
+var loadGalaxyCredentials = secrets.LoadGalaxyCredentials
+
+// galaxyServerEnv returns the ansible-galaxy server configuration for a stored
+// Automation Hub login: the hub first, then public Galaxy for everything the
+// hub does not carry. ansible-galaxy reads ANSIBLE_GALAXY_SERVER_* like the
+// [galaxy_server.*] sections of ansible.cfg, so the token never reaches a file
+// in the container.
+func galaxyServerEnv(creds *secrets.GalaxyCredentials) []string {
+	if creds == nil || creds.URL == "" || creds.Token == "" {
+		return nil
+	}
+	env := []string{
+		"ANSIBLE_GALAXY_SERVER_LIST=automation_hub,release_galaxy",
+		"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_URL=" + creds.URL,
+		"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_TOKEN=" + creds.Token,
+	}
+	if creds.AuthURL != "" {
+		env = append(env, "ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_AUTH_URL="+creds.AuthURL)
+	}
+	return append(env, "ANSIBLE_GALAXY_SERVER_RELEASE_GALAXY_URL="+config.DefaultGalaxyServerURL)
+}
+
+// galaxyServerArgs returns the docker run -e arguments for the stored
+// Automation Hub login; a missing login adds nothing
+func galaxyServerArgs() []string {
+	creds, err := loadGalaxyCredentials()
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to load Automation Hub login: %v"+config.ColorReset, err)
+		return nil
+	}
+	env := galaxyServerEnv(creds)
+	if len(env) == 0 {
+		return nil
+	}
+	log.Printf(config.ColorGreen+"Passing Automation Hub server %s to ansible-galaxy"+config.ColorReset, creds.URL)
+	args := make([]string, 0, 2*len(env))
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	return args
+}
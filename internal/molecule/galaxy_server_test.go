@@ -0,0 +1,70 @@
+package molecule
+
+import (
+	"reflect"
+	"testing"
+
+	"diffusion/internal/secrets"
+)
+
+func TestGalaxyServerEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds *secrets.GalaxyCredentials
+		want  []string
+	}{
+		{name: "no login", creds: nil, want: nil},
+		{name: "no token", creds: &secrets.GalaxyCredentials{URL: "https://hub.example.com/"}, want: nil},
+		{
+			name: "console.redhat.com",
+			creds: &secrets.GalaxyCredentials{
+				URL:     "https://console.redhat.com/api/automation-hub/content/published/",
+				AuthURL: "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token",
+				Token:   "secret",
+			},
+			want: []string{
+				"ANSIBLE_GALAXY_SERVER_LIST=automation_hub,release_galaxy",
+				"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_URL=https://console.redhat.com/api/automation-hub/content/published/",
+				"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_TOKEN=secret",
+				"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_AUTH_URL=https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token",
+				"ANSIBLE_GALAXY_SERVER_RELEASE_GALAXY_URL=https://galaxy.ansible.com/",
+			},
+		},
+		{
+			name:  "private hub without SSO",
+			creds: &secrets.GalaxyCredentials{URL: "https://hub.example.com/api/galaxy/content/published/", Token: "secret"},
+			want: []string{
+				"ANSIBLE_GALAXY_SERVER_LIST=automation_hub,release_galaxy",
+				"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_URL=https://hub.example.com/api/galaxy/content/published/",
+				"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_TOKEN=secret",
+				"ANSIBLE_GALAXY_SERVER_RELEASE_GALAXY_URL=https://galaxy.ansible.com/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := galaxyServerEnv(tt.creds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("galaxyServerEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGalaxyServerArgs(t *testing.T) {
+	orig := loadGalaxyCredentials
+	t.Cleanup(func() { loadGalaxyCredentials = orig })
+
+	loadGalaxyCredentials = func() (*secrets.GalaxyCredentials, error) { return nil, nil }
+	if args := galaxyServerArgs(); len(args) != 0 {
+		t.Errorf("expected no args without a login, got %q", args)
+	}
+
+	loadGalaxyCredentials = func() (*secrets.GalaxyCredentials, error) {
+		return &secrets.GalaxyCredentials{URL: "https://hub.example.com/", Token: "secret"}, nil
+	}
+	args := galaxyServerArgs()
+	if len(args) != 8 || args[0] != "-e" || args[1] != "ANSIBLE_GALAXY_SERVER_LIST=automation_hub,release_galaxy" {
+		t.Errorf("unexpected args %q", args)
+	}
+}
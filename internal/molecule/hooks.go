@@ -0,0 +1,74 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// hookExec runs a hook shell command inside the molecule container; tests
+// replace it with a stub
+var hookExec = func(opts *MoleculeOptions, cmdStr string) error {
+	return utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// applyHooks puts the [hooks] snippets from diffusion.toml ahead of the
+// --pre-script/--post-script flags, so the config hooks run first
+func applyHooks(opts *MoleculeOptions, cfg *config.Config) {
+	if cfg.HooksConfig == nil {
+		return
+	}
+	opts.PreScripts = append(append([]string{}, cfg.HooksConfig.PreConverge...), opts.PreScripts...)
+	opts.PostScripts = append(append([]string{}, cfg.HooksConfig.PostVerify...), opts.PostScripts...)
+	opts.PostScriptFatal = opts.PostScriptFatal || cfg.HooksConfig.PostVerifyFatal
+}
+
+// hookCommand runs script from the role directory inside the container
+func hookCommand(roleDirName, script string) string {
+	return fmt.Sprintf("cd ./%s && %s", roleDirName, script)
+}
+
+// runPreScripts runs the pre-converge hooks in order and stops at the first
+// failure, so converge never starts on a half-prepared container
+func runPreScripts(opts *MoleculeOptions, roleDirName string) error {
+	for _, script := range opts.PreScripts {
+		log.Printf(config.ColorAquamarine+"Running pre-script: %s"+config.ColorReset, script)
+		if err := hookExec(opts, hookCommand(roleDirName, script)); err != nil {
+			log.Printf(config.ColorRed+"Pre-script failed: %v"+config.ColorReset, err)
+			return fmt.Errorf("pre-script %q failed: %w", script, err)
+		}
+	}
+	return nil
+}
+
+// runPostScripts runs every post-verify hook, even after one fails. Failures
+// are only logged unless opts.PostScriptFatal is set.
+func runPostScripts(opts *MoleculeOptions, roleDirName string) error {
+	var firstErr error
+	for _, script := range opts.PostScripts {
+		log.Printf(config.ColorAquamarine+"Running post-script: %s"+config.ColorReset, script)
+		if err := hookExec(opts, hookCommand(roleDirName, script)); err != nil {
+			if !opts.PostScriptFatal {
+				log.Printf(config.ColorYellow+"warning: post-script %q failed: %v"+config.ColorReset, script, err)
+				continue
+			}
+			log.Printf(config.ColorRed+"Post-script %q failed: %v"+config.ColorReset, script, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("post-script %q failed: %w", script, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// afterVerify runs the post-verify hooks once verify finished. They run after a
+// failed verify too, since collecting artifacts matters most then; the verify
+// error takes precedence over a hook error.
+func afterVerify(opts *MoleculeOptions, roleDirName string, verifyErr error) error {
+	if err := runPostScripts(opts, roleDirName); verifyErr == nil {
+		return err
+	}
+	return verifyErr
+}
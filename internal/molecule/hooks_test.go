@@ -0,0 +1,119 @@
+package molecule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// stubHookExec records hook commands into calls and fails those containing fail
+func stubHookExec(t *testing.T, calls *[]string, fail string) {
+	t.Helper()
+	orig := hookExec
+	t.Cleanup(func() { hookExec = orig })
+
+	hookExec = func(_ *MoleculeOptions, cmdStr string) error {
+		*calls = append(*calls, cmdStr)
+		if fail != "" && strings.Contains(cmdStr, fail) {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+}
+
+func TestPreScriptsRunInOrderBeforeConverge(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	stubHookExec(t, calls, "")
+	opts := &MoleculeOptions{RoleFlag: "web", PreScripts: []string{"./seed.sh", "touch ready"}}
+
+	if err := runConverge(opts, "acme.web"); err != nil {
+		t.Fatalf("runConverge() error = %v", err)
+	}
+	if len(*calls) != 3 {
+		t.Fatalf("calls = %q, want two pre-scripts and converge", *calls)
+	}
+	if (*calls)[0] != "cd ./acme.web && ./seed.sh" || (*calls)[1] != "cd ./acme.web && touch ready" {
+		t.Errorf("pre-scripts = %q, want them in order from the role directory", (*calls)[:2])
+	}
+	if !strings.Contains((*calls)[2], "molecule converge") {
+		t.Errorf("last call = %q, want converge", (*calls)[2])
+	}
+}
+
+func TestFailedPreScriptAbortsConverge(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	stubHookExec(t, calls, "seed")
+	opts := &MoleculeOptions{RoleFlag: "web", PreScripts: []string{"./seed.sh", "touch ready"}}
+
+	err := runConverge(opts, "acme.web")
+	if err == nil || !strings.Contains(err.Error(), "pre-script") {
+		t.Fatalf("runConverge() error = %v, want a pre-script failure", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("calls = %q, want nothing after the failed pre-script", *calls)
+	}
+}
+
+func TestPostScriptsRunAfterVerify(t *testing.T) {
+	_, phaseCalls := fakeVerifyOnly(t, true)
+	stubHookExec(t, phaseCalls, "")
+	t.Chdir(t.TempDir())
+
+	path, _ := os.Getwd()
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org", VerifyFlag: true, VerifyOnly: true, PostScripts: []string{"tar czf /tmp/logs.tgz logs"}}
+	cfg := &config.Config{TestsConfig: &config.TestsSettings{Type: config.TestsTypeDiffusion}}
+
+	if err := handleSubcommands(opts, cfg, path, "org.role", filepath.Join(path, config.MoleculeDir, "org.role")); err != nil {
+		t.Fatalf("handleSubcommands() error = %v", err)
+	}
+	if len(*phaseCalls) != 2 || !strings.Contains((*phaseCalls)[0], "molecule verify") || (*phaseCalls)[1] != "cd ./org.role && tar czf /tmp/logs.tgz logs" {
+		t.Errorf("calls = %q, want verify then the post-script", *phaseCalls)
+	}
+}
+
+func TestPostScriptFailures(t *testing.T) {
+	var calls []string
+	stubHookExec(t, &calls, "collect")
+	opts := &MoleculeOptions{PostScripts: []string{"collect", "cleanup"}}
+
+	if err := runPostScripts(opts, "acme.web"); err != nil {
+		t.Errorf("non-fatal post-script failure returned %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("calls = %q, want every post-script to run", calls)
+	}
+
+	calls = nil
+	opts.PostScriptFatal = true
+	if err := runPostScripts(opts, "acme.web"); err == nil {
+		t.Error("expected the post-script failure to be returned with PostScriptFatal")
+	}
+	if len(calls) != 2 {
+		t.Errorf("calls = %q, want every post-script to run", calls)
+	}
+}
+
+func TestApplyHooksPutsConfigFirst(t *testing.T) {
+	opts := &MoleculeOptions{PreScripts: []string{"flag-pre"}, PostScripts: []string{"flag-post"}}
+	cfg := &config.Config{HooksConfig: &config.HooksSettings{
+		PreConverge:     []string{"cfg-pre"},
+		PostVerify:      []string{"cfg-post"},
+		PostVerifyFatal: true,
+	}}
+
+	applyHooks(opts, cfg)
+	if !reflect.DeepEqual(opts.PreScripts, []string{"cfg-pre", "flag-pre"}) {
+		t.Errorf("PreScripts = %q", opts.PreScripts)
+	}
+	if !reflect.DeepEqual(opts.PostScripts, []string{"cfg-post", "flag-post"}) {
+		t.Errorf("PostScripts = %q", opts.PostScripts)
+	}
+	if !opts.PostScriptFatal {
+		t.Error("post_verify_fatal was not applied")
+	}
+}
@@ -0,0 +1,94 @@
+package molecule
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ansiEscape       = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	taskHeaderLine   = regexp.MustCompile(`^(?:TASK|RUNNING HANDLER) \[(.*)\]\s*\**$`)
+	changedTaskLine  = regexp.MustCompile(`^changed: \[([^\]]+)\]`)
+	playRecapHostRow = regexp.MustCompile(`^(\S+)\s*:\s*ok=\d+\s+changed=(\d+)`)
+)
+
+// ChangedTask is a task that reported changed on the idempotence run
+type ChangedTask struct {
+	Host string
+	Task string
+}
+
+// idempotenceReport summarizes the ansible output of a molecule idempotence run
+type idempotenceReport struct {
+	Changed      []ChangedTask  // Tasks with a changed: line, in output order
+	ChangedHosts map[string]int // changed= count per host from the PLAY RECAP
+}
+
+// parseIdempotenceOutput extracts the changed tasks and the recap counts from
+// ansible output. Colors and carriage returns (docker exec -t) are ignored, and
+// loop items or delegation of the same task are reported once per host.
+func parseIdempotenceOutput(output string) idempotenceReport {
+	report := idempotenceReport{ChangedHosts: map[string]int{}}
+	seen := map[ChangedTask]bool{}
+	task := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiEscape.ReplaceAllString(strings.ReplaceAll(scanner.Text(), "\r", ""), ""))
+
+		if m := taskHeaderLine.FindStringSubmatch(line); m != nil {
+			task = m[1]
+			continue
+		}
+		if m := changedTaskLine.FindStringSubmatch(line); m != nil && task != "" {
+			// "instance -> localhost" is a delegated task of instance
+			host, _, _ := strings.Cut(m[1], " -> ")
+			changed := ChangedTask{Host: host, Task: task}
+			if !seen[changed] {
+				seen[changed] = true
+				report.Changed = append(report.Changed, changed)
+			}
+			continue
+		}
+		if m := playRecapHostRow.FindStringSubmatch(line); m != nil {
+			count, _ := strconv.Atoi(m[2])
+			report.ChangedHosts[m[1]] += count
+		}
+	}
+	return report
+}
+
+// writeIdempotenceReport prints the tasks that were not idempotent. It reports
+// false when the output had neither changed tasks nor changed hosts, so the
+// caller can show the full output instead.
+func writeIdempotenceReport(w io.Writer, report idempotenceReport) bool {
+	if len(report.Changed) > 0 {
+		fmt.Fprintf(w, "Tasks changed on the second run (%d):\n", len(report.Changed))
+		for _, task := range report.Changed {
+			fmt.Fprintf(w, "  - [%s] %s\n", task.Host, task.Task)
+		}
+		return true
+	}
+
+	hosts := make([]string, 0, len(report.ChangedHosts))
+	for host, count := range report.ChangedHosts {
+		if count > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return false
+	}
+	sort.Strings(hosts)
+	fmt.Fprintln(w, "Hosts with changes on the second run:")
+	for _, host := range hosts {
+		fmt.Fprintf(w, "  - %s: changed=%d\n", host, report.ChangedHosts[host])
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package molecule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleIdempotenceOutput is trimmed output of a failing molecule idempotence run
+const sampleIdempotenceOutput = "INFO     Running default > idempotence\r\n" +
+	"\r\n" +
+	"PLAY [Converge] *****************************************************************\r\n" +
+	"\r\n" +
+	"TASK [Gathering Facts] **********************************************************\r\n" +
+	"ok: [instance]\r\n" +
+	"ok: [instance-2]\r\n" +
+	"\r\n" +
+	"TASK [nginx : Install nginx] ****************************************************\r\n" +
+	"ok: [instance]\r\n" +
+	"ok: [instance-2]\r\n" +
+	"\r\n" +
+	"TASK [nginx : Render vhosts] ****************************************************\r\n" +
+	"\x1b[0;33mchanged: [instance] => (item=default)\x1b[0m\r\n" +
+	"\x1b[0;33mchanged: [instance] => (item=api)\x1b[0m\r\n" +
+	"ok: [instance-2] => (item=default)\r\n" +
+	"\r\n" +
+	"TASK [nginx : Write timestamp [build]] *******************************************\r\n" +
+	"changed: [instance -> localhost]\r\n" +
+	"changed: [instance-2]\r\n" +
+	"\r\n" +
+	"RUNNING HANDLER [nginx : Reload nginx] *****************************************\r\n" +
+	"changed: [instance]\r\n" +
+	"\r\n" +
+	"PLAY RECAP *********************************************************************\r\n" +
+	"instance                   : ok=5    changed=3    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0\r\n" +
+	"instance-2                 : ok=4    changed=1    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0\r\n" +
+	"\r\n" +
+	"CRITICAL Idempotence test failed because of the following tasks:\r\n" +
+	"*  => nginx : Render vhosts\r\n"
+
+func TestParseIdempotenceOutput(t *testing.T) {
+	report := parseIdempotenceOutput(sampleIdempotenceOutput)
+
+	want := []ChangedTask{
+		{Host: "instance", Task: "nginx : Render vhosts"},
+		{Host: "instance", Task: "nginx : Write timestamp [build]"},
+		{Host: "instance-2", Task: "nginx : Write timestamp [build]"},
+		{Host: "instance", Task: "nginx : Reload nginx"},
+	}
+	if !reflect.DeepEqual(report.Changed, want) {
+		t.Errorf("Changed =\n  %v\nwant\n  %v", report.Changed, want)
+	}
+	wantHosts := map[string]int{"instance": 3, "instance-2": 1}
+	if !reflect.DeepEqual(report.ChangedHosts, wantHosts) {
+		t.Errorf("ChangedHosts = %v, want %v", report.ChangedHosts, wantHosts)
+	}
+}
+
+func TestParseIdempotenceOutputIdempotent(t *testing.T) {
+	output := "TASK [nginx : Install nginx] ***\nok: [instance]\n\nPLAY RECAP ***\ninstance : ok=2 changed=0 unreachable=0 failed=0\n"
+	report := parseIdempotenceOutput(output)
+	if len(report.Changed) != 0 {
+		t.Errorf("expected no changed tasks, got %v", report.Changed)
+	}
+	var buf bytes.Buffer
+	if writeIdempotenceReport(&buf, report) {
+		t.Errorf("expected nothing to report, got %q", buf.String())
+	}
+}
+
+func TestWriteIdempotenceReport(t *testing.T) {
+	var buf bytes.Buffer
+	if !writeIdempotenceReport(&buf, parseIdempotenceOutput(sampleIdempotenceOutput)) {
+		t.Fatal("expected a report for changed tasks")
+	}
+	out := buf.String()
+	for _, want := range []string{"(4)", "  - [instance] nginx : Render vhosts\n", "  - [instance-2] nginx : Write timestamp [build]\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+
+	// Only the recap is known: fall back to the changed hosts
+	buf.Reset()
+	report := idempotenceReport{ChangedHosts: map[string]int{"b": 1, "a": 2, "c": 0}}
+	if !writeIdempotenceReport(&buf, report) {
+		t.Fatal("expected a report for changed hosts")
+	}
+	if want := "Hosts with changes on the second run:\n  - a: changed=2\n  - b: changed=1\n"; buf.String() != want {
+		t.Errorf("report = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunIdempotenceCapturesOutput(t *testing.T) {
+	origExec := phaseExec
+	t.Cleanup(func() { phaseExec = origExec })
+
+	var captured bool
+	phaseExec = func(_ context.Context, opts *MoleculeOptions, _ string) error {
+		if opts.phaseOutput == nil {
+			return errors.New("idempotence output is not captured")
+		}
+		captured = true
+		fmt.Fprint(opts.phaseOutput, sampleIdempotenceOutput)
+		return errors.New("exit status 1")
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "nginx"}
+	err := runIdempotence(opts, "org.nginx")
+	if err == nil || !strings.Contains(err.Error(), "exit status 1") {
+		t.Fatalf("runIdempotence() error = %v, want the phase error", err)
+	}
+	if !captured {
+		t.Error("phase output was not captured")
+	}
+	if opts.phaseOutput != nil {
+		t.Error("phaseOutput left set after idempotence")
+	}
+}
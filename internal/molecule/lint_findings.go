@@ -0,0 +1,222 @@
+package molecule
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lint output formats for --format; text streams the linters' own output
+const (
+	LintFormatText   = "text"
+	LintFormatGitHub = "github"
+	LintFormatJSON   = "json"
+)
+
+// yamllintParsableLine matches yamllint -f parsable:
+// ./tasks/main.yml:3:1: [warning] missing document start "---" (document-start)
+var yamllintParsableLine = regexp.MustCompile(`^(.+?):(\d+):(\d+): \[(\w+)\] (.*?)(?: \(([\w-]+)\))?$`)
+
+// LintFinding is one problem reported by yamllint or ansible-lint
+type LintFinding struct {
+	Tool    string `json:"tool"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Level   string `json:"level"` // error or warning
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidateLintFormat rejects unknown --format values
+func ValidateLintFormat(format string) error {
+	switch format {
+	case "", LintFormatText, LintFormatGitHub, LintFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid lint format %q (valid: %s, %s, %s)", format, LintFormatText, LintFormatGitHub, LintFormatJSON)
+}
+
+// StructuredLintFormat reports whether the format needs the linters' parsable output
+func StructuredLintFormat(format string) bool {
+	return format == LintFormatGitHub || format == LintFormatJSON
+}
+
+// LintFormatArgs returns the arguments that make a linter print the output
+// ParseLintOutput understands
+func LintFormatArgs(tool string) []string {
+	switch tool {
+	case "yamllint":
+		return []string{"-f", "parsable"}
+	case "ansible-lint":
+		return []string{"-f", "json", "--nocolor"}
+	}
+	return nil
+}
+
+// ParseLintOutput parses the captured output of a linter run with LintFormatArgs
+func ParseLintOutput(tool, output string) ([]LintFinding, error) {
+	switch tool {
+	case "yamllint":
+		return parseYamllintOutput(output), nil
+	case "ansible-lint":
+		return parseAnsibleLintOutput(output)
+	}
+	return nil, fmt.Errorf("unknown linter %q", tool)
+}
+
+// parseYamllintOutput reads yamllint -f parsable lines; other lines are ignored
+func parseYamllintOutput(output string) []LintFinding {
+	var findings []LintFinding
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiEscape.ReplaceAllString(strings.ReplaceAll(scanner.Text(), "\r", ""), ""))
+		m := yamllintParsableLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		findings = append(findings, LintFinding{
+			Tool:    "yamllint",
+			File:    strings.TrimPrefix(m[1], "./"),
+			Line:    lineNo,
+			Column:  col,
+			Level:   m[4],
+			Rule:    m[6],
+			Message: m[5],
+		})
+	}
+	return findings
+}
+
+// ansibleLintIssue is one entry of ansible-lint -f json (Code Climate format)
+type ansibleLintIssue struct {
+	CheckName   string `json:"check_name"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Level       string `json:"level"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin json.RawMessage `json:"begin"` // A line number, or {"line": n, "column": n}
+		} `json:"lines"`
+		Positions struct {
+			Begin struct {
+				Line   int `json:"line"`
+				Column int `json:"column"`
+			} `json:"begin"`
+		} `json:"positions"`
+	} `json:"location"`
+}
+
+// parseAnsibleLintOutput finds the JSON array in ansible-lint -f json output.
+// The captured output also holds the summary ansible-lint prints on stderr, so
+// only the line that is the array is decoded.
+func parseAnsibleLintOutput(output string) ([]LintFinding, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiEscape.ReplaceAllString(strings.ReplaceAll(scanner.Text(), "\r", ""), ""))
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+		var issues []ansibleLintIssue
+		if err := json.Unmarshal([]byte(line), &issues); err != nil {
+			continue
+		}
+		findings := make([]LintFinding, 0, len(issues))
+		for _, issue := range issues {
+			findings = append(findings, issue.finding())
+		}
+		return findings, nil
+	}
+	return nil, fmt.Errorf("no ansible-lint JSON report in the output")
+}
+
+func (issue ansibleLintIssue) finding() LintFinding {
+	finding := LintFinding{
+		Tool:    "ansible-lint",
+		File:    strings.TrimPrefix(issue.Location.Path, "./"),
+		Level:   issue.Level,
+		Rule:    issue.CheckName,
+		Message: issue.Description,
+	}
+	if pos := issue.Location.Positions.Begin; pos.Line > 0 {
+		finding.Line, finding.Column = pos.Line, pos.Column
+	} else if len(issue.Location.Lines.Begin) > 0 {
+		var line int
+		if err := json.Unmarshal(issue.Location.Lines.Begin, &line); err == nil {
+			finding.Line = line
+		} else {
+			var begin struct {
+				Line   int `json:"line"`
+				Column int `json:"column"`
+			}
+			if json.Unmarshal(issue.Location.Lines.Begin, &begin) == nil {
+				finding.Line, finding.Column = begin.Line, begin.Column
+			}
+		}
+	}
+	if finding.Level == "" {
+		// Older releases only set the Code Climate severity
+		finding.Level = "error"
+		if issue.Severity == "info" || issue.Severity == "minor" {
+			finding.Level = "warning"
+		}
+	}
+	return finding
+}
+
+// WriteLintFindings prints findings as a JSON array or as GitHub Actions
+// workflow commands (::error file=...,line=...::message)
+func WriteLintFindings(w io.Writer, format string, findings []LintFinding) error {
+	switch format {
+	case LintFormatJSON:
+		if findings == nil {
+			findings = []LintFinding{}
+		}
+		out, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case LintFormatGitHub:
+		for _, f := range findings {
+			command := "error"
+			if f.Level == "warning" {
+				command = "warning"
+			}
+			props := []string{"file=" + escapeAnnotationProperty(f.File), "line=" + strconv.Itoa(f.Line)}
+			if f.Column > 0 {
+				props = append(props, "col="+strconv.Itoa(f.Column))
+			}
+			title := f.Tool
+			if f.Rule != "" {
+				title += " " + f.Rule
+			}
+			props = append(props, "title="+escapeAnnotationProperty(title))
+			if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeAnnotationData(f.Message)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("lint format %q has no structured output", format)
+}
+
+// escapeAnnotationData escapes a workflow command message
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a workflow command property value
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
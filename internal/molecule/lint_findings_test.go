@@ -0,0 +1,149 @@
+package molecule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sampleYamllintOutput is yamllint -f parsable output as captured through docker exec -t
+const sampleYamllintOutput = "./tasks/main.yml:1:1: [warning] missing document start \"---\" (document-start)\r\n" +
+	"./tasks/main.yml:12:81: [error] line too long (95 > 80 characters) (line-length)\r\n" +
+	"defaults/main.yml:4:5: [error] syntax error: mapping values are not allowed here\r\n"
+
+// sampleAnsibleLintOutput is ansible-lint -f json --nocolor output with the
+// summary it prints on stderr merged in
+const sampleAnsibleLintOutput = `[{"type":"issue","check_name":"yaml[truthy]","categories":["formatting","yaml"],"url":"https://ansible.readthedocs.io/projects/lint/rules/yaml/","severity":"minor","level":"warning","description":"Truthy value should be one of [false, true]","fingerprint":"abc","location":{"path":"tasks/main.yml","lines":{"begin":7}}},` +
+	`{"type":"issue","check_name":"fqcn[action-core]","categories":["formatting"],"severity":"major","description":"Use FQCN for builtin module actions (shell).","fingerprint":"def","location":{"path":"handlers/main.yml","positions":{"begin":{"line":3,"column":5}}}}]` + "\n" +
+	"Failed: 2 failure(s), 0 warning(s) on 5 files. Last profile that met the validation criteria was 'min'.\n"
+
+func TestParseYamllintOutput(t *testing.T) {
+	findings, err := ParseLintOutput("yamllint", sampleYamllintOutput)
+	if err != nil {
+		t.Fatalf("ParseLintOutput failed: %v", err)
+	}
+	want := []LintFinding{
+		{Tool: "yamllint", File: "tasks/main.yml", Line: 1, Column: 1, Level: "warning", Rule: "document-start", Message: `missing document start "---"`},
+		{Tool: "yamllint", File: "tasks/main.yml", Line: 12, Column: 81, Level: "error", Rule: "line-length", Message: "line too long (95 > 80 characters)"},
+		{Tool: "yamllint", File: "defaults/main.yml", Line: 4, Column: 5, Level: "error", Message: "syntax error: mapping values are not allowed here"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+}
+
+func TestParseAnsibleLintOutput(t *testing.T) {
+	findings, err := ParseLintOutput("ansible-lint", sampleAnsibleLintOutput)
+	if err != nil {
+		t.Fatalf("ParseLintOutput failed: %v", err)
+	}
+	want := []LintFinding{
+		{Tool: "ansible-lint", File: "tasks/main.yml", Line: 7, Level: "warning", Rule: "yaml[truthy]", Message: "Truthy value should be one of [false, true]"},
+		// No level: derived from the severity
+		{Tool: "ansible-lint", File: "handlers/main.yml", Line: 3, Column: 5, Level: "error", Rule: "fqcn[action-core]", Message: "Use FQCN for builtin module actions (shell)."},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+}
+
+func TestParseAnsibleLintOutputClean(t *testing.T) {
+	findings, err := ParseLintOutput("ansible-lint", "[]\nPassed: 0 failure(s), 0 warning(s) on 5 files.\n")
+	if err != nil || len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v, %v", findings, err)
+	}
+	if _, err := ParseLintOutput("ansible-lint", "CRITICAL Couldn't parse task at tasks/main.yml:3\n"); err == nil {
+		t.Error("expected an error when the output has no JSON report")
+	}
+}
+
+func TestWriteLintFindingsGitHub(t *testing.T) {
+	findings := []LintFinding{
+		{Tool: "yamllint", File: "tasks/main.yml", Line: 12, Column: 81, Level: "error", Rule: "line-length", Message: "line too long"},
+		{Tool: "ansible-lint", File: "tasks/a,b.yml", Line: 7, Level: "warning", Rule: "yaml[truthy]", Message: "100% wrong\nvalue"},
+	}
+	var buf bytes.Buffer
+	if err := WriteLintFindings(&buf, LintFormatGitHub, findings); err != nil {
+		t.Fatalf("WriteLintFindings failed: %v", err)
+	}
+	want := "::error file=tasks/main.yml,line=12,col=81,title=yamllint line-length::line too long\n" +
+		"::warning file=tasks/a%2Cb.yml,line=7,title=ansible-lint yaml[truthy]::100%25 wrong%0Avalue\n"
+	if buf.String() != want {
+		t.Errorf("annotations =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteLintFindingsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLintFindings(&buf, LintFormatJSON, nil); err != nil {
+		t.Fatalf("WriteLintFindings failed: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("no findings should print an empty array, got %q", buf.String())
+	}
+
+	buf.Reset()
+	in := []LintFinding{{Tool: "yamllint", File: "a.yml", Line: 1, Level: "error", Message: "m"}}
+	if err := WriteLintFindings(&buf, LintFormatJSON, in); err != nil {
+		t.Fatalf("WriteLintFindings failed: %v", err)
+	}
+	var out []LintFinding
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || len(out) != 1 || out[0] != in[0] {
+		t.Errorf("round trip = %+v, %v", out, err)
+	}
+}
+
+func TestValidateLintFormat(t *testing.T) {
+	for _, format := range []string{"", "text", "github", "json"} {
+		if err := ValidateLintFormat(format); err != nil {
+			t.Errorf("ValidateLintFormat(%q) = %v", format, err)
+		}
+	}
+	if err := ValidateLintFormat("sarif"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestRunLintStructuredRunsBothLinters(t *testing.T) {
+	orig := lintCapture
+	t.Cleanup(func() { lintCapture = orig })
+
+	var cmds []string
+	lintCapture = func(_ *MoleculeOptions, cmdStr string, w io.Writer) error {
+		cmds = append(cmds, cmdStr)
+		if strings.Contains(cmdStr, "yamllint") {
+			io.WriteString(w, sampleYamllintOutput)
+			return errors.New("exit status 1")
+		}
+		io.WriteString(w, "[]\n")
+		return nil
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "web", LintFormat: LintFormatJSON}
+	err := runLintStructured(opts, "acme.web")
+	if err == nil || !strings.Contains(err.Error(), "yamllint") {
+		t.Fatalf("expected yamllint failure, got %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("expected both linters to run, got %v", cmds)
+	}
+	if !strings.Contains(cmds[0], "cd ./acme.web && yamllint -c .yamllint -f parsable .") {
+		t.Errorf("unexpected yamllint command: %s", cmds[0])
+	}
+	if !strings.Contains(cmds[1], "ansible-lint -c .ansible-lint -f json --nocolor") {
+		t.Errorf("unexpected ansible-lint command: %s", cmds[1])
+	}
+}
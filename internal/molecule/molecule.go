@@ -1,7 +1,10 @@
 package molecule
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,6 +12,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,17 +30,59 @@ type MoleculeOptions struct {
 	RoleFlag        string
 	OrgFlag         string
 	RoleScenario    string
+	AllScenarios    bool // Run the selected phase for every folder under scenarios/
 	TagFlag         string
+	LimitFlag       string     // Ansible host pattern passed to converge as --limit
+	Platforms       []Platform // Runtime platform overrides exported as MOLECULE_PLATFORM_* env
+	EnvFileVars     []EnvVar   // Extra container env loaded from --env-file, in file order
+	PreScripts      []string   // Shell snippets run in the role directory before converge; [hooks] pre_converge comes first
+	PostScripts     []string   // Shell snippets run in the role directory after verify; [hooks] post_verify comes first
+	PostScriptFatal bool       // Fail the run when a post-script fails instead of only logging it
+	PullPolicy      string     // docker run --pull override; empty uses [container] pull_policy
+	BuildContext    string     // Build the image from this directory instead of pulling; empty uses [container] build_context
+	RegistryServer  string     // Overrides [container_registry] registry_server for this run
+	RegistryImage   string     // Overrides [container_registry] molecule_container_name for this run
+	RegistryTag     string     // Overrides [container_registry] molecule_container_tag for this run
+	DNS             []string   // docker run --dns servers; empty uses [container] dns
+	DNSSearch       []string   // docker run --dns-search domains; empty uses [container] dns_search
+	Phases          []string   // Phases from --only/--phases, run in order; empty falls back to the phase flags
 	ConvergeFlag    bool
+	StepFlag        bool // Pass ansible-playbook --step to converge (interactive, non-CI only)
+	DiffFlag        bool // Show ansible file diffs during converge and idempotence (ANSIBLE_DIFF_ALWAYS)
+	PrepareFlag     bool
 	VerifyFlag      bool
+	VerifyOnly      bool // Verify the converged instance without copying role data or re-provisioning existing tests
 	TestsOverWrite  bool
+	KeepTestsCache  bool // Use cached tests repositories as they are, without pulling
+	RefreshTests    bool // Clone the tests repositories again instead of pulling the cached clones
 	LintFlag        bool
+	LintFormat      string // --lint output: text (default) streams the linters, github/json print parsed findings
 	IdempotenceFlag bool
 	DestroyFlag     bool
+	DestroyFirst    bool // Run molecule destroy and create before converge, keeping the container
+	DestroyOnFail   bool // Run molecule destroy and remove the container when a phase fails
 	WipeFlag        bool
+	VerifyCopy      bool // Compare the copied role data with its source (sizes and SHA-256) after each copy
+	KeepCache       bool // --wipe: make sure the cache is on the host before the container is removed
+	PurgeCache      bool // --wipe: also delete the role cache directory
 	CIMode          bool
 	OidcFlag        bool
+	NoCache         bool // Skip the role cache (mounts, copies, DinD images) for this run; config is untouched
 	ForceFlag       bool
+	KeepFlag        bool
+	SkipPermFix     bool // --fix-permissions=false: no chown of /opt/molecule after converge and role init
+	LogsFlag        bool
+	Verbosity       int           // -v count: passed to molecule/ansible as -v..-vvv; any level streams the full idempotence output
+	Timeout         time.Duration // Upper bound for converge/verify/idempotence/destroy; 0 disables
+	Retry           int           // Extra attempts for a failing converge/verify/idempotence
+	OnlyChangedFlag bool          // Skip converge when role inputs match the last successful converge
+	SkipIfUnchanged bool          // Exit early when no role inputs changed since BaseRef (git diff <base>...HEAD)
+	BaseRef         string        // Base ref for SkipIfUnchanged
+
+	Results *ResultCollector // Records phase results for --report-json; nil records nothing
+
+	inputHash   string    // Role input hash computed for --only-changed, stored after a successful converge
+	phaseOutput io.Writer // Receives a copy of the phase output while set (idempotence)
 }
 
 // scenarioFlag returns " -s <scenario>" if scenario is non-default, otherwise empty string.
@@ -47,10 +93,113 @@ func scenarioFlag(opts *MoleculeOptions) string {
 	return ""
 }
 
+// convergeCommand builds the shell command for molecule converge, including the
+// forced requirements install, tag and --limit/--step passthrough.
+func convergeCommand(opts *MoleculeOptions, roleDirName string) string {
+	galaxyInstall := ""
+	if opts.ForceFlag {
+		galaxyInstall = fmt.Sprintf("ansible-galaxy install --force -r molecule/%s/requirements.yml 2>/dev/null || true && ", activeScenario(opts))
+	}
+	tagEnv := ""
+	if opts.TagFlag != "" {
+		tagEnv = fmt.Sprintf("ANSIBLE_RUN_TAGS=%s ", opts.TagFlag)
+	}
+	var playbookArgs []string
+	if opts.LimitFlag != "" {
+		playbookArgs = append(playbookArgs, "--limit", shellQuote(opts.LimitFlag))
+	}
+	if opts.StepFlag {
+		playbookArgs = append(playbookArgs, "--step")
+	}
+	passthrough := ""
+	if len(playbookArgs) > 0 {
+		passthrough = " -- " + strings.Join(playbookArgs, " ")
+	}
+	return moleculeCommand(opts, roleDirName, galaxyInstall+tagEnv+diffEnv(opts), "converge") + passthrough
+}
+
+// validateStep rejects --step where ansible cannot prompt: in CI (no TTY) or
+// without a converge to step through
+func validateStep(opts *MoleculeOptions) error {
+	if !opts.StepFlag {
+		return nil
+	}
+	if opts.CIMode {
+		return fmt.Errorf("--step needs an interactive terminal and cannot be used with --ci")
+	}
+	if !isConverging(opts) {
+		return fmt.Errorf("--step only applies to converge (use --converge or the default flow)")
+	}
+	return nil
+}
+
+// isConverging reports whether the run converges: a converge phase or the default flow
+func isConverging(opts *MoleculeOptions) bool {
+	return !hasPhaseFlag(opts) || runsPhase(opts, PhaseConverge)
+}
+
+// shellQuote quotes s for /bin/sh so patterns like 'web:!web3' pass through unchanged
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// activeScenario returns the scenario selected with --scenario, or the default one.
+func activeScenario(opts *MoleculeOptions) string {
+	if opts.RoleScenario != "" {
+		return opts.RoleScenario
+	}
+	return config.DefaultScenario
+}
+
+// moleculeYmlExec runs the molecule.yml existence check; tests replace it.
+var moleculeYmlExec = func(opts *MoleculeOptions, args ...string) error {
+	return utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, args...)
+}
+
+// checkMoleculeYml verifies that the active scenario's molecule.yml exists inside
+// the container, listing the role directory to help debugging when it does not.
+func checkMoleculeYml(opts *MoleculeOptions, roleDirName string) error {
+	scenarioDir := fmt.Sprintf("/opt/molecule/%s/molecule/%s", roleDirName, activeScenario(opts))
+	log.Printf("Checking molecule.yml in container...")
+	if err := moleculeYmlExec(opts, "-c", fmt.Sprintf("ls -la %s/molecule.yml", scenarioDir)); err != nil {
+		log.Printf(config.ColorRed+"molecule.yml not found in container at %s/"+config.ColorReset, scenarioDir)
+		log.Printf(config.ColorYellow + "Listing container directory structure:" + config.ColorReset)
+		// Best-effort debug listing — output shown regardless of success/failure.
+		_ = moleculeYmlExec(opts, "-c", fmt.Sprintf("ls -laR /opt/molecule/%s/", roleDirName))
+		return fmt.Errorf("molecule.yml not found in container at %s/", scenarioDir)
+	}
+	return nil
+}
+
 // RunMolecule is the core function that implements the molecule workflow.
 // It handles wipe, converge, lint, verify, idempotence, destroy and the
 // default create/converge flow.
 func RunMolecule(opts *MoleculeOptions) error {
+	if err := validatePhases(opts); err != nil {
+		return err
+	}
+	if err := validateStep(opts); err != nil {
+		return err
+	}
+	if err := validateDestroyFirst(opts); err != nil {
+		return err
+	}
+	if err := validateDestroyOnFailure(opts); err != nil {
+		return err
+	}
+	if err := validateDiff(opts); err != nil {
+		return err
+	}
+	if err := ValidateLintFormat(opts.LintFormat); err != nil {
+		return err
+	}
+	if err := validateWipeCache(opts); err != nil {
+		return err
+	}
+	if err := validateVerifyCopy(opts); err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Printf(config.ColorYellow+"warning loading config: %v"+config.ColorReset, err)
@@ -61,6 +210,11 @@ func RunMolecule(opts *MoleculeOptions) error {
 	if cfg.ContainerRegistry == nil {
 		cfg.ContainerRegistry = &config.ContainerRegistry{}
 	}
+	applyHooks(opts, cfg)
+	applyRegistryOverrides(opts, cfg)
+	if opts.NoCache {
+		log.Printf(config.ColorYellow + "Cache bypassed for this run (--no-cache): no cache mounts, copies or DinD image loads" + config.ColorReset)
+	}
 
 	// prepare path
 	path, err := os.Getwd()
@@ -77,58 +231,138 @@ func RunMolecule(opts *MoleculeOptions) error {
 		return handleWipe(opts, cfg, roleDirName, roleMoleculePath)
 	}
 
-	// handle converge/lint/verify/idempotence/destroy
-	if opts.ConvergeFlag || opts.LintFlag || opts.VerifyFlag || opts.IdempotenceFlag || opts.DestroyFlag {
-		return handleSubcommands(opts, cfg, path, roleDirName, roleMoleculePath)
+	// handle logs
+	if opts.LogsFlag {
+		return handleLogs(opts)
+	}
+
+	// handle --skip-if-unchanged: nothing to test when the diff against the base misses the role
+	if opts.SkipIfUnchanged {
+		changed, err := utils.RoleChangedSince(path, opts.BaseRef)
+		if err != nil {
+			return fmt.Errorf("failed to detect role changes: %w", err)
+		}
+		if !changed {
+			fmt.Printf(config.ColorAquamarine+"%s: no role changes since %s, skipping\n"+config.ColorReset, roleDirName, opts.BaseRef)
+			return nil
+		}
+	}
+
+	// handle --only-changed for runs that converge (--converge or the default flow)
+	if opts.OnlyChangedFlag && isConverging(opts) {
+		unchanged, err := checkUnchanged(opts, path, roleDirName)
+		if err != nil {
+			return fmt.Errorf("failed to hash role inputs: %w", err)
+		}
+		if unchanged {
+			fmt.Printf(config.ColorAquamarine+"%s: no changes, skipping\n"+config.ColorReset, roleDirName)
+			return nil
+		}
 	}
 
-	// default flow: create/run container if not exists, copy data, converge
-	return handleDefaultFlow(opts, cfg, path, roleDirName, roleMoleculePath)
+	return runWithFailureCleanup(opts, roleDirName, func() error {
+		// handle --all-scenarios: run the selected phase (or default flow) for each scenario
+		if opts.AllScenarios {
+			return runAllScenarios(opts, cfg, path, roleDirName, roleMoleculePath)
+		}
+
+		// handle --only/--phases and the phase flags
+		if hasPhaseFlag(opts) {
+			return handleSubcommands(opts, cfg, path, roleDirName, roleMoleculePath)
+		}
+
+		// default flow: create/run container if not exists, copy data, converge
+		return handleDefaultFlow(opts, cfg, path, roleDirName, roleMoleculePath)
+	})
 }
 
 // handleWipe destroys the molecule container and removes the role folder.
-// Before removing the container, it saves DinD images and (—CI mode) copies
-// the cache out of the container back to the host.
+// Before removing the container, it saves DinD images and (—CI mode or with
+// --keep-cache) copies the cache out of the container back to the host. With
+// --purge-cache nothing is saved and the role cache directory is deleted.
 func handleWipe(opts *MoleculeOptions, cfg *config.Config, roleDirName, roleMoleculePath string) error {
-	log.Printf(config.ColorAquamarine+"Wiping: running molecule destroy, removing container molecule-%s and folder %s\n"+config.ColorReset, opts.RoleFlag, roleMoleculePath)
+	log.Printf(config.ColorAquamarine+"Wiping: running molecule destroy, removing container %s and folder %s\n"+config.ColorReset, ContainerName(opts.RoleFlag), roleMoleculePath)
 
 	// Run molecule destroy inside the container first
 	roleDir := utils.GetRoleDirName(opts.OrgFlag, opts.RoleFlag)
 	// Best-effort: container may already be destroyed or never created.
 	_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "bash", opts.CIMode, "-c", fmt.Sprintf("cd ./%s && molecule destroy%s", roleDir, scenarioFlag(opts)))
 
-	// Save DinD images before removing the container
-	if cfg.CacheConfig != nil && cfg.CacheConfig.Enabled && cfg.CacheConfig.DockerCache {
-		saveDinDImages(opts)
+	// Save DinD images before removing the container (pointless when purging)
+	if !opts.PurgeCache && cacheEnabled(opts, cfg) && cfg.CacheConfig.DockerCache {
+		if cfg.CacheConfig.DockerPerImage {
+			saveDinDImagesPerImage(opts)
+		} else {
+			saveDinDImages(opts)
+		}
 	}
 
 	// Windows: save UV cache back to precache (NTFS mount) before container removal
-	if !opts.CIMode && runtime.GOOS == "windows" && cfg.CacheConfig != nil && cfg.CacheConfig.Enabled && cfg.CacheConfig.UVCache {
+	if !opts.PurgeCache && !opts.CIMode && runtime.GOOS == "windows" && cacheEnabled(opts, cfg) && cfg.CacheConfig.UVCache {
 		saveUVCacheToPrecache(opts)
 	}
 
-	// CI mode: copy cache from container back to host before docker rm
-	if opts.CIMode {
-		copyCacheFromContainer(opts, cfg)
-	}
+	// CI mode (or --keep-cache without mounts): copy cache from container back to host before docker rm
+	saveCacheBeforeWipe(opts, cfg)
 
-	// Remove the container
+	// Remove the container (also covers containers started with --keep)
 	// Best-effort: -f flag means failure is safe to ignore (container may not exist).
-	_ = utils.RunCommandHide(opts.CIMode, "docker", "rm", fmt.Sprintf("molecule-%s", opts.RoleFlag), "-f")
+	_ = utils.RunCommandHide(opts.CIMode, "docker", "rm", ContainerName(opts.RoleFlag), "-f")
 
 	// Remove the role folder
 	if err := os.RemoveAll(roleMoleculePath); err != nil {
 		log.Printf(config.ColorYellow+"warning: failed remove role path: %v"+config.ColorReset, err)
 	}
+
+	if opts.PurgeCache {
+		return purgeCache(cfg)
+	}
+
+	// DinD tarballs and CI copies were just written, so report the resulting size
+	reportCacheSize(opts, cfg)
+	return nil
+}
+
+// handleLogs follows the molecule container logs until interrupted.
+func handleLogs(opts *MoleculeOptions) error {
+	cmd := exec.Command("docker", "logs", "-f", ContainerName(opts.RoleFlag))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to follow logs of container %s: %w", ContainerName(opts.RoleFlag), err)
+	}
 	return nil
 }
 
-// handleSubcommands handles --converge, --lint, --verify, --idempotence, --destroy flags.
+// printKeepHint tells the user how to get into a container that was kept
+// after a failed run. It is a no-op unless --keep is set.
+func printKeepHint(opts *MoleculeOptions) {
+	if !opts.KeepFlag {
+		return
+	}
+	log.Printf(config.ColorYellow+"Container kept for debugging. To reproduce, run:\n  docker exec -it %s bash\nUse 'diffusion molecule --wipe' to remove it."+config.ColorReset, ContainerName(opts.RoleFlag))
+}
+
+// handleSubcommands handles --prepare, --converge, --lint, --verify, --idempotence, --destroy flags.
+// --prepare runs first and can be combined with the other phases; of those, the first set one runs.
 func handleSubcommands(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+	if opts.VerifyOnly {
+		done, err := runVerifyOnly(opts, roleDirName)
+		if done {
+			return afterVerify(opts, roleDirName, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	if !opts.CIMode {
 		if err := utils.CopyRoleData(path, roleMoleculePath, opts.CIMode); err != nil {
 			log.Printf(config.ColorYellow+"warning copying data: %v"+config.ColorReset, err)
 		}
+		if err := verifyRoleCopy(opts, path, roleMoleculePath); err != nil {
+			return err
+		}
 		metaFixCmd := fmt.Sprintf(
 			`if [ -f /opt/molecule/%s/meta/main.yml ]; then sed -i 's/^\(\s*namespace:\s*\).*/\1%s/' /opt/molecule/%s/meta/main.yml; fi`,
 			roleDirName, opts.OrgFlag, roleDirName)
@@ -146,37 +380,31 @@ func handleSubcommands(opts *MoleculeOptions, cfg *config.Config, path, roleDirN
 	if err != nil {
 		log.Printf(config.ColorYellow+"warning exporting linters: %v"+config.ColorReset, err)
 	}
-
-	// Determine scenario name for tests directory
-	scenario := config.DefaultScenario
-	if opts.RoleScenario != "" {
-		scenario = opts.RoleScenario
+	if err := utils.ExportAnsibleCfg(cfg, linters, opts.CIMode, opts.RoleFlag, opts.OrgFlag); err != nil {
+		log.Printf(config.ColorYellow+"warning exporting ansible.cfg: %v"+config.ColorReset, err)
 	}
 
 	// Create tests directory for verify
-	moleculeDefaultTestsPath := fmt.Sprintf("molecule/%s.%s/molecule/%s/tests", opts.OrgFlag, opts.RoleFlag, scenario)
-	if err := os.MkdirAll(moleculeDefaultTestsPath, 0o755); err != nil {
-		log.Printf(config.ColorYellow+"warning: cannot create scenario tests dir: %v"+config.ColorReset, err)
-	}
-	defaultTestsDir := moleculeDefaultTestsPath
-	log.Printf("Default tests dir: %s", defaultTestsDir)
+	log.Printf("Default tests dir: %s", ensureScenarioTestsDir(opts))
 
-	if opts.ConvergeFlag {
-		return runConverge(opts, roleDirName)
-	}
-	if opts.LintFlag {
-		return runLint(opts, roleDirName)
-	}
-	if opts.VerifyFlag {
-		return runVerify(opts, cfg, path, roleDirName, roleMoleculePath, scenario)
-	}
-	if opts.IdempotenceFlag {
-		return runIdempotence(opts, roleDirName)
-	}
-	if opts.DestroyFlag {
-		return runDestroy(opts, roleDirName)
+	// --destroy-first resets the instances before the first phase so all start fresh
+	if opts.DestroyFirst && runsPhase(opts, PhaseConverge) {
+		if err := destroyAndCreate(opts, roleDirName); err != nil {
+			return err
+		}
 	}
+	return runPhases(opts, cfg, path, roleDirName, roleMoleculePath)
+}
 
+// runPrepare runs molecule prepare (the scenario's prepare.yml) inside the container.
+func runPrepare(opts *MoleculeOptions, roleDirName string) error {
+	cmdStr := moleculeCommand(opts, roleDirName, "", "prepare")
+	if err := execMoleculePhase(opts, roleDirName, "prepare", cmdStr); err != nil {
+		log.Printf(config.ColorRed+"Prepare failed: %v"+config.ColorReset, err)
+		printKeepHint(opts)
+		return fmt.Errorf("prepare failed: %w", err)
+	}
+	log.Printf(config.ColorGreen + "Prepare Done Successfully!" + config.ColorReset)
 	return nil
 }
 
@@ -184,54 +412,145 @@ func handleSubcommands(opts *MoleculeOptions, cfg *config.Config, path, roleDirN
 func runConverge(opts *MoleculeOptions, roleDirName string) error {
 	// Verify molecule.yml exists inside container before running
 	if opts.CIMode {
-		checkCmd := fmt.Sprintf("ls -la /opt/molecule/%s/molecule/default/molecule.yml", roleDirName)
-		log.Printf("Checking molecule.yml in container...")
-		if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", checkCmd); err != nil {
-			log.Printf(config.ColorRed+"molecule.yml not found  in container at /opt/molecule/%s/molecule/default/"+config.ColorReset, roleDirName)
-			log.Printf(config.ColorYellow + "Listing container directory structure:" + config.ColorReset)
-			// Best-effort debug listing — output shown regardless of success/failure.
-			_ = utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("ls -laR /opt/molecule/%s/", roleDirName))
-			return fmt.Errorf("molecule.yml not found in container at /opt/molecule/%s/molecule/default/", roleDirName)
+		if err := checkMoleculeYml(opts, roleDirName); err != nil {
+			return err
 		}
 	}
-
-	tagEnv := ""
-	if opts.TagFlag != "" {
-		tagEnv = fmt.Sprintf("ANSIBLE_RUN_TAGS=%s ", opts.TagFlag)
-	}
-	scenario := config.DefaultScenario
-	if opts.RoleScenario != "" {
-		scenario = opts.RoleScenario
-	}
-	galaxyInstall := ""
-	if opts.ForceFlag {
-		galaxyInstall = fmt.Sprintf("ansible-galaxy install --force -r molecule/%s/requirements.yml 2>/dev/null || true && ", scenario)
+	if err := runPreScripts(opts, roleDirName); err != nil {
+		return err
 	}
-	cmdStr := fmt.Sprintf("cd ./%s && %s%smolecule converge%s", roleDirName, galaxyInstall, tagEnv, scenarioFlag(opts))
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr); err != nil {
+
+	if err := runPhaseWithRetry(opts, roleDirName, "converge", convergeCommand(opts, roleDirName), nil); err != nil {
 		log.Printf(config.ColorRed+"Converge failed: %v"+config.ColorReset, err)
+		printKeepHint(opts)
 		return fmt.Errorf("converge failed: %w", err)
 	}
 	log.Printf(config.ColorGreen + "Converge Done Successfully!" + config.ColorReset)
+	recordConverge(opts, roleDirName)
 
 	// Fix permissions on molecule directory for Unix systems (inside container)
-	if runtime.GOOS != "windows" {
-		uid := os.Getuid()
-		gid := os.Getgid()
-		log.Printf("User UID: %d, GID: %d", uid, gid)
-		chownCmd := fmt.Sprintf("chown -R %d:%d /opt/molecule", uid, gid)
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", chownCmd); err != nil {
-			log.Printf(config.ColorYellow+"warning: failed to fix permissions: %v"+config.ColorReset, err)
-		}
-	}
+	fixPermissions(opts, "/opt/molecule")
 
 	return nil
 }
 
+// phaseContext returns the context for a molecule phase, bounded by --timeout when set
+func phaseContext(opts *MoleculeOptions) (context.Context, context.CancelFunc) {
+	if opts.Timeout > 0 {
+		return context.WithTimeout(context.Background(), opts.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// phaseKill kills the processes inside the container whose command line
+// matches pattern. Tests replace it.
+var phaseKill = func(opts *MoleculeOptions, pattern string) error {
+	return utils.DockerExecInteractiveHide(opts.RoleFlag, "pkill", opts.CIMode, "-f", pattern)
+}
+
+// timeoutDestroy runs the molecule destroy that cleans up after a timed out
+// phase. Tests replace it.
+var timeoutDestroy = func(ctx context.Context, opts *MoleculeOptions, cmdStr string) error {
+	return utils.DockerExecInteractiveContext(ctx, opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// phaseKillPattern returns the pkill -f pattern for a molecule phase. It
+// allows the -v flags moleculeBinary adds between "molecule" and the phase;
+// env assignments come before "molecule" and need no match.
+func phaseKillPattern(phase string) string {
+	return "molecule (-v+ )?" + phase
+}
+
+// execMoleculePhase runs a molecule phase command inside the container. When
+// --timeout expires the phase is killed and molecule destroy is run to clean
+// up the test instances.
+func execMoleculePhase(opts *MoleculeOptions, roleDirName, phase, cmdStr string) (err error) {
+	ctx, cancel := phaseContext(opts)
+	defer cancel()
+	start := time.Now()
+	defer func() { opts.Results.Record(activeScenario(opts), phase, time.Since(start), err) }()
+
+	// Every phase re-reads molecule.yml, so each needs the same platform override
+	envPrefix := platformEnvPrefix(opts.Platforms)
+	err = phaseExec(ctx, opts, envPrefix+cmdStr)
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	log.Printf(config.ColorRed+"%s timed out after %s"+config.ColorReset, phase, opts.Timeout)
+	// Killing docker exec does not stop the process inside the container
+	_ = phaseKill(opts, phaseKillPattern(phase))
+	if phase != "destroy" {
+		log.Printf(config.ColorYellow + "Running molecule destroy to clean up..." + config.ColorReset)
+		cleanupCtx, cleanupCancel := phaseContext(opts)
+		defer cleanupCancel()
+		destroyCmd := envPrefix + fmt.Sprintf("cd ./%s && molecule destroy%s", roleDirName, scenarioFlag(opts))
+		if err := timeoutDestroy(cleanupCtx, opts, destroyCmd); err != nil {
+			log.Printf(config.ColorYellow+"warning: cleanup destroy failed: %v"+config.ColorReset, err)
+		}
+	}
+	return fmt.Errorf("%s %w after %s", phase, errPhaseTimedOut, opts.Timeout)
+}
+
 // runLint runs yamllint and ansible-lint inside the container.
 func runLint(opts *MoleculeOptions, roleDirName string) error {
+	if StructuredLintFormat(opts.LintFormat) {
+		return runLintStructured(opts, roleDirName)
+	}
 	cmdStr := fmt.Sprintf(`cd ./%s && yamllint . -c .yamllint && ansible-lint -c .ansible-lint `, roleDirName)
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr); err != nil {
+	start := time.Now()
+	err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+	opts.Results.Record(activeScenario(opts), "lint", time.Since(start), err)
+	if err != nil {
+		log.Printf(config.ColorRed+"Lint failed: %v"+config.ColorReset, err)
+		return fmt.Errorf("lint failed: %w", err)
+	}
+	log.Printf(config.ColorGreen + "Lint Done Successfully!" + config.ColorReset)
+	return nil
+}
+
+// lintCapture runs a linter command in the container and copies its output to
+// w without streaming it; tests replace it with a stub runner
+var lintCapture = func(opts *MoleculeOptions, cmdStr string, w io.Writer) error {
+	return utils.DockerExecCaptureContext(context.Background(), opts.RoleFlag, "/bin/sh", opts.CIMode, false, w, "-c", cmdStr)
+}
+
+// runLintStructured runs both linters with their parsable formatters and prints
+// the combined findings in opts.LintFormat on stdout. Both linters always run;
+// the lint fails when either exits non-zero, as in the text format.
+func runLintStructured(opts *MoleculeOptions, roleDirName string) error {
+	linters := []struct{ tool, cmd string }{
+		{"yamllint", "yamllint -c .yamllint " + strings.Join(LintFormatArgs("yamllint"), " ") + " ."},
+		{"ansible-lint", "ansible-lint -c .ansible-lint " + strings.Join(LintFormatArgs("ansible-lint"), " ")},
+	}
+
+	start := time.Now()
+	var findings []LintFinding
+	var failed []string
+	for _, linter := range linters {
+		var output bytes.Buffer
+		runErr := lintCapture(opts, fmt.Sprintf("cd ./%s && %s", roleDirName, linter.cmd), &output)
+		parsed, err := ParseLintOutput(linter.tool, output.String())
+		if err != nil {
+			// Not a report (e.g. a crash or a config error): show what the linter printed
+			os.Stderr.Write(output.Bytes())
+			log.Printf(config.ColorYellow+"warning: %s output could not be parsed: %v"+config.ColorReset, linter.tool, err)
+		}
+		findings = append(findings, parsed...)
+		if runErr != nil {
+			failed = append(failed, linter.tool)
+		}
+	}
+
+	var err error
+	if len(failed) > 0 {
+		err = fmt.Errorf("%s reported problems", strings.Join(failed, ", "))
+	}
+	opts.Results.Record(activeScenario(opts), "lint", time.Since(start), err)
+	if werr := WriteLintFindings(os.Stdout, opts.LintFormat, findings); werr != nil {
+		return fmt.Errorf("failed to write lint findings: %w", werr)
+	}
+	if err != nil {
 		log.Printf(config.ColorRed+"Lint failed: %v"+config.ColorReset, err)
 		return fmt.Errorf("lint failed: %w", err)
 	}
@@ -241,6 +560,11 @@ func runLint(opts *MoleculeOptions, roleDirName string) error {
 
 // runVerify handles test source resolution (local/remote/diffusion) and runs molecule verify.
 func runVerify(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath, scenario string) error {
+	if opts.CIMode {
+		if err := checkMoleculeYml(opts, roleDirName); err != nil {
+			return err
+		}
+	}
 	if cfg.TestsConfig == nil {
 		log.Printf(config.ColorYellow + "warning: no tests config found, defaulting to diffusion" + config.ColorReset)
 		cfg.TestsConfig = &config.TestsSettings{Type: config.TestsTypeDiffusion}
@@ -254,21 +578,27 @@ func runVerify(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, rol
 		}
 		verifyRemoteTests(opts, cfg, roleMoleculePath, scenario)
 	case config.TestsTypeDiffusion:
-		if err := verifyDiffusionTests(opts, roleMoleculePath, scenario); err != nil {
+		if err := verifyDiffusionTests(opts, cfg, roleMoleculePath, scenario); err != nil {
 			return err
 		}
 	default:
 		return fmt.Errorf("unknown tests type: %s", cfg.TestsConfig.Type)
 	}
 
-	// run molecule verify
+	return runMoleculeVerify(opts, roleDirName)
+}
+
+// runMoleculeVerify runs molecule verify inside the container, assuming tests
+// are already in place.
+func runMoleculeVerify(opts *MoleculeOptions, roleDirName string) error {
 	tagEnv := ""
 	if opts.TagFlag != "" {
 		tagEnv = fmt.Sprintf("ANSIBLE_RUN_TAGS=%s ", opts.TagFlag)
 	}
-	cmdStr := fmt.Sprintf("cd ./%s && %smolecule verify%s", roleDirName, tagEnv, scenarioFlag(opts))
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr); err != nil {
+	cmdStr := moleculeCommand(opts, roleDirName, tagEnv, "verify")
+	if err := runPhaseWithRetry(opts, roleDirName, "verify", cmdStr, nil); err != nil {
 		log.Printf(config.ColorRed+"Verify failed: %v"+config.ColorReset, err)
+		printKeepHint(opts)
 		return fmt.Errorf("verify failed: %w", err)
 	}
 	log.Printf(config.ColorGreen + "Verify Done Successfully!" + config.ColorReset)
@@ -299,116 +629,64 @@ func verifyLocalTests(opts *MoleculeOptions, path, roleMoleculePath, scenario st
 	}
 }
 
-// verifyRemoteTests clones test files from remote repositories.
+// verifyRemoteTests installs the test files of each remote repository from the
+// tests repository cache into tests/<repo> of the scenario.
 func verifyRemoteTests(opts *MoleculeOptions, cfg *config.Config, roleMoleculePath, scenario string) {
 	for _, remoteRepo := range cfg.TestsConfig.RemoteRepositories {
 		log.Printf(config.ColorGreen+"Installing test files from remote repository: %s"+config.ColorReset, remoteRepo)
-
-		if !opts.TestsOverWrite {
-			if opts.CIMode {
-				cmdRemoteTests := fmt.Sprintf(`
-				cd /opt/molecule/%s.%s/molecule/%s && \
-				if [ ! -d tests ]; then \
-					mkdir -p tests && cd tests && git clone %s; \
-				else \
-					echo "Tests directory already exists, skipping clone"; \
-				fi
-			`, opts.OrgFlag, opts.RoleFlag, scenario, remoteRepo)
-				if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdRemoteTests); err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to clone remote tests —CI mode: %v"+config.ColorReset, err)
-				}
-			} else {
-				testsDst := filepath.Join(roleMoleculePath, config.MoleculeDir, scenario, config.TestsDir)
-				if _, err := os.Stat(testsDst); os.IsNotExist(err) {
-					cmdRemoteTests := fmt.Sprintf(`
-					cd %s && \
-					mkdir -p tests && cd tests && git clone %s;
-				`, filepath.Join(roleMoleculePath, config.MoleculeDir, scenario), remoteRepo)
-					if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdRemoteTests); err != nil {
-						log.Printf(config.ColorYellow+"warning: failed to clone remote tests: %v"+config.ColorReset, err)
-					}
-				} else {
-					log.Printf(config.ColorYellow + "Tests directory already exists, skipping clone" + config.ColorReset)
-				}
-			}
-		} else {
-			// Overwrite tests directory with remote repository
-			if opts.CIMode {
-				cmdRemoteTests := fmt.Sprintf(`
-				cd /opt/molecule/%s.%s/molecule/%s && \
-				rm -rf tests && \
-				mkdir -p tests && cd tests && git clone %s
-			`, opts.OrgFlag, opts.RoleFlag, scenario, remoteRepo)
-				if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdRemoteTests); err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to clone remote tests —CI mode: %v"+config.ColorReset, err)
-				}
-			} else {
-				testsDst := filepath.Join(roleMoleculePath, config.MoleculeDir, scenario, config.TestsDir)
-				if err := os.RemoveAll(testsDst); err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to remove tests directory before overwrite: %v"+config.ColorReset, err)
-				}
-				cmdRemoteTests := fmt.Sprintf(`
-				cd %s && \
-				git clone %s tests
-			`, filepath.Join(roleMoleculePath, config.MoleculeDir, scenario), remoteRepo)
-				if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdRemoteTests); err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to clone remote tests: %v"+config.ColorReset, err)
-				}
-			}
+		dir, err := syncTestsRepo(opts, remoteRepo)
+		if err != nil {
+			log.Printf(config.ColorYellow+"warning: %v"+config.ColorReset, err)
+			continue
+		}
+		if err := installTestsRepo(opts, dir, roleMoleculePath, scenario, testsRepoName(remoteRepo)); err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to install remote tests: %v"+config.ColorReset, err)
 		}
 	}
 }
 
-// verifyDiffusionTests clones/updates diffusion-managed test files.
-func verifyDiffusionTests(opts *MoleculeOptions, roleMoleculePath, scenario string) error {
+// verifyDiffusionTests installs the diffusion-managed test files of [tests]
+// diffusion_repo from the tests repository cache into tests/diffusion_tests of
+// the scenario.
+func verifyDiffusionTests(opts *MoleculeOptions, cfg *config.Config, roleMoleculePath, scenario string) error {
 	log.Printf(config.ColorGreen + "Using diffusion-managed test files" + config.ColorReset)
 
-	diffusionTestsPath := "/tmp/diffusion-tests-repo"
-	if !opts.TestsOverWrite {
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf(`ls %s`, diffusionTestsPath)); err != nil {
-			log.Printf(config.ColorGreen + "Cloning diffusion tests repository..." + config.ColorReset)
-			if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "git", opts.CIMode, "clone", "https://github.com/Polar-Team/diffusion-ansible-tests-role.git", diffusionTestsPath); err != nil {
-				return fmt.Errorf("failed to clone diffusion tests repository: %w", err)
-			}
-		} else {
-			log.Printf(config.ColorGreen + "Updating diffusion tests repository..." + config.ColorReset)
-			cmdPullCommand := fmt.Sprintf(`cd %s && git pull`, diffusionTestsPath)
-			if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdPullCommand); err != nil {
-				log.Printf(config.ColorYellow+"warning: failed to update diffusion tests repository: %v"+config.ColorReset, err)
-			}
-		}
-	} else {
-		cmdRemove := fmt.Sprintf("rm -rf %s", diffusionTestsPath)
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdRemove); err != nil {
-			return fmt.Errorf("failed to remove existing diffusion tests repository: %w", err)
-		}
-		log.Printf(config.ColorGreen + "Cloning diffusion tests repository (overwrite mode)..." + config.ColorReset)
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "git", opts.CIMode, "clone", "https://github.com/Polar-Team/diffusion-ansible-tests-role.git", diffusionTestsPath); err != nil {
-			return fmt.Errorf("failed to clone diffusion tests repository: %w", err)
-		}
+	dir, err := syncTestsRepo(opts, cfg.TestsConfig.DiffusionRepository())
+	if err != nil {
+		return err
 	}
-
-	// Copy tests from diffusion repo to role tests directory
-	destPath := fmt.Sprintf(
-		"/opt/molecule/%s.%s/%s/%s/%s/diffusion_tests", opts.OrgFlag, opts.RoleFlag,
-		config.MoleculeDir, scenario, config.TestsDir)
-	cmdCopy := fmt.Sprintf(`mkdir -p %s && cp -rf %s/. %s`, destPath, diffusionTestsPath, destPath)
-	if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdCopy); err != nil {
+	if err := installTestsRepo(opts, dir, roleMoleculePath, scenario, config.DiffusionTestsRoleName); err != nil {
 		log.Printf(config.ColorYellow+"warning: failed to copy diffusion tests: %v"+config.ColorReset, err)
 	}
-
 	return nil
 }
 
-// runIdempotence runs molecule idempotence inside the container.
+// runIdempotence runs molecule idempotence inside the container. The output is
+// captured (and streamed only with --verbose); on failure the tasks that
+// reported changed on the second run are listed, or the full output is shown
+// when none can be found in it.
 func runIdempotence(opts *MoleculeOptions, roleDirName string) error {
 	tagEnv := ""
 	if opts.TagFlag != "" {
 		tagEnv = fmt.Sprintf("ANSIBLE_RUN_TAGS=%s ", opts.TagFlag)
 	}
-	cmdStr := fmt.Sprintf("cd ./%s && %smolecule idempotence%s", roleDirName, tagEnv, scenarioFlag(opts))
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr); err != nil {
+	cmdStr := moleculeCommand(opts, roleDirName, tagEnv+diffEnv(opts), "idempotence")
+
+	var output bytes.Buffer
+	opts.phaseOutput = &output
+	err := runPhaseWithRetry(opts, roleDirName, "idempotence", cmdStr, nil)
+	opts.phaseOutput = nil
+	if err != nil {
 		log.Printf(config.ColorRed+"Idempotence failed: %v"+config.ColorReset, err)
+		var summary bytes.Buffer
+		if writeIdempotenceReport(&summary, parseIdempotenceOutput(output.String())) {
+			fmt.Print(config.ColorYellow + summary.String() + config.ColorReset)
+			if opts.Verbosity == 0 {
+				fmt.Println("Re-run with --verbose for the full idempotence output")
+			}
+		} else if opts.Verbosity == 0 {
+			os.Stdout.Write(output.Bytes())
+		}
 		return fmt.Errorf("idempotence failed: %w", err)
 	}
 	log.Printf(config.ColorGreen + "Idempotence Done Successfully!" + config.ColorReset)
@@ -417,8 +695,8 @@ func runIdempotence(opts *MoleculeOptions, roleDirName string) error {
 
 // runDestroy runs molecule destroy inside the container.
 func runDestroy(opts *MoleculeOptions, roleDirName string) error {
-	cmdStr := fmt.Sprintf("cd ./%s && molecule destroy%s", roleDirName, scenarioFlag(opts))
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr); err != nil {
+	cmdStr := moleculeCommand(opts, roleDirName, "", "destroy")
+	if err := execMoleculePhase(opts, roleDirName, "destroy", cmdStr); err != nil {
 		log.Printf(config.ColorRed+"Destroy failed: %v"+config.ColorReset, err)
 		return fmt.Errorf("destroy failed: %w", err)
 	}
@@ -426,12 +704,114 @@ func runDestroy(opts *MoleculeOptions, roleDirName string) error {
 	return nil
 }
 
+// defaultPrepare brings up the container for the default flow. Tests replace it.
+var defaultPrepare = prepareContainer
+
+// defaultUVSync syncs the container's Python dependencies with the
+// diffusion pyproject.toml, hiding the output for an existing container.
+// Tests replace it.
+var defaultUVSync = func(opts *MoleculeOptions, hide bool) error {
+	if hide {
+		return utils.DockerExecInteractiveHide(opts.RoleFlag, "uv-sync", opts.CIMode)
+	}
+	return utils.DockerExecInteractive(opts.RoleFlag, "uv-sync", opts.CIMode)
+}
+
+// defaultCreate runs molecule create in a freshly started container. Tests replace it.
+var defaultCreate = func(opts *MoleculeOptions, cmdStr string) error {
+	return utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// runDefaultCreate runs and records the create step of the default flow
+func runDefaultCreate(opts *MoleculeOptions, roleDirName string) error {
+	start := time.Now()
+	err := defaultCreate(opts, platformEnvPrefix(opts.Platforms)+moleculeCommand(opts, roleDirName, "", "create"))
+	opts.Results.Record(activeScenario(opts), "create", time.Since(start), err)
+	return err
+}
+
 // handleDefaultFlow handles the default molecule workflow: create container, copy data, converge.
 func handleDefaultFlow(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
-	// check if container exists
-	err := exec.Command("docker", "inspect", fmt.Sprintf("molecule-%s", opts.RoleFlag)).Run()
-	if err == nil {
-		fmt.Printf(config.ColorAquamarine+"Container molecule-%s already exists. To purge use --wipe.\n"+config.ColorReset, opts.RoleFlag)
+	if err := defaultPrepare(opts, cfg, path, roleDirName, roleMoleculePath); err != nil {
+		return err
+	}
+
+	// finally create/converge
+	recreate := recreateContainerFunc(opts, cfg, path, roleDirName)
+	var convergeErr error
+	if containerExists(opts) {
+		// container exists — best-effort uv-sync, then converge
+		if err := defaultUVSync(opts, true); err != nil {
+			log.Printf(config.ColorYellow+"warning: uv-sync failed (container-exists path): %v"+config.ColorReset, err)
+		}
+		if opts.DestroyFirst {
+			if err := destroyAndCreate(opts, roleDirName); err != nil {
+				return err
+			}
+		}
+		if err := runPreScripts(opts, roleDirName); err != nil {
+			return err
+		}
+		if err := withCISection(opts, "converge", func() error {
+			return runPhaseWithRetry(opts, roleDirName, "converge", convergeCommand(opts, roleDirName), recreate)
+		}); err != nil {
+			convergeErr = err
+			log.Printf(config.ColorYellow+"warning: converge failed (container-exists path): %v"+config.ColorReset, err)
+			printKeepHint(opts)
+		} else {
+			recordConverge(opts, roleDirName)
+		}
+	} else {
+		// Sync UV dependencies with pyproject.toml from diffusion
+		if err := defaultUVSync(opts, false); err != nil {
+			log.Printf(config.ColorYellow+"Warning: uv-sync failed: %v"+config.ColorReset, err)
+			log.Printf(config.ColorYellow + "Continuing with existing dependencies..." + config.ColorReset)
+		}
+		if opts.DestroyFirst {
+			if err := destroyAndCreate(opts, roleDirName); err != nil {
+				return err
+			}
+		} else if err := runDefaultCreate(opts, roleDirName); err != nil {
+			log.Printf(config.ColorYellow+"warning: molecule create failed: %v"+config.ColorReset, err)
+			printCgroupHint(detectCgroupVersion(hostCgroupRoot))
+		}
+		if err := runPreScripts(opts, roleDirName); err != nil {
+			return err
+		}
+		if err := withCISection(opts, "converge", func() error {
+			return runPhaseWithRetry(opts, roleDirName, "converge", convergeCommand(opts, roleDirName), recreate)
+		}); err != nil {
+			convergeErr = err
+			log.Printf(config.ColorYellow+"warning: converge failed: %v"+config.ColorReset, err)
+			printKeepHint(opts)
+		} else {
+			recordConverge(opts, roleDirName)
+		}
+	}
+
+	// Fix permissions on molecule directory for Unix systems (skip —CI mode - no volume mount)
+	if !opts.CIMode {
+		fixPermissions(opts, "/opt/molecule")
+	}
+
+	// A single run only warns about a failed converge; --all-scenarios needs it
+	// for the summary and --destroy-on-failure to clean up
+	if opts.AllScenarios || opts.DestroyOnFail {
+		return convergeErr
+	}
+	return nil
+}
+
+// prepareContainer starts the molecule container unless it already exists
+// (credentials, registry auth, docker run, cache copies in CI mode) and brings
+// the role into it, without running any molecule phase.
+func prepareContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+	// reuse a running container; a stopped one is started, or removed and recreated
+	if reviveContainer(opts) {
+		fmt.Printf(config.ColorAquamarine+"Container %s already exists. To purge use --wipe.\n"+config.ColorReset, ContainerName(opts.RoleFlag))
+		if opts.NoCache {
+			log.Printf(config.ColorYellow+"warning: --no-cache cannot remove the cache mounts of the existing container %s; run --wipe first for a clean run"+config.ColorReset, ContainerName(opts.RoleFlag))
+		}
 	} else {
 		// Container does not exist — set up credentials, auth, and run it
 		if err := setupCredentials(opts, cfg); err != nil {
@@ -459,12 +839,12 @@ func handleDefaultFlow(opts *MoleculeOptions, cfg *config.Config, path, roleDirN
 	}
 
 	// Windows: copy UV precache into native container cache (non-CI only, CI uses docker cp)
-	if !opts.CIMode && runtime.GOOS == "windows" && cfg.CacheConfig != nil && cfg.CacheConfig.Enabled && cfg.CacheConfig.UVCache {
+	if !opts.CIMode && runtime.GOOS == "windows" && cacheEnabled(opts, cfg) && cfg.CacheConfig.UVCache {
 		loadUVPrecache(opts)
 	}
 
 	// Load DinD images from cached tarball (both modes)
-	if cfg.CacheConfig != nil && cfg.CacheConfig.Enabled && cfg.CacheConfig.DockerCache {
+	if cacheEnabled(opts, cfg) && cfg.CacheConfig.DockerCache {
 		loadDinDImages(opts)
 	}
 
@@ -488,10 +868,16 @@ func handleDefaultFlow(opts *MoleculeOptions, cfg *config.Config, path, roleDirN
 		if err := utils.CopyRoleData(path, roleMoleculePath, opts.CIMode); err != nil {
 			log.Printf(config.ColorYellow+"copy role data warning: %v"+config.ColorReset, err)
 		}
+		if err := verifyRoleCopy(opts, path, roleMoleculePath); err != nil {
+			return err
+		}
 		err := utils.ExportLinters(cfg, roleMoleculePath, opts.CIMode, opts.RoleFlag, opts.OrgFlag)
 		if err != nil {
 			log.Printf(config.ColorYellow+"export linters warning: %v"+config.ColorReset, err)
 		}
+		if err := utils.ExportAnsibleCfg(cfg, roleMoleculePath, opts.CIMode, opts.RoleFlag, opts.OrgFlag); err != nil {
+			log.Printf(config.ColorYellow+"export ansible.cfg warning: %v"+config.ColorReset, err)
+		}
 		metaFixCmd := fmt.Sprintf(
 			`if [ -f /opt/molecule/%s/meta/main.yml ]; then sed -i 's/^\(\s*namespace:\s*\).*/\1%s/' /opt/molecule/%s/meta/main.yml; fi`,
 			roleDirName, opts.OrgFlag, roleDirName)
@@ -499,48 +885,6 @@ func handleDefaultFlow(opts *MoleculeOptions, cfg *config.Config, path, roleDirN
 		_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", metaFixCmd)
 	}
 
-	// finally create/converge
-	scenario := config.DefaultScenario
-	if opts.RoleScenario != "" {
-		scenario = opts.RoleScenario
-	}
-	galaxyInstall := ""
-	if opts.ForceFlag {
-		galaxyInstall = fmt.Sprintf("ansible-galaxy install --force -r molecule/%s/requirements.yml 2>/dev/null || true && ", scenario)
-	}
-	err = exec.Command("docker", "inspect", fmt.Sprintf("molecule-%s", opts.RoleFlag)).Run()
-	if err == nil {
-		// container exists — best-effort uv-sync, then converge
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "uv-sync", opts.CIMode); err != nil {
-			log.Printf(config.ColorYellow+"warning: uv-sync failed (container-exists path): %v"+config.ColorReset, err)
-		}
-		if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("cd ./%s && %smolecule converge%s", roleDirName, galaxyInstall, scenarioFlag(opts))); err != nil {
-			log.Printf(config.ColorYellow+"warning: converge failed (container-exists path): %v"+config.ColorReset, err)
-		}
-	} else {
-		// Sync UV dependencies with pyproject.toml from diffusion
-		if err := utils.DockerExecInteractive(opts.RoleFlag, "uv-sync", opts.CIMode); err != nil {
-			log.Printf(config.ColorYellow+"Warning: uv-sync failed: %v"+config.ColorReset, err)
-			log.Printf(config.ColorYellow + "Continuing with existing dependencies..." + config.ColorReset)
-		}
-		if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("cd ./%s && molecule create%s", roleDirName, scenarioFlag(opts))); err != nil {
-			log.Printf(config.ColorYellow+"warning: molecule create failed: %v"+config.ColorReset, err)
-		}
-		if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("cd ./%s && %smolecule converge%s", roleDirName, galaxyInstall, scenarioFlag(opts))); err != nil {
-			log.Printf(config.ColorYellow+"warning: converge failed: %v"+config.ColorReset, err)
-		}
-	}
-
-	// Fix permissions on molecule directory for Unix systems (skip —CI mode - no volume mount)
-	if !opts.CIMode && runtime.GOOS != "windows" {
-		uid := os.Getuid()
-		gid := os.Getgid()
-		chownCmd := fmt.Sprintf("chown -R %d:%d /opt/molecule", uid, gid)
-		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", chownCmd); err != nil {
-			log.Printf(config.ColorYellow+"warning: failed to fix permissions: %v"+config.ColorReset, err)
-		}
-	}
-
 	return nil
 }
 
@@ -583,7 +927,8 @@ func setupCredentials(opts *MoleculeOptions, cfg *config.Config) error {
 // When oidc is true, it reads credentials from environment variables instead of calling cloud CLIs.
 func setupRegistryAuth(cfg *config.Config, oidc bool, ciMode bool) {
 	provider := cfg.ContainerRegistry.RegistryProvider
-	if oidc {
+	// Basic registries use a static password, so there is no OIDC token to read
+	if oidc && provider != config.RegistryProviderBasic {
 		if err := registry.OidcInit(provider); err != nil {
 			log.Printf(config.ColorRed+"OIDC init error: %v"+config.ColorReset, err)
 			return
@@ -620,6 +965,10 @@ func setupRegistryAuth(cfg *config.Config, oidc bool, ciMode bool) {
 		if err := utils.RunCommandHide(ciMode, "docker", "login", cfg.ContainerRegistry.RegistryServer, "--username", "oauth2accesstoken", "--password", os.Getenv("TOKEN")); err != nil {
 			log.Printf(config.ColorYellow+"docker login to GCP registry failed: %v"+config.ColorReset, err)
 		}
+	case config.RegistryProviderBasic:
+		if err := registry.BasicLogin(cfg.ContainerRegistry, ""); err != nil {
+			log.Printf(config.ColorYellow+"docker login to registry failed: %v"+config.ColorReset, err)
+		}
 	case config.RegistryProviderPublic:
 		log.Printf(config.ColorMagenta + "Using public registry, skipping CLI initialization and authentication" + config.ColorReset)
 	default:
@@ -627,13 +976,33 @@ func setupRegistryAuth(cfg *config.Config, oidc bool, ciMode bool) {
 	}
 }
 
+// containerRunBaseArgs returns the leading docker run arguments. With --keep
+// the container is started without --rm so it survives a failed run.
+func containerRunBaseArgs(opts *MoleculeOptions) []string {
+	args := []string{"run"}
+	if !opts.KeepFlag {
+		args = append(args, "--rm")
+	}
+	return append(args, "-d", "--name="+ContainerName(opts.RoleFlag))
+}
+
 // runContainer builds docker run arguments and starts the molecule container.
 func runContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName string) error {
 	image := utils.GetImageURL(cfg.ContainerRegistry)
-	args := []string{
-		"run", "--rm", "-d", "--name=" + fmt.Sprintf("molecule-%s", opts.RoleFlag),
+	// Checked before any setup, so air-gapped runs fail fast
+	pull, err := pullPolicy(opts, cfg)
+	if err != nil {
+		return err
+	}
+	if err := buildMoleculeImage(opts, cfg, image); err != nil {
+		return err
+	}
+	if pull == config.PullPolicyNever && !imagePresent(image) {
+		return fmt.Errorf("image %s is not present locally and the pull policy is 'never'; load it first (e.g. docker load -i image.tar) or use --pull missing", image)
 	}
 
+	args := containerRunBaseArgs(opts)
+
 	// CI Mode: Don't mount /opt/molecule, we'll clone repo inside container
 	if !opts.CIMode {
 		args = append(args, "-v", fmt.Sprintf("%s/molecule:/opt/molecule", path))
@@ -646,6 +1015,9 @@ func runContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName s
 		"-e", "VAULT_ADDR="+os.Getenv("VAULT_ADDR"),
 		"-e", "SSL_CERT_FILE=/etc/ssl/certs/ca-certificates.crt",
 	)
+	args = append(args, platformEnvArgs(opts.Platforms)...)
+	args = append(args, envFileArgs(opts.EnvFileVars)...)
+	args = append(args, galaxyServerArgs()...)
 
 	// Get Python version from lock file if it exists, otherwise use default
 	pythonVersion := config.PinnedPythonVersion
@@ -720,62 +1092,12 @@ func runContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName s
 	}
 
 	// Add cgroup mount only if it exists (may not be available —WSL2)
-	if _, err := os.Stat("/sys/fs/cgroup"); err == nil {
-		args = append(args, "-v", "/sys/fs/cgroup:/sys/fs/cgroup:rw")
-	}
+	cgroupVersion := detectCgroupVersion(hostCgroupRoot)
+	log.Printf(config.ColorMagenta+"Host cgroup version: %s"+config.ColorReset, cgroupVersion)
+	args = append(args, cgroupMountArgs(cgroupVersion)...)
 
 	// Add cache volume mounts if enabled (non-CI mode only; CI mode uses docker cp)
-	if !opts.CIMode && cfg.CacheConfig != nil && cfg.CacheConfig.Enabled && cfg.CacheConfig.CacheID != "" {
-		cacheDir, err := cache.EnsureCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
-		if err != nil {
-			log.Printf(config.ColorYellow+"warning: failed to create cache directory: %v"+config.ColorReset, err)
-		} else {
-			// Roles and collections cache (always mounted when cache is enabled)
-			rolesDir := filepath.Join(cacheDir, config.CacheRolesDir)
-			collectionsDir := filepath.Join(cacheDir, config.CacheCollectionsDir)
-
-			if err := os.MkdirAll(rolesDir, 0755); err != nil {
-				log.Printf(config.ColorYellow+"warning: failed to create roles cache directory: %v"+config.ColorReset, err)
-			}
-			if err := os.MkdirAll(collectionsDir, 0755); err != nil {
-				log.Printf(config.ColorYellow+"warning: failed to create collections cache directory: %v"+config.ColorReset, err)
-			}
-
-			args = append(args, "-v", fmt.Sprintf("%s:%s", rolesDir, config.ContainerRolesCachePath))
-			args = append(args, "-v", fmt.Sprintf("%s:%s", collectionsDir, config.ContainerCollectionsCachePath))
-			log.Printf(config.ColorGreen+"Cache enabled: mounting roles and collections from %s"+config.ColorReset, cacheDir)
-
-			// UV/Python package cache mount
-			if cfg.CacheConfig.UVCache {
-				uvDir, err := cache.EnsureUVCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
-				if err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to create UV cache directory: %v"+config.ColorReset, err)
-				} else {
-					// On Windows, mount to a staging path (precache) instead of the real
-					// cache path. NTFS-mounted volumes are too slow for UV operations.
-					// The precache contents are copied to the native ext4 cache on start
-					// and saved back on wipe.
-					uvContainerPath := config.ContainerUVCachePath
-					if runtime.GOOS == "windows" {
-						uvContainerPath = config.ContainerUVPrecachePath
-					}
-					args = append(args, "-v", fmt.Sprintf("%s:%s", uvDir, uvContainerPath))
-					log.Printf(config.ColorGreen+"UV cache enabled: mounting %s -> %s"+config.ColorReset, uvDir, uvContainerPath)
-				}
-			}
-
-			// Docker/DinD image cache mount
-			if cfg.CacheConfig.DockerCache {
-				dockerDir, err := cache.EnsureDockerCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
-				if err != nil {
-					log.Printf(config.ColorYellow+"warning: failed to create Docker cache directory: %v"+config.ColorReset, err)
-				} else {
-					args = append(args, "-v", fmt.Sprintf("%s:%s", dockerDir, config.ContainerDockerCachePath))
-					log.Printf(config.ColorGreen+"Docker cache enabled: mounting %s -> %s"+config.ColorReset, dockerDir, config.ContainerDockerCachePath)
-				}
-			}
-		}
-	}
+	args = append(args, cacheMountArgs(opts, cfg)...)
 
 	// Add all indexed GIT environment variables
 	for i := 1; i <= config.MaxArtifactSources; i++ {
@@ -790,17 +1112,28 @@ func runContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName s
 		}
 	}
 
-	args = append(args, "--cgroupns", "host", "--privileged", "--pull", "always", image)
+	dnsArgs, err := dnsRunArgs(opts, cfg)
+	if err != nil {
+		return fmt.Errorf("invalid DNS settings: %w", err)
+	}
+	args = append(args, dnsArgs...)
 
-	// Run docker with error capture for better debugging
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
+	args, err = finalizeRunArgs(args, cfg, image, pull)
+	if err != nil {
+		return err
+	}
+
+	// Run docker with error capture for better debugging; transient network
+	// errors (e.g. while pulling the image) are retried
+	output, err := runDockerWithRetry(args, ContainerName(opts.RoleFlag))
 	if err != nil {
 		log.Printf(config.ColorRed+"docker run failed: %v"+config.ColorReset, err)
 		if len(output) > 0 {
 			log.Printf(config.ColorRed+"Docker error output: %s"+config.ColorReset, string(output))
 		}
 
+		printCgroupHint(cgroupVersion)
+
 		// Check for common WSL2 credential helper issue
 		if strings.Contains(string(output), "docker-credential-desktop.exe") {
 			log.Printf(config.ColorYellow + "\nWSL2 Docker credential issue detected!" + config.ColorReset)
@@ -816,6 +1149,154 @@ func runContainer(opts *MoleculeOptions, cfg *config.Config, path, roleDirName s
 	return nil
 }
 
+// cacheEnabled reports whether the role cache is used for this run: enabled in
+// [cache] and not bypassed with --no-cache
+func cacheEnabled(opts *MoleculeOptions, cfg *config.Config) bool {
+	return !opts.NoCache && cfg.CacheConfig != nil && cfg.CacheConfig.Enabled
+}
+
+// cacheMountArgs returns the docker run -v mounts for the roles/collections,
+// UV and Docker caches. CI mode copies the cache with docker cp instead, so it
+// gets no mounts, and neither does a --no-cache run.
+func cacheMountArgs(opts *MoleculeOptions, cfg *config.Config) []string {
+	if opts.CIMode || !cacheEnabled(opts, cfg) || cfg.CacheConfig.CacheID == "" {
+		return nil
+	}
+
+	cacheDir, err := cache.EnsureCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to create cache directory: %v"+config.ColorReset, err)
+		return nil
+	}
+
+	// Roles and collections cache (always mounted when cache is enabled)
+	rolesDir := filepath.Join(cacheDir, config.CacheRolesDir)
+	collectionsDir := filepath.Join(cacheDir, config.CacheCollectionsDir)
+
+	if err := os.MkdirAll(rolesDir, 0755); err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to create roles cache directory: %v"+config.ColorReset, err)
+	}
+	if err := os.MkdirAll(collectionsDir, 0755); err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to create collections cache directory: %v"+config.ColorReset, err)
+	}
+
+	args := []string{
+		"-v", fmt.Sprintf("%s:%s", rolesDir, utils.ContainerRolesPath(cfg)),
+		"-v", fmt.Sprintf("%s:%s", collectionsDir, utils.ContainerCollectionsPath(cfg)),
+	}
+	log.Printf(config.ColorGreen+"Cache enabled: mounting roles and collections from %s"+config.ColorReset, cacheDir)
+
+	// UV/Python package cache mount
+	if cfg.CacheConfig.UVCache {
+		uvDir, err := cache.EnsureUVCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
+		if err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to create UV cache directory: %v"+config.ColorReset, err)
+		} else {
+			// On Windows, mount to a staging path (precache) instead of the real
+			// cache path. NTFS-mounted volumes are too slow for UV operations.
+			// The precache contents are copied to the native ext4 cache on start
+			// and saved back on wipe.
+			uvContainerPath := config.ContainerUVCachePath
+			if runtime.GOOS == "windows" {
+				uvContainerPath = config.ContainerUVPrecachePath
+			}
+			args = append(args, "-v", fmt.Sprintf("%s:%s", uvDir, uvContainerPath))
+			log.Printf(config.ColorGreen+"UV cache enabled: mounting %s -> %s"+config.ColorReset, uvDir, uvContainerPath)
+		}
+	}
+
+	// Docker/DinD image cache mount
+	if cfg.CacheConfig.DockerCache {
+		dockerDir, err := cache.EnsureDockerCacheDir(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath)
+		if err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to create Docker cache directory: %v"+config.ColorReset, err)
+		} else {
+			args = append(args, "-v", fmt.Sprintf("%s:%s", dockerDir, config.ContainerDockerCachePath))
+			log.Printf(config.ColorGreen+"Docker cache enabled: mounting %s -> %s"+config.ColorReset, dockerDir, config.ContainerDockerCachePath)
+		}
+	}
+	return args
+}
+
+// containerExtraArgs converts the [container] config section into -e and -v
+// arguments. Values are expanded against the environment and volumes are
+// validated before anything is passed to docker.
+func containerExtraArgs(cs *config.ContainerSettings) ([]string, error) {
+	if cs == nil {
+		return nil, nil
+	}
+
+	var args []string
+	names := make([]string, 0, len(cs.ExtraEnv))
+	for name := range cs.ExtraEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-e", name+"="+os.ExpandEnv(cs.ExtraEnv[name]))
+	}
+
+	for _, volume := range cs.ExtraVolumes {
+		expanded := os.ExpandEnv(volume)
+		if err := config.ValidateVolumeSpec(expanded); err != nil {
+			return nil, err
+		}
+		args = append(args, "-v", expanded)
+	}
+
+	for _, mount := range cs.Tmpfs {
+		expanded := os.ExpandEnv(mount)
+		if err := config.ValidateTmpfsSpec(expanded); err != nil {
+			return nil, err
+		}
+		args = append(args, "--tmpfs", expanded)
+	}
+
+	return args, nil
+}
+
+// finalizeRunArgs appends the user-configured extra env/volumes/tmpfs and the
+// trailing runtime flags, pull policy and image to the docker run arguments.
+func finalizeRunArgs(args []string, cfg *config.Config, image, pull string) ([]string, error) {
+	if cfg.ContainerConfig != nil && cfg.ContainerConfig.HomePath != "" {
+		if err := config.ValidateContainerHomePath(cfg.ContainerConfig.HomePath); err != nil {
+			return nil, fmt.Errorf("invalid [container] config: %w", err)
+		}
+	}
+	extra, err := containerExtraArgs(cfg.ContainerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [container] config: %w", err)
+	}
+	args = append(args, extra...)
+	return append(args, "--cgroupns", "host", "--privileged", "--pull", pull, image), nil
+}
+
+// pullPolicy returns the docker run --pull value: never with a build context,
+// else --pull, then [container] pull_policy, then always
+func pullPolicy(opts *MoleculeOptions, cfg *config.Config) (string, error) {
+	// A locally built image only exists locally; pulling would replace it
+	if buildContextDir(opts, cfg) != "" {
+		return config.PullPolicyNever, nil
+	}
+	policy := opts.PullPolicy
+	if policy == "" && cfg.ContainerConfig != nil {
+		policy = cfg.ContainerConfig.PullPolicy
+	}
+	if policy == "" {
+		return config.DefaultPullPolicy, nil
+	}
+	if err := config.ValidatePullPolicy(policy); err != nil {
+		return "", err
+	}
+	return policy, nil
+}
+
+// imagePresent reports whether the image exists in the local docker image
+// store; replaced in tests
+var imagePresent = func(image string) bool {
+	return exec.Command("docker", "image", "inspect", image).Run() == nil
+}
+
 // setupCIRepository clones the repo and sets up role files inside the container.
 func setupCIRepository(opts *MoleculeOptions, hostPath, roleDirName string) error {
 	log.Printf(config.ColorGreen + "CI Mode: Setting up repository inside container..." + config.ColorReset)
@@ -865,10 +1346,16 @@ func setupCIRepository(opts *MoleculeOptions, hostPath, roleDirName string) erro
 	// The cloned repo inside the container has the remote (stale) versions;
 	// overwrite them with the runner's current copies so tests use the
 	// freshly resolved dependencies.
-	containerName := fmt.Sprintf("molecule-%s", opts.RoleFlag)
-	hostOverrideFiles := []string{config.LockFileName, "diffusion.toml"}
+	containerName := ContainerName(opts.RoleFlag)
+	// diffusion.toml is taken from --config / DIFFUSION_CONFIG when set
+	hostOverrideFiles := []string{config.LockFileName, config.ConfigFileName}
 	for _, fname := range hostOverrideFiles {
 		hostFile := filepath.Join(hostPath, fname)
+		if fname == config.ConfigFileName {
+			if configPath, err := config.ConfigPath(); err == nil {
+				hostFile = configPath
+			}
+		}
 		if _, err := os.Stat(hostFile); err == nil {
 			dest := fmt.Sprintf("%s:/opt/molecule/%s/%s", containerName, roleDirName, fname)
 			if cpErr := exec.Command("docker", "cp", hostFile, dest).Run(); cpErr != nil {
@@ -899,12 +1386,9 @@ func setupCIRepository(opts *MoleculeOptions, hostPath, roleDirName string) erro
 
 	log.Printf(config.ColorGreen+"CI Mode: Role files copied to /opt/molecule/%s"+config.ColorReset, roleDirName)
 
-	verifyCmd := fmt.Sprintf("ls -la /opt/molecule/%s/molecule/default/molecule.yml", roleDirName)
-	if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", verifyCmd); err != nil {
+	if err := checkMoleculeYml(opts, roleDirName); err != nil {
 		log.Printf(config.ColorRed + "CI Mode: molecule.yml not found!" + config.ColorReset)
-		// Best-effort debug listing — output shown regardless of success/failure.
-		_ = utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("ls -laR /opt/molecule/%s/", roleDirName))
-		return fmt.Errorf("molecule.yml not found —container")
+		return err
 	}
 	log.Printf(config.ColorGreen + "CI Mode: Setup complete!" + config.ColorReset)
 
@@ -923,14 +1407,7 @@ func ensureRole(opts *MoleculeOptions, roleMoleculePath string) {
 		}
 
 		// Fix ownership inside container after role init (Unix systems only)
-		if runtime.GOOS != "windows" {
-			uid := os.Getuid()
-			gid := os.Getgid()
-			chownCmd := fmt.Sprintf("chown -R %d:%d /opt/molecule/%s.%s", uid, gid, opts.OrgFlag, opts.RoleFlag)
-			if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", chownCmd); err != nil {
-				log.Printf(config.ColorYellow+"warning: failed to fix ownership after role init: %v"+config.ColorReset, err)
-			}
-		}
+		fixPermissions(opts, fmt.Sprintf("/opt/molecule/%s.%s", opts.OrgFlag, opts.RoleFlag))
 
 		if err := utils.DockerExecInteractive(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", fmt.Sprintf("rm -f %s.%s/*/*", opts.OrgFlag, opts.RoleFlag)); err != nil {
 			log.Printf(config.ColorYellow+"clean role dir warning: %v"+config.ColorReset, err)
@@ -955,6 +1432,10 @@ func loginInsideContainer(opts *MoleculeOptions, cfg *config.Config) {
 		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", loginCmd); err != nil {
 			log.Printf(config.ColorYellow+"warning: docker login inside container (GCP) failed: %v"+config.ColorReset, err)
 		}
+	case config.RegistryProviderBasic:
+		if err := registry.BasicLogin(cfg.ContainerRegistry, ContainerName(opts.RoleFlag)); err != nil {
+			log.Printf(config.ColorYellow+"warning: docker login inside container (Basic) failed: %v"+config.ColorReset, err)
+		}
 	case config.RegistryProviderPublic:
 		log.Printf(config.ColorMagenta + "Using public registry, skipping authentication" + config.ColorReset)
 	default:
@@ -966,7 +1447,7 @@ func loginInsideContainer(opts *MoleculeOptions, cfg *config.Config) {
 // image tarballs FROM the host cache directory INTO the running container using
 // "docker cp". This is used —CI mode where volume mounts (-v) are unavailable.
 func copyCacheIntoContainer(opts *MoleculeOptions, cfg *config.Config) {
-	if cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled || cfg.CacheConfig.CacheID == "" {
+	if !cacheEnabled(opts, cfg) || cfg.CacheConfig.CacheID == "" {
 		return
 	}
 
@@ -976,7 +1457,7 @@ func copyCacheIntoContainer(opts *MoleculeOptions, cfg *config.Config) {
 		return
 	}
 
-	containerName := fmt.Sprintf("molecule-%s", opts.RoleFlag)
+	containerName := ContainerName(opts.RoleFlag)
 
 	// Helper: docker cp <hostPath>/. <container>:<containerPath>
 	// The "/." suffix copies the directory *contents* (not the directory itself).
@@ -999,16 +1480,16 @@ func copyCacheIntoContainer(opts *MoleculeOptions, cfg *config.Config) {
 	}
 
 	// Roles & collections (always when cache is enabled)
-	copyDir(config.CacheRolesDir, config.ContainerRolesCachePath, "roles")
-	copyDir(config.CacheCollectionsDir, config.ContainerCollectionsCachePath, "collections")
+	copyDir(config.CacheRolesDir, utils.ContainerRolesPath(cfg), "roles")
+	copyDir(config.CacheCollectionsDir, utils.ContainerCollectionsPath(cfg), "collections")
 
 	// UV cache
 	if cfg.CacheConfig.UVCache {
 		copyDir(config.CacheUVDir, config.ContainerUVCachePath, "uv")
 	}
 
-	// Docker image tarball — copy the whole docker/ directory so that
-	// loadDinDImages can find images.tar at /root/.cache/docker/images.tar
+	// Docker image tarballs — copy the whole docker/ directory so that
+	// loadDinDImages can find images.tar or images/manifest.json under /root/.cache/docker
 	if cfg.CacheConfig.DockerCache {
 		copyDir(config.CacheDockerDir, config.ContainerDockerCachePath, "docker")
 	}
@@ -1019,7 +1500,29 @@ func copyCacheIntoContainer(opts *MoleculeOptions, cfg *config.Config) {
 // directory using "docker cp". Called —CI mode during --wipe, before the
 // container is removed.
 func copyCacheFromContainer(opts *MoleculeOptions, cfg *config.Config) {
-	if cfg.CacheConfig == nil || !cfg.CacheConfig.Enabled || cfg.CacheConfig.CacheID == "" {
+	if cfg.CacheConfig == nil {
+		return
+	}
+	copyCacheCategoriesFromContainer(opts, cfg, cacheCategories{
+		Roles:       true,
+		Collections: true,
+		UV:          cfg.CacheConfig.UVCache,
+		Docker:      cfg.CacheConfig.DockerCache,
+	})
+}
+
+// cacheCategories selects which cache subdirectories are copied
+type cacheCategories struct {
+	Roles       bool
+	Collections bool
+	UV          bool
+	Docker      bool
+}
+
+// copyCacheCategoriesFromContainer copies the selected cache categories from
+// the running container back to the host cache directory.
+func copyCacheCategoriesFromContainer(opts *MoleculeOptions, cfg *config.Config, cats cacheCategories) {
+	if !cacheEnabled(opts, cfg) || cfg.CacheConfig.CacheID == "" {
 		return
 	}
 
@@ -1029,7 +1532,7 @@ func copyCacheFromContainer(opts *MoleculeOptions, cfg *config.Config) {
 		return
 	}
 
-	containerName := fmt.Sprintf("molecule-%s", opts.RoleFlag)
+	containerName := ContainerName(opts.RoleFlag)
 
 	// Helper: docker cp <container>:<containerPath>/. <hostPath>
 	copyDir := func(containerPath, hostSubdir, label string) {
@@ -1048,16 +1551,20 @@ func copyCacheFromContainer(opts *MoleculeOptions, cfg *config.Config) {
 	}
 
 	// Roles & collections
-	copyDir(config.ContainerRolesCachePath, config.CacheRolesDir, "roles")
-	copyDir(config.ContainerCollectionsCachePath, config.CacheCollectionsDir, "collections")
+	if cats.Roles {
+		copyDir(utils.ContainerRolesPath(cfg), config.CacheRolesDir, "roles")
+	}
+	if cats.Collections {
+		copyDir(utils.ContainerCollectionsPath(cfg), config.CacheCollectionsDir, "collections")
+	}
 
 	// UV cache
-	if cfg.CacheConfig.UVCache {
+	if cats.UV {
 		copyDir(config.ContainerUVCachePath, config.CacheUVDir, "uv")
 	}
 
 	// Docker image tarball directory
-	if cfg.CacheConfig.DockerCache {
+	if cats.Docker {
 		copyDir(config.ContainerDockerCachePath, config.CacheDockerDir, "docker")
 	}
 }
@@ -1073,16 +1580,19 @@ func loadDinDImages(opts *MoleculeOptions) {
 	// Container paths are always Linux — use forward slashes, never filepath.Join.
 	tarballPath := fmt.Sprintf("%s/%s", config.ContainerDockerCachePath, config.DockerImageTarball)
 
-	// Check if the tarball exists inside the container
-	checkCmd := fmt.Sprintf("test -f %s", tarballPath)
-	if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", checkCmd); err != nil {
-		log.Printf(config.ColorYellow+"No cached Docker images found at %s, skipping load"+config.ColorReset, tarballPath)
-		return
+	// Check if a per-image manifest or the single tarball exists inside the container
+	perImage := utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", "test -f "+dindManifestPath()) == nil
+	if !perImage {
+		checkCmd := fmt.Sprintf("test -f %s", tarballPath)
+		if err := utils.DockerExecInteractiveHide(opts.RoleFlag, "sh", opts.CIMode, "-c", checkCmd); err != nil {
+			log.Printf(config.ColorYellow+"No cached Docker images found at %s, skipping load"+config.ColorReset, config.ContainerDockerCachePath)
+			return
+		}
 	}
 
 	// Wait for the inner DinD Docker daemon to be ready.
 	// The container may have just started and dockerd needs time to initialize.
-	containerName := fmt.Sprintf("molecule-%s", opts.RoleFlag)
+	containerName := ContainerName(opts.RoleFlag)
 	const maxRetries = 30
 	dockerReady := false
 	for i := range maxRetries {
@@ -1101,6 +1611,11 @@ func loadDinDImages(opts *MoleculeOptions) {
 		return
 	}
 
+	if perImage {
+		loadDinDImagesPerImage(containerName)
+		return
+	}
+
 	// Load the images into the inner Docker daemon.
 	// Never use -ti here: shell redirection (< file) conflicts with TTY allocation,
 	// and we don't need interactive terminal for this operation.
@@ -1129,7 +1644,7 @@ func loadDinDImages(opts *MoleculeOptions) {
 // on the host automatically via the volume mount. In CI mode the caller must
 // follow up with copyCacheFromContainer to pull it out.
 func saveDinDImages(opts *MoleculeOptions) {
-	containerName := fmt.Sprintf("molecule-%s", opts.RoleFlag)
+	containerName := ContainerName(opts.RoleFlag)
 
 	// Discover images inside the DinD daemon.
 	// We need to capture stdout, so we use exec.Command directly here.
@@ -2,7 +2,14 @@ package molecule
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
 )
 
 // TestMoleculeOptionsDefaults verifies that a zero-value MoleculeOptions
@@ -151,4 +158,384 @@ func TestScenarioFlag(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// TestContainerRunBaseArgs verifies that --keep drops --rm from docker run.
+func TestContainerRunBaseArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		keep   bool
+		wantRm bool
+	}{
+		{name: "default removes container", keep: false, wantRm: true},
+		{name: "keep preserves container", keep: true, wantRm: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &MoleculeOptions{RoleFlag: "testrole", KeepFlag: tt.keep}
+			args := containerRunBaseArgs(opts)
+
+			if args[0] != "run" {
+				t.Errorf("expected first arg 'run', got %q", args[0])
+			}
+			hasRm := false
+			hasName := false
+			for _, a := range args {
+				if a == "--rm" {
+					hasRm = true
+				}
+				if a == "--name=molecule-testrole" {
+					hasName = true
+				}
+			}
+			if hasRm != tt.wantRm {
+				t.Errorf("--rm present = %v, want %v (args: %v)", hasRm, tt.wantRm, args)
+			}
+			if !hasName {
+				t.Errorf("expected --name=molecule-testrole in args: %v", args)
+			}
+		})
+	}
+}
+
+func TestFinalizeRunArgsExtraContainerConfig(t *testing.T) {
+	t.Setenv("DIFFUSION_TEST_REGION", "eu-north-1")
+
+	cfg := &config.Config{
+		ContainerConfig: &config.ContainerSettings{
+			ExtraEnv: map[string]string{
+				"AWS_REGION": "${DIFFUSION_TEST_REGION}",
+				"APP_MODE":   "test",
+			},
+			ExtraVolumes: []string{"/etc/ssl/certs:/etc/ssl/certs:ro"},
+			Tmpfs:        []string{"/run", "/run/lock:rw,size=64m"},
+		},
+	}
+
+	args, err := finalizeRunArgs([]string{"run", "-d"}, cfg, "registry/image:tag", config.PullPolicyAlways)
+	if err != nil {
+		t.Fatalf("finalizeRunArgs() error = %v", err)
+	}
+
+	want := []string{
+		"run", "-d",
+		"-e", "APP_MODE=test",
+		"-e", "AWS_REGION=eu-north-1",
+		"-v", "/etc/ssl/certs:/etc/ssl/certs:ro",
+		"--tmpfs", "/run",
+		"--tmpfs", "/run/lock:rw,size=64m",
+		"--cgroupns", "host", "--privileged", "--pull", "always", "registry/image:tag",
+	}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("finalizeRunArgs() =\n  %v\nwant\n  %v", args, want)
+	}
+}
+
+func TestFinalizeRunArgsRejectsInvalidVolume(t *testing.T) {
+	cfg := &config.Config{
+		ContainerConfig: &config.ContainerSettings{ExtraVolumes: []string{"/etc/ssl/certs"}},
+	}
+	if _, err := finalizeRunArgs([]string{"run"}, cfg, "image", config.PullPolicyAlways); err == nil {
+		t.Error("expected error for volume without destination")
+	}
+}
+
+func TestFinalizeRunArgsRejectsRelativeTmpfs(t *testing.T) {
+	cfg := &config.Config{
+		ContainerConfig: &config.ContainerSettings{Tmpfs: []string{"run"}},
+	}
+	if _, err := finalizeRunArgs([]string{"run"}, cfg, "image", config.PullPolicyAlways); err == nil {
+		t.Error("expected error for tmpfs mount with a relative target")
+	}
+}
+
+func TestPhaseContextTimeout(t *testing.T) {
+	ctx, cancel := phaseContext(&MoleculeOptions{})
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline when --timeout is not set")
+	}
+
+	ctx, cancel = phaseContext(&MoleculeOptions{Timeout: time.Minute})
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected deadline when --timeout is set")
+	}
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("unexpected deadline in %s", remaining)
+	}
+}
+
+// TestExecMoleculePhaseTimeoutKillsVerbosePhase verifies that the pkill pattern
+// used on --timeout matches the phase command at every -v level.
+func TestExecMoleculePhaseTimeoutKillsVerbosePhase(t *testing.T) {
+	origExec, origKill, origDestroy := phaseExec, phaseKill, timeoutDestroy
+	defer func() { phaseExec, phaseKill, timeoutDestroy = origExec, origKill, origDestroy }()
+
+	for level := 0; level <= MaxVerbosity; level++ {
+		opts := &MoleculeOptions{RoleFlag: "web", Timeout: time.Millisecond, Verbosity: level, TagFlag: "nginx"}
+		var issued, pattern string
+		destroyed := false
+		phaseExec = func(ctx context.Context, _ *MoleculeOptions, cmdStr string) error {
+			issued = cmdStr
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		phaseKill = func(_ *MoleculeOptions, p string) error {
+			pattern = p
+			return nil
+		}
+		timeoutDestroy = func(context.Context, *MoleculeOptions, string) error {
+			destroyed = true
+			return nil
+		}
+
+		tagEnv := "ANSIBLE_RUN_TAGS=nginx "
+		cmdStr := verboseCommand(opts, "cd ./acme.web && "+tagEnv+verbosityEnv(opts)+moleculeBinary(opts)+" verify")
+		err := execMoleculePhase(opts, "acme.web", "verify", cmdStr)
+		if !errors.Is(err, errPhaseTimedOut) {
+			t.Fatalf("-v level %d: error = %v, want timeout", level, err)
+		}
+		if !regexp.MustCompile(pattern).MatchString(issued) {
+			t.Errorf("-v level %d: pkill pattern %q does not match %q", level, pattern, issued)
+		}
+		if regexp.MustCompile(pattern).MatchString("molecule -vv converge") {
+			t.Errorf("-v level %d: pkill pattern %q matches another phase", level, pattern)
+		}
+		if !destroyed {
+			t.Errorf("-v level %d: molecule destroy cleanup did not run", level)
+		}
+	}
+}
+
+// TestRunVerifyChecksScenarioMoleculeYml verifies that verifying a non-default
+// scenario checks that scenario's molecule.yml rather than the default one.
+func TestRunVerifyChecksScenarioMoleculeYml(t *testing.T) {
+	orig := moleculeYmlExec
+	defer func() { moleculeYmlExec = orig }()
+
+	var cmds []string
+	moleculeYmlExec = func(_ *MoleculeOptions, args ...string) error {
+		cmds = append(cmds, strings.Join(args, " "))
+		return errors.New("missing")
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "web", OrgFlag: "acme", RoleScenario: "prod", CIMode: true}
+	err := runVerify(opts, &config.Config{}, "", "acme.web", "", "prod")
+	if err == nil || !strings.Contains(err.Error(), "/opt/molecule/acme.web/molecule/prod/") {
+		t.Fatalf("expected missing molecule.yml error for scenario prod, got %v", err)
+	}
+	if len(cmds) == 0 || !strings.Contains(cmds[0], "/opt/molecule/acme.web/molecule/prod/molecule.yml") {
+		t.Fatalf("expected check of molecule/prod/molecule.yml, got %v", cmds)
+	}
+	for _, cmd := range cmds {
+		if strings.Contains(cmd, "molecule/default") {
+			t.Errorf("unexpected reference to the default scenario: %q", cmd)
+		}
+	}
+}
+
+func TestActiveScenario(t *testing.T) {
+	if got := activeScenario(&MoleculeOptions{}); got != config.DefaultScenario {
+		t.Errorf("expected %q, got %q", config.DefaultScenario, got)
+	}
+	if got := activeScenario(&MoleculeOptions{RoleScenario: "prod"}); got != "prod" {
+		t.Errorf("expected prod, got %q", got)
+	}
+}
+
+func TestConvergeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MoleculeOptions
+		want string
+	}{
+		{
+			name: "plain",
+			opts: MoleculeOptions{},
+			want: "cd ./acme.web && molecule converge",
+		},
+		{
+			name: "limit and tags",
+			opts: MoleculeOptions{TagFlag: "install,configure", LimitFlag: "web:!web3"},
+			want: "cd ./acme.web && ANSIBLE_RUN_TAGS=install,configure molecule converge -- --limit 'web:!web3'",
+		},
+		{
+			name: "limit with scenario and force",
+			opts: MoleculeOptions{RoleScenario: "ha", ForceFlag: true, LimitFlag: "db"},
+			want: "cd ./acme.web && ansible-galaxy install --force -r molecule/ha/requirements.yml 2>/dev/null || true && molecule converge -s ha -- --limit 'db'",
+		},
+		{
+			name: "step",
+			opts: MoleculeOptions{ConvergeFlag: true, StepFlag: true},
+			want: "cd ./acme.web && molecule converge -- --step",
+		},
+		{
+			name: "limit and step",
+			opts: MoleculeOptions{LimitFlag: "web", StepFlag: true},
+			want: "cd ./acme.web && molecule converge -- --limit 'web' --step",
+		},
+		{
+			name: "quote in pattern",
+			opts: MoleculeOptions{LimitFlag: "it's"},
+			want: `cd ./acme.web && molecule converge -- --limit 'it'\''s'`,
+		},
+		{
+			name: "diff with tags",
+			opts: MoleculeOptions{TagFlag: "configure", DiffFlag: true},
+			want: "cd ./acme.web && ANSIBLE_RUN_TAGS=configure ANSIBLE_DIFF_ALWAYS=1 molecule converge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convergeCommand(&tt.opts, "acme.web"); got != tt.want {
+				t.Errorf("convergeCommand() =\n  %q\nwant\n  %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MoleculeOptions
+		wantErr bool
+	}{
+		{name: "no step", opts: MoleculeOptions{CIMode: true}},
+		{name: "converge", opts: MoleculeOptions{ConvergeFlag: true, StepFlag: true}},
+		{name: "default flow", opts: MoleculeOptions{StepFlag: true}},
+		{name: "ci", opts: MoleculeOptions{ConvergeFlag: true, StepFlag: true, CIMode: true}, wantErr: true},
+		{name: "verify only", opts: MoleculeOptions{VerifyFlag: true, StepFlag: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStep(&tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateStep() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// RunMolecule rejects --step --ci before touching docker or the config
+	err := RunMolecule(&MoleculeOptions{ConvergeFlag: true, StepFlag: true, CIMode: true})
+	if err == nil || !strings.Contains(err.Error(), "--ci") {
+		t.Errorf("RunMolecule(--step --ci) error = %v, want --ci rejection", err)
+	}
+}
+
+func TestPullPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "always"},
+		{name: "config", config: "missing", want: "missing"},
+		{name: "flag overrides config", flag: "never", config: "missing", want: "never"},
+		{name: "invalid", flag: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerConfig: &config.ContainerSettings{PullPolicy: tt.config}}
+			got, err := pullPolicy(&MoleculeOptions{PullPolicy: tt.flag}, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pullPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("pullPolicy() = %q, want %q", got, tt.want)
+			}
+
+			args, err := finalizeRunArgs([]string{"run"}, cfg, "registry/image:tag", got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if joined := strings.Join(args, " "); !strings.HasSuffix(joined, "--pull "+tt.want+" registry/image:tag") {
+				t.Errorf("finalizeRunArgs() = %q, want --pull %s before the image", joined, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunContainerPullNeverRequiresLocalImage(t *testing.T) {
+	orig := imagePresent
+	defer func() { imagePresent = orig }()
+	var inspected string
+	imagePresent = func(image string) bool {
+		inspected = image
+		return false
+	}
+	t.Chdir(t.TempDir())
+
+	cfg := &config.Config{ContainerRegistry: &config.ContainerRegistry{
+		RegistryServer:        "registry.example.com",
+		RegistryProvider:      config.RegistryProviderPublic,
+		MoleculeContainerName: "molecule",
+		MoleculeContainerTag:  "1.0",
+	}}
+	err := runContainer(&MoleculeOptions{RoleFlag: "web", PullPolicy: config.PullPolicyNever, CIMode: true}, cfg, t.TempDir(), "acme.web")
+	if err == nil || !strings.Contains(err.Error(), "not present locally") {
+		t.Fatalf("runContainer() error = %v, want missing image error", err)
+	}
+	if inspected == "" {
+		t.Error("image presence was not checked for --pull never")
+	}
+}
+
+func TestCacheMountArgsNoCache(t *testing.T) {
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{
+		Enabled:     true,
+		CacheID:     "abc123",
+		CachePath:   t.TempDir(),
+		UVCache:     true,
+		DockerCache: true,
+	}}
+
+	args := strings.Join(cacheMountArgs(&MoleculeOptions{RoleFlag: "role"}, cfg), " ")
+	for _, target := range []string{utils.ContainerRolesPath(cfg), utils.ContainerCollectionsPath(cfg), config.ContainerDockerCachePath} {
+		if !strings.Contains(args, ":"+target) {
+			t.Errorf("expected a cache mount for %s, got %q", target, args)
+		}
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "role", NoCache: true}
+	if got := cacheMountArgs(opts, cfg); got != nil {
+		t.Errorf("cacheMountArgs() with --no-cache = %v, want no mounts", got)
+	}
+	if cacheEnabled(opts, cfg) {
+		t.Error("cacheEnabled() = true with --no-cache")
+	}
+	if !cfg.CacheConfig.Enabled {
+		t.Error("--no-cache changed the [cache] config")
+	}
+}
+
+func TestCacheMountArgsUseContainerHome(t *testing.T) {
+	cfg := &config.Config{
+		CacheConfig:     &config.CacheSettings{Enabled: true, CacheID: "abc123", CachePath: t.TempDir()},
+		ContainerConfig: &config.ContainerSettings{HomePath: "/home/ansible"},
+	}
+
+	args := strings.Join(cacheMountArgs(&MoleculeOptions{RoleFlag: "role"}, cfg), " ")
+	for _, target := range []string{"/home/ansible/.ansible/roles", "/home/ansible/.ansible/collections"} {
+		if !strings.Contains(args, ":"+target) {
+			t.Errorf("expected a cache mount for %s, got %q", target, args)
+		}
+	}
+	if strings.Contains(args, ":/root/") {
+		t.Errorf("cache mounted under /root despite home_path: %q", args)
+	}
+
+	cfg.ContainerConfig.HomePath = "home/ansible"
+	if _, err := finalizeRunArgs(nil, cfg, "image", config.PullPolicyAlways); err == nil {
+		t.Error("expected a relative home_path to be rejected")
+	}
+}
@@ -0,0 +1,30 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// chownExec runs the ownership fix inside the container; tests replace it
+var chownExec = func(opts *MoleculeOptions, cmdStr string) error {
+	return utils.DockerExecInteractiveHide(opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// fixPermissions hands target inside the container back to the host user, so
+// files written as root into the bind-mounted molecule directory stay editable.
+// It does nothing on Windows or with --fix-permissions=false (e.g. an NFS
+// workspace that is already owned correctly and rejects chown).
+func fixPermissions(opts *MoleculeOptions, target string) {
+	if runtime.GOOS == "windows" || opts.SkipPermFix {
+		return
+	}
+	chownCmd := fmt.Sprintf("chown -R %d:%d %s", os.Getuid(), os.Getgid(), target)
+	if err := chownExec(opts, chownCmd); err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to fix permissions on %s: %v (disable with --fix-permissions=false)"+config.ColorReset, target, err)
+	}
+}
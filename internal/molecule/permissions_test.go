@@ -0,0 +1,47 @@
+package molecule
+
+import (
+	"runtime"
+	"testing"
+)
+
+// stubChownExec records the ownership fix commands instead of running them
+func stubChownExec(t *testing.T) *[]string {
+	t.Helper()
+	orig := chownExec
+	t.Cleanup(func() { chownExec = orig })
+
+	var calls []string
+	chownExec = func(_ *MoleculeOptions, cmdStr string) error {
+		calls = append(calls, cmdStr)
+		return nil
+	}
+	return &calls
+}
+
+func TestRunConvergeFixesPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are never fixed on Windows")
+	}
+	stubPhaseExec(t, 0, true)
+	chowns := stubChownExec(t)
+
+	if err := runConverge(&MoleculeOptions{RoleFlag: "web"}, "acme.web"); err != nil {
+		t.Fatalf("runConverge() error = %v", err)
+	}
+	if len(*chowns) != 1 {
+		t.Fatalf("chown calls = %q, want one", *chowns)
+	}
+}
+
+func TestRunConvergeSkipsChownWhenDisabled(t *testing.T) {
+	stubPhaseExec(t, 0, true)
+	chowns := stubChownExec(t)
+
+	if err := runConverge(&MoleculeOptions{RoleFlag: "web", SkipPermFix: true}, "acme.web"); err != nil {
+		t.Fatalf("runConverge() error = %v", err)
+	}
+	if len(*chowns) != 0 {
+		t.Errorf("chown calls = %q, want none with --fix-permissions=false", *chowns)
+	}
+}
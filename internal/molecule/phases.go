@@ -0,0 +1,136 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"diffusion/internal/config"
+)
+
+// Phases selectable with --only and --phases
+const (
+	PhaseCreate      = "create"
+	PhasePrepare     = "prepare"
+	PhaseConverge    = "converge"
+	PhaseVerify      = "verify"
+	PhaseLint        = "lint"
+	PhaseIdempotence = "idempotence"
+	PhaseDestroy     = "destroy"
+	PhaseFull        = "full" // The default flow: start the container, create and converge
+)
+
+var knownPhases = []string{PhaseCreate, PhasePrepare, PhaseConverge, PhaseVerify, PhaseLint, PhaseIdempotence, PhaseDestroy, PhaseFull}
+
+// ParsePhases validates the phases of --only or --phases, keeping their order.
+// full stands for the whole default flow and cannot be combined with others.
+func ParsePhases(values []string) ([]string, error) {
+	phases := make([]string, 0, len(values))
+	for _, value := range values {
+		phase := strings.TrimSpace(value)
+		if !slices.Contains(knownPhases, phase) {
+			return nil, fmt.Errorf("unknown phase %q (want %s)", value, strings.Join(knownPhases, "|"))
+		}
+		if slices.Contains(phases, phase) {
+			return nil, fmt.Errorf("phase %q is listed twice", phase)
+		}
+		phases = append(phases, phase)
+	}
+	if len(phases) == 0 {
+		return nil, fmt.Errorf("no phase given (want %s)", strings.Join(knownPhases, "|"))
+	}
+	if len(phases) > 1 && slices.Contains(phases, PhaseFull) {
+		return nil, fmt.Errorf("phase %q runs the whole default flow and cannot be combined with other phases", PhaseFull)
+	}
+	return phases, nil
+}
+
+// validatePhases rejects --only/--phases mixed with the deprecated phase flags
+func validatePhases(opts *MoleculeOptions) error {
+	if len(opts.Phases) == 0 {
+		return nil
+	}
+	if opts.PrepareFlag || opts.ConvergeFlag || opts.LintFlag || (opts.VerifyFlag && !opts.VerifyOnly) || opts.IdempotenceFlag || opts.DestroyFlag {
+		return fmt.Errorf("--only/--phases cannot be combined with --prepare, --converge, --verify, --lint, --idempotence or --destroy")
+	}
+	if opts.VerifyOnly && !slices.Equal(opts.Phases, []string{PhaseVerify}) {
+		return fmt.Errorf("--verify-only only runs the verify phase (use --only verify)")
+	}
+	return nil
+}
+
+// selectedPhases returns the phases the run executes, in order: opts.Phases, or
+// the deprecated boolean flags mapped the way their if-chain ran them (prepare
+// first, then only the first of converge, lint, verify, idempotence, destroy).
+// An empty list means the default flow.
+func selectedPhases(opts *MoleculeOptions) []string {
+	if len(opts.Phases) > 0 {
+		return opts.Phases
+	}
+	var phases []string
+	if opts.PrepareFlag {
+		phases = append(phases, PhasePrepare)
+	}
+	switch {
+	case opts.ConvergeFlag:
+		phases = append(phases, PhaseConverge)
+	case opts.LintFlag:
+		phases = append(phases, PhaseLint)
+	case opts.VerifyFlag:
+		phases = append(phases, PhaseVerify)
+	case opts.IdempotenceFlag:
+		phases = append(phases, PhaseIdempotence)
+	case opts.DestroyFlag:
+		phases = append(phases, PhaseDestroy)
+	}
+	return phases
+}
+
+// runsPhase reports whether phase is one of the selected phases
+func runsPhase(opts *MoleculeOptions, phase string) bool {
+	return slices.Contains(selectedPhases(opts), phase)
+}
+
+// runPhases runs the selected phases in order inside the existing container,
+// stopping at the first failure
+func runPhases(opts *MoleculeOptions, cfg *config.Config, path, roleDirName, roleMoleculePath string) error {
+	scenario := activeScenario(opts)
+	for _, phase := range selectedPhases(opts) {
+		var err error
+		switch phase {
+		case PhaseCreate:
+			err = withCISection(opts, phase, func() error { return runCreate(opts, roleDirName) })
+		case PhasePrepare:
+			err = withCISection(opts, phase, func() error { return runPrepare(opts, roleDirName) })
+		case PhaseConverge:
+			err = withCISection(opts, phase, func() error { return runConverge(opts, roleDirName) })
+		case PhaseLint:
+			err = withCISection(opts, phase, func() error { return runLint(opts, roleDirName) })
+		case PhaseVerify:
+			err = afterVerify(opts, roleDirName, withCISection(opts, phase, func() error {
+				return runVerify(opts, cfg, path, roleDirName, roleMoleculePath, scenario)
+			}))
+		case PhaseIdempotence:
+			err = runIdempotence(opts, roleDirName)
+		case PhaseDestroy:
+			err = runDestroy(opts, roleDirName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCreate runs molecule create inside the container.
+func runCreate(opts *MoleculeOptions, roleDirName string) error {
+	cmdStr := moleculeCommand(opts, roleDirName, "", "create")
+	if err := execMoleculePhase(opts, roleDirName, PhaseCreate, cmdStr); err != nil {
+		log.Printf(config.ColorRed+"Create failed: %v"+config.ColorReset, err)
+		printCgroupHint(detectCgroupVersion(hostCgroupRoot))
+		return fmt.Errorf("create failed: %w", err)
+	}
+	log.Printf(config.ColorGreen + "Create Done Successfully!" + config.ColorReset)
+	return nil
+}
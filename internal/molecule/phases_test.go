@@ -0,0 +1,115 @@
+package molecule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestParsePhases(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr string
+	}{
+		{"single", []string{"verify"}, []string{"verify"}, ""},
+		{"ordered list", []string{"converge", " verify", "idempotence"}, []string{"converge", "verify", "idempotence"}, ""},
+		{"full alone", []string{"full"}, []string{"full"}, ""},
+		{"unknown", []string{"converge", "test"}, nil, `unknown phase "test"`},
+		{"empty value", []string{""}, nil, `unknown phase ""`},
+		{"duplicate", []string{"converge", "converge"}, nil, "listed twice"},
+		{"full combined", []string{"full", "verify"}, nil, "cannot be combined"},
+		{"none", nil, nil, "no phase given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePhases(tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePhases() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePhases() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePhases() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectedPhasesFromFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MoleculeOptions
+		want []string
+	}{
+		{"default flow", MoleculeOptions{}, nil},
+		{"converge", MoleculeOptions{ConvergeFlag: true}, []string{"converge"}},
+		{"prepare then converge", MoleculeOptions{PrepareFlag: true, ConvergeFlag: true}, []string{"prepare", "converge"}},
+		{"first flag wins", MoleculeOptions{VerifyFlag: true, LintFlag: true, DestroyFlag: true}, []string{"lint"}},
+		{"phases win", MoleculeOptions{Phases: []string{"verify", "destroy"}}, []string{"verify", "destroy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectedPhases(&tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectedPhases() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if hasPhaseFlag(&MoleculeOptions{Phases: []string{PhaseFull}}) {
+		t.Error("--only full should run the default flow")
+	}
+	if !isConverging(&MoleculeOptions{Phases: []string{PhaseFull}}) || isConverging(&MoleculeOptions{Phases: []string{PhaseVerify}}) {
+		t.Error("isConverging() should follow the selected phases")
+	}
+}
+
+func TestValidatePhases(t *testing.T) {
+	if err := validatePhases(&MoleculeOptions{Phases: []string{"converge"}, VerifyFlag: true}); err == nil {
+		t.Error("expected --phases with --verify to be rejected")
+	}
+	if err := validatePhases(&MoleculeOptions{Phases: []string{"verify"}, VerifyFlag: true, VerifyOnly: true}); err != nil {
+		t.Errorf("--verify-only with --only verify: %v", err)
+	}
+	if err := validatePhases(&MoleculeOptions{Phases: []string{"converge", "verify"}, VerifyFlag: true, VerifyOnly: true}); err == nil {
+		t.Error("expected --verify-only with a converge phase to be rejected")
+	}
+}
+
+func TestRunPhasesInOrder(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	opts := &MoleculeOptions{RoleFlag: "web", RoleScenario: "ubuntu", Phases: []string{"create", "converge", "idempotence", "destroy"}}
+
+	if err := runPhases(opts, &config.Config{}, t.TempDir(), "acme.web", t.TempDir()); err != nil {
+		t.Fatalf("runPhases() error = %v", err)
+	}
+	want := []string{
+		"cd ./acme.web && molecule create -s ubuntu",
+		"cd ./acme.web && molecule converge -s ubuntu",
+		"cd ./acme.web && molecule idempotence -s ubuntu",
+		"cd ./acme.web && molecule destroy -s ubuntu",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("calls = %q, want %q", *calls, want)
+	}
+}
+
+func TestRunPhasesStopsAtFirstFailure(t *testing.T) {
+	calls := stubPhaseExec(t, 1, true)
+	opts := &MoleculeOptions{RoleFlag: "web", Phases: []string{"converge", "idempotence", "destroy"}}
+
+	err := runPhases(opts, &config.Config{}, t.TempDir(), "acme.web", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "converge failed") {
+		t.Fatalf("runPhases() error = %v, want the converge failure", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("calls = %q, want nothing after the failed converge", *calls)
+	}
+}
@@ -0,0 +1,87 @@
+package molecule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultPlatformName matches the instance name used by 'molecule init' scenarios
+const defaultPlatformName = "instance"
+
+// Platform is a runtime platform override given with --platform name=<n>,image=<img>
+type Platform struct {
+	Name  string
+	Image string
+}
+
+// ParsePlatform parses a --platform value of the form name=<n>,image=<img>.
+// The name is optional and defaults to "instance".
+func ParsePlatform(value string) (Platform, error) {
+	var p Platform
+	for _, part := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Platform{}, fmt.Errorf("invalid platform %q: expected name=<name>,image=<image>", value)
+		}
+		val = strings.TrimSpace(val)
+		switch strings.TrimSpace(key) {
+		case "name":
+			p.Name = val
+		case "image":
+			p.Image = val
+		default:
+			return Platform{}, fmt.Errorf("invalid platform %q: unknown key %q (allowed: name, image)", value, key)
+		}
+	}
+	if p.Image == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q: image is required", value)
+	}
+	if p.Name == "" {
+		p.Name = defaultPlatformName
+	}
+	return p, nil
+}
+
+// platformEnv returns the MOLECULE_PLATFORM_* variables molecule.yml can reference:
+// MOLECULE_PLATFORM_NAME/IMAGE for the first platform and
+// MOLECULE_PLATFORM_<n>_NAME/IMAGE (1-based) for every platform.
+func platformEnv(platforms []Platform) []string {
+	if len(platforms) == 0 {
+		return nil
+	}
+	env := []string{
+		"MOLECULE_PLATFORM_NAME=" + platforms[0].Name,
+		"MOLECULE_PLATFORM_IMAGE=" + platforms[0].Image,
+	}
+	for i, p := range platforms {
+		env = append(env,
+			fmt.Sprintf("MOLECULE_PLATFORM_%d_NAME=%s", i+1, p.Name),
+			fmt.Sprintf("MOLECULE_PLATFORM_%d_IMAGE=%s", i+1, p.Image),
+		)
+	}
+	return env
+}
+
+// platformEnvArgs returns the platform variables as docker run -e arguments
+func platformEnvArgs(platforms []Platform) []string {
+	var args []string
+	for _, kv := range platformEnv(platforms) {
+		args = append(args, "-e", kv)
+	}
+	return args
+}
+
+// platformEnvPrefix returns the platform variables as a shell export prefix, so
+// phases run in a container started without them still get the override.
+func platformEnvPrefix(platforms []Platform) string {
+	env := platformEnv(platforms)
+	if len(env) == 0 {
+		return ""
+	}
+	assignments := make([]string, 0, len(env))
+	for _, kv := range env {
+		key, val, _ := strings.Cut(kv, "=")
+		assignments = append(assignments, key+"="+shellQuote(val))
+	}
+	return "export " + strings.Join(assignments, " ") + " && "
+}
@@ -0,0 +1,76 @@
+package molecule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Platform
+		wantErr string
+	}{
+		{value: "name=ubuntu,image=ubuntu:22.04", want: Platform{Name: "ubuntu", Image: "ubuntu:22.04"}},
+		{value: "image=quay.io/rockylinux/rockylinux:9, name=rocky", want: Platform{Name: "rocky", Image: "quay.io/rockylinux/rockylinux:9"}},
+		{value: "image=debian:12", want: Platform{Name: "instance", Image: "debian:12"}},
+		{value: "name=ubuntu", wantErr: "image is required"},
+		{value: "ubuntu:22.04", wantErr: "expected name=<name>,image=<image>"},
+		{value: "name=a,image=b,arch=arm64", wantErr: `unknown key "arch"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePlatform(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformEnv(t *testing.T) {
+	if env := platformEnv(nil); env != nil {
+		t.Errorf("expected no env without platforms, got %v", env)
+	}
+	if prefix := platformEnvPrefix(nil); prefix != "" {
+		t.Errorf("expected empty prefix without platforms, got %q", prefix)
+	}
+
+	platforms := []Platform{{Name: "ubuntu", Image: "ubuntu:22.04"}, {Name: "rocky", Image: "rockylinux:9"}}
+	want := []string{
+		"MOLECULE_PLATFORM_NAME=ubuntu",
+		"MOLECULE_PLATFORM_IMAGE=ubuntu:22.04",
+		"MOLECULE_PLATFORM_1_NAME=ubuntu",
+		"MOLECULE_PLATFORM_1_IMAGE=ubuntu:22.04",
+		"MOLECULE_PLATFORM_2_NAME=rocky",
+		"MOLECULE_PLATFORM_2_IMAGE=rockylinux:9",
+	}
+	if got := platformEnv(platforms); !reflect.DeepEqual(got, want) {
+		t.Errorf("platformEnv() = %v, want %v", got, want)
+	}
+
+	args := platformEnvArgs(platforms[:1])
+	wantArgs := []string{"-e", "MOLECULE_PLATFORM_NAME=ubuntu", "-e", "MOLECULE_PLATFORM_IMAGE=ubuntu:22.04",
+		"-e", "MOLECULE_PLATFORM_1_NAME=ubuntu", "-e", "MOLECULE_PLATFORM_1_IMAGE=ubuntu:22.04"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("platformEnvArgs() = %v, want %v", args, wantArgs)
+	}
+
+	prefix := platformEnvPrefix(platforms[:1])
+	wantPrefix := "export MOLECULE_PLATFORM_NAME='ubuntu' MOLECULE_PLATFORM_IMAGE='ubuntu:22.04' " +
+		"MOLECULE_PLATFORM_1_NAME='ubuntu' MOLECULE_PLATFORM_1_IMAGE='ubuntu:22.04' && "
+	if prefix != wantPrefix {
+		t.Errorf("platformEnvPrefix() = %q, want %q", prefix, wantPrefix)
+	}
+}
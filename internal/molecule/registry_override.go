@@ -0,0 +1,29 @@
+package molecule
+
+import (
+	"log"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// applyRegistryOverrides replaces the registry server, image and tag from
+// [container_registry] with the --registry-* flags for this run. The config
+// keeps its own copy, so nothing is written back to diffusion.toml.
+func applyRegistryOverrides(opts *MoleculeOptions, cfg *config.Config) {
+	if opts.RegistryServer == "" && opts.RegistryImage == "" && opts.RegistryTag == "" {
+		return
+	}
+	registry := *cfg.ContainerRegistry
+	if opts.RegistryServer != "" {
+		registry.RegistryServer = opts.RegistryServer
+	}
+	if opts.RegistryImage != "" {
+		registry.MoleculeContainerName = opts.RegistryImage
+	}
+	if opts.RegistryTag != "" {
+		registry.MoleculeContainerTag = opts.RegistryTag
+	}
+	cfg.ContainerRegistry = &registry
+	log.Printf(config.ColorYellow+"Using molecule image %s for this run"+config.ColorReset, utils.GetImageURL(cfg.ContainerRegistry))
+}
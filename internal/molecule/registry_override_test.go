@@ -0,0 +1,34 @@
+package molecule
+
+import (
+	"testing"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+func TestApplyRegistryOverrides(t *testing.T) {
+	configured := config.ContainerRegistry{RegistryServer: "ghcr.io", MoleculeContainerName: "polar-team/diffusion-molecule-container", MoleculeContainerTag: "latest-amd64"}
+	tests := []struct {
+		name string
+		opts MoleculeOptions
+		want string
+	}{
+		{"config value", MoleculeOptions{}, "ghcr.io/polar-team/diffusion-molecule-container:latest-amd64"},
+		{"tag", MoleculeOptions{RegistryTag: "rc1-amd64"}, "ghcr.io/polar-team/diffusion-molecule-container:rc1-amd64"},
+		{"all", MoleculeOptions{RegistryServer: "registry.example.com", RegistryImage: "qa/molecule", RegistryTag: "next"}, "registry.example.com/qa/molecule:next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := configured
+			cfg := &config.Config{ContainerRegistry: &registry}
+			applyRegistryOverrides(&tt.opts, cfg)
+			if got := utils.GetImageURL(cfg.ContainerRegistry); got != tt.want {
+				t.Errorf("GetImageURL() = %q, want %q", got, tt.want)
+			}
+			if registry != configured {
+				t.Errorf("override changed the loaded config: %+v", registry)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package molecule
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Step statuses recorded by ResultCollector
+const (
+	StepPassed   = "passed"
+	StepFailed   = "failed"
+	StepTimedOut = "timed_out"
+)
+
+// StepResult is one molecule phase run (each --retry attempt is its own step)
+type StepResult struct {
+	Scenario        string  `json:"scenario"`
+	Phase           string  `json:"phase"`
+	Status          string  `json:"status"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// ResultCollector records the phases of a run for --report-json
+type ResultCollector struct {
+	mu    sync.Mutex
+	steps []StepResult
+}
+
+// NewResultCollector returns an empty collector
+func NewResultCollector() *ResultCollector {
+	return &ResultCollector{}
+}
+
+// Record adds a step; a nil collector records nothing
+func (c *ResultCollector) Record(scenario, phase string, duration time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	step := StepResult{
+		Scenario:        scenario,
+		Phase:           phase,
+		Status:          StepPassed,
+		DurationSeconds: duration.Round(time.Millisecond).Seconds(),
+	}
+	if err != nil {
+		step.Status = StepFailed
+		if errors.Is(err, errPhaseTimedOut) {
+			step.Status = StepTimedOut
+		}
+		step.Error = err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, step)
+}
+
+// Steps returns the recorded steps in run order
+func (c *ResultCollector) Steps() []StepResult {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]StepResult(nil), c.steps...)
+}
@@ -0,0 +1,46 @@
+package molecule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestResultCollectorRecordsPhases(t *testing.T) {
+	stubPhaseExec(t, 1, true)
+	opts := &MoleculeOptions{RoleFlag: "web", RoleScenario: "ubuntu", Retry: 1, Results: NewResultCollector()}
+
+	if err := runPhaseWithRetry(opts, "web", "converge", "molecule converge", nil); err != nil {
+		t.Fatalf("runPhaseWithRetry() error = %v", err)
+	}
+
+	steps := opts.Results.Steps()
+	if len(steps) != 2 {
+		t.Fatalf("recorded %d steps, want 2 (failed attempt and retry)", len(steps))
+	}
+	if steps[0].Status != StepFailed || steps[0].Error == "" {
+		t.Errorf("first attempt = %+v, want failed with error", steps[0])
+	}
+	if steps[1].Status != StepPassed || steps[1].Error != "" {
+		t.Errorf("retry = %+v, want passed", steps[1])
+	}
+	for _, step := range steps {
+		if step.Scenario != "ubuntu" || step.Phase != "converge" {
+			t.Errorf("step = %+v, want scenario ubuntu, phase converge", step)
+		}
+	}
+}
+
+func TestResultCollectorStatus(t *testing.T) {
+	c := NewResultCollector()
+	c.Record("default", "verify", time.Second, fmt.Errorf("verify %w after 1s", errPhaseTimedOut))
+	if got := c.Steps()[0]; got.Status != StepTimedOut || got.DurationSeconds != 1 {
+		t.Errorf("step = %+v, want timed_out after 1s", got)
+	}
+
+	var nilCollector *ResultCollector
+	nilCollector.Record("default", "converge", time.Second, nil)
+	if steps := nilCollector.Steps(); steps != nil {
+		t.Errorf("nil collector Steps() = %v, want nil", steps)
+	}
+}
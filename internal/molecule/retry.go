@@ -0,0 +1,77 @@
+package molecule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// errPhaseTimedOut marks a phase killed by --timeout; such failures are not retried
+var errPhaseTimedOut = errors.New("timed out")
+
+// phaseExec runs a molecule phase shell command inside the container. When
+// opts.phaseOutput is set the output is captured there too, and only streamed
+// with --verbose. Tests replace it with a stub runner.
+var phaseExec = func(ctx context.Context, opts *MoleculeOptions, cmdStr string) error {
+	if opts.phaseOutput != nil {
+		return utils.DockerExecCaptureContext(ctx, opts.RoleFlag, "/bin/sh", opts.CIMode, opts.Verbosity > 0, opts.phaseOutput, "-c", cmdStr)
+	}
+	return utils.DockerExecInteractiveContext(ctx, opts.RoleFlag, "/bin/sh", opts.CIMode, "-c", cmdStr)
+}
+
+// retryDelay is the pause between --retry attempts; shortened in tests
+var retryDelay = 10 * time.Second
+
+// runPhaseWithRetry runs a molecule phase and re-runs only the phase command up
+// to opts.Retry times when it fails; credentials, registry logins and container
+// setup are not repeated. A container stopped by the failure is started again;
+// if it is gone or will not start, recreate (when non-nil) starts a new one
+// before the next attempt; without it retrying stops, since every further exec
+// would fail the same way.
+func runPhaseWithRetry(opts *MoleculeOptions, roleDirName, phase, cmdStr string, recreate func() error) error {
+	err := execMoleculePhase(opts, roleDirName, phase, cmdStr)
+	for attempt := 1; err != nil && attempt <= opts.Retry; attempt++ {
+		if errors.Is(err, errPhaseTimedOut) {
+			return err
+		}
+		if !reviveContainer(opts) {
+			if recreate == nil {
+				return fmt.Errorf("%w (container %s is gone, not retrying)", err, ContainerName(opts.RoleFlag))
+			}
+			log.Printf(config.ColorYellow+"Container %s is gone, recreating it before retrying %s..."+config.ColorReset, ContainerName(opts.RoleFlag), phase)
+			if rerr := recreate(); rerr != nil {
+				return fmt.Errorf("%w (failed to recreate container: %v)", err, rerr)
+			}
+		}
+
+		log.Printf(config.ColorYellow+"%s failed: %v; retrying in %s (attempt %d/%d)"+config.ColorReset, phase, err, retryDelay, attempt, opts.Retry)
+		time.Sleep(retryDelay)
+		err = execMoleculePhase(opts, roleDirName, phase, cmdStr)
+	}
+	return err
+}
+
+// recreateContainerFunc returns the callback that restarts a vanished container
+// during --retry in the default flow. Outside CI the role directory is a bind
+// mount, so a new container only needs the registry login. In CI the role is
+// cloned into the container and the whole setup would have to run again, so
+// nil is returned and retrying stops instead.
+func recreateContainerFunc(opts *MoleculeOptions, cfg *config.Config, path, roleDirName string) func() error {
+	if opts.CIMode {
+		return nil
+	}
+	return func() error {
+		if err := runContainer(opts, cfg, path, roleDirName); err != nil {
+			return err
+		}
+		loginInsideContainer(opts, cfg)
+		// Best-effort, as in the default flow
+		_ = utils.DockerExecInteractiveHide(opts.RoleFlag, "uv-sync", opts.CIMode)
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package molecule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubPhaseExec replaces the container exec with a runner that fails the first
+// failures calls and records every command
+func stubPhaseExec(t *testing.T, failures int, exists bool) *[]string {
+	t.Helper()
+	origExec, origExists, origPresent, origDelay := phaseExec, containerExists, containerPresent, retryDelay
+	t.Cleanup(func() {
+		phaseExec, containerExists, containerPresent, retryDelay = origExec, origExists, origPresent, origDelay
+	})
+
+	retryDelay = 0
+	containerExists = func(*MoleculeOptions) bool { return exists }
+	containerPresent = func(*MoleculeOptions) bool { return exists }
+	var calls []string
+	phaseExec = func(_ context.Context, _ *MoleculeOptions, cmdStr string) error {
+		calls = append(calls, cmdStr)
+		if len(calls) <= failures {
+			return errors.New("exit status 2")
+		}
+		return nil
+	}
+	return &calls
+}
+
+func TestRunPhaseWithRetrySucceedsAfterFailures(t *testing.T) {
+	calls := stubPhaseExec(t, 2, true)
+	opts := &MoleculeOptions{RoleFlag: "web", Retry: 2}
+
+	recreated := 0
+	err := runPhaseWithRetry(opts, "web", "converge", "cd ./web && molecule converge", func() error {
+		recreated++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runPhaseWithRetry() error = %v", err)
+	}
+	if len(*calls) != 3 {
+		t.Errorf("phase ran %d times, want 3", len(*calls))
+	}
+	for _, c := range *calls {
+		if !strings.HasSuffix(c, "molecule converge") {
+			t.Errorf("only the phase command should be retried, got %q", c)
+		}
+	}
+	if recreated != 0 {
+		t.Errorf("container recreated %d times while it still exists", recreated)
+	}
+}
+
+func TestRunPhaseWithRetryGivesUp(t *testing.T) {
+	calls := stubPhaseExec(t, 2, true)
+	opts := &MoleculeOptions{RoleFlag: "web", Retry: 1}
+
+	if err := runPhaseWithRetry(opts, "web", "verify", "molecule verify", nil); err == nil {
+		t.Fatal("runPhaseWithRetry() expected error after exhausting retries")
+	}
+	if len(*calls) != 2 {
+		t.Errorf("phase ran %d times, want 2", len(*calls))
+	}
+
+	// default: no retry
+	*calls = nil
+	opts.Retry = 0
+	if err := runPhaseWithRetry(opts, "web", "verify", "molecule verify", nil); err == nil {
+		t.Fatal("runPhaseWithRetry() expected error without --retry")
+	}
+	if len(*calls) != 1 {
+		t.Errorf("phase ran %d times without --retry, want 1", len(*calls))
+	}
+}
+
+func TestRunPhaseWithRetryContainerGone(t *testing.T) {
+	calls := stubPhaseExec(t, 1, false)
+	opts := &MoleculeOptions{RoleFlag: "web", Retry: 3}
+
+	if err := runPhaseWithRetry(opts, "web", "converge", "molecule converge", nil); err == nil || !strings.Contains(err.Error(), "gone") {
+		t.Fatalf("runPhaseWithRetry() error = %v, want container gone error", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("phase ran %d times without a recreate callback, want 1", len(*calls))
+	}
+
+	*calls = nil
+	recreated := 0
+	if err := runPhaseWithRetry(opts, "web", "converge", "molecule converge", func() error {
+		recreated++
+		return nil
+	}); err != nil {
+		t.Fatalf("runPhaseWithRetry() error = %v", err)
+	}
+	if recreated != 1 {
+		t.Errorf("container recreated %d times, want 1", recreated)
+	}
+}
+
+func TestRunPhaseWithRetrySkipsTimeouts(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	origKill := phaseKill
+	t.Cleanup(func() { phaseKill = origKill })
+	phaseKill = func(*MoleculeOptions, string) error { return nil }
+	phaseExec = func(ctx context.Context, _ *MoleculeOptions, cmdStr string) error {
+		*calls = append(*calls, cmdStr)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "web", Retry: 2, Timeout: time.Millisecond}
+	// destroy skips the molecule destroy cleanup that other phases run on timeout
+	err := runPhaseWithRetry(opts, "web", "destroy", "molecule destroy", nil)
+	if !errors.Is(err, errPhaseTimedOut) {
+		t.Fatalf("runPhaseWithRetry() error = %v, want timeout", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("timed out phase ran %d times, want 1", len(*calls))
+	}
+}
@@ -0,0 +1,128 @@
+package molecule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// roleStateVersion is the current format of the role state file
+const roleStateVersion = 1
+
+// RoleState is persisted under ~/.diffusion/state/<org>.<role>.<scenario>.hash
+// and records the role input hash of the last successful converge of that scenario.
+type RoleState struct {
+	Version     int       `json:"version"`
+	Hash        string    `json:"hash"`
+	ConvergedAt time.Time `json:"converged_at"`
+}
+
+// stateDir returns the directory holding role state files; replaced in tests
+var stateDir = func() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".diffusion", "state"), nil
+}
+
+// roleStatePath returns the state file path for the given role directory name
+// (<org>.<role>) and scenario
+func roleStatePath(roleDirName, scenario string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, roleDirName+"."+scenario+".hash"), nil
+}
+
+// loadRoleState reads a role state file. A missing file yields (nil, nil).
+func loadRoleState(path string) (*RoleState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st RoleState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// saveRoleState writes a role state file, creating the state directory if needed
+func saveRoleState(path string, st *RoleState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// checkUnchanged hashes the role inputs for --only-changed and reports whether the
+// last successful converge of the active scenario (of every scenario with
+// --all-scenarios) used the same inputs. The computed hash is kept on opts so
+// recordConverge can store it once the run succeeds.
+func checkUnchanged(opts *MoleculeOptions, path, roleDirName string) (bool, error) {
+	hash, err := utils.HashRoleInputs(path)
+	if err != nil {
+		return false, err
+	}
+	opts.inputHash = hash
+
+	if opts.ForceFlag {
+		return false, nil
+	}
+	scenarios := []string{activeScenario(opts)}
+	if opts.AllScenarios {
+		if scenarios, err = discoverScenarios(path); err != nil {
+			return false, nil // the run reports the missing scenarios
+		}
+	}
+	for _, scenario := range scenarios {
+		statePath, err := roleStatePath(roleDirName, scenario)
+		if err != nil {
+			return false, err
+		}
+		st, err := loadRoleState(statePath)
+		if err != nil {
+			log.Printf(config.ColorYellow+"warning: ignoring role state: %v"+config.ColorReset, err)
+			return false, nil
+		}
+		if st == nil || st.Hash != hash || st.ConvergedAt.IsZero() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// recordConverge stores the input hash after a successful converge of the
+// active scenario. It is a no-op unless --only-changed computed a hash for this run.
+func recordConverge(opts *MoleculeOptions, roleDirName string) {
+	if opts.inputHash == "" {
+		return
+	}
+	statePath, err := roleStatePath(roleDirName, activeScenario(opts))
+	if err == nil {
+		err = saveRoleState(statePath, &RoleState{
+			Version:     roleStateVersion,
+			Hash:        opts.inputHash,
+			ConvergedAt: time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to save role state: %v"+config.ColorReset, err)
+	}
+}
@@ -0,0 +1,101 @@
+package molecule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func withStateDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { stateDir = orig })
+	return dir
+}
+
+func TestOnlyChangedState(t *testing.T) {
+	stateRoot := withStateDir(t)
+	role := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(role, "tasks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(role, "tasks", "main.yml"), []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &MoleculeOptions{OnlyChangedFlag: true}
+	if unchanged, err := checkUnchanged(opts, role, "org.role"); err != nil || unchanged {
+		t.Fatalf("first run: unchanged = %v, err = %v; want false, nil", unchanged, err)
+	}
+
+	recordConverge(opts, "org.role")
+	st, err := loadRoleState(filepath.Join(stateRoot, "org.role.default.hash"))
+	if err != nil || st == nil {
+		t.Fatalf("loadRoleState() = %v, %v", st, err)
+	}
+	if st.Version != roleStateVersion || st.Hash != opts.inputHash || st.ConvergedAt.IsZero() {
+		t.Errorf("unexpected state %+v", st)
+	}
+
+	opts = &MoleculeOptions{OnlyChangedFlag: true}
+	if unchanged, _ := checkUnchanged(opts, role, "org.role"); !unchanged {
+		t.Error("second run with same inputs should be unchanged")
+	}
+
+	forced := &MoleculeOptions{OnlyChangedFlag: true, ForceFlag: true}
+	if unchanged, _ := checkUnchanged(forced, role, "org.role"); unchanged {
+		t.Error("--force should bypass the unchanged check")
+	}
+
+	if err := os.WriteFile(filepath.Join(role, "tasks", "main.yml"), []byte("- name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if unchanged, _ := checkUnchanged(&MoleculeOptions{OnlyChangedFlag: true}, role, "org.role"); unchanged {
+		t.Error("edited role should not be unchanged")
+	}
+}
+
+func TestRecordConvergeWithoutHash(t *testing.T) {
+	stateRoot := withStateDir(t)
+	recordConverge(&MoleculeOptions{}, "org.role")
+	if _, err := os.Stat(filepath.Join(stateRoot, "org.role.default.hash")); !os.IsNotExist(err) {
+		t.Errorf("state written without --only-changed: %v", err)
+	}
+}
+
+func TestOnlyChangedStatePerScenario(t *testing.T) {
+	withStateDir(t)
+	role := t.TempDir()
+	for _, dir := range []string{"tasks", "scenarios/default", "scenarios/ubuntu"} {
+		if err := os.MkdirAll(filepath.Join(role, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(role, "tasks", "main.yml"), []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ubuntu := &MoleculeOptions{OnlyChangedFlag: true, RoleScenario: "ubuntu"}
+	if _, err := checkUnchanged(ubuntu, role, "org.role"); err != nil {
+		t.Fatal(err)
+	}
+	recordConverge(ubuntu, "org.role")
+
+	if unchanged, _ := checkUnchanged(&MoleculeOptions{OnlyChangedFlag: true, RoleScenario: "ubuntu"}, role, "org.role"); !unchanged {
+		t.Error("converged scenario with same inputs should be unchanged")
+	}
+	if unchanged, _ := checkUnchanged(&MoleculeOptions{OnlyChangedFlag: true}, role, "org.role"); unchanged {
+		t.Error("a converge of ubuntu should not skip the default scenario")
+	}
+	all := &MoleculeOptions{OnlyChangedFlag: true, AllScenarios: true}
+	if unchanged, _ := checkUnchanged(all, role, "org.role"); unchanged {
+		t.Error("--all-scenarios should run until every scenario converged")
+	}
+
+	recordConverge(&MoleculeOptions{RoleScenario: "default", inputHash: all.inputHash}, "org.role")
+	if unchanged, _ := checkUnchanged(&MoleculeOptions{OnlyChangedFlag: true, AllScenarios: true}, role, "org.role"); !unchanged {
+		t.Error("--all-scenarios should skip once every scenario converged")
+	}
+}
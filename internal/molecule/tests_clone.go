@@ -0,0 +1,145 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// cloneAttempts is how many times a test repository clone is tried before giving up
+const cloneAttempts = 3
+
+// testsExec runs a command inside the molecule container while setting up
+// verify tests. Tests replace it to simulate clone failures.
+var testsExec = func(opts *MoleculeOptions, name string, args ...string) error {
+	return utils.DockerExecInteractiveHide(opts.RoleFlag, name, opts.CIMode, args...)
+}
+
+// testsGit runs git on the host for the tests repository cache; tests replace it
+var testsGit = func(args ...string) ([]byte, error) {
+	return exec.Command("git", args...).CombinedOutput()
+}
+
+// testsCopyToContainer copies the contents of a cached tests repository into
+// the container (CI mode, where /opt/molecule is not a bind mount)
+var testsCopyToContainer = func(opts *MoleculeOptions, src, dst string) error {
+	return exec.Command("docker", "cp", src+"/.", ContainerName(opts.RoleFlag)+":"+dst).Run()
+}
+
+// cloneBackoff returns the wait before the given retry (1s, 2s, ...)
+var cloneBackoff = func(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt)) * time.Second
+}
+
+// cloneWithRetry clones repoURL into dir, retrying transient failures with
+// exponential backoff. Whatever a failed attempt left in dir is removed first.
+func cloneWithRetry(repoURL, dir string) error {
+	var err error
+	for attempt := range cloneAttempts {
+		if err = os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", dir, err)
+		}
+		var out []byte
+		if out, err = testsGit("clone", repoURL, dir); err == nil {
+			return nil
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		log.Printf(config.ColorYellow+"warning: cloning %s failed (attempt %d/%d): %v"+config.ColorReset, repoURL, attempt+1, cloneAttempts, err)
+		if attempt < cloneAttempts-1 {
+			time.Sleep(cloneBackoff(attempt))
+		}
+	}
+	return err
+}
+
+// syncTestsRepo makes the cached clone of repoURL current and returns its
+// directory. A cached clone is pulled, reused as is with --keep-tests-cache or
+// cloned again with --refresh-tests. The commit is recorded next to the clone.
+func syncTestsRepo(opts *MoleculeOptions, repoURL string) (string, error) {
+	dir, err := cache.TestsRepoDir(repoURL)
+	if err != nil {
+		return "", err
+	}
+	cached := utils.Exists(filepath.Join(dir, ".git"))
+	if cached && opts.RefreshTests {
+		log.Printf(config.ColorGreen+"Refreshing cached tests repository %s..."+config.ColorReset, repoURL)
+		cached = false
+	}
+
+	switch {
+	case cached && opts.KeepTestsCache:
+		commit := "unknown commit"
+		if state, err := cache.LoadTestsRepoState(dir); err == nil && state != nil {
+			commit = state.Commit
+		}
+		log.Printf(config.ColorGreen+"Reusing cached tests repository %s (%s)"+config.ColorReset, repoURL, commit)
+		return dir, nil
+	case cached:
+		log.Printf(config.ColorGreen+"Updating tests repository %s..."+config.ColorReset, repoURL)
+		if out, err := testsGit("-C", dir, "pull", "--ff-only"); err != nil {
+			log.Printf(config.ColorYellow+"warning: failed to update %s, using the cached copy: %v %s"+config.ColorReset, repoURL, err, strings.TrimSpace(string(out)))
+		}
+	default:
+		log.Printf(config.ColorGreen+"Cloning tests repository %s..."+config.ColorReset, repoURL)
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create tests cache directory: %w", err)
+		}
+		if err := cloneWithRetry(repoURL, dir); err != nil {
+			return "", fmt.Errorf("failed to clone tests repository %s: %w", repoURL, err)
+		}
+	}
+
+	out, err := testsGit("-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the commit of %s: %w", repoURL, err)
+	}
+	state := cache.TestsRepoState{URL: repoURL, Commit: strings.TrimSpace(string(out)), FetchedAt: time.Now().UTC()}
+	if err := cache.SaveTestsRepoState(dir, state); err != nil {
+		log.Printf(config.ColorYellow+"warning: %v"+config.ColorReset, err)
+	}
+	return dir, nil
+}
+
+// installTestsRepo copies a cached tests repository, without .git, into
+// tests/<name> of the scenario. --testsoverwrite removes the previous copy
+// first; otherwise the files are copied over it.
+func installTestsRepo(opts *MoleculeOptions, src, roleMoleculePath, scenario, name string) error {
+	if opts.CIMode {
+		dst := path.Join(ContainerRoleDir(opts.OrgFlag, opts.RoleFlag), config.MoleculeDir, scenario, config.TestsDir, name)
+		prepare := fmt.Sprintf("mkdir -p %s", dst)
+		if opts.TestsOverWrite {
+			prepare = fmt.Sprintf("rm -rf %s && %s", dst, prepare)
+		}
+		if err := testsExec(opts, "/bin/sh", "-c", prepare); err != nil {
+			return fmt.Errorf("failed to prepare %s: %w", dst, err)
+		}
+		if err := testsCopyToContainer(opts, src, dst); err != nil {
+			return fmt.Errorf("failed to copy tests into the container: %w", err)
+		}
+		return testsExec(opts, "rm", "-rf", path.Join(dst, ".git"))
+	}
+
+	dst := filepath.Join(roleMoleculePath, config.MoleculeDir, scenario, config.TestsDir, name)
+	if opts.TestsOverWrite {
+		if err := os.RemoveAll(dst); err != nil {
+			return fmt.Errorf("failed to remove %s before overwrite: %w", dst, err)
+		}
+	}
+	return utils.CopyDirIgnoring(src, dst, utils.ParseIgnorePatterns([]string{".git/"}))
+}
+
+// testsRepoName is the tests/ subdirectory a remote tests repository is copied to
+func testsRepoName(repoURL string) string {
+	return strings.TrimSuffix(path.Base(strings.TrimRight(repoURL, "/")), ".git")
+}
@@ -0,0 +1,169 @@
+package molecule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+)
+
+// fakeTestsGit replaces host git for the tests repository cache, failing the
+// first failClones clones. A clone creates the repository with one test file;
+// rev-parse reports commit. It returns the git commands run.
+func fakeTestsGit(t *testing.T, failClones int, commit string) *[]string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	origGit, origBackoff := testsGit, cloneBackoff
+	t.Cleanup(func() { testsGit, cloneBackoff = origGit, origBackoff })
+	cloneBackoff = func(int) time.Duration { return 0 }
+
+	var calls []string
+	testsGit = func(args ...string) ([]byte, error) {
+		calls = append(calls, "git "+strings.Join(args, " "))
+		switch {
+		case args[0] == "clone":
+			if failClones > 0 {
+				failClones--
+				return []byte("fatal: unable to access repository: Could not resolve host"), errors.New("exit status 128")
+			}
+			dir := args[2]
+			if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+				return nil, err
+			}
+			return nil, os.WriteFile(filepath.Join(dir, "test_default.yml"), []byte("- hosts: all\n"), 0644)
+		case len(args) > 2 && args[2] == "rev-parse":
+			return []byte(commit + "\n"), nil
+		}
+		return nil, nil
+	}
+	return &calls
+}
+
+func countClones(calls []string) int {
+	n := 0
+	for _, c := range calls {
+		if strings.HasPrefix(c, "git clone") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestVerifyDiffusionTestsRetriesClone(t *testing.T) {
+	calls := fakeTestsGit(t, 1, "abc123")
+	roleMoleculePath := t.TempDir()
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org"}
+
+	if err := verifyDiffusionTests(opts, &config.Config{}, roleMoleculePath, "default"); err != nil {
+		t.Fatalf("verifyDiffusionTests() error = %v", err)
+	}
+	if got := countClones(*calls); got != 2 {
+		t.Errorf("clone attempts = %d, want 2 (calls: %v)", got, *calls)
+	}
+	installed := filepath.Join(roleMoleculePath, config.MoleculeDir, "default", config.TestsDir, config.DiffusionTestsRoleName)
+	if _, err := os.Stat(filepath.Join(installed, "test_default.yml")); err != nil {
+		t.Errorf("tests not installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installed, ".git")); !os.IsNotExist(err) {
+		t.Error(".git was copied into the scenario tests")
+	}
+}
+
+func TestVerifyDiffusionTestsCloneGivesUp(t *testing.T) {
+	calls := fakeTestsGit(t, cloneAttempts, "abc123")
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org"}
+
+	err := verifyDiffusionTests(opts, &config.Config{}, t.TempDir(), "default")
+	if err == nil || !strings.Contains(err.Error(), "Could not resolve host") {
+		t.Fatalf("verifyDiffusionTests() error = %v, want the clone failure", err)
+	}
+	if got := countClones(*calls); got != cloneAttempts {
+		t.Errorf("clone attempts = %d, want %d", got, cloneAttempts)
+	}
+}
+
+func TestSyncTestsRepoReuseAndRefresh(t *testing.T) {
+	const repo = "https://git.example.com/qa/role-tests.git"
+	calls := fakeTestsGit(t, 0, "abc123")
+
+	dir, err := syncTestsRepo(&MoleculeOptions{}, repo)
+	if err != nil {
+		t.Fatalf("first sync error = %v", err)
+	}
+	if home, _ := os.UserHomeDir(); !strings.HasPrefix(dir, filepath.Join(home, ".diffusion", "cache", "tests", "role-tests-")) {
+		t.Errorf("tests repository cached in %s, want ~/.diffusion/cache/tests", dir)
+	}
+
+	tests := []struct {
+		name string
+		opts MoleculeOptions
+		want []string
+	}{
+		{"default pulls", MoleculeOptions{}, []string{"git -C " + dir + " pull --ff-only", "git -C " + dir + " rev-parse HEAD"}},
+		{"keep reuses", MoleculeOptions{KeepTestsCache: true}, nil},
+		{"refresh clones again", MoleculeOptions{RefreshTests: true}, []string{"git clone " + repo + " " + dir, "git -C " + dir + " rev-parse HEAD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*calls = nil
+			if _, err := syncTestsRepo(&tt.opts, repo); err != nil {
+				t.Fatalf("syncTestsRepo() error = %v", err)
+			}
+			if strings.Join(*calls, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("git calls = %q, want %q", *calls, tt.want)
+			}
+		})
+	}
+
+	state, err := cache.LoadTestsRepoState(dir)
+	if err != nil || state == nil || state.URL != repo || state.Commit != "abc123" {
+		t.Errorf("recorded state = %+v, %v; want %s at abc123", state, err, repo)
+	}
+}
+
+func TestVerifyRemoteTestsInCIMode(t *testing.T) {
+	fakeTestsGit(t, 0, "abc123")
+	origExec, origCopy := testsExec, testsCopyToContainer
+	t.Cleanup(func() { testsExec, testsCopyToContainer = origExec, origCopy })
+	var execs, copies []string
+	testsExec = func(_ *MoleculeOptions, name string, args ...string) error {
+		execs = append(execs, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+	testsCopyToContainer = func(_ *MoleculeOptions, src, dst string) error {
+		copies = append(copies, dst)
+		return nil
+	}
+
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org", CIMode: true, TestsOverWrite: true}
+	cfg := &config.Config{TestsConfig: &config.TestsSettings{Type: config.TestsTypeRemote, RemoteRepositories: []string{"https://github.com/acme/web-tests.git"}}}
+	verifyRemoteTests(opts, cfg, "", "default")
+
+	dst := "/opt/molecule/org.role/molecule/default/tests/web-tests"
+	if len(copies) != 1 || copies[0] != dst {
+		t.Errorf("copied to %q, want %s", copies, dst)
+	}
+	want := []string{"/bin/sh -c rm -rf " + dst + " && mkdir -p " + dst, "rm -rf " + dst + "/.git"}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("container commands = %q, want %q", execs, want)
+	}
+}
+
+func TestVerifyDiffusionTestsClonesConfiguredRepo(t *testing.T) {
+	const repo = "git@git.example.com:qa/diffusion-tests.git"
+	calls := fakeTestsGit(t, 0, "abc123")
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org"}
+	cfg := &config.Config{TestsConfig: &config.TestsSettings{Type: config.TestsTypeDiffusion, DiffusionRepo: repo}}
+
+	if err := verifyDiffusionTests(opts, cfg, t.TempDir(), "default"); err != nil {
+		t.Fatalf("verifyDiffusionTests() error = %v", err)
+	}
+	if len(*calls) == 0 || !strings.HasPrefix((*calls)[0], "git clone "+repo+" ") {
+		t.Errorf("git calls = %q, want a clone of %s", *calls, repo)
+	}
+}
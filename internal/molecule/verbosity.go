@@ -0,0 +1,48 @@
+package molecule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxVerbosity is the highest -v level; it also turns on ANSIBLE_DEBUG and
+// shell tracing in the container. Higher counts are capped to it.
+const MaxVerbosity = 3
+
+// verbosity returns the -v count, capped to MaxVerbosity
+func verbosity(opts *MoleculeOptions) int {
+	return min(max(opts.Verbosity, 0), MaxVerbosity)
+}
+
+// moleculeBinary returns the molecule invocation with one -v per verbosity level;
+// molecule passes them on to ansible-playbook
+func moleculeBinary(opts *MoleculeOptions) string {
+	if level := verbosity(opts); level > 0 {
+		return "molecule -" + strings.Repeat("v", level)
+	}
+	return "molecule"
+}
+
+// verboseCommand adds shell tracing (set -x) and ANSIBLE_DEBUG=1 at the
+// highest verbosity; env is placed in front of the molecule command by the caller
+func verboseCommand(opts *MoleculeOptions, cmdStr string) string {
+	if verbosity(opts) < MaxVerbosity {
+		return cmdStr
+	}
+	return "set -x; " + cmdStr
+}
+
+// verbosityEnv returns the env assignments for the highest verbosity level
+func verbosityEnv(opts *MoleculeOptions) string {
+	if verbosity(opts) < MaxVerbosity {
+		return ""
+	}
+	return "ANSIBLE_DEBUG=1 "
+}
+
+// moleculeCommand returns the shell command running a molecule subcommand for
+// the active scenario in the role directory, at the requested verbosity. env
+// holds extra assignments placed in front of molecule (e.g. ANSIBLE_RUN_TAGS).
+func moleculeCommand(opts *MoleculeOptions, roleDirName, env, subcommand string) string {
+	return verboseCommand(opts, fmt.Sprintf("cd ./%s && %s%s%s %s%s", roleDirName, env, verbosityEnv(opts), moleculeBinary(opts), subcommand, scenarioFlag(opts)))
+}
@@ -0,0 +1,69 @@
+package molecule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestConvergeCommandVerbosity(t *testing.T) {
+	tests := []struct {
+		level int
+		flag  string
+	}{
+		{level: 0, flag: ""},
+		{level: 1, flag: " -v"},
+		{level: 2, flag: " -vv"},
+		{level: 3, flag: " -vvv"},
+		{level: 7, flag: " -vvv"},
+	}
+	for _, tt := range tests {
+		got := convergeCommand(&MoleculeOptions{Verbosity: tt.level}, "acme.web")
+		if want := "molecule" + tt.flag + " converge"; !strings.Contains(got, want) {
+			t.Errorf("level %d: command %q does not contain %q", tt.level, got, want)
+		}
+		debug := tt.level >= MaxVerbosity
+		if strings.HasPrefix(got, "set -x; ") != debug || strings.Contains(got, "ANSIBLE_DEBUG=1 molecule") != debug {
+			t.Errorf("level %d: command %q, want shell trace and ANSIBLE_DEBUG only at the highest level", tt.level, got)
+		}
+	}
+
+	if got, want := convergeCommand(&MoleculeOptions{}, "acme.web"), "cd ./acme.web && molecule converge"; got != want {
+		t.Errorf("default command = %q, want unchanged %q", got, want)
+	}
+}
+
+func TestVerifyCommandVerbosity(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	opts := &MoleculeOptions{RoleFlag: "web", Verbosity: 2}
+
+	if err := runMoleculeVerify(opts, "acme.web"); err != nil {
+		t.Fatalf("runMoleculeVerify() error = %v", err)
+	}
+	if got := (*calls)[0]; got != "cd ./acme.web && molecule -vv verify" {
+		t.Errorf("verify command = %q", got)
+	}
+}
+
+func TestPhaseCommandsVerbosity(t *testing.T) {
+	calls := stubPhaseExec(t, 0, true)
+	opts := &MoleculeOptions{RoleFlag: "web", RoleScenario: "ubuntu", Verbosity: 3, Phases: []string{"create", "idempotence", "destroy"}}
+
+	if err := runPhases(opts, &config.Config{}, t.TempDir(), "acme.web", t.TempDir()); err != nil {
+		t.Fatalf("runPhases() error = %v", err)
+	}
+	if err := runPrepare(opts, "acme.web"); err != nil {
+		t.Fatalf("runPrepare() error = %v", err)
+	}
+	want := []string{
+		"set -x; cd ./acme.web && ANSIBLE_DEBUG=1 molecule -vvv create -s ubuntu",
+		"set -x; cd ./acme.web && ANSIBLE_DEBUG=1 molecule -vvv idempotence -s ubuntu",
+		"set -x; cd ./acme.web && ANSIBLE_DEBUG=1 molecule -vvv destroy -s ubuntu",
+		"set -x; cd ./acme.web && ANSIBLE_DEBUG=1 molecule -vvv prepare -s ubuntu",
+	}
+	if !reflect.DeepEqual(*calls, want) {
+		t.Errorf("calls = %q, want %q", *calls, want)
+	}
+}
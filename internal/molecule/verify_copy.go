@@ -0,0 +1,31 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// validateVerifyCopy rejects --verify-copy in CI mode, where role data is copied
+// inside the container and never lands in the host molecule layout
+func validateVerifyCopy(opts *MoleculeOptions) error {
+	if opts.VerifyCopy && opts.CIMode {
+		return fmt.Errorf("--verify-copy checks the host copy of the role and cannot be used with --ci")
+	}
+	return nil
+}
+
+// verifyRoleCopy compares the role data copied into the molecule layout with
+// its source when --verify-copy is set
+func verifyRoleCopy(opts *MoleculeOptions, path, roleMoleculePath string) error {
+	if !opts.VerifyCopy {
+		return nil
+	}
+	if err := utils.VerifyRoleDataCopy(path, roleMoleculePath); err != nil {
+		return fmt.Errorf("copy verification failed: %w", err)
+	}
+	log.Printf(config.ColorGreen + "Role data copy verified" + config.ColorReset)
+	return nil
+}
@@ -0,0 +1,40 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+
+	"diffusion/internal/config"
+)
+
+// testsDirInContainer returns the scenario tests directory inside the container.
+// Outside CI it is the bind-mounted role folder, so the path is the same in both modes.
+func testsDirInContainer(opts *MoleculeOptions, roleDirName string) string {
+	return fmt.Sprintf("/opt/molecule/%s/%s/%s/%s", roleDirName, config.MoleculeDir, activeScenario(opts), config.TestsDir)
+}
+
+// testsProvisioned reports whether the scenario tests directory exists and is not empty.
+func testsProvisioned(opts *MoleculeOptions, roleDirName string) bool {
+	return testsExec(opts, "/bin/sh", "-c", fmt.Sprintf(`[ -n "$(ls -A %s 2>/dev/null)" ]`, testsDirInContainer(opts, roleDirName))) == nil
+}
+
+// runVerifyOnly handles --verify-only: when the instance is up and its tests are
+// already provisioned, molecule verify runs directly, without copying role data,
+// exporting linters or resolving tests again. It reports done=false when the tests
+// are missing, so the caller falls back to the regular --verify flow.
+func runVerifyOnly(opts *MoleculeOptions, roleDirName string) (done bool, err error) {
+	if !containerExists(opts) {
+		return false, fmt.Errorf("--verify-only needs a running container %s; run 'diffusion molecule --converge' first", ContainerName(opts.RoleFlag))
+	}
+	if !testsProvisioned(opts, roleDirName) {
+		log.Printf(config.ColorYellow + "No tests found in the instance, provisioning them as with --verify" + config.ColorReset)
+		return false, nil
+	}
+	if opts.CIMode {
+		if err := checkMoleculeYml(opts, roleDirName); err != nil {
+			return true, err
+		}
+	}
+	log.Printf(config.ColorGreen + "Tests already present, running verify against the converged instance" + config.ColorReset)
+	return true, withCISection(opts, "verify", func() error { return runMoleculeVerify(opts, roleDirName) })
+}
@@ -0,0 +1,86 @@
+package molecule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// fakeVerifyOnly stubs the container, tests and phase runners for --verify-only
+// and returns the recorded tests and phase commands.
+func fakeVerifyOnly(t *testing.T, testsPresent bool) (*[]string, *[]string) {
+	t.Helper()
+	origTests, origPhase, origExists := testsExec, phaseExec, containerExists
+	t.Cleanup(func() { testsExec, phaseExec, containerExists = origTests, origPhase, origExists })
+
+	containerExists = func(*MoleculeOptions) bool { return true }
+	var testsCalls, phaseCalls []string
+	testsExec = func(_ *MoleculeOptions, name string, args ...string) error {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		testsCalls = append(testsCalls, cmd)
+		if strings.Contains(cmd, "ls -A") && !testsPresent {
+			return os.ErrNotExist
+		}
+		return nil
+	}
+	phaseExec = func(_ context.Context, _ *MoleculeOptions, cmdStr string) error {
+		phaseCalls = append(phaseCalls, cmdStr)
+		return nil
+	}
+	return &testsCalls, &phaseCalls
+}
+
+func TestVerifyOnlySkipsCopyAndProvisioningWhenTestsExist(t *testing.T) {
+	testsCalls, phaseCalls := fakeVerifyOnly(t, true)
+	t.Chdir(t.TempDir())
+
+	path, _ := os.Getwd()
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org", VerifyFlag: true, VerifyOnly: true}
+	roleMoleculePath := filepath.Join(path, config.MoleculeDir, "org.role")
+	cfg := &config.Config{TestsConfig: &config.TestsSettings{Type: config.TestsTypeDiffusion}}
+
+	if err := handleSubcommands(opts, cfg, path, "org.role", roleMoleculePath); err != nil {
+		t.Fatalf("handleSubcommands() error = %v", err)
+	}
+	for _, cmd := range *testsCalls {
+		if strings.Contains(cmd, "git clone") || strings.Contains(cmd, "git pull") || strings.Contains(cmd, "cp -rf") {
+			t.Errorf("unexpected tests provisioning command under --verify-only: %q", cmd)
+		}
+	}
+	if _, err := os.Stat(roleMoleculePath); !os.IsNotExist(err) {
+		t.Errorf("role data was copied to %s under --verify-only", roleMoleculePath)
+	}
+	if len(*phaseCalls) != 1 || !strings.Contains((*phaseCalls)[0], "molecule verify") {
+		t.Errorf("expected a single molecule verify, got %v", *phaseCalls)
+	}
+}
+
+func TestVerifyOnlyFallsBackWhenTestsMissing(t *testing.T) {
+	testsCalls, _ := fakeVerifyOnly(t, false)
+
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org", VerifyFlag: true, VerifyOnly: true}
+	done, err := runVerifyOnly(opts, "org.role")
+	if err != nil {
+		t.Fatalf("runVerifyOnly() error = %v", err)
+	}
+	if done {
+		t.Error("expected fallback to the regular verify flow when tests are missing")
+	}
+	if len(*testsCalls) != 1 || !strings.Contains((*testsCalls)[0], "/opt/molecule/org.role/molecule/default/tests") {
+		t.Errorf("expected a single tests presence check, got %v", *testsCalls)
+	}
+}
+
+func TestVerifyOnlyRequiresContainer(t *testing.T) {
+	fakeVerifyOnly(t, true)
+	containerExists = func(*MoleculeOptions) bool { return false }
+
+	opts := &MoleculeOptions{RoleFlag: "role", OrgFlag: "org", VerifyFlag: true, VerifyOnly: true}
+	if _, err := runVerifyOnly(opts, "org.role"); err == nil || !strings.Contains(err.Error(), "molecule-role") {
+		t.Errorf("expected missing container error, got %v", err)
+	}
+}
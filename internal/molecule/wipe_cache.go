@@ -0,0 +1,100 @@
+package molecule
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+)
+
+// copyCacheOut copies the cache from the container to the host; tests replace it.
+var copyCacheOut = copyCacheFromContainer
+
+// cacheMounted reports whether the molecule container has the roles cache
+// volume-mounted from the host, under whichever home the image uses; tests
+// replace it.
+var cacheMounted = func(opts *MoleculeOptions) bool {
+	out, err := exec.Command("docker", "inspect", "-f", "{{range .Mounts}}{{.Destination}} {{end}}", ContainerName(opts.RoleFlag)).Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), "/"+config.ContainerRolesSubdir+" ")
+}
+
+// validateWipeCache checks --keep-cache and --purge-cache, which only apply to --wipe
+func validateWipeCache(opts *MoleculeOptions) error {
+	if !opts.KeepCache && !opts.PurgeCache {
+		return nil
+	}
+	if !opts.WipeFlag {
+		return fmt.Errorf("--keep-cache and --purge-cache only apply to --wipe")
+	}
+	if opts.KeepCache && opts.PurgeCache {
+		return fmt.Errorf("--keep-cache and --purge-cache cannot be combined")
+	}
+	if opts.KeepCache && opts.NoCache {
+		return fmt.Errorf("--keep-cache cannot be combined with --no-cache")
+	}
+	return nil
+}
+
+// saveCacheBeforeWipe copies the cache out of the container before it is
+// removed. CI containers have no cache mounts, so their cache is always copied.
+// With --keep-cache the copy also runs outside CI when the container was
+// started without the cache mounts (e.g. by an earlier --no-cache run); a
+// mounted cache already lives on the host and is left as is.
+func saveCacheBeforeWipe(opts *MoleculeOptions, cfg *config.Config) {
+	if opts.PurgeCache {
+		return
+	}
+	if opts.CIMode {
+		copyCacheOut(opts, cfg)
+		return
+	}
+	if !opts.KeepCache {
+		return
+	}
+	if !cacheEnabled(opts, cfg) || cfg.CacheConfig.CacheID == "" {
+		log.Printf(config.ColorYellow + "warning: --keep-cache has nothing to keep, the role cache is disabled (run 'diffusion cache enable')" + config.ColorReset)
+		return
+	}
+	if cacheMounted(opts) {
+		log.Printf(config.ColorGreen+"Cache is mounted from the host, kept for cache ID %s"+config.ColorReset, cfg.CacheConfig.CacheID)
+		return
+	}
+	copyCacheOut(opts, cfg)
+}
+
+// purgeCache removes the role cache directory for --purge-cache
+func purgeCache(cfg *config.Config) error {
+	if cfg.CacheConfig == nil || cfg.CacheConfig.CacheID == "" {
+		log.Printf(config.ColorYellow + "warning: --purge-cache: no role cache configured" + config.ColorReset)
+		return nil
+	}
+	if name := cache.SharedName(cfg.CacheConfig.CacheID); name != "" {
+		self, err := config.ConfigPath()
+		if err == nil {
+			self, err = filepath.Abs(self)
+		}
+		if err != nil {
+			return err
+		}
+		others, err := cache.OtherSharedUsers(name, self)
+		if err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			log.Printf(config.ColorYellow+"Shared cache '%s' is still used by %d other role(s), not purged: %s"+config.ColorReset, name, len(others), strings.Join(others, ", "))
+			return nil
+		}
+	}
+	if err := cache.CleanupCache(cfg.CacheConfig.CacheID, cfg.CacheConfig.CachePath); err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+	log.Printf(config.ColorGreen+"Removed cache for cache ID %s"+config.ColorReset, cfg.CacheConfig.CacheID)
+	return nil
+}
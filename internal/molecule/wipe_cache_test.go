@@ -0,0 +1,129 @@
+package molecule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diffusion/internal/cache"
+	"diffusion/internal/config"
+)
+
+func TestValidateWipeCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MoleculeOptions
+		wantErr bool
+	}{
+		{name: "plain wipe", opts: MoleculeOptions{WipeFlag: true}},
+		{name: "keep cache", opts: MoleculeOptions{WipeFlag: true, KeepCache: true}},
+		{name: "purge cache", opts: MoleculeOptions{WipeFlag: true, PurgeCache: true}},
+		{name: "keep without wipe", opts: MoleculeOptions{KeepCache: true}, wantErr: true},
+		{name: "purge without wipe", opts: MoleculeOptions{PurgeCache: true}, wantErr: true},
+		{name: "keep and purge", opts: MoleculeOptions{WipeFlag: true, KeepCache: true, PurgeCache: true}, wantErr: true},
+		{name: "keep with no-cache", opts: MoleculeOptions{WipeFlag: true, KeepCache: true, NoCache: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWipeCache(&tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateWipeCache() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveCacheBeforeWipe(t *testing.T) {
+	origCopy, origMounted := copyCacheOut, cacheMounted
+	t.Cleanup(func() { copyCacheOut, cacheMounted = origCopy, origMounted })
+
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc123"}}
+	tests := []struct {
+		name     string
+		opts     MoleculeOptions
+		mounted  bool
+		wantCopy bool
+	}{
+		{name: "local wipe keeps mounts as is", opts: MoleculeOptions{WipeFlag: true}, mounted: true},
+		{name: "ci wipe copies", opts: MoleculeOptions{WipeFlag: true, CIMode: true}, wantCopy: true},
+		{name: "keep cache with mounts", opts: MoleculeOptions{WipeFlag: true, KeepCache: true}, mounted: true},
+		{name: "keep cache without mounts copies", opts: MoleculeOptions{WipeFlag: true, KeepCache: true}, wantCopy: true},
+		{name: "purge skips the ci copy", opts: MoleculeOptions{WipeFlag: true, CIMode: true, PurgeCache: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := false
+			copyCacheOut = func(*MoleculeOptions, *config.Config) { copied = true }
+			cacheMounted = func(*MoleculeOptions) bool { return tt.mounted }
+
+			saveCacheBeforeWipe(&tt.opts, cfg)
+			if copied != tt.wantCopy {
+				t.Errorf("cache copied = %v, want %v", copied, tt.wantCopy)
+			}
+		})
+	}
+}
+
+func TestPurgeCacheRemovesCacheDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: "abc123"}}
+
+	dir, err := cache.EnsureUVCacheDir("abc123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/wheel.whl", []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := purgeCache(cfg); err != nil {
+		t.Fatalf("purgeCache() error = %v", err)
+	}
+	cacheDir, _ := cache.GetCacheDir("abc123", "")
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("cache dir %s still exists (err %v)", cacheDir, err)
+	}
+
+	// nothing configured is not an error
+	if err := purgeCache(&config.Config{}); err != nil {
+		t.Errorf("purgeCache() without cache config error = %v", err)
+	}
+}
+
+func TestPurgeCacheKeepsSharedCacheInUse(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.EnvConfig, "")
+	t.Chdir(t.TempDir())
+	self, _ := filepath.Abs(config.ConfigFileName)
+	other := filepath.Join(t.TempDir(), config.ConfigFileName)
+	for _, path := range []string{self, other} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.AddSharedReference("mono", path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cacheID := cache.SharedCacheID("mono")
+	cacheDir, err := cache.EnsureCacheDir(cacheID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{CacheConfig: &config.CacheSettings{Enabled: true, CacheID: cacheID}}
+
+	if err := purgeCache(cfg); err != nil {
+		t.Fatalf("purgeCache() error = %v", err)
+	}
+	if _, err := os.Stat(cacheDir); err != nil {
+		t.Fatalf("shared cache used by another role was purged: %v", err)
+	}
+
+	if err := os.Remove(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := purgeCache(cfg); err != nil {
+		t.Fatalf("purgeCache() error = %v", err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("shared cache of the last user was not purged (err %v)", err)
+	}
+}
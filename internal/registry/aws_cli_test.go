@@ -173,3 +173,41 @@ func TestAwsCliNotInstalled(t *testing.T) {
 		t.Logf("Got AWS CLI configured but authentication failed (expected in CI): %s", errMsg)
 	}
 }
+
+// TestParseEcrRegion tests region extraction from ECR registry hostnames
+func TestParseEcrRegion(t *testing.T) {
+	valid := map[string]string{
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com":               "us-east-1",
+		"123456789012.dkr.ecr.ap-southeast-2.amazonaws.com":          "ap-southeast-2",
+		"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn":           "cn-north-1",
+		"123456789012.dkr.ecr.cn-northwest-1.amazonaws.com.cn":       "cn-northwest-1",
+		"123456789012.dkr.ecr.us-gov-west-1.amazonaws.com":           "us-gov-west-1",
+		"123456789012.dkr.ecr-fips.us-gov-east-1.amazonaws.com":      "us-gov-east-1",
+		"https://123456789012.dkr.ecr.eu-central-1.amazonaws.com":    "eu-central-1",
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com/team/molecule": "eu-west-1",
+	}
+	for server, want := range valid {
+		got, err := ParseEcrRegion(server)
+		if err != nil {
+			t.Errorf("ParseEcrRegion(%q) error: %v", server, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseEcrRegion(%q) = %q, want %q", server, got, want)
+		}
+	}
+
+	for _, server := range []string{
+		"",
+		"ghcr.io",
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com.extra",
+		"123456789012.dkr.ecr.us-east-1.amazonaws.cn",
+		"123456789012.ecr.dkr.us-east-1.amazonaws.com",
+		"123456789012.dkr.ecr.not_a_region.amazonaws.com",
+		".dkr.ecr.us-east-1.amazonaws.com",
+	} {
+		if _, err := ParseEcrRegion(server); err == nil {
+			t.Errorf("ParseEcrRegion(%q) expected error", server)
+		}
+	}
+}
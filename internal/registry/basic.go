@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/secrets"
+)
+
+// loadCredential reads a stored credential; replaced in tests
+var loadCredential = secrets.LoadConfiguredCredentials
+
+// BasicPassword resolves the password of a Basic registry from the configured
+// environment variable or, failing that, from a stored credential entry.
+func BasicPassword(reg *config.ContainerRegistry) (string, error) {
+	if reg.Username == "" {
+		return "", fmt.Errorf("registry provider %s requires container_registry.username", config.RegistryProviderBasic)
+	}
+	switch {
+	case reg.PasswordEnv != "":
+		password := os.Getenv(reg.PasswordEnv)
+		if password == "" {
+			return "", fmt.Errorf("registry password environment variable %s is not set", reg.PasswordEnv)
+		}
+		return password, nil
+	case reg.PasswordCredential != "":
+		creds, err := loadCredential(reg.PasswordCredential)
+		if err != nil {
+			return "", fmt.Errorf("failed to load registry credential %q: %w", reg.PasswordCredential, err)
+		}
+		if creds.Password != "" {
+			return creds.Password, nil
+		}
+		if creds.Token != "" {
+			return creds.Token, nil
+		}
+		return "", fmt.Errorf("stored credential %q has no password or token", reg.PasswordCredential)
+	}
+	return "", fmt.Errorf("registry provider %s requires container_registry.password_env or container_registry.password_credential", config.RegistryProviderBasic)
+}
+
+// basicLoginArgs returns the docker login arguments for a Basic registry. The
+// password is always read from stdin, never passed on the command line. A
+// non-empty container runs the login inside it with docker exec -i.
+func basicLoginArgs(reg *config.ContainerRegistry, container string) []string {
+	login := []string{"docker", "login", reg.RegistryServer, "--username", reg.Username, "--password-stdin"}
+	if container == "" {
+		return login
+	}
+	return append([]string{"docker", "exec", "-i", container}, login...)
+}
+
+// basicLoginCommand builds the docker login command with the password on stdin
+func basicLoginCommand(reg *config.ContainerRegistry, container, password string) *exec.Cmd {
+	args := basicLoginArgs(reg, container)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(password)
+	return cmd
+}
+
+// BasicLogin runs docker login against a Basic registry, on the host when
+// container is empty or inside the named container otherwise.
+func BasicLogin(reg *config.ContainerRegistry, container string) error {
+	password, err := BasicPassword(reg)
+	if err != nil {
+		return err
+	}
+	out, err := basicLoginCommand(reg, container, password).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker login %s failed: %w (%s)", reg.RegistryServer, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
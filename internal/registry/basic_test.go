@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func basicRegistry() *config.ContainerRegistry {
+	return &config.ContainerRegistry{
+		RegistryServer:   "harbor.example.com",
+		RegistryProvider: config.RegistryProviderBasic,
+		Username:         "robot$ci",
+		PasswordEnv:      "TEST_REGISTRY_PASSWORD",
+	}
+}
+
+func TestBasicLoginCommandUsesStdin(t *testing.T) {
+	const password = "s3cr3t-pass"
+	reg := basicRegistry()
+
+	tests := []struct {
+		name      string
+		container string
+		want      []string
+	}{
+		{"host", "", []string{"docker", "login", "harbor.example.com", "--username", "robot$ci", "--password-stdin"}},
+		{"container", "molecule-nginx", []string{"docker", "exec", "-i", "molecule-nginx", "docker", "login", "harbor.example.com", "--username", "robot$ci", "--password-stdin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := basicLoginCommand(reg, tt.container, password)
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("args = %v, want %v", cmd.Args, tt.want)
+			}
+			for _, arg := range cmd.Args {
+				if strings.Contains(arg, password) {
+					t.Fatalf("password leaked into command line: %v", cmd.Args)
+				}
+			}
+			if cmd.Stdin == nil {
+				t.Fatal("expected the password on stdin")
+			}
+			stdin, err := io.ReadAll(cmd.Stdin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(stdin) != password {
+				t.Errorf("stdin = %q, want the password", stdin)
+			}
+		})
+	}
+}
+
+func TestBasicPassword(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("TEST_REGISTRY_PASSWORD", "from-env")
+		got, err := BasicPassword(basicRegistry())
+		if err != nil || got != "from-env" {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	})
+
+	t.Run("env unset", func(t *testing.T) {
+		t.Setenv("TEST_REGISTRY_PASSWORD", "")
+		if _, err := BasicPassword(basicRegistry()); err == nil {
+			t.Fatal("expected an error for an empty password variable")
+		}
+	})
+
+	t.Run("credential", func(t *testing.T) {
+		orig := loadCredential
+		t.Cleanup(func() { loadCredential = orig })
+		loadCredential = func(name string) (*config.ArtifactCredentials, error) {
+			if name != "harbor" {
+				return nil, fmt.Errorf("credentials not found for source '%s'", name)
+			}
+			return &config.ArtifactCredentials{Name: name, Token: "from-store"}, nil
+		}
+		reg := basicRegistry()
+		reg.PasswordEnv = ""
+		reg.PasswordCredential = "harbor"
+		got, err := BasicPassword(reg)
+		if err != nil || got != "from-store" {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	})
+
+	t.Run("missing username or source", func(t *testing.T) {
+		reg := basicRegistry()
+		reg.Username = ""
+		if _, err := BasicPassword(reg); err == nil {
+			t.Error("expected an error without a username")
+		}
+		reg = basicRegistry()
+		reg.PasswordEnv = ""
+		if _, err := BasicPassword(reg); err == nil {
+			t.Error("expected an error without a password source")
+		}
+	})
+}
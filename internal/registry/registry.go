@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
@@ -81,10 +82,47 @@ func YcCliInit() error {
 	return nil
 }
 
+// ecrRegionPattern matches AWS region names, including GovCloud (us-gov-west-1) and China (cn-north-1)
+var ecrRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// ParseEcrRegion extracts the region from an ECR registry server. Accepted forms:
+// <account-id>.dkr.ecr[-fips].<region>.amazonaws.com and, for China regions,
+// <account-id>.dkr.ecr.<region>.amazonaws.com.cn. A scheme or a repository path
+// after the host is ignored.
+func ParseEcrRegion(registryServer string) (string, error) {
+	invalid := fmt.Errorf("invalid AWS ECR registry server format: %s (expected format: <account-id>.dkr.ecr.<region>.amazonaws.com)", registryServer)
+
+	host := strings.TrimPrefix(strings.TrimPrefix(registryServer, "https://"), "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	// Format: <account-id>.dkr.ecr.<region>.amazonaws.com[.cn]
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 6 && parts[4] == "amazonaws" && parts[5] == "com":
+	case len(parts) == 7 && parts[4] == "amazonaws" && parts[5] == "com" && parts[6] == "cn":
+	default:
+		return "", invalid
+	}
+	if parts[0] == "" || parts[1] != "dkr" || (parts[2] != "ecr" && parts[2] != "ecr-fips") {
+		return "", invalid
+	}
+	if !ecrRegionPattern.MatchString(parts[3]) {
+		return "", invalid
+	}
+	return parts[3], nil
+}
+
 // AwsCliInit runs AWS CLI commands and retrieves ECR authorization token
 // Sets TOKEN environment variable for Docker authentication
 // Extracts region from registry server and sets AWS_REGION environment variable
 func AwsCliInit(registryServer string) error {
+	region, err := ParseEcrRegion(registryServer)
+	if err != nil {
+		return err
+	}
+
 	// Check if AWS CLI is installed
 	if _, err := exec.LookPath("aws"); err != nil {
 		return fmt.Errorf("AWS CLI is not installed or not in PATH. Please install AWS CLI to use AWS ECR authentication. Visit: https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html")
@@ -93,20 +131,12 @@ func AwsCliInit(registryServer string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	// Extract region from registry server (e.g., "123456789.dkr.ecr.us-east-1.amazonaws.com")
-	// Format: <account-id>.dkr.ecr.<region>.amazonaws.com
-	parts := strings.Split(registryServer, ".")
-	if len(parts) != 6 {
-		return fmt.Errorf("invalid AWS ECR registry server format: %s (expected format: <account-id>.dkr.ecr.<region>.amazonaws.com)", registryServer)
-	}
-
-	// Validate ECR format: parts should be [account-id, dkr, ecr, region, amazonaws, com]
-	if parts[1] != "dkr" || parts[2] != "ecr" || parts[4] != "amazonaws" || parts[5] != "com" {
-		return fmt.Errorf("invalid AWS ECR registry server format: %s (expected format: <account-id>.dkr.ecr.<region>.amazonaws.com)", registryServer)
+	// Check that the CLI has credentials first, so a missing profile is reported
+	// as such instead of as an ECR failure
+	if _, err := utils.RunCommandCapture(ctx, "aws", "sts", "get-caller-identity", "--region", region); err != nil {
+		return fmt.Errorf("aws sts get-caller-identity failed: no usable AWS credentials found. Run 'aws configure' or 'aws sso login', set AWS_PROFILE or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, or use --oidc in CI")
 	}
 
-	region := parts[3]
-
 	// Get ECR authorization token using AWS CLI
 	// This returns a base64-encoded authorization token
 	// Note: utils.RunCommandCapture automatically trims whitespace from the output
@@ -0,0 +1,99 @@
+package role
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Galaxy name length limits for namespaces and role names
+const (
+	galaxyNameMinLength = 2
+	galaxyNameMaxLength = 64
+)
+
+var (
+	galaxyNamePattern   = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	galaxyInvalidChars  = regexp.MustCompile(`[^a-z0-9_]+`)
+	galaxyRepeatedUnder = regexp.MustCompile(`_{2,}`)
+)
+
+// NameViolation is a Galaxy naming rule broken by a namespace or role name
+type NameViolation struct {
+	Field      string // "namespace" or "role_name"
+	Value      string
+	Problem    string
+	Suggestion string // Closest valid name, empty if none can be derived
+}
+
+func (v NameViolation) String() string {
+	if v.Suggestion == "" {
+		return fmt.Sprintf("%s %q: %s", v.Field, v.Value, v.Problem)
+	}
+	return fmt.Sprintf("%s %q: %s (suggested: %q)", v.Field, v.Value, v.Problem, v.Suggestion)
+}
+
+// LintGalaxyNames checks the namespace and role name of galaxy_info against
+// Galaxy's rules: lowercase letters, digits and underscores, starting with a
+// letter, 2-64 characters. Hyphens and dots get their own message since they
+// are the most common mistake.
+func LintGalaxyNames(info *GalaxyInfo) []NameViolation {
+	if info == nil {
+		info = &GalaxyInfo{}
+	}
+	var violations []NameViolation
+	violations = append(violations, LintGalaxyName("namespace", info.Namespace)...)
+	violations = append(violations, LintGalaxyName("role_name", info.RoleName)...)
+	return violations
+}
+
+// LintGalaxyName returns the violations of a single namespace or role name
+func LintGalaxyName(field, value string) []NameViolation {
+	suggestion := SuggestGalaxyName(value)
+	if suggestion == value {
+		suggestion = ""
+	}
+	violation := func(problem string) NameViolation {
+		return NameViolation{Field: field, Value: value, Problem: problem, Suggestion: suggestion}
+	}
+
+	if value == "" {
+		return []NameViolation{violation("is empty")}
+	}
+
+	var violations []NameViolation
+	if strings.ContainsAny(value, "-.") {
+		violations = append(violations, violation("contains '-' or '.', which Galaxy does not allow; use '_'"))
+	}
+	if value != strings.ToLower(value) {
+		violations = append(violations, violation("contains uppercase letters"))
+	}
+	// Uppercase is reported above, so only the letter itself matters here
+	if c := strings.ToLower(value[:1])[0]; c < 'a' || c > 'z' {
+		violations = append(violations, violation("must start with a letter"))
+	}
+	if n := len(value); n < galaxyNameMinLength || n > galaxyNameMaxLength {
+		violations = append(violations, violation(fmt.Sprintf("must be %d-%d characters long, got %d", galaxyNameMinLength, galaxyNameMaxLength, n)))
+	}
+	if len(violations) == 0 && !galaxyNamePattern.MatchString(value) {
+		violations = append(violations, violation("may only contain lowercase letters, digits and '_'"))
+	}
+	return violations
+}
+
+// SuggestGalaxyName derives the closest valid Galaxy name: lowercased, with
+// runs of other characters replaced by '_', leading non-letters removed and
+// the length capped. Returns "" when nothing usable is left.
+func SuggestGalaxyName(value string) string {
+	name := galaxyInvalidChars.ReplaceAllString(strings.ToLower(value), "_")
+	name = galaxyRepeatedUnder.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(name, "0123456789_")
+	if len(name) > galaxyNameMaxLength {
+		name = name[:galaxyNameMaxLength]
+	}
+	name = strings.TrimRight(name, "_")
+	if len(name) < galaxyNameMinLength {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package role
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintGalaxyName(t *testing.T) {
+	tests := []struct {
+		value          string
+		wantProblems   []string
+		wantSuggestion string
+	}{
+		{value: "nginx"},
+		{value: "polar_team"},
+		{value: "web2"},
+		{value: "ab"},
+		{value: "my-role", wantProblems: []string{"'-' or '.'"}, wantSuggestion: "my_role"},
+		{value: "acme.web", wantProblems: []string{"'-' or '.'"}, wantSuggestion: "acme_web"},
+		{value: "Polar-Team", wantProblems: []string{"'-' or '.'", "uppercase"}, wantSuggestion: "polar_team"},
+		{value: "MyRole", wantProblems: []string{"uppercase"}, wantSuggestion: "myrole"},
+		{value: "2fa_setup", wantProblems: []string{"start with a letter"}, wantSuggestion: "fa_setup"},
+		{value: "_private", wantProblems: []string{"start with a letter"}, wantSuggestion: "private"},
+		{value: "a", wantProblems: []string{"characters long"}},
+		{value: strings.Repeat("x", 65), wantProblems: []string{"characters long"}, wantSuggestion: strings.Repeat("x", 64)},
+		{value: "web role", wantProblems: []string{"only contain"}, wantSuggestion: "web_role"},
+		{value: "", wantProblems: []string{"empty"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			violations := LintGalaxyName("role_name", tt.value)
+			if len(violations) != len(tt.wantProblems) {
+				t.Fatalf("LintGalaxyName(%q) = %v, want %d violations", tt.value, violations, len(tt.wantProblems))
+			}
+			for i, v := range violations {
+				if !strings.Contains(v.Problem, tt.wantProblems[i]) {
+					t.Errorf("violation %d problem = %q, want it to mention %q", i, v.Problem, tt.wantProblems[i])
+				}
+				if v.Suggestion != tt.wantSuggestion {
+					t.Errorf("violation %d suggestion = %q, want %q", i, v.Suggestion, tt.wantSuggestion)
+				}
+				if v.Value != tt.value || v.Field != "role_name" {
+					t.Errorf("violation %d = %+v, want the offending value and field", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestLintGalaxyNames(t *testing.T) {
+	violations := LintGalaxyNames(&GalaxyInfo{Namespace: "Polar-Team", RoleName: "nginx"})
+	if len(violations) != 2 {
+		t.Fatalf("LintGalaxyNames() = %v, want 2 namespace violations", violations)
+	}
+	for _, v := range violations {
+		if v.Field != "namespace" {
+			t.Errorf("violation for %s, want namespace only", v.Field)
+		}
+	}
+	if got := violations[0].String(); got != `namespace "Polar-Team": contains '-' or '.', which Galaxy does not allow; use '_' (suggested: "polar_team")` {
+		t.Errorf("String() = %s", got)
+	}
+}
+
+func TestLintGalaxyNamesMissingInfo(t *testing.T) {
+	if violations := LintGalaxyNames(nil); len(violations) != 2 {
+		t.Errorf("LintGalaxyNames(nil) = %v, want empty namespace and role name", violations)
+	}
+}
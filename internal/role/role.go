@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"diffusion/internal/config"
 	"diffusion/internal/utils"
 
 	"gopkg.in/yaml.v3"
@@ -65,13 +66,109 @@ func (rc *RequirementCollection) UnmarshalYAML(value *yaml.Node) error {
 }
 
 type Meta struct {
-	GalaxyInfo  *GalaxyInfo `yaml:"galaxy_info"`
-	Collections []string    `yaml:"collections,omitempty"`
+	GalaxyInfo  *GalaxyInfo     `yaml:"galaxy_info"`
+	Collections MetaCollections `yaml:"collections,omitempty"`
+
+	// collectionMaps holds the collections written as name/version maps,
+	// keyed by name with their original version, so saving keeps that form
+	collectionMaps map[string]string
 }
 
+// UnmarshalYAML decodes meta/main.yml and remembers which collections were
+// written in the map form
+func (m *Meta) UnmarshalYAML(value *yaml.Node) error {
+	type rawMeta Meta
+	var raw rawMeta
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	*m = Meta(raw)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if value.Content[i].Value != "collections" {
+			continue
+		}
+		for _, item := range value.Content[i+1].Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			var entry RequirementCollection
+			if err := item.Decode(&entry); err != nil {
+				return err
+			}
+			if m.collectionMaps == nil {
+				m.collectionMaps = map[string]string{}
+			}
+			m.collectionMaps[entry.Name] = entry.Version
+		}
+	}
+	return nil
+}
+
+// MarshalYAML writes the collections read in the map form back as maps. The
+// original version is kept while the constraint is unchanged, so "1.5.4" is
+// not rewritten as "==1.5.4".
+func (m Meta) MarshalYAML() (interface{}, error) {
+	type rawMeta Meta
+	if len(m.collectionMaps) == 0 {
+		return rawMeta(m), nil
+	}
+	collections := make([]interface{}, 0, len(m.Collections))
+	for _, col := range m.Collections {
+		name, version := utils.ParseCollectionString(col)
+		orig, ok := m.collectionMaps[name]
+		if !ok {
+			collections = append(collections, col)
+			continue
+		}
+		if metaCollectionString(name, orig) == col {
+			version = orig
+		}
+		collections = append(collections, RequirementCollection{Name: name, Version: version})
+	}
+	return struct {
+		GalaxyInfo  *GalaxyInfo   `yaml:"galaxy_info"`
+		Collections []interface{} `yaml:"collections,omitempty"`
+	}{m.GalaxyInfo, collections}, nil
+}
+
+// MetaCollections holds the meta/main.yml collections normalized to the
+// "namespace.name[<op><version>]" string form. Entries may be written as plain
+// strings or as maps with name/version keys; Meta keeps track of the map ones
+// so saving writes them back in the same form.
+type MetaCollections []string
+
+// UnmarshalYAML accepts both the string and the name/version map form
+func (mc *MetaCollections) UnmarshalYAML(value *yaml.Node) error {
+	var entries []RequirementCollection
+	if err := value.Decode(&entries); err != nil {
+		return err
+	}
+	collections := make(MetaCollections, 0, len(entries))
+	for _, entry := range entries {
+		collections = append(collections, metaCollectionString(entry.Name, entry.Version))
+	}
+	*mc = collections
+	return nil
+}
+
+// metaCollectionString joins a collection name and version constraint; a bare
+// version is pinned with "==" so ParseCollectionString can split it again
+func metaCollectionString(name, version string) string {
+	version = strings.TrimSpace(version)
+	switch {
+	case version == "" || version == "*" || version == "latest":
+		return name
+	case strings.ContainsAny(version[:1], "<>=!"):
+		return name + version
+	}
+	return name + "==" + version
+}
+
+// Requirement is a requirements.yml file. Empty sections are omitted when
+// saving: some ansible-galaxy versions choke on an empty "roles: []".
 type Requirement struct {
-	Collections []RequirementCollection `yaml:"collections"`
-	Roles       []RequirementRole       `yaml:"roles"`
+	Collections []RequirementCollection `yaml:"collections,omitempty"`
+	Roles       []RequirementRole       `yaml:"roles,omitempty"`
 }
 
 func ParseMetaFile() (*Meta, error) {
@@ -88,11 +185,7 @@ func ParseMetaFile() (*Meta, error) {
 }
 
 func ParseRequirementFile(scenarios string) (*Requirement, error) {
-	path := "requirements.yml"
-	if scenarios != "" {
-		path = "scenarios/" + scenarios + "/requirements.yml"
-	}
-	file, err := os.ReadFile(path)
+	file, err := os.ReadFile(RequirementFilePath(scenarios))
 	if err != nil {
 		return nil, err
 	}
@@ -120,27 +213,53 @@ func LoadRoleConfig(scenarios string) (*Meta, *Requirement, error) {
 }
 
 func SaveMetaFile(meta *Meta) error {
-	data, err := marshalYaml4Indent(meta)
+	output, err := MarshalMetaFile(meta)
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(config.MetaFilePath, output, 0644)
+}
+
+// MarshalMetaFile returns meta/main.yml content exactly as SaveMetaFile writes it
+func MarshalMetaFile(meta *Meta) ([]byte, error) {
+	data, err := marshalYaml4Indent(meta)
+	if err != nil {
+		return nil, err
+	}
 	// Prepend YAML document header for correct formatting
-	output := append([]byte("---\n"), data...)
-	return os.WriteFile("meta/main.yml", output, 0644)
+	return append([]byte("---\n"), data...), nil
 }
 
 func SaveRequirementFile(req *Requirement, scenarios string) error {
-	path := "requirements.yml"
-	if scenarios != "" {
-		path = "scenarios/" + scenarios + "/requirements.yml"
+	output, err := MarshalRequirementFile(req)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(RequirementFilePath(scenarios), output, 0644)
+}
+
+// RequirementFilePath returns the requirements.yml path of a scenario, or the
+// role's top-level requirements.yml when scenario is empty
+func RequirementFilePath(scenario string) string {
+	if scenario == "" {
+		return "requirements.yml"
 	}
+	return "scenarios/" + scenario + "/requirements.yml"
+}
+
+// MarshalRequirementFile returns requirements.yml content exactly as
+// SaveRequirementFile writes it
+func MarshalRequirementFile(req *Requirement) ([]byte, error) {
 	data, err := marshalYaml4Indent(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(req.Collections) == 0 && len(req.Roles) == 0 {
+		// Both sections omitted would encode as "{}", which ansible-galaxy rejects
+		data = []byte("collections: []\n")
 	}
 	// Prepend YAML document header for correct formatting
-	output := append([]byte("---\n"), data...)
-	return os.WriteFile(path, output, 0644)
+	return append([]byte("---\n"), data...), nil
 }
 
 // marshalYaml4Indent encodes a value as YAML with consistent 4-space indentation.
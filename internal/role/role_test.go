@@ -4,7 +4,11 @@ import (
 	"diffusion/internal/utils"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseMetaFile(t *testing.T) {
@@ -77,6 +81,116 @@ collections:
 	}
 }
 
+func TestMetaCollectionsFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "string form",
+			content: `collections:
+  - community.general
+  - community.docker>=3.0.0
+`,
+			want: []string{"community.general", "community.docker>=3.0.0"},
+		},
+		{
+			name: "map form",
+			content: `collections:
+  - name: community.general
+    version: ">=7.4.0"
+  - name: ansible.posix
+    version: 1.5.4
+  - name: community.docker
+`,
+			want: []string{"community.general>=7.4.0", "ansible.posix==1.5.4", "community.docker"},
+		},
+		{
+			name: "mixed",
+			content: `collections:
+  - community.general
+  - name: ansible.posix
+    version: "*"
+`,
+			want: []string{"community.general", "ansible.posix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var meta Meta
+			if err := yaml.Unmarshal([]byte(tt.content), &meta); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if len(meta.Collections) != len(tt.want) {
+				t.Fatalf("collections = %v, want %v", meta.Collections, tt.want)
+			}
+			for i, want := range tt.want {
+				if meta.Collections[i] != want {
+					t.Errorf("collections[%d] = %q, want %q", i, meta.Collections[i], want)
+				}
+			}
+			// Normalized entries split back into name and version
+			name, _ := utils.ParseCollectionString(meta.Collections[0])
+			if !strings.Contains(name, ".") || strings.ContainsAny(name, "<>=") {
+				t.Errorf("unexpected collection name %q", name)
+			}
+		})
+	}
+}
+
+func TestMetaCollectionsRoundTrip(t *testing.T) {
+	content := `galaxy_info:
+  role_name: web
+collections:
+  - community.general>=7.4.0
+  - name: ansible.posix
+    version: 1.5.4
+  - name: community.docker
+`
+	var meta Meta
+	if err := yaml.Unmarshal([]byte(content), &meta); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	// A changed constraint keeps the map form with the new version
+	meta.Collections = append(meta.Collections, "ansible.utils")
+	meta.Collections[2] = "community.docker>=3.0.0"
+
+	output, err := MarshalMetaFile(&meta)
+	if err != nil {
+		t.Fatalf("MarshalMetaFile() error = %v", err)
+	}
+	var got struct {
+		Collections []interface{} `yaml:"collections"`
+	}
+	if err := yaml.Unmarshal(output, &got); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	want := []interface{}{
+		"community.general>=7.4.0",
+		map[string]interface{}{"name": "ansible.posix", "version": "1.5.4"},
+		map[string]interface{}{"name": "community.docker", "version": ">=3.0.0"},
+		"ansible.utils",
+	}
+	if len(got.Collections) != len(want) {
+		t.Fatalf("collections = %v, want %v\n%s", got.Collections, want, output)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got.Collections[i], want[i]) {
+			t.Errorf("collections[%d] = %#v, want %#v", i, got.Collections[i], want[i])
+		}
+	}
+
+	var reread Meta
+	if err := yaml.Unmarshal(output, &reread); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	if !reflect.DeepEqual(reread.Collections, meta.Collections) {
+		t.Errorf("reread collections = %v, want %v", reread.Collections, meta.Collections)
+	}
+}
+
 func TestParseRequirementFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -326,3 +440,90 @@ roles: []
 		t.Errorf("unexpected role name: got %q, want %q", meta.GalaxyInfo.RoleName, "test_role")
 	}
 }
+
+func TestSaveRequirementFileOmitsEmptySections(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldWd)
+
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "scenarios", "default"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		req         *Requirement
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "collections only",
+			req: &Requirement{Collections: []RequirementCollection{
+				{Name: "community.general", Version: ">=7.0.0"},
+			}, Roles: []RequirementRole{}},
+			contains:    []string{"collections:", "community.general"},
+			notContains: []string{"roles:"},
+		},
+		{
+			name:        "roles only",
+			req:         &Requirement{Roles: []RequirementRole{{Name: "test.role", Src: "https://github.com/test/role.git", Scm: "git", Version: "main"}}},
+			contains:    []string{"roles:", "test.role"},
+			notContains: []string{"collections:"},
+		},
+		{
+			name:        "empty",
+			req:         &Requirement{},
+			contains:    []string{"collections: []"},
+			notContains: []string{"roles:", "{}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SaveRequirementFile(tt.req, "default"); err != nil {
+				t.Fatalf("SaveRequirementFile failed: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join("scenarios", "default", "requirements.yml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("expected %q in:\n%s", s, data)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(string(data), s) {
+					t.Errorf("unexpected %q in:\n%s", s, data)
+				}
+			}
+
+			// Round-trip through the parser
+			loaded, err := ParseRequirementFile("default")
+			if err != nil {
+				t.Fatalf("ParseRequirementFile failed: %v", err)
+			}
+			if len(loaded.Collections) != len(tt.req.Collections) || len(loaded.Roles) != len(tt.req.Roles) {
+				t.Errorf("round-trip mismatch: got %d collections/%d roles, want %d/%d",
+					len(loaded.Collections), len(loaded.Roles), len(tt.req.Collections), len(tt.req.Roles))
+			}
+			for i, c := range tt.req.Collections {
+				if loaded.Collections[i] != c {
+					t.Errorf("collection %d = %+v, want %+v", i, loaded.Collections[i], c)
+				}
+			}
+			for i, r := range tt.req.Roles {
+				if loaded.Roles[i] != r {
+					t.Errorf("role %d = %+v, want %+v", i, loaded.Roles[i], r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package role
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/config"
+	"diffusion/internal/utils"
+)
+
+// validateScenarioName rejects names that would escape the scenarios directory
+func validateScenarioName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid scenario name %q", name)
+	}
+	return nil
+}
+
+// CloneScenario copies scenarios/<from> to scenarios/<to> under roleDir,
+// including its requirements.yml. It refuses to overwrite an existing scenario.
+func CloneScenario(roleDir, from, to string) error {
+	for _, name := range []string{from, to} {
+		if err := validateScenarioName(name); err != nil {
+			return err
+		}
+	}
+	if from == to {
+		return fmt.Errorf("source and target scenario are both %q", from)
+	}
+
+	scenariosDir := filepath.Join(roleDir, config.ScenariosDir)
+	src := filepath.Join(scenariosDir, from)
+	dst := filepath.Join(scenariosDir, to)
+
+	info, err := os.Stat(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("scenario %q not found in %s", from, scenariosDir)
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("scenario %q already exists in %s", to, scenariosDir)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := utils.CopyDir(src, dst); err != nil {
+		return fmt.Errorf("failed to copy scenario %q to %q: %w", from, to, err)
+	}
+	return nil
+}
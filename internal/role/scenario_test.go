@@ -0,0 +1,82 @@
+package role
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCloneScenario(t *testing.T) {
+	roleDir := t.TempDir()
+	src := filepath.Join(roleDir, "scenarios", "default")
+	files := map[string]string{
+		"molecule.yml":     "driver:\n  name: docker\n",
+		"converge.yml":     "- hosts: all\n",
+		"requirements.yml": "---\ncollections: []\n",
+		"files/extra.txt":  "extra\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := CloneScenario(roleDir, "default", "ubuntu"); err != nil {
+		t.Fatalf("CloneScenario: %v", err)
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(roleDir, "scenarios", "ubuntu", name))
+		if err != nil {
+			t.Fatalf("cloned %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("cloned %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCloneScenarioRefusesExisting(t *testing.T) {
+	roleDir := t.TempDir()
+	for _, s := range []string{"default", "ubuntu"} {
+		if err := os.MkdirAll(filepath.Join(roleDir, "scenarios", s), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keep := filepath.Join(roleDir, "scenarios", "ubuntu", "molecule.yml")
+	if err := os.WriteFile(keep, []byte("original\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(roleDir, "scenarios", "default", "molecule.yml"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CloneScenario(roleDir, "default", "ubuntu")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected already exists error, got %v", err)
+	}
+	if got, _ := os.ReadFile(keep); string(got) != "original\n" {
+		t.Errorf("existing scenario was overwritten: %q", got)
+	}
+}
+
+func TestCloneScenarioInvalid(t *testing.T) {
+	roleDir := t.TempDir()
+	cases := [][2]string{
+		{"missing", "new"},
+		{"default", "../escape"},
+		{"default", "default"},
+	}
+	if err := os.MkdirAll(filepath.Join(roleDir, "scenarios", "default"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if err := CloneScenario(roleDir, c[0], c[1]); err == nil {
+			t.Errorf("CloneScenario(%q, %q) succeeded, want error", c[0], c[1])
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/config"
+)
+
+// ageFileSuffix marks the credential files of the age backend
+const ageFileSuffix = ".age"
+
+// ageRun runs the age CLI with stdin and returns its stdout. Tests replace it.
+var ageRun = func(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("age", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("age: %s: %w", msg, err)
+		}
+		return nil, fmt.Errorf("age: %w", err)
+	}
+	return out, nil
+}
+
+// AgeStore keeps credentials as armored age files under
+// ~/.diffusion/age-secrets/<role>, so they can be decrypted with an existing
+// age identity. It needs the age CLI on PATH.
+type AgeStore struct {
+	Identity  string // Identity file passed to age -i
+	Recipient string // Encrypt to this recipient; empty encrypts to the identity's own
+}
+
+// ageSecretsDir returns the age backend's directory for the current role.
+// It lives outside ~/.diffusion/secrets so "secrets rekey" leaves it alone.
+func ageSecretsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	roleName := GetCurrentRoleName()
+	if roleName == "" {
+		roleName = "default"
+	}
+	dir := filepath.Join(homeDir, ".diffusion", "age-secrets", roleName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	return dir, nil
+}
+
+func (s *AgeStore) filePath(sourceName string) (string, error) {
+	dir, err := ageSecretsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sourceName+ageFileSuffix), nil
+}
+
+// identityPath expands a leading ~/ in the identity file path
+func (s *AgeStore) identityPath() (string, error) {
+	rest, ok := strings.CutPrefix(s.Identity, "~/")
+	if !ok {
+		return s.Identity, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, rest), nil
+}
+
+// Save encrypts the credentials to the recipient and writes them
+func (s *AgeStore) Save(creds *config.ArtifactCredentials) error {
+	jsonData, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	args := []string{"--encrypt", "--armor"}
+	if s.Recipient != "" {
+		args = append(args, "--recipient", s.Recipient)
+	} else {
+		identity, err := s.identityPath()
+		if err != nil {
+			return err
+		}
+		args = append(args, "--identity", identity)
+	}
+	encrypted, err := ageRun(jsonData, args...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	filePath, err := s.filePath(creds.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, encrypted, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// Load decrypts the credentials of a source with the identity
+func (s *AgeStore) Load(sourceName string) (*config.ArtifactCredentials, error) {
+	filePath, err := s.filePath(sourceName)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("credentials not found for source '%s'", sourceName)
+		}
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	identity, err := s.identityPath()
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := ageRun(encrypted, "--decrypt", "--identity", identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+
+	var creds config.ArtifactCredentials
+	if err := json.Unmarshal(decrypted, &creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+// Delete removes the credentials file of a source
+func (s *AgeStore) Delete(sourceName string) error {
+	filePath, err := s.filePath(sourceName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("credentials not found for source '%s'", sourceName)
+		}
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+	return nil
+}
+
+// List returns the sources with an age credentials file
+func (s *AgeStore) List() ([]string, error) {
+	dir, err := ageSecretsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets directory: %w", err)
+	}
+
+	sources := []string{}
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ageFileSuffix); ok && !entry.IsDir() {
+			sources = append(sources, name)
+		}
+	}
+	return sources, nil
+}
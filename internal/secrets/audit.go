@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditLogName is the local audit log under ~/.diffusion
+const auditLogName = "audit.log"
+
+// AuditLogPath returns the path of the local audit log
+func AuditLogPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".diffusion", auditLogName), nil
+}
+
+// AppendAuditLog records a sensitive action (e.g. revealing a token) as one
+// line in the local audit log. The secret itself is never written.
+func AppendAuditLog(action, sourceName string) error {
+	path, err := AuditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	role := GetCurrentRoleName()
+	if role == "" {
+		role = "default"
+	}
+	line := fmt.Sprintf("%s action=%s source=%s role=%s user=%s\n",
+		time.Now().UTC().Format(time.RFC3339), action, sourceName, role, user)
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// galaxyDirName holds the Automation Hub login next to the role directories, so
+// rekey re-encrypts it with the other credentials; the leading underscore keeps
+// it apart from the role names
+const galaxyDirName = "_galaxy"
+
+// GalaxyCredentials is the Automation Hub (or private Galaxy) server and token
+// stored by 'diffusion galaxy login'
+type GalaxyCredentials struct {
+	URL     string `json:"url"`
+	AuthURL string `json:"auth_url,omitempty"` // SSO token endpoint; empty for a private hub
+	Token   string `json:"token"`
+}
+
+// galaxyCredentialsPath returns ~/.diffusion/secrets/_galaxy/automation_hub
+func galaxyCredentialsPath() (string, error) {
+	root, err := secretsRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, galaxyDirName, "automation_hub"), nil
+}
+
+// SaveGalaxyCredentials encrypts and stores the Automation Hub login; it is
+// shared by all roles of the user
+func SaveGalaxyCredentials(creds *GalaxyCredentials) error {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return fmt.Errorf("failed to get encryption key: %w", err)
+	}
+
+	jsonData, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	encrypted, err := EncryptWithKey(jsonData, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	filePath, err := galaxyCredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, []byte(encrypted), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// LoadGalaxyCredentials returns the stored Automation Hub login, or nil when
+// 'diffusion galaxy login' was never run
+func LoadGalaxyCredentials() (*GalaxyCredentials, error) {
+	filePath, err := galaxyCredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key: %w", err)
+	}
+	decrypted, err := DecryptWithKey(string(encrypted), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+
+	var creds GalaxyCredentials
+	if err := json.Unmarshal(decrypted, &creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+// DeleteGalaxyCredentials removes the stored Automation Hub login
+func DeleteGalaxyCredentials() error {
+	filePath, err := galaxyCredentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no Automation Hub login stored")
+		}
+		return fmt.Errorf("failed to delete credentials: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGalaxyCredentialsRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	creds, err := LoadGalaxyCredentials()
+	if err != nil || creds != nil {
+		t.Fatalf("LoadGalaxyCredentials before login = %v, %v; want nil, nil", creds, err)
+	}
+
+	want := &GalaxyCredentials{
+		URL:     "https://hub.example.com/api/galaxy/content/published/",
+		AuthURL: "https://sso.example.com/token",
+		Token:   "hub-token-123456",
+	}
+	if err := SaveGalaxyCredentials(want); err != nil {
+		t.Fatalf("SaveGalaxyCredentials failed: %v", err)
+	}
+
+	path := filepath.Join(home, ".diffusion", "secrets", galaxyDirName, "automation_hub")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("credentials file not written: %v", err)
+	}
+	if strings.Contains(string(data), want.Token) {
+		t.Error("token is stored in plain text")
+	}
+
+	got, err := LoadGalaxyCredentials()
+	if err != nil {
+		t.Fatalf("LoadGalaxyCredentials failed: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("loaded %+v, want %+v", *got, *want)
+	}
+
+	if err := DeleteGalaxyCredentials(); err != nil {
+		t.Fatalf("DeleteGalaxyCredentials failed: %v", err)
+	}
+	if err := DeleteGalaxyCredentials(); err == nil {
+		t.Error("expected an error deleting a missing login")
+	}
+}
+
+func TestRekeyIncludesGalaxyCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	want := &GalaxyCredentials{URL: "https://hub.example.com/", Token: "hub-token-123456"}
+	if err := SaveGalaxyCredentials(want); err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rekey(newKey); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+
+	got, err := LoadGalaxyCredentials()
+	if err != nil {
+		t.Fatalf("LoadGalaxyCredentials after rekey failed: %v", err)
+	}
+	if got.Token != want.Token {
+		t.Errorf("token after rekey = %q, want %q", got.Token, want.Token)
+	}
+}
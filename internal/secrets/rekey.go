@@ -0,0 +1,165 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// GenerateKey returns a new random encryption key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// ParseKey reads a key stored as base64 text or as raw bytes
+func ParseKey(data []byte) ([]byte, error) {
+	if key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data))); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if len(data) == KeySize {
+		return data, nil
+	}
+	return nil, fmt.Errorf("expected a %d-byte key (raw or base64)", KeySize)
+}
+
+// rekeyFile is a stored credential file with its current and re-encrypted content
+type rekeyFile struct {
+	path      string
+	original  []byte
+	encrypted string
+}
+
+// Rekey re-encrypts every stored credential (of all roles, since the key is
+// shared) with newKey and replaces the key file. All credentials are decrypted
+// before anything is written, so a credential the current key cannot read
+// aborts the rotation untouched. The previous key file is kept as .key.bak
+// until every credential is rewritten; on failure the rewritten files and the
+// key are rolled back. Returns the number of re-encrypted credentials.
+func Rekey(newKey []byte) (int, error) {
+	if len(newKey) != KeySize {
+		return 0, fmt.Errorf("new key must be %d bytes, got %d", KeySize, len(newKey))
+	}
+
+	oldKey, err := getEncryptionKey()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current encryption key: %w", err)
+	}
+
+	root, err := secretsRoot()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return 0, fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	files, err := loadRekeyFiles(root, oldKey, newKey)
+	if err != nil {
+		return 0, err
+	}
+
+	keyPath := filepath.Join(root, keyFileName)
+	backupPath := keyPath + ".bak"
+	previousKey, err := os.ReadFile(keyPath)
+	hadKeyFile := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if hadKeyFile {
+		if err := os.WriteFile(backupPath, previousKey, 0600); err != nil {
+			return 0, fmt.Errorf("failed to back up key file: %w", err)
+		}
+	}
+
+	restoreKey := func() {
+		if hadKeyFile {
+			_ = os.Rename(backupPath, keyPath)
+		} else {
+			_ = os.Remove(keyPath)
+		}
+	}
+
+	encodedKey := []byte(base64.StdEncoding.EncodeToString(newKey) + "\n")
+	if err := writeFileAtomic(keyPath, encodedKey); err != nil {
+		restoreKey()
+		return 0, fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	for i, f := range files {
+		if err := writeFileAtomic(f.path, []byte(f.encrypted)); err != nil {
+			// Best-effort rollback: the originals are still readable with the old key
+			for _, done := range files[:i] {
+				_ = writeFileAtomic(done.path, done.original)
+			}
+			restoreKey()
+			return 0, fmt.Errorf("failed to rewrite %s, rolled back: %w", f.path, err)
+		}
+	}
+
+	if hadKeyFile {
+		_ = os.Remove(backupPath)
+	}
+	return len(files), nil
+}
+
+// loadRekeyFiles decrypts every credential file under the role directories of
+// root with oldKey and re-encrypts it with newKey
+func loadRekeyFiles(root string, oldKey, newKey []byte) ([]rekeyFile, error) {
+	roleDirs, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets directory: %w", err)
+	}
+
+	var files []rekeyFile
+	for _, roleDir := range roleDirs {
+		if !roleDir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, roleDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secrets directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(root, roleDir.Name(), entry.Name())
+			original, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read credentials file: %w", err)
+			}
+			plaintext, err := DecryptWithKey(string(original), oldKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt %s/%s with the current key: %w", roleDir.Name(), entry.Name(), err)
+			}
+			encrypted, err := EncryptWithKey(plaintext, newKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s/%s: %w", roleDir.Name(), entry.Name(), err)
+			}
+			files = append(files, rekeyFile{path: path, original: original, encrypted: encrypted})
+		}
+	}
+	return files, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers see either the old or the new content
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
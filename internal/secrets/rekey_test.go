@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// setupRekeyHome points the secrets storage at a temp home with two stored credentials
+func setupRekeyHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"aaa", "zzz"} {
+		creds := &config.ArtifactCredentials{Name: name, URL: "https://git.example.com/" + name, Username: "user", Token: "token-" + name}
+		if err := SaveArtifactCredentials(creds); err != nil {
+			t.Fatalf("SaveArtifactCredentials(%s) error: %v", name, err)
+		}
+	}
+}
+
+func assertCredentialsReadable(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"aaa", "zzz"} {
+		creds, err := LoadArtifactCredentials(name)
+		if err != nil {
+			t.Fatalf("LoadArtifactCredentials(%s) error: %v", name, err)
+		}
+		if creds.Token != "token-"+name {
+			t.Errorf("%s token = %q, want %q", name, creds.Token, "token-"+name)
+		}
+	}
+}
+
+func TestRekey(t *testing.T) {
+	setupRekeyHome(t)
+	oldKey, err := getEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := Rekey(newKey)
+	if err != nil {
+		t.Fatalf("Rekey() error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Rekey() re-encrypted %d credentials, want 2", count)
+	}
+
+	key, err := getEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != string(newKey) {
+		t.Error("encryption key was not replaced by the new key")
+	}
+	assertCredentialsReadable(t)
+
+	keyPath, _ := KeyPath()
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file %s should exist with mode 0600 (err %v)", keyPath, err)
+	}
+
+	// Old key no longer decrypts the stored credentials
+	secretsDir, _ := getSecretsDir()
+	data, _ := os.ReadFile(filepath.Join(secretsDir, "aaa"))
+	if _, err := DecryptWithKey(string(data), oldKey); err == nil {
+		t.Error("old key should not decrypt re-encrypted credentials")
+	}
+
+	// Rotating again replaces the key file and removes the backup
+	if _, err := Rekey(mustGenerateKey(t)); err != nil {
+		t.Fatalf("second Rekey() error: %v", err)
+	}
+	assertCredentialsReadable(t)
+	if _, err := os.Stat(keyPath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("key backup should be removed after a successful rekey (err %v)", err)
+	}
+}
+
+func TestRekeyRollsBackOnWriteFailure(t *testing.T) {
+	setupRekeyHome(t)
+	if _, err := Rekey(mustGenerateKey(t)); err != nil {
+		t.Fatalf("Rekey() error: %v", err)
+	}
+	keyPath, _ := KeyPath()
+	keyBefore, _ := os.ReadFile(keyPath)
+
+	// A directory in place of the temp file makes rewriting "zzz" fail after "aaa"
+	secretsDir, _ := getSecretsDir()
+	if err := os.Mkdir(filepath.Join(secretsDir, "zzz.tmp"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rekey(mustGenerateKey(t)); err == nil {
+		t.Fatal("Rekey() expected error when a credential cannot be rewritten")
+	}
+
+	keyAfter, _ := os.ReadFile(keyPath)
+	if string(keyAfter) != string(keyBefore) {
+		t.Error("key file was not restored after a failed rekey")
+	}
+	assertCredentialsReadable(t)
+}
+
+func TestRekeyAbortsOnUndecryptableCredential(t *testing.T) {
+	setupRekeyHome(t)
+	secretsDir, _ := getSecretsDir()
+	if err := os.WriteFile(filepath.Join(secretsDir, "broken"), []byte("not encrypted"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rekey(mustGenerateKey(t)); err == nil {
+		t.Fatal("Rekey() expected error for a credential the current key cannot decrypt")
+	}
+	keyPath, _ := KeyPath()
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Errorf("key file should not be written when rekey aborts (err %v)", err)
+	}
+	assertCredentialsReadable(t)
+}
+
+func TestParseKey(t *testing.T) {
+	key := mustGenerateKey(t)
+
+	for name, data := range map[string][]byte{
+		"raw":    key,
+		"base64": []byte(" " + base64.StdEncoding.EncodeToString(key) + "\n"),
+	} {
+		parsed, err := ParseKey(data)
+		if err != nil {
+			t.Errorf("ParseKey(%s) error: %v", name, err)
+			continue
+		}
+		if string(parsed) != string(key) {
+			t.Errorf("ParseKey(%s) returned a different key", name)
+		}
+	}
+
+	if _, err := ParseKey([]byte("too-short")); err == nil {
+		t.Error("ParseKey() expected error for a short key")
+	}
+}
+
+func mustGenerateKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -17,8 +17,46 @@ import (
 	"diffusion/internal/role"
 )
 
-// getEncryptionKey generates a unique encryption key based on computer name and username
+// KeySize is the length in bytes of the AES-256 encryption key
+const KeySize = 32
+
+// keyFileName is the key file inside the secrets root. It is written by
+// "diffusion secrets rekey"; until then the key is derived from the machine.
+const keyFileName = ".key"
+
+// KeyPath returns the location of the local encryption key file
+// (~/.diffusion/secrets/.key). The file may not exist yet.
+func KeyPath() (string, error) {
+	root, err := secretsRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, keyFileName), nil
+}
+
+// getEncryptionKey returns the key from the key file, falling back to the
+// machine-derived key when no key file exists
 func getEncryptionKey() ([]byte, error) {
+	keyPath, err := KeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		key, err := ParseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", keyPath, err)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return deriveMachineKey()
+}
+
+// deriveMachineKey generates a unique encryption key based on computer name and username
+func deriveMachineKey() ([]byte, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hostname: %w", err)
@@ -40,8 +78,8 @@ func getEncryptionKey() ([]byte, error) {
 	return hash[:], nil
 }
 
-// encryptData encrypts data using AES-256-GCM
-func encryptData(plaintext []byte, key []byte) (string, error) {
+// EncryptWithKey encrypts data with the given key using AES-256-GCM
+func EncryptWithKey(plaintext []byte, key []byte) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
@@ -61,8 +99,8 @@ func encryptData(plaintext []byte, key []byte) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// decryptData decrypts data using AES-256-GCM
-func decryptData(ciphertext string, key []byte) ([]byte, error) {
+// DecryptWithKey decrypts data encrypted by EncryptWithKey with the same key
+func DecryptWithKey(ciphertext string, key []byte) ([]byte, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode base64: %w", err)
@@ -92,12 +130,21 @@ func decryptData(ciphertext string, key []byte) ([]byte, error) {
 	return plaintext, nil
 }
 
-// getSecretsDir returns the directory for storing encrypted secrets
-func getSecretsDir() (string, error) {
+// secretsRoot returns ~/.diffusion/secrets, which holds one directory per role
+func secretsRoot() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
+	return filepath.Join(homeDir, ".diffusion", "secrets"), nil
+}
+
+// getSecretsDir returns the directory for storing encrypted secrets
+func getSecretsDir() (string, error) {
+	root, err := secretsRoot()
+	if err != nil {
+		return "", err
+	}
 
 	// Get current role name
 	roleName := GetCurrentRoleName()
@@ -105,7 +152,7 @@ func getSecretsDir() (string, error) {
 		roleName = "default"
 	}
 
-	secretsDir := filepath.Join(homeDir, ".diffusion", "secrets", roleName)
+	secretsDir := filepath.Join(root, roleName)
 	if err := os.MkdirAll(secretsDir, 0700); err != nil {
 		return "", fmt.Errorf("failed to create secrets directory: %w", err)
 	}
@@ -149,7 +196,7 @@ func SaveArtifactCredentials(creds *config.ArtifactCredentials) error {
 	}
 
 	// Encrypt the JSON data
-	encrypted, err := encryptData(jsonData, key)
+	encrypted, err := EncryptWithKey(jsonData, key)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt credentials: %w", err)
 	}
@@ -191,7 +238,7 @@ func LoadArtifactCredentials(sourceName string) (*config.ArtifactCredentials, er
 	}
 
 	// Decrypt the data
-	decrypted, err := decryptData(string(encrypted), key)
+	decrypted, err := DecryptWithKey(string(encrypted), key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
 	}
@@ -260,10 +307,9 @@ func GetArtifactCredentialsFromVault(source *config.ArtifactSource, vaultConfig
 		return nil, fmt.Errorf("vault integration not configured")
 	}
 
-	// Use the vault_client function from vault.go
-	result := vault_client(context.TODO(), source.VaultPath, source.VaultSecretName)
-	if result == nil {
-		return nil, fmt.Errorf("failed to retrieve credentials from vault")
+	data, err := readVaultSecret(context.TODO(), source.VaultPath, source.VaultSecretName, source.VaultKVVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials from vault: %w", err)
 	}
 
 	// Use per-source field names
@@ -277,12 +323,12 @@ func GetArtifactCredentialsFromVault(source *config.ArtifactSource, vaultConfig
 		tokenField = "token" // default
 	}
 
-	username, ok := result.Data.Data[usernameField].(string)
+	username, ok := data[usernameField].(string)
 	if !ok {
 		return nil, fmt.Errorf("username field '%s' not found in vault secret", usernameField)
 	}
 
-	token, ok := result.Data.Data[tokenField].(string)
+	token, ok := data[tokenField].(string)
 	if !ok {
 		return nil, fmt.Errorf("token field '%s' not found in vault secret", tokenField)
 	}
@@ -295,10 +341,11 @@ func GetArtifactCredentialsFromVault(source *config.ArtifactSource, vaultConfig
 	}, nil
 }
 
-// GetArtifactCredentials retrieves credentials from either Vault or local storage
+// GetArtifactCredentials retrieves credentials from either Vault or the
+// configured local secret store
 func GetArtifactCredentials(source *config.ArtifactSource, vaultConfig *config.HashicorpVault) (*config.ArtifactCredentials, error) {
 	if source.UseVault {
 		return GetArtifactCredentialsFromVault(source, vaultConfig)
 	}
-	return LoadArtifactCredentials(source.Name)
+	return LoadConfiguredCredentials(source.Name)
 }
@@ -38,9 +38,9 @@ func TestEncryptDecryptData(t *testing.T) {
 	testData := []byte("sensitive credential data")
 
 	// Encrypt
-	encrypted, err := encryptData(testData, key)
+	encrypted, err := EncryptWithKey(testData, key)
 	if err != nil {
-		t.Fatalf("encryptData failed: %v", err)
+		t.Fatalf("EncryptWithKey failed: %v", err)
 	}
 
 	if encrypted == string(testData) {
@@ -48,9 +48,9 @@ func TestEncryptDecryptData(t *testing.T) {
 	}
 
 	// Decrypt
-	decrypted, err := decryptData(encrypted, key)
+	decrypted, err := DecryptWithKey(encrypted, key)
 	if err != nil {
-		t.Fatalf("decryptData failed: %v", err)
+		t.Fatalf("DecryptWithKey failed: %v", err)
 	}
 
 	if string(decrypted) != string(testData) {
@@ -65,13 +65,13 @@ func TestEncryptDecryptDifferentKeys(t *testing.T) {
 
 	testData := []byte("test data")
 
-	encrypted, err := encryptData(testData, key1)
+	encrypted, err := EncryptWithKey(testData, key1)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Try to decrypt with wrong key
-	_, err = decryptData(encrypted, key2)
+	_, err = DecryptWithKey(encrypted, key2)
 	if err == nil {
 		t.Error("expected error when decrypting with wrong key")
 	}
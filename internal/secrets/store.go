@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"diffusion/internal/config"
+)
+
+// SecretStore keeps artifact credentials of the current role, keyed by
+// artifact source name
+type SecretStore interface {
+	Save(creds *config.ArtifactCredentials) error
+	Load(sourceName string) (*config.ArtifactCredentials, error)
+	Delete(sourceName string) error
+	List() ([]string, error)
+}
+
+// FileStore is the default backend: AES-GCM encrypted files under
+// ~/.diffusion/secrets/<role>, keyed by the local key (see KeyPath)
+type FileStore struct{}
+
+// Save encrypts and writes the credentials
+func (FileStore) Save(creds *config.ArtifactCredentials) error {
+	return SaveArtifactCredentials(creds)
+}
+
+// Load reads and decrypts the credentials of a source
+func (FileStore) Load(sourceName string) (*config.ArtifactCredentials, error) {
+	return LoadArtifactCredentials(sourceName)
+}
+
+// Delete removes the credentials of a source
+func (FileStore) Delete(sourceName string) error {
+	return DeleteArtifactCredentials(sourceName)
+}
+
+// List returns the sources with stored credentials
+func (FileStore) List() ([]string, error) {
+	return ListStoredCredentials()
+}
+
+// OpenStore returns the backend selected by [secrets]; nil settings select
+// the file backend
+func OpenStore(settings *config.SecretsSettings) (SecretStore, error) {
+	backend := config.DefaultSecretsBackend
+	if settings != nil && settings.Backend != "" {
+		backend = settings.Backend
+	}
+
+	switch backend {
+	case config.SecretsBackendFile:
+		return FileStore{}, nil
+	case config.SecretsBackendAge:
+		if settings.AgeIdentity == "" {
+			return nil, fmt.Errorf("[secrets] backend %q requires age_identity", backend)
+		}
+		return &AgeStore{Identity: settings.AgeIdentity, Recipient: settings.AgeRecipient}, nil
+	default:
+		return nil, fmt.Errorf("unknown [secrets] backend %q (expected %s or %s)", backend, config.SecretsBackendFile, config.SecretsBackendAge)
+	}
+}
+
+// ConfiguredStore opens the backend configured in diffusion.toml, falling
+// back to the file backend when there is no config yet
+func ConfiguredStore() (SecretStore, error) {
+	cfg, err := config.LoadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		return FileStore{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return OpenStore(cfg.SecretsConfig)
+}
+
+// LoadConfiguredCredentials loads the credentials of a source from the
+// configured backend
+func LoadConfiguredCredentials(sourceName string) (*config.ArtifactCredentials, error) {
+	store, err := ConfiguredStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load(sourceName)
+}
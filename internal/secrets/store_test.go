@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestOpenStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *config.SecretsSettings
+		want     SecretStore
+		wantErr  string
+	}{
+		{name: "default", settings: nil, want: FileStore{}},
+		{name: "file", settings: &config.SecretsSettings{Backend: "file"}, want: FileStore{}},
+		{name: "age", settings: &config.SecretsSettings{Backend: "age", AgeIdentity: "~/key.txt"}, want: &AgeStore{Identity: "~/key.txt"}},
+		{name: "age without identity", settings: &config.SecretsSettings{Backend: "age"}, wantErr: "requires age_identity"},
+		{name: "unknown", settings: &config.SecretsSettings{Backend: "keychain"}, wantErr: "unknown [secrets] backend"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenStore(tt.settings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("OpenStore() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenStore() error = %v", err)
+			}
+			switch want := tt.want.(type) {
+			case *AgeStore:
+				if age, ok := got.(*AgeStore); !ok || *age != *want {
+					t.Errorf("OpenStore() = %#v, want %#v", got, want)
+				}
+			default:
+				if got != want {
+					t.Errorf("OpenStore() = %#v, want %#v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfiguredStoreWithoutConfigIsFileStore(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(config.EnvConfig, "")
+
+	store, err := ConfiguredStore()
+	if err != nil {
+		t.Fatalf("ConfiguredStore() error = %v", err)
+	}
+	if _, ok := store.(FileStore); !ok {
+		t.Errorf("ConfiguredStore() = %#v, want FileStore", store)
+	}
+}
+
+// stubAge replaces the age CLI with a reversible fake that records the
+// argument lists
+func stubAge(t *testing.T) *[][]string {
+	t.Helper()
+	orig := ageRun
+	t.Cleanup(func() { ageRun = orig })
+
+	var calls [][]string
+	ageRun = func(stdin []byte, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if slices.Contains(args, "--encrypt") {
+			return append([]byte("AGE:"), stdin...), nil
+		}
+		return bytes.TrimPrefix(stdin, []byte("AGE:")), nil
+	}
+	return &calls
+}
+
+func TestAgeStoreRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	calls := stubAge(t)
+
+	store := &AgeStore{Identity: "~/age/keys.txt"}
+	creds := &config.ArtifactCredentials{Name: "gitlab", URL: "https://gitlab.example.com", Username: "ci", Token: "glpat-secret"}
+	if err := store.Save(creds); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := store.Load("gitlab")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if *loaded != *creds {
+		t.Errorf("Load() = %+v, want %+v", *loaded, *creds)
+	}
+
+	identity := filepath.Join(home, "age", "keys.txt")
+	want := [][]string{
+		{"--encrypt", "--armor", "--identity", identity},
+		{"--decrypt", "--identity", identity},
+	}
+	if !slices.EqualFunc(*calls, want, slices.Equal[[]string]) {
+		t.Errorf("age calls = %q, want %q", *calls, want)
+	}
+
+	names, err := store.List()
+	if err != nil || !slices.Equal(names, []string{"gitlab"}) {
+		t.Errorf("List() = %v, %v; want [gitlab]", names, err)
+	}
+	if stored, _ := ListStoredCredentials(); len(stored) != 0 {
+		t.Errorf("file backend holds %v, want nothing", stored)
+	}
+
+	if err := store.Delete("gitlab"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load("gitlab"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Load() after Delete error = %v, want not found", err)
+	}
+}
+
+func TestAgeStoreEncryptsToRecipient(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	calls := stubAge(t)
+
+	store := &AgeStore{Identity: "/keys.txt", Recipient: "age1example"}
+	if err := store.Save(&config.ArtifactCredentials{Name: "nexus", Token: "t"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	want := []string{"--encrypt", "--armor", "--recipient", "age1example"}
+	if len(*calls) != 1 || !slices.Equal((*calls)[0], want) {
+		t.Errorf("age calls = %q, want %q", *calls, want)
+	}
+
+	dir, err := ageSecretsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "nexus.age"))
+	if err != nil || !strings.HasPrefix(string(data), "AGE:") {
+		t.Errorf("nexus.age = %q, %v; want the age output", data, err)
+	}
+}
@@ -2,28 +2,81 @@ package secrets
 
 import (
 	"context"
-	"log"
-	"time"
+	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/hashicorp/vault-client-go"
-	"github.com/hashicorp/vault-client-go/schema"
 )
 
-func vault_client(ctx context.Context, path string, secret string) *vault.Response[schema.KvV2ReadResponse] {
-	client, err := vault.New(
-		vault.WithEnvironment(),
-		vault.WithRequestTimeout(30*time.Second),
-	)
+// KV secret engine versions
+const (
+	KVVersion1 = 1
+	KVVersion2 = 2
+)
+
+// kvSecretPath splits a configured Vault path and secret name into the mount
+// path and secret path for the given KV version. KV v2 paths are often written
+// with the API "data/" segment (secret/data/artifacts); the client adds that
+// segment itself, so it is folded into the secret path instead.
+func kvSecretPath(path, secret string, kvVersion int) (mount, secretPath string) {
+	mount = strings.Trim(path, "/")
+	secretPath = strings.Trim(secret, "/")
+	if kvVersion == KVVersion1 {
+		return mount, secretPath
+	}
+
+	if before, after, found := strings.Cut(mount+"/", "/data/"); found {
+		mount = before
+		if after = strings.Trim(after, "/"); after != "" {
+			secretPath = after + "/" + secretPath
+		}
+	}
+	return mount, secretPath
+}
+
+// readVaultSecret reads a secret from a KV v1 or v2 mount and returns its
+// key/value data. KV v1 returns the values directly under "data", KV v2 nests
+// them under "data.data" next to the version metadata.
+func readVaultSecret(ctx context.Context, path string, secret string, kvVersion int) (map[string]interface{}, error) {
+	client, token, err := newVaultClient()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	data, err := readKVSecret(ctx, client, path, secret, kvVersion)
+	if err != nil && vault.IsErrorStatus(err, http.StatusForbidden) && appRoleConfigured() {
+		// The token expired mid-run: log in again and retry once
+		if lerr := appRoleLogin(ctx, client); lerr != nil {
+			return nil, fmt.Errorf("%w (%v)", err, lerr)
+		}
+		return readKVSecret(ctx, client, path, secret, kvVersion)
 	}
-	result, err := client.Secrets.KvV2Read(
-		ctx,
-		secret,
-		vault.WithMountPath(path),
-	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	maintainVaultToken(ctx, client, token)
+	return data, nil
+}
+
+// readKVSecret reads one secret with an existing client
+func readKVSecret(ctx context.Context, client *vault.Client, path string, secret string, kvVersion int) (map[string]interface{}, error) {
+	mount, secretPath := kvSecretPath(path, secret, kvVersion)
+
+	switch kvVersion {
+	case KVVersion1:
+		result, err := client.Secrets.KvV1Read(ctx, secretPath, vault.WithMountPath(mount))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%s (kv v1): %w", mount, secretPath, err)
+		}
+		return result.Data, nil
+	case 0, KVVersion2:
+		result, err := client.Secrets.KvV2Read(ctx, secretPath, vault.WithMountPath(mount))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%s (kv v2): %w", mount, secretPath, err)
+		}
+		return result.Data.Data, nil
+	default:
+		return nil, fmt.Errorf("unsupported vault KV version %d (expected 1 or 2)", kvVersion)
 	}
-	return result
 }
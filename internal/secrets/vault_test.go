@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// Recorded responses from `vault kv get` against KV v1 and KV v2 mounts
+const (
+	kvV1Response = `{"request_id":"0a8f3f4e-2d0c-5e0b-8c5a-3f0b1c2d3e4f","lease_id":"","renewable":false,"lease_duration":2764800,` +
+		`"data":{"username":"ci-bot","token":"glpat-v1"},"wrap_info":null,"warnings":null,"auth":null}`
+	kvV2Response = `{"request_id":"7c1d2e3f-4a5b-6c7d-8e9f-0a1b2c3d4e5f","lease_id":"","renewable":false,"lease_duration":0,` +
+		`"data":{"data":{"username":"ci-bot","token":"glpat-v2"},"metadata":{"created_time":"2024-05-02T10:11:12.123456Z",` +
+		`"custom_metadata":null,"deletion_time":"","destroyed":false,"version":3}},"wrap_info":null,"warnings":null,"auth":null}`
+)
+
+func newRecordedVault(t *testing.T, responses map[string]string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "test-token")
+}
+
+func TestGetArtifactCredentialsKVVersions(t *testing.T) {
+	newRecordedVault(t, map[string]string{
+		"/v1/kv/artifacts/gitlab":          kvV1Response,
+		"/v1/secret/data/artifacts/gitlab": kvV2Response,
+	})
+	vaultConfig := &config.HashicorpVault{HashicorpVaultIntegration: true}
+
+	tests := []struct {
+		name      string
+		source    config.ArtifactSource
+		wantToken string
+	}{
+		{
+			name:      "kv v1",
+			source:    config.ArtifactSource{Name: "gitlab", UseVault: true, VaultPath: "kv/artifacts", VaultSecretName: "gitlab", VaultKVVersion: KVVersion1},
+			wantToken: "glpat-v1",
+		},
+		{
+			name:      "kv v2",
+			source:    config.ArtifactSource{Name: "gitlab", UseVault: true, VaultPath: "secret/data/artifacts", VaultSecretName: "gitlab", VaultKVVersion: KVVersion2},
+			wantToken: "glpat-v2",
+		},
+		{
+			name:      "kv v2 by default",
+			source:    config.ArtifactSource{Name: "gitlab", UseVault: true, VaultPath: "secret", VaultSecretName: "artifacts/gitlab"},
+			wantToken: "glpat-v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := GetArtifactCredentials(&tt.source, vaultConfig)
+			if err != nil {
+				t.Fatalf("GetArtifactCredentials() error = %v", err)
+			}
+			if creds.Username != "ci-bot" {
+				t.Errorf("Username = %q, want %q", creds.Username, "ci-bot")
+			}
+			if creds.Token != tt.wantToken {
+				t.Errorf("Token = %q, want %q", creds.Token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestGetArtifactCredentialsVaultErrors(t *testing.T) {
+	newRecordedVault(t, map[string]string{})
+	vaultConfig := &config.HashicorpVault{HashicorpVaultIntegration: true}
+
+	missing := &config.ArtifactSource{Name: "gitlab", UseVault: true, VaultPath: "kv", VaultSecretName: "absent", VaultKVVersion: KVVersion1}
+	if _, err := GetArtifactCredentials(missing, vaultConfig); err == nil {
+		t.Error("expected error for missing secret")
+	}
+
+	unsupported := &config.ArtifactSource{Name: "gitlab", UseVault: true, VaultPath: "kv", VaultSecretName: "x", VaultKVVersion: 3}
+	if _, err := GetArtifactCredentials(unsupported, vaultConfig); err == nil {
+		t.Error("expected error for unsupported KV version")
+	}
+}
+
+func TestKVSecretPath(t *testing.T) {
+	tests := []struct {
+		path, secret string
+		version      int
+		wantMount    string
+		wantSecret   string
+	}{
+		{"secret", "gitlab", KVVersion2, "secret", "gitlab"},
+		{"secret/data/artifacts", "gitlab", KVVersion2, "secret", "artifacts/gitlab"},
+		{"secret/data", "gitlab", KVVersion2, "secret", "gitlab"},
+		{"/kv/", "/gitlab", KVVersion1, "kv", "gitlab"},
+		{"kv/data/artifacts", "gitlab", KVVersion1, "kv/data/artifacts", "gitlab"},
+	}
+
+	for _, tt := range tests {
+		mount, secret := kvSecretPath(tt.path, tt.secret, tt.version)
+		if mount != tt.wantMount || secret != tt.wantSecret {
+			t.Errorf("kvSecretPath(%q, %q, %d) = (%q, %q), want (%q, %q)",
+				tt.path, tt.secret, tt.version, mount, secret, tt.wantMount, tt.wantSecret)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"diffusion/internal/config"
+
+	"github.com/hashicorp/vault-client-go"
+	"github.com/hashicorp/vault-client-go/schema"
+)
+
+// vaultRenewThreshold is the remaining token TTL below which the token is
+// renewed (or replaced by an AppRole login) after a Vault read
+const vaultRenewThreshold = 10 * time.Minute
+
+// liveVaultToken caches the token of the last successful Vault call for the
+// rest of the process, keyed by the Vault address it belongs to
+var liveVaultToken struct {
+	sync.Mutex
+	addr  string
+	token string
+}
+
+// newVaultClient creates a client from the VAULT_* environment, preferring the
+// cached live token over VAULT_TOKEN, and returns the token it uses
+func newVaultClient() (*vault.Client, string, error) {
+	client, err := vault.New(
+		vault.WithEnvironment(),
+		vault.WithRequestTimeout(30*time.Second),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create vault client: %w", err)
+	}
+
+	liveVaultToken.Lock()
+	defer liveVaultToken.Unlock()
+	token := os.Getenv(config.EnvVaultToken)
+	if liveVaultToken.token != "" && liveVaultToken.addr == os.Getenv(config.EnvVaultAddr) {
+		token = liveVaultToken.token
+		if err := client.SetToken(token); err != nil {
+			return nil, "", fmt.Errorf("failed to set vault token: %w", err)
+		}
+	}
+	return client, token, nil
+}
+
+// rememberVaultToken caches token for later clients and exports it as
+// VAULT_TOKEN, so containers started afterwards get the live token too
+func rememberVaultToken(token string) {
+	liveVaultToken.Lock()
+	defer liveVaultToken.Unlock()
+	liveVaultToken.addr = os.Getenv(config.EnvVaultAddr)
+	liveVaultToken.token = token
+	_ = os.Setenv(config.EnvVaultToken, token)
+}
+
+// appRoleConfigured reports whether AppRole credentials are in the environment
+func appRoleConfigured() bool {
+	return os.Getenv(config.EnvVaultRoleID) != "" && os.Getenv(config.EnvVaultSecretID) != ""
+}
+
+// appRoleLogin logs in with VAULT_ROLE_ID/VAULT_SECRET_ID and switches the
+// client to the new token
+func appRoleLogin(ctx context.Context, client *vault.Client) error {
+	resp, err := client.Auth.AppRoleLogin(ctx, schema.AppRoleLoginRequest{
+		RoleId:   os.Getenv(config.EnvVaultRoleID),
+		SecretId: os.Getenv(config.EnvVaultSecretID),
+	})
+	if err != nil {
+		return fmt.Errorf("vault AppRole login failed: %w", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault AppRole login returned no token")
+	}
+	if err := client.SetToken(resp.Auth.ClientToken); err != nil {
+		return fmt.Errorf("failed to set vault token: %w", err)
+	}
+	rememberVaultToken(resp.Auth.ClientToken)
+	return nil
+}
+
+// maintainVaultToken runs after a successful Vault read: it caches the token
+// and, when its remaining TTL is below vaultRenewThreshold, renews it, falling
+// back to an AppRole login when renewal is not possible. Failures only warn,
+// since the read already succeeded.
+func maintainVaultToken(ctx context.Context, client *vault.Client, token string) {
+	lookup, err := client.Auth.TokenLookUpSelf(ctx)
+	if err != nil {
+		log.Printf(config.ColorYellow+"warning: failed to look up vault token: %v"+config.ColorReset, err)
+		rememberVaultToken(token)
+		return
+	}
+
+	ttl := tokenSeconds(lookup.Data["ttl"])
+	renewable, _ := lookup.Data["renewable"].(bool)
+	// A TTL of 0 is a token that never expires (e.g. a root token)
+	if ttl == 0 || ttl >= vaultRenewThreshold {
+		rememberVaultToken(token)
+		return
+	}
+
+	if renewable {
+		if _, err := client.Auth.TokenRenewSelf(ctx, schema.TokenRenewSelfRequest{}); err == nil {
+			rememberVaultToken(token)
+			return
+		} else if !appRoleConfigured() {
+			log.Printf(config.ColorYellow+"warning: failed to renew vault token (%s left): %v"+config.ColorReset, ttl, err)
+			rememberVaultToken(token)
+			return
+		}
+	}
+	if appRoleConfigured() {
+		if err := appRoleLogin(ctx, client); err != nil {
+			log.Printf(config.ColorYellow+"warning: vault token expires in %s: %v"+config.ColorReset, ttl, err)
+			rememberVaultToken(token)
+		}
+		return
+	}
+	log.Printf(config.ColorYellow+"warning: vault token expires in %s and cannot be renewed; set %s and %s to re-authenticate with AppRole"+config.ColorReset, ttl, config.EnvVaultRoleID, config.EnvVaultSecretID)
+	rememberVaultToken(token)
+}
+
+// tokenSeconds converts a lookup-self TTL (seconds, decoded as json.Number) to a duration
+func tokenSeconds(v any) time.Duration {
+	var seconds int64
+	switch n := v.(type) {
+	case json.Number:
+		seconds, _ = n.Int64()
+	case float64:
+		seconds = int64(n)
+	case int:
+		seconds = int64(n)
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+// mockVault serves a KV v2 secret and the token endpoints. Reads are only
+// allowed with one of validTokens; lookup-self reports lookup as its data.
+type mockVault struct {
+	mu          sync.Mutex
+	validTokens map[string]bool
+	lookup      string
+	calls       []string
+}
+
+func (m *mockVault) serve(t *testing.T) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.calls = append(m.calls, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			m.validTokens["approle-token"] = true
+			_, _ = w.Write([]byte(`{"data":null,"auth":{"client_token":"approle-token","lease_duration":3600,"renewable":true}}`))
+			return
+		}
+		if !m.validTokens[r.Header.Get("X-Vault-Token")] {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			_, _ = w.Write([]byte(`{"data":` + m.lookup + `}`))
+		case "/v1/auth/token/renew-self":
+			_, _ = w.Write([]byte(`{"data":null,"auth":{"client_token":"` + r.Header.Get("X-Vault-Token") + `","lease_duration":3600,"renewable":true}}`))
+		case "/v1/secret/data/artifacts/gitlab":
+			_, _ = w.Write([]byte(kvV2Response))
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv(config.EnvVaultToken, "short-token")
+	t.Setenv(config.EnvVaultRoleID, "")
+	t.Setenv(config.EnvVaultSecretID, "")
+	t.Cleanup(func() {
+		liveVaultToken.Lock()
+		liveVaultToken.addr, liveVaultToken.token = "", ""
+		liveVaultToken.Unlock()
+	})
+}
+
+func (m *mockVault) called(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.calls {
+		if c == path {
+			return true
+		}
+	}
+	return false
+}
+
+func readTestSecret(t *testing.T) {
+	t.Helper()
+	data, err := readVaultSecret(t.Context(), "secret/data/artifacts", "gitlab", KVVersion2)
+	if err != nil {
+		t.Fatalf("readVaultSecret() error = %v", err)
+	}
+	if data["token"] != "glpat-v2" {
+		t.Errorf("token = %v, want glpat-v2", data["token"])
+	}
+}
+
+func TestVaultTokenRenewedWhenTTLIsShort(t *testing.T) {
+	m := &mockVault{validTokens: map[string]bool{"short-token": true}, lookup: `{"ttl":60,"renewable":true}`}
+	m.serve(t)
+
+	readTestSecret(t)
+	if !m.called("/v1/auth/token/renew-self") {
+		t.Errorf("expected renew-self, calls: %v", m.calls)
+	}
+	if got := os.Getenv(config.EnvVaultToken); got != "short-token" {
+		t.Errorf("VAULT_TOKEN = %q, want the renewed token", got)
+	}
+}
+
+func TestVaultTokenNotRenewedWithLongTTL(t *testing.T) {
+	m := &mockVault{validTokens: map[string]bool{"short-token": true}, lookup: `{"ttl":86400,"renewable":true}`}
+	m.serve(t)
+
+	readTestSecret(t)
+	if m.called("/v1/auth/token/renew-self") {
+		t.Errorf("unexpected renew-self, calls: %v", m.calls)
+	}
+}
+
+func TestVaultTokenReplacedByAppRole(t *testing.T) {
+	m := &mockVault{validTokens: map[string]bool{"short-token": true}, lookup: `{"ttl":60,"renewable":false}`}
+	m.serve(t)
+	t.Setenv(config.EnvVaultRoleID, "role")
+	t.Setenv(config.EnvVaultSecretID, "secret")
+
+	readTestSecret(t)
+	if !m.called("/v1/auth/approle/login") {
+		t.Fatalf("expected an AppRole login, calls: %v", m.calls)
+	}
+
+	// The next read reuses the cached AppRole token even with VAULT_TOKEN expired
+	m.mu.Lock()
+	delete(m.validTokens, "short-token")
+	m.lookup = `{"ttl":3600,"renewable":true}`
+	m.calls = nil
+	m.mu.Unlock()
+	t.Setenv(config.EnvVaultToken, "short-token")
+	readTestSecret(t)
+	if m.called("/v1/auth/approle/login") {
+		t.Errorf("expected the cached token to be reused, calls: %v", m.calls)
+	}
+}
+
+func TestVaultExpiredTokenReauthenticates(t *testing.T) {
+	m := &mockVault{validTokens: map[string]bool{}, lookup: `{"ttl":3600,"renewable":true}`}
+	m.serve(t)
+
+	_, err := readVaultSecret(t.Context(), "secret/data/artifacts", "gitlab", KVVersion2)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected a permission error without AppRole, got %v", err)
+	}
+
+	t.Setenv(config.EnvVaultRoleID, "role")
+	t.Setenv(config.EnvVaultSecretID, "secret")
+	readTestSecret(t)
+	if !m.called("/v1/auth/approle/login") {
+		t.Errorf("expected an AppRole login, calls: %v", m.calls)
+	}
+}
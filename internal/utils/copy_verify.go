@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxCopyMismatches caps how many diverging files a failed verification lists
+const maxCopyMismatches = 20
+
+// VerifyRoleDataCopy checks that every file CopyRoleData copies exists in the
+// molecule layout with the same size and SHA-256 as its source. Symlinks must
+// point to the same target. Extra files in the destination (tests, linters,
+// ansible.cfg) and files left out by .diffusionignore are skipped; permissions
+// are not compared.
+func VerifyRoleDataCopy(basePath, roleMoleculePath string) error {
+	ignore, err := LoadIgnoreFile(basePath)
+	if err != nil {
+		return err
+	}
+	var mismatches []string
+	files := 0
+	for _, p := range roleDataPairs {
+		srcRoot := filepath.Join(basePath, p.src)
+		if !Exists(srcRoot) {
+			continue
+		}
+		// A symlinked role dir (templates -> shared) is copied with its content;
+		// WalkDir does not follow a symlinked root, so walk its target
+		srcRoot, err := filepath.EvalSymlinks(srcRoot)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", p.src, err)
+		}
+		dstRoot := filepath.Join(roleMoleculePath, p.dst)
+		err = filepath.WalkDir(srcRoot, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(srcRoot, path)
+			if err != nil {
+				return err
+			}
+			if ignore.Match(ignorePath(p.src, rel), d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			files++
+			if problem := compareCopiedFile(path, filepath.Join(dstRoot, rel)); problem != "" {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s", filepath.ToSlash(filepath.Join(p.src, rel)), problem))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", srcRoot, err)
+		}
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+	shown := mismatches
+	if len(shown) > maxCopyMismatches {
+		shown = shown[:maxCopyMismatches]
+	}
+	msg := fmt.Sprintf("role data copy to %s does not match the source (%d of %d files differ):\n  %s",
+		roleMoleculePath, len(mismatches), files, strings.Join(shown, "\n  "))
+	if len(mismatches) > len(shown) {
+		msg += fmt.Sprintf("\n  ... and %d more", len(mismatches)-len(shown))
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// compareCopiedFile describes how dst differs from src, or returns "" when the copy matches
+func compareCopiedFile(src, dst string) string {
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return fmt.Sprintf("cannot read source: %v", err)
+	}
+	dstInfo, err := os.Lstat(dst)
+	if os.IsNotExist(err) {
+		return "missing in destination"
+	}
+	if err != nil {
+		return fmt.Sprintf("cannot read destination: %v", err)
+	}
+
+	if srcInfo.Mode()&fs.ModeSymlink != 0 {
+		srcTarget, _ := os.Readlink(src)
+		dstTarget, err := os.Readlink(dst)
+		if err != nil || srcTarget != dstTarget {
+			return fmt.Sprintf("symlink target %q, want %q", dstTarget, srcTarget)
+		}
+		return ""
+	}
+
+	if srcInfo.Size() != dstInfo.Size() {
+		return fmt.Sprintf("size %d bytes, want %d", dstInfo.Size(), srcInfo.Size())
+	}
+	srcSum, err := fileSHA256(src)
+	if err != nil {
+		return fmt.Sprintf("cannot hash source: %v", err)
+	}
+	dstSum, err := fileSHA256(dst)
+	if err != nil {
+		return fmt.Sprintf("cannot hash destination: %v", err)
+	}
+	if srcSum != dstSum {
+		return "content differs (sha256 mismatch)"
+	}
+	return ""
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's content
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
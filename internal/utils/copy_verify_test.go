@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copiedRole writes a small role and copies it with CopyRoleData
+func copiedRole(t *testing.T) (base, dest string) {
+	t.Helper()
+	base = t.TempDir()
+	writeRoleFile(t, base, "tasks/main.yml", "- name: install\n  ansible.builtin.package:\n    name: nginx\n")
+	writeRoleFile(t, base, "defaults/main.yml", "nginx_port: 80\n")
+	writeRoleFile(t, base, "scenarios/default/molecule.yml", "driver:\n  name: docker\n")
+	dest = filepath.Join(t.TempDir(), "acme.web")
+	if err := CopyRoleData(base, dest, true); err != nil {
+		t.Fatalf("CopyRoleData() error = %v", err)
+	}
+	return base, dest
+}
+
+func TestVerifyRoleDataCopy(t *testing.T) {
+	base, dest := copiedRole(t)
+	if err := VerifyRoleDataCopy(base, dest); err != nil {
+		t.Fatalf("VerifyRoleDataCopy() on a complete copy error = %v", err)
+	}
+
+	// files only in the destination are fine
+	writeRoleFile(t, dest, "molecule/default/tests/test_default.py", "def test(): pass\n")
+	if err := VerifyRoleDataCopy(base, dest); err != nil {
+		t.Errorf("VerifyRoleDataCopy() with extra destination files error = %v", err)
+	}
+}
+
+func TestVerifyRoleDataCopyDetectsTruncation(t *testing.T) {
+	base, dest := copiedRole(t)
+
+	// a disk-full copy leaves the file short
+	if err := os.Truncate(filepath.Join(dest, "tasks", "main.yml"), 10); err != nil {
+		t.Fatal(err)
+	}
+	err := VerifyRoleDataCopy(base, dest)
+	if err == nil {
+		t.Fatal("VerifyRoleDataCopy() did not detect a truncated file")
+	}
+	if !strings.Contains(err.Error(), "tasks/main.yml: size 10 bytes, want") || !strings.Contains(err.Error(), "1 of 3 files differ") {
+		t.Errorf("error does not name the truncated file: %v", err)
+	}
+}
+
+func TestVerifyRoleDataCopyDetectsContentAndMissing(t *testing.T) {
+	base, dest := copiedRole(t)
+
+	// same size, different bytes
+	writeRoleFile(t, dest, "defaults/main.yml", "nginx_port: 81\n")
+	if err := os.Remove(filepath.Join(dest, "molecule", "default", "molecule.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := VerifyRoleDataCopy(base, dest)
+	if err == nil {
+		t.Fatal("VerifyRoleDataCopy() did not detect the differences")
+	}
+	for _, want := range []string{
+		"defaults/main.yml: content differs (sha256 mismatch)",
+		"scenarios/default/molecule.yml: missing in destination",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestVerifyRoleDataCopySymlinkedDir(t *testing.T) {
+	base := t.TempDir()
+	writeRoleFile(t, base, "tasks/main.yml", "- name: render\n  ansible.builtin.template:\n    src: app.conf.j2\n    dest: /etc/app.conf\n")
+	writeRoleFile(t, base, "shared/app.conf.j2", "port={{ app_port }}\n")
+	writeRoleFile(t, base, "scenarios/default/molecule.yml", "driver:\n  name: docker\n")
+	if err := os.Symlink("shared", filepath.Join(base, "templates")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	dest := filepath.Join(t.TempDir(), "acme.web")
+	if err := CopyRoleData(base, dest, true); err != nil {
+		t.Fatalf("CopyRoleData() error = %v", err)
+	}
+
+	if err := VerifyRoleDataCopy(base, dest); err != nil {
+		t.Fatalf("VerifyRoleDataCopy() with a symlinked templates dir error = %v", err)
+	}
+
+	writeRoleFile(t, dest, "templates/app.conf.j2", "port={{ app_port }}!\n")
+	if err := VerifyRoleDataCopy(base, dest); err == nil || !strings.Contains(err.Error(), "templates/app.conf.j2") {
+		t.Errorf("VerifyRoleDataCopy() should check the files behind the symlink, got %v", err)
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"diffusion/internal/config"
@@ -104,7 +106,7 @@ func GetRoleMoleculePath(basePath, org, role string) string {
 // ValidateRegistryProvider validates the registry provider value
 func ValidateRegistryProvider(provider string) error {
 	switch provider {
-	case config.RegistryProviderYC, config.RegistryProviderAWS, config.RegistryProviderGCP, config.RegistryProviderPublic:
+	case config.RegistryProviderYC, config.RegistryProviderAWS, config.RegistryProviderGCP, config.RegistryProviderBasic, config.RegistryProviderPublic:
 		return nil
 	default:
 		return fmt.Errorf("%s", config.ErrInvalidRegistryProvider)
@@ -212,11 +214,24 @@ func GetUserMappingArgs() string {
 	return fmt.Sprintf("%d:%d", uid, gid)
 }
 
-// GetContainerHomePath returns the home directory path inside the container
-// The main molecule container always runs as root (required for Docker-in-Docker)
-// so it always uses /root as the home directory
-func GetContainerHomePath() string {
-	return "/root"
+// GetContainerHomePath returns the home directory path inside the container:
+// [container] home_path for images whose working user is not root, else /root
+// (the stock molecule image runs as root for Docker-in-Docker)
+func GetContainerHomePath(cfg *config.Config) string {
+	if cfg != nil && cfg.ContainerConfig != nil && cfg.ContainerConfig.HomePath != "" {
+		return path.Clean(cfg.ContainerConfig.HomePath)
+	}
+	return config.DefaultContainerHomePath
+}
+
+// ContainerRolesPath returns the Ansible roles cache path inside the container
+func ContainerRolesPath(cfg *config.Config) string {
+	return path.Join(GetContainerHomePath(cfg), config.ContainerRolesSubdir)
+}
+
+// ContainerCollectionsPath returns the Ansible collections cache path inside the container
+func ContainerCollectionsPath(cfg *config.Config) string {
+	return path.Join(GetContainerHomePath(cfg), config.ContainerCollectionsSubdir)
 }
 
 // ParseCollectionString parses a collection string like "community.general>=7.4.0" or "community.docker"
@@ -244,7 +259,7 @@ func RunCommandCapture(ctx context.Context, name string, args ...string) (string
 
 // runCommandHide runs command and discards stdout/stderr with a loading animation
 func RunCommandHide(ciMode bool, name string, args ...string) error {
-	if !ciMode {
+	if spinnerEnabled(ciMode) {
 		spinner := NewSpinner(fmt.Sprintf("Running %s", name))
 		spinner.Start()
 		defer spinner.Stop()
@@ -256,10 +271,10 @@ func RunCommandHide(ciMode bool, name string, args ...string) error {
 	return cmd.Run()
 }
 
-func ExportLinters(cfg *config.Config, roleMoleculePath string, CIMode bool, roleFlag string, orgFlag string) error {
-	if cfg.YamlLintConfig == nil || cfg.YamlLintConfig.Rules == nil || cfg.AnsibleLintConfig == nil {
-		log.Printf(config.ColorYellow + "warning: linter config incomplete, skipping export" + config.ColorReset)
-		return nil
+// MarshalYamlLint renders the .yamllint file content from the [yaml_lint] config
+func MarshalYamlLint(cfg *config.Config) ([]byte, error) {
+	if cfg.YamlLintConfig == nil || cfg.YamlLintConfig.Rules == nil {
+		return nil, fmt.Errorf("yaml_lint config is incomplete")
 	}
 
 	yamlrules := config.YamlLintRulesExport{
@@ -293,7 +308,29 @@ func ExportLinters(cfg *config.Config, roleMoleculePath string, CIMode bool, rol
 		Ignore:  strings.Join(cfg.YamlLintConfig.Ignore, "\n"),
 		Rules:   &yamlrules,
 	}
-	yamllint, err := yaml.Marshal(exportYamlLint)
+	return yaml.Marshal(exportYamlLint)
+}
+
+// MarshalAnsibleLint renders the .ansible-lint file content from the [ansible_lint] config
+func MarshalAnsibleLint(cfg *config.Config) ([]byte, error) {
+	if cfg.AnsibleLintConfig == nil {
+		return nil, fmt.Errorf("ansible_lint config is incomplete")
+	}
+	exportAnsibleLint := config.AnsibleLintExport{
+		ExcludedPaths: cfg.AnsibleLintConfig.ExcludedPaths,
+		WarnList:      cfg.AnsibleLintConfig.WarnList,
+		SkipList:      cfg.AnsibleLintConfig.SkipList,
+	}
+	return yaml.Marshal(exportAnsibleLint)
+}
+
+func ExportLinters(cfg *config.Config, roleMoleculePath string, CIMode bool, roleFlag string, orgFlag string) error {
+	if cfg.YamlLintConfig == nil || cfg.YamlLintConfig.Rules == nil || cfg.AnsibleLintConfig == nil {
+		log.Printf(config.ColorYellow + "warning: linter config incomplete, skipping export" + config.ColorReset)
+		return nil
+	}
+
+	yamllint, err := MarshalYamlLint(cfg)
 	if err != nil {
 		log.Printf("\033[33mwarning marshaling yamllint config: %v\033[0m", err)
 	} else {
@@ -315,13 +352,7 @@ func ExportLinters(cfg *config.Config, roleMoleculePath string, CIMode bool, rol
 		}
 	}
 
-	exportAnsibleLint := config.AnsibleLintExport{
-		ExcludedPaths: cfg.AnsibleLintConfig.ExcludedPaths,
-		WarnList:      cfg.AnsibleLintConfig.WarnList,
-		SkipList:      cfg.AnsibleLintConfig.SkipList,
-	}
-
-	ansiblelint, err := yaml.Marshal(exportAnsibleLint)
+	ansiblelint, err := MarshalAnsibleLint(cfg)
 	if err != nil {
 		log.Printf("\033[33mwarning marshaling ansible-lint config: %v\033[0m", err)
 	} else {
@@ -346,18 +377,145 @@ func ExportLinters(cfg *config.Config, roleMoleculePath string, CIMode bool, rol
 	return nil
 }
 
+// RenderAnsibleCfg renders ansible.cfg content from the [ansible_cfg] config
+// section, filling unset fields with defaults suited to molecule runs; the
+// default roles and collections paths are under containerHome
+func RenderAnsibleCfg(s *config.AnsibleCfgSettings, containerHome string) string {
+	if s == nil {
+		s = &config.AnsibleCfgSettings{}
+	}
+
+	forks := s.Forks
+	if forks <= 0 {
+		forks = config.DefaultAnsibleForks
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultAnsibleTimeout
+	}
+	hostKeyChecking := false
+	if s.HostKeyChecking != nil {
+		hostKeyChecking = *s.HostKeyChecking
+	}
+	collectionsPaths := s.CollectionsPaths
+	if len(collectionsPaths) == 0 {
+		collectionsPaths = []string{path.Join(containerHome, config.ContainerCollectionsSubdir), "/usr/share/ansible/collections"}
+	}
+	rolesPath := s.RolesPath
+	if len(rolesPath) == 0 {
+		rolesPath = []string{path.Join(containerHome, config.ContainerRolesSubdir), "/usr/share/ansible/roles"}
+	}
+
+	sections := map[string]map[string]string{
+		"defaults": {
+			"forks":             fmt.Sprintf("%d", forks),
+			"timeout":           fmt.Sprintf("%d", timeout),
+			"host_key_checking": fmt.Sprintf("%t", hostKeyChecking),
+			"collections_path":  strings.Join(collectionsPaths, ":"),
+			"roles_path":        strings.Join(rolesPath, ":"),
+		},
+	}
+	for section, values := range s.Extra {
+		if sections[section] == nil {
+			sections[section] = map[string]string{}
+		}
+		for key, value := range values {
+			sections[section][key] = value
+		}
+	}
+
+	// [defaults] first, remaining sections and all keys sorted for stable output
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		if name != "defaults" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{"defaults"}, names...)
+
+	var b strings.Builder
+	b.WriteString("# Generated by diffusion from [ansible_cfg] in diffusion.toml. Do not edit.\n")
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", name)
+		keys := make([]string, 0, len(sections[name]))
+		for key := range sections[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", key, sections[name][key])
+		}
+	}
+	return b.String()
+}
+
+// ExportAnsibleCfg writes ansible.cfg into the role layout when the config has
+// an [ansible_cfg] section. In CI mode the file is written inside the container.
+func ExportAnsibleCfg(cfg *config.Config, roleMoleculePath string, CIMode bool, roleFlag string, orgFlag string) error {
+	if cfg.AnsibleCfgConfig == nil {
+		return nil
+	}
+
+	content := []byte(RenderAnsibleCfg(cfg.AnsibleCfgConfig, GetContainerHomePath(cfg)))
+	if !CIMode {
+		if err := os.WriteFile(filepath.Join(roleMoleculePath, config.AnsibleCfgFileName), content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", config.AnsibleCfgFileName, err)
+		}
+		return nil
+	}
+
+	roleDirName := fmt.Sprintf("%s.%s", orgFlag, roleFlag)
+	containerPath := fmt.Sprintf("/opt/molecule/%s/%s", roleDirName, config.AnsibleCfgFileName)
+	// Use base64 encoding to safely transfer content
+	cmdCreateFile := fmt.Sprintf("echo '%s' | base64 -d > %s", base64.StdEncoding.EncodeToString(content), containerPath)
+	if err := DockerExecInteractiveHide(roleFlag, "/bin/sh", CIMode, "-c", cmdCreateFile); err != nil {
+		return fmt.Errorf("failed to write %s in CI mode: %w", config.AnsibleCfgFileName, err)
+	}
+	return nil
+}
+
 // dockerExecInteractive runs: docker exec -ti molecule-role <cmd...>
 // In CI mode, removes -ti flags to avoid TTY errors
 func DockerExecInteractive(role, command string, ciMode bool, args ...string) error {
+	return DockerExecInteractiveContext(context.Background(), role, command, ciMode, args...)
+}
+
+// DockerExecInteractiveContext is DockerExecInteractive bound to ctx: the
+// docker exec client is killed when ctx is cancelled or its deadline passes
+func DockerExecInteractiveContext(ctx context.Context, role, command string, ciMode bool, args ...string) error {
+	return DockerExecCaptureContext(ctx, role, command, ciMode, true, nil, args...)
+}
+
+// DockerExecCaptureContext is DockerExecInteractiveContext that also copies the
+// command's stdout and stderr to capture (when non-nil). With stream false the
+// output goes to capture only and is not shown. While capturing, stderr is
+// merged into stdout so capture sees the lines in order.
+func DockerExecCaptureContext(ctx context.Context, role, command string, ciMode, stream bool, capture io.Writer, args ...string) error {
 	execFlags := []string{"exec"}
 	if !ciMode {
 		execFlags = append(execFlags, "-ti")
 	}
-	execFlags = append(execFlags, fmt.Sprintf("molecule-%s", role), command)
+	execFlags = append(execFlags, GetMoleculeContainerName(role), command)
 	all := append(execFlags, args...)
-	cmd := exec.Command("docker", all...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := exec.CommandContext(ctx, "docker", all...)
+
+	stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	switch {
+	case capture != nil && stream:
+		// One writer for both streams: exec then never writes to it concurrently
+		tee := io.MultiWriter(os.Stdout, capture)
+		stdout, stderr = tee, tee
+	case capture != nil:
+		stdout, stderr = capture, capture
+	case !stream:
+		stdout, stderr = io.Discard, io.Discard
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
 	return cmd.Run()
 }
@@ -365,7 +523,7 @@ func DockerExecInteractive(role, command string, ciMode bool, args ...string) er
 // dockerExecInteractiveHide runs: docker exec -ti molecule-role <cmd...>
 // In CI mode, removes -ti flags to avoid TTY errors
 func DockerExecInteractiveHide(role, command string, ciMode bool, args ...string) error {
-	if !ciMode {
+	if spinnerEnabled(ciMode) {
 		spinner := NewSpinner(fmt.Sprintf("Running %s in container", command))
 		spinner.Start()
 		defer spinner.Stop()
@@ -375,7 +533,7 @@ func DockerExecInteractiveHide(role, command string, ciMode bool, args ...string
 	if !ciMode {
 		execFlags = append(execFlags, "-ti")
 	}
-	execFlags = append(execFlags, fmt.Sprintf("molecule-%s", role), command)
+	execFlags = append(execFlags, GetMoleculeContainerName(role), command)
 	all := append(execFlags, args...)
 	cmd := exec.Command("docker", all...)
 	cmd.Stdout = io.Discard
@@ -399,6 +557,12 @@ func FixContainerPermissions(role string, path string, ciMode bool) error {
 // CopyIfExists copies file/directory if it exists (recursively when directory)
 // Performance optimization: cache os.Stat result to avoid duplicate calls
 func CopyIfExists(src, dst string) {
+	copyIfExistsIgnoring(src, dst, nil, "")
+}
+
+// copyIfExistsIgnoring is CopyIfExists leaving out the paths ignore matches;
+// rel is the role-relative path of src the patterns are matched against
+func copyIfExistsIgnoring(src, dst string, ignore *IgnoreMatcher, rel string) {
 	fi, err := os.Stat(src)
 	if os.IsNotExist(err) {
 		log.Printf("\033[38;2;127;255;212mnote: %s does not exist, skipping\033[0m", src)
@@ -409,10 +573,10 @@ func CopyIfExists(src, dst string) {
 		return
 	}
 	if fi.IsDir() {
-		if err := CopyDir(src, dst); err != nil {
+		if err := copyDirIgnoring(src, dst, ignore, rel); err != nil {
 			log.Printf("copy dir error %s -> %s: %v", src, dst, err)
 		}
-	} else {
+	} else if !ignore.Match(rel, false) {
 		// file
 		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 			log.Printf("mkdir for file: %v", err)
@@ -423,12 +587,17 @@ func CopyIfExists(src, dst string) {
 	}
 }
 
-// CopyFile copies a single file with buffered I/O
+// CopyFile copies a single file with buffered I/O, preserving its permission bits
 func CopyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
 		return err
 	}
+	info, err := in.Stat()
+	if err != nil {
+		_ = in.Close()
+		return err
+	}
 	defer func() {
 		if cerr := in.Close(); cerr != nil {
 			log.Printf("Failed to close source file: %v", cerr)
@@ -456,17 +625,48 @@ func CopyFile(src, dst string) error {
 		return err
 	}
 
+	// os.Create uses 0644 (or keeps an existing file's mode); match the source, e.g. +x scripts
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
 	return out.Sync()
 }
 
-// CopyDir recursively copies a directory
+// CopyDir recursively copies a directory. File permissions are preserved and
+// symlinks inside the tree are recreated as links (with the same, possibly
+// relative, target) instead of being followed. A symlinked src itself (e.g. a
+// templates dir linked from elsewhere) is resolved and its contents copied.
 func CopyDir(src, dst string) error {
+	return copyDirIgnoring(src, dst, nil, "")
+}
+
+// CopyDirIgnoring is CopyDir leaving out the paths ignore matches, relative to src
+func CopyDirIgnoring(src, dst string, ignore *IgnoreMatcher) error {
+	return copyDirIgnoring(src, dst, ignore, "")
+}
+
+// copyDirIgnoring is CopyDir skipping the files and subtrees ignore matches;
+// prefix is the role-relative path of src the patterns are matched against
+func copyDirIgnoring(src, dst string, ignore *IgnoreMatcher, prefix string) error {
+	if resolved, err := filepath.EvalSymlinks(src); err == nil {
+		src = resolved
+	}
 	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		rel, _ := filepath.Rel(src, path)
+		if ignore.Match(ignorePath(prefix, rel), d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		target := filepath.Join(dst, rel)
+		if d.Type()&fs.ModeSymlink != 0 {
+			return copySymlink(path, target)
+		}
 		if d.IsDir() {
 			return os.MkdirAll(target, 0o755)
 		}
@@ -475,6 +675,33 @@ func CopyDir(src, dst string) error {
 	})
 }
 
+// copySymlink recreates the symlink at src as dst, replacing an existing dst
+func copySymlink(src, dst string) error {
+	link, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(link, dst)
+}
+
+// roleDataPairs lists the role directories copied into the molecule layout (source -> destination)
+var roleDataPairs = []struct{ src, dst string }{
+	{"tasks", "tasks"},
+	{"handlers", "handlers"},
+	{"templates", "templates"},
+	{"files", "files"},
+	{"vars", "vars"},
+	{"defaults", "defaults"},
+	{"meta", "meta"},
+	{config.ScenariosDir, config.MoleculeDir}, // copy scenarios into molecule/<role>/molecule/
+}
+
 // CopyRoleData copies tasks, handlers, templates, files, vars, defaults, meta, scenarios, .ansible-lint, .yamllint
 func CopyRoleData(basePath, roleMoleculePath string, ciMode bool) error {
 	// Validate that scenarios/default directory exists
@@ -493,22 +720,16 @@ func CopyRoleData(basePath, roleMoleculePath string, ciMode bool) error {
 		log.Printf("\033[38;2;127;255;212mCopying role data from %s to %s\033[0m", basePath, roleMoleculePath)
 	}
 
+	ignore, err := LoadIgnoreFile(basePath)
+	if err != nil {
+		return err
+	}
+
 	// create role dir base
 	if err := os.MkdirAll(roleMoleculePath, 0o755); err != nil {
 		return err
 	}
-	// helper copy pairs
-	pairs := []struct{ src, dst string }{
-		{"tasks", "tasks"},
-		{"handlers", "handlers"},
-		{"templates", "templates"},
-		{"files", "files"},
-		{"vars", "vars"},
-		{"defaults", "defaults"},
-		{"meta", "meta"},
-		{config.ScenariosDir, config.MoleculeDir}, // copy scenarios into molecule/<role>/molecule/
-	}
-	for _, p := range pairs {
+	for _, p := range roleDataPairs {
 		src := filepath.Join(basePath, p.src)
 		dst := filepath.Join(roleMoleculePath, p.dst)
 		if p.src == config.ScenariosDir {
@@ -517,7 +738,7 @@ func CopyRoleData(basePath, roleMoleculePath string, ciMode bool) error {
 		if ciMode {
 			log.Printf("Copying %s -> %s", src, dst)
 		}
-		CopyIfExists(src, dst)
+		copyIfExistsIgnoring(src, dst, ignore, p.src)
 	}
 
 	// Verify that molecule.yml was copied successfully
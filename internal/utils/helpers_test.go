@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"diffusion/internal/config"
+
+	"github.com/BurntSushi/toml"
 )
 
 func TestPathCache(t *testing.T) {
@@ -373,12 +375,20 @@ func TestGetDefaultMoleculeTag(t *testing.T) {
 }
 
 func TestGetContainerHomePath(t *testing.T) {
-	homePath := GetContainerHomePath()
+	homePath := GetContainerHomePath(&config.Config{})
 
-	// Main molecule container always runs as root (for DinD), so always uses /root
+	// The stock molecule image runs as root (for DinD), so the default is /root
 	if homePath != "/root" {
 		t.Errorf("expected '/root', got %q", homePath)
 	}
+
+	cfg := &config.Config{ContainerConfig: &config.ContainerSettings{HomePath: "/home/ansible/"}}
+	if got := GetContainerHomePath(cfg); got != "/home/ansible" {
+		t.Errorf("expected '/home/ansible', got %q", got)
+	}
+	if got := ContainerCollectionsPath(cfg); got != "/home/ansible/.ansible/collections" {
+		t.Errorf("unexpected collections path %q", got)
+	}
 }
 
 // TestCopyFile tests the CopyFile function
@@ -451,6 +461,112 @@ func TestCopyDir(t *testing.T) {
 	}
 }
 
+// TestCopyFilePreservesMode verifies that executable bits survive the copy
+func TestCopyFilePreservesMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcPath := filepath.Join(tmpDir, "helper.sh")
+	dstPath := filepath.Join(tmpDir, "copy.sh")
+
+	if err := os.WriteFile(srcPath, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// An existing destination keeps its own mode with os.Create; it must still match the source
+	if err := os.WriteFile(dstPath, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyFile(srcPath, dstPath); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+
+	info, err := os.Stat(dstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("expected mode 0755, got %o", info.Mode().Perm())
+	}
+}
+
+// TestCopyDirPreservesModesAndSymlinks verifies executable files keep +x and
+// relative symlinks are recreated rather than followed
+func TestCopyDirPreservesModesAndSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "source")
+	dstDir := filepath.Join(tmpDir, "dest")
+
+	if err := os.MkdirAll(filepath.Join(srcDir, "files", "shared"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "files", "run.sh"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "files", "shared", "app.conf.j2"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("files/shared", filepath.Join(srcDir, "templates")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("run.sh", filepath.Join(srcDir, "files", "latest.sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyDir(srcDir, dstDir); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dstDir, "files", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("expected run.sh mode 0755, got %o", info.Mode().Perm())
+	}
+
+	for link, want := range map[string]string{"templates": "files/shared", "files/latest.sh": "run.sh"} {
+		got, err := os.Readlink(filepath.Join(dstDir, link))
+		if err != nil {
+			t.Errorf("%s should be a symlink: %v", link, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: expected link target %q, got %q", link, want, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "templates", "app.conf.j2")); err != nil {
+		t.Errorf("relative symlinked dir should resolve inside the copy: %v", err)
+	}
+
+	// Copying again replaces existing links instead of failing
+	if err := CopyDir(srcDir, dstDir); err != nil {
+		t.Fatalf("second CopyDir failed: %v", err)
+	}
+}
+
+// TestCopyDirSymlinkedRoot verifies a symlinked source directory is copied by content
+func TestCopyDirSymlinkedRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	realDir := filepath.Join(tmpDir, "shared-templates")
+	if err := os.MkdirAll(realDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(realDir, "a.j2"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	linkDir := filepath.Join(tmpDir, "templates")
+	if err := os.Symlink("shared-templates", linkDir); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir := filepath.Join(tmpDir, "dest")
+	if err := CopyDir(linkDir, dstDir); err != nil {
+		t.Fatalf("CopyDir failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dstDir, "a.j2")); err != nil || string(data) != "a" {
+		t.Errorf("expected a.j2 copied from the symlink target, got %q, %v", data, err)
+	}
+}
+
 // TestCopyRoleData tests the CopyRoleData function
 func TestCopyRoleData(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -468,6 +584,9 @@ func TestCopyRoleData(t *testing.T) {
 	if err := os.WriteFile(moleculeYml, []byte("---\ndriver:\n  name: docker\n"), 0644); err != nil {
 		t.Fatal(err)
 	}
+	if err := os.WriteFile(filepath.Join(scenariosDir, "prepare.yml"), []byte("---\n- name: Prepare\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
 
 	// Create some role directories
 	for _, dir := range []string{"tasks", "defaults", "meta"} {
@@ -499,6 +618,9 @@ func TestCopyRoleData(t *testing.T) {
 	if !Exists(copiedMoleculeYml) {
 		t.Errorf("expected molecule.yml to be copied to %s", copiedMoleculeYml)
 	}
+	if !Exists(filepath.Join(roleMoleculePath, "molecule", "default", "prepare.yml")) {
+		t.Error("expected prepare.yml to be copied with the scenario")
+	}
 }
 
 // TestCopyRoleDataMissingScenarios tests that CopyRoleData returns an error when scenarios/ is missing
@@ -643,4 +765,73 @@ func TestGetImageURL_Construction(t *testing.T) {
 	if result != expected {
 		t.Errorf("got %q, want %q", result, expected)
 	}
-}
\ No newline at end of file
+}
+func TestRenderAnsibleCfg(t *testing.T) {
+	sample := `
+[ansible_cfg]
+forks = 20
+host_key_checking = true
+collections_paths = ["/opt/collections"]
+
+[ansible_cfg.extra.ssh_connection]
+pipelining = "True"
+`
+	var cfg config.Config
+	if _, err := toml.Decode(sample, &cfg); err != nil {
+		t.Fatalf("failed to decode sample config: %v", err)
+	}
+
+	got := RenderAnsibleCfg(cfg.AnsibleCfgConfig, config.DefaultContainerHomePath)
+	want := `# Generated by diffusion from [ansible_cfg] in diffusion.toml. Do not edit.
+[defaults]
+collections_path = /opt/collections
+forks = 20
+host_key_checking = true
+roles_path = /root/.ansible/roles:/usr/share/ansible/roles
+timeout = 30
+
+[ssh_connection]
+pipelining = True
+`
+	if got != want {
+		t.Errorf("RenderAnsibleCfg() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderAnsibleCfgDefaults(t *testing.T) {
+	got := RenderAnsibleCfg(&config.AnsibleCfgSettings{}, config.DefaultContainerHomePath)
+	for _, line := range []string{
+		"forks = 10",
+		"timeout = 30",
+		"host_key_checking = false",
+		"collections_path = /root/.ansible/collections:/usr/share/ansible/collections",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("expected %q in default ansible.cfg:\n%s", line, got)
+		}
+	}
+}
+
+func TestExportAnsibleCfg(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// No [ansible_cfg] section: nothing is written
+	if err := ExportAnsibleCfg(&config.Config{}, tmpDir, false, "role", "org"); err != nil {
+		t.Fatalf("ExportAnsibleCfg() error = %v", err)
+	}
+	if Exists(filepath.Join(tmpDir, config.AnsibleCfgFileName)) {
+		t.Error("ansible.cfg should not be written without [ansible_cfg] config")
+	}
+
+	cfg := &config.Config{AnsibleCfgConfig: &config.AnsibleCfgSettings{Forks: 3}}
+	if err := ExportAnsibleCfg(cfg, tmpDir, false, "role", "org"); err != nil {
+		t.Fatalf("ExportAnsibleCfg() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, config.AnsibleCfgFileName))
+	if err != nil {
+		t.Fatalf("ansible.cfg not written: %v", err)
+	}
+	if !strings.Contains(string(data), "forks = 3") {
+		t.Errorf("unexpected ansible.cfg content:\n%s", data)
+	}
+}
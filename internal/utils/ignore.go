@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"diffusion/internal/config"
+)
+
+// defaultIgnorePatterns keep key files and the gitignored secrets vars out of
+// the molecule layout; a .diffusionignore can re-include them with !pattern
+var defaultIgnorePatterns = []string{"*.key", "vars/secrets.yml"}
+
+// ignoreRule is one parsed .diffusionignore line
+type ignoreRule struct {
+	segments []string // Pattern split on "/"; a single segment matches the base name at any depth
+	negate   bool     // !pattern re-includes what earlier rules excluded
+	dirOnly  bool     // pattern/ only matches directories
+}
+
+// IgnoreMatcher matches role-relative paths against gitignore-style patterns:
+// globs per path segment, ** for any number of segments, a leading or inner /
+// anchoring to the role root, a trailing / for directories and ! to negate.
+// The last matching pattern decides. A nil matcher ignores nothing.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// ParseIgnorePatterns builds a matcher from .diffusionignore lines, skipping
+// blank lines and # comments
+func ParseIgnorePatterns(lines []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		if anchored {
+			rule.segments = strings.Split(line, "/")
+		} else {
+			rule.segments = []string{line}
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m
+}
+
+// LoadIgnoreFile reads <basePath>/.diffusionignore on top of the default
+// patterns; without the file only the defaults apply
+func LoadIgnoreFile(basePath string) (*IgnoreMatcher, error) {
+	lines := append([]string(nil), defaultIgnorePatterns...)
+	data, err := os.ReadFile(filepath.Join(basePath, config.IgnoreFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	lines = append(lines, strings.Split(string(data), "\n")...)
+	return ParseIgnorePatterns(lines), nil
+}
+
+// Match reports whether the slash-separated role-relative path rel is ignored
+func (m *IgnoreMatcher) Match(rel string, isDir bool) bool {
+	if m == nil || rel == "" || rel == "." {
+		return false
+	}
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.matches(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(rel string) bool {
+	if len(r.segments) == 1 && r.segments[0] != "**" {
+		ok, _ := path.Match(r.segments[0], path.Base(rel))
+		return ok
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where "**"
+// stands for zero or more segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignorePath joins a role-relative prefix and a walk-relative path into the
+// slash path the patterns see
+func ignorePath(prefix, rel string) string {
+	return path.Join(filepath.ToSlash(prefix), filepath.ToSlash(rel))
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcherMatch(t *testing.T) {
+	m := ParseIgnorePatterns([]string{
+		"# fixtures are too big for the container",
+		"files/fixtures/",
+		"*.tar.gz",
+		"/templates/local.j2",
+		"**/tmp/**",
+		"*.key",
+		"!files/ca.key",
+		"",
+	})
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"files/fixtures", true, true},
+		{"files/fixtures", false, false}, // dir-only pattern
+		{"files/app.conf", false, false},
+		{"files/dump.tar.gz", false, true},
+		{"files/nested/dump.tar.gz", false, true},
+		{"templates/local.j2", false, true},
+		{"templates/sub/local.j2", false, false}, // anchored to the role root
+		{"tasks/tmp/x.yml", false, true},
+		{"files/server.key", false, true},
+		{"files/ca.key", false, false}, // re-included by the later !pattern
+		{".", true, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	var none *IgnoreMatcher
+	if none.Match("files/server.key", false) {
+		t.Error("a nil matcher should ignore nothing")
+	}
+}
+
+func TestCopyRoleDataHonoursIgnoreFile(t *testing.T) {
+	base := t.TempDir()
+	writeRoleFile(t, base, "tasks/main.yml", "- name: install\n  ansible.builtin.package:\n    name: nginx\n")
+	writeRoleFile(t, base, "files/app.conf", "port=80\n")
+	writeRoleFile(t, base, "files/fixtures/big/data.json", "{}\n")
+	writeRoleFile(t, base, "files/fixtures/seed.sql", "select 1;\n")
+	writeRoleFile(t, base, "files/dump.tar.gz", "gz")
+	writeRoleFile(t, base, "files/tls/server.key", "secret")
+	writeRoleFile(t, base, "vars/main.yml", "a: 1\n")
+	writeRoleFile(t, base, "vars/secrets.yml", "password: hunter2\n")
+	writeRoleFile(t, base, "scenarios/default/molecule.yml", "driver:\n  name: docker\n")
+	writeRoleFile(t, base, ".diffusionignore", "files/fixtures/\n*.tar.gz\n")
+
+	dest := filepath.Join(t.TempDir(), "acme.web")
+	if err := CopyRoleData(base, dest, true); err != nil {
+		t.Fatalf("CopyRoleData() error = %v", err)
+	}
+
+	for _, rel := range []string{"tasks/main.yml", "files/app.conf", "vars/main.yml", "molecule/default/molecule.yml"} {
+		if !Exists(filepath.Join(dest, rel)) {
+			t.Errorf("%s was not copied", rel)
+		}
+	}
+	for _, rel := range []string{"files/fixtures", "files/dump.tar.gz", "files/tls/server.key", "vars/secrets.yml"} {
+		if Exists(filepath.Join(dest, rel)) {
+			t.Errorf("%s was copied despite being ignored", rel)
+		}
+	}
+
+	if err := VerifyRoleDataCopy(base, dest); err != nil {
+		t.Errorf("VerifyRoleDataCopy() should skip ignored files: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"diffusion/internal/config"
+)
+
+var quiet atomic.Bool
+
+// SetQuiet enables or disables quiet mode. In quiet mode the spinner is not
+// shown and info log lines are dropped; errors and warnings still reach stderr.
+func SetQuiet(enabled bool) {
+	quiet.Store(enabled)
+	if enabled {
+		log.SetOutput(&quietLogWriter{w: os.Stderr})
+	} else {
+		log.SetOutput(os.Stderr)
+	}
+}
+
+// IsQuiet reports whether quiet mode is on, either via SetQuiet or DIFFUSION_QUIET
+func IsQuiet() bool {
+	if quiet.Load() {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(config.EnvQuiet))
+	return enabled
+}
+
+// spinnerEnabled reports whether commands may show the spinner
+func spinnerEnabled(ciMode bool) bool {
+	return !ciMode && !IsQuiet()
+}
+
+// quietLogWriter forwards only error and warning log lines. The log package
+// writes each message with a single Write call, so filtering per call is safe.
+type quietLogWriter struct {
+	w io.Writer
+}
+
+func (q *quietLogWriter) Write(p []byte) (int, error) {
+	if !isErrorOrWarning(p) {
+		return len(p), nil
+	}
+	return q.w.Write(p)
+}
+
+// isErrorOrWarning classifies a log line by its color or wording
+func isErrorOrWarning(line []byte) bool {
+	if bytes.Contains(line, []byte(config.ColorRed)) || bytes.Contains(line, []byte(config.ColorYellow)) {
+		return true
+	}
+	lower := bytes.ToLower(line)
+	for _, word := range []string{"error", "fail", "warning"} {
+		if bytes.Contains(lower, []byte(word)) {
+			return true
+		}
+	}
+	return false
+}
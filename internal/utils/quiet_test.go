@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"diffusion/internal/config"
+)
+
+func TestQuietLogWriterKeepsErrorsAndWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&quietLogWriter{w: &buf}, "", 0)
+
+	logger.Printf(config.ColorGreen + "Role initialized" + config.ColorReset)
+	logger.Printf("Checking molecule.yml in container...")
+	logger.Printf(config.ColorRed + "molecule converge failed" + config.ColorReset)
+	logger.Printf(config.ColorYellow + "warning loading config" + config.ColorReset)
+	logger.Printf("copy stat error: permission denied")
+
+	out := buf.String()
+	for _, dropped := range []string{"Role initialized", "Checking molecule.yml"} {
+		if bytes.Contains(buf.Bytes(), []byte(dropped)) {
+			t.Errorf("quiet log output contains info line %q:\n%s", dropped, out)
+		}
+	}
+	for _, kept := range []string{"converge failed", "warning loading config", "copy stat error"} {
+		if !bytes.Contains(buf.Bytes(), []byte(kept)) {
+			t.Errorf("quiet log output missing %q:\n%s", kept, out)
+		}
+	}
+}
+
+func TestIsQuiet(t *testing.T) {
+	t.Cleanup(func() { SetQuiet(false) })
+
+	t.Setenv(config.EnvQuiet, "")
+	if IsQuiet() {
+		t.Error("IsQuiet() = true with no flag or env")
+	}
+	if !spinnerEnabled(false) {
+		t.Error("spinnerEnabled(false) = false outside quiet and CI mode")
+	}
+	if spinnerEnabled(true) {
+		t.Error("spinnerEnabled(true) = true in CI mode")
+	}
+
+	t.Setenv(config.EnvQuiet, "1")
+	if !IsQuiet() || spinnerEnabled(false) {
+		t.Error("DIFFUSION_QUIET=1 should enable quiet mode and disable the spinner")
+	}
+
+	t.Setenv(config.EnvQuiet, "")
+	SetQuiet(true)
+	if !IsQuiet() {
+		t.Error("IsQuiet() = false after SetQuiet(true)")
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"diffusion/internal/config"
+)
+
+// roleChangeFiles lists role-root files besides roleDataPairs whose changes
+// affect a molecule run
+var roleChangeFiles = []string{"diffusion.toml", config.LockFileName, ".ansible-lint", ".yamllint"}
+
+// roleChangeDirs lists role-root directories besides roleDataPairs that ship
+// with the role (custom modules, module utils, role tests). Any "*_plugins"
+// directory (filter_plugins, lookup_plugins, ...) counts as well.
+var roleChangeDirs = []string{"library", "module_utils", "tests"}
+
+// RoleChanged reports whether any of the changed files (paths relative to the
+// repository root, as printed by git diff --name-only) is a role input. prefix
+// is the role directory relative to the repository root ("" or "roles/nginx/").
+func RoleChanged(changedFiles []string, prefix string) bool {
+	prefix = strings.Trim(path.Clean("/"+prefix), "/")
+	for _, file := range changedFiles {
+		file = path.Clean(strings.TrimSpace(file))
+		rel := file
+		if prefix != "" {
+			if !strings.HasPrefix(file, prefix+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(file, prefix+"/")
+		}
+		if isRoleInput(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRoleInput reports whether a path relative to the role root is a role input
+func isRoleInput(rel string) bool {
+	for _, p := range roleDataPairs {
+		if rel == p.src || strings.HasPrefix(rel, p.src+"/") {
+			return true
+		}
+	}
+	for _, name := range roleChangeFiles {
+		if rel == name {
+			return true
+		}
+	}
+	top, _, nested := strings.Cut(rel, "/")
+	if !nested {
+		return false
+	}
+	return slices.Contains(roleChangeDirs, top) || strings.HasSuffix(top, "_plugins")
+}
+
+// RoleChangedSince reports whether role inputs under dir changed between the
+// merge base of baseRef and HEAD (git diff --name-only <base>...HEAD)
+func RoleChangedSince(dir, baseRef string) (bool, error) {
+	ctx := context.Background()
+	prefix, err := RunCommandCapture(ctx, "git", "-C", dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return false, fmt.Errorf("not a git repository: %v: %s", err, prefix)
+	}
+	out, err := RunCommandCapture(ctx, "git", "-C", dir, "diff", "--name-only", baseRef+"...HEAD")
+	if err != nil {
+		return false, fmt.Errorf("git diff against %s failed: %v: %s", baseRef, err, out)
+	}
+	return RoleChanged(strings.Split(strings.TrimSpace(out), "\n"), strings.TrimSpace(prefix)), nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoleChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		prefix string
+		want   bool
+	}{
+		{"task change at repo root", []string{"tasks/main.yml"}, "", true},
+		{"scenario change", []string{"scenarios/default/converge.yml"}, "", true},
+		{"lock file change", []string{"diffusion.lock"}, "", true},
+		{"docs only", []string{"README.md", "docs/index.html"}, "", false},
+		{"similar dir name is not a role dir", []string{"tasks-old/main.yml"}, "", false},
+		{"monorepo role changed", []string{"roles/nginx/templates/site.conf.j2"}, "roles/nginx/", true},
+		{"monorepo other role changed", []string{"roles/redis/tasks/main.yml"}, "roles/nginx/", false},
+		{"monorepo prefix without slash", []string{"roles/nginx/meta/main.yml"}, "roles/nginx", true},
+		{"monorepo sibling with shared prefix", []string{"roles/nginx-extra/tasks/main.yml"}, "roles/nginx/", false},
+		{"monorepo root file outside role", []string{"tasks/main.yml"}, "roles/nginx/", false},
+		{"custom module change", []string{"library/my_module.py"}, "", true},
+		{"module utils change", []string{"module_utils/helpers.py"}, "", true},
+		{"filter plugin change", []string{"filter_plugins/net.py"}, "", true},
+		{"role tests change", []string{"tests/test.yml"}, "", true},
+		{"monorepo lookup plugin change", []string{"roles/nginx/lookup_plugins/vault.py"}, "roles/nginx/", true},
+		{"file named like a plugin dir", []string{"filter_plugins"}, "", false},
+		{"empty diff", []string{""}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoleChanged(tt.files, tt.prefix); got != tt.want {
+				t.Errorf("RoleChanged(%v, %q) = %v, want %v", tt.files, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleChangedSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		full := filepath.Join(repo, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("roles/nginx/tasks/main.yml", "---\n")
+	write("roles/redis/tasks/main.yml", "---\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	git("checkout", "-q", "-b", "feature")
+	write("roles/redis/tasks/main.yml", "--- # changed\n")
+	git("commit", "-q", "-am", "change redis")
+
+	changed, err := RoleChangedSince(filepath.Join(repo, "roles", "nginx"), "main")
+	if err != nil {
+		t.Fatalf("RoleChangedSince(nginx) error: %v", err)
+	}
+	if changed {
+		t.Error("nginx should be unchanged when only redis changed")
+	}
+	changed, err = RoleChangedSince(filepath.Join(repo, "roles", "redis"), "main")
+	if err != nil {
+		t.Fatalf("RoleChangedSince(redis) error: %v", err)
+	}
+	if !changed {
+		t.Error("redis should be reported as changed")
+	}
+	if _, err := RoleChangedSince(repo, "no-such-ref"); err == nil {
+		t.Error("expected error for an unknown base ref")
+	}
+}
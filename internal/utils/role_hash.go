@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// HashRoleInputs returns a SHA-256 over the role input files that CopyRoleData copies
+// (tasks, handlers, templates, files, vars, defaults, meta, scenarios).
+// The hash covers relative paths, permission bits and file contents, not mtimes,
+// so touching a file without changing it keeps the hash stable.
+func HashRoleInputs(basePath string) (string, error) {
+	h := sha256.New()
+	for _, p := range roleDataPairs {
+		root := filepath.Join(basePath, p.src)
+		if !Exists(root) {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(basePath, path)
+			if err != nil {
+				return err
+			}
+			return hashRoleFile(h, path, filepath.ToSlash(rel), d)
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", root, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashRoleFile writes one file entry (path, mode, content) into the hash.
+// Symlinks contribute their target rather than the file they point to.
+func hashRoleFile(w io.Writer, path, rel string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "L %s\x00%s\x00", rel, target)
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "F %s\x00%o\x00%d\x00", rel, info.Mode().Perm(), info.Size()); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
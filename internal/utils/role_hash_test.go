@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeRoleFile(t *testing.T, base, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(base, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHashRoleInputs(t *testing.T) {
+	base := t.TempDir()
+	task := writeRoleFile(t, base, "tasks/main.yml", "- name: install\n")
+	writeRoleFile(t, base, "scenarios/default/molecule.yml", "driver:\n  name: docker\n")
+	writeRoleFile(t, base, "README.md", "not a role input\n")
+
+	first, err := HashRoleInputs(base)
+	if err != nil {
+		t.Fatalf("HashRoleInputs() error = %v", err)
+	}
+
+	// mtime-only changes and non-input files do not affect the hash
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(task, later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeRoleFile(t, base, "README.md", "edited\n")
+	if got, _ := HashRoleInputs(base); got != first {
+		t.Errorf("hash changed after touching files: %s != %s", got, first)
+	}
+
+	// content changes do
+	writeRoleFile(t, base, "tasks/main.yml", "- name: configure\n")
+	if got, _ := HashRoleInputs(base); got == first {
+		t.Error("hash did not change after editing tasks/main.yml")
+	}
+
+	// new files under scenarios do
+	writeRoleFile(t, base, "tasks/main.yml", "- name: install\n")
+	writeRoleFile(t, base, "scenarios/default/verify.yml", "---\n")
+	if got, _ := HashRoleInputs(base); got == first {
+		t.Error("hash did not change after adding a scenario file")
+	}
+}
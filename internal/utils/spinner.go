@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 )
@@ -11,6 +13,7 @@ type Spinner struct {
 	frames   []string
 	interval time.Duration
 	message  string
+	out      io.Writer
 	stop     chan bool
 	wg       sync.WaitGroup
 	active   bool
@@ -23,13 +26,17 @@ func NewSpinner(message string) *Spinner {
 		frames:   []string{".", "·", "•", "¤", "°", "¤", "•", "·"},
 		interval: 100 * time.Millisecond,
 		message:  message,
+		out:      os.Stdout,
 		stop:     make(chan bool),
 		active:   false,
 	}
 }
 
-// Start begins the spinner animation
+// Start begins the spinner animation. It does nothing in quiet mode.
 func (s *Spinner) Start() {
+	if IsQuiet() {
+		return
+	}
 	s.mu.Lock()
 	if s.active {
 		s.mu.Unlock()
@@ -46,11 +53,14 @@ func (s *Spinner) Start() {
 			select {
 			case <-s.stop:
 				// Clear the line
-				fmt.Printf("\r\033[K")
+				fmt.Fprint(s.out, "\r\033[K")
 				return
 			default:
 				// Aquamarine color: RGB(127, 255, 212) = \033[38;2;127;255;212m
-				fmt.Printf("\r\033[38;2;127;255;212m%s %s\033[0m", s.message, s.frames[i%len(s.frames)])
+				s.mu.Lock()
+				message := s.message
+				s.mu.Unlock()
+				fmt.Fprintf(s.out, "\r\033[38;2;127;255;212m%s %s\033[0m", message, s.frames[i%len(s.frames)])
 				i++
 				time.Sleep(s.interval)
 			}
@@ -72,6 +82,11 @@ func (s *Spinner) Stop() {
 	s.wg.Wait()
 }
 
+// SetOutput directs the animation to w instead of stdout; call it before Start
+func (s *Spinner) SetOutput(w io.Writer) {
+	s.out = w
+}
+
 // UpdateMessage changes the spinner message while running
 func (s *Spinner) UpdateMessage(message string) {
 	s.mu.Lock()
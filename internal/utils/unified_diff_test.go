@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "---\ncollections:\n    - name: community.general\n      version: 7.4.0\n"
+	after := "---\ncollections:\n    - name: community.general\n      version: 9.1.0\n    - name: amazon.aws\n"
+
+	want := "--- a/requirements.yml\n+++ b/requirements.yml\n" +
+		"@@ -1,4 +1,5 @@\n" +
+		" ---\n" +
+		" collections:\n" +
+		"     - name: community.general\n" +
+		"-      version: 7.4.0\n" +
+		"+      version: 9.1.0\n" +
+		"+    - name: amazon.aws\n"
+	if got := UnifiedDiff("a/requirements.yml", "b/requirements.yml", before, after); got != want {
+		t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+
+	want := "--- old\n+++ new\n" +
+		"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+		"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n"
+	if got := UnifiedDiff("old", "new", before, after); got != want {
+		t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffNewFileAndEqual(t *testing.T) {
+	want := "--- /dev/null\n+++ meta/main.yml\n@@ -0,0 +1,2 @@\n+---\n+collections: []\n"
+	if got := UnifiedDiff("/dev/null", "meta/main.yml", "", "---\ncollections: []\n"); got != want {
+		t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := UnifiedDiff("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("equal contents should give no diff, got %q", got)
+	}
+}